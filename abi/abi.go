@@ -0,0 +1,329 @@
+// Package abi extracts the ABIs of the declared classes and decodes the events and the calldata of the contracts
+// with them, so that the APIs and the webhooks of the node can render them with the names and the types of their
+// fields instead of as felts.
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// formatVersion is the version of ABI, the ABIs stored with an older version are extracted again from their class.
+// It must be incremented whenever ABI or how it is extracted changes.
+const formatVersion = 1
+
+// ABI is the part of the ABI of a class the events and the calldata of its contracts are decoded with
+type ABI struct {
+	// Version is the format version the ABI was extracted with
+	Version   uint64
+	Events    []Event
+	Functions []Function
+	Structs   []Struct
+	Enums     []Enum
+}
+
+// Member is a named and typed field of an event, a function, a struct or an enum
+type Member struct {
+	Name string
+	Type string
+}
+
+// Event is an event whose first key is Selector, followed by Keys. Its data is Data.
+type Event struct {
+	Selector *felt.Felt
+	Name     string
+	Keys     []Member
+	Data     []Member
+}
+
+// Function is an entry point of a class, called with Inputs
+type Function struct {
+	Selector *felt.Felt
+	Name     string
+	Inputs   []Member
+}
+
+// Struct is a struct type, serialised as its members in order
+type Struct struct {
+	Name    string
+	Members []Member
+}
+
+// Enum is an enum type of a Cairo 1 class, serialised as the index of its variant followed by the variant
+type Enum struct {
+	Name     string
+	Variants []Member
+}
+
+var (
+	// ErrNoABI is returned for the classes without an ABI
+	ErrNoABI = errors.New("the class has no ABI")
+	// ErrInvalidABI is returned for the classes whose ABI cannot be parsed
+	ErrInvalidABI = errors.New("the ABI of the class is invalid")
+)
+
+// Parse extracts the ABI of class
+func Parse(class core.Class) (*ABI, error) {
+	var (
+		abi *ABI
+		err error
+	)
+	switch c := class.(type) {
+	case *core.Cairo0Class:
+		if len(c.Abi) == 0 {
+			return nil, ErrNoABI
+		}
+		abi, err = parseCairo0(c.Abi)
+	case *core.Cairo1Class:
+		if c.Abi == "" {
+			return nil, ErrNoABI
+		}
+		abi, err = parseCairo1([]byte(c.Abi))
+	default:
+		return nil, fmt.Errorf("%w: unsupported class version %d", ErrInvalidABI, class.Version())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidABI, err)
+	}
+	abi.Version = formatVersion
+	return abi, nil
+}
+
+// entry is an entry of the JSON ABI of a Cairo 0 or Cairo 1 class, with the fields of all its types
+type entry struct {
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Inputs   []member `json:"inputs"`
+	Members  []member `json:"members"`
+	Keys     []member `json:"keys"`
+	Data     []member `json:"data"`
+	Variants []member `json:"variants"`
+	Items    []entry  `json:"items"`
+}
+
+type member struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Kind string `json:"kind"`
+}
+
+func members(ms []member) []Member {
+	converted := make([]Member, 0, len(ms))
+	for _, m := range ms {
+		converted = append(converted, Member{Name: m.Name, Type: m.Type})
+	}
+	return converted
+}
+
+func selectorOf(name string) (*felt.Felt, error) {
+	return crypto.StarknetKeccak([]byte(name))
+}
+
+func parseCairo0(raw json.RawMessage) (*ABI, error) {
+	var entries []entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	abi := new(ABI)
+	for _, e := range entries {
+		switch e.Type {
+		case "function", "l1_handler", "constructor":
+			if err := abi.addFunction(e.Name, e.Inputs); err != nil {
+				return nil, err
+			}
+		case "event":
+			selector, err := selectorOf(e.Name)
+			if err != nil {
+				return nil, err
+			}
+			abi.Events = append(abi.Events, Event{
+				Selector: selector,
+				Name:     e.Name,
+				Keys:     members(e.Keys),
+				Data:     members(e.Data),
+			})
+		case "struct":
+			abi.Structs = append(abi.Structs, Struct{Name: e.Name, Members: members(e.Members)})
+		}
+	}
+	return abi, nil
+}
+
+func parseCairo1(raw []byte) (*ABI, error) {
+	var entries []entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	abi := new(ABI)
+	// the struct events, which are emitted as the variants of an enum event, in the order of the ABI
+	structEvents := make(map[string]entry)
+	var structEventNames []string
+	var enumEvents []entry
+	for _, e := range entries {
+		switch e.Type {
+		case "function", "l1_handler", "constructor":
+			if err := abi.addFunction(e.Name, e.Inputs); err != nil {
+				return nil, err
+			}
+		case "interface":
+			for _, item := range e.Items {
+				if item.Type != "function" {
+					continue
+				}
+				if err := abi.addFunction(item.Name, item.Inputs); err != nil {
+					return nil, err
+				}
+			}
+		case "struct":
+			abi.Structs = append(abi.Structs, Struct{Name: e.Name, Members: members(e.Members)})
+		case "enum":
+			abi.Enums = append(abi.Enums, Enum{Name: e.Name, Variants: members(e.Variants)})
+		case "event":
+			switch e.Kind {
+			case "struct":
+				structEvents[e.Name] = e
+				structEventNames = append(structEventNames, e.Name)
+			case "enum":
+				enumEvents = append(enumEvents, e)
+			default:
+				// the events of the classes compiled before Cairo 2 have their data as inputs and no keys
+				selector, err := selectorOf(e.Name)
+				if err != nil {
+					return nil, err
+				}
+				abi.Events = append(abi.Events, Event{Selector: selector, Name: e.Name, Data: members(e.Inputs)})
+			}
+		}
+	}
+
+	// an event is selected by the name of its variant in the enum of the events of the contract, which is the name
+	// of its struct unless it is renamed
+	for _, enum := range enumEvents {
+		for _, variant := range enum.Variants {
+			structEvent, ok := structEvents[variant.Type]
+			if !ok || variant.Kind != "nested" {
+				continue
+			}
+			if err := abi.addStructEvent(variant.Name, structEvent); err != nil {
+				return nil, err
+			}
+			delete(structEvents, variant.Type)
+		}
+	}
+	for _, name := range structEventNames {
+		structEvent, ok := structEvents[name]
+		if !ok {
+			continue
+		}
+		variant := name
+		if i := strings.LastIndex(name, "::"); i >= 0 {
+			variant = name[i+len("::"):]
+		}
+		if err := abi.addStructEvent(variant, structEvent); err != nil {
+			return nil, err
+		}
+	}
+	return abi, nil
+}
+
+func (a *ABI) addFunction(name string, inputs []member) error {
+	selector, err := selectorOf(name)
+	if err != nil {
+		return err
+	}
+	a.Functions = append(a.Functions, Function{Selector: selector, Name: name, Inputs: members(inputs)})
+	return nil
+}
+
+// addStructEvent adds the event of a Cairo 2 struct event, selected by variant
+func (a *ABI) addStructEvent(variant string, e entry) error {
+	selector, err := selectorOf(variant)
+	if err != nil {
+		return err
+	}
+	event := Event{Selector: selector, Name: e.Name}
+	for _, m := range e.Members {
+		if m.Kind == "key" {
+			event.Keys = append(event.Keys, Member{Name: m.Name, Type: m.Type})
+		} else {
+			event.Data = append(event.Data, Member{Name: m.Name, Type: m.Type})
+		}
+	}
+	a.Events = append(a.Events, event)
+	return nil
+}
+
+// Event returns the event of the ABI whose selector is selector
+func (a *ABI) Event(selector *felt.Felt) (*Event, bool) {
+	for i := range a.Events {
+		if a.Events[i].Selector.Equal(selector) {
+			return &a.Events[i], true
+		}
+	}
+	return nil, false
+}
+
+// Function returns the function of the ABI whose selector is selector
+func (a *ABI) Function(selector *felt.Felt) (*Function, bool) {
+	for i := range a.Functions {
+		if a.Functions[i].Selector.Equal(selector) {
+			return &a.Functions[i], true
+		}
+	}
+	return nil, false
+}
+
+func (a *ABI) structOf(name string) (*Struct, bool) {
+	for i := range a.Structs {
+		if a.Structs[i].Name == name {
+			return &a.Structs[i], true
+		}
+	}
+	return nil, false
+}
+
+func (a *ABI) enumOf(name string) (*Enum, bool) {
+	for i := range a.Enums {
+		if a.Enums[i].Name == name {
+			return &a.Enums[i], true
+		}
+	}
+	return nil, false
+}
+
+// size estimates the memory the ABI takes
+func (a *ABI) size() uint64 {
+	size := uint64(0)
+	addMembers := func(ms []Member) {
+		for _, m := range ms {
+			size += uint64(len(m.Name) + len(m.Type) + 32)
+		}
+	}
+	for _, e := range a.Events {
+		size += uint64(len(e.Name)) + felt.Bytes
+		addMembers(e.Keys)
+		addMembers(e.Data)
+	}
+	for _, f := range a.Functions {
+		size += uint64(len(f.Name)) + felt.Bytes
+		addMembers(f.Inputs)
+	}
+	for _, s := range a.Structs {
+		size += uint64(len(s.Name))
+		addMembers(s.Members)
+	}
+	for _, e := range a.Enums {
+		size += uint64(len(e.Name))
+		addMembers(e.Variants)
+	}
+	return size
+}
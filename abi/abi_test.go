@@ -0,0 +1,112 @@
+package abi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cairo0ABI = `[
+	{"type": "struct", "name": "Point", "size": 2, "members": [
+		{"name": "x", "type": "felt", "offset": 0}, {"name": "y", "type": "felt", "offset": 1}]},
+	{"type": "event", "name": "Moved", "keys": [{"name": "owner", "type": "felt"}],
+		"data": [{"name": "to", "type": "Point"}, {"name": "path_len", "type": "felt"}, {"name": "path", "type": "Point*"}]},
+	{"type": "function", "name": "move", "inputs": [{"name": "to", "type": "Point"}], "outputs": []},
+	{"type": "l1_handler", "name": "deposit", "inputs": [{"name": "from_address", "type": "felt"}], "outputs": []},
+	{"type": "constructor", "name": "constructor", "inputs": [], "outputs": []}
+]`
+
+const cairo1ABI = `[
+	{"type": "interface", "name": "game::IGame", "items": [
+		{"type": "function", "name": "play", "inputs": [{"name": "moves", "type": "core::array::Array::<game::Move>"}],
+			"outputs": [], "state_mutability": "external"}]},
+	{"type": "struct", "name": "core::integer::u256", "members": [
+		{"name": "low", "type": "core::integer::u128"}, {"name": "high", "type": "core::integer::u128"}]},
+	{"type": "enum", "name": "game::Move", "variants": [
+		{"name": "Pass", "type": "()"}, {"name": "Bet", "type": "core::integer::u256"}]},
+	{"type": "event", "name": "game::Game::Played", "kind": "struct", "members": [
+		{"name": "player", "type": "core::starknet::contract_address::ContractAddress", "kind": "key"},
+		{"name": "move", "type": "game::Move", "kind": "data"}]},
+	{"type": "event", "name": "game::Game::Ended", "kind": "struct", "members": [
+		{"name": "winner", "type": "core::starknet::contract_address::ContractAddress", "kind": "data"}]},
+	{"type": "event", "name": "game::Game::Event", "kind": "enum", "variants": [
+		{"name": "Turn", "type": "game::Game::Played", "kind": "nested"}]}
+]`
+
+func selector(t *testing.T, name string) *felt.Felt {
+	t.Helper()
+	s, err := crypto.StarknetKeccak([]byte(name))
+	require.NoError(t, err)
+	return s
+}
+
+func TestParse(t *testing.T) {
+	t.Run("cairo 0", func(t *testing.T) {
+		parsed, err := abi.Parse(&core.Cairo0Class{Abi: json.RawMessage(cairo0ABI)})
+		require.NoError(t, err)
+
+		event, ok := parsed.Event(selector(t, "Moved"))
+		require.True(t, ok)
+		assert.Equal(t, []abi.Member{{Name: "owner", Type: "felt"}}, event.Keys)
+		assert.Len(t, event.Data, 3)
+		for _, name := range []string{"move", "deposit", "constructor"} {
+			function, ok := parsed.Function(selector(t, name))
+			require.True(t, ok, name)
+			assert.Equal(t, name, function.Name)
+		}
+		assert.Equal(t, []abi.Struct{{Name: "Point", Members: []abi.Member{{"x", "felt"}, {"y", "felt"}}}}, parsed.Structs)
+	})
+
+	t.Run("cairo 1", func(t *testing.T) {
+		parsed, err := abi.Parse(&core.Cairo1Class{Abi: cairo1ABI})
+		require.NoError(t, err)
+
+		function, ok := parsed.Function(selector(t, "play"))
+		require.True(t, ok)
+		assert.Equal(t, "play", function.Name)
+
+		// the event is selected by the name of its variant, not of its struct
+		_, ok = parsed.Event(selector(t, "Played"))
+		assert.False(t, ok)
+		event, ok := parsed.Event(selector(t, "Turn"))
+		require.True(t, ok)
+		assert.Equal(t, "game::Game::Played", event.Name)
+		assert.Equal(t, []abi.Member{{"player", "core::starknet::contract_address::ContractAddress"}}, event.Keys)
+		assert.Equal(t, []abi.Member{{"move", "game::Move"}}, event.Data)
+
+		// a struct event that is not a variant is selected by the last segment of its name
+		event, ok = parsed.Event(selector(t, "Ended"))
+		require.True(t, ok)
+		assert.Equal(t, "game::Game::Ended", event.Name)
+		assert.Len(t, parsed.Enums, 1)
+	})
+
+	t.Run("cairo 1 before cairo 2", func(t *testing.T) {
+		parsed, err := abi.Parse(&core.Cairo1Class{
+			Abi: `[{"type": "event", "name": "Ping", "inputs": [{"name": "count", "type": "core::felt252"}]}]`,
+		})
+		require.NoError(t, err)
+		event, ok := parsed.Event(selector(t, "Ping"))
+		require.True(t, ok)
+		assert.Empty(t, event.Keys)
+		assert.Equal(t, []abi.Member{{"count", "core::felt252"}}, event.Data)
+	})
+
+	t.Run("no abi", func(t *testing.T) {
+		_, err := abi.Parse(&core.Cairo0Class{})
+		require.ErrorIs(t, err, abi.ErrNoABI)
+		_, err = abi.Parse(&core.Cairo1Class{})
+		require.ErrorIs(t, err, abi.ErrNoABI)
+	})
+
+	t.Run("invalid abi", func(t *testing.T) {
+		_, err := abi.Parse(&core.Cairo1Class{Abi: `{"type": "function"}`})
+		require.ErrorIs(t, err, abi.ErrInvalidABI)
+	})
+}
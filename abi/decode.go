@@ -0,0 +1,178 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// maxDepth is the maximum nesting of the types of a decoded value, beyond which the type is deemed recursive
+const maxDepth = 16
+
+var (
+	// ErrNotInABI is returned when the selector of an event or a call is not in the ABI it is decoded with
+	ErrNotInABI = errors.New("the selector is not in the ABI")
+	// ErrMalformed is returned when the felts of an event or a call do not match the types of the ABI
+	ErrMalformed = errors.New("the felts do not match the ABI")
+)
+
+// Decoded is an event or a call decoded with an ABI
+type Decoded struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// Field is a decoded field of an event, a call or a struct. Value is a *felt.Felt for the types serialised as a
+// felt, a []any of the elements of an array, a []Field of the members of a struct, the Field of the variant of an
+// enum, or nil for the unit type.
+type Field struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// DecodeEvent decodes event with the ABI of the class of the contract that emitted it
+func (a *ABI) DecodeEvent(event *core.Event) (*Decoded, error) {
+	if len(event.Keys) == 0 {
+		return nil, ErrNotInABI
+	}
+	definition, ok := a.Event(event.Keys[0])
+	if !ok {
+		return nil, ErrNotInABI
+	}
+
+	keys, data := &reader{felts: event.Keys[1:]}, &reader{felts: event.Data}
+	keyFields, err := a.decodeMembers(keys, definition.Keys, 0)
+	if err != nil {
+		return nil, err
+	}
+	dataFields, err := a.decodeMembers(data, definition.Data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys.felts) > 0 || len(data.felts) > 0 {
+		return nil, fmt.Errorf("%w: event %s has more felts than fields", ErrMalformed, definition.Name)
+	}
+	return &Decoded{Name: definition.Name, Fields: append(keyFields, dataFields...)}, nil
+}
+
+// DecodeCall decodes the calldata of a call to the entry point selector with the ABI of the class of the called
+// contract
+func (a *ABI) DecodeCall(selector *felt.Felt, calldata []*felt.Felt) (*Decoded, error) {
+	definition, ok := a.Function(selector)
+	if !ok {
+		return nil, ErrNotInABI
+	}
+
+	r := &reader{felts: calldata}
+	fields, err := a.decodeMembers(r, definition.Inputs, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.felts) > 0 {
+		return nil, fmt.Errorf("%w: call to %s has more felts than inputs", ErrMalformed, definition.Name)
+	}
+	return &Decoded{Name: definition.Name, Fields: fields}, nil
+}
+
+// reader consumes the felts a value is decoded from
+type reader struct {
+	felts []*felt.Felt
+}
+
+func (r *reader) next() (*felt.Felt, error) {
+	if len(r.felts) == 0 {
+		return nil, fmt.Errorf("%w: not enough felts", ErrMalformed)
+	}
+	f := r.felts[0]
+	r.felts = r.felts[1:]
+	return f, nil
+}
+
+func (a *ABI) decodeMembers(r *reader, members []Member, depth int) ([]Field, error) {
+	fields := make([]Field, 0, len(members))
+	for i, m := range members {
+		var (
+			value any
+			err   error
+		)
+		if element, ok := strings.CutSuffix(m.Type, "*"); ok {
+			// the pointers of Cairo 0 are arrays whose length is the member before them
+			var length *felt.Felt
+			if i > 0 && fields[i-1].Name == m.Name+"_len" {
+				length, _ = fields[i-1].Value.(*felt.Felt)
+			}
+			if length == nil {
+				return nil, fmt.Errorf("%w: the length of %s is not the member before it", ErrMalformed, m.Name)
+			}
+			value, err = a.decodeArray(r, element, length, depth)
+		} else {
+			value, err = a.decodeValue(r, m.Type, depth)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Name: m.Name, Type: m.Type, Value: value})
+	}
+	return fields, nil
+}
+
+func (a *ABI) decodeValue(r *reader, typ string, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: %s is nested too deeply", ErrMalformed, typ)
+	}
+	if typ == "()" {
+		return nil, nil
+	}
+	for _, array := range []string{"core::array::Array::<", "core::array::Span::<"} {
+		if element, ok := strings.CutPrefix(typ, array); ok && strings.HasSuffix(element, ">") {
+			length, err := r.next()
+			if err != nil {
+				return nil, err
+			}
+			return a.decodeArray(r, strings.TrimSuffix(element, ">"), length, depth)
+		}
+	}
+	if s, ok := a.structOf(typ); ok {
+		return a.decodeMembers(r, s.Members, depth+1)
+	}
+	if e, ok := a.enumOf(typ); ok {
+		index, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if index.Cmp(new(felt.Felt).SetUint64(uint64(len(e.Variants)))) >= 0 {
+			return nil, fmt.Errorf("%w: %s has no variant %s", ErrMalformed, typ, index)
+		}
+		variant := e.Variants[index.Uint64()]
+		value, err := a.decodeValue(r, variant.Type, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return Field{Name: variant.Name, Type: variant.Type, Value: value}, nil
+	}
+	if strings.HasPrefix(typ, "(") {
+		return nil, fmt.Errorf("%w: the tuple %s is not supported", ErrMalformed, typ)
+	}
+	// the other types, such as felts, integers and addresses, are serialised as a felt
+	return r.next()
+}
+
+func (a *ABI) decodeArray(r *reader, element string, length *felt.Felt, depth int) ([]any, error) {
+	// every element takes a felt at least, which bounds the length of a malformed array
+	if length.Cmp(new(felt.Felt).SetUint64(uint64(len(r.felts)))) > 0 {
+		return nil, fmt.Errorf("%w: the length %s of an array of %s exceeds the felts", ErrMalformed, length, element)
+	}
+	elements := make([]any, 0, length.Uint64())
+	for i := uint64(0); i < length.Uint64(); i++ {
+		value, err := a.decodeValue(r, element, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return elements, nil
+}
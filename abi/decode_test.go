@@ -0,0 +1,113 @@
+package abi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func felts(values ...uint64) []*felt.Felt {
+	fs := make([]*felt.Felt, 0, len(values))
+	for _, v := range values {
+		fs = append(fs, new(felt.Felt).SetUint64(v))
+	}
+	return fs
+}
+
+func TestDecodeEvent(t *testing.T) {
+	t.Run("cairo 0", func(t *testing.T) {
+		parsed, err := abi.Parse(&core.Cairo0Class{Abi: json.RawMessage(cairo0ABI)})
+		require.NoError(t, err)
+
+		event := &core.Event{
+			Keys: append([]*felt.Felt{selector(t, "Moved")}, felts(7)...),
+			// to, then a path of two points
+			Data: felts(1, 2, 2, 3, 4, 5, 6),
+		}
+		decoded, err := parsed.DecodeEvent(event)
+		require.NoError(t, err)
+		point := func(x, y uint64) []abi.Field {
+			return []abi.Field{
+				{Name: "x", Type: "felt", Value: new(felt.Felt).SetUint64(x)},
+				{Name: "y", Type: "felt", Value: new(felt.Felt).SetUint64(y)},
+			}
+		}
+		assert.Equal(t, &abi.Decoded{Name: "Moved", Fields: []abi.Field{
+			{Name: "owner", Type: "felt", Value: new(felt.Felt).SetUint64(7)},
+			{Name: "to", Type: "Point", Value: point(1, 2)},
+			{Name: "path_len", Type: "felt", Value: new(felt.Felt).SetUint64(2)},
+			{Name: "path", Type: "Point*", Value: []any{point(3, 4), point(5, 6)}},
+		}}, decoded)
+
+		// the path is shorter than its length
+		event.Data = felts(1, 2, 2, 3, 4)
+		_, err = parsed.DecodeEvent(event)
+		require.ErrorIs(t, err, abi.ErrMalformed)
+		// a felt is left after the path
+		event.Data = felts(1, 2, 0, 3)
+		_, err = parsed.DecodeEvent(event)
+		require.ErrorIs(t, err, abi.ErrMalformed)
+	})
+
+	t.Run("cairo 1", func(t *testing.T) {
+		parsed, err := abi.Parse(&core.Cairo1Class{Abi: cairo1ABI})
+		require.NoError(t, err)
+
+		decoded, err := parsed.DecodeEvent(&core.Event{
+			Keys: append([]*felt.Felt{selector(t, "Turn")}, felts(9)...),
+			Data: felts(1, 10, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &abi.Decoded{Name: "game::Game::Played", Fields: []abi.Field{
+			{Name: "player", Type: "core::starknet::contract_address::ContractAddress", Value: new(felt.Felt).SetUint64(9)},
+			{Name: "move", Type: "game::Move", Value: abi.Field{Name: "Bet", Type: "core::integer::u256", Value: []abi.Field{
+				{Name: "low", Type: "core::integer::u128", Value: new(felt.Felt).SetUint64(10)},
+				{Name: "high", Type: "core::integer::u128", Value: new(felt.Felt).SetUint64(0)},
+			}}},
+		}}, decoded)
+
+		// the enum has no third variant
+		_, err = parsed.DecodeEvent(&core.Event{
+			Keys: append([]*felt.Felt{selector(t, "Turn")}, felts(9)...),
+			Data: felts(2),
+		})
+		require.ErrorIs(t, err, abi.ErrMalformed)
+	})
+
+	t.Run("not in abi", func(t *testing.T) {
+		parsed, err := abi.Parse(&core.Cairo1Class{Abi: cairo1ABI})
+		require.NoError(t, err)
+		_, err = parsed.DecodeEvent(&core.Event{Keys: []*felt.Felt{selector(t, "Played")}})
+		require.ErrorIs(t, err, abi.ErrNotInABI)
+		_, err = parsed.DecodeEvent(&core.Event{})
+		require.ErrorIs(t, err, abi.ErrNotInABI)
+	})
+}
+
+func TestDecodeCall(t *testing.T) {
+	parsed, err := abi.Parse(&core.Cairo1Class{Abi: cairo1ABI})
+	require.NoError(t, err)
+
+	decoded, err := parsed.DecodeCall(selector(t, "play"), felts(2, 0, 1, 5, 0))
+	require.NoError(t, err)
+	assert.Equal(t, &abi.Decoded{Name: "play", Fields: []abi.Field{
+		{Name: "moves", Type: "core::array::Array::<game::Move>", Value: []any{
+			abi.Field{Name: "Pass", Type: "()"},
+			abi.Field{Name: "Bet", Type: "core::integer::u256", Value: []abi.Field{
+				{Name: "low", Type: "core::integer::u128", Value: new(felt.Felt).SetUint64(5)},
+				{Name: "high", Type: "core::integer::u128", Value: new(felt.Felt).SetUint64(0)},
+			}},
+		}},
+	}}, decoded)
+
+	// the length of the array exceeds the calldata
+	_, err = parsed.DecodeCall(selector(t, "play"), felts(1000))
+	require.ErrorIs(t, err, abi.ErrMalformed)
+	_, err = parsed.DecodeCall(selector(t, "fold"), nil)
+	require.ErrorIs(t, err, abi.ErrNotInABI)
+}
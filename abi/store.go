@@ -0,0 +1,120 @@
+package abi
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/memory"
+)
+
+// cacheSize is the memory in bytes the parsed ABIs cached by a Decoder may take
+const cacheSize = 32 << 20
+
+// StoreDeclared stores the ABIs of the classes declared in a block, keyed by their class hash. The classes whose ABI
+// cannot be parsed are skipped, so that a class the decoder does not understand does not fail the block.
+func StoreDeclared(txn db.Transaction, classes map[felt.Felt]core.Class) error {
+	for classHash, class := range classes {
+		abi, err := Parse(class)
+		if err != nil {
+			continue
+		}
+		abiBytes, err := encoder.Marshal(abi)
+		if err != nil {
+			return err
+		}
+		if err = txn.Set(db.ABIs.Key(classHash.Marshal()), abiBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the ABI of the class classHash. The ABIs that were not stored, because the class was declared before
+// they were, or that were stored with an older format version, are extracted from the class.
+func Get(txn db.Transaction, classHash *felt.Felt) (*ABI, error) {
+	abi := new(ABI)
+	err := txn.Get(db.ABIs.Key(classHash.Marshal()), func(val []byte) error {
+		return encoder.Unmarshal(val, abi)
+	})
+	if err == nil && abi.Version == formatVersion {
+		return abi, nil
+	} else if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	declared, err := core.NewState(txn).Class(classHash)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(declared.Class)
+}
+
+// Decoder decodes the events and the calldata of the contracts of a database with the ABIs of their class, which
+// it caches
+type Decoder struct {
+	database db.DB
+	cache    *memory.Cache[felt.Felt, *ABI]
+}
+
+// NewDecoder returns a Decoder of the contracts of database
+func NewDecoder(database db.DB) *Decoder {
+	return &Decoder{
+		database: database,
+		cache:    memory.NewCache[felt.Felt, *ABI](cacheSize, (*ABI).size),
+	}
+}
+
+// DecodeEvent decodes event, emitted in the block blockNumber, with the ABI of the class of the contract that emitted
+// it at that block. It returns nil if the event cannot be decoded with the ABI.
+func (d *Decoder) DecodeEvent(event *core.Event, blockNumber uint64) (*Decoded, error) {
+	abi, err := d.abiAt(event.From, blockNumber)
+	if err != nil {
+		return undecodable(nil, err)
+	}
+	return undecodable(abi.DecodeEvent(event))
+}
+
+// DecodeCall decodes the calldata of a call to the entry point selector of contract, in the block blockNumber, with
+// the ABI of the class of contract at that block. It returns nil if the call cannot be decoded with the ABI.
+func (d *Decoder) DecodeCall(contract, selector *felt.Felt, calldata []*felt.Felt, blockNumber uint64) (*Decoded,
+	error,
+) {
+	abi, err := d.abiAt(contract, blockNumber)
+	if err != nil {
+		return undecodable(nil, err)
+	}
+	return undecodable(abi.DecodeCall(selector, calldata))
+}
+
+// undecodable drops the errors of the events and the calls that cannot be decoded, because their contract or its
+// class is unknown, its class has no valid ABI or the ABI does not match them
+func undecodable(decoded *Decoded, err error) (*Decoded, error) {
+	if errors.Is(err, ErrNotInABI) || errors.Is(err, ErrMalformed) || errors.Is(err, ErrNoABI) ||
+		errors.Is(err, ErrInvalidABI) || errors.Is(err, core.ErrContractNotDeployed) || errors.Is(err, db.ErrKeyNotFound) {
+		return nil, nil
+	}
+	return decoded, err
+}
+
+// abiAt returns the ABI of the class of contract at the block blockNumber
+func (d *Decoder) abiAt(contract *felt.Felt, blockNumber uint64) (*ABI, error) {
+	var abi *ABI
+	return abi, d.database.View(func(txn db.Transaction) error {
+		classHash, err := core.NewStateSnapshot(core.NewState(txn), blockNumber).ContractClassHash(contract)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if abi, ok = d.cache.Get(*classHash); ok {
+			return nil
+		}
+		if abi, err = Get(txn, classHash); err != nil {
+			return err
+		}
+		d.cache.Add(*classHash, abi)
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package abi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.MAINNET, utils.NewNopZapLogger()).WithABIs()
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+
+	block, err := gw.BlockByNumber(context.Background(), 0)
+	require.NoError(t, err)
+	stateUpdate, err := gw.StateUpdate(context.Background(), 0)
+	require.NoError(t, err)
+	classHash := utils.HexToFelt(t, "0x10455c752b86932ce552f2b0fe81a880746649b9aee7e0d842bf3f52378f9f8")
+	class, err := gw.Class(context.Background(), classHash)
+	require.NoError(t, err)
+	require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate,
+		map[felt.Felt]core.Class{*classHash: class}))
+
+	t.Run("stored abi", func(t *testing.T) {
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			return txn.Get(db.ABIs.Key(classHash.Marshal()), func([]byte) error { return nil })
+		}))
+		stored, err := abi.Parse(class)
+		require.NoError(t, err)
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			got, getErr := abi.Get(txn, classHash)
+			require.NoError(t, getErr)
+			assert.Equal(t, stored, got)
+			return nil
+		}))
+	})
+
+	decoder := abi.NewDecoder(testDB)
+	t.Run("call", func(t *testing.T) {
+		invoke, ok := block.Transactions[3].(*core.InvokeTransaction)
+		require.True(t, ok)
+		decoded, err := decoder.DecodeCall(invoke.ContractAddress, invoke.EntryPointSelector, invoke.CallData, 0)
+		require.NoError(t, err)
+		require.NotNil(t, decoded)
+		require.Len(t, decoded.Fields, len(invoke.CallData))
+		for i, field := range decoded.Fields {
+			assert.Equal(t, "felt", field.Type)
+			assert.Equal(t, invoke.CallData[i], field.Value)
+		}
+	})
+
+	t.Run("undecodable", func(t *testing.T) {
+		invoke, ok := block.Transactions[3].(*core.InvokeTransaction)
+		require.True(t, ok)
+		// the selector is not in the abi
+		decoded, err := decoder.DecodeCall(invoke.ContractAddress, invoke.ContractAddress, invoke.CallData, 0)
+		require.NoError(t, err)
+		assert.Nil(t, decoded)
+		// the contract is not deployed
+		decoded, err = decoder.DecodeCall(new(felt.Felt).SetUint64(1), invoke.EntryPointSelector, invoke.CallData, 0)
+		require.NoError(t, err)
+		assert.Nil(t, decoded)
+	})
+}
@@ -0,0 +1,333 @@
+package core2feeder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+)
+
+func AdaptBlock(block *core.Block, status string) (*feeder.Block, error) {
+	if block == nil {
+		return nil, errors.New("nil core block")
+	}
+
+	txns := make([]*feeder.Transaction, len(block.Transactions))
+	for i, txn := range block.Transactions {
+		var err error
+		txns[i], err = AdaptTransaction(txn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	receipts := make([]*feeder.TransactionReceipt, len(block.Receipts))
+	for i, receipt := range block.Receipts {
+		receipts[i] = AdaptTransactionReceipt(receipt)
+		receipts[i].TransactionIndex = uint64(i)
+	}
+
+	return &feeder.Block{
+		Hash:             block.Hash,
+		ParentHash:       block.ParentHash,
+		Number:           block.Number,
+		StateRoot:        block.GlobalStateRoot,
+		Status:           status,
+		GasPrice:         block.GasPrice,
+		Transactions:     txns,
+		Timestamp:        block.Timestamp,
+		Version:          block.ProtocolVersion,
+		Receipts:         receipts,
+		SequencerAddress: block.SequencerAddress,
+	}, nil
+}
+
+func AdaptTransactionReceipt(receipt *core.TransactionReceipt) *feeder.TransactionReceipt {
+	if receipt == nil {
+		return nil
+	}
+
+	events := make([]*feeder.Event, len(receipt.Events))
+	for i, event := range receipt.Events {
+		events[i] = AdaptEvent(event)
+	}
+
+	l2ToL1Messages := make([]*feeder.L2ToL1Message, len(receipt.L2ToL1Message))
+	for i, msg := range receipt.L2ToL1Message {
+		l2ToL1Messages[i] = AdaptL2ToL1Message(msg)
+	}
+
+	executionStatus := feeder.Succeeded
+	if receipt.Reverted {
+		executionStatus = feeder.Reverted
+	}
+
+	return &feeder.TransactionReceipt{
+		ActualFee:          receipt.Fee,
+		Events:             events,
+		ExecutionStatus:    executionStatus,
+		ExecutionResources: AdaptExecutionResources(receipt.ExecutionResources),
+		L1ToL2Message:      AdaptL1ToL2Message(receipt.L1ToL2Message),
+		L2ToL1Message:      l2ToL1Messages,
+		TransactionHash:    receipt.TransactionHash,
+		RevertError:        receipt.RevertReason,
+	}
+}
+
+func AdaptEvent(event *core.Event) *feeder.Event {
+	if event == nil {
+		return nil
+	}
+
+	return &feeder.Event{
+		From: event.From,
+		Data: event.Data,
+		Keys: event.Keys,
+	}
+}
+
+func AdaptExecutionResources(resources *core.ExecutionResources) *feeder.ExecutionResources {
+	if resources == nil {
+		return nil
+	}
+
+	return &feeder.ExecutionResources{
+		Steps: resources.Steps,
+		BuiltinInstanceCounter: feeder.BuiltinInstanceCounter{
+			Pedersen:   resources.BuiltinInstanceCounter.Pedersen,
+			RangeCheck: resources.BuiltinInstanceCounter.RangeCheck,
+			Bitwise:    resources.BuiltinInstanceCounter.Bitwise,
+			Output:     resources.BuiltinInstanceCounter.Output,
+			Ecsda:      resources.BuiltinInstanceCounter.Ecsda,
+			EcOp:       resources.BuiltinInstanceCounter.EcOp,
+		},
+		MemoryHoles: resources.MemoryHoles,
+	}
+}
+
+func AdaptL1ToL2Message(msg *core.L1ToL2Message) *feeder.L1ToL2Message {
+	if msg == nil {
+		return nil
+	}
+
+	return &feeder.L1ToL2Message{
+		From:     msg.From.String(),
+		Payload:  msg.Payload,
+		Selector: msg.Selector,
+		To:       msg.To,
+		Nonce:    msg.Nonce,
+	}
+}
+
+func AdaptL2ToL1Message(msg *core.L2ToL1Message) *feeder.L2ToL1Message {
+	if msg == nil {
+		return nil
+	}
+
+	return &feeder.L2ToL1Message{
+		From:    msg.From,
+		Payload: msg.Payload,
+		To:      msg.To.String(),
+	}
+}
+
+func AdaptTransaction(transaction core.Transaction) (*feeder.Transaction, error) {
+	switch t := transaction.(type) {
+	case *core.DeclareTransaction:
+		return AdaptDeclareTransaction(t), nil
+	case *core.DeployTransaction:
+		return AdaptDeployTransaction(t), nil
+	case *core.InvokeTransaction:
+		return AdaptInvokeTransaction(t), nil
+	case *core.DeployAccountTransaction:
+		return AdaptDeployAccountTransaction(t), nil
+	case *core.L1HandlerTransaction:
+		return AdaptL1HandlerTransaction(t), nil
+	default:
+		return nil, fmt.Errorf("unknown transaction type %T", transaction)
+	}
+}
+
+func AdaptDeclareTransaction(t *core.DeclareTransaction) *feeder.Transaction {
+	return &feeder.Transaction{
+		Hash:              t.TransactionHash,
+		Type:              feeder.TxnDeclare,
+		SenderAddress:     t.SenderAddress,
+		MaxFee:            t.MaxFee,
+		Signature:         &t.TransactionSignature,
+		Nonce:             t.Nonce,
+		Version:           t.Version,
+		ClassHash:         t.ClassHash,
+		CompiledClassHash: t.CompiledClassHash,
+	}
+}
+
+func AdaptDeployTransaction(t *core.DeployTransaction) *feeder.Transaction {
+	return &feeder.Transaction{
+		Hash:                t.TransactionHash,
+		Type:                feeder.TxnDeploy,
+		ContractAddressSalt: t.ContractAddressSalt,
+		ContractAddress:     t.ContractAddress,
+		ClassHash:           t.ClassHash,
+		ConstructorCallData: &t.ConstructorCallData,
+		Version:             t.Version,
+	}
+}
+
+func AdaptInvokeTransaction(t *core.InvokeTransaction) *feeder.Transaction {
+	return &feeder.Transaction{
+		Hash:               t.TransactionHash,
+		Type:               feeder.TxnInvoke,
+		ContractAddress:    t.ContractAddress,
+		EntryPointSelector: t.EntryPointSelector,
+		Nonce:              t.Nonce,
+		CallData:           &t.CallData,
+		Signature:          &t.TransactionSignature,
+		MaxFee:             t.MaxFee,
+		Version:            t.Version,
+		SenderAddress:      t.SenderAddress,
+	}
+}
+
+func AdaptL1HandlerTransaction(t *core.L1HandlerTransaction) *feeder.Transaction {
+	return &feeder.Transaction{
+		Hash:               t.TransactionHash,
+		Type:               feeder.TxnL1Handler,
+		ContractAddress:    t.ContractAddress,
+		EntryPointSelector: t.EntryPointSelector,
+		Nonce:              t.Nonce,
+		CallData:           &t.CallData,
+		Version:            t.Version,
+	}
+}
+
+func AdaptDeployAccountTransaction(t *core.DeployAccountTransaction) *feeder.Transaction {
+	txn := AdaptDeployTransaction(&t.DeployTransaction)
+	txn.Type = feeder.TxnDeployAccount
+	txn.MaxFee = t.MaxFee
+	txn.Signature = &t.TransactionSignature
+	txn.Nonce = t.Nonce
+	return txn
+}
+
+func AdaptStateUpdate(update *core.StateUpdate) *feeder.StateUpdate {
+	res := &feeder.StateUpdate{
+		BlockHash: update.BlockHash,
+		NewRoot:   update.NewRoot,
+		OldRoot:   update.OldRoot,
+	}
+
+	stateDiff := &res.StateDiff
+	stateDiff.OldDeclaredContracts = update.StateDiff.DeclaredV0Classes
+	if stateDiff.OldDeclaredContracts == nil {
+		stateDiff.OldDeclaredContracts = []*felt.Felt{}
+	}
+
+	stateDiff.DeclaredClasses = make([]feeder.DeclaredClass, len(update.StateDiff.DeclaredV1Classes))
+	for index, declaredV1Class := range update.StateDiff.DeclaredV1Classes {
+		stateDiff.DeclaredClasses[index] = feeder.DeclaredClass{
+			ClassHash:         declaredV1Class.ClassHash,
+			CompiledClassHash: declaredV1Class.CompiledClassHash,
+		}
+	}
+
+	stateDiff.ReplacedClasses = make([]feeder.ReplacedClass, len(update.StateDiff.ReplacedClasses))
+	for index, replacedClass := range update.StateDiff.ReplacedClasses {
+		stateDiff.ReplacedClasses[index] = feeder.ReplacedClass{
+			Address:   replacedClass.Address,
+			ClassHash: replacedClass.ClassHash,
+		}
+	}
+
+	stateDiff.DeployedContracts = make([]feeder.DeployedContract, len(update.StateDiff.DeployedContracts))
+	for index, deployedContract := range update.StateDiff.DeployedContracts {
+		stateDiff.DeployedContracts[index] = feeder.DeployedContract{
+			Address:   deployedContract.Address,
+			ClassHash: deployedContract.ClassHash,
+		}
+	}
+
+	stateDiff.Nonces = make(map[string]*felt.Felt, len(update.StateDiff.Nonces))
+	for addr, nonce := range update.StateDiff.Nonces {
+		stateDiff.Nonces[addr.String()] = nonce
+	}
+
+	stateDiff.StorageDiffs = make(map[string][]feeder.StorageEntry, len(update.StateDiff.StorageDiffs))
+	for addr, diffs := range update.StateDiff.StorageDiffs {
+		entries := make([]feeder.StorageEntry, len(diffs))
+		for index, diff := range diffs {
+			entries[index] = feeder.StorageEntry{
+				Key:   diff.Key,
+				Value: diff.Value,
+			}
+		}
+		stateDiff.StorageDiffs[addr.String()] = entries
+	}
+
+	return res
+}
+
+func AdaptClass(class core.Class) (*feeder.ClassDefinition, error) {
+	switch c := class.(type) {
+	case *core.Cairo0Class:
+		definition, err := AdaptCairo0Class(c)
+		if err != nil {
+			return nil, err
+		}
+		return &feeder.ClassDefinition{V0: definition}, nil
+	case *core.Cairo1Class:
+		return &feeder.ClassDefinition{V1: AdaptCairo1Class(c)}, nil
+	default:
+		return nil, fmt.Errorf("unknown class type %T", class)
+	}
+}
+
+func AdaptCairo1Class(class *core.Cairo1Class) *feeder.SierraDefinition {
+	adaptEntryPoints := func(entryPoints []core.SierraEntryPoint) []feeder.SierraEntryPoint {
+		adapted := make([]feeder.SierraEntryPoint, len(entryPoints))
+		for index, entryPoint := range entryPoints {
+			adapted[index] = feeder.SierraEntryPoint{Index: entryPoint.Index, Selector: entryPoint.Selector}
+		}
+		return adapted
+	}
+
+	return &feeder.SierraDefinition{
+		Abi: class.Abi,
+		EntryPoints: feeder.SierraEntryPoints{
+			Constructor: adaptEntryPoints(class.EntryPoints.Constructor),
+			External:    adaptEntryPoints(class.EntryPoints.External),
+			L1Handler:   adaptEntryPoints(class.EntryPoints.L1Handler),
+		},
+		Program: class.Program,
+		Version: class.SemanticVersion,
+	}
+}
+
+func AdaptCairo0Class(class *core.Cairo0Class) (*feeder.Cairo0Definition, error) {
+	adaptEntryPoints := func(entryPoints []core.EntryPoint) []feeder.EntryPoint {
+		adapted := make([]feeder.EntryPoint, len(entryPoints))
+		for index, entryPoint := range entryPoints {
+			adapted[index] = feeder.EntryPoint{Selector: entryPoint.Selector, Offset: entryPoint.Offset}
+		}
+		return adapted
+	}
+
+	program, err := utils.Gzip64Decode(class.Program)
+	if err != nil {
+		return nil, err
+	}
+
+	return &feeder.Cairo0Definition{
+		Abi: class.Abi,
+		EntryPoints: feeder.EntryPoints{
+			Constructor: adaptEntryPoints(class.Constructors),
+			External:    adaptEntryPoints(class.Externals),
+			L1Handler:   adaptEntryPoints(class.L1Handlers),
+		},
+		Program: json.RawMessage(program),
+	}, nil
+}
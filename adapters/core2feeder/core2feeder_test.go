@@ -0,0 +1,139 @@
+package core2feeder_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/NethermindEth/juno/adapters/core2feeder"
+	"github.com/NethermindEth/juno/adapters/feeder2core"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptBlock(t *testing.T) {
+	tests := []struct {
+		number  uint64
+		network utils.Network
+	}{
+		{number: 147, network: utils.MAINNET},
+		{number: 11817, network: utils.MAINNET},
+		{number: 304740, network: utils.INTEGRATION},
+		{number: 485004, network: utils.GOERLI},
+	}
+
+	ctx := context.Background()
+
+	for _, test := range tests {
+		t.Run(test.network.String()+" block number "+strconv.FormatUint(test.number, 10), func(t *testing.T) {
+			client := feeder.NewTestClient(t, test.network)
+
+			response, err := client.Block(ctx, strconv.FormatUint(test.number, 10))
+			require.NoError(t, err)
+			block, err := feeder2core.AdaptBlock(response)
+			require.NoError(t, err)
+
+			adapted, err := core2feeder.AdaptBlock(block, response.Status)
+			require.NoError(t, err)
+			assert.Equal(t, response.Status, adapted.Status)
+
+			roundTripped, err := feeder2core.AdaptBlock(adapted)
+			require.NoError(t, err)
+			assert.Equal(t, block, roundTripped)
+		})
+	}
+
+	t.Run("nil block", func(t *testing.T) {
+		_, err := core2feeder.AdaptBlock(nil, "")
+		require.Error(t, err)
+	})
+}
+
+func TestAdaptStateUpdate(t *testing.T) {
+	tests := []struct {
+		number  uint64
+		network utils.Network
+	}{
+		{number: 0, network: utils.MAINNET},
+		{number: 21656, network: utils.MAINNET},
+		{number: 283364, network: utils.INTEGRATION},
+	}
+
+	ctx := context.Background()
+
+	for _, test := range tests {
+		t.Run(test.network.String()+" number "+strconv.FormatUint(test.number, 10), func(t *testing.T) {
+			client := feeder.NewTestClient(t, test.network)
+
+			response, err := client.StateUpdate(ctx, strconv.FormatUint(test.number, 10))
+			require.NoError(t, err)
+			update, err := feeder2core.AdaptStateUpdate(response)
+			require.NoError(t, err)
+
+			roundTripped, err := feeder2core.AdaptStateUpdate(core2feeder.AdaptStateUpdate(update))
+			require.NoError(t, err)
+			assert.Equal(t, update, roundTripped)
+		})
+	}
+}
+
+func TestAdaptClass(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cairo0", func(t *testing.T) {
+		client := feeder.NewTestClient(t, utils.MAINNET)
+		classHash := utils.HexToFelt(t, "0x10455c752b86932ce552f2b0fe81a880746649b9aee7e0d842bf3f52378f9f8")
+
+		response, err := client.ClassDefinition(ctx, classHash)
+		require.NoError(t, err)
+		class, err := feeder2core.AdaptCairo0Class(response.V0)
+		require.NoError(t, err)
+
+		adapted, err := core2feeder.AdaptClass(class)
+		require.NoError(t, err)
+		require.NotNil(t, adapted.V0)
+		assert.Nil(t, adapted.V1)
+
+		roundTripped, err := feeder2core.AdaptCairo0Class(adapted.V0)
+		require.NoError(t, err)
+		assert.Equal(t, class, roundTripped)
+	})
+
+	t.Run("cairo1", func(t *testing.T) {
+		client := feeder.NewTestClient(t, utils.INTEGRATION)
+		classHash := utils.HexToFelt(t, "0x1cd2edfb485241c4403254d550de0a097fa76743cd30696f714a491a454bad5")
+
+		response, err := client.ClassDefinition(ctx, classHash)
+		require.NoError(t, err)
+		compiled, err := client.CompiledClassDefinition(ctx, classHash)
+		require.NoError(t, err)
+		class, err := feeder2core.AdaptCairo1Class(response.V1, compiled)
+		require.NoError(t, err)
+
+		adapted, err := core2feeder.AdaptClass(class)
+		require.NoError(t, err)
+		require.NotNil(t, adapted.V1)
+		assert.Nil(t, adapted.V0)
+
+		roundTripped, err := feeder2core.AdaptCairo1Class(adapted.V1, compiled)
+		require.NoError(t, err)
+		assert.Equal(t, class, roundTripped)
+	})
+
+	t.Run("unknown class", func(t *testing.T) {
+		_, err := core2feeder.AdaptClass(nil)
+		require.Error(t, err)
+	})
+}
+
+func TestAdaptTransactionReceipt(t *testing.T) {
+	assert.Nil(t, core2feeder.AdaptTransactionReceipt(nil))
+
+	receipt := &core.TransactionReceipt{Reverted: true, RevertReason: "reason"}
+	adapted := core2feeder.AdaptTransactionReceipt(receipt)
+	assert.Equal(t, feeder.Reverted, adapted.ExecutionStatus)
+	assert.Equal(t, "reason", adapted.RevertError)
+}
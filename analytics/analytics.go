@@ -0,0 +1,436 @@
+// Package analytics aggregates the activity of the chain by UTC day: the blocks, the transactions, the events, the
+// fees paid and the contracts whose state changed. The aggregates of the last days are kept in the database and
+// kept up to date with the stored blocks in the background, so that basic network analytics are served without
+// exporting the database.
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+)
+
+var _ service.Service = (*Aggregator)(nil)
+
+const (
+	// pollInterval is how frequently the head is checked for new blocks once the aggregates caught up with it
+	pollInterval = time.Second
+	// undoDepth is the number of recent blocks whose contributions are kept to remove them from the aggregates if
+	// they are reverted, the aggregates are rebuilt if a reorg reverts more blocks than that
+	undoDepth = 128
+	// progressName is the name the progress of the aggregates is recorded with, alongside the optional indexes
+	progressName  = "analytics"
+	secondsPerDay = 24 * 60 * 60
+)
+
+// The kinds of keys of the Analytics bucket
+const (
+	dayKey    byte = iota // day -> Day
+	activeKey             // day, address -> number of blocks of the day the state of the contract changed in
+	undoKey               // block number -> contribution
+)
+
+// Day is the activity of a UTC day
+type Day struct {
+	// Day is the number of days since the Unix epoch
+	Day             uint64
+	Blocks          uint64
+	Transactions    uint64
+	Events          uint64
+	Fees            *felt.Felt
+	ActiveContracts uint64
+}
+
+// Date returns the date of the day
+func (d *Day) Date() time.Time {
+	return time.Unix(int64(d.Day*secondsPerDay), 0).UTC()
+}
+
+// contribution is what a block adds to the aggregates of its day, kept for the recent blocks to remove it if the
+// block is reverted. The blocks that are older than the retention contribute nothing.
+type contribution struct {
+	Hash         *felt.Felt
+	Day          uint64
+	Aggregated   bool
+	Transactions uint64
+	Events       uint64
+	Fees         *felt.Felt
+	Contracts    []*felt.Felt
+}
+
+// Aggregator keeps the daily aggregates of the activity of the chain up to date with its blocks
+type Aggregator struct {
+	database db.DB
+	// retention is the number of days whose aggregates are kept
+	retention uint64
+	log       utils.SimpleLogger
+}
+
+// New returns an Aggregator that keeps the aggregates of the last retention days
+func New(database db.DB, retention uint64, log utils.SimpleLogger) *Aggregator {
+	return &Aggregator{
+		database:  database,
+		retention: retention,
+		log:       log,
+	}
+}
+
+// Run aggregates the stored blocks, and then the new blocks as they are stored, until ctx is cancelled
+func (a *Aggregator) Run(ctx context.Context) error {
+	for {
+		stepped, err := a.step()
+		if err != nil {
+			return fmt.Errorf("aggregate analytics: %w", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if stepped {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// step removes the last aggregated block from the aggregates if it was reverted, or aggregates the next block.
+// It returns false if the aggregates are up to date with the head.
+func (a *Aggregator) step() (bool, error) {
+	stepped := false
+	err := a.database.Update(func(txn db.Transaction) error {
+		aggregated, lastHash, err := blockchain.IndexProgress(txn, progressName)
+		if err != nil {
+			return err
+		}
+
+		if aggregated > 0 {
+			last, headerErr := blockchain.BlockHeaderByNumber(txn, aggregated-1)
+			if headerErr != nil && !errors.Is(headerErr, db.ErrKeyNotFound) {
+				return headerErr
+			}
+			if headerErr != nil || !last.Hash.Equal(lastHash) {
+				stepped = true
+				return a.revert(txn, aggregated-1)
+			}
+		}
+
+		block, err := blockchain.BlockByNumber(txn, aggregated)
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		stepped = true
+		return a.aggregate(txn, block)
+	})
+	return stepped, err
+}
+
+func (a *Aggregator) aggregate(txn db.Transaction, block *core.Block) error {
+	c := &contribution{
+		Hash: block.Hash,
+		Day:  block.Timestamp / secondsPerDay,
+		Fees: new(felt.Felt),
+	}
+	today := uint64(time.Now().Unix()) / secondsPerDay
+	if c.Day+a.retention > today {
+		if err := a.contribute(txn, block, c); err != nil {
+			return err
+		}
+	}
+
+	if block.Number > 0 {
+		parent, err := contributionOf(txn, block.Number-1)
+		if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+			return err
+		}
+		if (err != nil || parent.Day < c.Day) && c.Day > a.retention {
+			// a new day started, the days that are too old are dropped
+			if err = deleteRange(txn, db.Analytics.Key([]byte{dayKey}), dayBytes(c.Day-a.retention)); err != nil {
+				return err
+			}
+			if err = deleteRange(txn, db.Analytics.Key([]byte{activeKey}), dayBytes(c.Day-a.retention)); err != nil {
+				return err
+			}
+		}
+	}
+
+	contributionBytes, err := encoder.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = txn.Set(undoKeyOf(block.Number), contributionBytes); err != nil {
+		return err
+	}
+	if block.Number >= undoDepth {
+		if err = txn.Delete(undoKeyOf(block.Number - undoDepth)); err != nil {
+			return err
+		}
+	}
+	return blockchain.SetIndexProgress(txn, progressName, block.Number+1, block.Hash)
+}
+
+// contribute adds the activity of block to the aggregates of its day
+func (a *Aggregator) contribute(txn db.Transaction, block *core.Block, c *contribution) error {
+	c.Aggregated = true
+	c.Transactions = uint64(len(block.Transactions))
+	for _, receipt := range block.Receipts {
+		c.Events += uint64(len(receipt.Events))
+		if receipt.Fee != nil {
+			c.Fees.Add(c.Fees, receipt.Fee)
+		}
+	}
+	stateUpdate, err := blockchain.StateUpdateByNumber(txn, block.Number)
+	if err != nil {
+		return err
+	}
+	c.Contracts = changedContracts(stateUpdate.StateDiff)
+
+	day, err := dayOf(txn, c.Day)
+	if err != nil {
+		return err
+	}
+	day.Blocks++
+	day.Transactions += c.Transactions
+	day.Events += c.Events
+	day.Fees.Add(day.Fees, c.Fees)
+	for _, address := range c.Contracts {
+		blocks, activeErr := activeBlocks(txn, c.Day, address)
+		if activeErr != nil {
+			return activeErr
+		}
+		if blocks == 0 {
+			day.ActiveContracts++
+		}
+		if err = txn.Set(activeKeyOf(c.Day, address), binary.BigEndian.AppendUint64(nil, blocks+1)); err != nil {
+			return err
+		}
+	}
+	return setDay(txn, day)
+}
+
+// revert removes the contribution of a reverted block from the aggregates of its day. The aggregates are dropped
+// to be built again if the block is too old to be removed.
+func (a *Aggregator) revert(txn db.Transaction, number uint64) error {
+	c, err := contributionOf(txn, number)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return a.drop(txn)
+	} else if err != nil {
+		return err
+	}
+
+	if c.Aggregated {
+		day, dayErr := dayOf(txn, c.Day)
+		if dayErr != nil {
+			return dayErr
+		}
+		day.Blocks--
+		day.Transactions -= c.Transactions
+		day.Events -= c.Events
+		day.Fees.Sub(day.Fees, c.Fees)
+		for _, address := range c.Contracts {
+			blocks, activeErr := activeBlocks(txn, c.Day, address)
+			if activeErr != nil {
+				return activeErr
+			}
+			if blocks <= 1 {
+				day.ActiveContracts--
+				err = txn.Delete(activeKeyOf(c.Day, address))
+			} else {
+				err = txn.Set(activeKeyOf(c.Day, address), binary.BigEndian.AppendUint64(nil, blocks-1))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if day.Blocks == 0 {
+			err = txn.Delete(dayKeyOf(c.Day))
+		} else {
+			err = setDay(txn, day)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = txn.Delete(undoKeyOf(number)); err != nil {
+		return err
+	}
+	if number == 0 {
+		return blockchain.SetIndexProgress(txn, progressName, 0, nil)
+	}
+	// the parent may have been reverted too, which the next step finds out from its hash
+	parent, err := contributionOf(txn, number-1)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return a.drop(txn)
+	} else if err != nil {
+		return err
+	}
+	return blockchain.SetIndexProgress(txn, progressName, number, parent.Hash)
+}
+
+// drop deletes the aggregates so that they are built again from genesis
+func (a *Aggregator) drop(txn db.Transaction) error {
+	a.log.Warnw("Reorg is deeper than the blocks kept to revert the analytics, they are aggregated again")
+	if err := deleteRange(txn, db.Analytics.Key(), nil); err != nil {
+		return err
+	}
+	return blockchain.SetIndexProgress(txn, progressName, 0, nil)
+}
+
+// Days returns the aggregates of the last count days with blocks, the most recent first
+func (a *Aggregator) Days(count uint64) ([]Day, error) {
+	var days []Day
+	return days, a.database.View(func(txn db.Transaction) error {
+		it, err := txn.NewIterator()
+		if err != nil {
+			return err
+		}
+
+		prefix := db.Analytics.Key([]byte{dayKey})
+		for it.Seek(prefix); it.Valid() && bytes.HasPrefix(it.Key(), prefix); it.Next() {
+			var day Day
+			val, valErr := it.Value()
+			if valErr == nil {
+				valErr = encoder.Unmarshal(val, &day)
+			}
+			if valErr != nil {
+				return errors.Join(valErr, it.Close())
+			}
+			days = append(days, day)
+		}
+		if err = it.Close(); err != nil {
+			return err
+		}
+
+		if uint64(len(days)) > count {
+			days = days[uint64(len(days))-count:]
+		}
+		for i, j := 0, len(days)-1; i < j; i, j = i+1, j-1 {
+			days[i], days[j] = days[j], days[i]
+		}
+		return nil
+	})
+}
+
+// changedContracts returns the addresses of the contracts whose storage, nonce or class changed
+func changedContracts(stateDiff *core.StateDiff) []*felt.Felt {
+	changed := make(map[felt.Felt]struct{})
+	for address := range stateDiff.StorageDiffs {
+		changed[address] = struct{}{}
+	}
+	for address := range stateDiff.Nonces {
+		changed[address] = struct{}{}
+	}
+	for _, deployed := range stateDiff.DeployedContracts {
+		changed[*deployed.Address] = struct{}{}
+	}
+	for _, replaced := range stateDiff.ReplacedClasses {
+		changed[*replaced.Address] = struct{}{}
+	}
+
+	contracts := make([]*felt.Felt, 0, len(changed))
+	for address := range changed {
+		address := address
+		contracts = append(contracts, &address)
+	}
+	return contracts
+}
+
+func dayOf(txn db.Transaction, number uint64) (*Day, error) {
+	day := &Day{Day: number, Fees: new(felt.Felt)}
+	err := txn.Get(dayKeyOf(number), func(val []byte) error {
+		return encoder.Unmarshal(val, day)
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return day, nil
+	}
+	return day, err
+}
+
+func setDay(txn db.Transaction, day *Day) error {
+	dayBytes, err := encoder.Marshal(day)
+	if err != nil {
+		return err
+	}
+	return txn.Set(dayKeyOf(day.Day), dayBytes)
+}
+
+func activeBlocks(txn db.Transaction, day uint64, address *felt.Felt) (uint64, error) {
+	var blocks uint64
+	err := txn.Get(activeKeyOf(day, address), func(val []byte) error {
+		blocks = binary.BigEndian.Uint64(val)
+		return nil
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return blocks, err
+}
+
+func contributionOf(txn db.Transaction, number uint64) (*contribution, error) {
+	c := new(contribution)
+	return c, txn.Get(undoKeyOf(number), func(val []byte) error {
+		return encoder.Unmarshal(val, c)
+	})
+}
+
+// deleteRange deletes the keys with prefix that are lower than prefix followed by end, or all of them if end is
+// nil
+func deleteRange(txn db.Transaction, prefix, end []byte) error {
+	upper := db.PrefixEnd(prefix)
+	if end != nil {
+		upper = append(append([]byte(nil), prefix...), end...)
+	}
+	it, err := txn.NewIteratorWithBounds(prefix, upper)
+	if err != nil {
+		return err
+	}
+
+	var keys [][]byte
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err = it.Close(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err = txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dayBytes(day uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, day)
+}
+
+func dayKeyOf(day uint64) []byte {
+	return db.Analytics.Key([]byte{dayKey}, dayBytes(day))
+}
+
+func activeKeyOf(day uint64, address *felt.Felt) []byte {
+	addressBytes := address.Bytes()
+	return db.Analytics.Key([]byte{activeKey}, dayBytes(day), addressBytes[:])
+}
+
+func undoKeyOf(number uint64) []byte {
+	return db.Analytics.Key([]byte{undoKey}, core.MarshalBlockNumber(number))
+}
@@ -0,0 +1,143 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/analytics"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// aggregate computes the aggregates of blocks the way the Aggregator is expected to, the most recent day first
+func aggregate(blocks []*core.Block, stateUpdates []*core.StateUpdate) []analytics.Day {
+	var days []analytics.Day
+	active := make(map[felt.Felt]struct{})
+	for i, block := range blocks {
+		number := block.Timestamp / (24 * 60 * 60)
+		if len(days) == 0 || days[0].Day != number {
+			days = append([]analytics.Day{{Day: number, Fees: new(felt.Felt)}}, days...)
+			active = make(map[felt.Felt]struct{})
+		}
+		day := &days[0]
+		day.Blocks++
+		day.Transactions += uint64(len(block.Transactions))
+		for _, receipt := range block.Receipts {
+			day.Events += uint64(len(receipt.Events))
+			day.Fees.Add(day.Fees, receipt.Fee)
+		}
+		stateDiff := stateUpdates[i].StateDiff
+		for address := range stateDiff.StorageDiffs {
+			active[address] = struct{}{}
+		}
+		for address := range stateDiff.Nonces {
+			active[address] = struct{}{}
+		}
+		for _, deployed := range stateDiff.DeployedContracts {
+			active[*deployed.Address] = struct{}{}
+		}
+		for _, replaced := range stateDiff.ReplacedClasses {
+			active[*replaced.Address] = struct{}{}
+		}
+		day.ActiveContracts = uint64(len(active))
+	}
+	return days
+}
+
+func TestAggregator(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	const blocks = 5
+	stored := make([]*core.Block, 0, blocks)
+	stateUpdates := make([]*core.StateUpdate, 0, blocks)
+	for i := uint64(0); i < blocks; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+		stored = append(stored, block)
+		stateUpdates = append(stateUpdates, stateUpdate)
+	}
+
+	// run aggregates the blocks in the background until t ends
+	run := func(t *testing.T, aggregator *analytics.Aggregator) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- aggregator.Run(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			require.NoError(t, <-done)
+		})
+	}
+	awaitProgress := func(database db.DB, aggregated uint64) {
+		require.Eventually(t, func() bool {
+			var progress uint64
+			require.NoError(t, database.View(func(txn db.Transaction) error {
+				var err error
+				progress, _, err = blockchain.IndexProgress(txn, "analytics")
+				return err
+			}))
+			return progress == aggregated
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+
+	// the test blocks are older than a day, so they are only aggregated with a long retention
+	aggregator := analytics.New(testDB, 1<<20, utils.NewNopZapLogger())
+	run(t, aggregator)
+	awaitProgress(testDB, blocks)
+
+	t.Run("blocks are aggregated by day", func(t *testing.T) {
+		days, err := aggregator.Days(10)
+		require.NoError(t, err)
+		assert.Equal(t, aggregate(stored, stateUpdates), days)
+
+		days, err = aggregator.Days(1)
+		require.NoError(t, err)
+		assert.Len(t, days, 1)
+	})
+
+	t.Run("reverted blocks are removed from the aggregates", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		require.NoError(t, chain.RevertHead())
+		awaitProgress(testDB, blocks-2)
+
+		days, err := aggregator.Days(10)
+		require.NoError(t, err)
+		assert.Equal(t, aggregate(stored[:blocks-2], stateUpdates[:blocks-2]), days)
+	})
+
+	t.Run("blocks older than the retention are not aggregated", func(t *testing.T) {
+		oldDB := pebble.NewMemTest()
+		t.Cleanup(func() {
+			require.NoError(t, oldDB.Close())
+		})
+		oldChain := blockchain.New(oldDB, utils.GOERLI2, utils.NewNopZapLogger())
+		for i := range stored[:2] {
+			require.NoError(t, oldChain.Store(stored[i], &core.BlockCommitments{}, stateUpdates[i], nil))
+		}
+
+		oldAggregator := analytics.New(oldDB, 1, utils.NewNopZapLogger())
+		run(t, oldAggregator)
+		awaitProgress(oldDB, 2)
+		days, err := oldAggregator.Days(10)
+		require.NoError(t, err)
+		assert.Empty(t, days)
+	})
+}
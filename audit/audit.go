@@ -0,0 +1,222 @@
+// Package audit re-executes the stored blocks with the VM and compares the results to the stored receipts,
+// which continuously checks that the stored chain and the VM agree.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/vm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ jobs.Job = (*Auditor)(nil)
+
+// HeadPollInterval is how frequently the head should be checked for new blocks once the audit caught up with it
+const HeadPollInterval = 10 * time.Second
+
+// Divergence is a transaction whose re-execution does not match its receipt
+type Divergence struct {
+	BlockNumber     uint64
+	TransactionHash *felt.Felt
+	// Contract is the contract the transaction was sent to
+	Contract *felt.Felt
+	Reason   string
+}
+
+// Auditor re-executes blocks from a starting block up to the head, and then every new block
+type Auditor struct {
+	chain   blockchain.Reader
+	vm      vm.VM
+	network utils.Network
+	log     utils.SimpleLogger
+	labels  *labels.Registry
+	next    uint64
+	// started is whether the start of the audit was logged
+	started bool
+
+	// metrics
+	auditedBlock prometheus.Gauge
+	divergences  prometheus.Counter
+}
+
+// New returns an Auditor that starts from block from
+func New(chain blockchain.Reader, virtualMachine vm.VM, network utils.Network, from uint64,
+	log utils.SimpleLogger,
+) *Auditor {
+	a := &Auditor{
+		chain:   chain,
+		vm:      virtualMachine,
+		network: network,
+		log:     log,
+		next:    from,
+		auditedBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "audit",
+			Name:      "block",
+			Help:      "The last block that was re-executed",
+		}),
+		divergences: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "audit",
+			Name:      "divergences",
+			Help:      "The number of transactions whose re-execution does not match their receipt",
+		}),
+	}
+	metrics.MustRegister(a.auditedBlock, a.divergences)
+	return a
+}
+
+// WithLabels logs the contracts of the divergences with their labels in registry
+func (a *Auditor) WithLabels(registry *labels.Registry) *Auditor {
+	a.labels = registry
+	return a
+}
+
+// Step re-executes the next block if it is stored, and returns whether there are more blocks to re-execute.
+// Divergences are logged and counted, they do not stop the audit.
+func (a *Auditor) Step(_ context.Context) (bool, error) {
+	if !a.started {
+		a.log.Infow("Auditing blocks by re-executing them", "from", a.next)
+		a.started = true
+	}
+	height, err := a.chain.Height()
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if a.next > height {
+		return false, nil
+	}
+
+	divergences, auditErr := a.Audit(a.next)
+	if auditErr != nil {
+		// the block may have been reverted by a reorg, it is audited again once it is replaced
+		a.log.Warnw("Failed to re-execute block", "number", a.next, "err", auditErr)
+		return false, nil
+	}
+	for _, divergence := range divergences {
+		a.divergences.Inc()
+		fields := []any{"block", divergence.BlockNumber, "transaction", divergence.TransactionHash}
+		if divergence.Contract != nil {
+			fields = append(fields, "contract", a.labels.Describe(divergence.Contract))
+		}
+		a.log.Errorw("Re-execution diverged from the stored receipt", append(fields, "reason", divergence.Reason)...)
+	}
+	a.auditedBlock.Set(float64(a.next))
+	a.next++
+	return a.next <= height, nil
+}
+
+// Audit re-executes a block on the state of its parent and returns the transactions whose results do not
+// match their receipts
+func (a *Auditor) Audit(number uint64) ([]Divergence, error) {
+	block, err := a.chain.BlockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+
+	state, closer, err := a.chain.StateAtBlockHash(block.ParentHash)
+	if err != nil {
+		return nil, err
+	}
+	defer a.callAndLogErr(closer, "Failed to close parent state")
+
+	classes, paidFeesOnL1, err := a.executionInputs(block)
+	if err != nil {
+		return nil, err
+	}
+
+	sequencerAddress := block.SequencerAddress
+	if sequencerAddress == nil {
+		sequencerAddress = core.NetworkBlockHashMetaInfo(a.network).FallBackSequencerAddress
+	}
+	// the blocks were accepted by the network, so their execution is not limited
+	_, traces, err := a.vm.Execute(block.Transactions, classes, block.Number, block.Timestamp, sequencerAddress,
+		state, a.network, paidFeesOnL1, vm.Limits{})
+	if err != nil {
+		return []Divergence{{BlockNumber: number, Reason: "execution failed: " + err.Error()}}, nil
+	}
+	if len(traces) != len(block.Receipts) {
+		return []Divergence{{
+			BlockNumber: number,
+			Reason:      fmt.Sprintf("%d transactions were executed instead of %d", len(traces), len(block.Receipts)),
+		}}, nil
+	}
+
+	var divergences []Divergence
+	for i, receipt := range block.Receipts {
+		if reason := compare(traces[i], receipt); reason != "" {
+			divergences = append(divergences, Divergence{
+				BlockNumber:     number,
+				TransactionHash: receipt.TransactionHash,
+				Contract:        contractOf(block.Transactions[i]),
+				Reason:          reason,
+			})
+		}
+	}
+	return divergences, nil
+}
+
+// contractOf returns the contract a transaction was sent to: the account of the transactions sent by accounts,
+// and the contract that was called or deployed by the others
+func contractOf(transaction core.Transaction) *felt.Felt {
+	switch tx := transaction.(type) {
+	case *core.InvokeTransaction:
+		if tx.SenderAddress != nil {
+			return tx.SenderAddress
+		}
+		return tx.ContractAddress
+	case *core.DeclareTransaction:
+		return tx.SenderAddress
+	case *core.DeployAccountTransaction:
+		return tx.ContractAddress
+	case *core.DeployTransaction:
+		return tx.ContractAddress
+	case *core.L1HandlerTransaction:
+		return tx.ContractAddress
+	}
+	return nil
+}
+
+// executionInputs returns the classes declared by block and the fees paid on L1 by its L1 handler
+// transactions
+func (a *Auditor) executionInputs(block *core.Block) ([]core.Class, []*felt.Felt, error) {
+	state, closer, err := a.chain.StateAtBlockNumber(block.Number)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer a.callAndLogErr(closer, "Failed to close block state")
+
+	var classes []core.Class
+	paidFeesOnL1 := []*felt.Felt{}
+	for _, transaction := range block.Transactions {
+		switch tx := transaction.(type) {
+		case *core.DeclareTransaction:
+			class, classErr := state.Class(tx.ClassHash)
+			if classErr != nil {
+				return nil, nil, fmt.Errorf("declared class %s: %w", tx.ClassHash, classErr)
+			}
+			classes = append(classes, class.Class)
+		case *core.L1HandlerTransaction:
+			// the fee paid on L1 is not stored, any non-zero fee lets the transaction be executed
+			paidFeesOnL1 = append(paidFeesOnL1, new(felt.Felt).SetUint64(1))
+		}
+	}
+	return classes, paidFeesOnL1, nil
+}
+
+func (a *Auditor) callAndLogErr(f func() error, msg string) {
+	if err := f(); err != nil {
+		a.log.Errorw(msg, "err", err)
+	}
+}
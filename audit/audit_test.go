@@ -0,0 +1,130 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/audit"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/mocks"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/vm"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracesOf returns traces that match the receipts of block, with the events and messages of a nested call
+func tracesOf(t *testing.T, block *core.Block) []json.RawMessage {
+	t.Helper()
+
+	traces := make([]json.RawMessage, 0, len(block.Receipts))
+	for _, receipt := range block.Receipts {
+		events := make([]map[string][]*felt.Felt, 0, len(receipt.Events))
+		for _, event := range receipt.Events {
+			events = append(events, map[string][]*felt.Felt{"keys": event.Keys, "data": event.Data})
+		}
+		messages := make([]map[string]any, 0, len(receipt.L2ToL1Message))
+		for _, message := range receipt.L2ToL1Message {
+			messages = append(messages, map[string]any{"to_address": message.To.Hex(), "payload": message.Payload})
+		}
+		trace, err := json.Marshal(map[string]any{
+			"execute_invocation": map[string]any{
+				"calls": []any{map[string]any{"events": events, "messages": messages}},
+			},
+		})
+		require.NoError(t, err)
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+func TestAudit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	var blocks []*core.Block
+	for i := uint64(0); i < 3; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+		blocks = append(blocks, block)
+	}
+
+	mockVM := mocks.NewMockVM(mockCtrl)
+	auditor := audit.New(chain, mockVM, utils.MAINNET, 0, utils.NewNopZapLogger())
+
+	t.Run("matching re-execution", func(t *testing.T) {
+		for _, block := range blocks {
+			mockVM.EXPECT().Execute(block.Transactions, gomock.Any(), block.Number, block.Timestamp, gomock.Any(),
+				gomock.Any(), utils.MAINNET, gomock.Any(), vm.Limits{}).Return(nil, tracesOf(t, block), nil)
+
+			divergences, err := auditor.Audit(block.Number)
+			require.NoError(t, err)
+			assert.Empty(t, divergences)
+		}
+	})
+
+	block := blocks[2]
+	require.NotEmpty(t, block.Receipts)
+
+	t.Run("diverging events", func(t *testing.T) {
+		traces := tracesOf(t, block)
+		traces[0] = json.RawMessage(`{"execute_invocation":{"events":[{"keys":["0x1"],"data":[]}]}}`)
+		mockVM.EXPECT().Execute(block.Transactions, gomock.Any(), block.Number, block.Timestamp, gomock.Any(),
+			gomock.Any(), utils.MAINNET, gomock.Any(), vm.Limits{}).Return(nil, traces, nil)
+
+		divergences, err := auditor.Audit(block.Number)
+		require.NoError(t, err)
+		require.Len(t, divergences, 1)
+		assert.Equal(t, block.Receipts[0].TransactionHash, divergences[0].TransactionHash)
+		require.IsType(t, &core.DeployTransaction{}, block.Transactions[0])
+		assert.Equal(t, block.Transactions[0].(*core.DeployTransaction).ContractAddress, divergences[0].Contract)
+		assert.Contains(t, divergences[0].Reason, "events")
+	})
+
+	t.Run("failed execution", func(t *testing.T) {
+		mockVM.EXPECT().Execute(block.Transactions, gomock.Any(), block.Number, block.Timestamp, gomock.Any(),
+			gomock.Any(), utils.MAINNET, gomock.Any(), vm.Limits{}).Return(nil, nil, errors.New("out of gas"))
+
+		divergences, err := auditor.Audit(block.Number)
+		require.NoError(t, err)
+		require.Len(t, divergences, 1)
+		assert.Contains(t, divergences[0].Reason, "out of gas")
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		_, err := auditor.Audit(3)
+		require.Error(t, err)
+	})
+
+	t.Run("Step audits up to the head", func(t *testing.T) {
+		stepper := audit.New(chain, mockVM, utils.MAINNET, 1, utils.NewNopZapLogger())
+		for _, block := range blocks[1:] {
+			mockVM.EXPECT().Execute(block.Transactions, gomock.Any(), block.Number, block.Timestamp, gomock.Any(),
+				gomock.Any(), utils.MAINNET, gomock.Any(), vm.Limits{}).Return(nil, tracesOf(t, block), nil)
+		}
+
+		more, err := stepper.Step(context.Background())
+		require.NoError(t, err)
+		assert.True(t, more)
+		more, err = stepper.Step(context.Background())
+		require.NoError(t, err)
+		assert.False(t, more)
+		// the head is audited, there is nothing to re-execute until a new block is stored
+		more, err = stepper.Step(context.Background())
+		require.NoError(t, err)
+		assert.False(t, more)
+	})
+}
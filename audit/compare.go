@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The VM does not report the state diff of a transaction, so re-executions are compared to receipts by the
+// events they emit and the messages they send to L1.
+type invocation struct {
+	Calls    []*invocation `json:"calls"`
+	Events   []event       `json:"events"`
+	Messages []message     `json:"messages"`
+}
+
+type event struct {
+	Keys []*felt.Felt `json:"keys"`
+	Data []*felt.Felt `json:"data"`
+}
+
+type message struct {
+	ToAddress string       `json:"to_address"`
+	Payload   []*felt.Felt `json:"payload"`
+}
+
+type transactionTrace struct {
+	ValidateInvocation    *invocation `json:"validate_invocation"`
+	ExecuteInvocation     *invocation `json:"execute_invocation"`
+	FeeTransferInvocation *invocation `json:"fee_transfer_invocation"`
+}
+
+// compare returns why trace does not match receipt, or an empty string if it does
+func compare(trace json.RawMessage, receipt *core.TransactionReceipt) string {
+	var txTrace transactionTrace
+	if err := json.Unmarshal(trace, &txTrace); err != nil {
+		return "malformed trace: " + err.Error()
+	}
+
+	var events, messages []string
+	for _, root := range []*invocation{txTrace.ValidateInvocation, txTrace.ExecuteInvocation, txTrace.FeeTransferInvocation} {
+		events, messages = root.collect(events, messages)
+	}
+
+	receiptEvents := make([]string, 0, len(receipt.Events))
+	for _, e := range receipt.Events {
+		receiptEvents = append(receiptEvents, eventKey(e.Keys, e.Data))
+	}
+	receiptMessages := make([]string, 0, len(receipt.L2ToL1Message))
+	for _, m := range receipt.L2ToL1Message {
+		receiptMessages = append(receiptMessages, messageKey(m.To, m.Payload))
+	}
+
+	// events of nested calls are not ordered in traces, so only the sets of events are compared
+	if !sameElements(events, receiptEvents) {
+		return fmt.Sprintf("emitted %d events that do not match the %d events of the receipt", len(events),
+			len(receiptEvents))
+	}
+	if !sameElements(messages, receiptMessages) {
+		return fmt.Sprintf("sent %d messages to L1 that do not match the %d messages of the receipt", len(messages),
+			len(receiptMessages))
+	}
+	return ""
+}
+
+// collect appends the events and messages of i and its nested calls
+func (i *invocation) collect(events, messages []string) ([]string, []string) {
+	if i == nil {
+		return events, messages
+	}
+	for _, e := range i.Events {
+		events = append(events, eventKey(e.Keys, e.Data))
+	}
+	for _, m := range i.Messages {
+		messages = append(messages, messageKey(common.HexToAddress(m.ToAddress), m.Payload))
+	}
+	for _, call := range i.Calls {
+		events, messages = call.collect(events, messages)
+	}
+	return events, messages
+}
+
+func eventKey(keys, data []*felt.Felt) string {
+	return feltsKey(keys) + "|" + feltsKey(data)
+}
+
+func messageKey(to common.Address, payload []*felt.Felt) string {
+	return to.Hex() + "|" + feltsKey(payload)
+}
+
+func feltsKey(felts []*felt.Felt) string {
+	strs := make([]string, 0, len(felts))
+	for _, f := range felts {
+		strs = append(strs, f.String())
+	}
+	return strings.Join(strs, ",")
+}
+
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
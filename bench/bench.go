@@ -0,0 +1,229 @@
+// Package bench replays blocks that are already stored in a database through the verification and commit pipeline
+// of the node into another database, measuring the time spent in each of its stages. It is the standard harness to
+// compare the performance of changes to the pipeline.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+// Stage is a stage of the import of a block
+type Stage string
+
+const (
+	// StageDecode reads and decodes the block, its state update and its new classes from the source database
+	StageDecode Stage = "decode"
+	// StageHash verifies the hash of the block and computes its commitments
+	StageHash Stage = "hash"
+	// StageTrie applies the state diff of the block to the state tries
+	StageTrie Stage = "trie"
+	// StageWrite writes the rest of the block and commits it to the target database
+	StageWrite Stage = "db write"
+)
+
+// Stages are the stages of the import of a block, in the order they run
+var Stages = []Stage{StageDecode, StageHash, StageTrie, StageWrite}
+
+// Report is the time spent in each stage to import the blocks from From to To
+type Report struct {
+	From         uint64
+	To           uint64
+	Transactions uint64
+	Elapsed      time.Duration
+	Stages       map[Stage]time.Duration
+}
+
+// Blocks returns the number of imported blocks
+func (r *Report) Blocks() uint64 {
+	return r.To - r.From + 1
+}
+
+// BlocksPerSecond returns the import rate of the blocks
+func (r *Report) BlocksPerSecond() float64 {
+	if r.Elapsed == 0 {
+		return 0
+	}
+	return float64(r.Blocks()) / r.Elapsed.Seconds()
+}
+
+// Importer imports the blocks of a source database into target
+type Importer struct {
+	source  db.DB
+	target  *blockchain.Blockchain
+	network utils.Network
+	log     utils.SimpleLogger
+
+	// the durations of the trie and write stages of the last block, passed by the target
+	trie  time.Duration
+	write time.Duration
+}
+
+// New returns an Importer that imports the blocks of source into target, which is set up to report the durations
+// of its stages
+func New(source db.DB, target *blockchain.Blockchain, network utils.Network, log utils.SimpleLogger) *Importer {
+	i := &Importer{
+		source:  source,
+		target:  target,
+		network: network,
+		log:     log,
+	}
+	target.WithStoreObserver(func(trie, write time.Duration) {
+		i.trie, i.write = trie, write
+	})
+	return i
+}
+
+// Import imports the blocks from from to to into the target and reports the time spent in each stage. The blocks
+// before from that the target does not have are imported first without being measured. The decode and hash
+// stages are labelled with their names in CPU profiles, under the "stage" label, and the trie and write stages
+// together with "store".
+func (i *Importer) Import(ctx context.Context, from, to uint64) (*Report, error) {
+	if from > to {
+		return nil, fmt.Errorf("the first block %d is after the last block %d", from, to)
+	}
+	next, err := i.target.Height()
+	if errors.Is(err, db.ErrKeyNotFound) {
+		next = 0
+	} else if err != nil {
+		return nil, err
+	} else {
+		next++
+	}
+	if next > from {
+		return nil, fmt.Errorf("the target database already has block %d, import into an empty one", from)
+	}
+
+	if next < from {
+		i.log.Infow("Importing the blocks before the measured range", "from", next, "to", from-1)
+		for number := next; number < from; number++ {
+			if _, err = i.importBlock(ctx, number); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	report := &Report{From: from, To: to, Stages: make(map[Stage]time.Duration, len(Stages))}
+	started := time.Now()
+	for number := from; number <= to; number++ {
+		stages, err := i.importBlock(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		for stage, elapsed := range stages.durations {
+			report.Stages[stage] += elapsed
+		}
+		report.Transactions += stages.transactions
+	}
+	report.Elapsed = time.Since(started)
+	return report, nil
+}
+
+// blockStages are the durations of the stages of the import of a block
+type blockStages struct {
+	durations    map[Stage]time.Duration
+	transactions uint64
+}
+
+func (i *Importer) importBlock(ctx context.Context, number uint64) (*blockStages, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stages := &blockStages{durations: make(map[Stage]time.Duration, len(Stages))}
+	// measure runs f labelled with label in CPU profiles and returns its duration
+	measure := func(label string, f func() error) (time.Duration, error) {
+		var err error
+		started := time.Now()
+		pprof.Do(ctx, pprof.Labels("stage", label), func(context.Context) {
+			err = f()
+		})
+		return time.Since(started), err
+	}
+
+	var (
+		block       *core.Block
+		update      *core.StateUpdate
+		classes     map[felt.Felt]core.Class
+		commitments *core.BlockCommitments
+		err         error
+	)
+	if stages.durations[StageDecode], err = measure(string(StageDecode), func() error {
+		block, update, classes, err = i.read(number)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("read block %d: %w", number, err)
+	}
+	if stages.durations[StageHash], err = measure(string(StageHash), func() error {
+		commitments, err = core.VerifyBlockHash(block, i.network)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("verify block %d: %w", number, err)
+	}
+	// the trie and write stages both run in Store, which measures them
+	if _, err = measure("store", func() error {
+		return i.target.Store(block, commitments, update, classes)
+	}); err != nil {
+		return nil, fmt.Errorf("store block %d: %w", number, err)
+	}
+	stages.durations[StageTrie], stages.durations[StageWrite] = i.trie, i.write
+	stages.transactions = uint64(len(block.Transactions))
+	return stages, nil
+}
+
+// read reads the block number from the source along with its state update and the classes it declares or deploys
+// first
+func (i *Importer) read(number uint64) (*core.Block, *core.StateUpdate, map[felt.Felt]core.Class, error) {
+	var (
+		block   *core.Block
+		update  *core.StateUpdate
+		classes = make(map[felt.Felt]core.Class)
+	)
+	err := i.source.View(func(txn db.Transaction) error {
+		var err error
+		if block, err = blockchain.BlockByNumber(txn, number); err != nil {
+			return err
+		}
+		if update, err = blockchain.StateUpdateByNumber(txn, number); err != nil {
+			return err
+		}
+
+		state := core.NewState(txn)
+		addIfNew := func(classHash *felt.Felt) error {
+			declared, err := state.Class(classHash)
+			if err != nil {
+				return fmt.Errorf("read class %s: %w", classHash, err)
+			}
+			if declared.At == number {
+				classes[*classHash] = declared.Class
+			}
+			return nil
+		}
+		diff := update.StateDiff
+		for _, deployed := range diff.DeployedContracts {
+			if err = addIfNew(deployed.ClassHash); err != nil {
+				return err
+			}
+		}
+		for _, classHash := range diff.DeclaredV0Classes {
+			if err = addIfNew(classHash); err != nil {
+				return err
+			}
+		}
+		for _, declared := range diff.DeclaredV1Classes {
+			if err = addIfNew(declared.ClassHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return block, update, classes, err
+}
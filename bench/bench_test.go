@@ -0,0 +1,68 @@
+package bench_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/bench"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	sourceDB := pebble.NewMemTest()
+	targetDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, sourceDB.Close())
+		require.NoError(t, targetDB.Close())
+	})
+	source := blockchain.New(sourceDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+	for i := uint64(0); i < 5; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		// the classes of the test blocks are not fetched, their contents do not matter
+		classes := make(map[felt.Felt]core.Class)
+		for _, deployed := range stateUpdate.StateDiff.DeployedContracts {
+			classes[*deployed.ClassHash] = &core.Cairo0Class{}
+		}
+		require.NoError(t, source.Store(block, &core.BlockCommitments{}, stateUpdate, classes))
+	}
+
+	target := blockchain.New(targetDB, utils.GOERLI2, utils.NewNopZapLogger())
+	importer := bench.New(sourceDB, target, utils.GOERLI2, utils.NewNopZapLogger())
+
+	_, err := importer.Import(context.Background(), 3, 2)
+	require.Error(t, err)
+
+	report, err := importer.Import(context.Background(), 2, 4)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), report.Blocks())
+	assert.Positive(t, report.Elapsed)
+	for _, stage := range bench.Stages {
+		assert.Positive(t, report.Stages[stage], stage)
+	}
+
+	for i := uint64(0); i < 5; i++ {
+		want, err := source.BlockByNumber(i)
+		require.NoError(t, err)
+		got, err := target.BlockByNumber(i)
+		require.NoError(t, err)
+		assert.Equal(t, want.Hash, got.Hash)
+		assert.Equal(t, want.GlobalStateRoot, got.GlobalStateRoot)
+	}
+
+	_, err = importer.Import(context.Background(), 4, 4)
+	require.ErrorContains(t, err, "already has block 4")
+	_, err = importer.Import(context.Background(), 5, 5)
+	require.Error(t, err, "the source does not have block 5")
+}
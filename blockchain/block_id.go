@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// BlockID identifies a block as the latest or the pending block, by its hash, or else by its number
+type BlockID struct {
+	Pending bool
+	Latest  bool
+	Hash    *felt.Felt
+	Number  uint64
+}
+
+// BlockByID returns the block that id identifies
+func BlockByID(reader Reader, id BlockID) (*core.Block, error) {
+	switch {
+	case id.Latest:
+		return reader.Head()
+	case id.Hash != nil:
+		return reader.BlockByHash(id.Hash)
+	case id.Pending:
+		pending, err := reader.Pending()
+		if err != nil {
+			return nil, err
+		}
+		return pending.Block, nil
+	default:
+		return reader.BlockByNumber(id.Number)
+	}
+}
+
+// BlockHeaderByID returns the header of the block that id identifies
+func BlockHeaderByID(reader Reader, id BlockID) (*core.Header, error) {
+	switch {
+	case id.Latest:
+		return reader.HeadsHeader()
+	case id.Hash != nil:
+		return reader.BlockHeaderByHash(id.Hash)
+	case id.Pending:
+		pending, err := reader.Pending()
+		if err != nil {
+			return nil, err
+		}
+		return pending.Block.Header, nil
+	default:
+		return reader.BlockHeaderByNumber(id.Number)
+	}
+}
+
+// StateUpdateByID returns the state update of the block that id identifies
+func StateUpdateByID(reader Reader, id BlockID) (*core.StateUpdate, error) {
+	switch {
+	case id.Latest:
+		height, err := reader.Height()
+		if err != nil {
+			return nil, err
+		}
+		return reader.StateUpdateByNumber(height)
+	case id.Hash != nil:
+		return reader.StateUpdateByHash(id.Hash)
+	case id.Pending:
+		pending, err := reader.Pending()
+		if err != nil {
+			return nil, err
+		}
+		return pending.StateUpdate, nil
+	default:
+		return reader.StateUpdateByNumber(id.Number)
+	}
+}
+
+// StateByID returns the state after the block that id identifies
+func StateByID(reader Reader, id BlockID) (core.StateReader, StateCloser, error) {
+	switch {
+	case id.Latest:
+		return reader.HeadState()
+	case id.Hash != nil:
+		return reader.StateAtBlockHash(id.Hash)
+	case id.Pending:
+		return reader.PendingState()
+	default:
+		return reader.StateAtBlockNumber(id.Number)
+	}
+}
@@ -0,0 +1,74 @@
+package blockchain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByID(t *testing.T) {
+	chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	for i := uint64(0); i < 3; i++ {
+		b, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		su, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		if i < 2 {
+			require.NoError(t, chain.Store(b, &emptyCommitments, su, nil))
+		} else {
+			require.NoError(t, chain.StorePending(&blockchain.Pending{Block: b, StateUpdate: su}))
+		}
+	}
+	head, err := chain.Head()
+	require.NoError(t, err)
+	pending, err := chain.Pending()
+	require.NoError(t, err)
+
+	for name, test := range map[string]struct {
+		id       blockchain.BlockID
+		expected uint64
+	}{
+		"latest":    {id: blockchain.BlockID{Latest: true}, expected: 1},
+		"pending":   {id: blockchain.BlockID{Pending: true}, expected: 2},
+		"by hash":   {id: blockchain.BlockID{Hash: head.Hash}, expected: 1},
+		"by number": {id: blockchain.BlockID{Number: 0}, expected: 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			block, err := blockchain.BlockByID(chain, test.id)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, block.Number)
+
+			header, err := blockchain.BlockHeaderByID(chain, test.id)
+			require.NoError(t, err)
+			assert.Equal(t, block.Header, header)
+
+			update, err := blockchain.StateUpdateByID(chain, test.id)
+			require.NoError(t, err)
+			if test.id.Pending {
+				assert.Equal(t, pending.StateUpdate, update)
+			} else {
+				assert.Equal(t, block.GlobalStateRoot, update.NewRoot)
+			}
+
+			_, closer, err := blockchain.StateByID(chain, test.id)
+			require.NoError(t, err)
+			require.NoError(t, closer())
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := blockchain.BlockByID(chain, blockchain.BlockID{Number: 42})
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+		_, err = blockchain.StateUpdateByID(chain, blockchain.BlockID{Number: 42})
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+	})
+}
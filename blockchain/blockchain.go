@@ -2,15 +2,18 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/NethermindEth/juno/abi"
 	"github.com/NethermindEth/juno/core"
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/db"
-	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/tracing"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/ethereum/go-ethereum/event"
 )
@@ -35,6 +38,7 @@ type Reader interface {
 	StateUpdateByHash(hash *felt.Felt) (update *core.StateUpdate, err error)
 
 	HeadState() (core.StateReader, StateCloser, error)
+	StorageProof(addr, key *felt.Felt) (*core.StorageProof, error)
 	StateAtBlockHash(blockHash *felt.Felt) (core.StateReader, StateCloser, error)
 	StateAtBlockNumber(blockNumber uint64) (core.StateReader, StateCloser, error)
 	PendingState() (core.StateReader, StateCloser, error)
@@ -72,6 +76,32 @@ type Blockchain struct {
 	log utils.SimpleLogger
 
 	newHeads event.FeedOf[*core.Header]
+
+	// nil unless set with WithCaches
+	headers  *HeaderCache
+	receipts *ReceiptCache
+	// nil unless set with WithProofCache
+	proofs *ProofCache
+	// nil unless set with WithStoreObserver
+	observeStore StoreObserver
+	// set with WithABIs
+	storeABIs bool
+}
+
+// StoreObserver is passed the time Store spent updating the state tries, and writing and committing the rest of
+// the block
+type StoreObserver func(trie, write time.Duration)
+
+// WithStoreObserver passes the durations of the stages of Store to observe, once the block is committed
+func (b *Blockchain) WithStoreObserver(observe StoreObserver) *Blockchain {
+	b.observeStore = observe
+	return b
+}
+
+// WithABIs stores the ABIs of the classes declared in the blocks it stores, see abi.StoreDeclared
+func (b *Blockchain) WithABIs() *Blockchain {
+	b.storeABIs = true
+	return b
 }
 
 func New(database db.DB, network utils.Network, log utils.SimpleLogger) *Blockchain {
@@ -109,11 +139,7 @@ func (b *Blockchain) Height() (uint64, error) {
 }
 
 func chainHeight(txn db.Transaction) (uint64, error) {
-	var height uint64
-	return height, txn.Get(db.ChainHeight.Key(), func(val []byte) error {
-		height = binary.BigEndian.Uint64(val)
-		return nil
-	})
+	return chainHeightValue.Get(txn)
 }
 
 func (b *Blockchain) Head() (*core.Block, error) {
@@ -130,7 +156,7 @@ func (b *Blockchain) HeadsHeader() (*core.Header, error) {
 
 	return header, b.database.View(func(txn db.Transaction) error {
 		var err error
-		header, err = headsHeader(txn)
+		header, err = cachedHeadsHeader(b.headers, txn)
 		return err
 	})
 }
@@ -144,12 +170,16 @@ func head(txn db.Transaction) (*core.Block, error) {
 }
 
 func headsHeader(txn db.Transaction) (*core.Header, error) {
+	return cachedHeadsHeader(nil, txn)
+}
+
+func cachedHeadsHeader(headers *HeaderCache, txn db.Transaction) (*core.Header, error) {
 	height, err := chainHeight(txn)
 	if err != nil {
 		return nil, err
 	}
 
-	return blockHeaderByNumber(txn, height)
+	return cachedBlockHeaderByNumber(headers, txn, height)
 }
 
 func (b *Blockchain) BlockByNumber(number uint64) (*core.Block, error) {
@@ -165,7 +195,7 @@ func (b *Blockchain) BlockHeaderByNumber(number uint64) (*core.Header, error) {
 	var header *core.Header
 	return header, b.database.View(func(txn db.Transaction) error {
 		var err error
-		header, err = blockHeaderByNumber(txn, number)
+		header, err = cachedBlockHeaderByNumber(b.headers, txn, number)
 		return err
 	})
 }
@@ -192,7 +222,7 @@ func (b *Blockchain) StateUpdateByNumber(number uint64) (*core.StateUpdate, erro
 	var update *core.StateUpdate
 	return update, b.database.View(func(txn db.Transaction) error {
 		var err error
-		update, err = stateUpdateByNumber(txn, number)
+		update, err = StateUpdateByNumber(txn, number)
 		return err
 	})
 }
@@ -289,22 +319,12 @@ func (b *Blockchain) L1Head() (*core.L1Head, error) {
 }
 
 func l1Head(txn db.Transaction) (*core.L1Head, error) {
-	var update *core.L1Head
-	if err := txn.Get(db.L1Height.Key(), func(updateBytes []byte) error {
-		return encoder.Unmarshal(updateBytes, &update)
-	}); err != nil {
-		return nil, err
-	}
-	return update, nil
+	return l1HeadValue.Get(txn)
 }
 
 func (b *Blockchain) SetL1Head(update *core.L1Head) error {
-	updateBytes, err := encoder.Marshal(update)
-	if err != nil {
-		return err
-	}
 	return b.database.Update(func(txn db.Transaction) error {
-		return txn.Set(db.L1Height.Key(), updateBytes)
+		return l1HeadValue.Put(txn, update)
 	})
 }
 
@@ -312,13 +332,38 @@ func (b *Blockchain) SetL1Head(update *core.L1Head) error {
 func (b *Blockchain) Store(block *core.Block, blockCommitments *core.BlockCommitments,
 	stateUpdate *core.StateUpdate, newClasses map[felt.Felt]core.Class,
 ) error {
-	return b.database.Update(func(txn db.Transaction) error {
+	return b.StoreContext(context.Background(), block, blockCommitments, stateUpdate, newClasses)
+}
+
+// StoreContext is Store, which traces the update of the state tries and the commit of the block as children of
+// any span in ctx
+func (b *Blockchain) StoreContext(ctx context.Context, block *core.Block, blockCommitments *core.BlockCommitments,
+	stateUpdate *core.StateUpdate, newClasses map[felt.Felt]core.Class,
+) error {
+	defer b.forgetBlock(block.Number)
+	if err := writeCommitJournal(b.database, block, stateUpdate); err != nil {
+		return fmt.Errorf("write commit journal: %w", err)
+	}
+	started := time.Now()
+	var trieElapsed time.Duration
+	store := func(txn db.Transaction) error {
 		if err := verifyBlock(txn, block); err != nil {
 			return err
 		}
-		if err := core.NewState(txn).Update(block.Number, stateUpdate, newClasses); err != nil {
+		trieStarted := time.Now()
+		// the tries are committed, which hashes their nodes, as the state is updated
+		_, trieSpan := tracing.StartSpan(ctx, "trie.commit", tracing.BlockNumber(block.Number))
+		err := core.NewState(txn).Update(block.Number, stateUpdate, newClasses)
+		tracing.EndSpan(trieSpan, err)
+		if err != nil {
 			return err
 		}
+		trieElapsed = time.Since(trieStarted)
+		if b.storeABIs {
+			if err := abi.StoreDeclared(txn, newClasses); err != nil {
+				return err
+			}
+		}
 		if err := StoreBlockHeader(txn, block.Header); err != nil {
 			return err
 		}
@@ -339,15 +384,32 @@ func (b *Blockchain) Store(block *core.Block, blockCommitments *core.BlockCommit
 			return err
 		}
 
-		if err := txn.Delete(db.Pending.Key()); err != nil {
+		if err := pendingValue.Delete(txn); err != nil {
+			return err
+		}
+		if err := commitJournalValue.Delete(txn); err != nil {
 			return err
 		}
 
 		// Head of the blockchain is maintained as follows:
 		// [db.ChainHeight]() -> (BlockNumber)
-		heightBin := core.MarshalBlockNumber(block.Number)
-		return txn.Set(db.ChainHeight.Key(), heightBin)
-	})
+		return chainHeightValue.Put(txn, block.Number)
+	}
+	txn := b.database.NewTransaction(true)
+	err := store(txn)
+	if err == nil {
+		_, commitSpan := tracing.StartSpan(ctx, "db.commit", tracing.BlockNumber(block.Number))
+		err = txn.Commit()
+		tracing.EndSpan(commitSpan, err)
+	}
+	if err = db.CloseAndWrapOnError(txn.Discard, err); err != nil {
+		// nothing was committed, the journal is only left behind by a crash
+		return errors.Join(err, deleteCommitJournal(b.database))
+	}
+	if b.observeStore != nil {
+		b.observeStore(trieElapsed, time.Since(started)-trieElapsed)
+	}
+	return nil
 }
 
 // VerifyBlock assumes the block has already been sanity-checked.
@@ -384,36 +446,31 @@ func verifyBlock(txn db.Transaction, block *core.Block) error {
 }
 
 func StoreBlockCommitments(txn db.Transaction, blockNumber uint64, commitments *core.BlockCommitments) error {
-	numBytes := core.MarshalBlockNumber(blockNumber)
-
-	commitmentBytes, err := encoder.Marshal(commitments)
-	if err != nil {
-		return err
-	}
-
-	return txn.Set(db.BlockCommitments.Key(numBytes), commitmentBytes)
+	return commitmentsTable.Put(txn, blockNumber, commitments)
 }
 
+// BlockCommitmentsByNumber returns the commitments of the block, which are calculated from the block if they were
+// not stored, such as while the migration that calculates them is applied in the background
 func (b *Blockchain) BlockCommitmentsByNumber(blockNumber uint64) (*core.BlockCommitments, error) {
 	var commitments *core.BlockCommitments
 	return commitments, b.database.View(func(txn db.Transaction) error {
 		var err error
 		commitments, err = blockCommitmentsByNumber(txn, blockNumber)
+		if !errors.Is(err, db.ErrKeyNotFound) {
+			return err
+		}
+
+		block, err := BlockByNumber(txn, blockNumber)
+		if err != nil {
+			return err
+		}
+		commitments, err = core.VerifyBlockHash(block, b.network)
 		return err
 	})
 }
 
 func blockCommitmentsByNumber(txn db.Transaction, blockNumber uint64) (*core.BlockCommitments, error) {
-	numBytes := core.MarshalBlockNumber(blockNumber)
-
-	var commitments *core.BlockCommitments
-	if err := txn.Get(db.BlockCommitments.Key(numBytes), func(val []byte) error {
-		commitments = new(core.BlockCommitments)
-		return encoder.Unmarshal(val, commitments)
-	}); err != nil {
-		return nil, err
-	}
-	return commitments, nil
+	return commitmentsTable.Get(txn, blockNumber)
 }
 
 // StoreBlockHeader stores the given block in the database.
@@ -426,50 +483,28 @@ func blockCommitmentsByNumber(txn db.Transaction, blockNumber uint64) (*core.Blo
 // "()" are additional keys appended to the prefix or multiple values marshalled together
 // "->" represents a key value pair.
 func StoreBlockHeader(txn db.Transaction, header *core.Header) error {
-	numBytes := core.MarshalBlockNumber(header.Number)
-
-	if err := txn.Set(db.BlockHeaderNumbersByHash.Key(header.Hash.Marshal()), numBytes); err != nil {
-		return err
-	}
-
-	headerBytes, err := encoder.Marshal(header)
-	if err != nil {
-		return err
-	}
-
-	if err = txn.Set(db.BlockHeadersByNumber.Key(numBytes), headerBytes); err != nil {
+	if err := headerNumberTable.Put(txn, header.Hash, header.Number); err != nil {
 		return err
 	}
-
-	return nil
+	return headerTable.Put(txn, header.Number, header)
 }
 
-// blockHeaderByNumber retrieves a block header from database by its number
-func blockHeaderByNumber(txn db.Transaction, number uint64) (*core.Header, error) {
-	numBytes := core.MarshalBlockNumber(number)
-
-	var header *core.Header
-	if err := txn.Get(db.BlockHeadersByNumber.Key(numBytes), func(val []byte) error {
-		header = new(core.Header)
-		return encoder.Unmarshal(val, header)
-	}); err != nil {
-		return nil, err
-	}
-	return header, nil
+// BlockHeaderByNumber retrieves a block header from database by its number
+func BlockHeaderByNumber(txn db.Transaction, number uint64) (*core.Header, error) {
+	return headerTable.Get(txn, number)
 }
 
 func blockHeaderByHash(txn db.Transaction, hash *felt.Felt) (*core.Header, error) {
-	var header *core.Header
-	return header, txn.Get(db.BlockHeaderNumbersByHash.Key(hash.Marshal()), func(val []byte) error {
-		var err error
-		header, err = blockHeaderByNumber(txn, binary.BigEndian.Uint64(val))
-		return err
-	})
+	number, err := headerNumberTable.Get(txn, hash)
+	if err != nil {
+		return nil, err
+	}
+	return BlockHeaderByNumber(txn, number)
 }
 
 // BlockByNumber retrieves a block from database by its number
 func BlockByNumber(txn db.Transaction, number uint64) (*core.Block, error) {
-	header, err := blockHeaderByNumber(txn, number)
+	header, err := BlockHeaderByNumber(txn, number)
 	if err != nil {
 		return nil, err
 	}
@@ -489,116 +524,47 @@ func BlockByNumber(txn db.Transaction, number uint64) (*core.Block, error) {
 }
 
 func transactionsByBlockNumber(txn db.Transaction, number uint64) ([]core.Transaction, error) {
-	iterator, err := txn.NewIterator()
-	if err != nil {
-		return nil, err
-	}
-
 	var txs []core.Transaction
-	numBytes := core.MarshalBlockNumber(number)
-
-	prefix := db.TransactionsByBlockNumberAndIndex.Key(numBytes)
-	for iterator.Seek(prefix); iterator.Valid(); iterator.Next() {
-		if !bytes.Equal(iterator.Key()[:len(prefix)], prefix) {
-			break
-		}
-
-		val, vErr := iterator.Value()
-		if vErr != nil {
-			return nil, db.CloseAndWrapOnError(iterator.Close, vErr)
-		}
-
-		var tx core.Transaction
-		if err = encoder.Unmarshal(val, &tx); err != nil {
-			return nil, db.CloseAndWrapOnError(iterator.Close, err)
-		}
-
-		txs = append(txs, tx)
-	}
-
-	if err = iterator.Close(); err != nil {
-		return nil, err
-	}
-
-	return txs, nil
+	return txs, transactionTable.IteratePrefix(txn, core.MarshalBlockNumber(number),
+		func(_ *txAndReceiptDBKey, tx core.Transaction) error {
+			txs = append(txs, tx)
+			return nil
+		})
 }
 
 func receiptsByBlockNumber(txn db.Transaction, number uint64) ([]*core.TransactionReceipt, error) {
-	iterator, err := txn.NewIterator()
-	if err != nil {
-		return nil, err
-	}
-
 	var receipts []*core.TransactionReceipt
-	numBytes := core.MarshalBlockNumber(number)
-
-	prefix := db.ReceiptsByBlockNumberAndIndex.Key(numBytes)
-	for iterator.Seek(prefix); iterator.Valid(); iterator.Next() {
-		if !bytes.Equal(iterator.Key()[:len(prefix)], prefix) {
-			break
-		}
-
-		val, vErr := iterator.Value()
-		if vErr != nil {
-			return nil, db.CloseAndWrapOnError(iterator.Close, vErr)
-		}
-
-		receipt := new(core.TransactionReceipt)
-		if err = encoder.Unmarshal(val, receipt); err != nil {
-			return nil, db.CloseAndWrapOnError(iterator.Close, err)
-		}
-
-		receipts = append(receipts, receipt)
-	}
-
-	if err = iterator.Close(); err != nil {
-		return nil, err
-	}
-
-	return receipts, nil
+	return receipts, receiptTable.IteratePrefix(txn, core.MarshalBlockNumber(number),
+		func(_ *txAndReceiptDBKey, receipt *core.TransactionReceipt) error {
+			receipts = append(receipts, receipt)
+			return nil
+		})
 }
 
 // blockByHash retrieves a block from database by its hash
 func blockByHash(txn db.Transaction, hash *felt.Felt) (*core.Block, error) {
-	var block *core.Block
-	return block, txn.Get(db.BlockHeaderNumbersByHash.Key(hash.Marshal()), func(val []byte) error {
-		var err error
-		block, err = BlockByNumber(txn, binary.BigEndian.Uint64(val))
-		return err
-	})
-}
-
-func storeStateUpdate(txn db.Transaction, blockNumber uint64, update *core.StateUpdate) error {
-	numBytes := core.MarshalBlockNumber(blockNumber)
-
-	updateBytes, err := encoder.Marshal(update)
+	number, err := headerNumberTable.Get(txn, hash)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return txn.Set(db.StateUpdatesByBlockNumber.Key(numBytes), updateBytes)
+	return BlockByNumber(txn, number)
 }
 
-func stateUpdateByNumber(txn db.Transaction, blockNumber uint64) (*core.StateUpdate, error) {
-	numBytes := core.MarshalBlockNumber(blockNumber)
+func storeStateUpdate(txn db.Transaction, blockNumber uint64, update *core.StateUpdate) error {
+	return stateUpdateTable.Put(txn, blockNumber, update)
+}
 
-	var update *core.StateUpdate
-	if err := txn.Get(db.StateUpdatesByBlockNumber.Key(numBytes), func(val []byte) error {
-		update = new(core.StateUpdate)
-		return encoder.Unmarshal(val, update)
-	}); err != nil {
-		return nil, err
-	}
-	return update, nil
+// StateUpdateByNumber returns the state update of the block with number
+func StateUpdateByNumber(txn db.Transaction, blockNumber uint64) (*core.StateUpdate, error) {
+	return stateUpdateTable.Get(txn, blockNumber)
 }
 
 func stateUpdateByHash(txn db.Transaction, hash *felt.Felt) (*core.StateUpdate, error) {
-	var update *core.StateUpdate
-	return update, txn.Get(db.BlockHeaderNumbersByHash.Key(hash.Marshal()), func(val []byte) error {
-		var err error
-		update, err = stateUpdateByNumber(txn, binary.BigEndian.Uint64(val))
-		return err
-	})
+	number, err := headerNumberTable.Get(txn, hash)
+	if err != nil {
+		return nil, err
+	}
+	return StateUpdateByNumber(txn, number)
 }
 
 // SanityCheckNewHeight checks integrity of a block and resulting state update
@@ -636,6 +602,26 @@ func (t *txAndReceiptDBKey) UnmarshalBinary(data []byte) error {
 	return binary.Read(r, binary.BigEndian, &t.Index)
 }
 
+// txAndReceiptCodec encodes the txAndReceiptDBKeys of the keys and of the values of the tables of the transactions
+type txAndReceiptCodec struct{}
+
+func (txAndReceiptCodec) EncodeKey(key *txAndReceiptDBKey) []byte {
+	return key.MarshalBinary()
+}
+
+func (txAndReceiptCodec) DecodeKey(encoded []byte) (*txAndReceiptDBKey, error) {
+	key := new(txAndReceiptDBKey)
+	return key, key.UnmarshalBinary(encoded)
+}
+
+func (c txAndReceiptCodec) EncodeValue(value *txAndReceiptDBKey) ([]byte, error) {
+	return c.EncodeKey(value), nil
+}
+
+func (c txAndReceiptCodec) DecodeValue(encoded []byte) (*txAndReceiptDBKey, error) {
+	return c.DecodeKey(encoded)
+}
+
 // storeTransactionAndReceipt stores the given transaction receipt in the database.
 // The db storage for transaction and receipts is maintained by three buckets as follows:
 //
@@ -649,47 +635,24 @@ func (t *txAndReceiptDBKey) UnmarshalBinary(data []byte) error {
 // "()" are additional keys appended to the prefix or multiple values marshalled together
 // "->" represents a key value pair.
 func storeTransactionAndReceipt(txn db.Transaction, number, i uint64, t core.Transaction, r *core.TransactionReceipt) error {
-	bnIndexBytes := (&txAndReceiptDBKey{number, i}).MarshalBinary()
-
-	if err := txn.Set(db.TransactionBlockNumbersAndIndicesByHash.Key((r.TransactionHash).Marshal()),
-		bnIndexBytes); err != nil {
-		return err
-	}
-
-	txnBytes, err := encoder.Marshal(t)
-	if err != nil {
-		return err
-	}
-	if err = txn.Set(db.TransactionsByBlockNumberAndIndex.Key(bnIndexBytes), txnBytes); err != nil {
+	bnIndex := &txAndReceiptDBKey{number, i}
+	if err := transactionNumberTable.Put(txn, r.TransactionHash, bnIndex); err != nil {
 		return err
 	}
-
-	rBytes, err := encoder.Marshal(r)
-	if err != nil {
+	if err := transactionTable.Put(txn, bnIndex, t); err != nil {
 		return err
 	}
-	return txn.Set(db.ReceiptsByBlockNumberAndIndex.Key(bnIndexBytes), rBytes)
+	return receiptTable.Put(txn, bnIndex, r)
 }
 
 // transactionBlockNumberAndIndexByHash gets the block number and index for a given transaction hash
 func transactionBlockNumberAndIndexByHash(txn db.Transaction, hash *felt.Felt) (*txAndReceiptDBKey, error) {
-	var bnIndex *txAndReceiptDBKey
-	if err := txn.Get(db.TransactionBlockNumbersAndIndicesByHash.Key(hash.Marshal()), func(val []byte) error {
-		bnIndex = new(txAndReceiptDBKey)
-		return bnIndex.UnmarshalBinary(val)
-	}); err != nil {
-		return nil, err
-	}
-	return bnIndex, nil
+	return transactionNumberTable.Get(txn, hash)
 }
 
 // transactionByBlockNumberAndIndex gets the transaction for a given block number and index.
 func transactionByBlockNumberAndIndex(txn db.Transaction, bnIndex *txAndReceiptDBKey) (core.Transaction, error) {
-	var transaction core.Transaction
-	err := txn.Get(db.TransactionsByBlockNumberAndIndex.Key(bnIndex.MarshalBinary()), func(val []byte) error {
-		return encoder.Unmarshal(val, &transaction)
-	})
-	return transaction, err
+	return transactionTable.Get(txn, bnIndex)
 }
 
 // transactionByHash gets the transaction for a given hash.
@@ -713,7 +676,7 @@ func receiptByHash(txn db.Transaction, hash *felt.Felt) (*core.TransactionReceip
 		return nil, nil, 0, err
 	}
 
-	header, err := blockHeaderByNumber(txn, bnIndex.Number)
+	header, err := BlockHeaderByNumber(txn, bnIndex.Number)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -723,11 +686,7 @@ func receiptByHash(txn db.Transaction, hash *felt.Felt) (*core.TransactionReceip
 
 // receiptByBlockNumberAndIndex gets the transaction receipt for a given block number and index.
 func receiptByBlockNumberAndIndex(txn db.Transaction, bnIndex *txAndReceiptDBKey) (*core.TransactionReceipt, error) {
-	var r *core.TransactionReceipt
-	err := txn.Get(db.ReceiptsByBlockNumberAndIndex.Key(bnIndex.MarshalBinary()), func(val []byte) error {
-		return encoder.Unmarshal(val, &r)
-	})
-	return r, err
+	return receiptTable.Get(txn, bnIndex)
 }
 
 type StateCloser = func() error
@@ -746,7 +705,10 @@ func (b *Blockchain) HeadState() (core.StateReader, StateCloser, error) {
 // StateAtBlockNumber returns a StateReader that provides a stable view to the state at the given block number
 func (b *Blockchain) StateAtBlockNumber(blockNumber uint64) (core.StateReader, StateCloser, error) {
 	txn := b.database.NewTransaction(false)
-	_, err := blockHeaderByNumber(txn, blockNumber)
+	_, err := BlockHeaderByNumber(txn, blockNumber)
+	if err == nil {
+		err = core.CheckStateAt(txn, blockNumber)
+	}
 	if err != nil {
 		return nil, nil, db.CloseAndWrapOnError(txn.Discard, err)
 	}
@@ -764,6 +726,9 @@ func (b *Blockchain) StateAtBlockHash(blockHash *felt.Felt) (core.StateReader, S
 
 	txn := b.database.NewTransaction(false)
 	header, err := blockHeaderByHash(txn, blockHash)
+	if err == nil {
+		err = core.CheckStateAt(txn, header.Number)
+	}
 	if err != nil {
 		return nil, nil, db.CloseAndWrapOnError(txn.Discard, err)
 	}
@@ -771,6 +736,34 @@ func (b *Blockchain) StateAtBlockHash(blockHash *felt.Felt) (core.StateReader, S
 	return core.NewStateSnapshot(core.NewState(txn), header.Number), txn.Discard, nil
 }
 
+// StorageProof returns the proof of the value of key in the storage of the contract at addr in the head state, see
+// core.StorageProof. The proof is a copy of the one that is cached, if any.
+func (b *Blockchain) StorageProof(addr, key *felt.Felt) (*core.StorageProof, error) {
+	var proof *core.StorageProof
+	return proof, b.database.View(func(txn db.Transaction) error {
+		if _, err := chainHeight(txn); err != nil {
+			return err
+		}
+		state := core.NewState(txn)
+		root, err := state.Root()
+		if err != nil {
+			return err
+		}
+		// the proofs are cached by state commitment as well, so that a proof of a state that was replaced before
+		// the cache is cleared is never served
+		cacheKey := proofKey{root: *root, contract: *addr, key: *key}
+		cached, ok := b.proofs.Get(cacheKey)
+		if !ok {
+			if cached, err = state.StorageProof(addr, key); err != nil {
+				return err
+			}
+			b.proofs.Add(cacheKey, cached)
+		}
+		proof = cached.Copy()
+		return nil
+	})
+}
+
 // EventFilter returns an EventFilter object that is tied to a snapshot of the blockchain
 func (b *Blockchain) EventFilter(from *felt.Felt, keys [][]felt.Felt) (*EventFilter, error) {
 	txn := b.database.NewTransaction(false)
@@ -779,12 +772,26 @@ func (b *Blockchain) EventFilter(from *felt.Felt, keys [][]felt.Felt) (*EventFil
 		return nil, err
 	}
 
-	return newEventFilter(txn, from, keys, 0, latest), nil
+	filter := newEventFilter(txn, from, keys, 0, latest)
+	filter.headers, filter.receipts = b.headers, b.receipts
+	return filter, nil
 }
 
 // RevertHead reverts the head block
 func (b *Blockchain) RevertHead() error {
-	return b.database.Update(b.revertHead)
+	var reverted *uint64
+	defer func() {
+		if reverted != nil {
+			b.forgetBlock(*reverted)
+		}
+	}()
+	return b.database.Update(func(txn db.Transaction) error {
+		height, err := chainHeight(txn)
+		if err == nil {
+			reverted = &height
+		}
+		return b.revertHead(txn)
+	})
 }
 
 func (b *Blockchain) revertHead(txn db.Transaction) error {
@@ -792,9 +799,8 @@ func (b *Blockchain) revertHead(txn db.Transaction) error {
 	if err != nil {
 		return err
 	}
-	numBytes := core.MarshalBlockNumber(blockNumber)
 
-	stateUpdate, err := stateUpdateByNumber(txn, blockNumber)
+	stateUpdate, err := StateUpdateByNumber(txn, blockNumber)
 	if err != nil {
 		return err
 	}
@@ -805,7 +811,7 @@ func (b *Blockchain) revertHead(txn db.Transaction) error {
 		return err
 	}
 
-	header, err := blockHeaderByNumber(txn, blockNumber)
+	header, err := BlockHeaderByNumber(txn, blockNumber)
 	if err != nil {
 		return err
 	}
@@ -814,9 +820,9 @@ func (b *Blockchain) revertHead(txn db.Transaction) error {
 
 	// remove block header
 	for _, key := range [][]byte{
-		db.BlockHeadersByNumber.Key(numBytes),
-		db.BlockHeaderNumbersByHash.Key(header.Hash.Marshal()),
-		db.BlockCommitments.Key(numBytes),
+		headerTable.Key(blockNumber),
+		headerNumberTable.Key(header.Hash),
+		commitmentsTable.Key(blockNumber),
 	} {
 		if err = txn.Delete(key); err != nil {
 			return err
@@ -824,7 +830,7 @@ func (b *Blockchain) revertHead(txn db.Transaction) error {
 	}
 	if !genesisBlock {
 		var newHeader *core.Header
-		newHeader, err = blockHeaderByNumber(txn, blockNumber-1)
+		newHeader, err = BlockHeaderByNumber(txn, blockNumber-1)
 		if err != nil {
 			return err
 		}
@@ -836,22 +842,20 @@ func (b *Blockchain) revertHead(txn db.Transaction) error {
 	}
 
 	// remove state update
-	if err = txn.Delete(db.StateUpdatesByBlockNumber.Key(numBytes)); err != nil {
+	if err = stateUpdateTable.Delete(txn, blockNumber); err != nil {
 		return err
 	}
 
 	// remove pending
-	if err = txn.Delete(db.Pending.Key()); err != nil {
+	if err = pendingValue.Delete(txn); err != nil {
 		return err
 	}
 
 	// update chain height
 	if genesisBlock {
-		return txn.Delete(db.ChainHeight.Key())
+		return chainHeightValue.Delete(txn)
 	}
-
-	heightBin := core.MarshalBlockNumber(blockNumber - 1)
-	return txn.Set(db.ChainHeight.Key(), heightBin)
+	return chainHeightValue.Put(txn, blockNumber-1)
 }
 
 func removeTxsAndReceipts(txn db.Transaction, blockNumber, numTxs uint64) error {
@@ -866,14 +870,13 @@ func removeTxsAndReceipts(txn db.Transaction, blockNumber, numTxs uint64) error
 			return err
 		}
 
-		keySuffix := blockIDAndIndex.MarshalBinary()
-		if err = txn.Delete(db.TransactionsByBlockNumberAndIndex.Key(keySuffix)); err != nil {
+		if err = transactionTable.Delete(txn, &blockIDAndIndex); err != nil {
 			return err
 		}
-		if err = txn.Delete(db.ReceiptsByBlockNumberAndIndex.Key(keySuffix)); err != nil {
+		if err = receiptTable.Delete(txn, &blockIDAndIndex); err != nil {
 			return err
 		}
-		if err = txn.Delete(db.TransactionBlockNumbersAndIndicesByHash.Key(reorgedTxn.Hash().Marshal())); err != nil {
+		if err = transactionNumberTable.Delete(txn, reorgedTxn.Hash()); err != nil {
 			return err
 		}
 	}
@@ -903,20 +906,12 @@ func (b *Blockchain) StorePending(pending *Pending) error {
 			return nil // ignore the incoming pending if it has fewer transactions than the one we already have
 		}
 
-		pendingBytes, err := encoder.Marshal(pending)
-		if err != nil {
-			return err
-		}
-		return txn.Set(db.Pending.Key(), pendingBytes)
+		return pendingValue.Put(txn, *pending)
 	})
 }
 
 func pendingBlock(txn db.Transaction) (Pending, error) {
-	var pending Pending
-	err := txn.Get(db.Pending.Key(), func(bytes []byte) error {
-		return encoder.Unmarshal(bytes, &pending)
-	})
-	return pending, err
+	return pendingValue.Get(txn)
 }
 
 // Pending returns the pending block from the database
@@ -12,9 +12,13 @@ import (
 	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/db/pebble"
 	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/tracing"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 var emptyCommitments = core.BlockCommitments{}
@@ -266,6 +270,26 @@ func TestStore(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, stateUpdate1, got1Update)
 	})
+	t.Run("trace the store", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, log)
+		ctx, parent := tracing.StartSpan(context.Background(), "store")
+		require.NoError(t, chain.StoreContext(ctx, block0, &emptyCommitments, stateUpdate0, nil))
+		tracing.EndSpan(parent, nil)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 3)
+		assert.Equal(t, "trie.commit", spans[0].Name())
+		assert.Equal(t, "db.commit", spans[1].Name())
+		for _, span := range spans[:2] {
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Contains(t, span.Attributes(), tracing.BlockNumber(0))
+		}
+	})
 }
 
 func TestTransactionAndReceipt(t *testing.T) {
@@ -505,6 +529,14 @@ func TestEvents(t *testing.T) {
 			}
 		})
 
+		t.Run("continue with an event", func(t *testing.T) {
+			for i, event := range allEvents {
+				events, _, err := filter.Events(blockchain.NewContinuationToken(event.BlockNumber, event.EventIndex), 10)
+				require.NoError(t, err)
+				assert.Equal(t, allEvents[i:], events)
+			}
+		})
+
 		require.NoError(t, filter.Close())
 	})
 
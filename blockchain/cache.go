@@ -0,0 +1,124 @@
+package blockchain
+
+import (
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/memory"
+)
+
+// The names of the caches of the blockchain in a memory budget
+const (
+	HeaderCacheName = "header-cache"
+	EventCacheName  = "event-cache"
+	ProofCacheName  = "proof-cache"
+)
+
+// proofKey is the state commitment a storage proof is of, and the contract and the key of the storage it proves
+type proofKey struct {
+	root, contract, key felt.Felt
+}
+
+type (
+	HeaderCache  = memory.Cache[uint64, *core.Header]
+	ReceiptCache = memory.Cache[uint64, []*core.TransactionReceipt]
+	ProofCache   = memory.Cache[proofKey, *core.StorageProof]
+)
+
+// NewHeaderCache returns an empty cache of block headers by number, it must be resized to hold anything
+func NewHeaderCache() *HeaderCache {
+	return memory.NewCache[uint64, *core.Header](0, headerSize)
+}
+
+// NewReceiptCache returns an empty cache of the receipts of blocks by number, event filters read the events
+// of the blocks whose bloom filter matches from it
+func NewReceiptCache() *ReceiptCache {
+	return memory.NewCache[uint64, []*core.TransactionReceipt](0, receiptsSize)
+}
+
+// NewProofCache returns an empty cache of the storage proofs of the head state, it must be resized to hold anything
+func NewProofCache() *ProofCache {
+	return memory.NewCache[proofKey, *core.StorageProof](0, proofSize)
+}
+
+// WithProofCache makes the blockchain serve the storage proofs that are requested again before the head changes
+// from memory, since the clients that prove the storage of a contract, such as bridges, request the same proofs
+// repeatedly and a proof reads the nodes along two paths of the tries
+func (b *Blockchain) WithProofCache(proofs *ProofCache) *Blockchain {
+	b.proofs = proofs
+	return b
+}
+
+// WithCaches makes the blockchain serve block headers and the receipts read by event filters from memory
+func (b *Blockchain) WithCaches(headers *HeaderCache, receipts *ReceiptCache) *Blockchain {
+	b.headers = headers
+	b.receipts = receipts
+	return b
+}
+
+// forgetBlock drops the cached data of a block that was stored or reverted
+func (b *Blockchain) forgetBlock(number uint64) {
+	b.headers.Remove(number)
+	b.receipts.Remove(number)
+	// the proofs are of the head state, which changed
+	b.proofs.Clear()
+}
+
+func cachedBlockHeaderByNumber(headers *HeaderCache, txn db.Transaction, number uint64) (*core.Header, error) {
+	if header, ok := headers.Get(number); ok {
+		return header, nil
+	}
+	header, err := BlockHeaderByNumber(txn, number)
+	if err != nil {
+		return nil, err
+	}
+	headers.Add(number, header)
+	return header, nil
+}
+
+func cachedReceiptsByBlockNumber(receipts *ReceiptCache, txn db.Transaction, number uint64) ([]*core.TransactionReceipt, error) {
+	if blockReceipts, ok := receipts.Get(number); ok {
+		return blockReceipts, nil
+	}
+	blockReceipts, err := receiptsByBlockNumber(txn, number)
+	if err != nil {
+		return nil, err
+	}
+	core.InternEvents(blockReceipts)
+	receipts.Add(number, blockReceipts)
+	return blockReceipts, nil
+}
+
+// The sizes below are estimates of the memory held by the decoded values, fixed overheads are rounded up.
+const (
+	headerOverhead    = 512
+	receiptOverhead   = 256
+	eventOverhead     = 64
+	proofOverhead     = 256
+	proofNodeOverhead = 48
+)
+
+func headerSize(header *core.Header) uint64 {
+	size := uint64(headerOverhead + len(header.ProtocolVersion))
+	if header.EventsBloom != nil {
+		size += uint64(header.EventsBloom.Cap() / 8)
+	}
+	return size
+}
+
+func receiptsSize(receipts []*core.TransactionReceipt) uint64 {
+	var size uint64
+	for _, receipt := range receipts {
+		size += receiptOverhead + uint64(len(receipt.RevertReason))
+		for _, event := range receipt.Events {
+			size += eventOverhead + uint64(len(event.Keys)+len(event.Data))*felt.Bytes
+		}
+	}
+	return size
+}
+
+func proofSize(proof *core.StorageProof) uint64 {
+	// a node holds two felts, a binary node its two children and an edge node its child and its path
+	nodes := uint64(len(proof.ContractProof) + len(proof.StorageProof))
+	return proofOverhead + nodes*(proofNodeOverhead+2*felt.Bytes)
+}
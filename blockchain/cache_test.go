@@ -0,0 +1,105 @@
+package blockchain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaches(t *testing.T) {
+	headers, receipts := blockchain.NewHeaderCache(), blockchain.NewReceiptCache()
+	headers.Resize(1 << 20)
+	receipts.Resize(1 << 20)
+	chain := blockchain.New(pebble.NewMemTest(), utils.GOERLI2, utils.NewNopZapLogger()).WithCaches(headers, receipts)
+
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+	for i := uint64(0); i < 6; i++ {
+		b, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		su, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(b, &emptyCommitments, su, nil))
+	}
+	assert.Zero(t, headers.Usage())
+
+	filter, err := chain.EventFilter(nil, nil)
+	require.NoError(t, err)
+	events, _, err := filter.Events(nil, 100)
+	require.NoError(t, err)
+	require.NoError(t, filter.Close())
+	assert.NotEmpty(t, events)
+	assert.NotZero(t, headers.Usage())
+	assert.NotZero(t, receipts.Usage())
+
+	header, err := chain.BlockHeaderByNumber(5)
+	require.NoError(t, err)
+	hits, _ := headers.Stats()
+	assert.Equal(t, uint64(1), hits)
+
+	require.NoError(t, chain.RevertHead())
+
+	_, err = chain.BlockHeaderByNumber(5)
+	require.Error(t, err, "reverted header must not be served from the cache")
+	head, err := chain.HeadsHeader()
+	require.NoError(t, err)
+	assert.Equal(t, header.ParentHash, head.Hash)
+}
+
+func TestProofCache(t *testing.T) {
+	proofs := blockchain.NewProofCache()
+	proofs.Resize(1 << 20)
+	chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, utils.NewNopZapLogger()).WithProofCache(proofs)
+	addr := utils.HexToFelt(t, "0x20cfa74ee3564b4cd5435cdace0f9c4d43b939620e4a0bb5076105df0a626c6")
+	key := utils.HexToFelt(t, "0x5")
+
+	_, err := chain.StorageProof(addr, key)
+	require.ErrorIs(t, err, db.ErrKeyNotFound, "there is no head state")
+
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	store := func(number uint64) *core.StateUpdate {
+		b, err := gw.BlockByNumber(context.Background(), number)
+		require.NoError(t, err)
+		su, err := gw.StateUpdate(context.Background(), number)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(b, &emptyCommitments, su, nil))
+		return su
+	}
+	su0 := store(0)
+
+	proof, err := chain.StorageProof(addr, key)
+	require.NoError(t, err)
+	assert.True(t, proof.Verify(su0.NewRoot, addr, key, utils.HexToFelt(t, "0x22b")))
+	assert.NotZero(t, proofs.Usage())
+
+	// the proof is served from the cache, and the cached proof is not modified by those it is returned to
+	proof.StateTrieRoot.SetUint64(1)
+	cached, err := chain.StorageProof(addr, key)
+	require.NoError(t, err)
+	hits, _ := proofs.Stats()
+	assert.Equal(t, uint64(1), hits)
+	assert.True(t, cached.Verify(su0.NewRoot, addr, key, utils.HexToFelt(t, "0x22b")))
+
+	// the proofs of the former head state are dropped
+	su1 := store(1)
+	assert.Zero(t, proofs.Usage())
+	proof, err = chain.StorageProof(addr, key)
+	require.NoError(t, err)
+	assert.True(t, proof.Verify(su1.NewRoot, addr, key, utils.HexToFelt(t, "0x22b")))
+	hits, _ = proofs.Stats()
+	assert.Equal(t, uint64(1), hits)
+
+	require.NoError(t, chain.RevertHead())
+	assert.Zero(t, proofs.Usage())
+	proof, err = chain.StorageProof(addr, key)
+	require.NoError(t, err)
+	assert.True(t, proof.Verify(su0.NewRoot, addr, key, utils.HexToFelt(t, "0x22b")))
+}
@@ -19,6 +19,9 @@ type EventFilter struct {
 	toBlock         uint64
 	contractAddress *felt.Felt
 	keys            [][]felt.Felt
+
+	headers  *HeaderCache
+	receipts *ReceiptCache
 }
 
 type EventFilterRange uint
@@ -59,6 +62,16 @@ func (e *EventFilter) SetRangeEndBlockByHash(filterRange EventFilterRange, block
 	return e.SetRangeEndBlockByNumber(filterRange, header.Number)
 }
 
+// HeadsHeader returns the header of the head block of the snapshot the filter reads
+func (e *EventFilter) HeadsHeader() (*core.Header, error) {
+	return cachedHeadsHeader(e.headers, e.txn)
+}
+
+// BlockHeaderByNumber returns the header of the block with number in the snapshot the filter reads
+func (e *EventFilter) BlockHeaderByNumber(number uint64) (*core.Header, error) {
+	return cachedBlockHeaderByNumber(e.headers, e.txn, number)
+}
+
 // Close closes the underlying database transaction that provides the blockchain snapshot
 func (e *EventFilter) Close() error {
 	return e.txn.Discard()
@@ -69,6 +82,22 @@ type ContinuationToken struct {
 	processedEvents uint64
 }
 
+// NewContinuationToken returns the token that continues with the events of block, after the first processedEvents
+// of its events
+func NewContinuationToken(block, processedEvents uint64) *ContinuationToken {
+	return &ContinuationToken{fromBlock: block, processedEvents: processedEvents}
+}
+
+// Block returns the block the token continues with
+func (c *ContinuationToken) Block() uint64 {
+	return c.fromBlock
+}
+
+// ProcessedEvents returns the number of events of Block that were processed
+func (c *ContinuationToken) ProcessedEvents() uint64 {
+	return c.processedEvents
+}
+
 func (c *ContinuationToken) String() string {
 	return fmt.Sprintf("%d-%d", c.fromBlock, c.processedEvents)
 }
@@ -83,6 +112,9 @@ type FilteredEvent struct {
 	BlockNumber     uint64
 	BlockHash       *felt.Felt
 	TransactionHash *felt.Felt
+	// EventIndex is the index of the event among the events of its block, the continuation token of the block
+	// and EventIndex continues with the event
+	EventIndex uint64
 }
 
 func (e *EventFilter) Events(cToken *ContinuationToken, chunkSize uint64) ([]*FilteredEvent, *ContinuationToken, error) {
@@ -104,6 +136,10 @@ func (e *EventFilter) Events(cToken *ContinuationToken, chunkSize uint64) ([]*Fi
 	}
 
 	filterKeysMaps := makeKeysMaps(e.keys)
+	indexed, err := canonicalIndexProgress(e.txn, EventsIndexName)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	curBlock := e.fromBlock
 	// skip the blocks that we previously processed for this request
@@ -112,9 +148,19 @@ func (e *EventFilter) Events(cToken *ContinuationToken, chunkSize uint64) ([]*Fi
 	}
 
 	for ; curBlock <= e.toBlock; curBlock++ {
+		if e.contractAddress != nil && curBlock < indexed {
+			// the events index lists the blocks with events of the contract
+			if curBlock, err = e.nextIndexedBlock(curBlock, indexed); err != nil {
+				return nil, nil, err
+			}
+			if curBlock > e.toBlock {
+				break
+			}
+		}
+
 		var header *core.Header
 		if curBlock != latest+1 {
-			header, err = blockHeaderByNumber(e.txn, curBlock)
+			header, err = cachedBlockHeaderByNumber(e.headers, e.txn, curBlock)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -129,7 +175,7 @@ func (e *EventFilter) Events(cToken *ContinuationToken, chunkSize uint64) ([]*Fi
 
 		var receipts []*core.TransactionReceipt
 		if curBlock != latest+1 {
-			receipts, err = receiptsByBlockNumber(e.txn, header.Number)
+			receipts, err = cachedReceiptsByBlockNumber(e.receipts, e.txn, header.Number)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -152,6 +198,19 @@ func (e *EventFilter) Events(cToken *ContinuationToken, chunkSize uint64) ([]*Fi
 	return matchedEvents, nil, nil
 }
 
+// nextIndexedBlock returns the first block from curBlock with events of the contract of the filter, or indexed
+// if the events index lists none of the blocks it covers
+func (e *EventFilter) nextIndexedBlock(curBlock, indexed uint64) (uint64, error) {
+	next, found, err := nextEventBlock(e.txn, e.contractAddress, curBlock)
+	if err != nil {
+		return 0, err
+	}
+	if !found || next > indexed {
+		return indexed, nil
+	}
+	return next, nil
+}
+
 func (e *EventFilter) testBloom(bloomFilter *bloom.BloomFilter, keysMap []map[felt.Felt]struct{}) bool {
 	possibleMatches := true
 	if e.contractAddress != nil {
@@ -209,7 +268,9 @@ func (e *EventFilter) appendBlockEvents(matchedEventsSofar []*FilteredEvent, hea
 						BlockNumber:     header.Number,
 						BlockHash:       header.Hash,
 						TransactionHash: receipt.TransactionHash,
-						Event:           event,
+						// the cached events share interned felts, see core.InternEvents
+						Event:      event.Copy(),
+						EventIndex: processedEvents,
 					})
 				} else {
 					// we are at the capacity, return what we have accumulated so far and a continuation token
@@ -0,0 +1,46 @@
+package blockchain
+
+import (
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+)
+
+const (
+	// SubmittedRetention is how long a submitted transaction is tracked, the feeder knows its status afterwards
+	SubmittedRetention = 24 * time.Hour
+	// PendingRetention is how long after its timestamp a pending block is kept, the node stopped syncing if it was
+	// not replaced by then
+	PendingRetention = time.Hour
+)
+
+func init() {
+	db.RegisterExpiry(db.SubmittedTransactions, submittedExpiry)
+	db.RegisterExpiry(db.Pending, pendingExpiry)
+}
+
+// submittedExpiry returns when a submitted transaction is no longer tracked, the transaction itself is not decoded
+func submittedExpiry(_, value []byte) (time.Time, error) {
+	var submitted struct {
+		SubmittedAt uint64
+	}
+	if err := encoder.Unmarshal(value, &submitted); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(submitted.SubmittedAt), 0).Add(SubmittedRetention), nil
+}
+
+// pendingExpiry returns when the pending block is stale, the block itself is not decoded
+func pendingExpiry(_, value []byte) (time.Time, error) {
+	// the fields of the header are those of the block, which embeds it
+	var pending struct {
+		Block struct {
+			Timestamp uint64
+		}
+	}
+	if err := encoder.Unmarshal(value, &pending); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(pending.Block.Timestamp), 0).Add(PendingRetention), nil
+}
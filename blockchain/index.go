@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+)
+
+// Index is an optional index over the blocks of the chain. Optional indexes are not written when blocks are
+// stored, they are built in the background from the stored blocks, so enabling one on a synced node does not
+// delay it.
+type Index interface {
+	// Name identifies the index in the progress of its backfill, it must not change
+	Name() string
+	// Bucket holds the entries of the index and nothing else, so that the index can be dropped
+	Bucket() db.Bucket
+	// Keys returns the keys that index block, they are in Bucket and have no values
+	Keys(block *core.Block) [][]byte
+}
+
+// indexProgressSize is the size of the progress of an index: the number of blocks it covers and the hash of
+// the last of them
+const indexProgressSize = 8 + felt.Bytes
+
+// IndexProgress returns the number of blocks from genesis an index covers and the hash of the last of them,
+// which is nil if the index covers no block. The blocks covered by an index are those it was built from, they
+// may since have been reverted by a reorg.
+func IndexProgress(txn db.Transaction, name string) (uint64, *felt.Felt, error) {
+	var (
+		indexed uint64
+		hash    *felt.Felt
+	)
+	err := txn.Get(db.IndexProgress.Key([]byte(name)), func(val []byte) error {
+		if len(val) != indexProgressSize {
+			return errors.New("malformed index progress")
+		}
+		indexed = binary.BigEndian.Uint64(val)
+		hash = new(felt.Felt).SetBytes(val[8:])
+		return nil
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil, nil
+	}
+	return indexed, hash, err
+}
+
+// SetIndexProgress records that an index covers indexed blocks from genesis, the last of which has hash
+func SetIndexProgress(txn db.Transaction, name string, indexed uint64, hash *felt.Felt) error {
+	if indexed == 0 {
+		return txn.Delete(db.IndexProgress.Key([]byte(name)))
+	}
+	hashBytes := hash.Bytes()
+	return txn.Set(db.IndexProgress.Key([]byte(name)), append(binary.BigEndian.AppendUint64(nil, indexed), hashBytes[:]...))
+}
+
+// canonicalIndexProgress returns the number of blocks an index covers that are still part of the chain. A reorg
+// reverts blocks from the head, so the blocks an index covers are all part of the chain if the last one is.
+func canonicalIndexProgress(txn db.Transaction, name string) (uint64, error) {
+	indexed, hash, err := IndexProgress(txn, name)
+	if err != nil || indexed == 0 {
+		return 0, err
+	}
+
+	header, err := BlockHeaderByNumber(txn, indexed-1)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if !header.Hash.Equal(hash) {
+		return 0, nil
+	}
+	return indexed, nil
+}
+
+// EventsIndexName is the name of the index of the blocks with events emitted by a contract
+const EventsIndexName = "events"
+
+var _ Index = EventsIndex{}
+
+// EventsIndex indexes the blocks with events emitted by a contract, event filters that match a contract
+// address skip the blocks it does not list instead of testing their bloom filter
+type EventsIndex struct{}
+
+// Name : see Index.Name
+func (EventsIndex) Name() string {
+	return EventsIndexName
+}
+
+// Bucket : see Index.Bucket
+func (EventsIndex) Bucket() db.Bucket {
+	return db.EventBlocksByAddress
+}
+
+// Keys : see Index.Keys
+func (EventsIndex) Keys(block *core.Block) [][]byte {
+	numberBytes := binary.BigEndian.AppendUint64(nil, block.Number)
+	var keys [][]byte
+	seen := make(map[felt.Felt]struct{})
+	for _, receipt := range block.Receipts {
+		for _, event := range receipt.Events {
+			if _, ok := seen[*event.From]; ok {
+				continue
+			}
+			seen[*event.From] = struct{}{}
+			keys = append(keys, db.EventBlocksByAddress.Key(event.From.Marshal(), numberBytes))
+		}
+	}
+	return keys
+}
+
+// nextEventBlock returns the first block from fromBlock with events emitted by address, according to the events
+// index. found is false if the index lists no such block.
+func nextEventBlock(txn db.Transaction, address *felt.Felt, fromBlock uint64) (uint64, bool, error) {
+	it, err := txn.NewIterator()
+	if err != nil {
+		return 0, false, err
+	}
+
+	prefix := db.EventBlocksByAddress.Key(address.Marshal())
+	var (
+		number uint64
+		found  bool
+	)
+	if it.Seek(db.EventBlocksByAddress.Key(address.Marshal(), binary.BigEndian.AppendUint64(nil, fromBlock))) {
+		if key := it.Key(); len(key) == len(prefix)+8 && bytes.HasPrefix(key, prefix) {
+			number, found = binary.BigEndian.Uint64(key[len(prefix):]), true
+		}
+	}
+	return number, found, it.Close()
+}
@@ -0,0 +1,165 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+)
+
+// commitJournal is the block Store is committing. It is stored before the block and deleted together with its
+// commit, so that a block whose commit was interrupted, and which may be partially applied if the database does not
+// commit atomically, is found and rolled back at startup.
+type commitJournal struct {
+	Number            uint64
+	Hash              *felt.Felt
+	TransactionHashes []*felt.Felt
+	StateUpdate       *core.StateUpdate
+}
+
+// CommitRecovery is the block whose commit was interrupted, RolledBack reports whether part of it had been applied
+// and was rolled back
+type CommitRecovery struct {
+	Number     uint64
+	Hash       *felt.Felt
+	RolledBack bool
+}
+
+func writeCommitJournal(database db.DB, block *core.Block, stateUpdate *core.StateUpdate) error {
+	journal := &commitJournal{
+		Number:            block.Number,
+		Hash:              block.Hash,
+		TransactionHashes: make([]*felt.Felt, len(block.Transactions)),
+		StateUpdate:       stateUpdate,
+	}
+	for i, tx := range block.Transactions {
+		journal.TransactionHashes[i] = tx.Hash()
+	}
+	return database.Update(func(txn db.Transaction) error {
+		return commitJournalValue.Put(txn, journal)
+	})
+}
+
+func deleteCommitJournal(database db.DB) error {
+	return database.Update(commitJournalValue.Delete)
+}
+
+// RecoverInterruptedCommit rolls back the block whose commit was interrupted by a crash, if any, so that the chain
+// and the state are both at its parent and the block is synced again. It returns nil if no commit was interrupted,
+// which is checked without a write transaction so that read-only databases start.
+func (b *Blockchain) RecoverInterruptedCommit() (*CommitRecovery, error) {
+	var interrupted bool
+	if err := b.database.View(func(txn db.Transaction) error {
+		err := txn.Get(commitJournalValue.Key(), func([]byte) error { return nil })
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil
+		}
+		interrupted = err == nil
+		return err
+	}); err != nil || !interrupted {
+		return nil, err
+	}
+
+	var recovery *CommitRecovery
+	err := b.database.Update(func(txn db.Transaction) error {
+		journal, err := commitJournalValue.Get(txn)
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		recovery = &CommitRecovery{Number: journal.Number, Hash: journal.Hash}
+		if recovery.RolledBack, err = rollBackCommit(txn, journal); err != nil {
+			return fmt.Errorf("roll back block %d: %w", journal.Number, err)
+		}
+		return commitJournalValue.Delete(txn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if recovery != nil && recovery.RolledBack {
+		b.forgetBlock(recovery.Number)
+	}
+	return recovery, nil
+}
+
+// rollBackCommit rolls back the parts of the block of journal that were committed, and reports whether there were
+// any. The block is stored if the chain height is its number, and the tries are updated if the state root is its
+// new root.
+func rollBackCommit(txn db.Transaction, journal *commitJournal) (bool, error) {
+	height, err := chainHeight(txn)
+	stored := err == nil && height == journal.Number
+	if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+		return false, err
+	}
+	root, err := core.NewState(txn).Root()
+	if err != nil {
+		return false, err
+	}
+	update := journal.StateUpdate
+
+	switch {
+	case stored && root.Equal(update.NewRoot):
+		// the commit completed
+		return false, nil
+	case !stored && root.Equal(update.OldRoot):
+		// nothing was committed
+		return false, nil
+	case stored && root.Equal(update.OldRoot):
+		// the block was stored without the tries
+		return true, removeJournaledBlock(txn, journal)
+	case !stored && root.Equal(update.NewRoot):
+		// the tries were updated without the block
+		if err = core.NewState(txn).Revert(journal.Number, update); err != nil {
+			return false, err
+		}
+		// the history the tries are reverted with is committed with them
+		if root, err = core.NewState(txn).Root(); err != nil {
+			return false, err
+		} else if !root.Equal(update.OldRoot) {
+			return false, fmt.Errorf("state root %s is not the old root %s of the block once it is reverted", root,
+				update.OldRoot)
+		}
+		return true, removeJournaledBlock(txn, journal)
+	default:
+		return false, fmt.Errorf("state root %s is neither the old root %s nor the new root %s of the block",
+			root, update.OldRoot, update.NewRoot)
+	}
+}
+
+// removeJournaledBlock removes whatever was stored of the block of journal and sets the chain height back to its
+// parent. The state is not changed.
+func removeJournaledBlock(txn db.Transaction, journal *commitJournal) error {
+	keys := [][]byte{
+		headerTable.Key(journal.Number),
+		headerNumberTable.Key(journal.Hash),
+		commitmentsTable.Key(journal.Number),
+		stateUpdateTable.Key(journal.Number),
+		pendingValue.Key(),
+	}
+	for i, hash := range journal.TransactionHashes {
+		key := txAndReceiptDBKey{Number: journal.Number, Index: uint64(i)}
+		keys = append(keys, transactionTable.Key(&key), receiptTable.Key(&key))
+
+		// the hash may be indexed to an earlier transaction with the same hash
+		indexed, err := transactionBlockNumberAndIndexByHash(txn, hash)
+		if err == nil && *indexed == key {
+			keys = append(keys, transactionNumberTable.Key(hash))
+		} else if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	if journal.Number == 0 {
+		return chainHeightValue.Delete(txn)
+	}
+	return chainHeightValue.Put(txn, journal.Number-1)
+}
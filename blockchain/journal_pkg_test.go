@@ -0,0 +1,201 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/split"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverInterruptedCommit(t *testing.T) {
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	block0, err := gw.BlockByNumber(context.Background(), 0)
+	require.NoError(t, err)
+	stateUpdate0, err := gw.StateUpdate(context.Background(), 0)
+	require.NoError(t, err)
+	block1, err := gw.BlockByNumber(context.Background(), 1)
+	require.NoError(t, err)
+	stateUpdate1, err := gw.StateUpdate(context.Background(), 1)
+	require.NoError(t, err)
+
+	// newChain returns a chain with block 0 whose commit of block 1 was interrupted after commit was applied
+	newChain := func(t *testing.T, commit func(txn db.Transaction) error) *Blockchain {
+		testDB := pebble.NewMemTest()
+		t.Cleanup(func() {
+			require.NoError(t, testDB.Close())
+		})
+		chain := New(testDB, utils.MAINNET, utils.NewNopZapLogger())
+		require.NoError(t, chain.Store(block0, &core.BlockCommitments{}, stateUpdate0, nil))
+		require.NoError(t, writeCommitJournal(testDB, block1, stateUpdate1))
+		require.NoError(t, testDB.Update(commit))
+		return chain
+	}
+	storeBlock := func(txn db.Transaction) error {
+		if err := StoreBlockHeader(txn, block1.Header); err != nil {
+			return err
+		}
+		for i, tx := range block1.Transactions {
+			if err := storeTransactionAndReceipt(txn, 1, uint64(i), tx, block1.Receipts[i]); err != nil {
+				return err
+			}
+		}
+		if err := storeStateUpdate(txn, 1, stateUpdate1); err != nil {
+			return err
+		}
+		return txn.Set(db.ChainHeight.Key(), core.MarshalBlockNumber(1))
+	}
+	updateTries := func(txn db.Transaction) error {
+		return core.NewState(txn).Update(1, stateUpdate1, nil)
+	}
+	// assertRecovered asserts that the chain is back at block 0 and that block 1 can be stored again
+	assertRecovered := func(t *testing.T, chain *Blockchain) {
+		height, err := chain.Height()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), height)
+		root, err := chain.StateCommitment()
+		require.NoError(t, err)
+		assert.Equal(t, stateUpdate0.NewRoot, root)
+		_, err = chain.BlockByHash(block1.Hash)
+		require.ErrorIs(t, err, db.ErrKeyNotFound)
+		_, err = chain.TransactionByHash(block1.Transactions[0].Hash())
+		require.ErrorIs(t, err, db.ErrKeyNotFound)
+
+		require.NoError(t, chain.Store(block1, &core.BlockCommitments{}, stateUpdate1, nil))
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Nil(t, recovery)
+	}
+
+	t.Run("no interrupted commit", func(t *testing.T) {
+		chain := newChain(t, func(txn db.Transaction) error {
+			return txn.Delete(db.CommitJournal.Key())
+		})
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Nil(t, recovery)
+
+		// the database of a replica is read-only
+		recovery, err = New(readOnlyDB{chain.database}, utils.MAINNET, utils.NewNopZapLogger()).
+			RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Nil(t, recovery)
+	})
+
+	t.Run("nothing committed", func(t *testing.T) {
+		chain := newChain(t, func(db.Transaction) error { return nil })
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Equal(t, &CommitRecovery{Number: 1, Hash: block1.Hash}, recovery)
+		assertRecovered(t, chain)
+	})
+
+	t.Run("block stored without the tries", func(t *testing.T) {
+		chain := newChain(t, storeBlock)
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Equal(t, &CommitRecovery{Number: 1, Hash: block1.Hash, RolledBack: true}, recovery)
+		assertRecovered(t, chain)
+	})
+
+	t.Run("tries updated without the block", func(t *testing.T) {
+		chain := newChain(t, updateTries)
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Equal(t, &CommitRecovery{Number: 1, Hash: block1.Hash, RolledBack: true}, recovery)
+		assertRecovered(t, chain)
+	})
+
+	t.Run("state committed without the chain of a split database", func(t *testing.T) {
+		// the commits of a split database are not atomic across its stores, the chain store fails to commit as if
+		// the node crashed once the state store committed
+		interrupted := false
+		splitDB := split.New(interruptibleDB{DB: pebble.NewMemTest(), interrupted: &interrupted}, pebble.NewMemTest())
+		t.Cleanup(func() {
+			require.NoError(t, splitDB.Close())
+		})
+		chain := New(splitDB, utils.MAINNET, utils.NewNopZapLogger())
+		require.NoError(t, chain.Store(block0, &core.BlockCommitments{}, stateUpdate0, nil))
+		require.NoError(t, writeCommitJournal(splitDB, block1, stateUpdate1))
+		interrupted = true
+		require.ErrorIs(t, splitDB.Update(func(txn db.Transaction) error {
+			if err := updateTries(txn); err != nil {
+				return err
+			}
+			return storeBlock(txn)
+		}), errInterrupted)
+		interrupted = false
+
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Equal(t, &CommitRecovery{Number: 1, Hash: block1.Hash, RolledBack: true}, recovery)
+		assertRecovered(t, chain)
+	})
+
+	t.Run("commit completed", func(t *testing.T) {
+		chain := newChain(t, func(txn db.Transaction) error {
+			if err := updateTries(txn); err != nil {
+				return err
+			}
+			return storeBlock(txn)
+		})
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Equal(t, &CommitRecovery{Number: 1, Hash: block1.Hash}, recovery)
+
+		head, err := chain.Head()
+		require.NoError(t, err)
+		assert.Equal(t, block1.Hash, head.Hash)
+	})
+
+	t.Run("failed store leaves no journal", func(t *testing.T) {
+		chain := newChain(t, func(txn db.Transaction) error {
+			return txn.Delete(db.CommitJournal.Key())
+		})
+		require.Error(t, chain.Store(block0, &core.BlockCommitments{}, stateUpdate0, nil))
+		recovery, err := chain.RecoverInterruptedCommit()
+		require.NoError(t, err)
+		assert.Nil(t, recovery)
+	})
+}
+
+var errInterrupted = errors.New("interrupted commit")
+
+// interruptibleDB fails the commits of the write transactions while interrupted is set
+type interruptibleDB struct {
+	db.DB
+	interrupted *bool
+}
+
+func (d interruptibleDB) NewTransaction(update bool) db.Transaction {
+	txn := d.DB.NewTransaction(update)
+	if update && *d.interrupted {
+		return interruptedTransaction{txn}
+	}
+	return txn
+}
+
+type interruptedTransaction struct {
+	db.Transaction
+}
+
+func (t interruptedTransaction) Commit() error {
+	return errors.Join(errInterrupted, t.Discard())
+}
+
+// readOnlyDB fails the write transactions
+type readOnlyDB struct {
+	db.DB
+}
+
+func (readOnlyDB) Update(func(db.Transaction) error) error {
+	return errors.New("read-only database")
+}
@@ -1,6 +1,8 @@
 package blockchain
 
 import (
+	"errors"
+
 	"github.com/NethermindEth/juno/core"
 	"github.com/NethermindEth/juno/core/felt"
 )
@@ -59,6 +61,40 @@ func (p *PendingState) ContractStorage(addr, key *felt.Felt) (*felt.Felt, error)
 	return p.head.ContractStorage(addr, key)
 }
 
+// ContractStorageIterator iterates over the locations of the storage of the head state and the locations the
+// pending block changes
+func (p *PendingState) ContractStorageIterator(addr, start *felt.Felt) (core.StorageIterator, error) {
+	var changed []*felt.Felt
+	for _, diff := range p.pending.StateUpdate.StateDiff.StorageDiffs[*addr] {
+		if diff.Key.Cmp(start) >= 0 {
+			changed = append(changed, diff.Key)
+		}
+	}
+
+	var head core.KeyIterator
+	head, err := p.head.ContractStorageIterator(addr, start)
+	if err != nil {
+		if !errors.Is(err, core.ErrContractNotDeployed) || !p.deploys(addr) {
+			return nil, err
+		}
+		// the contract is deployed by the pending block, so the head state has no storage for it
+		head = core.NewSliceKeyIterator(nil)
+	}
+	return core.NewOverlayStorageIterator(head, core.NewSliceKeyIterator(changed), func(location *felt.Felt) (*felt.Felt, error) {
+		return p.ContractStorage(addr, location)
+	}), nil
+}
+
+// deploys reports whether the pending block deploys the contract at addr
+func (p *PendingState) deploys(addr *felt.Felt) bool {
+	for _, deployed := range p.pending.StateUpdate.StateDiff.DeployedContracts {
+		if deployed.Address.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *PendingState) Class(classHash *felt.Felt) (*core.DeclaredClass, error) {
 	if class, found := p.pending.NewClasses[*classHash]; found {
 		return &core.DeclaredClass{
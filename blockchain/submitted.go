@@ -0,0 +1,31 @@
+package blockchain
+
+import (
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+)
+
+// SubmittedTransaction is a transaction that was relayed to the gateway through this node
+type SubmittedTransaction struct {
+	Transaction core.Transaction
+	SubmittedAt uint64 // unix timestamp
+}
+
+// StoreSubmittedTransaction persists a submitted transaction so that its status can be tracked
+// until it is included in a block
+func (b *Blockchain) StoreSubmittedTransaction(submitted *SubmittedTransaction) error {
+	return b.database.Update(func(txn db.Transaction) error {
+		return submittedTable.Put(txn, submitted.Transaction.Hash(), submitted)
+	})
+}
+
+// SubmittedTransaction returns a transaction that was submitted through this node
+func (b *Blockchain) SubmittedTransaction(hash *felt.Felt) (*SubmittedTransaction, error) {
+	var submitted *SubmittedTransaction
+	return submitted, b.database.View(func(txn db.Transaction) error {
+		var err error
+		submitted, err = submittedTable.Get(txn, hash)
+		return err
+	})
+}
@@ -0,0 +1,80 @@
+package blockchain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmittedTransaction(t *testing.T) {
+	chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, utils.NewNopZapLogger())
+
+	submitted := &blockchain.SubmittedTransaction{
+		Transaction: &core.InvokeTransaction{
+			TransactionHash: utils.HexToFelt(t, "0x1"),
+			SenderAddress:   utils.HexToFelt(t, "0x2"),
+			Nonce:           utils.HexToFelt(t, "0x3"),
+		},
+		SubmittedAt: 42,
+	}
+
+	_, err := chain.SubmittedTransaction(submitted.Transaction.Hash())
+	assert.True(t, errors.Is(err, db.ErrKeyNotFound))
+
+	require.NoError(t, chain.StoreSubmittedTransaction(submitted))
+
+	got, err := chain.SubmittedTransaction(submitted.Transaction.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, submitted, got)
+}
+
+func TestSweepExpired(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.MAINNET, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+
+	block, err := gw.BlockByNumber(context.Background(), 0)
+	require.NoError(t, err)
+	stateUpdate, err := gw.StateUpdate(context.Background(), 0)
+	require.NoError(t, err)
+	require.NoError(t, chain.StorePending(&blockchain.Pending{Block: block, StateUpdate: stateUpdate}))
+	pendingAt := time.Unix(int64(block.Timestamp), 0)
+
+	submitted := func(hash string, at time.Time) *blockchain.SubmittedTransaction {
+		return &blockchain.SubmittedTransaction{
+			Transaction: &core.InvokeTransaction{TransactionHash: utils.HexToFelt(t, hash)},
+			SubmittedAt: uint64(at.Unix()),
+		}
+	}
+	old, recent := submitted("0x1", pendingAt), submitted("0x2", pendingAt.Add(blockchain.SubmittedRetention))
+	require.NoError(t, chain.StoreSubmittedTransaction(old))
+	require.NoError(t, chain.StoreSubmittedTransaction(recent))
+
+	swept, err := db.SweepExpired(context.Background(), testDB, pendingAt.Add(blockchain.PendingRetention/2))
+	require.NoError(t, err)
+	assert.Zero(t, swept)
+
+	swept, err = db.SweepExpired(context.Background(), testDB, pendingAt.Add(blockchain.SubmittedRetention+time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), swept)
+	_, err = chain.Pending()
+	require.ErrorIs(t, err, db.ErrKeyNotFound)
+	_, err = chain.SubmittedTransaction(old.Transaction.Hash())
+	require.ErrorIs(t, err, db.ErrKeyNotFound)
+	_, err = chain.SubmittedTransaction(recent.Transaction.Hash())
+	require.NoError(t, err)
+}
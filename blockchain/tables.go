@@ -0,0 +1,33 @@
+package blockchain
+
+import (
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+)
+
+// The buckets of the chain, see db.Table
+var (
+	chainHeightValue   = db.NewValue[uint64](db.ChainHeight, db.Uint64Codec{})
+	l1HeadValue        = db.NewValue[*core.L1Head](db.L1Height, db.EncodedCodec[*core.L1Head]{})
+	pendingValue       = db.NewValue[Pending](db.Pending, db.EncodedCodec[Pending]{})
+	commitJournalValue = db.NewValue[*commitJournal](db.CommitJournal, db.EncodedCodec[*commitJournal]{})
+
+	headerTable = db.NewTable[uint64, *core.Header](db.BlockHeadersByNumber, db.Uint64Codec{},
+		db.EncodedCodec[*core.Header]{})
+	headerNumberTable = db.NewTable[*felt.Felt, uint64](db.BlockHeaderNumbersByHash, db.FeltCodec{},
+		db.Uint64Codec{})
+	commitmentsTable = db.NewTable[uint64, *core.BlockCommitments](db.BlockCommitments, db.Uint64Codec{},
+		db.EncodedCodec[*core.BlockCommitments]{})
+	stateUpdateTable = db.NewTable[uint64, *core.StateUpdate](db.StateUpdatesByBlockNumber, db.Uint64Codec{},
+		db.EncodedCodec[*core.StateUpdate]{})
+
+	transactionTable = db.NewTable[*txAndReceiptDBKey, core.Transaction](db.TransactionsByBlockNumberAndIndex,
+		txAndReceiptCodec{}, db.EncodedCodec[core.Transaction]{})
+	receiptTable = db.NewTable[*txAndReceiptDBKey, *core.TransactionReceipt](db.ReceiptsByBlockNumberAndIndex,
+		txAndReceiptCodec{}, db.EncodedCodec[*core.TransactionReceipt]{})
+	transactionNumberTable = db.NewTable[*felt.Felt, *txAndReceiptDBKey](db.TransactionBlockNumbersAndIndicesByHash,
+		db.FeltCodec{}, txAndReceiptCodec{})
+	submittedTable = db.NewTable[*felt.Felt, *SubmittedTransaction](db.SubmittedTransactions, db.FeltCodec{},
+		db.EncodedCodec[*SubmittedTransaction]{})
+)
@@ -2,6 +2,7 @@ package feeder
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/NethermindEth/juno/core/felt"
 )
@@ -46,6 +47,17 @@ type ClassDefinition struct {
 	V1 *SierraDefinition
 }
 
+func (c ClassDefinition) MarshalJSON() ([]byte, error) {
+	switch {
+	case c.V1 != nil:
+		return json.Marshal(c.V1)
+	case c.V0 != nil:
+		return json.Marshal(c.V0)
+	default:
+		return nil, errors.New("empty class definition")
+	}
+}
+
 func (c *ClassDefinition) UnmarshalJSON(data []byte) error {
 	jsonMap := make(map[string]any)
 	if err := json.Unmarshal(data, &jsonMap); err != nil {
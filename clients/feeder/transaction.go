@@ -14,6 +14,17 @@ const (
 	Reverted
 )
 
+func (es ExecutionStatus) MarshalJSON() ([]byte, error) {
+	switch es {
+	case Succeeded:
+		return []byte(`"SUCCEEDED"`), nil
+	case Reverted:
+		return []byte(`"REVERTED"`), nil
+	default:
+		return nil, errors.New("unknown ExecutionStatus")
+	}
+}
+
 func (es *ExecutionStatus) UnmarshalJSON(data []byte) error {
 	switch string(data) {
 	case `"SUCCEEDED"`:
@@ -33,6 +44,17 @@ const (
 	AcceptedOnL1
 )
 
+func (fs FinalityStatus) MarshalJSON() ([]byte, error) {
+	switch fs {
+	case AcceptedOnL2:
+		return []byte(`"ACCEPTED_ON_L2"`), nil
+	case AcceptedOnL1:
+		return []byte(`"ACCEPTED_ON_L1"`), nil
+	default:
+		return nil, errors.New("unknown FinalityStatus")
+	}
+}
+
 func (fs *FinalityStatus) UnmarshalJSON(data []byte) error {
 	switch string(data) {
 	case `"ACCEPTED_ON_L2"`:
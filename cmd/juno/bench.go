@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"time"
+
+	"github.com/NethermindEth/juno/bench"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	benchFromF         = "from"
+	benchToF           = "to"
+	benchTargetDBPathF = "target-db-path"
+	benchCPUProfileF   = "cpu-profile"
+
+	benchDBPathUsage  = "Location of the database the blocks are imported from."
+	benchNetworkUsage = "The network of the database. Options: mainnet, goerli, goerli2, integration."
+	benchFromUsage    = "The first block of the measured range, the blocks before it are imported without being " +
+		"measured."
+	benchToUsage           = "The last block of the measured range (the head of the database by default)."
+	benchTargetDBPathUsage = "Location of the empty database the blocks are imported into (a temporary directory " +
+		"that is removed afterwards by default)."
+	benchCPUProfileUsage = "The file a CPU profile of the import is written to, whose samples are labelled with the " +
+		"stages (no profile by default)."
+
+	// benchCacheSize is the size of the block caches of the databases, like the default of the node
+	benchCacheSize = 8 << 20
+)
+
+// NewBenchCmd returns the bench command, whose subcommands measure the performance of the node
+func NewBenchCmd() *cobra.Command {
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measures the performance of the node.",
+	}
+
+	importCmd := &cobra.Command{
+		Use:   "import [flags]",
+		Short: "Replays the blocks of a database through the verification and commit pipeline.",
+		Long: "Replays a range of blocks that are already stored in a database into an empty one, through the " +
+			"verification and commit pipeline of sync, and prints the time spent in each stage of the pipeline: " +
+			"decoding the blocks, verifying their hashes, updating the state tries and writing them to the " +
+			"database. No network access is needed, so the results can be compared across changes.",
+		Args: cobra.NoArgs,
+		RunE: runBenchImport,
+	}
+	importCmd.Flags().String(dbPathF, defaultDBPath, benchDBPathUsage)
+	importCmd.Flags().String(networkF, defaultNetwork, benchNetworkUsage)
+	importCmd.Flags().Uint64(benchFromF, 0, benchFromUsage)
+	importCmd.Flags().Uint64(benchToF, 0, benchToUsage)
+	importCmd.Flags().String(benchTargetDBPathF, "", benchTargetDBPathUsage)
+	importCmd.Flags().String(benchCPUProfileF, "", benchCPUProfileUsage)
+
+	benchCmd.AddCommand(importCmd)
+	return benchCmd
+}
+
+func runBenchImport(cmd *cobra.Command, _ []string) error {
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	from, err := cmd.Flags().GetUint64(benchFromF)
+	if err != nil {
+		return err
+	}
+	targetPath, err := cmd.Flags().GetString(benchTargetDBPathF)
+	if err != nil {
+		return err
+	}
+	cpuProfile, err := cmd.Flags().GetString(benchCPUProfileF)
+	if err != nil {
+		return err
+	}
+
+	source, err := openDBCmdDBWithCache(cmd, benchCacheSize)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	to, err := cmd.Flags().GetUint64(benchToF)
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed(benchToF) {
+		if to, err = blockchain.New(source, network, utils.NewNopZapLogger()).Height(); err != nil {
+			return fmt.Errorf("read the head of the database: %w", err)
+		}
+	}
+
+	if targetPath == "" {
+		if targetPath, err = os.MkdirTemp("", "juno-bench-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(targetPath)
+	}
+	log, err := utils.NewZapLogger(utils.INFO, false)
+	if err != nil {
+		return err
+	}
+	target, err := pebble.New(targetPath, benchCacheSize, log)
+	if err != nil {
+		return fmt.Errorf("open target DB: %w", err)
+	}
+	defer target.Close()
+
+	if cpuProfile != "" {
+		profile, err := os.Create(cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer profile.Close()
+		if err = pprof.StartCPUProfile(profile); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	importer := bench.New(source, blockchain.New(target, network, log), network, log)
+	report, err := importer.Import(cmd.Context(), from, to)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return fmt.Errorf("%w: the database must have the blocks up to %d", err, to)
+	} else if err != nil {
+		return err
+	}
+	printBenchReport(cmd, report)
+	return nil
+}
+
+func printBenchReport(cmd *cobra.Command, report *bench.Report) {
+	cmd.Printf("Imported blocks %d to %d (%d transactions) in %s, %.1f blocks/s\n\n", report.From, report.To,
+		report.Transactions, report.Elapsed.Round(time.Millisecond), report.BlocksPerSecond())
+
+	var total time.Duration
+	for _, elapsed := range report.Stages {
+		total += elapsed
+	}
+	cmd.Printf("%-10s %14s %14s %8s\n", "stage", "total", "per block", "share")
+	for _, stage := range bench.Stages {
+		elapsed := report.Stages[stage]
+		perBlock := elapsed / time.Duration(report.Blocks())
+		cmd.Printf("%-10s %14s %14s %8s\n", stage, elapsed.Round(time.Microsecond), perBlock.Round(time.Microsecond),
+			percentage(uint64(elapsed), uint64(total)))
+	}
+}
@@ -0,0 +1,34 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// the import itself is tested by the bench package, the feeder fixtures are not available to this package
+func TestBenchImport(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	run := func(args ...string) error {
+		var out bytes.Buffer
+		cmd := juno.NewBenchCmd()
+		cmd.SetArgs(append([]string{"import"}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		return cmd.ExecuteContext(context.Background())
+	}
+
+	require.ErrorContains(t, run("--db-path", dbPath), "read the head of the database")
+	require.ErrorContains(t, run("--db-path", dbPath, "--to", "0"), "the database must have the blocks up to 0")
+	require.Error(t, run("--db-path", filepath.Join(t.TempDir(), "missing")))
+}
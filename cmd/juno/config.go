@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/juno/node"
+	"github.com/spf13/cobra"
+)
+
+const (
+	effectiveF = "effective"
+
+	effectiveUsage = "Shows every option, including those left to their default."
+)
+
+// The sources of the value of an option, in order of precedence
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceFile    = "config file"
+	sourceDefault = "default"
+)
+
+// NewConfigCmd returns the config command, which shows the configuration the node would run with
+func NewConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspects the configuration of the node.",
+		Args:  cobra.NoArgs,
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show [flags]",
+		Short: "Shows the options that are set, and where each of them is set, for the given flags and environment.",
+		Long: "Shows the options that are set, and where each of them is set, for the given flags and environment. " +
+			"Options are set, in order of precedence, by flags, by " + envPrefix + "_<OPTION> environment " +
+			"variables, by the configuration file and by defaults.",
+		Args: cobra.NoArgs,
+		RunE: runConfigShow,
+	}
+	addNodeFlags(showCmd.Flags())
+	showCmd.Flags().Bool(effectiveF, false, effectiveUsage)
+
+	configCmd.AddCommand(showCmd)
+	return configCmd
+}
+
+func runConfigShow(cmd *cobra.Command, _ []string) error {
+	effective, err := cmd.Flags().GetBool(effectiveF)
+	if err != nil {
+		return err
+	}
+
+	config := new(node.Config)
+	if err = loadConfig(cmd, config); err != nil {
+		return err
+	}
+	v, err := newViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	settings := config.Settings()
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var source string
+		switch {
+		case cmd.Flags().Lookup(name) != nil && cmd.Flags().Changed(name):
+			source = sourceFlag
+		case isEnvSet(name):
+			source = sourceEnv
+		case v.InConfig(name):
+			source = sourceFile
+		default:
+			source = sourceDefault
+		}
+		if source == sourceDefault && !effective {
+			continue
+		}
+		cmd.Printf("%s: %s # %s\n", name, settings[name], source)
+	}
+	return nil
+}
+
+// isEnvSet returns whether the environment variable of an option is set, empty variables are ignored
+func isEnvSet(name string) bool {
+	return os.Getenv(envPrefix+"_"+strings.ToUpper(strings.ReplaceAll(name, "-", "_"))) != ""
+}
@@ -0,0 +1,40 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigShow(t *testing.T) {
+	cfgFile := tempCfgFile(t, "http-port: 4576\nws-port: 4577\n")
+	t.Setenv("JUNO_WS_PORT", "4578")
+	t.Setenv("JUNO_NETWORK", "goerli")
+
+	show := func(t *testing.T, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := juno.NewConfigCmd()
+		cmd.SetArgs(append([]string{"show", "--config", cfgFile, "--network", "goerli2"}, args...))
+		cmd.SetOut(&out)
+		require.NoError(t, cmd.ExecuteContext(context.Background()))
+		return out.String()
+	}
+
+	t.Run("options that are set", func(t *testing.T) {
+		assert.Equal(t, "http-port: 4576 # config file\nnetwork: goerli2 # flag\nws-port: 4578 # env\n", show(t))
+	})
+
+	t.Run("effective options", func(t *testing.T) {
+		out := show(t, "--effective")
+		assert.Contains(t, out, "network: goerli2 # flag\n")
+		assert.Contains(t, out, "ws-port: 4578 # env\n")
+		assert.Contains(t, out, "http-port: 4576 # config file\n")
+		assert.Contains(t, out, "memory-budget: 512 # default\n")
+		assert.Contains(t, out, "log-level: info # default\n")
+	})
+}
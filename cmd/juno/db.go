@@ -0,0 +1,775 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/rpc"
+	"github.com/NethermindEth/juno/selfcheck"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	jsonF     = "json"
+	revertToF = "to"
+	rewriteF  = "rewrite"
+	estimateF = "estimate"
+	bucketF   = "bucket"
+	archiveF  = "wal-archive"
+	pointF    = "point"
+
+	defaultJSON     = false
+	defaultRevertTo = 0
+	defaultRewrite  = false
+	defaultEstimate = false
+	defaultArchive  = ""
+	defaultPoint    = ""
+
+	dbCmdPathUsage    = "Location of the database files."
+	dbCmdNetworkUsage = "The network of the database. Options: mainnet, goerli, goerli2, integration."
+	jsonUsage         = "Prints the output as JSON."
+	revertToUsage     = "The schema version to revert the database to, the latest schema version of the older " +
+		"version of Juno to run. Required."
+	rewriteUsage = "Copies the keys of the database to a new database that replaces it, which reclaims all the " +
+		"space of the overwritten and deleted keys but needs the disk space of a copy of the database while it runs."
+	estimateUsage = "Estimates the keys and their size from the metadata of the database instead of reading them, " +
+		"the size is then the compressed disk space of the keys and their values."
+	bucketUsage  = "The bucket to inspect, can be repeated. All the buckets are inspected if it is not set."
+	archiveUsage = "The directory the write-ahead logs of the database were archived to, see --db-wal-archive-dir. " +
+		"The commits made after the backup was taken are replayed from them."
+	pointUsage = "The time, in RFC 3339, up to which the commits of --wal-archive are replayed. All of them are " +
+		"replayed if it is not set."
+
+	// dbCmdCacheSize is the size of the block cache of the database the db commands open
+	dbCmdCacheSize = 8 << 20
+)
+
+type namedBucket struct {
+	name   string
+	bucket db.Bucket
+}
+
+// bucketCategories groups the buckets by the kind of data they hold. The data of prunable categories is only
+// needed for the recent blocks by nodes that do not serve historical queries.
+var bucketCategories = []struct {
+	name     string
+	buckets  []namedBucket
+	prunable bool
+}{
+	{name: "state trie", buckets: []namedBucket{
+		{"StateTrie", db.StateTrie},
+		{"ClassesTrie", db.ClassesTrie},
+	}},
+	{name: "contract storage", buckets: []namedBucket{
+		{"ContractStorage", db.ContractStorage},
+	}},
+	{name: "contracts", buckets: []namedBucket{
+		{"ContractClassHash", db.ContractClassHash},
+		{"ContractNonce", db.ContractNonce},
+		{"ContractDeploymentHeight", db.ContractDeploymentHeight},
+	}},
+	{name: "state history", buckets: []namedBucket{
+		{"ContractStorageHistory", db.ContractStorageHistory},
+		{"ContractNonceHistory", db.ContractNonceHistory},
+		{"ContractClassHashHistory", db.ContractClassHashHistory},
+	}, prunable: true},
+	{name: "blocks", buckets: []namedBucket{
+		{"BlockHeadersByNumber", db.BlockHeadersByNumber},
+		{"TransactionsByBlockNumberAndIndex", db.TransactionsByBlockNumberAndIndex},
+		{"StateUpdatesByBlockNumber", db.StateUpdatesByBlockNumber},
+		{"BlockCommitments", db.BlockCommitments},
+		{"Pending", db.Pending},
+	}},
+	{name: "receipts", buckets: []namedBucket{
+		{"ReceiptsByBlockNumberAndIndex", db.ReceiptsByBlockNumberAndIndex},
+	}, prunable: true},
+	{name: "classes", buckets: []namedBucket{
+		{"Class", db.Class},
+		{"ABIs", db.ABIs},
+	}},
+	{name: "indexes", buckets: []namedBucket{
+		{"BlockHeaderNumbersByHash", db.BlockHeaderNumbersByHash},
+		{"TransactionBlockNumbersAndIndicesByHash", db.TransactionBlockNumbersAndIndicesByHash},
+		{"EventBlocksByAddress", db.EventBlocksByAddress},
+		{"IndexUndo", db.IndexUndo},
+	}},
+	{name: "metadata", buckets: []namedBucket{
+		{"Unused", db.Unused},
+		{"ChainHeight", db.ChainHeight},
+		{"L1Height", db.L1Height},
+		{"SchemaVersion", db.SchemaVersion},
+		{"SubmittedTransactions", db.SubmittedTransactions},
+		{"IndexProgress", db.IndexProgress},
+		{"Webhooks", db.Webhooks},
+		{"Analytics", db.Analytics},
+		{"MigrationCheckpoints", db.MigrationCheckpoints},
+		{"MigrationBackups", db.MigrationBackups},
+		{"CommitJournal", db.CommitJournal},
+		{"EventFilters", db.EventFilters},
+		{"BackgroundMigrations", db.BackgroundMigrations},
+		{"SchemaMetadata", db.SchemaMetadata},
+		{"Jobs", db.Jobs},
+		{"Quarantine", db.Quarantine},
+		{"StateHistoryStart", db.StateHistoryStart},
+		{"ValueEncoding", db.ValueEncoding},
+	}},
+}
+
+// categoryUsage is the disk space used by a category of buckets
+type categoryUsage struct {
+	Name    string            `json:"name"`
+	Size    uint64            `json:"size"`
+	Buckets map[string]uint64 `json:"buckets"`
+}
+
+// dbUsage is the disk space used by a database. Other is the space used by its logs and by the keys that are
+// not flushed yet.
+type dbUsage struct {
+	Total      uint64          `json:"total"`
+	Other      uint64          `json:"other"`
+	Categories []categoryUsage `json:"categories"`
+}
+
+// NewDBCmd returns the db command, whose subcommands inspect the database of a stopped node
+func NewDBCmd() *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspects the database, the node must be stopped.",
+		Args:  cobra.NoArgs,
+	}
+
+	sizeCmd := &cobra.Command{
+		Use:   "size [flags]",
+		Short: "Prints the disk space used by the database, broken down by the kind of data and by bucket.",
+		Args:  cobra.NoArgs,
+		RunE:  runDBSize,
+	}
+	sizeCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	sizeCmd.Flags().Bool(jsonF, defaultJSON, jsonUsage)
+
+	checkCmd := &cobra.Command{
+		Use:   "check [flags]",
+		Short: "Runs the startup self-check of the database and prints how to recover from the problems it finds.",
+		Args:  cobra.NoArgs,
+		RunE:  runDBCheck,
+	}
+	checkCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	checkCmd.Flags().String(networkF, defaultNetwork, dbCmdNetworkUsage)
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify [flags]",
+		Short: "Reads the whole database to check that its values decode, its blocks and its tries are intact.",
+		Long: "Reads the whole database to check that the values of the chain and of the state decode, that the " +
+			"hashes of the blocks match their contents, and that the roots of the tries of the state resolve. Unlike " +
+			"check, which only reads the head, it takes hours for a database of mainnet, and is meant to run after " +
+			"an unclean shutdown or a disk error before the node is trusted again.",
+		Args: cobra.NoArgs,
+		RunE: runDBVerify,
+	}
+	verifyCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	verifyCmd.Flags().String(networkF, defaultNetwork, dbCmdNetworkUsage)
+
+	verifyClassCmd := &cobra.Command{
+		Use:   "verify-class [flags] <class hash>",
+		Short: "Recomputes the hashes of a declared class and compares them to the hashes it was declared with.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runDBVerifyClass,
+	}
+	verifyClassCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	verifyClassCmd.Flags().String(networkF, defaultNetwork, dbCmdNetworkUsage)
+
+	forecastCmd := &cobra.Command{
+		Use:   "forecast [flags]",
+		Short: "Forecasts the disk space used by the database from the growth of the recent blocks.",
+		Long: "Forecasts the disk space used by the database from the growth of the recent blocks, and warns if " +
+			"it reaches the target size within the horizon. If it does, the forecast suggests how many recent blocks " +
+			"of prunable data (state history and receipts) to keep for the database to fit the target size.",
+		Args: cobra.NoArgs,
+		RunE: runDBForecast,
+	}
+	forecastCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	forecastCmd.Flags().String(networkF, defaultNetwork, dbCmdNetworkUsage)
+	forecastCmd.Flags().Uint64(targetSizeF, defaultTargetSize, targetSizeUsage)
+	forecastCmd.Flags().Duration(horizonF, defaultHorizon, horizonUsage)
+	forecastCmd.Flags().Uint64(growthBlocksF, defaultGrowthBlocks, growthBlocksUsage)
+	forecastCmd.Flags().Bool(jsonF, defaultJSON, jsonUsage)
+
+	revertCmd := &cobra.Command{
+		Use:   "revert [flags]",
+		Short: "Reverts the migrations of the database, so that it can be used by an older version of Juno.",
+		Long: "Reverts the migrations applied to the database after a schema version, so that it can be used by " +
+			"the older versions of Juno whose latest schema version it is, without syncing again. Nothing is " +
+			"reverted if one of the migrations cannot be reverted. The values are stored uncompressed and without " +
+			"checksums, which the node rewrites as its --db-compress and --db-checksum say when it starts.",
+		Args: cobra.NoArgs,
+		RunE: runDBRevert,
+	}
+	revertCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	revertCmd.Flags().String(networkF, defaultNetwork, dbCmdNetworkUsage)
+	revertCmd.Flags().Uint64(revertToF, defaultRevertTo, revertToUsage)
+
+	compactCmd := &cobra.Command{
+		Use:   "compact [flags]",
+		Short: "Compacts the database to reclaim the disk space of the overwritten and deleted keys.",
+		Long: "Compacts the database to reclaim the disk space of the overwritten and deleted keys, which the " +
+			"database otherwise reclaims gradually. A compaction in place leaves the files that are not worth " +
+			"rewriting as they are, a rewrite reclaims all the space. A rewrite that is interrupted while the " +
+			"copy replaces the database is completed by the next command or node that opens the database.",
+		Args: cobra.NoArgs,
+		RunE: runDBCompact,
+	}
+	compactCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	compactCmd.Flags().Bool(rewriteF, defaultRewrite, rewriteUsage)
+
+	inspectCmd := &cobra.Command{
+		Use:   "inspect [flags]",
+		Short: "Prints the number of keys of each bucket, the size of their values and their average size.",
+		Long: "Prints the number of keys of each bucket, the size of their values and their average size, by " +
+			"reading all the keys of the database. With --estimate, the statistics are estimated from the " +
+			"metadata of the database in seconds, the keys not flushed to disk yet are then not counted.",
+		Args: cobra.NoArgs,
+		RunE: runDBInspect,
+	}
+	inspectCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	inspectCmd.Flags().Bool(estimateF, defaultEstimate, estimateUsage)
+	inspectCmd.Flags().StringSlice(bucketF, nil, bucketUsage)
+	inspectCmd.Flags().Bool(jsonF, defaultJSON, jsonUsage)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [flags] <backup file>",
+		Short: "Restores a backup of the database taken from the /debug/db/backup endpoint of a running node.",
+		Long: "Restores a backup of the database taken from the /debug/db/backup endpoint of the pprof server of " +
+			"a running node. The database is restored to --db-path, which must not exist or be empty. With " +
+			"--wal-archive, the database is restored to --point rather than to the time of the backup.",
+		Args: cobra.ExactArgs(1),
+		RunE: runDBRestore,
+	}
+	restoreCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+	restoreCmd.Flags().String(archiveF, defaultArchive, archiveUsage)
+	restoreCmd.Flags().String(pointF, defaultPoint, pointUsage)
+
+	repairCmd := &cobra.Command{
+		Use:   "repair [flags]",
+		Short: "Repairs a database corrupted by a crash so that the node can start on it without syncing again.",
+		Long: "Repairs a database corrupted by a crash, such as a power loss, so that the node can start on it " +
+			"without syncing again. The write-ahead logs torn by the crash are truncated, losing the commits after " +
+			"the tear, then every entry is read and the entries that cannot be read or do not decode are moved to " +
+			"the Quarantine bucket. The node serves the quarantined entries as missing, run verify afterwards to " +
+			"find the blocks and the state it is missing. Back up the database directory first.",
+		Args: cobra.NoArgs,
+		RunE: runDBRepair,
+	}
+	repairCmd.Flags().String(dbPathF, defaultDBPath, dbCmdPathUsage)
+
+	dbCmd.AddCommand(sizeCmd, checkCmd, verifyCmd, verifyClassCmd, forecastCmd, revertCmd, compactCmd, inspectCmd,
+		restoreCmd, repairCmd)
+	return dbCmd
+}
+
+// openDBCmdDB opens the database of the --db-path flag of a db command
+func openDBCmdDB(cmd *cobra.Command) (*pebble.DB, error) {
+	return openDBCmdDBWithCache(cmd, dbCmdCacheSize)
+}
+
+// openDBCmdDBWithCache opens the database of the --db-path flag of a command with a block cache of cacheSize bytes
+func openDBCmdDBWithCache(cmd *cobra.Command, cacheSize uint64) (*pebble.DB, error) {
+	return openDBCmdDBWithOptions(cmd, db.Options{CacheSize: cacheSize})
+}
+
+// openDBCmdDBWithOptions opens the database of the --db-path flag of a command with opts, which log errors only
+// unless they have a logger
+func openDBCmdDBWithOptions(cmd *cobra.Command, opts db.Options) (*pebble.DB, error) {
+	dbPath, err := dbCmdPath(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Logger == nil {
+		if opts.Logger, err = utils.NewZapLogger(utils.ERROR, false); err != nil {
+			return nil, err
+		}
+	}
+
+	database, err := db.Open(db.DefaultBackend, dbPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("open DB: %w", err)
+	}
+	pebbleDB, ok := database.(*pebble.DB)
+	if !ok {
+		return nil, errors.Join(errors.New("unexpected database implementation"), database.Close())
+	}
+	return pebbleDB, nil
+}
+
+// dbCmdPath returns the --db-path flag of a command, which must be the path of an existing database
+func dbCmdPath(cmd *cobra.Command) (string, error) {
+	dbPath, err := cmd.Flags().GetString(dbPathF)
+	if err != nil {
+		return "", err
+	}
+	if dbPath == "" {
+		return "", fmt.Errorf("--%s is required", dbPathF)
+	}
+	// opening a path that does not exist would create an empty database there
+	if _, err = os.Stat(dbPath); err != nil {
+		return "", err
+	}
+	return dbPath, nil
+}
+
+func runDBSize(cmd *cobra.Command, _ []string) error {
+	printJSON, err := cmd.Flags().GetBool(jsonF)
+	if err != nil {
+		return err
+	}
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	size, err := dbSize(database)
+	if err != nil {
+		return err
+	}
+
+	if printJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(size)
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for i, category := range size.Categories {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t\n", category.Name, formatBytes(category.Size), percentage(category.Size, size.Total))
+		for _, bucket := range bucketCategories[i].buckets {
+			bucketSize := category.Buckets[bucket.name]
+			fmt.Fprintf(writer, "  %s\t%s\t%s\t\n", bucket.name, formatBytes(bucketSize), percentage(bucketSize, size.Total))
+		}
+	}
+	fmt.Fprintf(writer, "other\t%s\t%s\t\n", formatBytes(size.Other), percentage(size.Other, size.Total))
+	fmt.Fprintf(writer, "total\t%s\t\t\n", formatBytes(size.Total))
+	return writer.Flush()
+}
+
+func runDBCheck(cmd *cobra.Command, _ []string) error {
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	problems := selfcheck.Run(database, network)
+	if len(problems) == 0 {
+		cmd.Println("No problems found")
+		return nil
+	}
+	for _, problem := range problems {
+		cmd.Println(problem)
+	}
+	return fmt.Errorf("found %d problems", len(problems))
+}
+
+func runDBVerify(cmd *cobra.Command, _ []string) error {
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	problems, err := selfcheck.Verify(cmd.Context(), database, network, func(p selfcheck.Progress) {
+		cmd.Printf("%s: %d verified\n", p.Check, p.Done)
+	})
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		cmd.Println("No problems found")
+		return nil
+	}
+	for _, problem := range problems {
+		cmd.Println(problem)
+	}
+	return fmt.Errorf("found %d problems", len(problems))
+}
+
+func runDBRevert(cmd *cobra.Command, _ []string) error {
+	if !cmd.Flags().Changed(revertToF) {
+		return fmt.Errorf("--%s is required", revertToF)
+	}
+	target, err := cmd.Flags().GetUint64(revertToF)
+	if err != nil {
+		return err
+	}
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	log, err := utils.NewZapLogger(utils.INFO, false)
+	if err != nil {
+		return err
+	}
+	// the older versions of Juno read the values as they are set
+	database, err := openDBCmdDBWithOptions(cmd, db.Options{CacheSize: dbCmdCacheSize, Logger: log, Reencode: true})
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err = migration.RevertTo(cmd.Context(), database, network, target, log,
+		migration.MigrationOptions{JunoVersion: Version}); err != nil {
+		return err
+	}
+	cmd.Printf("Reverted the database to schema version %d\n", target)
+	return nil
+}
+
+func runDBCompact(cmd *cobra.Command, _ []string) error {
+	rewrite, err := cmd.Flags().GetBool(rewriteF)
+	if err != nil {
+		return err
+	}
+
+	var before, after uint64
+	if rewrite {
+		dbPath, err := dbCmdPath(cmd)
+		if err != nil {
+			return err
+		}
+		log, err := utils.NewZapLogger(utils.ERROR, false)
+		if err != nil {
+			return err
+		}
+		if before, after, err = pebble.Rewrite(dbPath, log); err != nil {
+			return fmt.Errorf("rewrite DB: %w", err)
+		}
+	} else {
+		database, err := openDBCmdDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		before = database.DiskUsage()
+		if err = database.Compact(); err != nil {
+			return fmt.Errorf("compact DB: %w", err)
+		}
+		after = database.DiskUsage()
+	}
+	cmd.Printf("Compacted the database from %s to %s\n", formatBytes(before), formatBytes(after))
+	return nil
+}
+
+// bucketStats are the statistics of a bucket printed by `juno db inspect`
+type bucketStats struct {
+	Bucket string `json:"bucket"`
+	db.BucketStats
+	AverageValueSize uint64 `json:"average_value_size"`
+}
+
+func runDBInspect(cmd *cobra.Command, _ []string) error {
+	estimate, err := cmd.Flags().GetBool(estimateF)
+	if err != nil {
+		return err
+	}
+	names, err := cmd.Flags().GetStringSlice(bucketF)
+	if err != nil {
+		return err
+	}
+	printJSON, err := cmd.Flags().GetBool(jsonF)
+	if err != nil {
+		return err
+	}
+	buckets, err := namedBuckets(names)
+	if err != nil {
+		return err
+	}
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	// the statistics are printed as the buckets are read, which takes a while for the large ones
+	printStats := func(stats *bucketStats) {
+		if !printJSON {
+			cmd.Printf("%-40s %15d keys %12s %12s avg\n", stats.Bucket, stats.Keys, formatBytes(stats.ValueBytes),
+				formatBytes(stats.AverageValueSize))
+		}
+	}
+	var inspected []*bucketStats
+	inspect := func(inspectBucket func(db.Bucket) (db.BucketStats, error)) error {
+		for _, bucket := range buckets {
+			stats, err := inspectBucket(bucket.bucket)
+			if err != nil {
+				return fmt.Errorf("inspect bucket %s: %w", bucket.name, err)
+			}
+			inspected = append(inspected, &bucketStats{
+				Bucket:           bucket.name,
+				BucketStats:      stats,
+				AverageValueSize: stats.AverageValueSize(),
+			})
+			printStats(inspected[len(inspected)-1])
+		}
+		return nil
+	}
+	if estimate {
+		err = inspect(func(bucket db.Bucket) (db.BucketStats, error) {
+			return db.EstimateBucket(database, bucket)
+		})
+	} else {
+		// the buckets are read from the same snapshot of the database
+		err = database.View(func(txn db.Transaction) error {
+			return inspect(func(bucket db.Bucket) (db.BucketStats, error) {
+				return db.InspectBucket(txn, bucket)
+			})
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if printJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(inspected)
+	}
+	return nil
+}
+
+// namedBuckets returns the buckets of bucketCategories with the given names, or all of them if names is empty
+func namedBuckets(names []string) ([]namedBucket, error) {
+	var all []namedBucket
+	for _, category := range bucketCategories {
+		all = append(all, category.buckets...)
+	}
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	buckets := make([]namedBucket, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, bucket := range all {
+			if bucket.name == name {
+				buckets = append(buckets, bucket)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown bucket %s", name)
+		}
+	}
+	return buckets, nil
+}
+
+func runDBRestore(cmd *cobra.Command, args []string) error {
+	dbPath, err := cmd.Flags().GetString(dbPathF)
+	if err != nil {
+		return err
+	}
+	if dbPath == "" {
+		return fmt.Errorf("--%s is required", dbPathF)
+	}
+	archive, err := cmd.Flags().GetString(archiveF)
+	if err != nil {
+		return err
+	}
+	pointFlag, err := cmd.Flags().GetString(pointF)
+	if err != nil {
+		return err
+	}
+	point := time.Now()
+	if pointFlag != "" {
+		if archive == "" {
+			return fmt.Errorf("--%s needs --%s", pointF, archiveF)
+		}
+		if point, err = time.Parse(time.RFC3339, pointFlag); err != nil {
+			return fmt.Errorf("invalid --%s: %w", pointF, err)
+		}
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if archive == "" {
+		if err = db.Restore(file, dbPath); err != nil {
+			return fmt.Errorf("restore backup: %w", err)
+		}
+		cmd.Printf("Restored %s to %s\n", args[0], dbPath)
+		return nil
+	}
+	restoredTo, err := db.RestoreToPoint(db.DefaultBackend, file, archive, dbPath, point)
+	if err != nil {
+		return fmt.Errorf("restore backup: %w", err)
+	}
+	if restoredTo.IsZero() {
+		// the commits are only stamped with their time once the logs are archived
+		cmd.Printf("Restored %s to %s, no archived commit was replayed\n", args[0], dbPath)
+		return nil
+	}
+	cmd.Printf("Restored %s to %s as of %s\n", args[0], dbPath, restoredTo.UTC().Format(time.RFC3339Nano))
+	return nil
+}
+
+func runDBRepair(cmd *cobra.Command, _ []string) error {
+	dbPath, err := dbCmdPath(cmd)
+	if err != nil {
+		return err
+	}
+	report, err := db.Repair(db.DefaultBackend, dbPath, selfcheck.CheckValue)
+	if err != nil {
+		return fmt.Errorf("repair DB: %w", err)
+	}
+
+	names := make(map[db.Bucket]string)
+	buckets, err := namedBuckets(nil)
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		names[bucket.bucket] = bucket.name
+	}
+	bucketName := func(key []byte) string {
+		if name, ok := names[db.Bucket(key[0])]; ok {
+			return name
+		}
+		return fmt.Sprintf("bucket %d", key[0])
+	}
+
+	for _, repair := range report.Engine {
+		cmd.Println(repair)
+	}
+	cmd.Printf("Checked %d entries, quarantined %d\n", report.Scanned, report.Quarantined)
+	for _, entry := range report.Entries {
+		cmd.Printf("%s key %x: %v\n", bucketName(entry.Key), entry.Key, entry.Err)
+	}
+	if report.Quarantined > uint64(len(report.Entries)) {
+		cmd.Printf("and %d more entries\n", report.Quarantined-uint64(len(report.Entries)))
+	}
+	for bucket, readErr := range report.Unreadable {
+		cmd.Printf("%s could not be read to the end: %v\n", bucketName(bucket.Key()), readErr)
+	}
+	if report.Degraded() {
+		cmd.Println("The database is missing data, run `juno db verify` to find out which blocks and state, and " +
+			"restore a snapshot if the node cannot do without them")
+	}
+	return nil
+}
+
+func runDBVerifyClass(cmd *cobra.Command, args []string) error {
+	classHash, err := new(felt.Felt).SetString(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid class hash %q: %w", args[0], err)
+	}
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	chain := blockchain.New(database, network, utils.NewNopZapLogger())
+	handler := rpc.New(chain, nil, network, nil, nil, nil, "", utils.NewNopZapLogger())
+	verification, rpcErr := handler.VerifyClassHash(rpc.BlockID{Latest: true}, *classHash)
+	if rpcErr != nil {
+		if rpcErr.Data != nil {
+			return fmt.Errorf("%s: %v", rpcErr.Message, rpcErr.Data)
+		}
+		return errors.New(rpcErr.Message)
+	}
+
+	cmd.Printf("class hash:                   %s\n", verification.ClassHash)
+	cmd.Printf("computed class hash:          %s\n", verification.ComputedClassHash)
+	if verification.CompiledClassHash != nil {
+		cmd.Printf("compiled class hash:          %s\n", verification.CompiledClassHash)
+		cmd.Printf("computed compiled class hash: %s\n", verification.ComputedCompiledClassHash)
+	}
+	if !verification.Valid {
+		return errors.New("the class does not match the hashes it was declared with")
+	}
+	cmd.Println("The class matches the hashes it was declared with")
+	return nil
+}
+
+func dbSize(database *pebble.DB) (*dbUsage, error) {
+	size := &dbUsage{Total: database.DiskUsage()}
+	var bucketsTotal uint64
+	for _, category := range bucketCategories {
+		categorySize := categoryUsage{Name: category.name, Buckets: make(map[string]uint64, len(category.buckets))}
+		for _, bucket := range category.buckets {
+			bucketSize, err := database.BucketSize(bucket.bucket)
+			if err != nil {
+				return nil, fmt.Errorf("estimate size of bucket %s: %w", bucket.name, err)
+			}
+			categorySize.Buckets[bucket.name] = bucketSize
+			categorySize.Size += bucketSize
+		}
+		bucketsTotal += categorySize.Size
+		size.Categories = append(size.Categories, categorySize)
+	}
+	// the sizes of the buckets are estimates, so they may add up to slightly more than the total
+	if size.Total > bucketsTotal {
+		size.Other = size.Total - bucketsTotal
+	}
+	return size, nil
+}
+
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func percentage(part, total uint64) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+}
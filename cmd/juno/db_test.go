@@ -0,0 +1,435 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	pebblev "github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBSize(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	block := &core.Block{Header: &core.Header{
+		Hash:            new(felt.Felt).SetUint64(1),
+		ParentHash:      &felt.Zero,
+		GlobalStateRoot: &felt.Zero,
+	}}
+	stateUpdate := &core.StateUpdate{
+		BlockHash: block.Hash,
+		NewRoot:   &felt.Zero,
+		OldRoot:   &felt.Zero,
+		StateDiff: new(core.StateDiff),
+	}
+	chain := blockchain.New(database, utils.MAINNET, utils.NewNopZapLogger())
+	require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+	require.NoError(t, database.Close())
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	t.Run("text", func(t *testing.T) {
+		out, err := run("size", "--db-path", dbPath)
+		require.NoError(t, err)
+		assert.Contains(t, out, "state trie")
+		assert.Contains(t, out, "  BlockHeadersByNumber")
+		assert.Contains(t, out, "total")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := run("size", "--db-path", dbPath, "--json")
+		require.NoError(t, err)
+
+		var size struct {
+			Total      uint64 `json:"total"`
+			Other      uint64 `json:"other"`
+			Categories []struct {
+				Name    string            `json:"name"`
+				Size    uint64            `json:"size"`
+				Buckets map[string]uint64 `json:"buckets"`
+			} `json:"categories"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &size))
+		assert.NotZero(t, size.Total)
+
+		sum := size.Other
+		for _, category := range size.Categories {
+			var bucketsSum uint64
+			for _, bucketSize := range category.Buckets {
+				bucketsSum += bucketSize
+			}
+			assert.Equal(t, category.Size, bucketsSum, category.Name)
+			sum += category.Size
+		}
+		assert.GreaterOrEqual(t, sum, size.Total)
+	})
+
+	t.Run("database does not exist", func(t *testing.T) {
+		_, err := run("size", "--db-path", filepath.Join(t.TempDir(), "missing"))
+		require.Error(t, err)
+	})
+}
+
+func TestDBForecast(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	chain := blockchain.New(database, utils.MAINNET, utils.NewNopZapLogger())
+	// blocks with an empty state, one every 10 seconds, whose receipts hold events
+	parentHash := &felt.Zero
+	for i := uint64(0); i < 5; i++ {
+		block := &core.Block{
+			Header: &core.Header{
+				Number:          i,
+				Hash:            new(felt.Felt).SetUint64(i + 1),
+				ParentHash:      parentHash,
+				GlobalStateRoot: &felt.Zero,
+				Timestamp:       10 * i,
+			},
+			Transactions: []core.Transaction{&core.InvokeTransaction{
+				TransactionHash: new(felt.Felt).SetUint64(i + 1),
+				Version:         new(felt.Felt).SetUint64(1),
+			}},
+		}
+		receipt := &core.TransactionReceipt{TransactionHash: block.Transactions[0].Hash(), Fee: &felt.Zero}
+		for j := uint64(0); j < 100; j++ {
+			receipt.Events = append(receipt.Events, &core.Event{
+				From: new(felt.Felt).SetUint64(j),
+				Keys: []*felt.Felt{new(felt.Felt).SetUint64(i)},
+				Data: []*felt.Felt{new(felt.Felt).SetUint64(j)},
+			})
+		}
+		block.Receipts = []*core.TransactionReceipt{receipt}
+		stateUpdate := &core.StateUpdate{
+			BlockHash: block.Hash,
+			NewRoot:   &felt.Zero,
+			OldRoot:   &felt.Zero,
+			StateDiff: new(core.StateDiff),
+		}
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+		parentHash = block.Hash
+	}
+	// the sizes are estimated from the files of the database, which hold the flushed keys
+	require.NoError(t, database.Impl().(*pebblev.DB).Flush())
+	require.NoError(t, database.Close())
+
+	type forecast struct {
+		GrowthBlocks     uint64   `json:"growth_blocks"`
+		BlockTimeSeconds float64  `json:"block_time_seconds"`
+		PerBlock         float64  `json:"per_block"`
+		PerDay           float64  `json:"per_day"`
+		DaysToTarget     *float64 `json:"days_to_target"`
+		Retention        *uint64  `json:"retention"`
+		Warnings         []string `json:"warnings"`
+	}
+	run := func(args ...string) (*forecast, error) {
+		var out bytes.Buffer
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs(append([]string{"forecast", "--db-path", dbPath, "--json"}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		if err := cmd.ExecuteContext(context.Background()); err != nil {
+			return nil, err
+		}
+		f := new(forecast)
+		return f, json.Unmarshal(out.Bytes(), f)
+	}
+
+	t.Run("fits the target size", func(t *testing.T) {
+		f, err := run("--target-size", "1024")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), f.GrowthBlocks)
+		assert.Positive(t, f.BlockTimeSeconds)
+		assert.Positive(t, f.PerBlock)
+		assert.Positive(t, f.PerDay)
+		require.NotNil(t, f.DaysToTarget)
+		assert.Nil(t, f.Retention)
+		assert.Empty(t, f.Warnings)
+	})
+
+	t.Run("reaches the target size within the horizon", func(t *testing.T) {
+		// the blocks grow the database by about 4 KiB, so it reaches 1 GiB in about a month
+		f, err := run("--target-size", "1", "--horizon", "1000h")
+		require.NoError(t, err)
+		require.NotNil(t, f.DaysToTarget)
+		assert.Less(t, *f.DaysToTarget, 1000.0/24)
+		require.Len(t, f.Warnings, 1)
+		assert.Contains(t, f.Warnings[0], "reaches the target size")
+		require.NotNil(t, f.Retention)
+		assert.Positive(t, *f.Retention)
+	})
+
+	t.Run("does not fit the target size without prunable data", func(t *testing.T) {
+		f, err := run("--target-size", "1", "--horizon", "100000h")
+		require.NoError(t, err)
+		assert.Nil(t, f.Retention)
+		require.Len(t, f.Warnings, 2)
+		assert.Contains(t, f.Warnings[1], "even without prunable data")
+	})
+
+	t.Run("target size is required", func(t *testing.T) {
+		_, err := run()
+		require.Error(t, err)
+	})
+}
+
+func TestDBVerify(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	run := func() (string, error) {
+		var out bytes.Buffer
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs([]string{"verify", "--db-path", dbPath})
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	out, err := run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "values of BlockHeadersByNumber: 0 verified")
+	assert.Contains(t, out, "No problems found")
+
+	database, err = pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		return txn.Set(db.BlockHeadersByNumber.Key(make([]byte, 8)), []byte("garbage"))
+	}))
+	require.NoError(t, database.Close())
+
+	out, err = run()
+	require.EqualError(t, err, "found 1 problems")
+	assert.Contains(t, out, "values of BlockHeadersByNumber: 1 failures")
+	assert.Contains(t, out, "juno snapshot import")
+}
+
+func TestDBRepair(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		return txn.Set(db.BlockHeadersByNumber.Key(make([]byte, 8)), []byte("garbage"))
+	}))
+	require.NoError(t, database.Close())
+
+	var out bytes.Buffer
+	cmd := juno.NewDBCmd()
+	cmd.SetArgs([]string{"repair", "--db-path", dbPath})
+	cmd.SetOut(&out)
+	require.NoError(t, cmd.ExecuteContext(context.Background()))
+	assert.Contains(t, out.String(), "Checked 1 entries, quarantined 1")
+	assert.Contains(t, out.String(), "BlockHeadersByNumber key 080000000000000000")
+	assert.Contains(t, out.String(), "juno db verify")
+}
+
+func TestDBRevert(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), database, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+	require.NoError(t, database.Close())
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs(append([]string{"revert", "--db-path", dbPath}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	_, err = run()
+	require.ErrorContains(t, err, "--to is required")
+
+	_, err = run("--to", "1")
+	require.ErrorIs(t, err, migration.ErrIrreversible)
+
+	target := migration.LatestSchemaVersion() - 1
+	out, err := run("--to", strconv.FormatUint(target, 10))
+	require.NoError(t, err)
+	assert.Contains(t, out, "Reverted the database")
+
+	database, err = pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	version, err := migration.SchemaVersion(database)
+	require.NoError(t, err)
+	assert.Equal(t, target, version)
+	require.NoError(t, database.Close())
+}
+
+func TestDBCompact(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), database, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+	require.NoError(t, database.Close())
+
+	for _, args := range [][]string{nil, {"--rewrite"}} {
+		var out bytes.Buffer
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs(append([]string{"compact", "--db-path", dbPath}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		require.NoError(t, cmd.ExecuteContext(context.Background()))
+		assert.Contains(t, out.String(), "Compacted the database")
+	}
+
+	database, err = pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	version, err := migration.SchemaVersion(database)
+	require.NoError(t, err)
+	assert.Equal(t, migration.LatestSchemaVersion(), version)
+	require.NoError(t, database.Close())
+}
+
+func TestDBInspect(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		for i := 0; i < 10; i++ {
+			if err := txn.Set(db.Class.Key([]byte{byte(i)}), make([]byte, 100)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, database.Close())
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs(append([]string{"inspect", "--db-path", dbPath}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	t.Run("text", func(t *testing.T) {
+		out, err := run("--bucket", "Class", "--bucket", "ABIs")
+		require.NoError(t, err)
+		assert.Regexp(t, `Class +10 keys +1000 B +100 B avg`, out)
+		assert.Regexp(t, `ABIs +0 keys`, out)
+		assert.NotContains(t, out, "StateTrie")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := run("--json")
+		require.NoError(t, err)
+		var stats []struct {
+			Bucket           string `json:"bucket"`
+			Keys             uint64 `json:"keys"`
+			ValueBytes       uint64 `json:"value_bytes"`
+			AverageValueSize uint64 `json:"average_value_size"`
+			Estimated        bool   `json:"estimated"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &stats))
+		for _, bucket := range stats {
+			if bucket.Bucket == "Class" {
+				assert.Equal(t, uint64(10), bucket.Keys)
+				assert.Equal(t, uint64(1000), bucket.ValueBytes)
+				assert.Equal(t, uint64(100), bucket.AverageValueSize)
+				assert.False(t, bucket.Estimated)
+				return
+			}
+		}
+		t.Fatal("Class bucket not inspected")
+	})
+
+	t.Run("estimate", func(t *testing.T) {
+		out, err := run("--estimate", "--bucket", "Class")
+		require.NoError(t, err)
+		assert.Contains(t, out, "Class")
+	})
+
+	t.Run("unknown bucket", func(t *testing.T) {
+		_, err := run("--bucket", "NotABucket")
+		require.ErrorContains(t, err, "unknown bucket NotABucket")
+	})
+}
+
+func TestDBRestore(t *testing.T) {
+	database, err := pebble.New(t.TempDir(), 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), database, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+	backupPath := filepath.Join(t.TempDir(), "backup.tar")
+	backup, err := os.Create(backupPath)
+	require.NoError(t, err)
+	require.NoError(t, db.WriteBackup(database, backup, t.TempDir()))
+	require.NoError(t, backup.Close())
+	require.NoError(t, database.Close())
+
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	var out bytes.Buffer
+	cmd := juno.NewDBCmd()
+	cmd.SetArgs([]string{"restore", "--db-path", dbPath, backupPath})
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	require.NoError(t, cmd.ExecuteContext(context.Background()))
+	assert.Contains(t, out.String(), "Restored "+backupPath)
+
+	database, err = pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	version, err := migration.SchemaVersion(database)
+	require.NoError(t, err)
+	assert.Equal(t, migration.LatestSchemaVersion(), version)
+	require.NoError(t, database.Close())
+
+	// the database restored to is not overwritten
+	cmd = juno.NewDBCmd()
+	cmd.SetArgs([]string{"restore", "--db-path", dbPath, backupPath})
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	require.ErrorContains(t, cmd.ExecuteContext(context.Background()), "is not empty")
+
+	t.Run("to a point in time", func(t *testing.T) {
+		// the backup of a database that did not archive its logs is restored as it is
+		dbPath := filepath.Join(t.TempDir(), "juno")
+		cmd := juno.NewDBCmd()
+		cmd.SetArgs([]string{
+			"restore", "--db-path", dbPath, "--wal-archive", t.TempDir(), "--point", "2030-01-01T00:00:00Z", backupPath,
+		})
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		require.NoError(t, cmd.ExecuteContext(context.Background()))
+		assert.Contains(t, out.String(), "no archived commit was replayed")
+
+		cmd = juno.NewDBCmd()
+		cmd.SetArgs([]string{"restore", "--db-path", dbPath, "--point", "2030-01-01T00:00:00Z", backupPath})
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		require.ErrorContains(t, cmd.ExecuteContext(context.Background()), "--point needs --wal-archive")
+	})
+}
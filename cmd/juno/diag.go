@@ -0,0 +1,176 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	debugAddrF         = "debug-addr"
+	outputF            = "output"
+	cpuProfileSecondsF = "cpu-profile-seconds"
+
+	defaultDebugAddr         = "localhost:9080"
+	defaultOutput            = ""
+	defaultCPUProfileSeconds = 10
+
+	debugAddrUsage         = "The host:port of the pprof server of the running node."
+	outputUsage            = "The file the bundle is written to (juno-diag-<time>.tar.gz by default)."
+	cpuProfileSecondsUsage = "The duration of the CPU profile in seconds, 0 to skip it."
+)
+
+// diagnostic is a file of a bundle and the path of the pprof server it is collected from
+type diagnostic struct {
+	name string
+	path string
+}
+
+var diagnostics = []diagnostic{
+	{name: "config.json", path: "/debug/config"},
+	{name: "runtime.json", path: "/debug/runtime"},
+	{name: "log-levels.json", path: "/debug/log/level"},
+	{name: "logs.txt", path: "/debug/logs"},
+	{name: "db-metrics.txt", path: "/debug/db"},
+	{name: "migrations.json", path: "/debug/migrations"},
+	{name: "goroutines.txt", path: "/debug/pprof/goroutine?debug=2"},
+	{name: "heap.pprof", path: "/debug/pprof/heap"},
+}
+
+// NewDiagCmd returns the diag command, which collects a diagnostics bundle from a running node
+func NewDiagCmd() *cobra.Command {
+	diagCmd := &cobra.Command{
+		Use:   "diag [flags]",
+		Short: "Collects a diagnostics bundle from a running node to attach to bug reports.",
+		Long: "Collects the version, configuration, recent logs, runtime and database statistics and profiles " +
+			"of a running node into a .tar.gz file. The node must be started with --pprof.",
+		Args: cobra.NoArgs,
+		RunE: runDiag,
+	}
+
+	diagCmd.Flags().String(debugAddrF, defaultDebugAddr, debugAddrUsage)
+	diagCmd.Flags().String(outputF, defaultOutput, outputUsage)
+	diagCmd.Flags().Uint(cpuProfileSecondsF, defaultCPUProfileSeconds, cpuProfileSecondsUsage)
+	return diagCmd
+}
+
+func runDiag(cmd *cobra.Command, _ []string) error {
+	debugAddr, err := cmd.Flags().GetString(debugAddrF)
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString(outputF)
+	if err != nil {
+		return err
+	}
+	cpuProfileSeconds, err := cmd.Flags().GetUint(cpuProfileSecondsF)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = "juno-diag-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	}
+
+	files := map[string][]byte{
+		"version.txt": []byte(fmt.Sprintf("juno diag %s\n%s %s/%s\n", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)),
+	}
+	names := []string{"version.txt"}
+
+	collect := append([]diagnostic(nil), diagnostics...)
+	if cpuProfileSeconds > 0 {
+		collect = append(collect, diagnostic{
+			name: "cpu.pprof",
+			path: fmt.Sprintf("/debug/pprof/profile?seconds=%d", cpuProfileSeconds),
+		})
+	}
+
+	// the CPU profile blocks for its whole duration
+	client := &http.Client{Timeout: time.Duration(cpuProfileSeconds)*time.Second + 30*time.Second}
+	var failures []string
+	for _, d := range collect {
+		body, fetchErr := fetchDiagnostic(cmd.Context(), client, "http://"+debugAddr+d.path)
+		if fetchErr != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", d.path, fetchErr))
+			continue
+		}
+		files[d.name] = body
+		names = append(names, d.name)
+	}
+	if len(failures) == len(collect) {
+		return fmt.Errorf("collect diagnostics from %s, is the node running with --pprof? %s", debugAddr, failures[0])
+	}
+	if len(failures) > 0 {
+		files["errors.txt"] = []byte(strings.Join(failures, "\n") + "\n")
+		names = append(names, "errors.txt")
+	}
+
+	if err = writeBundle(output, names, files); err != nil {
+		return fmt.Errorf("write diagnostics bundle: %w", err)
+	}
+	cmd.Printf("Wrote diagnostics bundle to %s\n", output)
+	return nil
+}
+
+func fetchDiagnostic(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// writeBundle writes files to a gzipped tarball at path, in the order of names
+func writeBundle(path string, names []string, files map[string][]byte) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	now := time.Now()
+	for _, name := range names {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(files[name])),
+			ModTime: now,
+		}
+		if err = tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err = tarWriter.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiag(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/config", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"version": "v0.0.0"}`))
+	})
+	mux.HandleFunc("/debug/logs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("INFO Stored Block"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	debugAddr := strings.TrimPrefix(srv.URL, "http://")
+
+	t.Run("collects the available diagnostics", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		cmd := juno.NewDiagCmd()
+		cmd.SetArgs([]string{"--debug-addr", debugAddr, "--output", output, "--cpu-profile-seconds", "0"})
+		cmd.SetOut(io.Discard)
+		require.NoError(t, cmd.ExecuteContext(context.Background()))
+
+		files := readBundle(t, output)
+		assert.Equal(t, `{"version": "v0.0.0"}`, files["config.json"])
+		assert.Equal(t, "INFO Stored Block", files["logs.txt"])
+		assert.Contains(t, files, "version.txt")
+		assert.NotContains(t, files, "heap.pprof")
+		assert.Contains(t, files["errors.txt"], "/debug/pprof/heap: 404 Not Found")
+	})
+
+	t.Run("node is not reachable", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		cmd := juno.NewDiagCmd()
+		cmd.SetArgs([]string{"--debug-addr", "localhost:1", "--output", output, "--cpu-profile-seconds", "0"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		require.Error(t, cmd.ExecuteContext(context.Background()))
+		assert.NoFileExists(t, output)
+	})
+}
+
+func readBundle(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	targetSizeF   = "target-size"
+	horizonF      = "horizon"
+	growthBlocksF = "growth-blocks"
+
+	defaultTargetSize   = 0
+	defaultHorizon      = 30 * 24 * time.Hour
+	defaultGrowthBlocks = 1000
+
+	targetSizeUsage   = "The disk space the database must fit in, in GiB. Required."
+	horizonUsage      = "Warns if the database reaches the target size within this duration."
+	growthBlocksUsage = "The number of recent blocks the growth of the database is measured over."
+
+	gib = 1 << 30
+)
+
+// blockKeyedBuckets are the buckets whose keys start with a block number, so that the disk space used by a
+// range of blocks can be estimated
+var blockKeyedBuckets = map[db.Bucket]struct{}{
+	db.BlockHeadersByNumber:              {},
+	db.TransactionsByBlockNumberAndIndex: {},
+	db.ReceiptsByBlockNumberAndIndex:     {},
+	db.StateUpdatesByBlockNumber:         {},
+	db.BlockCommitments:                  {},
+}
+
+// categoryGrowth is the disk space used by a category of buckets and how much it grows with every block
+type categoryGrowth struct {
+	Name     string  `json:"name"`
+	Size     uint64  `json:"size"`
+	PerBlock float64 `json:"per_block"`
+	Prunable bool    `json:"prunable"`
+}
+
+// usageForecast is the disk space a database is expected to use, from the growth of its recent blocks.
+// DaysToTarget is nil if the database does not grow, and Retention is nil if the database fits the target size
+// at the horizon without pruning.
+type usageForecast struct {
+	Head             uint64           `json:"head"`
+	GrowthBlocks     uint64           `json:"growth_blocks"`
+	BlockTimeSeconds float64          `json:"block_time_seconds"`
+	Size             uint64           `json:"size"`
+	PerBlock         float64          `json:"per_block"`
+	PerDay           float64          `json:"per_day"`
+	TargetSize       uint64           `json:"target_size"`
+	DaysToTarget     *float64         `json:"days_to_target,omitempty"`
+	HorizonDays      float64          `json:"horizon_days"`
+	HorizonSize      uint64           `json:"horizon_size"`
+	Retention        *uint64          `json:"retention,omitempty"`
+	Warnings         []string         `json:"warnings"`
+	Categories       []categoryGrowth `json:"categories"`
+}
+
+func runDBForecast(cmd *cobra.Command, _ []string) error {
+	targetSize, err := cmd.Flags().GetUint64(targetSizeF)
+	if err != nil {
+		return err
+	}
+	if targetSize == 0 {
+		return fmt.Errorf("--%s is required", targetSizeF)
+	}
+	horizon, err := cmd.Flags().GetDuration(horizonF)
+	if err != nil {
+		return err
+	}
+	growthBlocks, err := cmd.Flags().GetUint64(growthBlocksF)
+	if err != nil {
+		return err
+	}
+	printJSON, err := cmd.Flags().GetBool(jsonF)
+	if err != nil {
+		return err
+	}
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	forecast, err := dbForecast(database, network, growthBlocks, targetSize*gib, horizon)
+	if err != nil {
+		return err
+	}
+
+	if printJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(forecast)
+	}
+
+	var blocksPerDay float64
+	if forecast.BlockTimeSeconds > 0 {
+		blocksPerDay = (24 * time.Hour).Seconds() / forecast.BlockTimeSeconds
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "category\tsize\tper block\tper day\t\n")
+	for _, category := range forecast.Categories {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t\n", category.Name, formatBytes(category.Size),
+			formatBytes(uint64(category.PerBlock)), formatBytes(uint64(category.PerBlock*blocksPerDay)))
+	}
+	fmt.Fprintf(writer, "total\t%s\t%s\t%s\t\n", formatBytes(forecast.Size), formatBytes(uint64(forecast.PerBlock)),
+		formatBytes(uint64(forecast.PerDay)))
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+
+	cmd.Printf("\nGrowth measured over the last %d blocks\n", forecast.GrowthBlocks)
+	if forecast.BlockTimeSeconds > 0 {
+		cmd.Printf("Size in %.0f days: %s\n", forecast.HorizonDays, formatBytes(forecast.HorizonSize))
+		if forecast.DaysToTarget != nil {
+			cmd.Printf("Target size of %s reached in %.1f days\n", formatBytes(forecast.TargetSize),
+				*forecast.DaysToTarget)
+		} else {
+			cmd.Printf("Target size of %s is never reached\n", formatBytes(forecast.TargetSize))
+		}
+	}
+	if forecast.Retention != nil {
+		cmd.Printf("To fit the target size, keep the state history and receipts of the last %d blocks\n",
+			*forecast.Retention)
+	}
+	for _, warning := range forecast.Warnings {
+		cmd.Printf("Warning: %s\n", warning)
+	}
+	return nil
+}
+
+// dbForecast forecasts the disk space used by database from the growth of its last growthBlocks blocks. The
+// growth of the buckets that are not keyed by block number is not measured directly: they grow with the
+// changes to the state, so their growth is their size scaled by the share of the state updates held by the
+// recent blocks.
+func dbForecast(database *pebble.DB, network utils.Network, growthBlocks, targetSize uint64,
+	horizon time.Duration,
+) (*usageForecast, error) {
+	chain := blockchain.New(database, network, utils.NewNopZapLogger())
+	head, err := chain.HeadsHeader()
+	if err != nil {
+		return nil, fmt.Errorf("read head: %w", err)
+	}
+	if growthBlocks > head.Number {
+		growthBlocks = head.Number
+	}
+	if growthBlocks == 0 {
+		return nil, errors.New("the database needs at least two blocks to measure its growth")
+	}
+	from, err := chain.BlockHeaderByNumber(head.Number - growthBlocks)
+	if err != nil {
+		return nil, fmt.Errorf("read block %d: %w", head.Number-growthBlocks, err)
+	}
+
+	// the range of keys of the recent blocks in a block keyed bucket
+	recentSize := func(bucket db.Bucket) (uint64, error) {
+		return database.RangeSize(bucket.Key(core.MarshalBlockNumber(from.Number+1)),
+			bucket.Key(core.MarshalBlockNumber(head.Number+1)))
+	}
+	stateUpdatesSize, err := database.BucketSize(db.StateUpdatesByBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	recentStateUpdatesSize, err := recentSize(db.StateUpdatesByBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	recentShare := float64(growthBlocks) / float64(head.Number+1)
+	if stateUpdatesSize > 0 {
+		recentShare = float64(recentStateUpdatesSize) / float64(stateUpdatesSize)
+	}
+
+	usage, err := dbSize(database)
+	if err != nil {
+		return nil, err
+	}
+	forecast := &usageForecast{
+		Head:         head.Number,
+		GrowthBlocks: growthBlocks,
+		Size:         usage.Total,
+		TargetSize:   targetSize,
+		HorizonDays:  horizon.Hours() / 24,
+	}
+	for i, category := range bucketCategories {
+		growth := categoryGrowth{
+			Name:     category.name,
+			Size:     usage.Categories[i].Size,
+			Prunable: category.prunable,
+		}
+		for _, bucket := range category.buckets {
+			recent := recentShare * float64(usage.Categories[i].Buckets[bucket.name])
+			if _, ok := blockKeyedBuckets[bucket.bucket]; ok {
+				recentBytes, sizeErr := recentSize(bucket.bucket)
+				if sizeErr != nil {
+					return nil, fmt.Errorf("estimate size of bucket %s: %w", bucket.name, sizeErr)
+				}
+				recent = float64(recentBytes)
+			}
+			growth.PerBlock += recent / float64(growthBlocks)
+		}
+		forecast.PerBlock += growth.PerBlock
+		forecast.Categories = append(forecast.Categories, growth)
+	}
+
+	if head.Timestamp <= from.Timestamp {
+		forecast.Warnings = append(forecast.Warnings, "the recent blocks have no timestamps, the growth cannot be "+
+			"forecast over time")
+		return forecast, nil
+	}
+	forecast.BlockTimeSeconds = float64(head.Timestamp-from.Timestamp) / float64(growthBlocks)
+	blocksPerDay := (24 * time.Hour).Seconds() / forecast.BlockTimeSeconds
+	forecast.PerDay = forecast.PerBlock * blocksPerDay
+	horizonBlocks := horizon.Seconds() / forecast.BlockTimeSeconds
+	horizonSize := float64(forecast.Size) + forecast.PerBlock*horizonBlocks
+	forecast.HorizonSize = uint64(horizonSize)
+
+	switch {
+	case forecast.Size >= targetSize:
+		days := 0.0
+		forecast.DaysToTarget = &days
+		forecast.Warnings = append(forecast.Warnings, "the database exceeds the target size")
+	case forecast.PerDay > 0:
+		days := float64(targetSize-forecast.Size) / forecast.PerDay
+		forecast.DaysToTarget = &days
+		if days <= forecast.HorizonDays {
+			forecast.Warnings = append(forecast.Warnings,
+				fmt.Sprintf("the database reaches the target size in %.1f days", days))
+		}
+	}
+
+	if horizonSize <= float64(targetSize) {
+		return forecast, nil
+	}
+	// the prunable data of the recent blocks is assumed to use as much space as that of the last blocks
+	var prunableSize, prunablePerBlock float64
+	for _, category := range forecast.Categories {
+		if category.Prunable {
+			prunableSize += float64(category.Size)
+			prunablePerBlock += category.PerBlock
+		}
+	}
+	unprunableSize := horizonSize - prunableSize - prunablePerBlock*horizonBlocks
+	if unprunableSize >= float64(targetSize) || prunablePerBlock == 0 {
+		forecast.Warnings = append(forecast.Warnings, "the database does not fit the target size at the horizon "+
+			"even without prunable data")
+		return forecast, nil
+	}
+	retention := uint64(math.Floor((float64(targetSize) - unprunableSize) / prunablePerBlock))
+	forecast.Retention = &retention
+	return forecast, nil
+}
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/cached"
+	_ "github.com/NethermindEth/juno/db/memory" // registers the in-memory backend
 	"github.com/NethermindEth/juno/node"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -26,37 +31,149 @@ const greeting = `
 Juno is a Go implementation of a Starknet full node client created by Nethermind.`
 
 const (
-	configF              = "config"
-	logLevelF            = "log-level"
-	httpPortF            = "http-port"
-	wsPortF              = "ws-port"
-	grpcPortF            = "grpc-port"
-	dbPathF              = "db-path"
-	networkF             = "network"
-	ethNodeF             = "eth-node"
-	pprofF               = "pprof"
-	colourF              = "colour"
-	pendingPollIntervalF = "pending-poll-interval"
-	p2pF                 = "p2p"
-	p2pAddrF             = "p2p-addr"
-	p2pBootPeersF        = "p2p-boot-peers"
-	metricsF             = "metrics"
-	metricsPortF         = "metrics-port"
-
-	defaultConfig              = ""
-	defaultHTTPPort            = 6060
-	defaultWSPort              = 6061
-	defaultGRPCPort            = 0
-	defaultDBPath              = ""
-	defaultEthNode             = ""
-	defaultPprof               = false
-	defaultColour              = true
-	defaultPendingPollInterval = time.Duration(0)
-	defaultP2p                 = false
-	defaultP2pAddr             = ""
-	defaultP2pBootPeers        = ""
-	defaultMetrics             = false
-	defaultMetricsPort         = 9090
+	configF                 = "config"
+	logLevelF               = "log-level"
+	httpPortF               = "http-port"
+	wsPortF                 = "ws-port"
+	grpcPortF               = "grpc-port"
+	dbPathF                 = "db-path"
+	dbBackendF              = "db-backend"
+	dbCompressF             = "db-compress"
+	dbChecksumF             = "db-checksum"
+	dbChecksumVerifyF       = "db-checksum-verify"
+	dbCacheSizeF            = "db-cache-size"
+	dbMemTableSizeF         = "db-memtable-size"
+	dbMaxOpenFilesF         = "db-max-open-files"
+	dbCompactionsF          = "db-compaction-concurrency"
+	dbWALDirF               = "db-wal-dir"
+	dbWALSyncSizeF          = "db-wal-sync-size"
+	dbSlowThresholdF        = "db-slow-threshold"
+	dbWALArchiveDirF        = "db-wal-archive-dir"
+	networkF                = "network"
+	ethNodeF                = "eth-node"
+	pprofF                  = "pprof"
+	colourF                 = "colour"
+	pendingPollIntervalF    = "pending-poll-interval"
+	p2pF                    = "p2p"
+	p2pAddrF                = "p2p-addr"
+	p2pBootPeersF           = "p2p-boot-peers"
+	metricsF                = "metrics"
+	metricsPortF            = "metrics-port"
+	feederGatewayPortF      = "feeder-gateway-port"
+	otlpEndpointF           = "otlp-endpoint"
+	otlpInsecureF           = "otlp-insecure"
+	otlpSampleRatioF        = "otlp-sample-ratio"
+	graphQLPortF            = "graphql-port"
+	abiDecodingF            = "abi-decoding"
+	memoryBudgetF           = "memory-budget"
+	stallTimeoutF           = "stall-timeout"
+	watchdogRestartF        = "watchdog-restart"
+	auditF                  = "audit"
+	auditFromF              = "audit-from"
+	telemetryEndpointF      = "telemetry-endpoint"
+	replicaOfF              = "replica-of"
+	rpcMaxStepsF            = "rpc-max-steps"
+	rpcMaxMemoryF           = "rpc-max-memory"
+	rpcExecutionTimeoutF    = "rpc-execution-timeout"
+	rpcMaxResponseSizeF     = "rpc-max-response-size"
+	indexEventsF            = "index-events"
+	indexBackfillRateF      = "index-backfill-rate"
+	jobConcurrencyF         = "job-concurrency"
+	labelsF                 = "labels"
+	recentEventsBlocksF     = "recent-events-blocks"
+	recentEventsRateF       = "recent-events-rate"
+	eventFiltersF           = "event-filters"
+	analyticsDaysF          = "analytics-days"
+	migrationBackupDirF     = "migration-backup-dir"
+	migrationWorkersF       = "migration-workers"
+	migrationBatchSizeF     = "migration-batch-size"
+	migrationMemoryBudgetF  = "migration-memory-budget"
+	migrationChunkSizeF     = "migration-chunk-size"
+	migrationOnInterruptedF = "migration-on-interrupted"
+	migrateInBackgroundF    = "migrate-in-background"
+	refuseToMigrateF        = "refuse-to-migrate"
+	coldDBPathF             = "cold-db-path"
+	coldAfterF              = "cold-after"
+	coldDBBackendF          = "cold-db-backend"
+	stateDBPathF            = "state-db-path"
+	dbReadCacheF            = "db-read-cache"
+	stateHistoryBlocksF     = "state-history-blocks"
+	devnetF                 = "devnet"
+	devnetBlockTimeF        = "devnet-block-time"
+	devnetAccountsF         = "devnet-accounts"
+	devnetSeedF             = "devnet-seed"
+	devnetAccountClassF     = "devnet-account-class"
+	devnetFeeTokenClassF    = "devnet-fee-token-class"
+	forensicsDirF           = "forensics-dir"
+
+	defaultConfig                 = ""
+	defaultHTTPPort               = 6060
+	defaultWSPort                 = 6061
+	defaultGRPCPort               = 0
+	defaultDBPath                 = ""
+	defaultDBBackend              = db.DefaultBackend
+	defaultDBCacheSize            = 0
+	defaultDBMemTableSize         = 0
+	defaultDBMaxOpenFiles         = 0
+	defaultDBCompactions          = 0
+	defaultDBWALDir               = ""
+	defaultDBWALSyncSize          = 0
+	defaultDBSlowThreshold        = time.Duration(0)
+	defaultDBWALArchiveDir        = ""
+	defaultDBChecksumVerify       = "fail"
+	defaultNetwork                = "mainnet"
+	defaultEthNode                = ""
+	defaultPprof                  = false
+	defaultColour                 = true
+	defaultPendingPollInterval    = time.Duration(0)
+	defaultP2p                    = false
+	defaultP2pAddr                = ""
+	defaultP2pBootPeers           = ""
+	defaultMetrics                = false
+	defaultMetricsPort            = 9090
+	defaultFeederGatewayPort      = 0
+	defaultOTLPEndpoint           = ""
+	defaultOTLPInsecure           = false
+	defaultOTLPSampleRatio        = 1.0
+	defaultGraphQLPort            = 0
+	defaultABIDecoding            = false
+	defaultMemoryBudget           = 512
+	defaultStallTimeout           = 10 * time.Minute
+	defaultWatchdogRestart        = false
+	defaultAudit                  = false
+	defaultAuditFrom              = 0
+	defaultTelemetryEndpoint      = ""
+	defaultReplicaOf              = ""
+	defaultRPCMaxSteps            = 0
+	defaultRPCMaxMemory           = 0
+	defaultRPCExecutionTimeout    = time.Duration(0)
+	defaultRPCMaxResponseSize     = 0
+	defaultIndexEvents            = false
+	defaultIndexBackfillRate      = 100
+	defaultJobConcurrency         = 2
+	defaultRecentEventsBlocks     = 0
+	defaultRecentEventsRate       = 10
+	defaultEventFilters           = 0
+	defaultAnalyticsDays          = 0
+	defaultMigrationBackupDir     = ""
+	defaultMigrationWorkers       = 0
+	defaultMigrationBatchSize     = 0
+	defaultMigrationMemoryBudget  = 0
+	defaultMigrationChunkSize     = 0
+	defaultMigrationOnInterrupted = "resume"
+	defaultMigrateInBackground    = false
+	defaultRefuseToMigrate        = false
+	defaultColdDBPath             = ""
+	defaultColdDBBackend          = ""
+	defaultStateDBPath            = ""
+	defaultStateHistoryBlocks     = 0
+	defaultDevnet                 = false
+	defaultDevnetBlockTime        = 10 * time.Second
+	defaultDevnetAccounts         = 10
+	defaultDevnetSeed             = 0
+	defaultDevnetAccountClass     = ""
+	defaultDevnetFeeTokenClass    = ""
+	defaultForensicsDir           = ""
 
 	configFlagUsage   = "The yaml configuration file."
 	logLevelFlagUsage = "Options: debug, info, warn, error."
@@ -64,8 +181,9 @@ const (
 	wsPortUsage       = "The port on which the Websocket RPC server will listen for requests."
 	grpcPortUsage     = "The port on which the gRPC server will listen for requests."
 	dbPathUsage       = "Location of the database files."
-	networkUsage      = "Options: mainnet, goerli, goerli2, integration."
-	pprofUsage        = "Enables the pprof server and listens on port 9080."
+	dbBackendUsage    = "The key-value store the database is kept in. Options:"
+	networkUsage      = "Options: mainnet, goerli, goerli2, integration, or one defined under networks in the config file."
+	pprofUsage        = "Enables the pprof server and listens on port 9080, it also serves the diagnostics for juno diag."
 	colourUsage       = "Uses --colour=false command to disable colourized outputs (ANSI Escape Codes)."
 	ethNodeUsage      = "Websocket endpoint of the Ethereum node. In order to verify the correctness of the L2 chain, " +
 		"Juno must connect to an Ethereum node and parse events in the Starknet contract."
@@ -75,6 +193,111 @@ const (
 	p2pBootPeersUsage        = "specify list of p2p boot peers splitted by a comma"
 	metricsUsage             = "enable prometheus endpoint"
 	metricsPortUsage         = "The port on which the prometheus server will listen for requests"
+	feederGatewayPortUsage   = "The port on which the feeder gateway compatible HTTP server will listen for requests " +
+		"(disabled by default)."
+	otlpEndpointUsage = "The host:port of an OpenTelemetry collector to which traces are exported over OTLP/gRPC " +
+		"(disabled by default)."
+	otlpInsecureUsage    = "Disables TLS for the connection to the OpenTelemetry collector."
+	otlpSampleRatioUsage = "The fraction of traces that are sampled, between 0 and 1."
+	graphQLPortUsage     = "The port on which the GraphQL server will listen for requests on the /graphql path " +
+		"(disabled by default)."
+	abiDecodingUsage = "Stores the ABIs of the declared classes and decodes the events and the calldata with them in " +
+		"the GraphQL API and in the webhooks that set decode."
+	memoryBudgetUsage = "The memory in MiB shared by the database block cache, the header and event caches and the VM class cache."
+	stallTimeoutUsage = "How long sync may store no block while the network advances, or a database commit may take, " +
+		"before they are reported as stalled (0 disables stall detection)."
+	watchdogRestartUsage = "Restarts sync when it is stalled."
+	auditUsage           = "Re-executes the stored blocks in the background and reports the transactions whose events " +
+		"or messages to L1 do not match their receipts."
+	auditFromUsage         = "The block the re-execution audit starts from."
+	telemetryEndpointUsage = "The http(s) URL that anonymous telemetry (version, network, sync height, sync rate, " +
+		"platform and memory usage) is sent to every hour (disabled by default)."
+	replicaOfUsage = "The host:port of the gRPC server of a primary node (see --grpc-port). The node then serves " +
+		"reads from the database of the primary instead of its own and does not sync (disabled by default)."
+	rpcMaxStepsUsage = "The Cairo steps a call, or each transaction, executed by an RPC request may take " +
+		"(0 keeps the limits of the VM)."
+	rpcMaxMemoryUsage        = "The memory in MiB an execution of an RPC request may allocate (0 for no limit)."
+	rpcExecutionTimeoutUsage = "The wall time an execution of an RPC request may take (0 for no limit)."
+	rpcMaxResponseSizeUsage  = "The size in MiB of the responses of starknet_getEvents, which is then truncated with a " +
+		"continuation token, and of starknet_getClass, starknet_getClassAt, starknet_traceTransaction and " +
+		"starknet_simulateTransactions, which then fail (0 for no limit)."
+	indexEventsUsage = "Builds an index of the blocks with events of each contract in the background, which speeds " +
+		"up the event filters that match a contract address."
+	indexBackfillRateUsage = "The blocks per second the optional indexes are built from at most, so that building " +
+		"them over the stored blocks does not starve sync and RPC of disk IO (0 for no limit)."
+	jobConcurrencyUsage = "The number of background jobs, such as building the optional indexes, moving data to " +
+		"the cold database and the audit, that run at once. The jobs with a higher priority run first."
+	labelsUsage = "Files of the labels of contract addresses and event selectors (YAML or JSON), shown by the " +
+		"APIs and logs of the node."
+	recentEventsBlocksUsage = "The number of recent blocks whose events are kept in memory and served by " +
+		"juno_getRecentEvents to polling clients (0 disables it)."
+	recentEventsRateUsage = "The requests per second juno_getRecentEvents serves at most (0 for no limit)."
+	eventFiltersUsage     = "The number of event filters clients can install with juno_newEventFilter at most, which " +
+		"are kept in the database across restarts (0 disables them)."
+	analyticsDaysUsage = "The number of days whose aggregates of the activity of the chain are kept and served " +
+		"by juno_getDailyAnalytics (0 disables them)."
+	migrationBackupDirUsage = "The directory the database is backed up to before the migrations that rewrite it, " +
+		"so that it can be restored if they fail. The database is not backed up if it is empty."
+	migrationWorkersUsage = "The number of goroutines the migrations process blocks with in parallel " +
+		"(0 for the number of CPUs)."
+	migrationBatchSizeUsage    = "The number of blocks the migrations hold in memory at once (0 for 1024)."
+	migrationMemoryBudgetUsage = "The memory, in MiB, the blocks the migrations hold at once and the writes of " +
+		"their transactions may take, lower it on machines with little RAM (0 for no limit)."
+	migrationChunkSizeUsage = "The number of entries the migrations that rewrite buckets, such as the re-encoding " +
+		"of the trie nodes, migrate in a transaction (0 for 1000000)."
+	migrationOnInterruptedUsage = "What is done with a migration that was interrupted by a crash or a stop of the " +
+		"node. Options: resume, verify (checks the data it left before resuming it), fail."
+	migrateInBackgroundUsage = "Applies the migrations that only rewrite the data of each block, such as the " +
+		"calculation of the block commitments, while the node syncs and serves instead of before it starts."
+	refuseToMigrateUsage = "Fails to start, listing the pending migrations, if the database is behind instead of " +
+		"migrating it, and does not resume the migrations deferred to the background."
+	coldDBPathUsage = "The path of the database the cold data is moved to, usually on a slower volume than the " +
+		"database. The data is read from it transparently (empty disables it)."
+	coldAfterUsage = "The age, in blocks behind the head, after which the data of a category is moved to the cold " +
+		"database, as category=age pairs. The categories are bodies, state-updates and classes."
+	coldDBBackendUsage = "The key-value store the cold database is kept in, that of the database if empty. The " +
+		"freezer keeps the bodies and the state updates in append-only files that are never compacted."
+	stateDBPathUsage = "The path of the database the state is kept in, usually on a faster volume than the history " +
+		"of the chain. The state is moved to it the first time it is set (empty keeps it in the database)."
+	dbReadCacheUsage = "The groups of buckets whose values are cached in memory as they are read, from the share of " +
+		"the memory budget of each group, for the nodes under heavy RPC load. Options:"
+	stateHistoryBlocksUsage = "The number of blocks behind the head whose state is kept, the history of the state of " +
+		"the blocks before is pruned, so that they can no longer be read or reverted (0 keeps the whole history)."
+	dbCacheSizeUsage = "The size in MiB of the block cache of the database, which is then not part of the memory " +
+		"budget (0 for its share of the memory budget)."
+	dbMemTableSizeUsage = "The size in MiB of the memtables the writes to the database are buffered in, larger " +
+		"memtables flush less often on the machines with memory to spare (0 for the default of the backend)."
+	dbMaxOpenFilesUsage = "The number of files the database keeps open at most (0 for the default of the backend)."
+	dbCompactionsUsage  = "The number of compactions of the database that run at once at most, more keep up with " +
+		"the writes of sync on the machines with spare cores (0 for the default of the backend)."
+	dbWALDirUsage = "The directory of the write-ahead log of the database, usually on a faster volume than the " +
+		"database (the directory of the database if empty)."
+	dbWALSyncSizeUsage = "The MiB written to the write-ahead log after which it is synced to disk in the " +
+		"background, which smooths out the latency of the commits (0 disables it)."
+	dbSlowThresholdUsage = "The time after which the reads, writes, iterator moves and commits of the chain on the " +
+		"database are logged as slow, with their bucket and key size (0 disables it)."
+	dbWALArchiveDirUsage = "The directory the write-ahead logs of the database are archived to once they are " +
+		"flushed, so that a backup can be restored to a point in time with `juno db restore --wal-archive` " +
+		"(empty deletes them)."
+	dbCompressUsage = "The buckets whose values are compressed with zstd when they are written, those that are " +
+		"already stored are rewritten when the node starts. Options:"
+	dbChecksumUsage = "The buckets whose values are checksummed when they are written, so that a value corrupted on " +
+		"disk is detected when it is read. The values of the buckets that are added or removed are rewritten when the " +
+		"node starts. Options:"
+	dbChecksumVerifyUsage = "What is done when the checksum of a value read from the database does not match: fail " +
+		"fails the read, log logs the corrupted value and reads it, off does not verify the checksums."
+	devnetUsage = "Runs a local devnet instead of syncing a network: the transactions submitted to the node are " +
+		"executed into a block every devnet block time, starting from a genesis block with prefunded accounts."
+	devnetBlockTimeUsage = "The time between the blocks of the devnet."
+	devnetAccountsUsage  = "The number of prefunded accounts of the devnet, their keys are served by " +
+		"juno_getDevnetAccounts."
+	devnetSeedUsage = "The seed the keys of the devnet accounts are derived from, the same seed derives the same " +
+		"accounts."
+	devnetAccountClassUsage  = "The file of the Cairo 0 class of the devnet accounts, in the format of the feeder gateway."
+	devnetFeeTokenClassUsage = "The file of the Cairo 0 ERC20 class of the devnet fee token, in the format of the " +
+		"feeder gateway."
+	forensicsDirUsage = "The directory the forensic reports of the blocks whose state root does not match the local " +
+		"state are written to (the forensics directory next to the database by default)."
 )
 
 var Version string
@@ -87,6 +310,10 @@ func main() {
 	go func() {
 		<-quit
 		cancel()
+		// a second signal skips the graceful shutdown, the DB recovers from its WAL on the next start
+		<-quit
+		fmt.Fprintln(os.Stderr, "Forcing shutdown")
+		os.Exit(1)
 	}()
 
 	config := new(node.Config)
@@ -98,15 +325,100 @@ func main() {
 			return err
 		}
 
+		go reloadOnHangup(cmd.Context(), cmd, n)
 		n.Run(cmd.Context())
 		return nil
 	})
+	cmd.AddCommand(NewDiagCmd(), NewSnapshotCmd(), NewConfigCmd(), NewDBCmd(), NewMigrateCmd(), NewBenchCmd(),
+		NewPruneCmd())
 
 	if err := cmd.ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
 }
 
+// envPrefix is the prefix of the environment variables that set the options, JUNO_HTTP_PORT sets --http-port
+const envPrefix = "JUNO"
+
+// newViper returns a viper that resolves every option from, in order of precedence, the flags of cmd that
+// were set, the environment variables, the configuration file and the defaults of the flags. The networks
+// of the configuration file are registered so that the network option can refer to them.
+func newViper(cmd *cobra.Command) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+	if err := v.BindPFlags(cmd.Flags()); err != nil {
+		return nil, err
+	}
+
+	// the configuration file can itself be set by JUNO_CONFIG
+	if cfgFile := v.GetString(configF); cfgFile != "" {
+		v.SetConfigType("yaml")
+		v.SetConfigFile(cfgFile)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	// custom networks must be registered before the network is unmarshalled so that it can refer to them
+	var networks []utils.NetworkDefinition
+	if err := v.UnmarshalKey("networks", &networks); err != nil {
+		return nil, err
+	}
+	for _, network := range networks {
+		if _, err := utils.RegisterNetwork(network); err != nil {
+			return nil, err
+		}
+	}
+	var verifications map[string]utils.Verification
+	if err := v.UnmarshalKey("verification", &verifications); err != nil {
+		return nil, err
+	}
+	for name, verification := range verifications {
+		var network utils.Network
+		if err := network.Set(name); err != nil {
+			return nil, fmt.Errorf("verification of network %q: %w", name, err)
+		}
+		if err := utils.SetVerification(network, verification); err != nil {
+			return nil, fmt.Errorf("verification of network %q: %w", name, err)
+		}
+	}
+	return v, nil
+}
+
+// loadConfig populates config from the flags of cmd, the environment and the configuration file, see newViper.
+// It is called again whenever the configuration is reloaded, so that changes to the file are picked up.
+func loadConfig(cmd *cobra.Command, config *node.Config) error {
+	v, err := newViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	// TextUnmarshallerHookFunc allows us to unmarshal values that satisfy the
+	// encoding.TextUnmarshaller interface (see the LogLevel type for an example).
+	return v.Unmarshal(config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.TextUnmarshallerHookFunc(), mapstructure.StringToTimeDurationHookFunc())))
+}
+
+// reloadOnHangup reloads the configuration of n every time the process receives SIGHUP
+func reloadOnHangup(ctx context.Context, cmd *cobra.Command, n *node.Node) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			n.Reload(func(config *node.Config) error {
+				return loadConfig(cmd, config)
+			})
+		}
+	}
+}
+
 // NewCmd returns a command that can be executed with any of the Cobra Execute* functions.
 // The RunE field is set to the user-provided run function, allowing for robust testing setups.
 //
@@ -116,57 +428,106 @@ func main() {
 //  4. Cobra calls the run function.
 func NewCmd(config *node.Config, run func(*cobra.Command, []string) error) *cobra.Command {
 	junoCmd := &cobra.Command{
-		Use:     "juno [flags]",
-		Short:   "Starknet client implementation in Go.",
+		Use:   "juno [flags]",
+		Short: "Starknet client implementation in Go.",
+		Long: "Starknet client implementation in Go.\n\nOptions are set, in order of precedence, by flags, by " +
+			envPrefix + "_<OPTION> environment variables such as " + envPrefix + "_HTTP_PORT, by the configuration " +
+			"file and by defaults. Use juno config show to see where each option is set.",
 		Version: Version,
 		RunE:    run,
 	}
 
-	var cfgFile string
-
 	// PreRunE populates the configuration struct from the Cobra flags and Viper configuration.
 	// This is called in step 3 of the process described above.
 	junoCmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
-		v := viper.New()
-		if cfgFile != "" {
-			v.SetConfigType("yaml")
-			v.SetConfigFile(cfgFile)
-			if err := v.ReadInConfig(); err != nil {
-				return err
-			}
-		}
+		return loadConfig(cmd, config)
+	}
 
-		if err := v.BindPFlags(cmd.Flags()); err != nil {
-			return nil
-		}
+	addNodeFlags(junoCmd.Flags())
 
-		// TextUnmarshallerHookFunc allows us to unmarshal values that satisfy the
-		// encoding.TextUnmarshaller interface (see the LogLevel type for an example).
-		return v.Unmarshal(config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
-			mapstructure.TextUnmarshallerHookFunc(), mapstructure.StringToTimeDurationHookFunc())))
-	}
+	return junoCmd
+}
 
+// addNodeFlags adds the flags of the options of the node to flags
+func addNodeFlags(flags *pflag.FlagSet) {
 	// For testing purposes, these variables cannot be declared outside the function because Cobra
 	// may mutate their values.
 	defaultLogLevel := utils.INFO
-	defaultNetwork := utils.MAINNET
-
-	junoCmd.Flags().StringVar(&cfgFile, configF, defaultConfig, configFlagUsage)
-	junoCmd.Flags().Var(&defaultLogLevel, logLevelF, logLevelFlagUsage)
-	junoCmd.Flags().Uint16(httpPortF, defaultHTTPPort, httpPortUsage)
-	junoCmd.Flags().Uint16(wsPortF, defaultWSPort, wsPortUsage)
-	junoCmd.Flags().Uint16(grpcPortF, defaultGRPCPort, grpcPortUsage)
-	junoCmd.Flags().String(dbPathF, defaultDBPath, dbPathUsage)
-	junoCmd.Flags().Var(&defaultNetwork, networkF, networkUsage)
-	junoCmd.Flags().String(ethNodeF, defaultEthNode, ethNodeUsage)
-	junoCmd.Flags().Bool(pprofF, defaultPprof, pprofUsage)
-	junoCmd.Flags().Bool(colourF, defaultColour, colourUsage)
-	junoCmd.Flags().Duration(pendingPollIntervalF, defaultPendingPollInterval, pendingPollIntervalUsage)
-	junoCmd.Flags().Bool(p2pF, defaultP2p, p2pUsage)
-	junoCmd.Flags().String(p2pAddrF, defaultP2pAddr, p2PAddrUsage)
-	junoCmd.Flags().String(p2pBootPeersF, defaultP2pBootPeers, p2pBootPeersUsage)
-	junoCmd.Flags().Bool(metricsF, defaultMetrics, metricsUsage)
-	junoCmd.Flags().Uint16(metricsPortF, defaultMetricsPort, metricsPortUsage)
 
-	return junoCmd
+	flags.String(configF, defaultConfig, configFlagUsage)
+	flags.Var(&defaultLogLevel, logLevelF, logLevelFlagUsage)
+	flags.Uint16(httpPortF, defaultHTTPPort, httpPortUsage)
+	flags.Uint16(wsPortF, defaultWSPort, wsPortUsage)
+	flags.Uint16(grpcPortF, defaultGRPCPort, grpcPortUsage)
+	flags.String(dbPathF, defaultDBPath, dbPathUsage)
+	flags.String(dbBackendF, defaultDBBackend, dbBackendUsage+" "+strings.Join(db.Backends(), ", ")+".")
+	flags.StringSlice(dbCompressF, nil, dbCompressUsage+" "+strings.Join(db.CompressibleBucketNames(), ", ")+".")
+	flags.StringSlice(dbChecksumF, nil, dbChecksumUsage+" "+strings.Join(db.ChecksummableBucketNames(), ", ")+".")
+	flags.String(dbChecksumVerifyF, defaultDBChecksumVerify, dbChecksumVerifyUsage)
+	flags.Uint(dbCacheSizeF, defaultDBCacheSize, dbCacheSizeUsage)
+	flags.Uint(dbMemTableSizeF, defaultDBMemTableSize, dbMemTableSizeUsage)
+	flags.Uint(dbMaxOpenFilesF, defaultDBMaxOpenFiles, dbMaxOpenFilesUsage)
+	flags.Uint(dbCompactionsF, defaultDBCompactions, dbCompactionsUsage)
+	flags.String(dbWALDirF, defaultDBWALDir, dbWALDirUsage)
+	flags.Uint(dbWALSyncSizeF, defaultDBWALSyncSize, dbWALSyncSizeUsage)
+	flags.Duration(dbSlowThresholdF, defaultDBSlowThreshold, dbSlowThresholdUsage)
+	flags.String(dbWALArchiveDirF, defaultDBWALArchiveDir, dbWALArchiveDirUsage)
+	// the network is a string flag so that it can name the networks of the configuration file, which are only
+	// known once the file is read
+	flags.String(networkF, defaultNetwork, networkUsage)
+	flags.String(ethNodeF, defaultEthNode, ethNodeUsage)
+	flags.Bool(pprofF, defaultPprof, pprofUsage)
+	flags.Bool(colourF, defaultColour, colourUsage)
+	flags.Duration(pendingPollIntervalF, defaultPendingPollInterval, pendingPollIntervalUsage)
+	flags.Bool(p2pF, defaultP2p, p2pUsage)
+	flags.String(p2pAddrF, defaultP2pAddr, p2PAddrUsage)
+	flags.String(p2pBootPeersF, defaultP2pBootPeers, p2pBootPeersUsage)
+	flags.Bool(metricsF, defaultMetrics, metricsUsage)
+	flags.Uint16(metricsPortF, defaultMetricsPort, metricsPortUsage)
+	flags.Uint16(feederGatewayPortF, defaultFeederGatewayPort, feederGatewayPortUsage)
+	flags.String(otlpEndpointF, defaultOTLPEndpoint, otlpEndpointUsage)
+	flags.Bool(otlpInsecureF, defaultOTLPInsecure, otlpInsecureUsage)
+	flags.Float64(otlpSampleRatioF, defaultOTLPSampleRatio, otlpSampleRatioUsage)
+	flags.Uint16(graphQLPortF, defaultGraphQLPort, graphQLPortUsage)
+	flags.Bool(abiDecodingF, defaultABIDecoding, abiDecodingUsage)
+	flags.Uint(memoryBudgetF, defaultMemoryBudget, memoryBudgetUsage)
+	flags.Duration(stallTimeoutF, defaultStallTimeout, stallTimeoutUsage)
+	flags.Bool(watchdogRestartF, defaultWatchdogRestart, watchdogRestartUsage)
+	flags.Bool(auditF, defaultAudit, auditUsage)
+	flags.Uint64(auditFromF, defaultAuditFrom, auditFromUsage)
+	flags.String(telemetryEndpointF, defaultTelemetryEndpoint, telemetryEndpointUsage)
+	flags.String(replicaOfF, defaultReplicaOf, replicaOfUsage)
+	flags.Uint64(rpcMaxStepsF, defaultRPCMaxSteps, rpcMaxStepsUsage)
+	flags.Uint(rpcMaxMemoryF, defaultRPCMaxMemory, rpcMaxMemoryUsage)
+	flags.Duration(rpcExecutionTimeoutF, defaultRPCExecutionTimeout, rpcExecutionTimeoutUsage)
+	flags.Uint(rpcMaxResponseSizeF, defaultRPCMaxResponseSize, rpcMaxResponseSizeUsage)
+	flags.Bool(indexEventsF, defaultIndexEvents, indexEventsUsage)
+	flags.Uint(indexBackfillRateF, defaultIndexBackfillRate, indexBackfillRateUsage)
+	flags.Int(jobConcurrencyF, defaultJobConcurrency, jobConcurrencyUsage)
+	flags.StringSlice(labelsF, nil, labelsUsage)
+	flags.Uint64(recentEventsBlocksF, defaultRecentEventsBlocks, recentEventsBlocksUsage)
+	flags.Uint(recentEventsRateF, defaultRecentEventsRate, recentEventsRateUsage)
+	flags.Uint(eventFiltersF, defaultEventFilters, eventFiltersUsage)
+	flags.Uint64(analyticsDaysF, defaultAnalyticsDays, analyticsDaysUsage)
+	flags.String(migrationBackupDirF, defaultMigrationBackupDir, migrationBackupDirUsage)
+	flags.Int(migrationWorkersF, defaultMigrationWorkers, migrationWorkersUsage)
+	flags.Uint64(migrationBatchSizeF, defaultMigrationBatchSize, migrationBatchSizeUsage)
+	flags.Uint64(migrationMemoryBudgetF, defaultMigrationMemoryBudget, migrationMemoryBudgetUsage)
+	flags.Uint64(migrationChunkSizeF, defaultMigrationChunkSize, migrationChunkSizeUsage)
+	flags.String(migrationOnInterruptedF, defaultMigrationOnInterrupted, migrationOnInterruptedUsage)
+	flags.Bool(migrateInBackgroundF, defaultMigrateInBackground, migrateInBackgroundUsage)
+	flags.Bool(refuseToMigrateF, defaultRefuseToMigrate, refuseToMigrateUsage)
+	flags.String(coldDBPathF, defaultColdDBPath, coldDBPathUsage)
+	flags.StringSlice(coldAfterF, nil, coldAfterUsage)
+	flags.String(coldDBBackendF, defaultColdDBBackend, coldDBBackendUsage)
+	flags.String(stateDBPathF, defaultStateDBPath, stateDBPathUsage)
+	flags.StringSlice(dbReadCacheF, nil, dbReadCacheUsage+" "+strings.Join(cached.CacheNames(), ", ")+".")
+	flags.Uint64(stateHistoryBlocksF, defaultStateHistoryBlocks, stateHistoryBlocksUsage)
+	flags.Bool(devnetF, defaultDevnet, devnetUsage)
+	flags.Duration(devnetBlockTimeF, defaultDevnetBlockTime, devnetBlockTimeUsage)
+	flags.Uint64(devnetAccountsF, defaultDevnetAccounts, devnetAccountsUsage)
+	flags.Uint64(devnetSeedF, defaultDevnetSeed, devnetSeedUsage)
+	flags.String(devnetAccountClassF, defaultDevnetAccountClass, devnetAccountClassUsage)
+	flags.String(devnetFeeTokenClassF, defaultDevnetFeeTokenClass, devnetFeeTokenClassUsage)
+	flags.String(forensicsDirF, defaultForensicsDir, forensicsDirUsage)
 }
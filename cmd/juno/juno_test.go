@@ -9,6 +9,7 @@ import (
 	juno "github.com/NethermindEth/juno/cmd/juno"
 	"github.com/NethermindEth/juno/node"
 	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/vm"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,10 +31,28 @@ func TestConfigPrecedence(t *testing.T) {
 	defaultColour := true
 	defaultPendingPollInterval := time.Duration(0)
 	defaultMetricsPort := uint16(9090)
+	defaultOTLPSampleRatio := 1.0
+	defaultMemoryBudget := uint(512)
+	defaultStallTimeout := 10 * time.Minute
+	defaultIndexBackfillRate := uint(100)
+	defaultJobConcurrency := 2
+	defaultDBBackend := "pebble"
+	defaultMigrationOnInterrupted := "resume"
+	defaultLabels := []string{}
+	defaultColdAfter := []string{}
+	defaultDBCompression := []string{}
+	defaultDBChecksum := []string{}
+	defaultDBChecksumVerify := "fail"
+	defaultDBReadCache := []string{}
+	defaultRecentEventsRate := uint(10)
+	defaultDevnetBlockTime := 10 * time.Second
+	defaultDevnetAccounts := uint64(10)
 
 	tests := map[string]struct {
 		cfgFile         bool
 		cfgFileContents string
+		cfgFileInEnv    bool
+		env             map[string]string
 		expectErr       bool
 		inputArgs       []string
 		expectedConfig  *node.Config
@@ -41,29 +60,61 @@ func TestConfigPrecedence(t *testing.T) {
 		"default config with no flags": {
 			inputArgs: []string{""},
 			expectedConfig: &node.Config{
-				LogLevel:            defaultLogLevel,
-				HTTPPort:            defaultHTTPPort,
-				WSPort:              defaultWSPort,
-				DatabasePath:        defaultDBPath,
-				Network:             defaultNetwork,
-				Pprof:               defaultPprof,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               defaultLogLevel,
+				HTTPPort:               defaultHTTPPort,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           defaultDBPath,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                defaultNetwork,
+				Pprof:                  defaultPprof,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"config file path is empty string": {
 			inputArgs: []string{"--config", ""},
 			expectedConfig: &node.Config{
-				LogLevel:            defaultLogLevel,
-				HTTPPort:            defaultHTTPPort,
-				WSPort:              defaultWSPort,
-				DatabasePath:        defaultDBPath,
-				Network:             defaultNetwork,
-				Pprof:               defaultPprof,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               defaultLogLevel,
+				HTTPPort:               defaultHTTPPort,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           defaultDBPath,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                defaultNetwork,
+				Pprof:                  defaultPprof,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"config file doesn't exist": {
@@ -74,13 +125,29 @@ func TestConfigPrecedence(t *testing.T) {
 			cfgFile:         true,
 			cfgFileContents: "\n",
 			expectedConfig: &node.Config{
-				LogLevel:            defaultLogLevel,
-				HTTPPort:            defaultHTTPPort,
-				WSPort:              defaultWSPort,
-				Network:             defaultNetwork,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               defaultLogLevel,
+				HTTPPort:               defaultHTTPPort,
+				WSPort:                 defaultWSPort,
+				Network:                defaultNetwork,
+				Colour:                 defaultColour,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"config file with all settings but without any other flags": {
@@ -92,15 +159,31 @@ network: goerli2
 pprof: true
 `,
 			expectedConfig: &node.Config{
-				LogLevel:            utils.DEBUG,
-				HTTPPort:            4576,
-				WSPort:              defaultWSPort,
-				DatabasePath:        "/home/.juno",
-				Network:             utils.GOERLI2,
-				Pprof:               true,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               utils.DEBUG,
+				HTTPPort:               4576,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           "/home/.juno",
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.GOERLI2,
+				Pprof:                  true,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"config file with some settings but without any other flags": {
@@ -109,15 +192,31 @@ pprof: true
 http-port: 4576
 `,
 			expectedConfig: &node.Config{
-				LogLevel:            utils.DEBUG,
-				HTTPPort:            4576,
-				WSPort:              defaultWSPort,
-				DatabasePath:        defaultDBPath,
-				Network:             defaultNetwork,
-				Pprof:               defaultPprof,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               utils.DEBUG,
+				HTTPPort:               4576,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           defaultDBPath,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                defaultNetwork,
+				Pprof:                  defaultPprof,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"all flags without config file": {
@@ -126,14 +225,30 @@ http-port: 4576
 				"--db-path", "/home/.juno", "--network", "goerli", "--pprof",
 			},
 			expectedConfig: &node.Config{
-				LogLevel:     utils.DEBUG,
-				HTTPPort:     4576,
-				WSPort:       defaultWSPort,
-				DatabasePath: "/home/.juno",
-				Network:      utils.GOERLI,
-				Pprof:        true,
-				Colour:       defaultColour,
-				MetricsPort:  defaultMetricsPort,
+				LogLevel:               utils.DEBUG,
+				HTTPPort:               4576,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           "/home/.juno",
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.GOERLI,
+				Pprof:                  true,
+				Colour:                 defaultColour,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"some flags without config file": {
@@ -142,14 +257,30 @@ http-port: 4576
 				"--network", "integration",
 			},
 			expectedConfig: &node.Config{
-				LogLevel:            utils.DEBUG,
-				HTTPPort:            4576,
-				WSPort:              defaultWSPort,
-				DatabasePath:        "/home/.juno",
-				Network:             utils.INTEGRATION,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               utils.DEBUG,
+				HTTPPort:               4576,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           "/home/.juno",
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.INTEGRATION,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"all setting set in both config file and flags": {
@@ -166,15 +297,31 @@ pending-poll-interval: 5s
 				"--db-path", "/home/flag/.juno", "--network", "integration", "--pprof", "--pending-poll-interval", time.Millisecond.String(),
 			},
 			expectedConfig: &node.Config{
-				LogLevel:            utils.ERROR,
-				HTTPPort:            4577,
-				WSPort:              defaultWSPort,
-				DatabasePath:        "/home/flag/.juno",
-				Network:             utils.INTEGRATION,
-				Pprof:               true,
-				Colour:              defaultColour,
-				PendingPollInterval: time.Millisecond,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               utils.ERROR,
+				HTTPPort:               4577,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           "/home/flag/.juno",
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.INTEGRATION,
+				Pprof:                  true,
+				Colour:                 defaultColour,
+				PendingPollInterval:    time.Millisecond,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"some setting set in both config file and flags": {
@@ -185,15 +332,187 @@ network: goerli
 `,
 			inputArgs: []string{"--db-path", "/home/flag/.juno", "--ws-port", "4577"},
 			expectedConfig: &node.Config{
-				LogLevel:            utils.WARN,
-				HTTPPort:            4576,
-				WSPort:              4577,
-				DatabasePath:        "/home/flag/.juno",
-				Network:             utils.GOERLI,
-				Pprof:               defaultPprof,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               utils.WARN,
+				HTTPPort:               4576,
+				WSPort:                 4577,
+				DatabasePath:           "/home/flag/.juno",
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.GOERLI,
+				Pprof:                  defaultPprof,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
+			},
+		},
+		"settings set in the environment": {
+			env: map[string]string{
+				"JUNO_LOG_LEVEL":             "debug",
+				"JUNO_HTTP_PORT":             "4576",
+				"JUNO_NETWORK":               "goerli",
+				"JUNO_PPROF":                 "true",
+				"JUNO_PENDING_POLL_INTERVAL": "5s",
+				"JUNO_MEMORY_BUDGET":         "1024",
+			},
+			inputArgs: []string{""},
+			expectedConfig: &node.Config{
+				LogLevel:               utils.DEBUG,
+				HTTPPort:               4576,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           defaultDBPath,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.GOERLI,
+				Pprof:                  true,
+				Colour:                 defaultColour,
+				PendingPollInterval:    5 * time.Second,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           1024,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
+			},
+		},
+		"database tuning set in the config file and flags": {
+			cfgFile: true,
+			cfgFileContents: `db-memtable-size: 64
+db-max-open-files: 5000
+db-wal-dir: /fast/wal
+db-wal-archive-dir: /backup/wal
+`,
+			inputArgs: []string{
+				"--db-cache-size", "2048", "--db-compaction-concurrency", "4", "--db-wal-sync-size", "1",
+				"--db-slow-threshold", "500ms", "--db-read-cache", "headers,trie",
+			},
+			expectedConfig: &node.Config{
+				LogLevel:                      defaultLogLevel,
+				HTTPPort:                      defaultHTTPPort,
+				WSPort:                        defaultWSPort,
+				DatabasePath:                  defaultDBPath,
+				DatabaseBackend:               defaultDBBackend,
+				DatabaseCompression:           defaultDBCompression,
+				DatabaseChecksum:              defaultDBChecksum,
+				DatabaseChecksumVerify:        defaultDBChecksumVerify,
+				DatabaseCacheSize:             2048,
+				DatabaseMemTableSize:          64,
+				DatabaseMaxOpenFiles:          5000,
+				DatabaseCompactionConcurrency: 4,
+				DatabaseWALDir:                "/fast/wal",
+				DatabaseWALSyncSize:           1,
+				DatabaseSlowThreshold:         500 * time.Millisecond,
+				DatabaseWALArchiveDir:         "/backup/wal",
+				DatabaseReadCache:             []string{"headers", "trie"},
+				Network:                       defaultNetwork,
+				Pprof:                         defaultPprof,
+				Colour:                        defaultColour,
+				PendingPollInterval:           defaultPendingPollInterval,
+				MetricsPort:                   defaultMetricsPort,
+				OTLPSampleRatio:               defaultOTLPSampleRatio,
+				MemoryBudget:                  defaultMemoryBudget,
+				StallTimeout:                  defaultStallTimeout,
+				IndexBackfillRate:             defaultIndexBackfillRate,
+				JobConcurrency:                defaultJobConcurrency,
+				MigrationOnInterrupted:        defaultMigrationOnInterrupted,
+				Labels:                        defaultLabels,
+				ColdAfter:                     defaultColdAfter,
+				RecentEventsRate:              defaultRecentEventsRate,
+				DevnetBlockTime:               defaultDevnetBlockTime,
+				DevnetAccounts:                defaultDevnetAccounts,
+			},
+		},
+		"environment overrides the config file and flags override the environment": {
+			cfgFile: true,
+			cfgFileContents: `http-port: 4576
+ws-port: 4577
+network: goerli2
+`,
+			env: map[string]string{
+				"JUNO_WS_PORT": "4578",
+				"JUNO_NETWORK": "goerli",
+			},
+			inputArgs: []string{"--network", "integration"},
+			expectedConfig: &node.Config{
+				LogLevel:               defaultLogLevel,
+				HTTPPort:               4576,
+				WSPort:                 4578,
+				DatabasePath:           defaultDBPath,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.INTEGRATION,
+				Pprof:                  defaultPprof,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
+			},
+		},
+		"config file set in the environment": {
+			cfgFile:         true,
+			cfgFileContents: "http-port: 4576\n",
+			cfgFileInEnv:    true,
+			expectedConfig: &node.Config{
+				LogLevel:               defaultLogLevel,
+				HTTPPort:               4576,
+				WSPort:                 defaultWSPort,
+				DatabasePath:           defaultDBPath,
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                defaultNetwork,
+				Pprof:                  defaultPprof,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 		"some setting set in default, config file and flags": {
@@ -202,24 +521,47 @@ network: goerli
 ws-port: 4577`,
 			inputArgs: []string{"--db-path", "/home/flag/.juno", "--pprof"},
 			expectedConfig: &node.Config{
-				LogLevel:            defaultLogLevel,
-				HTTPPort:            defaultHTTPPort,
-				WSPort:              4577,
-				DatabasePath:        "/home/flag/.juno",
-				Network:             utils.GOERLI2,
-				Pprof:               true,
-				Colour:              defaultColour,
-				PendingPollInterval: defaultPendingPollInterval,
-				MetricsPort:         defaultMetricsPort,
+				LogLevel:               defaultLogLevel,
+				HTTPPort:               defaultHTTPPort,
+				WSPort:                 4577,
+				DatabasePath:           "/home/flag/.juno",
+				DatabaseBackend:        defaultDBBackend,
+				DatabaseCompression:    defaultDBCompression,
+				DatabaseChecksum:       defaultDBChecksum,
+				DatabaseChecksumVerify: defaultDBChecksumVerify,
+				Network:                utils.GOERLI2,
+				Pprof:                  true,
+				Colour:                 defaultColour,
+				PendingPollInterval:    defaultPendingPollInterval,
+				MetricsPort:            defaultMetricsPort,
+				OTLPSampleRatio:        defaultOTLPSampleRatio,
+				MemoryBudget:           defaultMemoryBudget,
+				StallTimeout:           defaultStallTimeout,
+				IndexBackfillRate:      defaultIndexBackfillRate,
+				JobConcurrency:         defaultJobConcurrency,
+				MigrationOnInterrupted: defaultMigrationOnInterrupted,
+				Labels:                 defaultLabels,
+				ColdAfter:              defaultColdAfter,
+				DatabaseReadCache:      defaultDBReadCache,
+				RecentEventsRate:       defaultRecentEventsRate,
+				DevnetBlockTime:        defaultDevnetBlockTime,
+				DevnetAccounts:         defaultDevnetAccounts,
 			},
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
 			if tc.cfgFile {
 				fileN := tempCfgFile(t, tc.cfgFileContents)
-				tc.inputArgs = append(tc.inputArgs, "--config", fileN)
+				if tc.cfgFileInEnv {
+					t.Setenv("JUNO_CONFIG", fileN)
+				} else {
+					tc.inputArgs = append(tc.inputArgs, "--config", fileN)
+				}
 			}
 
 			config := new(node.Config)
@@ -238,6 +580,110 @@ ws-port: 4577`,
 	}
 }
 
+func TestCustomNetwork(t *testing.T) {
+	networks := `networks:
+  - name: appchain
+    chain-id: SN_APPCHAIN
+    feeder-url: https://appchain.example.com/feeder_gateway/
+    gateway-url: https://appchain.example.com/gateway/
+    l1-chain-id: 11155111
+    core-contract-address: "0x1234567890123456789012345678901234567890"
+    unverifiable-range: [0, 10]
+`
+
+	run := func(t *testing.T, cfg string, args ...string) (*node.Config, error) {
+		t.Helper()
+		config := new(node.Config)
+		cmd := juno.NewCmd(config, func(_ *cobra.Command, _ []string) error { return nil })
+		cmd.SetArgs(append(args, "--config", tempCfgFile(t, cfg)))
+		return config, cmd.ExecuteContext(context.Background())
+	}
+
+	t.Run("network set in the config file", func(t *testing.T) {
+		config, err := run(t, networks+"network: appchain\n")
+		require.NoError(t, err)
+
+		assert.Equal(t, "appchain", config.Network.String())
+		assert.Equal(t, "SN_APPCHAIN", config.Network.ChainIDString())
+		assert.Equal(t, "https://appchain.example.com/feeder_gateway/", config.Network.FeederURL())
+		assert.Equal(t, "https://appchain.example.com/gateway/", config.Network.GatewayURL())
+		require.Len(t, config.Networks, 1)
+		assert.Equal(t, []uint64{0, 10}, config.Networks[0].UnverifiableRange)
+	})
+
+	t.Run("network set by flag", func(t *testing.T) {
+		config, err := run(t, networks, "--network", "appchain")
+		require.NoError(t, err)
+		assert.Equal(t, "appchain", config.Network.String())
+	})
+
+	t.Run("invalid network definition", func(t *testing.T) {
+		_, err := run(t, "networks:\n  - name: appchain\n", "--network", "appchain")
+		require.Error(t, err)
+	})
+
+	t.Run("unknown network", func(t *testing.T) {
+		_, err := run(t, networks, "--network", "otherchain")
+		require.ErrorContains(t, err, utils.ErrUnknownNetwork.Error())
+	})
+
+	t.Run("verification of a network", func(t *testing.T) {
+		config, err := run(t, networks+`verification:
+  appchain:
+    unverifiable-range: [5, 20]
+    trust-block-hashes: true
+`, "--network", "appchain")
+		require.NoError(t, err)
+
+		verification, ok := config.Network.Verification()
+		require.True(t, ok)
+		assert.Equal(t, utils.Verification{UnverifiableRange: []uint64{5, 20}, TrustBlockHashes: true}, verification)
+	})
+
+	t.Run("verification of an unknown network", func(t *testing.T) {
+		_, err := run(t, networks+"verification:\n  otherchain:\n    trust-block-hashes: true\n")
+		require.ErrorContains(t, err, utils.ErrUnknownNetwork.Error())
+	})
+}
+
+func TestExecutionLimits(t *testing.T) {
+	cfg := `rpc-max-memory: 256
+rpc-method-limits:
+  starknet_call:
+    max-steps: 100000
+    timeout: 2s
+  starknet_simulateTransactions:
+    max-memory: 1024
+`
+	config := new(node.Config)
+	cmd := juno.NewCmd(config, func(_ *cobra.Command, _ []string) error { return nil })
+	cmd.SetArgs([]string{"--config", tempCfgFile(t, cfg), "--rpc-max-steps", "1000000", "--rpc-execution-timeout", "10s"})
+	require.NoError(t, cmd.ExecuteContext(context.Background()))
+
+	assert.Equal(t, uint64(1000000), config.RPCMaxSteps)
+	assert.Equal(t, uint(256), config.RPCMaxMemory)
+	assert.Equal(t, 10*time.Second, config.RPCExecutionTimeout)
+	// the keys of the configuration file are lowercased
+	assert.Equal(t, map[string]vm.Limits{
+		"starknet_call":                 {MaxSteps: 100000, Timeout: 2 * time.Second},
+		"starknet_simulatetransactions": {MaxMemory: 1024},
+	}, config.RPCMethodLimits)
+}
+
+func TestMaxResponseSizes(t *testing.T) {
+	cfg := `rpc-method-response-sizes:
+  starknet_getEvents: 16
+`
+	config := new(node.Config)
+	cmd := juno.NewCmd(config, func(_ *cobra.Command, _ []string) error { return nil })
+	cmd.SetArgs([]string{"--config", tempCfgFile(t, cfg), "--rpc-max-response-size", "64"})
+	require.NoError(t, cmd.ExecuteContext(context.Background()))
+
+	assert.Equal(t, uint(64), config.RPCMaxResponseSize)
+	// the keys of the configuration file are lowercased
+	assert.Equal(t, map[string]uint{"starknet_getevents": 16}, config.RPCMethodResponseSizes)
+}
+
 func tempCfgFile(t *testing.T, cfg string) string {
 	t.Helper()
 
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	migrateToF = "to"
+
+	migrateDBPathUsage   = "Location of the database files."
+	migrateNetworkUsage  = "The network of the database. Options: mainnet, goerli, goerli2, integration."
+	migrateToUsage       = "The schema version to migrate the database to (the latest schema version by default)."
+	migrateEncodingUsage = "The values already stored are rewritten to match --db-compress and --db-checksum if " +
+		"either is set, as the node does when it starts, and are left as they are otherwise."
+
+	// migrateCacheSize is the size of the block cache of the database while it is migrated, migrations read
+	// most of the database so they benefit from a larger cache than the db commands
+	migrateCacheSize = 256 << 20
+)
+
+// NewMigrateCmd returns the migrate command, which applies the migrations to the database of a stopped node
+func NewMigrateCmd() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate [flags]",
+		Short: "Applies the migrations to the database without starting the node, the node must be stopped.",
+		Long: "Applies the migrations to the database without starting the node, the node must be stopped. " +
+			"Migrations can take hours on large databases, running them with this command keeps them apart from " +
+			"the startup of the node. Interrupted migrations are resumed by the next run or by the node.",
+		Args: cobra.NoArgs,
+		RunE: runMigrate,
+	}
+	defaultLogLevel := utils.INFO
+	migrateCmd.Flags().String(dbPathF, defaultDBPath, migrateDBPathUsage)
+	migrateCmd.Flags().String(networkF, defaultNetwork, migrateNetworkUsage)
+	migrateCmd.Flags().Uint64(migrateToF, migration.LatestSchemaVersion(), migrateToUsage)
+	migrateCmd.Flags().Var(&defaultLogLevel, logLevelF, logLevelFlagUsage)
+	migrateCmd.Flags().String(migrationBackupDirF, defaultMigrationBackupDir, migrationBackupDirUsage)
+	migrateCmd.Flags().Int(migrationWorkersF, defaultMigrationWorkers, migrationWorkersUsage)
+	migrateCmd.Flags().Uint64(migrationBatchSizeF, defaultMigrationBatchSize, migrationBatchSizeUsage)
+	migrateCmd.Flags().Uint64(migrationMemoryBudgetF, defaultMigrationMemoryBudget, migrationMemoryBudgetUsage)
+	migrateCmd.Flags().Uint64(migrationChunkSizeF, defaultMigrationChunkSize, migrationChunkSizeUsage)
+	migrateCmd.Flags().String(migrationOnInterruptedF, defaultMigrationOnInterrupted, migrationOnInterruptedUsage)
+	migrateCmd.Flags().StringSlice(dbCompressF, nil, dbCompressUsage+" "+
+		strings.Join(db.CompressibleBucketNames(), ", ")+". "+migrateEncodingUsage)
+	migrateCmd.Flags().StringSlice(dbChecksumF, nil, dbChecksumUsage+" "+
+		strings.Join(db.ChecksummableBucketNames(), ", ")+". "+migrateEncodingUsage)
+	migrateCmd.Flags().String(dbChecksumVerifyF, defaultDBChecksumVerify, dbChecksumVerifyUsage)
+	return migrateCmd
+}
+
+func runMigrate(cmd *cobra.Command, _ []string) error {
+	target, err := cmd.Flags().GetUint64(migrateToF)
+	if err != nil {
+		return err
+	}
+	opts, err := migrationOptions(cmd)
+	if err != nil {
+		return err
+	}
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+	var logLevel utils.LogLevel
+	if err = logLevel.Set(cmd.Flags().Lookup(logLevelF).Value.String()); err != nil {
+		return err
+	}
+	log, err := utils.NewZapLogger(logLevel, false)
+	if err != nil {
+		return err
+	}
+
+	dbOpts, err := migrateDBOptions(cmd)
+	if err != nil {
+		return err
+	}
+	dbOpts.Logger = log
+	database, err := openDBCmdDBWithOptions(cmd, dbOpts)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	version, err := migration.SchemaVersion(database)
+	if err != nil {
+		return err
+	}
+	if version == target {
+		cmd.Printf("The database is already at schema version %d\n", target)
+		return nil
+	}
+
+	// the progress is printed every percent, on top of the logs of the migrations
+	printed := make(map[uint64]int)
+	opts.OnProgress = func(progress migration.Progress) {
+		if progress.Total == 0 {
+			return
+		}
+		if percent := int(progress.Percent()); percent > printed[progress.Version] {
+			printed[progress.Version] = percent
+			cmd.Printf("Migration to schema version %d: %d%%\n", progress.Version, percent)
+		}
+	}
+	if err = migration.MigrateTo(cmd.Context(), database, network, target, log, opts); err != nil {
+		return fmt.Errorf("migrate from schema version %d: %w", version, err)
+	}
+	cmd.Printf("Migrated the database from schema version %d to %d\n", version, target)
+	return nil
+}
+
+// migrateDBOptions returns the options the database is migrated with, which rewrite the values that are already
+// stored if --db-compress or --db-checksum is set
+func migrateDBOptions(cmd *cobra.Command) (db.Options, error) {
+	opts := db.Options{CacheSize: migrateCacheSize}
+	if !cmd.Flags().Changed(dbCompressF) && !cmd.Flags().Changed(dbChecksumF) {
+		return opts, nil
+	}
+	compressed, err := cmd.Flags().GetStringSlice(dbCompressF)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Compression, err = db.NewCompression(compressed); err != nil {
+		return opts, err
+	}
+	checksummed, err := cmd.Flags().GetStringSlice(dbChecksumF)
+	if err != nil {
+		return opts, err
+	}
+	verify, err := cmd.Flags().GetString(dbChecksumVerifyF)
+	if err != nil {
+		return opts, err
+	}
+	mode, err := db.ParseChecksumMode(verify)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Checksums, err = db.NewChecksums(checksummed, mode); err != nil {
+		return opts, err
+	}
+	opts.Reencode = true
+	return opts, nil
+}
+
+// migrationOptions returns the options of the migrations set by the flags of cmd
+func migrationOptions(cmd *cobra.Command) (migration.MigrationOptions, error) {
+	var (
+		opts migration.MigrationOptions
+		err  error
+	)
+	if opts.BackupDir, err = cmd.Flags().GetString(migrationBackupDirF); err != nil {
+		return opts, err
+	}
+	if opts.DataDir, err = cmd.Flags().GetString(dbPathF); err != nil {
+		return opts, err
+	}
+	if opts.Workers, err = cmd.Flags().GetInt(migrationWorkersF); err != nil {
+		return opts, err
+	}
+	if opts.BatchSize, err = cmd.Flags().GetUint64(migrationBatchSizeF); err != nil {
+		return opts, err
+	}
+	if opts.MemoryBudget, err = cmd.Flags().GetUint64(migrationMemoryBudgetF); err != nil {
+		return opts, err
+	}
+	opts.MemoryBudget <<= 20
+	opts.JunoVersion = Version
+	if opts.ChunkSize, err = cmd.Flags().GetUint64(migrationChunkSizeF); err != nil {
+		return opts, err
+	}
+	onInterrupted, err := cmd.Flags().GetString(migrationOnInterruptedF)
+	if err != nil {
+		return opts, err
+	}
+	opts.OnInterrupted, err = migration.ParseInterruptedPolicy(onInterrupted)
+	return opts, err
+}
@@ -0,0 +1,101 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	pebbledb "github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := juno.NewMigrateCmd()
+		cmd.SetArgs(append([]string{"--db-path", dbPath, "--log-level", "error"}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+	schemaVersion := func() uint64 {
+		database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		version, err := migration.SchemaVersion(database)
+		require.NoError(t, err)
+		require.NoError(t, database.Close())
+		return version
+	}
+
+	out, err := run("--to", "2")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Migrated the database from schema version 0 to 2")
+	assert.Equal(t, uint64(2), schemaVersion())
+
+	_, err = run("--to", "1")
+	require.ErrorContains(t, err, "juno db revert")
+
+	out, err = run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "Migrated the database from schema version 2")
+	assert.Equal(t, migration.LatestSchemaVersion(), schemaVersion())
+
+	out, err = run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "already at schema version")
+
+	_, err = run("--log-level", "verbose")
+	require.Error(t, err)
+
+	t.Run("value encoding", func(t *testing.T) {
+		class := bytes.Repeat([]byte("class"), 100)
+		classKey := db.Class.Key([]byte{1})
+		database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.Set(classKey, class)
+		}))
+		require.NoError(t, database.Close())
+		storedSize := func() int {
+			database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, database.Close())
+			}()
+			value, closer, err := database.Impl().(*pebbledb.DB).Get(classKey)
+			require.NoError(t, err)
+			defer closer.Close()
+			return len(value)
+		}
+
+		// the values already stored are rewritten even though the database is at the latest schema version
+		_, err = run("--db-compress", "classes")
+		require.NoError(t, err)
+		assert.Less(t, storedSize(), len(class))
+
+		// they are left as they are without the flags
+		_, err = run()
+		require.NoError(t, err)
+		assert.Less(t, storedSize(), len(class))
+
+		_, err = run("--db-compress=", "--db-checksum", "trie")
+		require.NoError(t, err)
+		assert.Equal(t, len(class), storedSize())
+
+		_, err = run("--db-compress", "trie")
+		require.ErrorContains(t, err, "unknown compressible bucket")
+	})
+}
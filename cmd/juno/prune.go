@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/prune"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pruneDBPathUsage  = "Location of the database files."
+	pruneNetworkUsage = "The network of the database. Options: mainnet, goerli, goerli2, integration."
+	pruneBlocksUsage  = "The number of blocks behind the head whose state is kept, the history of the state of the " +
+		"blocks before is pruned."
+)
+
+// NewPruneCmd returns the prune command, which prunes the history of the state of the database of a stopped node
+func NewPruneCmd() *cobra.Command {
+	pruneCmd := &cobra.Command{
+		Use:   "prune [flags]",
+		Short: "Prunes the history of the state older than a number of blocks, the node must be stopped.",
+		Long: "Prunes the history of the state of the blocks that are more than --state-history-blocks behind the " +
+			"head, and compacts the database to reclaim its disk space. The state of the pruned blocks can no " +
+			"longer be read and the blocks can no longer be reverted. A node started with --state-history-blocks " +
+			"keeps the history pruned as the chain grows.",
+		Args: cobra.NoArgs,
+		RunE: runPrune,
+	}
+	pruneCmd.Flags().String(dbPathF, defaultDBPath, pruneDBPathUsage)
+	pruneCmd.Flags().String(networkF, defaultNetwork, pruneNetworkUsage)
+	pruneCmd.Flags().Uint64(stateHistoryBlocksF, defaultStateHistoryBlocks, pruneBlocksUsage)
+	return pruneCmd
+}
+
+func runPrune(cmd *cobra.Command, _ []string) error {
+	retention, err := cmd.Flags().GetUint64(stateHistoryBlocksF)
+	if err != nil {
+		return err
+	}
+	if retention == 0 {
+		return fmt.Errorf("--%s is required", stateHistoryBlocksF)
+	}
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return err
+	}
+	var network utils.Network
+	if err = network.Set(networkName); err != nil {
+		return err
+	}
+
+	database, err := openDBCmdDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	chain := blockchain.New(database, network, utils.NewNopZapLogger())
+	pruned, err := prune.New(database, chain, retention, utils.NewNopZapLogger()).Prune(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("prune after %d blocks: %w", pruned, err)
+	}
+	if pruned == 0 {
+		cmd.Println("The history of the state is already within the window, nothing was pruned")
+		return nil
+	}
+
+	before := database.DiskUsage()
+	if err = database.Compact(); err != nil {
+		return fmt.Errorf("compact DB: %w", err)
+	}
+	cmd.Printf("Pruned the history of the state of %d blocks, the database went from %s to %s\n", pruned,
+		formatBytes(before), formatBytes(database.DiskUsage()))
+	return nil
+}
@@ -0,0 +1,39 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := juno.NewPruneCmd()
+		cmd.SetArgs(append([]string{"--db-path", dbPath}, args...))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	_, err = run()
+	require.ErrorContains(t, err, "--state-history-blocks is required")
+
+	// the pruning itself is tested by the prune package
+	out, err := run("--state-history-blocks", "128")
+	require.NoError(t, err)
+	assert.Contains(t, out, "nothing was pruned")
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/snapshot"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	snapshotDirF    = "dir"
+	snapshotAddrF   = "addr"
+	snapshotSourceF = "source"
+
+	defaultSnapshotDir  = "."
+	defaultSnapshotAddr = ":8080"
+
+	snapshotDBPathUsage  = "Location of the database files."
+	snapshotOutputUsage  = "The file the snapshot is written to (juno_<network>_<block>.tar by default)."
+	snapshotDirUsage     = "The directory of the snapshots to serve."
+	snapshotAddrUsage    = "The host:port the snapshots are served on."
+	snapshotSourceUsage  = "The path or http(s) URL of the snapshot to import."
+	snapshotNetworkUsage = "The network of the snapshot. Options: mainnet, goerli, goerli2, integration."
+
+	// snapshotCacheSize is the size of the block cache of the database a snapshot is created from
+	snapshotCacheSize = 64 << 20
+)
+
+// NewSnapshotCmd returns the snapshot command, whose subcommands create, serve and import snapshots of the
+// database
+func NewSnapshotCmd() *cobra.Command {
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Creates, serves and imports snapshots of the database to start new nodes from a recent block.",
+		Args:  cobra.NoArgs,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create [flags]",
+		Short: "Verifies the head of a database and writes a snapshot of it, the node must be stopped.",
+		Args:  cobra.NoArgs,
+		RunE:  runSnapshotCreate,
+	}
+	createCmd.Flags().String(dbPathF, defaultDBPath, snapshotDBPathUsage)
+	createCmd.Flags().String(networkF, defaultNetwork, snapshotNetworkUsage)
+	createCmd.Flags().String(outputF, defaultOutput, snapshotOutputUsage)
+
+	serveCmd := &cobra.Command{
+		Use:   "serve [flags]",
+		Short: "Serves the snapshots of a directory over HTTP, downloads can be resumed.",
+		Args:  cobra.NoArgs,
+		RunE:  runSnapshotServe,
+	}
+	serveCmd.Flags().String(snapshotDirF, defaultSnapshotDir, snapshotDirUsage)
+	serveCmd.Flags().String(snapshotAddrF, defaultSnapshotAddr, snapshotAddrUsage)
+
+	importCmd := &cobra.Command{
+		Use:   "import [flags]",
+		Short: "Downloads a snapshot if needed, and imports and verifies it into an empty database.",
+		Args:  cobra.NoArgs,
+		RunE:  runSnapshotImport,
+	}
+	importCmd.Flags().String(dbPathF, defaultDBPath, snapshotDBPathUsage)
+	importCmd.Flags().String(networkF, defaultNetwork, snapshotNetworkUsage)
+	importCmd.Flags().String(snapshotSourceF, "", snapshotSourceUsage)
+
+	snapshotCmd.AddCommand(createCmd, serveCmd, importCmd)
+	return snapshotCmd
+}
+
+// snapshotFlags returns the database path and the network of the create and import commands
+func snapshotFlags(cmd *cobra.Command) (string, utils.Network, error) {
+	var network utils.Network
+	dbPath, err := cmd.Flags().GetString(dbPathF)
+	if err != nil {
+		return "", network, err
+	}
+	if dbPath == "" {
+		return "", network, fmt.Errorf("--%s is required", dbPathF)
+	}
+	networkName, err := cmd.Flags().GetString(networkF)
+	if err != nil {
+		return "", network, err
+	}
+	return dbPath, network, network.Set(networkName)
+}
+
+func runSnapshotCreate(cmd *cobra.Command, _ []string) error {
+	dbPath, network, err := snapshotFlags(cmd)
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString(outputF)
+	if err != nil {
+		return err
+	}
+	log, err := utils.NewZapLogger(utils.ERROR, false)
+	if err != nil {
+		return err
+	}
+
+	database, err := pebble.New(dbPath, snapshotCacheSize, log)
+	if err != nil {
+		return fmt.Errorf("open DB: %w", err)
+	}
+	defer database.Close()
+
+	// the snapshot is named after its head once it is written
+	file, err := os.CreateTemp(filepath.Dir(output), "juno-snapshot-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	metadata, err := snapshot.Create(database, network, io.MultiWriter(file, hash))
+	if err = errors.Join(err, file.Close()); err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	if output == "" {
+		output = fmt.Sprintf("juno_%s_%d.tar", network, metadata.BlockNumber)
+	}
+	if err = os.Rename(file.Name(), output); err != nil {
+		return err
+	}
+
+	checksum := fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash.Sum(nil)), filepath.Base(output))
+	if err = os.WriteFile(output+snapshot.ChecksumSuffix, []byte(checksum), 0o644); err != nil {
+		return err
+	}
+	cmd.Printf("Wrote snapshot of block %d %s to %s\n", metadata.BlockNumber, metadata.BlockHash, output)
+	return nil
+}
+
+func runSnapshotServe(cmd *cobra.Command, _ []string) error {
+	dir, err := cmd.Flags().GetString(snapshotDirF)
+	if err != nil {
+		return err
+	}
+	addr, err := cmd.Flags().GetString(snapshotAddrF)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	// http.FileServer serves range requests, which lets downloads be resumed
+	srv := &http.Server{
+		Handler: http.FileServer(http.Dir(dir)),
+		// ReadTimeout is also treated as ReadHeaderTimeout and IdleTimeout.
+		ReadTimeout: 30 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		<-cmd.Context().Done()
+		errCh <- srv.Shutdown(context.Background())
+	}()
+
+	cmd.Printf("Serving the snapshots in %s on %s\n", dir, listener.Addr())
+	if err = srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-errCh
+}
+
+func runSnapshotImport(cmd *cobra.Command, _ []string) error {
+	dbPath, network, err := snapshotFlags(cmd)
+	if err != nil {
+		return err
+	}
+	source, err := cmd.Flags().GetString(snapshotSourceF)
+	if err != nil {
+		return err
+	}
+	if source == "" {
+		return fmt.Errorf("--%s is required", snapshotSourceF)
+	}
+	log, err := utils.NewZapLogger(utils.ERROR, false)
+	if err != nil {
+		return err
+	}
+
+	path, checksum, err := fetchSnapshot(cmd, source, dbPath)
+	if err != nil {
+		return err
+	}
+	if checksum == "" {
+		cmd.Printf("No checksum found for %s, the download is only verified by its contents\n", source)
+	} else {
+		var got string
+		if got, err = snapshot.Checksum(path); err != nil {
+			return err
+		}
+		if got != checksum {
+			return fmt.Errorf("checksum of %s is %s instead of %s, delete it to download it again", path, got, checksum)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	metadata, err := snapshot.Import(file, dbPath, network, log)
+	if err != nil {
+		return fmt.Errorf("import snapshot: %w", err)
+	}
+	cmd.Printf("Imported snapshot of block %d %s to %s\n", metadata.BlockNumber, metadata.BlockHash, dbPath)
+	if path != source {
+		return errors.Join(os.Remove(path), os.RemoveAll(path+snapshot.ChecksumSuffix))
+	}
+	return nil
+}
+
+// fetchSnapshot returns the path of the snapshot at source, downloading it next to dbPath if it is a URL,
+// and its checksum if source has a checksum file
+func fetchSnapshot(cmd *cobra.Command, source, dbPath string) (string, string, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		contents, err := os.ReadFile(source + snapshot.ChecksumSuffix)
+		if errors.Is(err, os.ErrNotExist) {
+			return source, "", nil
+		} else if err != nil {
+			return "", "", err
+		}
+		checksum, err := snapshot.ParseChecksum(string(contents))
+		return source, checksum, err
+	}
+
+	// downloads are kept until they are imported, so that they can be resumed
+	path := strings.TrimSuffix(dbPath, string(filepath.Separator)) + ".snapshot.tar"
+	client := &http.Client{}
+	checksum, err := downloadChecksum(cmd.Context(), client, source, path)
+	if err != nil {
+		return "", "", err
+	}
+
+	cmd.Printf("Downloading %s to %s\n", source, path)
+	if err = snapshot.Download(cmd.Context(), client, source, path); err != nil {
+		return "", "", fmt.Errorf("download snapshot: %w", err)
+	}
+	return path, checksum, nil
+}
+
+// downloadChecksum returns the checksum of the snapshot at url, or an empty string if it has none
+func downloadChecksum(ctx context.Context, client *http.Client, url, path string) (string, error) {
+	// the checksum file is small enough to be downloaded again every time
+	checksumPath := path + snapshot.ChecksumSuffix
+	if err := os.RemoveAll(checksumPath); err != nil {
+		return "", err
+	}
+	if err := snapshot.Download(ctx, client, url+snapshot.ChecksumSuffix, checksumPath); err != nil {
+		return "", os.RemoveAll(checksumPath)
+	}
+	contents, err := os.ReadFile(checksumPath)
+	if err != nil {
+		return "", err
+	}
+	return snapshot.ParseChecksum(string(contents))
+}
@@ -0,0 +1,93 @@
+package main_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	juno "github.com/NethermindEth/juno/cmd/juno"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	database, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	// a block with an empty state is enough, the snapshot package is tested on mainnet blocks
+	block := &core.Block{Header: &core.Header{
+		Hash:            new(felt.Felt).SetUint64(1),
+		ParentHash:      &felt.Zero,
+		GlobalStateRoot: &felt.Zero,
+	}}
+	stateUpdate := &core.StateUpdate{
+		BlockHash: block.Hash,
+		NewRoot:   &felt.Zero,
+		OldRoot:   &felt.Zero,
+		StateDiff: new(core.StateDiff),
+	}
+	chain := blockchain.New(database, utils.MAINNET, utils.NewNopZapLogger())
+	require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+	require.NoError(t, database.Close())
+
+	snapshotDir := t.TempDir()
+	output := filepath.Join(snapshotDir, "mainnet.tar")
+	run := func(args ...string) error {
+		cmd := juno.NewSnapshotCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		return cmd.ExecuteContext(context.Background())
+	}
+
+	require.NoError(t, run("create", "--db-path", dbPath, "--output", output))
+	assert.FileExists(t, output)
+	assert.FileExists(t, output+".sha256")
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(snapshotDir)))
+	t.Cleanup(srv.Close)
+
+	t.Run("import from a URL", func(t *testing.T) {
+		importPath := filepath.Join(t.TempDir(), "juno")
+		require.NoError(t, run("import", "--db-path", importPath, "--source", srv.URL+"/mainnet.tar"))
+
+		imported, err := pebble.New(importPath, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		defer imported.Close()
+		head, err := blockchain.New(imported, utils.MAINNET, utils.NewNopZapLogger()).HeadsHeader()
+		require.NoError(t, err)
+		assert.Equal(t, block.Hash, head.Hash)
+
+		// the download is removed once it is imported
+		assert.NoFileExists(t, importPath+".snapshot.tar")
+	})
+
+	t.Run("import of a corrupted file", func(t *testing.T) {
+		corrupted := filepath.Join(t.TempDir(), "mainnet.tar")
+		contents, err := os.ReadFile(output)
+		require.NoError(t, err)
+		contents[len(contents)/2] ^= 0xff
+		require.NoError(t, os.WriteFile(corrupted, contents, 0o600))
+		checksum, err := os.ReadFile(output + ".sha256")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(corrupted+".sha256", checksum, 0o600))
+
+		importPath := filepath.Join(t.TempDir(), "juno")
+		require.ErrorContains(t, run("import", "--db-path", importPath, "--source", corrupted), "checksum")
+		assert.NoDirExists(t, importPath)
+	})
+
+	t.Run("missing flags", func(t *testing.T) {
+		require.Error(t, run("create"))
+		require.Error(t, run("import", "--db-path", filepath.Join(t.TempDir(), "juno")))
+	})
+}
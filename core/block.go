@@ -49,9 +49,23 @@ type blockHashMetaInfo struct {
 	First07Block             uint64     // First block that uses the post-0.7.0 block hash algorithm
 	UnverifiableRange        []uint64   // Range of blocks that are not verifiable
 	FallBackSequencerAddress *felt.Felt // The sequencer address to use for blocks that do not have one
+	TrustBlockHashes         bool       // Whether no block hash is verified
 }
 
+// NetworkBlockHashMetaInfo returns how the block hashes of network are computed and verified, the defaults of
+// network are overridden by its utils.Verification
 func NetworkBlockHashMetaInfo(network utils.Network) *blockHashMetaInfo {
+	info := defaultBlockHashMetaInfo(network)
+	if verification, ok := network.Verification(); ok {
+		if verification.UnverifiableRange != nil {
+			info.UnverifiableRange = verification.UnverifiableRange
+		}
+		info.TrustBlockHashes = verification.TrustBlockHashes
+	}
+	return info
+}
+
+func defaultBlockHashMetaInfo(network utils.Network) *blockHashMetaInfo {
 	fallBackSequencerAddress, err := new(felt.Felt).SetString(
 		"0x046a89ae102987331d369645031b49c27738ed096f2789c24449966da4c6de6b")
 	if err != nil {
@@ -87,6 +101,12 @@ func NetworkBlockHashMetaInfo(network utils.Network) *blockHashMetaInfo {
 			FallBackSequencerAddress: fallBackSequencerAddress,
 		}
 	default:
+		if _, ok := network.Definition(); ok {
+			return &blockHashMetaInfo{
+				First07Block:             0,
+				FallBackSequencerAddress: fallBackSequencerAddress,
+			}
+		}
 		// This should never happen
 		panic(fmt.Sprintf("unknown network: %d", network))
 	}
@@ -130,7 +150,7 @@ func VerifyBlockHash(b *Block, network utils.Network) (*BlockCommitments, error)
 			return nil, err
 		}
 
-		if hash.Equal(b.Hash) {
+		if hash.Equal(b.Hash) || metaInfo.TrustBlockHashes {
 			return commitments, nil
 		} else if unverifiableRange != nil {
 			// Check if the block number is in the unverifiable range
@@ -143,6 +163,11 @@ func VerifyBlockHash(b *Block, network utils.Network) (*BlockCommitments, error)
 	return nil, errors.New("can not verify hash in block header")
 }
 
+// BlockHash computes the hash of a block that the node produces itself, and the commitments of the block
+func BlockHash(b *Block, network utils.Network) (*felt.Felt, *BlockCommitments, error) {
+	return blockHash(b, network, nil)
+}
+
 // blockHash computes the block hash, with option to override sequence address
 func blockHash(b *Block, network utils.Network, overrideSeqAddr *felt.Felt) (*felt.Felt, *BlockCommitments, error) {
 	metaInfo := NetworkBlockHashMetaInfo(network)
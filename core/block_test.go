@@ -209,6 +209,39 @@ func TestBlockHash(t *testing.T) {
 		assert.NotNil(t, commitments)
 	})
 
+	t.Run("verification of the network", func(t *testing.T) {
+		network, err := utils.RegisterNetwork(utils.NetworkDefinition{
+			Name:       "verification",
+			ChainID:    "SN_MAIN",
+			FeederURL:  "http://localhost/feeder_gateway/",
+			GatewayURL: "http://localhost/gateway/",
+		})
+		require.NoError(t, err)
+		mainnetBlock1, err := mainnetGW.BlockByNumber(context.Background(), 1)
+		require.NoError(t, err)
+		mainnetBlock1.Hash = h1
+
+		for _, test := range []struct {
+			name         string
+			verification utils.Verification
+			verified     bool
+		}{
+			{"defaults", utils.Verification{}, false},
+			{"outside the unverifiable range", utils.Verification{UnverifiableRange: []uint64{2, 10}}, false},
+			{"in the unverifiable range", utils.Verification{UnverifiableRange: []uint64{0, 10}}, true},
+			{"trusted block hashes", utils.Verification{TrustBlockHashes: true}, true},
+		} {
+			require.NoError(t, utils.SetVerification(network, test.verification))
+			commitments, err := core.VerifyBlockHash(mainnetBlock1, network)
+			if test.verified {
+				assert.NoError(t, err, test.name)
+				assert.NotNil(t, commitments, test.name)
+			} else {
+				assert.Error(t, err, test.name)
+			}
+		}
+	})
+
 	t.Run("error if len of transactions do not match len of receipts", func(t *testing.T) {
 		mainnetBlock1, err := mainnetGW.BlockByNumber(context.Background(), 1)
 		require.NoError(t, err)
@@ -2,6 +2,7 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/NethermindEth/juno/core/crypto"
@@ -79,6 +80,111 @@ func (c *Cairo1Class) Hash() *felt.Felt {
 	)
 }
 
+// compiledClass is the part of a compiled (CASM) class its hash is computed from
+type compiledClass struct {
+	Bytecode               []*felt.Felt    `json:"bytecode"`
+	BytecodeSegmentLengths json.RawMessage `json:"bytecode_segment_lengths"`
+	EntryPoints            struct {
+		External    []compiledEntryPoint `json:"EXTERNAL"`
+		L1Handler   []compiledEntryPoint `json:"L1_HANDLER"`
+		Constructor []compiledEntryPoint `json:"CONSTRUCTOR"`
+	} `json:"entry_points_by_type"`
+}
+
+type compiledEntryPoint struct {
+	Selector *felt.Felt `json:"selector"`
+	Offset   *felt.Felt `json:"offset"`
+	Builtins []string   `json:"builtins"`
+}
+
+// CompiledClassHash computes the hash of the compiled (CASM) class of c, which declare transactions and
+// state updates commit to. The classes compiled by the pre-releases of the v1.0.0 compiler, which have no
+// bytecode, are not supported.
+func (c *Cairo1Class) CompiledClassHash() (*felt.Felt, error) {
+	if len(c.Compiled) == 0 {
+		return nil, errors.New("compiled class is not available")
+	}
+
+	var compiled compiledClass
+	if err := json.Unmarshal(c.Compiled, &compiled); err != nil {
+		return nil, fmt.Errorf("decode compiled class: %w", err)
+	}
+	if compiled.Bytecode == nil {
+		return nil, errors.New("compiled class has no bytecode")
+	}
+
+	bytecodeHash, err := bytecodeHash(compiled.Bytecode, compiled.BytecodeSegmentLengths)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.PoseidonArray(
+		new(felt.Felt).SetBytes([]byte("COMPILED_CLASS_V1")),
+		compiledEntryPointsHash(compiled.EntryPoints.External),
+		compiledEntryPointsHash(compiled.EntryPoints.L1Handler),
+		compiledEntryPointsHash(compiled.EntryPoints.Constructor),
+		bytecodeHash,
+	), nil
+}
+
+func compiledEntryPointsHash(entryPoints []compiledEntryPoint) *felt.Felt {
+	result := make([]*felt.Felt, 0, len(entryPoints)*3)
+	for _, entryPoint := range entryPoints {
+		builtins := make([]*felt.Felt, 0, len(entryPoint.Builtins))
+		for _, builtin := range entryPoint.Builtins {
+			builtins = append(builtins, new(felt.Felt).SetBytes([]byte(builtin)))
+		}
+		result = append(result, entryPoint.Selector, entryPoint.Offset, crypto.PoseidonArray(builtins...))
+	}
+	return crypto.PoseidonArray(result...)
+}
+
+// bytecodeHash hashes bytecode as a whole, or as the tree of segments described by segmentLengths. Each
+// segment is either a length, whose bytecode is hashed as a leaf, or a list of nested segments, whose hash is
+// one more than the hash of the lengths and hashes of its segments.
+func bytecodeHash(bytecode []*felt.Felt, segmentLengths json.RawMessage) (*felt.Felt, error) {
+	if len(segmentLengths) == 0 || string(segmentLengths) == "null" {
+		return crypto.PoseidonArray(bytecode...), nil
+	}
+
+	hash, rest, err := bytecodeSegmentHash(bytecode, segmentLengths)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("bytecode segments do not cover the %d felts of the bytecode", len(bytecode))
+	}
+	return hash, nil
+}
+
+// bytecodeSegmentHash hashes the segment described by segment at the start of bytecode, and returns the
+// bytecode that follows it
+func bytecodeSegmentHash(bytecode []*felt.Felt, segment json.RawMessage) (*felt.Felt, []*felt.Felt, error) {
+	var length uint64
+	if err := json.Unmarshal(segment, &length); err == nil {
+		if length > uint64(len(bytecode)) {
+			return nil, nil, fmt.Errorf("bytecode segment of length %d exceeds the bytecode", length)
+		}
+		return crypto.PoseidonArray(bytecode[:length]...), bytecode[length:], nil
+	}
+
+	var segments []json.RawMessage
+	if err := json.Unmarshal(segment, &segments); err != nil {
+		return nil, nil, fmt.Errorf("decode bytecode segment lengths: %w", err)
+	}
+	nodes := make([]*felt.Felt, 0, len(segments)*2)
+	for _, nested := range segments {
+		remaining := len(bytecode)
+		hash, rest, err := bytecodeSegmentHash(bytecode, nested)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes = append(nodes, new(felt.Felt).SetUint64(uint64(remaining-len(rest))), hash)
+		bytecode = rest
+	}
+	hash := crypto.PoseidonArray(nodes...)
+	return hash.Add(hash, new(felt.Felt).SetUint64(1)), bytecode, nil
+}
+
 func flattenSierraEntryPoints(entryPoints []SierraEntryPoint) []*felt.Felt {
 	result := make([]*felt.Felt, len(entryPoints)*2)
 	for i, entryPoint := range entryPoints {
@@ -95,7 +201,7 @@ func VerifyClassHashes(classes map[felt.Felt]Class) error {
 		cairo1Class, ok := class.(*Cairo1Class)
 		// cairo0 classes are deprecated and hard to verify their hash, just ignore them
 		if !ok {
-			return nil
+			continue
 		}
 
 		cHash := cairo1Class.Hash()
@@ -9,6 +9,7 @@ import (
 
 	"github.com/NethermindEth/juno/clients/feeder"
 	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/crypto"
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/encoder"
 	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
@@ -40,6 +41,94 @@ func TestClassV1Hash(t *testing.T) {
 	}
 }
 
+func TestCompiledClassHash(t *testing.T) {
+	felts := func(values ...uint64) []*felt.Felt {
+		result := make([]*felt.Felt, 0, len(values))
+		for _, value := range values {
+			result = append(result, new(felt.Felt).SetUint64(value))
+		}
+		return result
+	}
+	shortString := func(s string) *felt.Felt {
+		return new(felt.Felt).SetBytes([]byte(s))
+	}
+
+	entryPoints := `"entry_points_by_type": {
+		"EXTERNAL": [
+			{"selector": "0x11", "offset": 0, "builtins": ["pedersen", "range_check"]},
+			{"selector": "0x12", "offset": 3, "builtins": []}
+		],
+		"L1_HANDLER": [],
+		"CONSTRUCTOR": [{"selector": "0x13", "offset": 4, "builtins": ["range_check"]}]
+	}`
+	entryPointsHashes := []*felt.Felt{
+		crypto.PoseidonArray(
+			utils.HexToFelt(t, "0x11"), new(felt.Felt), crypto.PoseidonArray(shortString("pedersen"), shortString("range_check")),
+			utils.HexToFelt(t, "0x12"), new(felt.Felt).SetUint64(3), crypto.PoseidonArray(),
+		),
+		crypto.PoseidonArray(),
+		crypto.PoseidonArray(utils.HexToFelt(t, "0x13"), new(felt.Felt).SetUint64(4), crypto.PoseidonArray(shortString("range_check"))),
+	}
+	classHash := func(bytecodeHash *felt.Felt) *felt.Felt {
+		return crypto.PoseidonArray(append(append([]*felt.Felt{shortString("COMPILED_CLASS_V1")}, entryPointsHashes...),
+			bytecodeHash)...)
+	}
+	segmentHash := func(nodes ...*felt.Felt) *felt.Felt {
+		hash := crypto.PoseidonArray(nodes...)
+		return hash.Add(hash, new(felt.Felt).SetUint64(1))
+	}
+
+	tests := map[string]struct {
+		compiled string
+		want     *felt.Felt
+		wantErr  string
+	}{
+		"bytecode": {
+			compiled: `{"bytecode": ["0x1", "0x2", "0x3", "0x4", "0x5"], ` + entryPoints + `}`,
+			want:     classHash(crypto.PoseidonArray(felts(1, 2, 3, 4, 5)...)),
+		},
+		"bytecode segments": {
+			compiled: `{"bytecode": ["0x1", "0x2", "0x3", "0x4", "0x5"], "bytecode_segment_lengths": [2, [1, 2]], ` +
+				entryPoints + `}`,
+			want: classHash(segmentHash(
+				new(felt.Felt).SetUint64(2), crypto.PoseidonArray(felts(1, 2)...),
+				new(felt.Felt).SetUint64(3), segmentHash(
+					new(felt.Felt).SetUint64(1), crypto.PoseidonArray(felts(3)...),
+					new(felt.Felt).SetUint64(2), crypto.PoseidonArray(felts(4, 5)...),
+				),
+			)),
+		},
+		"segments longer than bytecode": {
+			compiled: `{"bytecode": ["0x1", "0x2"], "bytecode_segment_lengths": [1, 2], ` + entryPoints + `}`,
+			wantErr:  "bytecode segment of length 2 exceeds the bytecode",
+		},
+		"segments shorter than bytecode": {
+			compiled: `{"bytecode": ["0x1", "0x2"], "bytecode_segment_lengths": [1], ` + entryPoints + `}`,
+			wantErr:  "bytecode segments do not cover the 2 felts of the bytecode",
+		},
+		"no bytecode": {
+			compiled: `{"program": {"data": ["0x1"]}, ` + entryPoints + `}`,
+			wantErr:  "compiled class has no bytecode",
+		},
+		"no compiled class": {
+			wantErr: "compiled class is not available",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			class := &core.Cairo1Class{Compiled: json.RawMessage(test.compiled)}
+			got, err := class.CompiledClassHash()
+			if test.wantErr != "" {
+				require.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
 func TestClassEncoding(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -163,4 +252,13 @@ func TestVerifyClassHash(t *testing.T) {
 
 		assert.NoError(t, core.VerifyClassHashes(classMap))
 	})
+
+	t.Run("cairo0 classes do not skip the verification of other classes", func(t *testing.T) {
+		classMap := map[felt.Felt]core.Class{
+			*utils.HexToFelt(t, "0xab"): cairo1Class,
+			*cairo0ClassHash:            cairo0Class,
+		}
+
+		assert.Error(t, core.VerifyClassHashes(classMap))
+	})
 }
@@ -115,22 +115,12 @@ func (c *Contract) Purge() error {
 // Nonce returns the amount transactions sent from this contract.
 // Only account contracts can have a non-zero nonce.
 func (c *Contract) Nonce() (*felt.Felt, error) {
-	key := db.ContractNonce.Key(c.Address.Marshal())
-	var nonce *felt.Felt
-	if err := c.txn.Get(key, func(val []byte) error {
-		nonce = new(felt.Felt)
-		nonce.SetBytes(val)
-		return nil
-	}); err != nil {
-		return nil, err
-	}
-	return nonce, nil
+	return contractNonceTable.Get(c.txn, c.Address)
 }
 
 // UpdateNonce updates the nonce value in the database.
 func (c *Contract) UpdateNonce(nonce *felt.Felt) error {
-	nonceKey := db.ContractNonce.Key(c.Address.Marshal())
-	return c.txn.Set(nonceKey, nonce.Marshal())
+	return contractNonceTable.Put(c.txn, c.Address, nonce)
 }
 
 // ClassHash returns hash of the class that this contract instantiates.
@@ -147,6 +137,26 @@ func (c *Contract) Root() (*felt.Felt, error) {
 	return cStorage.Root()
 }
 
+// Commitment returns the commitment of the contract, its leaf in the global state trie.
+func (c *Contract) Commitment() (*felt.Felt, error) {
+	root, err := c.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	cHash, err := c.ClassHash()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := c.Nonce()
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateContractCommitment(root, cHash, nonce), nil
+}
+
 type OnValueChanged = func(location, oldValue *felt.Felt) error
 
 // UpdateStorage applies a change-set to the contract storage.
@@ -186,21 +196,11 @@ func (c *Contract) Storage(key *felt.Felt) (*felt.Felt, error) {
 
 // ClassHash returns hash of the class that the contract at the given address instantiates.
 func classHash(addr *felt.Felt, txn db.Transaction) (*felt.Felt, error) {
-	key := db.ContractClassHash.Key(addr.Marshal())
-	var classHash *felt.Felt
-	if err := txn.Get(key, func(val []byte) error {
-		classHash = new(felt.Felt)
-		classHash.SetBytes(val)
-		return nil
-	}); err != nil {
-		return nil, err
-	}
-	return classHash, nil
+	return contractClassHashTable.Get(txn, addr)
 }
 
 func setClassHash(txn db.Transaction, addr, classHash *felt.Felt) error {
-	classHashKey := db.ContractClassHash.Key(addr.Marshal())
-	return txn.Set(classHashKey, classHash.Marshal())
+	return contractClassHashTable.Put(txn, addr, classHash)
 }
 
 // Replace replaces the class that the contract instantiates
@@ -0,0 +1,106 @@
+package felt
+
+import "sync"
+
+const (
+	// internShards is the number of independently locked shards of an Interner, so that concurrent callers rarely
+	// contend on the same lock
+	internShards = 64
+	// defaultInternCapacity is the capacity of the global Interner, a million felts take about 80 MiB with the
+	// overhead of the maps
+	defaultInternCapacity = 1 << 20
+)
+
+// Interner deduplicates the felts that are held in memory for long, the emitters and the keys of the events
+// of cached blocks, which repeat across blocks. Intern returns the same pointer for equal felts, so that the
+// duplicates can be released and the interned felts compare by pointer. It is safe for concurrent use.
+//
+// The interned felts are shared, so they must never be modified.
+type Interner struct {
+	shards      [internShards]internShard
+	maxPerShard int
+}
+
+type internShard struct {
+	mu    sync.RWMutex
+	felts map[Felt]*Felt
+}
+
+// NewInterner returns an Interner that holds about capacity felts at most. A shard that is full is emptied, the
+// felts it returned remain valid but are no longer deduplicated with the ones it returns afterwards.
+func NewInterner(capacity int) *Interner {
+	maxPerShard := capacity / internShards
+	if maxPerShard == 0 {
+		maxPerShard = 1
+	}
+	i := &Interner{maxPerShard: maxPerShard}
+	for s := range i.shards {
+		i.shards[s].felts = make(map[Felt]*Felt)
+	}
+	return i
+}
+
+// Intern returns the interned felt equal to f, which is f itself if no equal felt was interned. A nil f is
+// returned as is.
+func (i *Interner) Intern(f *Felt) *Felt {
+	if f == nil {
+		return nil
+	}
+	shard := &i.shards[shardOf(f)]
+
+	shard.mu.RLock()
+	interned, ok := shard.felts[*f]
+	shard.mu.RUnlock()
+	if ok {
+		return interned
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if interned, ok = shard.felts[*f]; ok {
+		return interned
+	}
+	if len(shard.felts) >= i.maxPerShard {
+		shard.felts = make(map[Felt]*Felt, len(shard.felts))
+	}
+	shard.felts[*f] = f
+	return f
+}
+
+// shardOf returns the shard of f. The limbs of small felts differ little, so they are mixed with a multiplicative
+// hash to spread them evenly across the shards.
+func shardOf(f *Felt) uint64 {
+	const fibonacci = 0x9e3779b97f4a7c15
+	return ((f.val[0] ^ f.val[1] ^ f.val[2] ^ f.val[3]) * fibonacci) >> 58 // top 6 bits, one of the 64 shards
+}
+
+// InternAll interns the felts of fs in place
+func (i *Interner) InternAll(fs []*Felt) {
+	for j, f := range fs {
+		fs[j] = i.Intern(f)
+	}
+}
+
+// Len returns the number of interned felts
+func (i *Interner) Len() int {
+	var n int
+	for s := range i.shards {
+		shard := &i.shards[s]
+		shard.mu.RLock()
+		n += len(shard.felts)
+		shard.mu.RUnlock()
+	}
+	return n
+}
+
+var global = NewInterner(defaultInternCapacity)
+
+// Intern interns f in the interner that is shared by the whole node, see Interner.Intern
+func Intern(f *Felt) *Felt {
+	return global.Intern(f)
+}
+
+// InternAll interns the felts of fs in place in the interner that is shared by the whole node
+func InternAll(fs []*Felt) {
+	global.InternAll(fs)
+}
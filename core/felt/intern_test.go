@@ -0,0 +1,53 @@
+package felt_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterner(t *testing.T) {
+	interner := felt.NewInterner(1 << 10)
+
+	a := new(felt.Felt).SetUint64(42)
+	assert.Same(t, a, interner.Intern(a))
+	assert.Same(t, a, interner.Intern(new(felt.Felt).SetUint64(42)), "equal felts are interned to the first one")
+	assert.NotSame(t, a, interner.Intern(new(felt.Felt).SetUint64(43)))
+	assert.Nil(t, interner.Intern(nil))
+
+	fs := []*felt.Felt{new(felt.Felt).SetUint64(42), new(felt.Felt).SetUint64(43)}
+	interner.InternAll(fs)
+	assert.Same(t, a, fs[0])
+	assert.Equal(t, 2, interner.Len())
+
+	t.Run("the capacity is bounded", func(t *testing.T) {
+		small := felt.NewInterner(64)
+		for i := uint64(0); i < 10_000; i++ {
+			small.Intern(new(felt.Felt).SetUint64(i))
+		}
+		assert.LessOrEqual(t, small.Len(), 64)
+	})
+
+	t.Run("concurrent callers get the same felt", func(t *testing.T) {
+		concurrent := felt.NewInterner(1 << 16)
+		interned := make([]*felt.Felt, 8)
+		var wg sync.WaitGroup
+		for i := range interned {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := uint64(0); j < 100; j++ {
+					concurrent.Intern(new(felt.Felt).SetUint64(j))
+				}
+				interned[i] = concurrent.Intern(new(felt.Felt).SetUint64(7))
+			}()
+		}
+		wg.Wait()
+		for _, f := range interned {
+			assert.Same(t, interned[0], f)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/db"
@@ -11,8 +12,42 @@ import (
 
 var ErrCheckHeadState = errors.New("check head state")
 
+// ErrStatePruned is returned for the state of the blocks before the history of the state was pruned, see
+// HistoryStart
+var ErrStatePruned = errors.New("the history of the state of the block is pruned")
+
+var historyStartValue = db.NewValue[uint64](db.StateHistoryStart, db.Uint64Codec{})
+
+// HistoryStart returns the first block whose changes to the state are logged, the logs of the blocks before it are
+// pruned. The state can be read at the blocks from the one before it.
+func HistoryStart(txn db.Transaction) (uint64, error) {
+	start, err := historyStartValue.Get(txn)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return start, err
+}
+
+// CheckStateAt returns ErrStatePruned if the state at height cannot be read since its history is pruned
+func CheckStateAt(txn db.Transaction, height uint64) error {
+	start, err := HistoryStart(txn)
+	if err != nil {
+		return err
+	}
+	return checkStateAt(start, height)
+}
+
+func checkStateAt(start, height uint64) error {
+	if height+1 < start {
+		return fmt.Errorf("%w: the state can be read from block %d", ErrStatePruned, start-1)
+	}
+	return nil
+}
+
 type History struct {
 	txn db.Transaction
+	// start is the HistoryStart of txn, read on the first read of the history
+	start *uint64
 }
 
 func NewHistory(txn db.Transaction) *History {
@@ -31,7 +66,42 @@ func (h *History) deleteLog(key []byte, height uint64) error {
 	return h.txn.Delete(logDBKey(key, height))
 }
 
+// PruneBlock deletes the logs of the changes to the state of the block blockNumber, whose state diff is diff, and
+// makes the next block the HistoryStart. The blocks have to be pruned in order.
+func (h *History) PruneBlock(blockNumber uint64, diff *StateDiff) error {
+	for addr, storageDiffs := range diff.StorageDiffs {
+		for _, storageDiff := range storageDiffs {
+			if err := h.DeleteContractStorageLog(&addr, storageDiff.Key, blockNumber); err != nil {
+				return err
+			}
+		}
+	}
+	for addr := range diff.Nonces {
+		if err := h.DeleteContractNonceLog(&addr, blockNumber); err != nil {
+			return err
+		}
+	}
+	for _, replaced := range diff.ReplacedClasses {
+		if err := h.DeleteContractClassHashLog(replaced.Address, blockNumber); err != nil {
+			return err
+		}
+	}
+	h.start = nil
+	return historyStartValue.Put(h.txn, blockNumber+1)
+}
+
 func (h *History) valueAt(key []byte, height uint64) ([]byte, error) {
+	if h.start == nil {
+		start, err := HistoryStart(h.txn)
+		if err != nil {
+			return nil, err
+		}
+		h.start = &start
+	}
+	if err := checkStateAt(*h.start, height); err != nil {
+		return nil, err
+	}
+
 	it, err := h.txn.NewIterator()
 	if err != nil {
 		return nil, err
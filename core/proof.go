@@ -0,0 +1,114 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/core/trie"
+)
+
+// StorageProof proves the value of a key of the storage of a contract in a state. StorageProof proves the value in
+// the storage trie of the contract, whose root ContractRoot is. The commitment of the contract is calculated from
+// ContractRoot, ClassHash and Nonce, and ContractProof proves it in the state trie, whose root StateTrieRoot is. The
+// state commitment is calculated from StateTrieRoot and ClassesTrieRoot.
+type StorageProof struct {
+	ClassHash       *felt.Felt       `json:"class_hash"`
+	Nonce           *felt.Felt       `json:"nonce"`
+	ContractRoot    *felt.Felt       `json:"contract_root"`
+	StateTrieRoot   *felt.Felt       `json:"state_trie_root"`
+	ClassesTrieRoot *felt.Felt       `json:"classes_trie_root"`
+	ContractProof   []trie.ProofNode `json:"contract_proof"`
+	StorageProof    []trie.ProofNode `json:"storage_proof"`
+}
+
+// StorageProof returns the proof of the value of key in the storage of the contract at addr
+func (s *State) StorageProof(addr, key *felt.Felt) (*StorageProof, error) {
+	contract, err := NewContract(addr, s.txn)
+	if err != nil {
+		return nil, err
+	}
+	proof := new(StorageProof)
+	if proof.ClassHash, err = contract.ClassHash(); err != nil {
+		return nil, err
+	}
+	if proof.Nonce, err = contract.Nonce(); err != nil {
+		return nil, err
+	}
+	if proof.ContractRoot, err = contract.Root(); err != nil {
+		return nil, err
+	}
+	if proof.StateTrieRoot, proof.ClassesTrieRoot, err = s.Roots(); err != nil {
+		return nil, err
+	}
+
+	storageTrie, err := storage(addr, s.txn)
+	if err != nil {
+		return nil, err
+	}
+	if proof.StorageProof, err = storageTrie.Prove(key); err != nil {
+		return nil, err
+	}
+	stateTrie, closer, err := s.storage()
+	if err != nil {
+		return nil, err
+	}
+	if proof.ContractProof, err = stateTrie.Prove(addr); err != nil {
+		return nil, errors.Join(err, closer())
+	}
+	return proof, closer()
+}
+
+// Verify reports whether p proves that key has value in the storage of the contract at addr in the state whose
+// commitment is root
+func (p *StorageProof) Verify(root, addr, key, value *felt.Felt) bool {
+	stateRoot := p.StateTrieRoot
+	if !p.ClassesTrieRoot.IsZero() {
+		stateRoot = crypto.PoseidonArray(stateVersion, p.StateTrieRoot, p.ClassesTrieRoot)
+	}
+	commitment := calculateContractCommitment(p.ContractRoot, p.ClassHash, p.Nonce)
+	return stateRoot.Equal(root) &&
+		trie.VerifyProof(p.StateTrieRoot, addr, commitment, globalTrieHeight, p.ContractProof, crypto.Pedersen) &&
+		trie.VerifyProof(p.ContractRoot, key, value, contractStorageTrieHeight, p.StorageProof, crypto.Pedersen)
+}
+
+// Copy returns a copy of p that shares none of its felts, so that a proof that is cached is not modified by those it
+// is returned to
+func (p *StorageProof) Copy() *StorageProof {
+	return &StorageProof{
+		ClassHash:       copyFelt(p.ClassHash),
+		Nonce:           copyFelt(p.Nonce),
+		ContractRoot:    copyFelt(p.ContractRoot),
+		StateTrieRoot:   copyFelt(p.StateTrieRoot),
+		ClassesTrieRoot: copyFelt(p.ClassesTrieRoot),
+		ContractProof:   copyProof(p.ContractProof),
+		StorageProof:    copyProof(p.StorageProof),
+	}
+}
+
+func copyFelt(f *felt.Felt) *felt.Felt {
+	if f == nil {
+		return nil
+	}
+	return new(felt.Felt).Set(f)
+}
+
+func copyProof(proof []trie.ProofNode) []trie.ProofNode {
+	if proof == nil {
+		return nil
+	}
+	copied := make([]trie.ProofNode, len(proof))
+	for i, node := range proof {
+		if node.Binary != nil {
+			copied[i].Binary = &trie.BinaryNode{Left: copyFelt(node.Binary.Left), Right: copyFelt(node.Binary.Right)}
+		}
+		if node.Edge != nil {
+			copied[i].Edge = &trie.EdgeNode{
+				Child:  copyFelt(node.Edge.Child),
+				Path:   copyFelt(node.Edge.Path),
+				Length: node.Edge.Length,
+			}
+		}
+	}
+	return copied
+}
@@ -0,0 +1,65 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageProof(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	txn := testDB.NewTransaction(true)
+	t.Cleanup(func() {
+		require.NoError(t, txn.Discard())
+	})
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	su0, err := gw.StateUpdate(context.Background(), 0)
+	require.NoError(t, err)
+	state := core.NewState(txn)
+	require.NoError(t, state.Update(0, su0, nil))
+
+	addr := utils.HexToFelt(t, "0x20cfa74ee3564b4cd5435cdace0f9c4d43b939620e4a0bb5076105df0a626c6")
+	key, value := utils.HexToFelt(t, "0x5"), utils.HexToFelt(t, "0x22b")
+	proof, err := state.StorageProof(addr, key)
+	require.NoError(t, err)
+	assert.True(t, proof.Verify(su0.NewRoot, addr, key, value))
+	assert.False(t, proof.Verify(su0.NewRoot, addr, key, new(felt.Felt).SetUint64(1)))
+	assert.False(t, proof.Verify(new(felt.Felt).SetUint64(1), addr, key, value))
+	otherAddr := utils.HexToFelt(t, "0x31c887d82502ceb218c06ebb46198da3f7b92864a8223746bc836dda3e34b52")
+	assert.False(t, proof.Verify(su0.NewRoot, otherAddr, key, value))
+
+	t.Run("key not in the storage", func(t *testing.T) {
+		missing := utils.HexToFelt(t, "0xdeadbeef")
+		proof, err := state.StorageProof(addr, missing)
+		require.NoError(t, err)
+		assert.True(t, proof.Verify(su0.NewRoot, addr, missing, &felt.Zero))
+		assert.False(t, proof.Verify(su0.NewRoot, addr, missing, value))
+	})
+
+	t.Run("contract not deployed", func(t *testing.T) {
+		_, err := state.StorageProof(new(felt.Felt).SetUint64(1), key)
+		assert.ErrorIs(t, err, core.ErrContractNotDeployed)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		copied := proof.Copy()
+		assert.Equal(t, proof, copied)
+		copied.ContractRoot.SetUint64(1)
+		for i := range copied.ContractProof {
+			if node := &copied.ContractProof[i]; node.Binary != nil {
+				node.Binary.Left.SetUint64(1)
+			} else {
+				node.Edge.Child.SetUint64(1)
+			}
+		}
+		assert.True(t, proof.Verify(su0.NewRoot, addr, key, value))
+	})
+}
@@ -1,7 +1,6 @@
 package core
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"runtime"
@@ -11,13 +10,31 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/core/trie"
 	"github.com/NethermindEth/juno/db"
-	"github.com/NethermindEth/juno/encoder"
 	"github.com/bits-and-blooms/bitset"
 	"github.com/sourcegraph/conc/pool"
 )
 
 const globalTrieHeight = 251
 
+// ErrMismatchedRoot is matched by the errors returned when the root of the state does not match the root of a
+// state update
+var ErrMismatchedRoot = errors.New("mismatched state root")
+
+// MismatchedRootError is returned when the root of the state, Current, does not match the root of a state update,
+// Expected
+type MismatchedRootError struct {
+	Current  *felt.Felt
+	Expected *felt.Felt
+}
+
+func (e *MismatchedRootError) Error() string {
+	return fmt.Sprintf("state's current root: %s does not match the expected root: %s", e.Current, e.Expected)
+}
+
+func (e *MismatchedRootError) Is(target error) bool {
+	return target == ErrMismatchedRoot
+}
+
 var (
 	stateVersion = new(felt.Felt).SetBytes([]byte(`STARKNET_STATE_V0`))
 	leafVersion  = new(felt.Felt).SetBytes([]byte(`CONTRACT_CLASS_LEAF_V0`))
@@ -33,12 +50,16 @@ type StateHistoryReader interface {
 	ContractNonceAt(addr *felt.Felt, blockNumber uint64) (*felt.Felt, error)
 	ContractClassHashAt(addr *felt.Felt, blockNumber uint64) (*felt.Felt, error)
 	ContractIsAlreadyDeployedAt(addr *felt.Felt, blockNumber uint64) (bool, error)
+	ContractStorageLogIterator(addr, start *felt.Felt) (KeyIterator, error)
 }
 
 type StateReader interface {
 	ContractClassHash(addr *felt.Felt) (*felt.Felt, error)
 	ContractNonce(addr *felt.Felt) (*felt.Felt, error)
 	ContractStorage(addr, key *felt.Felt) (*felt.Felt, error)
+	// ContractStorageIterator returns an iterator over the non-zero storage of the contract at addr, from
+	// location start
+	ContractStorageIterator(addr, start *felt.Felt) (StorageIterator, error)
 	Class(classHash *felt.Felt) (*DeclaredClass, error)
 }
 
@@ -62,8 +83,7 @@ func (s *State) putNewContract(stateTrie *trie.Trie, addr, classHash *felt.Felt,
 		return err
 	}
 
-	numBytes := MarshalBlockNumber(blockNumber)
-	if err = s.txn.Set(db.ContractDeploymentHeight.Key(addr.Marshal()), numBytes); err != nil {
+	if err = deploymentHeightTable.Put(s.txn, addr, blockNumber); err != nil {
 		return err
 	}
 
@@ -98,41 +118,63 @@ func (s *State) ContractStorage(addr, key *felt.Felt) (*felt.Felt, error) {
 	return contract.Storage(key)
 }
 
+// ContractStorageIterator returns an iterator over the non-zero storage of the contract at the given address,
+// from location start.
+func (s *State) ContractStorageIterator(addr, start *felt.Felt) (StorageIterator, error) {
+	if _, err := NewContract(addr, s.txn); err != nil {
+		return nil, err
+	}
+
+	storageTrie, err := storage(addr, s.txn)
+	if err != nil {
+		return nil, err
+	}
+	return storageTrie.LeafIterator(start), nil
+}
+
 // Root returns the state commitment.
 func (s *State) Root() (*felt.Felt, error) {
-	var storageRoot, classesRoot *felt.Felt
+	storageRoot, classesRoot, err := s.Roots()
+	if err != nil {
+		return nil, err
+	}
+
+	if classesRoot.IsZero() {
+		return storageRoot, nil
+	}
+
+	return crypto.PoseidonArray(stateVersion, storageRoot, classesRoot), nil
+}
 
+// Roots returns the roots of the global state trie and of the classes trie, which the state commitment is
+// computed from.
+func (s *State) Roots() (storageRoot, classesRoot *felt.Felt, err error) {
 	sStorage, closer, err := s.storage()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if storageRoot, err = sStorage.Root(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err = closer(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	classes, closer, err := s.classesTrie()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if classesRoot, err = classes.Root(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err = closer(); err != nil {
-		return nil, err
-	}
-
-	if classesRoot.IsZero() {
-		return storageRoot, nil
+		return nil, nil, err
 	}
-
-	return crypto.PoseidonArray(stateVersion, storageRoot, classesRoot), nil
+	return storageRoot, classesRoot, nil
 }
 
 // storage returns a [core.Trie] that represents the Starknet global state in the given Txn context.
@@ -199,7 +241,7 @@ func (s *State) verifyStateUpdateRoot(root *felt.Felt) error {
 	}
 
 	if !root.Equal(currentRoot) {
-		return fmt.Errorf("state's current root: %s does not match the expected root: %s", currentRoot, root)
+		return &MismatchedRootError{Current: currentRoot, Expected: root}
 	}
 	return nil
 }
@@ -214,38 +256,46 @@ func (s *State) Update(blockNumber uint64, update *StateUpdate, declaredClasses
 		return err
 	}
 
+	if _, err = s.Apply(blockNumber, update.StateDiff, declaredClasses); err != nil {
+		return err
+	}
+	return s.verifyStateUpdateRoot(update.NewRoot)
+}
+
+// Apply applies a StateDiff to the State object without verifying the roots, and returns the root of the resulting
+// state. It is used to compute the root of the blocks that the node produces itself.
+func (s *State) Apply(blockNumber uint64, diff *StateDiff, declaredClasses map[felt.Felt]Class) (*felt.Felt, error) {
 	// register declared classes mentioned in stateDiff.deployedContracts and stateDiff.declaredClasses
 	for cHash, class := range declaredClasses {
-		if err = s.putClass(&cHash, class, blockNumber); err != nil {
-			return err
+		if err := s.putClass(&cHash, class, blockNumber); err != nil {
+			return nil, err
 		}
 	}
 
-	if err = s.updateDeclaredClassesTrie(update.StateDiff.DeclaredV1Classes, declaredClasses); err != nil {
-		return err
+	if err := s.updateDeclaredClassesTrie(diff.DeclaredV1Classes, declaredClasses); err != nil {
+		return nil, err
 	}
 
 	stateTrie, storageCloser, err := s.storage()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// register deployed contracts
-	for _, contract := range update.StateDiff.DeployedContracts {
+	for _, contract := range diff.DeployedContracts {
 		if err = s.putNewContract(stateTrie, contract.Address, contract.ClassHash, blockNumber); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	if err = s.updateContracts(stateTrie, blockNumber, update.StateDiff, true); err != nil {
-		return err
+	if err = s.updateContracts(stateTrie, blockNumber, diff, true); err != nil {
+		return nil, err
 	}
 
 	if err = storageCloser(); err != nil {
-		return err
+		return nil, err
 	}
-
-	return s.verifyStateUpdateRoot(update.NewRoot)
+	return s.Root()
 }
 
 var (
@@ -318,34 +368,22 @@ type DeclaredClass struct {
 }
 
 func (s *State) putClass(classHash *felt.Felt, class Class, declaredAt uint64) error {
-	classKey := db.Class.Key(classHash.Marshal())
-
-	err := s.txn.Get(classKey, func(val []byte) error {
+	err := s.txn.Get(classTable.Key(classHash), func(val []byte) error {
 		return nil
 	})
 
 	if errors.Is(err, db.ErrKeyNotFound) {
-		classEncoded, encErr := encoder.Marshal(DeclaredClass{
+		return classTable.Put(s.txn, classHash, DeclaredClass{
 			At:    declaredAt,
 			Class: class,
 		})
-		if encErr != nil {
-			return encErr
-		}
-
-		return s.txn.Set(classKey, classEncoded)
 	}
 	return err
 }
 
 // Class returns the class object corresponding to the given classHash
 func (s *State) Class(classHash *felt.Felt) (*DeclaredClass, error) {
-	classKey := db.Class.Key(classHash.Marshal())
-
-	var class DeclaredClass
-	err := s.txn.Get(classKey, func(val []byte) error {
-		return encoder.Unmarshal(val, &class)
-	})
+	class, err := classTable.Get(s.txn, classHash)
 	if err != nil {
 		return nil, err
 	}
@@ -471,23 +509,11 @@ func (s *State) updateContractNonce(stateTrie *trie.Trie, addr, nonce *felt.Felt
 
 // updateContractCommitment recalculates the contract commitment and updates its value in the global state Trie
 func (s *State) updateContractCommitment(stateTrie *trie.Trie, contract *Contract) error {
-	root, err := contract.Root()
+	commitment, err := contract.Commitment()
 	if err != nil {
 		return err
 	}
 
-	cHash, err := contract.ClassHash()
-	if err != nil {
-		return err
-	}
-
-	nonce, err := contract.Nonce()
-	if err != nil {
-		return err
-	}
-
-	commitment := calculateContractCommitment(root, cHash, nonce)
-
 	_, err = stateTrie.Put(contract.Address, commitment)
 	return err
 }
@@ -519,11 +545,8 @@ func (s *State) updateDeclaredClassesTrie(declaredClasses []DeclaredV1Class, cla
 
 // ContractIsAlreadyDeployedAt returns if contract at given addr was deployed at blockNumber
 func (s *State) ContractIsAlreadyDeployedAt(addr *felt.Felt, blockNumber uint64) (bool, error) {
-	var deployedAt uint64
-	if err := s.txn.Get(db.ContractDeploymentHeight.Key(addr.Marshal()), func(bytes []byte) error {
-		deployedAt = binary.BigEndian.Uint64(bytes)
-		return nil
-	}); err != nil {
+	deployedAt, err := deploymentHeightTable.Get(s.txn, addr)
+	if err != nil {
 		if errors.Is(err, db.ErrKeyNotFound) {
 			return false, nil
 		}
@@ -618,7 +641,7 @@ func (s *State) removeDeclaredClasses(blockNumber uint64, v0Classes []*felt.Felt
 			continue
 		}
 
-		if err = s.txn.Delete(db.Class.Key(cHash.Marshal())); err != nil {
+		if err = classTable.Delete(s.txn, cHash); err != nil {
 			return err
 		}
 
@@ -643,7 +666,7 @@ func (s *State) purgeContract(addr *felt.Felt) error {
 		return err
 	}
 
-	if err = s.txn.Delete(db.ContractDeploymentHeight.Key(addr.Marshal())); err != nil {
+	if err = deploymentHeightTable.Delete(s.txn, addr); err != nil {
 		return err
 	}
 
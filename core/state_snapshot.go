@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
 )
 
 type stateSnapshot struct {
@@ -53,6 +54,10 @@ func (s *stateSnapshot) ContractStorage(addr, key *felt.Felt) (*felt.Felt, error
 		return nil, err
 	}
 
+	return s.storageAt(addr, key)
+}
+
+func (s *stateSnapshot) storageAt(addr, key *felt.Felt) (*felt.Felt, error) {
 	val, err := s.state.ContractStorageAt(addr, key, s.blockNumber)
 	if err != nil {
 		if errors.Is(err, ErrCheckHeadState) {
@@ -63,6 +68,26 @@ func (s *stateSnapshot) ContractStorage(addr, key *felt.Felt) (*felt.Felt, error
 	return val, nil
 }
 
+// ContractStorageIterator iterates over the locations of the storage of the head state and the locations that
+// changed since, which are all the locations the storage may have had at the block of the snapshot
+func (s *stateSnapshot) ContractStorageIterator(addr, start *felt.Felt) (StorageIterator, error) {
+	if err := s.checkDeployed(addr); err != nil {
+		return nil, err
+	}
+
+	head, err := s.state.ContractStorageIterator(addr, start)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.state.ContractStorageLogIterator(addr, start)
+	if err != nil {
+		return nil, db.CloseAndWrapOnError(head.Close, err)
+	}
+	return NewOverlayStorageIterator(head, logs, func(location *felt.Felt) (*felt.Felt, error) {
+		return s.storageAt(addr, location)
+	}), nil
+}
+
 func (s *stateSnapshot) checkDeployed(addr *felt.Felt) error {
 	isDeployed, err := s.state.ContractIsAlreadyDeployedAt(addr, s.blockNumber)
 	if err != nil {
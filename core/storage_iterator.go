@@ -0,0 +1,222 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/core/trie"
+	"github.com/NethermindEth/juno/db"
+)
+
+// KeyIterator iterates over keys in ascending order
+type KeyIterator interface {
+	// Next moves the iterator to the next key and reports whether there is one
+	Next() bool
+	Key() *felt.Felt
+	// Err returns the error that stopped the iteration, if any
+	Err() error
+	Close() error
+}
+
+// StorageIterator iterates over the non-zero storage of a contract in ascending order of location
+type StorageIterator interface {
+	KeyIterator
+	Value() *felt.Felt
+}
+
+var _ StorageIterator = (*trie.LeafIterator)(nil)
+
+// storageLogIterator iterates over the storage locations of a contract whose changes were logged
+type storageLogIterator struct {
+	it     db.Iterator
+	prefix []byte
+	seeked bool
+	start  []byte
+	key    *felt.Felt
+}
+
+// ContractStorageLogIterator returns an iterator over the storage locations of the contract at addr that
+// changed since it was deployed, from location start
+func (h *History) ContractStorageLogIterator(addr, start *felt.Felt) (KeyIterator, error) {
+	it, err := h.txn.NewIterator()
+	if err != nil {
+		return nil, err
+	}
+	prefix := db.ContractStorageHistory.Key(addr.Marshal())
+	return &storageLogIterator{
+		it:     it,
+		prefix: prefix,
+		start:  append(bytes.Clone(prefix), start.Marshal()...),
+	}, nil
+}
+
+func (l *storageLogIterator) Next() bool {
+	for {
+		var valid bool
+		if !l.seeked {
+			valid, l.seeked = l.it.Seek(l.start), true
+		} else {
+			valid = l.it.Next()
+		}
+
+		logKey := l.it.Key()
+		// the keys of the logs are the location followed by the height of the change
+		if !valid || len(logKey) != len(l.prefix)+felt.Bytes+8 || !bytes.HasPrefix(logKey, l.prefix) {
+			l.key = nil
+			return false
+		}
+
+		location := new(felt.Felt).SetBytes(logKey[len(l.prefix) : len(l.prefix)+felt.Bytes])
+		// a location is logged once per change
+		if l.key != nil && l.key.Equal(location) {
+			continue
+		}
+		l.key = location
+		return true
+	}
+}
+
+func (l *storageLogIterator) Key() *felt.Felt {
+	return l.key
+}
+
+// Err returns nil, the errors of the database are returned by Close
+func (l *storageLogIterator) Err() error {
+	return nil
+}
+
+func (l *storageLogIterator) Close() error {
+	return l.it.Close()
+}
+
+// sliceKeyIterator iterates over a sorted slice of keys
+type sliceKeyIterator struct {
+	keys []*felt.Felt
+	key  *felt.Felt
+}
+
+// NewSliceKeyIterator returns an iterator over keys
+func NewSliceKeyIterator(keys []*felt.Felt) KeyIterator {
+	sorted := make([]*felt.Felt, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	return &sliceKeyIterator{keys: sorted}
+}
+
+func (s *sliceKeyIterator) Next() bool {
+	if len(s.keys) == 0 {
+		s.key = nil
+		return false
+	}
+	s.key, s.keys = s.keys[0], s.keys[1:]
+	return true
+}
+
+func (s *sliceKeyIterator) Key() *felt.Felt {
+	return s.key
+}
+
+func (s *sliceKeyIterator) Err() error {
+	return nil
+}
+
+func (s *sliceKeyIterator) Close() error {
+	return nil
+}
+
+// overlayStorageIterator iterates over the union of the locations of two iterators
+type overlayStorageIterator struct {
+	iterators [2]KeyIterator
+	// heads are the current keys of iterators, nil once an iterator is exhausted
+	heads   [2]*felt.Felt
+	started bool
+	value   func(location *felt.Felt) (*felt.Felt, error)
+
+	key *felt.Felt
+	val *felt.Felt
+	err error
+}
+
+// NewOverlayStorageIterator returns an iterator over the locations of base and overlay, such as the storage of
+// the head state and the locations a pending block or the history changes. The values of the locations are read
+// with value and the locations whose value is zero are skipped.
+func NewOverlayStorageIterator(base, overlay KeyIterator, value func(location *felt.Felt) (*felt.Felt, error),
+) StorageIterator {
+	return &overlayStorageIterator{
+		iterators: [2]KeyIterator{base, overlay},
+		value:     value,
+	}
+}
+
+func (o *overlayStorageIterator) advance(i int) {
+	o.heads[i] = nil
+	if o.iterators[i].Next() {
+		o.heads[i] = o.iterators[i].Key()
+	}
+}
+
+func (o *overlayStorageIterator) Next() bool {
+	if !o.started {
+		o.advance(0)
+		o.advance(1)
+		o.started = true
+	}
+
+	for o.err == nil {
+		var location *felt.Felt
+		for _, head := range o.heads {
+			if head != nil && (location == nil || head.Cmp(location) < 0) {
+				location = head
+			}
+		}
+		if location == nil {
+			break
+		}
+
+		for i, head := range o.heads {
+			if head != nil && head.Equal(location) {
+				o.advance(i)
+			}
+		}
+
+		val, err := o.value(location)
+		if err != nil {
+			o.err = err
+			break
+		}
+		if !val.IsZero() {
+			o.key, o.val = location, val
+			return true
+		}
+	}
+	o.key, o.val = nil, nil
+	return false
+}
+
+func (o *overlayStorageIterator) Key() *felt.Felt {
+	return o.key
+}
+
+func (o *overlayStorageIterator) Value() *felt.Felt {
+	return o.val
+}
+
+func (o *overlayStorageIterator) Err() error {
+	if o.err != nil {
+		return o.err
+	}
+	for _, it := range o.iterators {
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayStorageIterator) Close() error {
+	return errors.Join(o.iterators[0].Close(), o.iterators[1].Close())
+}
@@ -0,0 +1,16 @@
+package core
+
+import (
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+)
+
+// The buckets of the state that are not tries, see db.Table
+var (
+	classTable = db.NewTable[*felt.Felt, DeclaredClass](db.Class, db.FeltCodec{},
+		db.EncodedCodec[DeclaredClass]{})
+	contractNonceTable     = db.NewTable[*felt.Felt, *felt.Felt](db.ContractNonce, db.FeltCodec{}, db.FeltCodec{})
+	contractClassHashTable = db.NewTable[*felt.Felt, *felt.Felt](db.ContractClassHash, db.FeltCodec{}, db.FeltCodec{})
+	deploymentHeightTable  = db.NewTable[*felt.Felt, uint64](db.ContractDeploymentHeight, db.FeltCodec{},
+		db.Uint64Codec{})
+)
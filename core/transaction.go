@@ -363,6 +363,9 @@ func VerifyTransactions(txs []Transaction, n utils.Network, protocolVersion stri
 	if blockVersion.LessThan(semver.MustParse("0.11.0")) {
 		return nil
 	}
+	if verification, ok := n.Verification(); ok && verification.TrustTransactionHashes {
+		return nil
+	}
 
 	for _, t := range txs {
 		calculatedTxHash, hErr := TransactionHash(t, n)
@@ -504,3 +507,35 @@ func EventsBloom(receipts []*TransactionReceipt) *bloom.BloomFilter {
 	}
 	return filter
 }
+
+// InternEvents interns the emitters and the keys of the events of receipts, which repeat across the blocks, so that
+// the receipts that are held in memory share them. The interned felts must not be modified, see felt.Interner, so
+// the events of the receipts are copied before they are returned to the callers of the caches, see Event.Copy.
+func InternEvents(receipts []*TransactionReceipt) {
+	for _, receipt := range receipts {
+		for _, event := range receipt.Events {
+			event.From = felt.Intern(event.From)
+			felt.InternAll(event.Keys)
+		}
+	}
+}
+
+// Copy returns a copy of e that shares none of its felts
+func (e *Event) Copy() *Event {
+	return &Event{
+		Data: copyFelts(e.Data),
+		From: copyFelt(e.From),
+		Keys: copyFelts(e.Keys),
+	}
+}
+
+func copyFelts(fs []*felt.Felt) []*felt.Felt {
+	if fs == nil {
+		return nil
+	}
+	copied := make([]*felt.Felt, len(fs))
+	for i, f := range fs {
+		copied[i] = copyFelt(f)
+	}
+	return copied
+}
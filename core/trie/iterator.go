@@ -0,0 +1,108 @@
+package trie
+
+import (
+	"encoding/binary"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/bits-and-blooms/bitset"
+)
+
+// LeafIterator iterates over the leaves of a [Trie] in ascending order of their keys
+type LeafIterator struct {
+	trie  *Trie
+	start *bitset.BitSet
+	// stack holds the keys of the nodes left to visit, the next one is last
+	stack []*bitset.BitSet
+	key   *felt.Felt
+	value *felt.Felt
+	err   error
+}
+
+// LeafIterator returns an iterator over the leaves of t whose keys are not lower than start, or over all
+// of them if start is nil
+func (t *Trie) LeafIterator(start *felt.Felt) *LeafIterator {
+	it := &LeafIterator{
+		trie:  t,
+		start: t.feltToBitSet(start),
+	}
+	if t.rootKey != nil {
+		it.stack = append(it.stack, t.rootKey.Clone())
+	}
+	return it
+}
+
+// Next moves the iterator to the next leaf and reports whether there is one
+func (it *LeafIterator) Next() bool {
+	for len(it.stack) > 0 && it.err == nil {
+		key := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+		// the leaves under key are all lower than start
+		if it.start != nil && comparePrefix(key, it.start) < 0 {
+			continue
+		}
+
+		node, err := it.trie.storage.Get(key)
+		if err != nil {
+			it.err = err
+			break
+		}
+		if key.Len() == it.trie.height {
+			it.key, it.value = bitSetToFelt(key), new(felt.Felt).Set(node.Value)
+			nodePool.Put(node)
+			// the leaves that follow are greater than this one
+			it.start = nil
+			return true
+		}
+
+		// the left child is pushed last so that it is visited first, the children of pooled nodes are reused
+		it.stack = append(it.stack, node.Right.Clone(), node.Left.Clone())
+		nodePool.Put(node)
+	}
+	it.key, it.value = nil, nil
+	return false
+}
+
+// Key returns the key of the current leaf
+func (it *LeafIterator) Key() *felt.Felt {
+	return it.key
+}
+
+// Value returns the value of the current leaf
+func (it *LeafIterator) Value() *felt.Felt {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *LeafIterator) Err() error {
+	return it.err
+}
+
+// Close releases the iterator, it returns the error that stopped the iteration, if any
+func (it *LeafIterator) Close() error {
+	it.stack = nil
+	return it.err
+}
+
+// comparePrefix compares key, the key of a node, to the same number of most significant bits of leafKey
+func comparePrefix(key, leafKey *bitset.BitSet) int {
+	for i := uint(1); i <= key.Len(); i++ {
+		keyBit, leafBit := key.Test(key.Len()-i), leafKey.Test(leafKey.Len()-i)
+		if keyBit != leafBit {
+			if keyBit {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// bitSetToFelt returns the felt that key, the key of a leaf, is the bits of
+func bitSetToFelt(key *bitset.BitSet) *felt.Felt {
+	var keyBytes [felt.Bytes]byte
+	for idx, word := range key.Bytes() {
+		startBytes := 24 - (idx * 8)
+		binary.BigEndian.PutUint64(keyBytes[startBytes:startBytes+8], word)
+	}
+	return new(felt.Felt).SetBytes(keyBytes[:])
+}
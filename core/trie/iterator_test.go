@@ -0,0 +1,97 @@
+package trie_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/core/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeafIterator(t *testing.T) {
+	leaves := func(t *testing.T, it *trie.LeafIterator) map[uint64]uint64 {
+		t.Helper()
+		result := make(map[uint64]uint64)
+		var last *felt.Felt
+		for it.Next() {
+			if last != nil {
+				assert.Equal(t, 1, it.Key().Cmp(last), "leaves are not in ascending order")
+			}
+			last = it.Key()
+			result[it.Key().Uint64()] = it.Value().Uint64()
+		}
+		require.NoError(t, it.Close())
+		return result
+	}
+
+	t.Run("empty trie", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			assert.Empty(t, leaves(t, tempTrie.LeafIterator(nil)))
+			return nil
+		}))
+	})
+
+	t.Run("single leaf", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			_, err := tempTrie.Put(new(felt.Felt).SetUint64(7), new(felt.Felt).SetUint64(70))
+			require.NoError(t, err)
+			require.NoError(t, tempTrie.Commit())
+
+			assert.Equal(t, map[uint64]uint64{7: 70}, leaves(t, tempTrie.LeafIterator(nil)))
+			assert.Equal(t, map[uint64]uint64{7: 70}, leaves(t, tempTrie.LeafIterator(new(felt.Felt).SetUint64(7))))
+			assert.Empty(t, leaves(t, tempTrie.LeafIterator(new(felt.Felt).SetUint64(8))))
+			return nil
+		}))
+	})
+
+	t.Run("leaves from start", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			all := make(map[uint64]uint64)
+			for _, key := range []uint64{9, 1, 1000, 4, 5, 300, 2} {
+				_, err := tempTrie.Put(new(felt.Felt).SetUint64(key), new(felt.Felt).SetUint64(key*10))
+				require.NoError(t, err)
+				all[key] = key * 10
+			}
+			// zero values delete their leaf
+			_, err := tempTrie.Put(new(felt.Felt).SetUint64(4), new(felt.Felt))
+			require.NoError(t, err)
+			delete(all, 4)
+			require.NoError(t, tempTrie.Commit())
+
+			assert.Equal(t, all, leaves(t, tempTrie.LeafIterator(nil)))
+			assert.Equal(t, map[uint64]uint64{5: 50, 9: 90, 300: 3000, 1000: 10000},
+				leaves(t, tempTrie.LeafIterator(new(felt.Felt).SetUint64(3))))
+			assert.Equal(t, map[uint64]uint64{1000: 10000}, leaves(t, tempTrie.LeafIterator(new(felt.Felt).SetUint64(301))))
+			assert.Empty(t, leaves(t, tempTrie.LeafIterator(new(felt.Felt).SetUint64(1001))))
+			return nil
+		}))
+	})
+
+	t.Run("keys wider than a word", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			keys := []string{
+				"0x1",
+				"0x10000000000000000",
+				"0x5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a",
+				"0x7ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			}
+			for _, hex := range keys {
+				key, err := new(felt.Felt).SetString(hex)
+				require.NoError(t, err)
+				_, err = tempTrie.Put(key, key)
+				require.NoError(t, err)
+			}
+			require.NoError(t, tempTrie.Commit())
+
+			it := tempTrie.LeafIterator(nil)
+			for _, hex := range keys {
+				require.True(t, it.Next())
+				assert.Equal(t, hex, it.Key().String())
+				assert.Equal(t, hex, it.Value().String())
+			}
+			assert.False(t, it.Next())
+			return it.Close()
+		}))
+	})
+}
@@ -0,0 +1,152 @@
+package trie
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/bits-and-blooms/bitset"
+)
+
+// A ProofNode is a node of the path from the root of a [Trie] to a leaf, in the form the commitment of the [Trie]
+// is specified in. Exactly one of Binary and Edge is set.
+type ProofNode struct {
+	Binary *BinaryNode `json:"binary,omitempty"`
+	Edge   *EdgeNode   `json:"edge,omitempty"`
+}
+
+// A BinaryNode is a node with two children, given by their hashes
+type BinaryNode struct {
+	Left  *felt.Felt `json:"left"`
+	Right *felt.Felt `json:"right"`
+}
+
+// An EdgeNode is the path of Length bits that leads to a child, given by its hash
+type EdgeNode struct {
+	Child  *felt.Felt `json:"child"`
+	Path   *felt.Felt `json:"path"`
+	Length uint8      `json:"length"`
+}
+
+// Hash calculates the hash of a [ProofNode]
+func (n *ProofNode) Hash(hash func(*felt.Felt, *felt.Felt) *felt.Felt) *felt.Felt {
+	if n.Binary != nil {
+		return hash(n.Binary.Left, n.Binary.Right)
+	}
+	h := hash(n.Edge.Child, n.Edge.Path)
+	return h.Add(h, new(felt.Felt).SetUint64(uint64(n.Edge.Length)))
+}
+
+// Prove returns the nodes of the path from the root of t to the leaf of key, starting with the root: a [BinaryNode]
+// for every node on the path with the hashes of both its children, preceded by an [EdgeNode] for every node whose path
+// from its parent is not empty. The path of the leaf is followed while the leaf exists, the proof of a key that is
+// not in t ends with the [EdgeNode] that diverges from it. The dirty nodes are hashed first, as [Trie.Root] does.
+func (t *Trie) Prove(key *felt.Felt) ([]ProofNode, error) {
+	if key.Cmp(t.maxKey) > 0 {
+		return nil, fmt.Errorf("key %s exceeds trie height %d", key, t.height)
+	}
+	if _, err := t.Root(); err != nil {
+		return nil, err
+	}
+
+	leafKey := t.feltToBitSet(key)
+	var proof []ProofNode
+	var parent *bitset.BitSet
+	cur := t.rootKey
+	for cur != nil {
+		node, err := t.storage.Get(cur)
+		if err != nil {
+			return nil, err
+		}
+		if p := path(cur, parent); p.Len() > 0 {
+			proof = append(proof, ProofNode{Edge: &EdgeNode{
+				Child:  new(felt.Felt).Set(node.Value),
+				Path:   bitSetToFelt(p),
+				Length: uint8(p.Len()),
+			}})
+		}
+		if cur.Len() == t.height || !isSubset(leafKey, cur) {
+			nodePool.Put(node)
+			break
+		}
+
+		// the children of pooled nodes are reused
+		left, right := node.Left.Clone(), node.Right.Clone()
+		nodePool.Put(node)
+		binaryNode := &BinaryNode{}
+		if binaryNode.Left, err = t.childHash(left, cur); err != nil {
+			return nil, err
+		}
+		if binaryNode.Right, err = t.childHash(right, cur); err != nil {
+			return nil, err
+		}
+		proof = append(proof, ProofNode{Binary: binaryNode})
+
+		parent = cur
+		if leafKey.Test(leafKey.Len() - cur.Len() - 1) {
+			cur = right
+		} else {
+			cur = left
+		}
+	}
+	return proof, nil
+}
+
+// childHash returns the hash of the child with key of the node with parentKey
+func (t *Trie) childHash(key, parentKey *bitset.BitSet) (*felt.Felt, error) {
+	child, err := t.storage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer nodePool.Put(child)
+	return child.Hash(path(key, parentKey), t.hash), nil
+}
+
+// VerifyProof reports whether proof, as returned by [Trie.Prove], proves that the leaf of key has value in the trie of
+// height whose commitment is root and whose nodes are hashed with hash. The value of a key that is not in the trie
+// is zero.
+func VerifyProof(root, key, value *felt.Felt, height uint, proof []ProofNode,
+	hash func(*felt.Felt, *felt.Felt) *felt.Felt,
+) bool {
+	keyBits := key.Bits()
+	leafKey := bitset.FromWithLength(height, keyBits[:])
+	// depth is the number of bits of the key that the nodes verified so far lead along
+	var depth uint
+	expected := root
+	for i := range proof {
+		node := &proof[i]
+		if (node.Binary == nil) == (node.Edge == nil) || depth >= height || !node.Hash(hash).Equal(expected) {
+			return false
+		}
+
+		if node.Binary != nil {
+			if leafKey.Test(height - depth - 1) {
+				expected = node.Binary.Right
+			} else {
+				expected = node.Binary.Left
+			}
+			depth++
+			continue
+		}
+
+		length := uint(node.Edge.Length)
+		if length == 0 || depth+length > height {
+			return false
+		}
+		pathBits := node.Edge.Path.Bits()
+		edgePath := bitset.FromWithLength(length, pathBits[:])
+		for bit := uint(0); bit < length; bit++ {
+			if edgePath.Test(length-bit-1) != leafKey.Test(height-depth-bit-1) {
+				// the edge leads away from the key, which is not in the trie
+				return i == len(proof)-1 && value.IsZero()
+			}
+		}
+		expected = node.Edge.Child
+		depth += length
+	}
+
+	if len(proof) == 0 {
+		// the trie is empty
+		return root.IsZero() && value.IsZero()
+	}
+	return depth == height && expected.Equal(value)
+}
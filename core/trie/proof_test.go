@@ -0,0 +1,126 @@
+package trie_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/core/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProve(t *testing.T) {
+	keys := []uint64{0b0000, 0b0001, 0b0100, 0b1101, 0b1111_0000}
+	verify := func(root *felt.Felt, key, value uint64, proof []trie.ProofNode) bool {
+		return trie.VerifyProof(root, new(felt.Felt).SetUint64(key), new(felt.Felt).SetUint64(value), 251, proof,
+			crypto.Pedersen)
+	}
+
+	t.Run("empty trie", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			proof, err := tempTrie.Prove(new(felt.Felt).SetUint64(1))
+			require.NoError(t, err)
+			assert.Empty(t, proof)
+			assert.True(t, verify(&felt.Zero, 1, 0, proof))
+			assert.False(t, verify(&felt.Zero, 1, 1, proof))
+			return nil
+		}))
+	})
+
+	t.Run("single leaf", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			_, err := tempTrie.Put(new(felt.Felt).SetUint64(5), new(felt.Felt).SetUint64(50))
+			require.NoError(t, err)
+			root, err := tempTrie.Root()
+			require.NoError(t, err)
+
+			proof, err := tempTrie.Prove(new(felt.Felt).SetUint64(5))
+			require.NoError(t, err)
+			require.Len(t, proof, 1)
+			assert.Equal(t, uint8(251), proof[0].Edge.Length)
+			assert.True(t, verify(root, 5, 50, proof))
+			assert.False(t, verify(root, 5, 51, proof))
+
+			proof, err = tempTrie.Prove(new(felt.Felt).SetUint64(6))
+			require.NoError(t, err)
+			assert.True(t, verify(root, 6, 0, proof))
+			return nil
+		}))
+	})
+
+	t.Run("leaves", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			for _, key := range keys {
+				_, err := tempTrie.Put(new(felt.Felt).SetUint64(key), new(felt.Felt).SetUint64(key+100))
+				require.NoError(t, err)
+			}
+			// the dirty nodes are hashed by Prove
+			proof, err := tempTrie.Prove(new(felt.Felt).SetUint64(keys[0]))
+			require.NoError(t, err)
+			root, err := tempTrie.Root()
+			require.NoError(t, err)
+			assert.True(t, verify(root, keys[0], keys[0]+100, proof))
+
+			for _, key := range keys {
+				proof, err = tempTrie.Prove(new(felt.Felt).SetUint64(key))
+				require.NoError(t, err)
+				assert.True(t, verify(root, key, key+100, proof), "key %b", key)
+				assert.False(t, verify(root, key, key+101, proof), "key %b", key)
+				assert.False(t, verify(new(felt.Felt).SetUint64(1), key, key+100, proof), "key %b", key)
+			}
+
+			for _, key := range []uint64{0b0010, 0b1100, 0b1_0000_0000} {
+				proof, err = tempTrie.Prove(new(felt.Felt).SetUint64(key))
+				require.NoError(t, err)
+				assert.True(t, verify(root, key, 0, proof), "key %b", key)
+				assert.False(t, verify(root, key, 1, proof), "key %b", key)
+			}
+			return nil
+		}))
+	})
+
+	t.Run("tampered proof", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(251, func(tempTrie *trie.Trie) error {
+			for _, key := range keys {
+				_, err := tempTrie.Put(new(felt.Felt).SetUint64(key), new(felt.Felt).SetUint64(key+100))
+				require.NoError(t, err)
+			}
+			root, err := tempTrie.Root()
+			require.NoError(t, err)
+			proof, err := tempTrie.Prove(new(felt.Felt).SetUint64(0b0100))
+			require.NoError(t, err)
+
+			// the proof of a key is not the proof of another
+			assert.False(t, verify(root, 0b0001, 0b0100+100, proof))
+			// nor is a part of it
+			assert.False(t, verify(root, 0b0100, 0b0100+100, proof[:len(proof)-1]))
+
+			// the proof is serializable
+			encoded, err := json.Marshal(proof)
+			require.NoError(t, err)
+			var decoded []trie.ProofNode
+			require.NoError(t, json.Unmarshal(encoded, &decoded))
+			assert.Equal(t, proof, decoded)
+			assert.True(t, verify(root, 0b0100, 0b0100+100, decoded))
+
+			for i := range decoded {
+				if decoded[i].Binary != nil {
+					decoded[i].Binary.Left, decoded[i].Binary.Right = decoded[i].Binary.Right, decoded[i].Binary.Left
+					break
+				}
+			}
+			assert.False(t, verify(root, 0b0100, 0b0100+100, decoded))
+			return nil
+		}))
+	})
+
+	t.Run("key exceeds the height", func(t *testing.T) {
+		require.NoError(t, trie.RunOnTempTrie(8, func(tempTrie *trie.Trie) error {
+			_, err := tempTrie.Prove(new(felt.Felt).SetUint64(256))
+			assert.Error(t, err)
+			return nil
+		}))
+	})
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBackend is the backend the databases of the node are opened with unless another one is chosen
+const DefaultBackend = "pebble"
+
+// ErrUnknownBackend is returned by Open when no backend is registered under the name it is given
+var ErrUnknownBackend = errors.New("unknown database backend")
+
+// Logger is what the backends log with
+type Logger interface {
+	Infof(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// Options are the options a backend opens a database with
+type Options struct {
+	// CacheSize is the size in bytes of the block cache of the database
+	CacheSize uint64
+	// Namespace is the namespace of the metrics of the database, so that several databases can be opened by a node.
+	// The backends use their own default if it is empty.
+	Namespace string
+	Logger    Logger
+	// Compression is the set of buckets whose values are compressed, by the backends that support it. The values are
+	// written with the buckets the database records that its values are compressed in, which are those of Compression
+	// only once the database is reencoded, see Reencode.
+	Compression Compression
+	// Checksums are the buckets whose values are checksummed and how their checksums are verified, by the backends
+	// that support them. The values are read and written with the buckets the database records that its values are
+	// checksummed in, which are those of Checksums only once the database is reencoded, see Reencode.
+	Checksums Checksums
+	// Reencode rewrites the values of the buckets that are added to or removed from the compressed or checksummed
+	// buckets the database records, so that it stores its values as Compression and Checksums say. It is re-run
+	// whenever they change. The tools that open a database without the settings of the node leave it false, which
+	// keeps the values as they are stored.
+	Reencode bool
+	// Tuning are the settings of the storage engine, by the backends that support them
+	Tuning Tuning
+	// WALArchiveDir is the directory the write-ahead logs are moved to once their writes are flushed, by the backends
+	// that archive them, so that the database can be restored to a point in time with RestoreToPoint. The logs are
+	// deleted if it is empty.
+	WALArchiveDir string
+}
+
+// Tuning are the settings of the storage engine of a database, whose defaults suit neither the machines with little
+// memory nor the archive nodes with plenty of it. Their zero values keep the defaults of the backend.
+type Tuning struct {
+	// MemTableSize is the size in bytes of the memtables the writes are buffered in until they are flushed to disk
+	MemTableSize uint64
+	// MaxOpenFiles is the number of files the database keeps open at most
+	MaxOpenFiles uint
+	// CompactionConcurrency is the number of compactions that run at once at most
+	CompactionConcurrency uint
+	// WALDir is the directory of the write-ahead log, the directory of the database if it is empty. It is usually
+	// on a faster volume than the database.
+	WALDir string
+	// WALBytesPerSync is the number of bytes written to the write-ahead log after which it is synced to disk in the
+	// background, so that the syncs of the commits do not stall on large writes
+	WALBytesPerSync uint64
+}
+
+// Backend opens the database of a key-value store at path, which is created if it does not exist
+type Backend func(path string, opts Options) (DB, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// Register makes backend available to Open under name. It is called from an init function of the package of the
+// backend, so that a build of Juno supports the backends of the packages it imports, and panics if name is taken.
+func Register(name string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if backend == nil {
+		panic("db: Register backend is nil")
+	}
+	if _, found := backends[name]; found {
+		panic(fmt.Sprintf("db: Register called twice for backend %s", name))
+	}
+	backends[name] = backend
+}
+
+// Open opens the database at path with the backend registered under name
+func Open(name, path string, opts Options) (DB, error) {
+	backendsMu.RLock()
+	backend, found := backends[name]
+	backendsMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("%w %q, the backends are %s", ErrUnknownBackend, name, strings.Join(Backends(), ", "))
+	}
+	return backend(path, opts)
+}
+
+// Backends returns the names of the registered backends, sorted
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
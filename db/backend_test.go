@@ -0,0 +1,55 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackends(t *testing.T) {
+	assert.Contains(t, db.Backends(), db.DefaultBackend)
+
+	t.Run("open a registered backend", func(t *testing.T) {
+		var opened string
+		var openedOpts db.Options
+		db.Register("test", func(path string, opts db.Options) (db.DB, error) {
+			opened, openedOpts = path, opts
+			return pebble.NewMemTest(), nil
+		})
+		assert.Contains(t, db.Backends(), "test")
+
+		database, err := db.Open("test", "/tmp/test", db.Options{CacheSize: 8, Namespace: "test_db"})
+		require.NoError(t, err)
+		require.NoError(t, database.Close())
+		assert.Equal(t, "/tmp/test", opened)
+		assert.Equal(t, db.Options{CacheSize: 8, Namespace: "test_db"}, openedOpts)
+	})
+
+	t.Run("open the default backend", func(t *testing.T) {
+		database, err := db.Open(db.DefaultBackend, t.TempDir(), db.Options{CacheSize: 1 << 20})
+		require.NoError(t, err)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.Set([]byte("key"), []byte("value"))
+		}))
+		require.NoError(t, database.Close())
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		_, err := db.Open("unknown", t.TempDir(), db.Options{})
+		require.ErrorIs(t, err, db.ErrUnknownBackend)
+	})
+
+	t.Run("register twice", func(t *testing.T) {
+		assert.Panics(t, func() {
+			db.Register(db.DefaultBackend, func(string, db.Options) (db.DB, error) {
+				return nil, nil
+			})
+		})
+		assert.Panics(t, func() {
+			db.Register("nil", nil)
+		})
+	})
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriteBackup writes a point-in-time copy of database to w as a tarball of the files of its backup, while the
+// database keeps being used. The backup is taken in a temporary directory in tmpDir before it is written, which
+// should be on the filesystem of the database for the files of the backup to be hard links rather than copies.
+// The system's temporary directory is used if tmpDir is empty.
+func WriteBackup(database DB, w io.Writer, tmpDir string) error {
+	tmp, err := os.MkdirTemp(tmpDir, "juno-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	backupDir := filepath.Join(tmp, "db")
+	if err = database.Backup(backupDir); err != nil {
+		return fmt.Errorf("back up database: %w", err)
+	}
+
+	tarWriter := tar.NewWriter(w)
+	err = filepath.WalkDir(backupDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(backupDir, path)
+		if err != nil {
+			return err
+		}
+		if err = writeBackupFile(tarWriter, path, filepath.ToSlash(name)); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tarWriter.Close()
+}
+
+func writeBackupFile(tarWriter *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+// Restore extracts the backup written by WriteBackup read from r to path, which must not exist or be empty.
+// Nothing is left at path if the backup cannot be read.
+func Restore(r io.Reader, path string) error {
+	if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	tmp := strings.TrimSuffix(path, string(filepath.Separator)) + ".restore"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := extractBackup(r, tmp); err != nil {
+		return errors.Join(err, os.RemoveAll(tmp))
+	}
+
+	// path is empty if it exists
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func extractBackup(r io.Reader, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(r)
+	for files := 0; ; files++ {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			if files == 0 {
+				return errors.New("backup is empty")
+			}
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read backup: %w", err)
+		}
+
+		if !filepath.IsLocal(header.Name) || header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unexpected file %s in backup", header.Name)
+		}
+		if err = extractBackupFile(tarReader, filepath.Join(dir, filepath.FromSlash(header.Name))); err != nil {
+			return fmt.Errorf("extract %s: %w", header.Name, err)
+		}
+	}
+}
+
+func extractBackupFile(r io.Reader, path string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+	_, err = io.Copy(file, r)
+	return err
+}
+
+// Replayer replays onto the database at path the commits of the write-ahead logs archived in archive that were made
+// up to point, see Options.WALArchiveDir, and returns the time of the last commit of the database after the replay
+type Replayer func(path, archive string, point time.Time) (time.Time, error)
+
+var (
+	replayersMu sync.RWMutex
+	replayers   = make(map[string]Replayer)
+)
+
+// RegisterReplayer makes the replayer of the backend registered under name available to RestoreToPoint, like
+// Register, and panics if name is taken
+func RegisterReplayer(name string, replayer Replayer) {
+	replayersMu.Lock()
+	defer replayersMu.Unlock()
+	if replayer == nil {
+		panic("db: RegisterReplayer replayer is nil")
+	}
+	if _, found := replayers[name]; found {
+		panic(fmt.Sprintf("db: RegisterReplayer called twice for backend %s", name))
+	}
+	replayers[name] = replayer
+}
+
+// RestoreToPoint restores the backup read from r to path like Restore, and replays onto it the commits archived in
+// archive that were made after the backup was taken and up to point, so that a database is restored to the time
+// before an operator error rather than to its last backup. The archive has to hold the write-ahead logs from before
+// the backup was taken. It returns the time of the last commit of the restored database, which is before point if
+// the logs of the commits up to point are not archived yet. Nothing is left at path if it fails.
+func RestoreToPoint(backend string, r io.Reader, archive, path string, point time.Time) (time.Time, error) {
+	replayersMu.RLock()
+	replay, found := replayers[backend]
+	replayersMu.RUnlock()
+	if !found {
+		return time.Time{}, fmt.Errorf("the %s backend does not archive its write-ahead logs", backend)
+	}
+
+	if err := Restore(r, path); err != nil {
+		return time.Time{}, err
+	}
+	restoredTo, err := replay(path, archive, point)
+	if err != nil {
+		return time.Time{}, errors.Join(fmt.Errorf("replay the write-ahead logs: %w", err), os.RemoveAll(path))
+	}
+	return restoredTo, nil
+}
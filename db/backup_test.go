@@ -0,0 +1,77 @@
+package db_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBackupRestore(t *testing.T) {
+	database, err := pebble.New(t.TempDir(), 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, database.Close())
+	})
+	set := func(database db.DB, key string) {
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.Set([]byte(key), []byte(key))
+		}))
+	}
+	has := func(database db.DB, key string) bool {
+		err := database.View(func(txn db.Transaction) error {
+			return txn.Get([]byte(key), func([]byte) error { return nil })
+		})
+		if err != nil {
+			require.ErrorIs(t, err, db.ErrKeyNotFound)
+		}
+		return err == nil
+	}
+	set(database, "before")
+
+	var backup bytes.Buffer
+	require.NoError(t, db.WriteBackup(database, &backup, t.TempDir()))
+	// the database is still open and the writes after the backup are not in it
+	set(database, "after")
+
+	t.Run("restore", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "restored")
+		require.NoError(t, db.Restore(bytes.NewReader(backup.Bytes()), path))
+
+		restored, err := pebble.New(path, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, restored.Close())
+		})
+		assert.True(t, has(restored, "before"))
+		assert.False(t, has(restored, "after"))
+	})
+
+	t.Run("path is not empty", func(t *testing.T) {
+		path := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(path, "file"), nil, 0o600))
+		require.ErrorContains(t, db.Restore(bytes.NewReader(backup.Bytes()), path), "is not empty")
+	})
+
+	t.Run("not a backup", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "restored")
+		require.Error(t, db.Restore(bytes.NewReader([]byte("not a tarball")), path))
+		assert.NoDirExists(t, path)
+		assert.NoDirExists(t, path+".restore")
+	})
+
+	t.Run("database without backups", func(t *testing.T) {
+		memDB := memory.New()
+		t.Cleanup(func() {
+			require.NoError(t, memDB.Close())
+		})
+		require.Error(t, db.WriteBackup(memDB, &backup, t.TempDir()))
+	})
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"errors"
+)
+
+// ErrBatchDone is returned by the methods of a Batch that is committed or discarded
+var ErrBatchDone = errors.New("batch is committed or discarded")
+
+// Batch holds writes that are committed to a database at once, without reading it. Its Size lets the writers that
+// produce more than fits in memory commit in batches of a given size instead of a guessed number of writes.
+type Batch interface {
+	// Set updates the value of the given key
+	Set(key, val []byte) error
+	// Delete removes the key from the database
+	Delete(key []byte) error
+	// Size returns the size in bytes of the writes of the batch
+	Size() uint64
+	// Commit writes the batch to the database atomically, waiting for the update transaction in progress if
+	// there is one. The batch cannot be used afterwards.
+	Commit() error
+	// Discard drops the writes of the batch, which cannot be used afterwards
+	Discard() error
+}
+
+// Batcher is implemented by the databases that build batches natively, see NewBatch
+type Batcher interface {
+	NewBatch() Batch
+}
+
+// NewBatch returns a batch of writes to database. The writes are buffered in memory and committed in an update
+// transaction if database is not a Batcher.
+func NewBatch(database DB) Batch {
+	if batcher, ok := database.(Batcher); ok {
+		return batcher.NewBatch()
+	}
+	return &bufferedBatch{database: database}
+}
+
+// bufferedWrite is a write of a bufferedBatch, a deletion if deleted is set
+type bufferedWrite struct {
+	key, value []byte
+	deleted    bool
+}
+
+type bufferedBatch struct {
+	database DB
+	writes   []bufferedWrite
+	size     uint64
+	done     bool
+}
+
+// Set : see db.Batch.Set
+func (b *bufferedBatch) Set(key, val []byte) error {
+	if b.done {
+		return ErrBatchDone
+	}
+	if len(key) == 0 {
+		return errors.New("empty key")
+	}
+	b.writes = append(b.writes, bufferedWrite{key: append([]byte{}, key...), value: append([]byte{}, val...)})
+	b.size += uint64(len(key) + len(val))
+	return nil
+}
+
+// Delete : see db.Batch.Delete
+func (b *bufferedBatch) Delete(key []byte) error {
+	if b.done {
+		return ErrBatchDone
+	}
+	b.writes = append(b.writes, bufferedWrite{key: append([]byte{}, key...), deleted: true})
+	b.size += uint64(len(key))
+	return nil
+}
+
+// Size : see db.Batch.Size
+func (b *bufferedBatch) Size() uint64 {
+	return b.size
+}
+
+// Commit : see db.Batch.Commit
+func (b *bufferedBatch) Commit() error {
+	if b.done {
+		return ErrBatchDone
+	}
+	writes := b.writes
+	if err := b.Discard(); err != nil {
+		return err
+	}
+	return b.database.Update(func(txn Transaction) error {
+		for _, w := range writes {
+			var err error
+			if w.deleted {
+				err = txn.Delete(w.key)
+			} else {
+				err = txn.Set(w.key, w.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Discard : see db.Batch.Discard
+func (b *bufferedBatch) Discard() error {
+	b.done = true
+	b.writes, b.size = nil, 0
+	return nil
+}
@@ -0,0 +1,60 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	databases := map[string]db.DB{
+		// pebble builds its batches natively and the in-memory database buffers them
+		"pebble": pebble.NewMemTest(),
+		"memory": memory.New(),
+	}
+	for name, database := range databases {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() {
+				require.NoError(t, database.Close())
+			})
+			require.NoError(t, database.Update(func(txn db.Transaction) error {
+				return txn.Set([]byte("deleted"), []byte("value"))
+			}))
+			has := func(key string) bool {
+				err := database.View(func(txn db.Transaction) error {
+					return txn.Get([]byte(key), func([]byte) error { return nil })
+				})
+				if err != nil {
+					require.ErrorIs(t, err, db.ErrKeyNotFound)
+				}
+				return err == nil
+			}
+
+			batch := db.NewBatch(database)
+			// the size of an empty batch is that of its header, if it has one
+			emptySize := batch.Size()
+			require.NoError(t, batch.Set([]byte("set"), make([]byte, 100)))
+			require.NoError(t, batch.Delete([]byte("deleted")))
+			assert.Error(t, batch.Set(nil, []byte("value")))
+			assert.GreaterOrEqual(t, batch.Size()-emptySize, uint64(len("set")+100+len("deleted")))
+
+			assert.False(t, has("set"), "the writes are not visible before the batch is committed")
+			assert.True(t, has("deleted"))
+			require.NoError(t, batch.Commit())
+			assert.True(t, has("set"))
+			assert.False(t, has("deleted"))
+			require.ErrorIs(t, batch.Set([]byte("key"), nil), db.ErrBatchDone)
+			require.ErrorIs(t, batch.Commit(), db.ErrBatchDone)
+
+			batch = db.NewBatch(database)
+			require.NoError(t, batch.Set([]byte("discarded"), []byte("value")))
+			require.NoError(t, batch.Discard())
+			require.ErrorIs(t, batch.Commit(), db.ErrBatchDone)
+			assert.False(t, has("discarded"))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"bytes"
+)
+
+var _ Iterator = (*boundedIterator)(nil)
+
+// boundedIterator hides the keys of an iterator that are out of its bounds
+type boundedIterator struct {
+	it           Iterator
+	lower, upper []byte
+	positioned   bool
+	// exhausted is set when SeekLast found no key, the iterator is then invalid whatever key it is at
+	exhausted bool
+}
+
+// NewBoundedIterator returns an iterator over the keys of it from lower, inclusive, to upper, exclusive, for the
+// databases whose iterators are not bounded natively. The keys out of the bounds are read but not returned.
+func NewBoundedIterator(it Iterator, lower, upper []byte) Iterator {
+	return &boundedIterator{it: it, lower: bytes.Clone(lower), upper: bytes.Clone(upper)}
+}
+
+// Valid : see db.Transaction.Iterator.Valid
+func (i *boundedIterator) Valid() bool {
+	if i.exhausted || !i.it.Valid() {
+		return false
+	}
+	key := i.it.Key()
+	return (i.lower == nil || bytes.Compare(key, i.lower) >= 0) && (i.upper == nil || bytes.Compare(key, i.upper) < 0)
+}
+
+// Key : see db.Transaction.Iterator.Key
+func (i *boundedIterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.it.Key()
+}
+
+// Value : see db.Transaction.Iterator.Value
+func (i *boundedIterator) Value() ([]byte, error) {
+	if !i.Valid() {
+		return nil, nil
+	}
+	return i.it.Value()
+}
+
+// Next : see db.Transaction.Iterator.Next
+func (i *boundedIterator) Next() bool {
+	if !i.positioned {
+		return i.Seek(i.lower)
+	}
+	if !i.Valid() {
+		return false
+	}
+	i.it.Next()
+	return i.Valid()
+}
+
+// Seek : see db.Transaction.Iterator.Seek
+func (i *boundedIterator) Seek(key []byte) bool {
+	i.positioned, i.exhausted = true, false
+	if bytes.Compare(key, i.lower) < 0 {
+		key = i.lower
+	}
+	i.it.Seek(key)
+	return i.Valid()
+}
+
+// Prev : see db.Transaction.Iterator.Prev
+func (i *boundedIterator) Prev() bool {
+	if !i.positioned {
+		return i.SeekLast(nil)
+	}
+	if !i.Valid() {
+		return false
+	}
+	i.it.Prev()
+	return i.Valid()
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *boundedIterator) SeekLast(prefix []byte) bool {
+	i.positioned, i.exhausted = true, false
+	if end := PrefixEnd(prefix); i.upper != nil && (end == nil || bytes.Compare(i.upper, end) < 0) {
+		// the last key of prefix may be after the bounds, the iterator is moved to the last key before them
+		if !i.it.Seek(i.upper) {
+			i.it.SeekLast(nil)
+		} else {
+			i.it.Prev()
+		}
+	} else {
+		i.it.SeekLast(prefix)
+	}
+	i.exhausted = !i.Valid() || !bytes.HasPrefix(i.it.Key(), prefix)
+	return i.Valid()
+}
+
+// Close : see db.Transaction.Iterator.Close
+func (i *boundedIterator) Close() error {
+	return i.it.Close()
+}
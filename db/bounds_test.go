@@ -0,0 +1,112 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unbounded makes the iterators of a transaction bounded by db.NewBoundedIterator
+type unbounded struct {
+	db.Transaction
+}
+
+func (t unbounded) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	it, err := t.NewIterator()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBoundedIterator(it, lower, upper), nil
+}
+
+func TestNewIteratorWithBounds(t *testing.T) {
+	pebbleDB, memDB := pebble.NewMemTest(), memory.New()
+	t.Cleanup(func() {
+		require.NoError(t, pebbleDB.Close())
+		require.NoError(t, memDB.Close())
+	})
+	for _, database := range []db.DB{pebbleDB, memDB} {
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			for _, key := range []string{"a", "b0", "b1", "b2", "c", "d"} {
+				if err := txn.Set([]byte(key), []byte(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	transactions := map[string]db.Transaction{
+		"pebble":  pebbleDB.NewTransaction(false),
+		"memory":  memDB.NewTransaction(false),
+		"wrapped": unbounded{pebbleDB.NewTransaction(false)},
+	}
+	t.Cleanup(func() {
+		for _, txn := range transactions {
+			require.NoError(t, txn.Discard())
+		}
+	})
+
+	// walk returns the keys the moves stop at
+	walk := func(t *testing.T, it db.Iterator, moves ...func(db.Iterator) bool) []string {
+		t.Helper()
+		var keys []string
+		for _, move := range moves {
+			valid := move(it)
+			require.Equal(t, valid, it.Valid())
+			key := "<invalid>"
+			if valid {
+				key = string(it.Key())
+				value, err := it.Value()
+				require.NoError(t, err)
+				require.Equal(t, key, string(value))
+			}
+			keys = append(keys, key)
+		}
+		return keys
+	}
+	next := func(it db.Iterator) bool { return it.Next() }
+	prev := func(it db.Iterator) bool { return it.Prev() }
+	seek := func(key string) func(db.Iterator) bool {
+		return func(it db.Iterator) bool { return it.Seek([]byte(key)) }
+	}
+	seekLast := func(prefix string) func(db.Iterator) bool {
+		return func(it db.Iterator) bool { return it.SeekLast([]byte(prefix)) }
+	}
+
+	for name, txn := range transactions {
+		t.Run(name, func(t *testing.T) {
+			newIterator := func(lower, upper string) db.Iterator {
+				var lowerKey, upperKey []byte
+				if lower != "" {
+					lowerKey = []byte(lower)
+				}
+				if upper != "" {
+					upperKey = []byte(upper)
+				}
+				it, err := txn.NewIteratorWithBounds(lowerKey, upperKey)
+				require.NoError(t, err)
+				t.Cleanup(func() {
+					require.NoError(t, it.Close())
+				})
+				return it
+			}
+
+			assert.Equal(t, []string{"b0", "b1", "b2", "<invalid>", "<invalid>"},
+				walk(t, newIterator("b", "c"), next, next, next, next, prev))
+			assert.Equal(t, []string{"b2", "b1", "b0", "<invalid>"},
+				walk(t, newIterator("b", "c"), prev, prev, prev, prev))
+			assert.Equal(t, []string{"b1", "b1", "<invalid>", "b2"},
+				walk(t, newIterator("b1", "c"), seek("a"), seek("b1"), seek("c"), seekLast("")))
+			assert.Equal(t, []string{"<invalid>", "b1", "<invalid>", "<invalid>", "a"},
+				walk(t, newIterator("", "b2"), seekLast("c"), seekLast("b"), seekLast("e"), next, seekLast("a")))
+			assert.Equal(t, []string{"c", "d", "<invalid>", "d"},
+				walk(t, newIterator("b3", ""), next, next, next, seekLast("")))
+			assert.Equal(t, []string{"<invalid>", "<invalid>"},
+				walk(t, newIterator("b3", "b4"), next, seekLast("")))
+		})
+	}
+}
@@ -30,6 +30,23 @@ const (
 	SchemaVersion
 	Pending
 	BlockCommitments
+	SubmittedTransactions // maps transaction hashes to transactions submitted to the gateway through this node
+	IndexProgress         // maps the names of optional indexes to the number of blocks they cover
+	IndexUndo             // maps the names of optional indexes and block numbers to the keys indexed for the block
+	EventBlocksByAddress  // optional index of the blocks with events emitted by a contract
+	Webhooks              // progress, recently notified blocks and undelivered notifications of webhooks
+	Analytics             // daily aggregates of the activity of the chain
+	MigrationCheckpoints  // maps the names of the migrations spanning several transactions to where they resume from
+	MigrationBackups      // maps schema versions to the backups taken before migrating from them
+	CommitJournal         // the block being committed, to recover from a commit that is interrupted
+	EventFilters          // EventFilterID -> the event filters installed by clients, with their cursors
+	BackgroundMigrations  // maps schema versions to the progress of the migrations applied in the background
+	SchemaMetadata        // the history of the migrations applied to and reverted from the database
+	ABIs                  // ClassHash -> the ABI of the class, which is not deleted if the class is reverted
+	Jobs                  // maps the names of the background jobs to their histories
+	Quarantine            // the entries moved aside by Repair because their values do not decode, by their keys
+	StateHistoryStart     // the first block whose history of the state is kept, that of the blocks before is pruned
+	ValueEncoding         // the buckets whose values are checksummed, and the progress of their rewrite when they change
 )
 
 // Key flattens a prefix and series of byte arrays into a single []byte.
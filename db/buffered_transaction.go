@@ -45,6 +45,11 @@ func (t *BufferedTransaction) Delete(key []byte) error {
 	return nil
 }
 
+// DeletePrefix : see db.Transaction.DeletePrefix, the keys are deleted one by one
+func (t *BufferedTransaction) DeletePrefix(prefix []byte) error {
+	return DeletePrefixByKey(t, prefix)
+}
+
 // Get : see db.Transaction.Get
 func (t *BufferedTransaction) Get(key []byte, cb func([]byte) error) error {
 	if value, found := t.updates[string(key)]; found {
@@ -56,6 +61,29 @@ func (t *BufferedTransaction) Get(key []byte, cb func([]byte) error) error {
 	return t.txn.Get(key, cb)
 }
 
+// MultiGet : see db.Transaction.MultiGet, the keys that are not pending are read from the underlying Transaction at
+// once
+func (t *BufferedTransaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	var unbuffered [][]byte
+	var indexes []int
+	for i, key := range keys {
+		value, found := t.updates[string(key)]
+		if !found {
+			unbuffered = append(unbuffered, key)
+			indexes = append(indexes, i)
+			continue
+		}
+		if value != nil {
+			if err := cb(i, value); err != nil {
+				return err
+			}
+		}
+	}
+	return t.txn.MultiGet(unbuffered, func(i int, value []byte) error {
+		return cb(indexes[i], value)
+	})
+}
+
 // Flush applies the pending changes to the underlying Transaction
 func (t *BufferedTransaction) Flush() error {
 	for key, value := range t.updates {
@@ -82,3 +110,8 @@ func (t *BufferedTransaction) Impl() any {
 func (t *BufferedTransaction) NewIterator() (Iterator, error) {
 	return nil, errors.New("buffered transactions dont support iterators")
 }
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds
+func (t *BufferedTransaction) NewIteratorWithBounds(lower, upper []byte) (Iterator, error) {
+	return t.NewIterator()
+}
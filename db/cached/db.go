@@ -0,0 +1,196 @@
+// Package cached wraps a database with caches of the values of its hot buckets, such as the recent headers and the
+// nodes of the tries, so that the reads that are repeated under the load of the RPC are served from memory rather
+// than from the storage engine. The caches are grouped by the buckets they hold, see Caches, and each group is a
+// memory.Cache sized by the memory budget.
+//
+// The caches are read through: the values that are not cached are read from the database and cached. The keys a
+// transaction writes are dropped from the caches when it commits, and the values a transaction caches are only
+// those of the latest commit, so that no transaction reads a value from the caches that its snapshot would not
+// read from the database.
+package cached
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/memory"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	_ db.DB        = (*DB)(nil)
+	_ db.Estimator = (*DB)(nil)
+	_ db.Meterer   = (*DB)(nil)
+)
+
+// Caches are the buckets whose values each cache holds, by the name of the cache
+var Caches = map[string][]db.Bucket{
+	"headers":   {db.BlockHeadersByNumber, db.BlockHeaderNumbersByHash},
+	"trie":      {db.StateTrie, db.ClassesTrie, db.ContractStorage},
+	"contracts": {db.ContractClassHash, db.ContractNonce, db.ContractDeploymentHeight},
+	"classes":   {db.Class},
+}
+
+// entryOverhead is an estimate of the memory a cached value takes on top of its key and its bytes
+const entryOverhead = 64
+
+// CacheNames returns the names of Caches, sorted
+func CacheNames() []string {
+	names := make([]string, 0, len(Caches))
+	for name := range Caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ConsumerName returns the name of the memory budget consumer of the cache called name
+func ConsumerName(name string) string {
+	return "db-cache-" + name
+}
+
+// entry is a cached value, along with the size of its key
+type entry struct {
+	value []byte
+	size  uint64
+}
+
+// DB is a database whose reads of the buckets of its caches are cached, its other methods are those of the database
+// it wraps
+type DB struct {
+	db.DB
+	// caches holds the cache of each bucket, nil for the buckets that are not cached
+	caches    [256]*memory.Cache[string, entry]
+	consumers map[string]*memory.Cache[string, entry]
+
+	// mu is held for writing while a transaction that wrote to the caches commits, and for reading to read or fill
+	// the caches
+	mu sync.RWMutex
+	// generation is the number of the commits that wrote to the caches, a transaction only reads and fills the
+	// caches while it is that of its snapshot
+	generation uint64
+}
+
+// New returns database with the reads of the buckets of the caches called names cached. The caches hold nothing
+// until they are sized, see Consumers.
+func New(database db.DB, names []string) (*DB, error) {
+	d := &DB{
+		DB:        database,
+		consumers: make(map[string]*memory.Cache[string, entry], len(names)),
+	}
+	for _, name := range names {
+		buckets, ok := Caches[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown database cache %q, the caches are %v", name, CacheNames())
+		}
+		cache := memory.NewCache[string, entry](0, func(e entry) uint64 {
+			return e.size
+		})
+		d.consumers[ConsumerName(name)] = cache
+		for _, bucket := range buckets {
+			d.caches[bucket] = cache
+		}
+	}
+	return d, nil
+}
+
+// Consumers returns the caches by the names of their memory budget consumers, see ConsumerName
+func (d *DB) Consumers() map[string]memory.Consumer {
+	consumers := make(map[string]memory.Consumer, len(d.consumers))
+	for name, cache := range d.consumers {
+		consumers[name] = cache
+	}
+	return consumers
+}
+
+// Uncached returns the database that d caches the reads of
+func (d *DB) Uncached() db.DB {
+	return d.DB
+}
+
+// NewTransaction : see db.DB.NewTransaction
+func (d *DB) NewTransaction(update bool) db.Transaction {
+	txn := &transaction{db: d, written: make(map[string]struct{})}
+	if update {
+		// an update transaction waits for the one that is committing, whose commit its snapshot includes
+		txn.Transaction = d.DB.NewTransaction(true)
+		d.mu.RLock()
+		txn.generation = d.generation
+		d.mu.RUnlock()
+		return txn
+	}
+
+	d.mu.RLock()
+	txn.generation = d.generation
+	txn.Transaction = d.DB.NewTransaction(false)
+	d.mu.RUnlock()
+	return txn
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(true)
+	if err := fn(txn); err != nil {
+		return db.CloseAndWrapOnError(txn.Discard, err)
+	}
+	return db.CloseAndWrapOnError(txn.Discard, txn.Commit())
+}
+
+// RangeKeyCount : see db.Estimator.RangeKeyCount
+func (d *DB) RangeKeyCount(start, end []byte) (uint64, error) {
+	if estimator, ok := d.DB.(db.Estimator); ok {
+		return estimator.RangeKeyCount(start, end)
+	}
+	return 0, nil
+}
+
+// RangeSize : see db.Estimator.RangeSize
+func (d *DB) RangeSize(start, end []byte) (uint64, error) {
+	if estimator, ok := d.DB.(db.Estimator); ok {
+		return estimator.RangeSize(start, end)
+	}
+	return 0, nil
+}
+
+// Meter : see db.Meterer.Meter. The hits of the caches are reported by the memory budget.
+func (d *DB) Meter() prometheus.Collector {
+	return db.MetersOf(d.DB)
+}
+
+// cacheOf returns the cache of the bucket of key, nil if it is not cached
+func (d *DB) cacheOf(key []byte) *memory.Cache[string, entry] {
+	if len(key) == 0 {
+		return nil
+	}
+	return d.caches[key[0]]
+}
+
+// get returns the cached value of key, unless a commit wrote to the caches since generation
+func (d *DB) get(cache *memory.Cache[string, entry], generation uint64, key []byte) ([]byte, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if generation != d.generation {
+		return nil, false
+	}
+	e, ok := cache.Get(string(key))
+	return e.value, ok
+}
+
+// add caches value, which is not used by the caller afterwards, for key, unless a commit wrote to the caches since
+// generation
+func (d *DB) add(cache *memory.Cache[string, entry], generation uint64, key, value []byte) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if generation != d.generation {
+		return
+	}
+	cache.Add(string(key), entry{value: value, size: uint64(len(key)+len(value)) + entryOverhead})
+}
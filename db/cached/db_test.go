@@ -0,0 +1,138 @@
+package cached_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/cached"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// get returns the value of key in txn, nil if it is not present
+func get(t *testing.T, txn db.Transaction, key []byte) []byte {
+	var value []byte
+	err := txn.Get(key, func(val []byte) error {
+		value = append([]byte{}, val...)
+		return nil
+	})
+	if err != nil {
+		require.ErrorIs(t, err, db.ErrKeyNotFound)
+	}
+	return value
+}
+
+func set(t *testing.T, database db.DB, key, value []byte) {
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		return txn.Set(key, value)
+	}))
+}
+
+func TestDB(t *testing.T) {
+	_, err := cached.New(pebble.NewMemTest(), []string{"blocks"})
+	require.ErrorContains(t, err, `unknown database cache "blocks"`)
+
+	cachedDB, err := cached.New(pebble.NewMemTest(), []string{"headers", "trie"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, cachedDB.Close())
+	})
+	consumers := cachedDB.Consumers()
+	require.Len(t, consumers, 2)
+	for _, consumer := range consumers {
+		consumer.Resize(1 << 20)
+	}
+	headers := consumers[cached.ConsumerName("headers")]
+
+	header := db.BlockHeadersByNumber.Key([]byte{1})
+	node := db.StateTrie.Key([]byte{1})
+	receipt := db.ReceiptsByBlockNumberAndIndex.Key([]byte{1})
+	for _, key := range [][]byte{header, node, receipt} {
+		set(t, cachedDB, key, []byte("v1"))
+	}
+
+	t.Run("reads are cached", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			require.NoError(t, cachedDB.View(func(txn db.Transaction) error {
+				assert.Equal(t, []byte("v1"), get(t, txn, header))
+				assert.Equal(t, []byte("v1"), get(t, txn, receipt))
+				return nil
+			}))
+		}
+		hits, misses := headers.(interface{ Stats() (uint64, uint64) }).Stats()
+		assert.Equal(t, uint64(1), hits)
+		assert.Equal(t, uint64(1), misses)
+		assert.NotZero(t, headers.Usage())
+	})
+
+	t.Run("writes are read back", func(t *testing.T) {
+		require.NoError(t, cachedDB.Update(func(txn db.Transaction) error {
+			assert.Equal(t, []byte("v1"), get(t, txn, header))
+			require.NoError(t, txn.Set(header, []byte("v2")))
+			assert.Equal(t, []byte("v2"), get(t, txn, header))
+			return nil
+		}))
+		require.NoError(t, cachedDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, []byte("v2"), get(t, txn, header))
+			return nil
+		}))
+	})
+
+	t.Run("snapshots do not read later commits from the caches", func(t *testing.T) {
+		before := cachedDB.NewTransaction(false)
+		assert.Equal(t, []byte("v2"), get(t, before, header))
+
+		set(t, cachedDB, header, []byte("v3"))
+		set(t, cachedDB, node, []byte("v3"))
+		// the node was not cached before the commit, it is not cached as of the snapshot of before
+		assert.Equal(t, []byte("v2"), get(t, before, header))
+		assert.Equal(t, []byte("v1"), get(t, before, node))
+		require.NoError(t, before.Discard())
+
+		require.NoError(t, cachedDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, []byte("v3"), get(t, txn, header))
+			assert.Equal(t, []byte("v3"), get(t, txn, node))
+			return nil
+		}))
+	})
+
+	t.Run("multiget", func(t *testing.T) {
+		missing := db.BlockHeadersByNumber.Key([]byte{2})
+		keys := [][]byte{header, node, receipt, missing}
+		for i := 0; i < 2; i++ {
+			values := make(map[int]string)
+			require.NoError(t, cachedDB.View(func(txn db.Transaction) error {
+				return txn.MultiGet(keys, func(i int, val []byte) error {
+					values[i] = string(val)
+					return nil
+				})
+			}))
+			assert.Equal(t, map[int]string{0: "v3", 1: "v3", 2: "v1"}, values)
+		}
+	})
+
+	t.Run("deleted prefixes are not read from the caches", func(t *testing.T) {
+		require.NoError(t, cachedDB.Update(func(txn db.Transaction) error {
+			require.NoError(t, txn.DeletePrefix(db.BlockHeadersByNumber.Key()))
+			assert.Nil(t, get(t, txn, header))
+			return nil
+		}))
+		assert.Zero(t, headers.Usage())
+		require.NoError(t, cachedDB.View(func(txn db.Transaction) error {
+			assert.Nil(t, get(t, txn, header))
+			assert.Equal(t, []byte("v3"), get(t, txn, node))
+			return nil
+		}))
+	})
+
+	t.Run("discarded writes", func(t *testing.T) {
+		txn := cachedDB.NewTransaction(true)
+		require.NoError(t, txn.Set(node, []byte("v4")))
+		require.NoError(t, txn.Discard())
+		require.NoError(t, cachedDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, []byte("v3"), get(t, txn, node))
+			return nil
+		}))
+	})
+}
@@ -0,0 +1,164 @@
+package cached
+
+import (
+	"bytes"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/memory"
+)
+
+// maxWrittenKeys bounds the number of the keys a transaction drops from the caches one by one when it commits
+const maxWrittenKeys = 1 << 16
+
+var (
+	_ db.Transaction  = (*transaction)(nil)
+	_ db.PendingSizer = (*transaction)(nil)
+)
+
+// transaction reads the cached buckets through the caches, its iterators read the database
+type transaction struct {
+	db.Transaction
+	db         *DB
+	generation uint64
+
+	// written are the keys of the cached buckets the transaction set or deleted, which it reads from the database
+	// since it holds their new values, and which are dropped from the caches when it commits
+	written map[string]struct{}
+	// cleared are the cached buckets the transaction deleted keys of by prefix, or wrote too many keys of to track
+	// them, which it reads from the database and whose caches are cleared when it commits
+	cleared [256]bool
+}
+
+// cacheOf returns the cache key is read through, nil if it is read from the database
+func (t *transaction) cacheOf(key []byte) *memory.Cache[string, entry] {
+	cache := t.db.cacheOf(key)
+	if cache == nil || t.cleared[key[0]] {
+		return nil
+	}
+	if _, ok := t.written[string(key)]; ok {
+		return nil
+	}
+	return cache
+}
+
+// Get : see db.Transaction.Get. The values of the cached buckets are passed to cb from the caches, which cb must not
+// modify, like the values of the database.
+func (t *transaction) Get(key []byte, cb func([]byte) error) error {
+	cache := t.cacheOf(key)
+	if cache == nil {
+		return t.Transaction.Get(key, cb)
+	}
+	if value, ok := t.db.get(cache, t.generation, key); ok {
+		return cb(value)
+	}
+
+	var value []byte
+	err := t.Transaction.Get(key, func(val []byte) error {
+		value = bytes.Clone(val)
+		return cb(val)
+	})
+	if err == nil {
+		t.db.add(cache, t.generation, key, value)
+	}
+	return err
+}
+
+// MultiGet : see db.Transaction.MultiGet. The keys that are cached are passed to cb first, the others are read at
+// once.
+func (t *transaction) MultiGet(keys [][]byte, cb func(i int, val []byte) error) error {
+	var missing [][]byte
+	var indexes []int
+	for i, key := range keys {
+		if cache := t.cacheOf(key); cache != nil {
+			if value, ok := t.db.get(cache, t.generation, key); ok {
+				if err := cb(i, value); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		missing = append(missing, key)
+		indexes = append(indexes, i)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return t.Transaction.MultiGet(missing, func(i int, val []byte) error {
+		if cache := t.cacheOf(missing[i]); cache != nil {
+			t.db.add(cache, t.generation, missing[i], bytes.Clone(val))
+		}
+		return cb(indexes[i], val)
+	})
+}
+
+// Set : see db.Transaction.Set
+func (t *transaction) Set(key, val []byte) error {
+	t.write(key)
+	return t.Transaction.Set(key, val)
+}
+
+// Delete : see db.Transaction.Delete
+func (t *transaction) Delete(key []byte) error {
+	t.write(key)
+	return t.Transaction.Delete(key)
+}
+
+// DeletePrefix : see db.Transaction.DeletePrefix
+func (t *transaction) DeletePrefix(prefix []byte) error {
+	if len(prefix) == 0 {
+		for bucket := range t.cleared {
+			t.cleared[bucket] = t.db.caches[bucket] != nil
+		}
+	} else if t.db.cacheOf(prefix) != nil {
+		t.cleared[prefix[0]] = true
+	}
+	return t.Transaction.DeletePrefix(prefix)
+}
+
+// write tracks the write of key, the bucket of key is cleared instead once the transaction wrote maxWrittenKeys, so
+// that the large writes of the migrations do not keep all their keys around
+func (t *transaction) write(key []byte) {
+	if t.db.cacheOf(key) == nil {
+		return
+	}
+	if len(t.written) >= maxWrittenKeys {
+		t.cleared[key[0]] = true
+		return
+	}
+	t.written[string(key)] = struct{}{}
+}
+
+// Commit : see db.Transaction.Commit. A transaction that wrote to the cached buckets holds off the reads of the
+// caches while it commits, and drops its keys from the caches even if the commit fails.
+func (t *transaction) Commit() error {
+	clears := false
+	for _, cleared := range t.cleared {
+		clears = clears || cleared
+	}
+	if len(t.written) == 0 && !clears {
+		return t.Transaction.Commit()
+	}
+
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	err := t.Transaction.Commit()
+	for key := range t.written {
+		t.db.cacheOf([]byte(key)).Remove(key)
+	}
+	for bucket, cleared := range t.cleared {
+		if cleared {
+			t.db.caches[bucket].Clear()
+		}
+	}
+	t.db.generation++
+	return err
+}
+
+// PendingSize : see db.PendingSizer.PendingSize
+func (t *transaction) PendingSize() uint64 {
+	if sizer, ok := t.Transaction.(db.PendingSizer); ok {
+		return sizer.PendingSize()
+	}
+	return 0
+}
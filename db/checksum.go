@@ -0,0 +1,177 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned for the values whose checksum does not match, see Checksums
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksummableBuckets are the buckets whose values may be checksummed, by the name they are configured with
+var ChecksummableBuckets = map[string][]Bucket{
+	"trie":          {StateTrie, ClassesTrie, ContractStorage},
+	"contracts":     {ContractClassHash, ContractNonce, ContractDeploymentHeight},
+	"headers":       {BlockHeadersByNumber},
+	"transactions":  {TransactionsByBlockNumberAndIndex},
+	"receipts":      {ReceiptsByBlockNumberAndIndex},
+	"state-updates": {StateUpdatesByBlockNumber},
+	"classes":       {Class},
+}
+
+// checksumMagic ends every checksummed value, after its checksum, so that a trailer that is truncated or overwritten
+// is detected as well as a value that is corrupted. Whether a value is checksummed is never guessed from it: the
+// values of a bucket are checksummed if and only if the database records that the bucket is.
+var checksumMagic = []byte{0x9e, 0x3c, 0x5a, 0x1f, 0xc4, 0x7d}
+
+// checksumSize is the size of the trailer a value is checksummed with, its CRC-32C followed by checksumMagic
+var checksumSize = crc32.Size + len(checksumMagic)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumMode is what is done with the checksummed values whose checksum does not match when they are read
+type ChecksumMode int
+
+const (
+	// ChecksumFail fails the read of the value with ErrChecksumMismatch
+	ChecksumFail ChecksumMode = iota
+	// ChecksumLog reports the mismatch and reads the value
+	ChecksumLog
+	// ChecksumOff does not verify the checksums
+	ChecksumOff
+)
+
+var checksumModes = map[string]ChecksumMode{
+	"fail": ChecksumFail,
+	"log":  ChecksumLog,
+	"off":  ChecksumOff,
+}
+
+// ParseChecksumMode parses a ChecksumMode from its name, fail, log or off, the empty name is ChecksumFail
+func ParseChecksumMode(name string) (ChecksumMode, error) {
+	if name == "" {
+		return ChecksumFail, nil
+	}
+	mode, ok := checksumModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown checksum mode %q, the modes are fail, log and off", name)
+	}
+	return mode, nil
+}
+
+// Checksums are the buckets whose values a database checksums when they are written, and how the checksums are
+// verified when they are read. The checksums detect the values that were corrupted on disk when they are read,
+// rather than when the state they are part of does not match the network. The zero value checksums none of the
+// buckets and fails the reads of the values whose checksum does not match.
+//
+// The values of a bucket are either all checksummed or none of them are, so a database records the buckets it
+// checksums and rewrites the values of the buckets that are added to them or removed from them, see
+// Options.Reencode.
+type Checksums struct {
+	buckets [256]bool
+	mode    ChecksumMode
+	report  func(key []byte, err error)
+}
+
+// NewChecksums returns the Checksums of the ChecksummableBuckets named names, verified according to mode
+func NewChecksums(names []string, mode ChecksumMode) (Checksums, error) {
+	c := Checksums{mode: mode}
+	for _, name := range names {
+		buckets, ok := ChecksummableBuckets[name]
+		if !ok {
+			return Checksums{}, fmt.Errorf("unknown checksummable bucket %q, the buckets are %s", name,
+				strings.Join(ChecksummableBucketNames(), ", "))
+		}
+		for _, bucket := range buckets {
+			c.buckets[bucket] = true
+		}
+	}
+	return c, nil
+}
+
+// ChecksummableBucketNames returns the names of the ChecksummableBuckets, sorted
+func ChecksummableBucketNames() []string {
+	names := make([]string, 0, len(ChecksummableBuckets))
+	for name := range ChecksummableBuckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Buckets returns the buckets c checksums, in the order of the buckets
+func (c Checksums) Buckets() []Bucket {
+	var buckets []Bucket
+	for bucket, checksummed := range c.buckets {
+		if checksummed {
+			buckets = append(buckets, Bucket(bucket))
+		}
+	}
+	return buckets
+}
+
+// WithBuckets returns c checksumming buckets instead of its own buckets, such as those a database records that its
+// values are checksummed in
+func (c Checksums) WithBuckets(buckets []Bucket) Checksums {
+	c.buckets = [256]bool{}
+	for _, bucket := range buckets {
+		c.buckets[bucket] = true
+	}
+	return c
+}
+
+// WithReport returns c reporting the mismatches to report when its mode is ChecksumLog
+func (c Checksums) WithReport(report func(key []byte, err error)) Checksums {
+	c.report = report
+	return c
+}
+
+// Seal returns the value of key as it is stored: followed by its checksum if c checksums the bucket of key, as it is
+// otherwise
+func (c Checksums) Seal(key, value []byte) []byte {
+	if len(key) == 0 || !c.buckets[key[0]] {
+		return value
+	}
+	sealed := make([]byte, len(value), len(value)+checksumSize)
+	copy(sealed, value)
+	sealed = binary.LittleEndian.AppendUint32(sealed, crc32.Checksum(value, castagnoli))
+	return append(sealed, checksumMagic...)
+}
+
+// Open returns the value of key as it was sealed from the value that is stored, which ends with its checksum and
+// checksumMagic if c checksums the bucket of key. The checksum is verified according to the mode of c, and so is the
+// presence of the trailer.
+func (c Checksums) Open(key, stored []byte) ([]byte, error) {
+	if len(key) == 0 || !c.buckets[key[0]] {
+		return stored, nil
+	}
+	if len(stored) < checksumSize || !bytes.HasSuffix(stored, checksumMagic) {
+		// the trailer itself is corrupted
+		return c.mismatch(key, stored)
+	}
+	value := stored[:len(stored)-checksumSize]
+	if c.mode == ChecksumOff || binary.LittleEndian.Uint32(stored[len(value):]) == crc32.Checksum(value, castagnoli) {
+		return value, nil
+	}
+	return c.mismatch(key, value)
+}
+
+// mismatch fails the read of value, the value of key whose checksum does not match, or reports it and returns the
+// value, according to the mode of c
+func (c Checksums) mismatch(key, value []byte) ([]byte, error) {
+	err := fmt.Errorf("the value of key %x: %w", key, ErrChecksumMismatch)
+	switch c.mode {
+	case ChecksumFail:
+		return nil, err
+	case ChecksumLog:
+		if c.report != nil {
+			c.report(key, err)
+		}
+	}
+	return value, nil
+}
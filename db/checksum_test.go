@@ -0,0 +1,164 @@
+package db_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	pebbledb "github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksums(t *testing.T) {
+	checksums, err := db.NewChecksums([]string{"trie", "headers"}, db.ChecksumFail)
+	require.NoError(t, err)
+	assert.Equal(t, []db.Bucket{db.StateTrie, db.ContractStorage, db.BlockHeadersByNumber, db.ClassesTrie},
+		checksums.Buckets())
+
+	_, err = db.NewChecksums([]string{"pending"}, db.ChecksumFail)
+	assert.ErrorContains(t, err, "classes, contracts, headers, receipts, state-updates, transactions, trie")
+	_, err = db.ParseChecksumMode("warn")
+	assert.Error(t, err)
+
+	key := db.StateTrie.Key([]byte{1})
+	node := bytes.Repeat([]byte("node"), 10)
+	sealed := checksums.Seal(key, node)
+	assert.Greater(t, len(sealed), len(node))
+	value, err := checksums.Open(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, node, value)
+
+	corrupted := bytes.Clone(sealed)
+	corrupted[0] ^= 1
+	// a storage value whose last bytes look like a checksum, which anyone can write with a transaction
+	leafKey := db.ContractStorage.Key([]byte{1})
+	leaf := append(bytes.Repeat([]byte{1}, 22), sealed[len(sealed)-10:]...)
+
+	t.Run("values that are not checksummed", func(t *testing.T) {
+		// the bucket is not checksummed
+		assert.Equal(t, node, checksums.Seal(db.Class.Key([]byte{1}), node))
+		value, err := checksums.Open(db.Class.Key([]byte{1}), corrupted)
+		require.NoError(t, err)
+		assert.Equal(t, corrupted, value)
+		// whether a value is checksummed is never guessed from its contents
+		value, err = db.Checksums{}.Open(leafKey, leaf)
+		require.NoError(t, err)
+		assert.Equal(t, leaf, value)
+		value, err = db.Checksums{}.Open(key, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, sealed, value)
+	})
+
+	t.Run("corrupted value", func(t *testing.T) {
+		_, err := checksums.Open(key, corrupted)
+		assert.ErrorIs(t, err, db.ErrChecksumMismatch)
+		// the values of a checksummed bucket all end with their checksum
+		_, err = checksums.Open(key, node)
+		assert.ErrorIs(t, err, db.ErrChecksumMismatch)
+
+		var reported [][]byte
+		logging, err := db.NewChecksums([]string{"trie"}, db.ChecksumLog)
+		require.NoError(t, err)
+		logging = logging.WithReport(func(key []byte, err error) {
+			assert.ErrorIs(t, err, db.ErrChecksumMismatch)
+			reported = append(reported, key)
+		})
+		value, err := logging.Open(key, corrupted)
+		require.NoError(t, err)
+		assert.Equal(t, corrupted[:len(node)], value)
+		value, err = logging.Open(key, node)
+		require.NoError(t, err)
+		assert.Equal(t, node, value)
+		assert.Equal(t, [][]byte{key, key}, reported)
+
+		off, err := db.NewChecksums([]string{"trie"}, db.ChecksumOff)
+		require.NoError(t, err)
+		value, err = off.Open(key, corrupted)
+		require.NoError(t, err)
+		assert.Equal(t, corrupted[:len(node)], value)
+	})
+
+	t.Run("database", func(t *testing.T) {
+		path := t.TempDir()
+		open := func(opts db.Options) db.DB {
+			testDB, err := db.Open(db.DefaultBackend, path, opts)
+			require.NoError(t, err)
+			return testDB
+		}
+		get := func(testDB db.DB, key []byte) ([]byte, error) {
+			var value []byte
+			return value, testDB.View(func(txn db.Transaction) error {
+				return txn.Get(key, func(v []byte) error {
+					value = bytes.Clone(v)
+					return nil
+				})
+			})
+		}
+		stored := func(testDB db.DB, key []byte) []byte {
+			value, closer, err := testDB.Impl().(*pebbledb.DB).Get(key)
+			require.NoError(t, err)
+			defer closer.Close()
+			return bytes.Clone(value)
+		}
+		reencoded := db.Options{Checksums: checksums, Reencode: true}
+
+		// the values are stored by a database that does not checksum them
+		testDB := open(db.Options{})
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			if err := txn.Set(leafKey, leaf); err != nil {
+				return err
+			}
+			return txn.Set(key, node)
+		}))
+		value, err := get(testDB, leafKey)
+		require.NoError(t, err)
+		assert.Equal(t, leaf, value)
+		require.NoError(t, testDB.Close())
+
+		// they are checksummed once the database is reencoded, as are the values written afterwards
+		testDB = open(reencoded)
+		batch := testDB.(db.Batcher).NewBatch()
+		require.NoError(t, batch.Set(db.StateTrie.Key([]byte{2}), node))
+		require.NoError(t, batch.Commit())
+		for _, k := range [][]byte{key, db.StateTrie.Key([]byte{2})} {
+			assert.Equal(t, sealed, stored(testDB, k))
+			value, err = get(testDB, k)
+			require.NoError(t, err)
+			assert.Equal(t, node, value)
+		}
+		value, err = get(testDB, leafKey)
+		require.NoError(t, err)
+		assert.Equal(t, leaf, value)
+		require.NoError(t, testDB.Close())
+
+		// the tools that open the database without its settings read them with the checksums it records
+		testDB = open(db.Options{})
+		value, err = get(testDB, key)
+		require.NoError(t, err)
+		assert.Equal(t, node, value)
+		require.NoError(t, testDB.Close())
+
+		// the checksums are stripped once they are turned off
+		testDB = open(db.Options{Reencode: true})
+		assert.Equal(t, node, stored(testDB, key))
+		assert.Equal(t, leaf, stored(testDB, leafKey))
+		require.NoError(t, testDB.Close())
+
+		testDB = open(reencoded)
+		require.NoError(t, testDB.Impl().(*pebbledb.DB).Set(key, corrupted, pebbledb.Sync))
+		_, err = get(testDB, key)
+		assert.ErrorIs(t, err, db.ErrChecksumMismatch)
+		err = testDB.View(func(txn db.Transaction) error {
+			return db.IterateBucket(txn, db.StateTrie, func(_, _ []byte) error {
+				return nil
+			})
+		})
+		assert.ErrorIs(t, err, db.ErrChecksumMismatch)
+		require.NoError(t, testDB.Close())
+
+		// a corrupted value is not rewritten
+		_, err = db.Open(db.DefaultBackend, path, db.Options{Reencode: true})
+		assert.ErrorIs(t, err, db.ErrChecksumMismatch)
+	})
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressibleBuckets are the buckets whose values may be compressed, by the name they are configured with. Their
+// values are encoded with the encoder package, whose encodings never start with the magic number of a zstd frame,
+// so that the compressed values are told apart from the ones written before their bucket was compressed.
+var CompressibleBuckets = map[string]Bucket{
+	"headers":       BlockHeadersByNumber,
+	"transactions":  TransactionsByBlockNumberAndIndex,
+	"receipts":      ReceiptsByBlockNumberAndIndex,
+	"state-updates": StateUpdatesByBlockNumber,
+	"classes":       Class,
+}
+
+// zstdMagic starts every zstd frame
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// Compression is the set of buckets whose values a database compresses when they are written, the zero value
+// compresses none of them
+type Compression struct {
+	buckets [256]bool
+}
+
+// NewCompression returns the Compression of the CompressibleBuckets named names
+func NewCompression(names []string) (Compression, error) {
+	var c Compression
+	for _, name := range names {
+		bucket, ok := CompressibleBuckets[name]
+		if !ok {
+			return Compression{}, fmt.Errorf("unknown compressible bucket %q, the buckets are %s", name,
+				strings.Join(CompressibleBucketNames(), ", "))
+		}
+		c.buckets[bucket] = true
+	}
+	return c, nil
+}
+
+// CompressibleBucketNames returns the names of the CompressibleBuckets, sorted
+func CompressibleBucketNames() []string {
+	names := make([]string, 0, len(CompressibleBuckets))
+	for name := range CompressibleBuckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Buckets returns the buckets c compresses, in the order of the buckets
+func (c Compression) Buckets() []Bucket {
+	var buckets []Bucket
+	for bucket, compressed := range c.buckets {
+		if compressed {
+			buckets = append(buckets, Bucket(bucket))
+		}
+	}
+	return buckets
+}
+
+// WithBuckets returns the Compression of buckets, such as those a database records that its values are compressed
+// in
+func (Compression) WithBuckets(buckets []Bucket) Compression {
+	var c Compression
+	for _, bucket := range buckets {
+		c.buckets[bucket] = true
+	}
+	return c
+}
+
+// Compress returns the value of key as it is stored: compressed if c compresses the bucket of key and the value is
+// smaller compressed, as it is otherwise
+func (c Compression) Compress(key, value []byte) []byte {
+	if len(key) == 0 || !c.buckets[key[0]] {
+		return value
+	}
+	if compressed := zstdEncoder.EncodeAll(value, nil); len(compressed) < len(value) {
+		return compressed
+	}
+	return value
+}
+
+// Decompress returns the value of key as it was written from the value that is stored, which is compressed if it
+// is in one of the CompressibleBuckets and starts with the magic number of a zstd frame. The buckets are checked
+// whatever the Compression of the database, so that the values written while a bucket was compressed are read
+// once it is not anymore.
+func Decompress(key, stored []byte) ([]byte, error) {
+	if !compressible(key) || !bytes.HasPrefix(stored, zstdMagic) {
+		return stored, nil
+	}
+	value, err := zstdDecoder.DecodeAll(stored, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompress the value of key %x: %w", key, err)
+	}
+	return value, nil
+}
+
+// allCompressible is the Compression of all the CompressibleBuckets
+var allCompressible = func() Compression {
+	c, err := NewCompression(CompressibleBucketNames())
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+func compressible(key []byte) bool {
+	return len(key) > 0 && allCompressible.buckets[key[0]]
+}
@@ -0,0 +1,109 @@
+package db_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	pebbledb "github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	compression, err := db.NewCompression([]string{"classes", "receipts"})
+	require.NoError(t, err)
+	assert.Equal(t, []db.Bucket{db.Class, db.ReceiptsByBlockNumberAndIndex}, compression.Buckets())
+
+	_, err = db.NewCompression([]string{"trie"})
+	assert.ErrorContains(t, err, "classes, headers, receipts, state-updates, transactions")
+
+	class := bytes.Repeat([]byte("class"), 100)
+	compressed := compression.Compress(db.Class.Key([]byte{1}), class)
+	assert.Less(t, len(compressed), len(class))
+	decompressed, err := db.Decompress(db.Class.Key([]byte{1}), compressed)
+	require.NoError(t, err)
+	assert.Equal(t, class, decompressed)
+
+	t.Run("values that are not compressed", func(t *testing.T) {
+		// the bucket is not compressed
+		assert.Equal(t, class, compression.Compress(db.BlockHeadersByNumber.Key([]byte{1}), class))
+		// the value is larger compressed
+		assert.Equal(t, []byte{1}, compression.Compress(db.Class.Key([]byte{1}), []byte{1}))
+
+		// only the values of the compressible buckets are decompressed
+		value, err := db.Decompress(db.ContractNonce.Key([]byte{1}), compressed)
+		require.NoError(t, err)
+		assert.Equal(t, compressed, value)
+		value, err = db.Decompress(db.BlockHeadersByNumber.Key([]byte{1}), class)
+		require.NoError(t, err)
+		assert.Equal(t, class, value)
+	})
+
+	t.Run("corrupted value", func(t *testing.T) {
+		_, err := db.Decompress(db.Class.Key([]byte{1}), compressed[:len(compressed)-4])
+		assert.Error(t, err)
+	})
+
+	t.Run("database", func(t *testing.T) {
+		path := t.TempDir()
+		open := func(opts db.Options) db.DB {
+			testDB, err := db.Open(db.DefaultBackend, path, opts)
+			require.NoError(t, err)
+			return testDB
+		}
+		values := func(testDB db.DB) [][]byte {
+			var values [][]byte
+			require.NoError(t, testDB.View(func(txn db.Transaction) error {
+				return db.IterateBucket(txn, db.Class, func(_, value []byte) error {
+					values = append(values, value)
+					return nil
+				})
+			}))
+			return values
+		}
+		stored := func(testDB db.DB, key []byte) []byte {
+			value, closer, err := testDB.Impl().(*pebbledb.DB).Get(key)
+			require.NoError(t, err)
+			defer closer.Close()
+			return bytes.Clone(value)
+		}
+
+		// the values are stored by a database that does not compress them
+		testDB := open(db.Options{})
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.Class.Key([]byte{1}), class)
+		}))
+		require.NoError(t, testDB.Close())
+
+		// they are compressed once the database is reencoded, as are the values written afterwards
+		testDB = open(db.Options{Compression: compression, Reencode: true})
+		batch := testDB.(db.Batcher).NewBatch()
+		require.NoError(t, batch.Set(db.Class.Key([]byte{2}), class))
+		require.NoError(t, batch.Commit())
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.Class.Key([]byte{3}), class)
+		}))
+		for _, key := range []byte{1, 2, 3} {
+			assert.Equal(t, compressed, stored(testDB, db.Class.Key([]byte{key})))
+		}
+		assert.Equal(t, [][]byte{class, class, class}, values(testDB))
+		require.NoError(t, testDB.Close())
+
+		// the tools that open the database without its settings write with the compression it records
+		testDB = open(db.Options{})
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.Class.Key([]byte{4}), class)
+		}))
+		assert.Equal(t, compressed, stored(testDB, db.Class.Key([]byte{4})))
+		require.NoError(t, testDB.Close())
+
+		// the values are decompressed once the compression is turned off
+		testDB = open(db.Options{Reencode: true})
+		for _, key := range []byte{1, 2, 3, 4} {
+			assert.Equal(t, class, stored(testDB, db.Class.Key([]byte{key})))
+		}
+		assert.Equal(t, [][]byte{class, class, class, class}, values(testDB))
+		require.NoError(t, testDB.Close())
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // ErrKeyNotFound is returned when key isn't found on a txn.Get.
@@ -24,11 +26,62 @@ type DB interface {
 	// Update should handle committing or discarding the transaction. Transaction should be discarded when fn
 	// returns an error
 	Update(fn func(txn Transaction) error) error
+	// Backup writes a consistent copy of the database to dir, which must not exist, while it is in use
+	Backup(dir string) error
+	// CompactRange compacts the keys from start, inclusive, to end, exclusive, or to the last key if end is nil, which
+	// drops the overwritten and the deleted keys the database still holds in the range
+	CompactRange(start, end []byte) error
 
 	// Impl returns the underlying database object
 	Impl() any
 }
 
+// Estimator is implemented by the databases that estimate the number of keys and the disk space of a range of keys
+// without reading it. The estimates are of the keys flushed to disk, and count the keys deleted since along with
+// the ones they replace until they are compacted away.
+type Estimator interface {
+	// RangeKeyCount returns an estimate of the number of keys from start, inclusive, to end, exclusive
+	RangeKeyCount(start, end []byte) (uint64, error)
+	// RangeSize returns an estimate of the disk space used by the keys from start, inclusive, to end, exclusive
+	RangeSize(start, end []byte) (uint64, error)
+}
+
+// Meterer is implemented by the databases that report the metrics of their engine, such as the compaction backlog,
+// the hit ratio of the block cache and the latency of reads and commits, for the node to serve them with its own
+type Meterer interface {
+	// Meter returns the collector of the metrics of the database
+	Meter() prometheus.Collector
+}
+
+// Meters collects the metrics of several collectors together, such as those of the databases that a database is
+// built from
+type Meters []prometheus.Collector
+
+// MetersOf returns the Meters of the databases that are Meterers, the others do not report metrics
+func MetersOf(databases ...DB) Meters {
+	var m Meters
+	for _, database := range databases {
+		if meterer, ok := database.(Meterer); ok {
+			m = append(m, meterer.Meter())
+		}
+	}
+	return m
+}
+
+// Describe : see prometheus.Collector.Describe
+func (m Meters) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range m {
+		collector.Describe(ch)
+	}
+}
+
+// Collect : see prometheus.Collector.Collect
+func (m Meters) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range m {
+		collector.Collect(ch)
+	}
+}
+
 // Iterator is an iterator over a DB's key/value pairs.
 type Iterator interface {
 	io.Closer
@@ -50,6 +103,22 @@ type Iterator interface {
 	// Seek would seek to the provided key if present. If absent, it would seek to the next
 	// key in lexicographical order
 	Seek(key []byte) bool
+
+	// Prev moves the iterator to the previous key/value pair, or to the last one if the iterator
+	// is not positioned yet. It returns whether the iterator is valid after the call. Once invalid,
+	// the iterator remains invalid.
+	Prev() bool
+
+	// SeekLast moves the iterator to the last key that starts with prefix, or to the last key if
+	// prefix is empty. It returns whether there is such a key, the iterator is invalid otherwise.
+	SeekLast(prefix []byte) bool
+}
+
+// PendingSizer is implemented by the transactions that report the size of the writes they hold until they are
+// committed
+type PendingSizer interface {
+	// PendingSize returns the size in bytes of the writes of the transaction
+	PendingSize() uint64
 }
 
 // Transaction provides an interface to access the database's state at the point the transaction was created
@@ -58,6 +127,9 @@ type Iterator interface {
 type Transaction interface {
 	// NewIterator returns an iterator over the database's key/value pairs.
 	NewIterator() (Iterator, error)
+	// NewIteratorWithBounds returns an iterator over the keys from lower, inclusive, to upper, exclusive. A nil bound
+	// leaves its side of the range open. The database skips the keys out of the bounds instead of reading them.
+	NewIteratorWithBounds(lower, upper []byte) (Iterator, error)
 	// Discard discards all the changes done to the database with this transaction
 	Discard() error
 	// Commit flushes all the changes pending on this transaction to the database, making the changes visible to other
@@ -68,14 +140,35 @@ type Transaction interface {
 	Set(key, val []byte) error
 	// Delete removes the key from the database
 	Delete(key []byte) error
+	// DeletePrefix removes the keys that start with prefix from the database, with a range deletion if the database
+	// supports them and key by key otherwise, see DeletePrefixByKey
+	DeletePrefix(prefix []byte) error
 	// Get fetches the value for the given key, should return ErrKeyNotFound if key is not present
 	// Caller should not assume that the slice would stay valid after the call to cb
 	Get(key []byte, cb func([]byte) error) error
+	// MultiGet fetches the values of keys at once, with the batched reads of the database if it supports them and
+	// key by key otherwise, see MultiGetByKey. cb is called with the index in keys and the value of each key that is
+	// present, in no particular order, and like with Get the value is only valid until cb returns.
+	MultiGet(keys [][]byte, cb func(i int, val []byte) error) error
 
 	// Impl returns the underlying transaction object
 	Impl() any
 }
 
+// MultiGetByKey calls txn.Get for each of keys, for the transactions that cannot read several keys at once. The
+// keys that are not present are skipped.
+func MultiGetByKey(txn Transaction, keys [][]byte, cb func(i int, val []byte) error) error {
+	for i, key := range keys {
+		err := txn.Get(key, func(val []byte) error {
+			return cb(i, val)
+		})
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
 func CloseAndWrapOnError(closeFn func() error, existingErr error) error {
 	if closeErr := closeFn(); closeErr != nil {
 		if existingErr == nil {
@@ -85,3 +178,8 @@ func CloseAndWrapOnError(closeFn func() error, existingErr error) error {
 	}
 	return existingErr
 }
+
+// CompactPrefix compacts the keys that start with prefix, see DB.CompactRange
+func CompactPrefix(database DB, prefix []byte) error {
+	return database.CompactRange(prefix, PrefixEnd(prefix))
+}
@@ -6,7 +6,12 @@ import (
 	"testing"
 
 	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/tiered"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var errClose = errors.New("close error")
@@ -45,3 +50,100 @@ func TestCloseAndJoinOnError(t *testing.T) {
 		})
 	})
 }
+
+func TestMultiGet(t *testing.T) {
+	databases := map[string]db.DB{
+		// pebble reads the keys with a single iterator and the in-memory database key by key
+		"pebble": pebble.NewMemTest(),
+		"memory": memory.New(),
+		"tiered": tiered.New(pebble.NewMemTest(), pebble.NewMemTest(), [][]byte{{2}}),
+	}
+	for name, database := range databases {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() {
+				require.NoError(t, database.Close())
+			})
+			require.NoError(t, database.Update(func(txn db.Transaction) error {
+				for _, key := range []string{"\x01a", "\x01c", "\x02a", "\x02b"} {
+					if err := txn.Set([]byte(key), []byte("value "+key)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}))
+			keys := [][]byte{[]byte("\x02b"), []byte("\x01b"), []byte("\x01a"), []byte("\x02a"), []byte("\x01a")}
+			multiGet := func(txn db.Transaction) map[int]string {
+				values := make(map[int]string)
+				require.NoError(t, txn.MultiGet(keys, func(i int, val []byte) error {
+					values[i] = string(val)
+					return nil
+				}))
+				return values
+			}
+
+			require.NoError(t, database.View(func(txn db.Transaction) error {
+				assert.Equal(t, map[int]string{
+					0: "value \x02b",
+					2: "value \x01a",
+					3: "value \x02a",
+					4: "value \x01a",
+				}, multiGet(txn))
+				return nil
+			}))
+
+			t.Run("writes of the transaction", func(t *testing.T) {
+				require.NoError(t, database.Update(func(txn db.Transaction) error {
+					require.NoError(t, txn.Set([]byte("\x01b"), []byte("new")))
+					require.NoError(t, txn.Delete([]byte("\x02b")))
+					buffered := db.NewBufferedTransaction(txn)
+					require.NoError(t, buffered.Delete([]byte("\x01a")))
+					require.NoError(t, buffered.Set([]byte("\x02a"), []byte("buffered")))
+
+					assert.Equal(t, map[int]string{1: "new", 2: "value \x01a", 3: "value \x02a", 4: "value \x01a"},
+						multiGet(txn))
+					assert.Equal(t, map[int]string{1: "new", 3: "buffered"}, multiGet(buffered))
+					return nil
+				}))
+			})
+
+			t.Run("callback error", func(t *testing.T) {
+				errCallback := errors.New("callback")
+				require.ErrorIs(t, database.View(func(txn db.Transaction) error {
+					return txn.MultiGet(keys, func(int, []byte) error {
+						return errCallback
+					})
+				}), errCallback)
+			})
+		})
+	}
+
+	t.Run("key by key", func(t *testing.T) {
+		txn := db.NewMemTransaction()
+		require.NoError(t, txn.Set([]byte("a"), []byte("1")))
+		values := make(map[int]string)
+		require.NoError(t, db.MultiGetByKey(txn, [][]byte{[]byte("b"), []byte("a")}, func(i int, val []byte) error {
+			values[i] = string(val)
+			return nil
+		}))
+		assert.Equal(t, map[int]string{1: "1"}, values)
+	})
+}
+
+func TestMetersOf(t *testing.T) {
+	pebbleDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, pebbleDB.Close())
+	})
+	meters := db.MetersOf(memory.New(), pebbleDB)
+	require.Len(t, meters, 1)
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(meters))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	names := make([]string, 0, len(families))
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.Contains(t, names, "db_compaction_debt_bytes")
+}
@@ -0,0 +1,207 @@
+// Package dbtest tests that the backends of the db package behave the same: the tests of a backend run TestBackend
+// on the databases it opens, so that the node can be run on any of them.
+package dbtest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackend tests the transactions and the iterators of the databases returned by open, which must be empty. The
+// databases are closed by TestBackend.
+func TestBackend(t *testing.T, open func(t *testing.T) db.DB) {
+	t.Run("transactions", func(t *testing.T) {
+		testTransactions(t, open(t))
+	})
+	t.Run("iterators", func(t *testing.T) {
+		testIterators(t, open(t))
+	})
+	t.Run("batched reads and deletions", func(t *testing.T) {
+		testMultiGetAndDeletePrefix(t, open(t))
+	})
+}
+
+// get returns the value of key in txn, or nil if it is not found
+func get(t *testing.T, txn db.Transaction, key string) []byte {
+	t.Helper()
+	var value []byte
+	err := txn.Get([]byte(key), func(v []byte) error {
+		value = bytes.Clone(v)
+		return nil
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return nil
+	}
+	require.NoError(t, err)
+	return value
+}
+
+func set(t *testing.T, testDB db.DB, pairs ...string) {
+	t.Helper()
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := 0; i < len(pairs); i += 2 {
+			if err := txn.Set([]byte(pairs[i]), []byte(pairs[i+1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func testTransactions(t *testing.T, testDB db.DB) {
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	set(t, testDB, "a", "1", "b", "2")
+
+	// the read transactions read the database as it was when they started
+	snapshot := testDB.NewTransaction(false)
+	txn := testDB.NewTransaction(true)
+	require.NoError(t, txn.Set([]byte("a"), []byte("3")))
+	require.NoError(t, txn.Delete([]byte("b")))
+	require.NoError(t, txn.Set([]byte("c"), []byte("4")))
+	assert.Equal(t, []byte("3"), get(t, txn, "a"), "a transaction reads its own writes")
+	assert.Nil(t, get(t, txn, "b"))
+	assert.Equal(t, []byte("1"), get(t, snapshot, "a"), "the writes are not visible until they are committed")
+	require.NoError(t, txn.Commit())
+
+	assert.Equal(t, []byte("1"), get(t, snapshot, "a"))
+	assert.Equal(t, []byte("2"), get(t, snapshot, "b"))
+	assert.Nil(t, get(t, snapshot, "c"))
+	require.NoError(t, snapshot.Discard())
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		assert.Equal(t, []byte("3"), get(t, txn, "a"))
+		assert.Nil(t, get(t, txn, "b"))
+		assert.Equal(t, []byte("4"), get(t, txn, "c"))
+		err := txn.Get([]byte("b"), func([]byte) error { return nil })
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+		assert.Error(t, txn.Set([]byte("d"), []byte("5")), "a read transaction does not write")
+		return nil
+	}))
+
+	// the writes of a transaction that fails are discarded
+	errFailed := errors.New("failed")
+	require.ErrorIs(t, testDB.Update(func(txn db.Transaction) error {
+		if err := txn.Set([]byte("a"), []byte("5")); err != nil {
+			return err
+		}
+		return errFailed
+	}), errFailed)
+	txn = testDB.NewTransaction(true)
+	require.NoError(t, txn.Set([]byte("a"), []byte("6")))
+	require.NoError(t, txn.Discard())
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		assert.Equal(t, []byte("3"), get(t, txn, "a"))
+		return nil
+	}))
+}
+
+// keys returns the keys it iterates over by moving it with move until it is invalid, and checks their values
+func keys(t *testing.T, it db.Iterator, first bool, move func() bool) []string {
+	t.Helper()
+	var found []string
+	for valid := first; valid; valid = move() {
+		value, err := it.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "value of "+string(it.Key()), string(value))
+		found = append(found, string(it.Key()))
+	}
+	return found
+}
+
+func testIterators(t *testing.T, testDB db.DB) {
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for _, key := range []string{"a1", "a2", "b1", "b2", "c1"} {
+			if err := txn.Set([]byte(key), []byte("value of "+key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		it, err := txn.NewIterator()
+		require.NoError(t, err)
+		assert.False(t, it.Valid())
+		assert.Equal(t, []string{"a1", "a2", "b1", "b2", "c1"}, keys(t, it, it.Next(), it.Next))
+		assert.False(t, it.Valid())
+		assert.Equal(t, []string{"b1", "a2", "a1"}, keys(t, it, it.Seek([]byte("b")), it.Prev))
+		assert.Equal(t, []string{"b2", "c1"}, keys(t, it, it.SeekLast([]byte("b")), it.Next))
+		assert.False(t, it.Seek([]byte("d")))
+		assert.False(t, it.SeekLast([]byte("d")))
+		assert.Equal(t, []string{"c1"}, keys(t, it, it.SeekLast(nil), it.Next))
+		require.NoError(t, it.Close())
+
+		it, err = txn.NewIterator()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c1", "b2", "b1", "a2", "a1"}, keys(t, it, it.Prev(), it.Prev))
+		require.NoError(t, it.Close())
+
+		it, err = txn.NewIteratorWithBounds([]byte("a2"), []byte("c"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a2", "b1", "b2"}, keys(t, it, it.Next(), it.Next))
+		assert.Equal(t, []string{"b2", "b1", "a2"}, keys(t, it, it.SeekLast([]byte("b")), it.Prev))
+		assert.False(t, it.SeekLast([]byte("c")))
+		return it.Close()
+	}))
+
+	// the iterators of a transaction see its writes
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		require.NoError(t, txn.Delete([]byte("a2")))
+		require.NoError(t, txn.Set([]byte("b0"), []byte("value of b0")))
+		require.NoError(t, txn.Set([]byte("b2"), []byte("value of b2")))
+		it, err := txn.NewIterator()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a1", "b0", "b1", "b2", "c1"}, keys(t, it, it.Next(), it.Next))
+		assert.Equal(t, []string{"b2", "b1", "b0", "a1"}, keys(t, it, it.SeekLast([]byte("b")), it.Prev))
+		require.NoError(t, it.Close())
+
+		it, err = txn.NewIteratorWithBounds([]byte("a2"), []byte("b1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b0"}, keys(t, it, it.Next(), it.Next))
+		return it.Close()
+	}))
+
+	var iterated []string
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		return db.IterateBucket(txn, db.Bucket('b'), func(key, _ []byte) error {
+			iterated = append(iterated, string(key))
+			return nil
+		})
+	}))
+	assert.Equal(t, []string{"b0", "b1", "b2"}, iterated)
+}
+
+func testMultiGetAndDeletePrefix(t *testing.T, testDB db.DB) {
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	set(t, testDB, "a1", "1", "b1", "2", "b2", "3", "c1", "4")
+
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		require.NoError(t, txn.Set([]byte("a2"), []byte("5")))
+		values := make(map[int]string)
+		require.NoError(t, txn.MultiGet([][]byte{[]byte("c1"), []byte("d1"), []byte("a2"), []byte("a1")},
+			func(i int, val []byte) error {
+				values[i] = string(val)
+				return nil
+			}))
+		assert.Equal(t, map[int]string{0: "4", 2: "5", 3: "1"}, values)
+		return txn.DeletePrefix([]byte("b"))
+	}))
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		assert.Nil(t, get(t, txn, "b1"))
+		assert.Nil(t, get(t, txn, "b2"))
+		assert.Equal(t, []byte("1"), get(t, txn, "a1"))
+		assert.Equal(t, []byte("4"), get(t, txn, "c1"))
+		return nil
+	}))
+}
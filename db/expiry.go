@@ -0,0 +1,112 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sweepBatch is the number of entries SweepExpired goes through in a transaction, the writes of the other
+// transactions are held off while it does
+const sweepBatch = 1000
+
+// Expiry returns the time after which the entry of a transient bucket with key and value expired, the zero time if
+// it does not expire
+type Expiry func(key, value []byte) (time.Time, error)
+
+var (
+	expiriesMu sync.RWMutex
+	expiries   = make(map[Bucket]Expiry)
+)
+
+// RegisterExpiry makes bucket a transient bucket, whose entries are deleted by SweepExpired once expiry says they
+// expired, and panics if bucket is already transient. It is called from the init functions of the packages that
+// write the buckets of the data that is not kept forever, such as the pending block and the submitted transactions.
+func RegisterExpiry(bucket Bucket, expiry Expiry) {
+	expiriesMu.Lock()
+	defer expiriesMu.Unlock()
+	if expiry == nil {
+		panic("db: RegisterExpiry expiry is nil")
+	}
+	if _, found := expiries[bucket]; found {
+		panic(fmt.Sprintf("db: RegisterExpiry called twice for bucket %d", bucket))
+	}
+	expiries[bucket] = expiry
+}
+
+// TransientBuckets returns the buckets registered with RegisterExpiry, in order
+func TransientBuckets() []Bucket {
+	expiriesMu.RLock()
+	defer expiriesMu.RUnlock()
+	buckets := make([]Bucket, 0, len(expiries))
+	for bucket := range expiries {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i] < buckets[j]
+	})
+	return buckets
+}
+
+// SweepExpired deletes the entries of the transient buckets that expired by now, see RegisterExpiry, and returns the
+// number of entries deleted. The buckets are gone through sweepBatch entries per transaction.
+func SweepExpired(ctx context.Context, database DB, now time.Time) (uint64, error) {
+	var swept uint64
+	for _, bucket := range TransientBuckets() {
+		expiriesMu.RLock()
+		expiry := expiries[bucket]
+		expiriesMu.RUnlock()
+
+		from := bucket.Key()
+		for from != nil && ctx.Err() == nil {
+			var deleted uint64
+			err := database.Update(func(txn Transaction) error {
+				var err error
+				deleted, from, err = sweepBatchOf(txn, bucket, expiry, from, now)
+				return err
+			})
+			if err != nil {
+				return swept, fmt.Errorf("sweep bucket %d: %w", bucket, err)
+			}
+			swept += deleted
+		}
+	}
+	return swept, ctx.Err()
+}
+
+// sweepBatchOf deletes the entries of bucket from the key from that expired by now, out of the next sweepBatch
+// entries. It returns the number of entries deleted and the key the next batch starts from, nil once the bucket is
+// swept.
+func sweepBatchOf(txn Transaction, bucket Bucket, expiry Expiry, from []byte, now time.Time) (uint64, []byte, error) {
+	var expired [][]byte
+	var scanned int
+	var next []byte
+	err := IterateBucket(txn, bucket, func(key, value []byte) error {
+		if scanned == sweepBatch {
+			next = key
+			return ErrStopIteration
+		}
+		scanned++
+		expiresAt, err := expiry(key, value)
+		if err != nil {
+			return fmt.Errorf("expiry of key %x: %w", key, err)
+		}
+		if !expiresAt.IsZero() && now.After(expiresAt) {
+			expired = append(expired, bytes.Clone(key))
+		}
+		return nil
+	}, From(from))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, key := range expired {
+		if err = txn.Delete(key); err != nil {
+			return 0, nil, err
+		}
+	}
+	return uint64(len(expired)), next, nil
+}
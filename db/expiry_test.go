@@ -0,0 +1,66 @@
+package db_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweepExpired(t *testing.T) {
+	// the values of the test bucket are the unix times their entries expire at, zero for those that do not
+	db.RegisterExpiry(db.Unused, func(_, value []byte) (time.Time, error) {
+		if at := binary.BigEndian.Uint64(value); at > 0 {
+			return time.Unix(int64(at), 0), nil
+		}
+		return time.Time{}, nil
+	})
+	assert.Contains(t, db.TransientBuckets(), db.Unused)
+	assert.Panics(t, func() {
+		db.RegisterExpiry(db.Unused, func(_, _ []byte) (time.Time, error) {
+			return time.Time{}, nil
+		})
+	})
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	// more entries than a transaction of a sweep goes through
+	const entries = 2500
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := uint64(0); i < entries; i++ {
+			if err := txn.Set(db.Unused.Key(binary.BigEndian.AppendUint64(nil, i)),
+				binary.BigEndian.AppendUint64(nil, i)); err != nil {
+				return err
+			}
+		}
+		return txn.Set(db.StateTrie.Key(), binary.BigEndian.AppendUint64(nil, 1))
+	}))
+	count := func(bucket db.Bucket) int {
+		var keys int
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			return db.IterateBucket(txn, bucket, func(_, _ []byte) error {
+				keys++
+				return nil
+			}, db.KeysOnly())
+		}))
+		return keys
+	}
+
+	swept, err := db.SweepExpired(context.Background(), testDB, time.Unix(2000, 0))
+	require.NoError(t, err)
+	// the entries that expire at 2000 or later are kept, and so is the one that does not expire
+	assert.Equal(t, uint64(1999), swept)
+	assert.Equal(t, entries-1999, count(db.Unused))
+	assert.Equal(t, 1, count(db.StateTrie))
+
+	swept, err = db.SweepExpired(context.Background(), testDB, time.Unix(2000, 0))
+	require.NoError(t, err)
+	assert.Zero(t, swept)
+}
@@ -0,0 +1,270 @@
+// Package freezer implements an append-only flat-file db.DB for the data of the old blocks, which does not change
+// once the blocks are final: their transactions, receipts and state updates. It is registered as the "freezer"
+// backend, to be used as the cold database of a tiered.DB, which moves the data of the old blocks to it and reads
+// it from there transparently. Unlike an LSM tree, the freezer never compacts the data it holds.
+//
+// Each kind of data is a table of two files. The data file holds an item per block, the entries of the block one
+// after another, and is only appended to. The index file holds the location of the item of each block by block
+// number. A block that is written to again, because its entries are moved in several batches or one of them is
+// deleted, is appended as a new item that the index points to instead, and its previous item is left unused in the
+// data file. The index is held in memory, 16 bytes per block, and replaced on each commit, so that the read
+// transactions keep the index they started with as their snapshot.
+package freezer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+// Backend is the name the freezer is registered under, see db.Register
+const Backend = "freezer"
+
+var (
+	// ErrClosed is returned by the transactions of a freezer that is closed
+	ErrClosed = errors.New("freezer is closed")
+	// ErrNotStored is returned when a key that the freezer does not store is written to it
+	ErrNotStored = errors.New("the freezer only stores the transactions, receipts and state updates of the blocks")
+)
+
+var _ db.DB = (*DB)(nil)
+
+func init() {
+	db.Register(Backend, func(path string, _ db.Options) (db.DB, error) {
+		return Open(path)
+	})
+}
+
+// blockKeyLen is the length of the bucket and the block number that the keys of the tables start with
+const blockKeyLen = 9
+
+// tables are the buckets the freezer stores, in the order of their keys, and the names of their files
+var tables = []struct {
+	name   string
+	bucket db.Bucket
+}{
+	{name: "transactions", bucket: db.TransactionsByBlockNumberAndIndex},
+	{name: "receipts", bucket: db.ReceiptsByBlockNumberAndIndex},
+	{name: "state-updates", bucket: db.StateUpdatesByBlockNumber},
+}
+
+// Stores returns whether the freezer stores the keys that start with prefix
+func Stores(prefix []byte) bool {
+	if len(prefix) == 0 {
+		return false
+	}
+	for _, t := range tables {
+		if db.Bucket(prefix[0]) == t.bucket {
+			return true
+		}
+	}
+	return false
+}
+
+type DB struct {
+	tables []*table
+	closed atomic.Bool
+	wMutex *sync.Mutex
+}
+
+// Open opens the freezer in dir, which is created if it does not exist
+func Open(dir string) (*DB, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &DB{wMutex: new(sync.Mutex)}
+	for _, t := range tables {
+		opened, err := openTable(dir, t.name, t.bucket)
+		if err != nil {
+			return nil, errors.Join(err, d.Close())
+		}
+		d.tables = append(d.tables, opened)
+	}
+	return d, nil
+}
+
+// NewTransaction : see db.DB.NewTransaction
+func (d *DB) NewTransaction(update bool) db.Transaction {
+	txn := &Transaction{db: d}
+	if update {
+		d.wMutex.Lock()
+		txn.writes = make(map[string][]byte)
+	}
+	// the snapshot is taken once the write lock is held, so that no commit happens between them
+	txn.snapshot = d.snapshot()
+	return txn
+}
+
+// snapshot returns the current index of each table
+func (d *DB) snapshot() []index {
+	snapshot := make([]index, len(d.tables))
+	for i, t := range d.tables {
+		snapshot[i] = *t.items.Load()
+	}
+	return snapshot
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(true)
+	if err := fn(txn); err != nil {
+		return db.CloseAndWrapOnError(txn.Discard, err)
+	}
+	return db.CloseAndWrapOnError(txn.Discard, txn.Commit())
+}
+
+// Close : see io.Closer.Close
+func (d *DB) Close() error {
+	d.closed.Store(true)
+	var err error
+	for _, t := range d.tables {
+		err = errors.Join(err, t.close())
+	}
+	return err
+}
+
+// Backup : see db.DB.Backup. The index files are copied while no transaction commits, the data files are copied
+// up to the data they referenced since they are only appended to.
+func (d *DB) Backup(dir string) error {
+	if d.closed.Load() {
+		return ErrClosed
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return err
+	}
+
+	d.wMutex.Lock()
+	dataSizes := make([]uint64, len(d.tables))
+	var err error
+	for i, t := range d.tables {
+		dataSizes[i] = t.dataSize
+		if err = copyFile(t.index, filepath.Join(dir, tables[i].name+".idx"), -1); err != nil {
+			break
+		}
+	}
+	d.wMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for i, t := range d.tables {
+		if err = copyFile(t.data, filepath.Join(dir, tables[i].name+".dat"), int64(dataSizes[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the first size bytes of src to a new file at path, all of them if size is negative
+func copyFile(src *os.File, path string, size int64) (err error) {
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, dst.Close())
+	}()
+
+	var reader io.Reader = io.NewSectionReader(src, 0, 1<<63-1)
+	if size >= 0 {
+		reader = io.LimitReader(reader, size)
+	}
+	if _, err = io.Copy(dst, reader); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
+
+// CompactRange : see db.DB.CompactRange. The freezer does not reclaim the items that were replaced by newer ones,
+// which only happens to the blocks that are written to again.
+func (d *DB) CompactRange(start, end []byte) error {
+	return nil
+}
+
+// Impl : see db.DB.Impl
+func (d *DB) Impl() any {
+	return d
+}
+
+// tableOf returns the index of the table that stores key, or -1 if none does
+func tableOf(key []byte) int {
+	if len(key) == 0 {
+		return -1
+	}
+	for i, t := range tables {
+		if db.Bucket(key[0]) == t.bucket {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitKey returns the table, the block number and the suffix of a key the freezer stores
+func splitKey(key []byte) (int, uint64, []byte, error) {
+	i := tableOf(key)
+	if i < 0 || len(key) < blockKeyLen {
+		return 0, 0, nil, fmt.Errorf("%w: key %x", ErrNotStored, key)
+	}
+	return i, binary.BigEndian.Uint64(key[1:blockKeyLen]), key[blockKeyLen:], nil
+}
+
+// commit appends the items of the blocks that writes change to the data files and points the indexes at them. The
+// data files are synced before the indexes are written, so that the indexes only point at data that is on disk.
+func (d *DB) commit(writes map[string][]byte) error {
+	if d.closed.Load() {
+		return ErrClosed
+	}
+
+	// the writes by table, block and suffix
+	grouped := make([]map[uint64]map[string][]byte, len(d.tables))
+	for key, value := range writes {
+		i, block, suffix, err := splitKey([]byte(key))
+		if err != nil {
+			return err
+		}
+		if grouped[i] == nil {
+			grouped[i] = make(map[uint64]map[string][]byte)
+		}
+		if grouped[i][block] == nil {
+			grouped[i][block] = make(map[string][]byte)
+		}
+		grouped[i][block][string(suffix)] = value
+	}
+
+	// the indexes of the tables that are written are stored even if a later table fails, they point at their data
+	updated := make([]*index, len(d.tables))
+	defer func() {
+		for i, t := range d.tables {
+			if updated[i] != nil {
+				t.items.Store(updated[i])
+			}
+		}
+	}()
+	for i, t := range d.tables {
+		if grouped[i] == nil {
+			continue
+		}
+		ix, err := t.commit(grouped[i])
+		if err != nil {
+			return fmt.Errorf("commit to table %s: %w", tables[i].name, err)
+		}
+		updated[i] = &ix
+	}
+	return nil
+}
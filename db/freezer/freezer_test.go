@@ -0,0 +1,263 @@
+package freezer_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/freezer"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/tiered"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// key returns the key of bucket for block and index
+func key(bucket db.Bucket, block uint64, index ...uint64) []byte {
+	suffix := binary.BigEndian.AppendUint64(nil, block)
+	for _, i := range index {
+		suffix = binary.BigEndian.AppendUint64(suffix, i)
+	}
+	return bucket.Key(suffix)
+}
+
+func get(t *testing.T, database db.DB, key []byte) string {
+	t.Helper()
+	var value string
+	err := database.View(func(txn db.Transaction) error {
+		return txn.Get(key, func(v []byte) error {
+			value = string(v)
+			return nil
+		})
+	})
+	if err == db.ErrKeyNotFound {
+		return "<not found>"
+	}
+	require.NoError(t, err)
+	return value
+}
+
+func open(t *testing.T, dir string) *freezer.DB {
+	t.Helper()
+	database, err := freezer.Open(dir)
+	require.NoError(t, err)
+	return database
+}
+
+func TestDB(t *testing.T) {
+	dir := t.TempDir()
+	database := open(t, dir)
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		for block := uint64(0); block < 10; block++ {
+			for i := uint64(0); i < 3; i++ {
+				require.NoError(t, txn.Set(key(db.TransactionsByBlockNumberAndIndex, block, i), []byte("txn")))
+				require.NoError(t, txn.Set(key(db.ReceiptsByBlockNumberAndIndex, block, i), []byte("receipt")))
+			}
+			require.NoError(t, txn.Set(key(db.StateUpdatesByBlockNumber, block), []byte("update")))
+		}
+		return nil
+	}))
+
+	t.Run("keys it does not store", func(t *testing.T) {
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			assert.ErrorIs(t, txn.Set(db.Class.Key([]byte{1}), []byte("class")), freezer.ErrNotStored)
+			assert.ErrorIs(t, txn.Set(db.StateUpdatesByBlockNumber.Key([]byte{1}), nil), freezer.ErrNotStored)
+			return txn.Delete(db.Class.Key([]byte{1}))
+		}))
+		assert.Equal(t, "<not found>", get(t, database, db.Class.Key([]byte{1})))
+	})
+
+	t.Run("block written in several commits", func(t *testing.T) {
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			require.NoError(t, txn.Set(key(db.TransactionsByBlockNumberAndIndex, 3, 1), []byte("new txn")))
+			require.NoError(t, txn.Set(key(db.TransactionsByBlockNumberAndIndex, 3, 3), []byte("txn")))
+			return txn.Delete(key(db.TransactionsByBlockNumberAndIndex, 3, 0))
+		}))
+		assert.Equal(t, "<not found>", get(t, database, key(db.TransactionsByBlockNumberAndIndex, 3, 0)))
+		assert.Equal(t, "new txn", get(t, database, key(db.TransactionsByBlockNumberAndIndex, 3, 1)))
+		assert.Equal(t, "txn", get(t, database, key(db.TransactionsByBlockNumberAndIndex, 3, 3)))
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		snapshot := database.NewTransaction(false)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.DeletePrefix(key(db.ReceiptsByBlockNumberAndIndex, 5))
+		}))
+		require.NoError(t, snapshot.Get(key(db.ReceiptsByBlockNumberAndIndex, 5, 2), func(v []byte) error {
+			assert.Equal(t, "receipt", string(v))
+			return nil
+		}))
+		require.NoError(t, snapshot.Discard())
+		assert.Equal(t, "<not found>", get(t, database, key(db.ReceiptsByBlockNumberAndIndex, 5, 2)))
+		assert.Equal(t, "receipt", get(t, database, key(db.ReceiptsByBlockNumberAndIndex, 6, 0)))
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		require.NoError(t, database.Close())
+		database = open(t, dir)
+		assert.Equal(t, "new txn", get(t, database, key(db.TransactionsByBlockNumberAndIndex, 3, 1)))
+		assert.Equal(t, "<not found>", get(t, database, key(db.ReceiptsByBlockNumberAndIndex, 5, 0)))
+		assert.Equal(t, "update", get(t, database, key(db.StateUpdatesByBlockNumber, 9)))
+		assert.Equal(t, "<not found>", get(t, database, key(db.StateUpdatesByBlockNumber, 10)))
+	})
+
+	t.Run("backup", func(t *testing.T) {
+		backupDir := filepath.Join(t.TempDir(), "backup")
+		require.NoError(t, database.Backup(backupDir))
+		backup := open(t, backupDir)
+		assert.Equal(t, "new txn", get(t, backup, key(db.TransactionsByBlockNumberAndIndex, 3, 1)))
+		assert.Equal(t, "update", get(t, backup, key(db.StateUpdatesByBlockNumber, 9)))
+		require.NoError(t, backup.Close())
+	})
+
+	require.NoError(t, database.Close())
+
+	t.Run("torn index", func(t *testing.T) {
+		index, err := os.OpenFile(filepath.Join(dir, "state-updates.idx"), os.O_WRONLY|os.O_APPEND, 0o644)
+		require.NoError(t, err)
+		_, err = index.Write([]byte{1, 2, 3})
+		require.NoError(t, err)
+		require.NoError(t, index.Close())
+
+		database = open(t, dir)
+		assert.Equal(t, "update", get(t, database, key(db.StateUpdatesByBlockNumber, 9)))
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.Set(key(db.StateUpdatesByBlockNumber, 10), []byte("update"))
+		}))
+		assert.Equal(t, "update", get(t, database, key(db.StateUpdatesByBlockNumber, 10)))
+		require.NoError(t, database.Close())
+	})
+}
+
+func TestIterator(t *testing.T) {
+	freezerDB, memDB := open(t, t.TempDir()), memory.New()
+	t.Cleanup(func() {
+		require.NoError(t, freezerDB.Close())
+		require.NoError(t, memDB.Close())
+	})
+	keys := [][]byte{
+		key(db.TransactionsByBlockNumberAndIndex, 0, 0),
+		key(db.TransactionsByBlockNumberAndIndex, 0, 1),
+		key(db.TransactionsByBlockNumberAndIndex, 2, 0),
+		key(db.TransactionsByBlockNumberAndIndex, 5000, 0),
+		key(db.StateUpdatesByBlockNumber, 1),
+		key(db.StateUpdatesByBlockNumber, 4),
+	}
+	for _, database := range []db.DB{freezerDB, memDB} {
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			for _, k := range keys {
+				if err := txn.Set(k, k); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+
+	type move func(it db.Iterator) bool
+	next := func(it db.Iterator) bool { return it.Next() }
+	prev := func(it db.Iterator) bool { return it.Prev() }
+	seek := func(key []byte) move {
+		return func(it db.Iterator) bool { return it.Seek(key) }
+	}
+	seekLast := func(prefix []byte) move {
+		return func(it db.Iterator) bool { return it.SeekLast(prefix) }
+	}
+	tests := map[string][]move{
+		"forward":  {next, next, next, next, next, next, next},
+		"backward": {prev, prev, prev, prev, prev, prev, prev},
+		"seek": {
+			seek(key(db.TransactionsByBlockNumberAndIndex, 0, 1)), next, seek(key(db.TransactionsByBlockNumberAndIndex, 1)),
+			seek(db.ReceiptsByBlockNumberAndIndex.Key()), prev, seek([]byte{0xff}), seek(nil), prev,
+		},
+		"seek within the block number": {
+			seek(db.TransactionsByBlockNumberAndIndex.Key([]byte{0})), seek(db.StateUpdatesByBlockNumber.Key([]byte{0, 0})),
+			seek(key(db.TransactionsByBlockNumberAndIndex, 0, 2)),
+		},
+		"seek last": {
+			seekLast(db.TransactionsByBlockNumberAndIndex.Key()), prev, seekLast(key(db.TransactionsByBlockNumberAndIndex, 0)),
+			seekLast(db.ReceiptsByBlockNumberAndIndex.Key()), seekLast(nil), next, seekLast(key(db.StateUpdatesByBlockNumber, 3)),
+		},
+		"back and forth": {
+			seek(key(db.StateUpdatesByBlockNumber, 2)), prev, prev, next, next, next, prev, prev, prev, prev, prev, prev,
+		},
+	}
+
+	for name, moves := range tests {
+		var positions [2][]string
+		for d, database := range []db.DB{freezerDB, memDB} {
+			txn := database.NewTransaction(false)
+			it, err := txn.NewIterator()
+			require.NoError(t, err)
+			for _, m := range moves {
+				valid := m(it)
+				require.Equal(t, valid, it.Valid())
+				position := "<invalid>"
+				if valid {
+					value, err := it.Value()
+					require.NoError(t, err)
+					assert.Equal(t, it.Key(), value)
+					position = string(it.Key())
+				}
+				positions[d] = append(positions[d], position)
+			}
+			require.NoError(t, it.Close())
+			require.NoError(t, txn.Discard())
+		}
+		assert.Equal(t, positions[1], positions[0], name)
+	}
+
+	t.Run("pending writes", func(t *testing.T) {
+		txn := freezerDB.NewTransaction(true)
+		require.NoError(t, txn.Set(key(db.StateUpdatesByBlockNumber, 7), nil))
+		_, err := txn.NewIterator()
+		assert.Error(t, err)
+		require.NoError(t, txn.Discard())
+	})
+}
+
+func TestTiered(t *testing.T) {
+	cold := open(t, t.TempDir())
+	prefixes := [][]byte{db.TransactionsByBlockNumberAndIndex.Key(), db.StateUpdatesByBlockNumber.Key()}
+	tieredDB := tiered.New(pebble.NewMemTest(), cold, prefixes)
+	t.Cleanup(func() {
+		require.NoError(t, tieredDB.Close())
+	})
+
+	var keys [][]byte
+	for block := uint64(0); block < 4; block++ {
+		keys = append(keys, key(db.TransactionsByBlockNumberAndIndex, block, 0), key(db.StateUpdatesByBlockNumber, block))
+	}
+	require.NoError(t, tieredDB.Update(func(txn db.Transaction) error {
+		for _, k := range keys {
+			if err := txn.Set(k, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, tieredDB.Demote(keys[:4]))
+
+	for _, k := range keys[:4] {
+		assert.Equal(t, string(k), get(t, cold, k))
+		assert.Equal(t, string(k), get(t, tieredDB, k))
+	}
+	var iterated [][]byte
+	require.NoError(t, tieredDB.View(func(txn db.Transaction) error {
+		return db.IteratePrefix(txn, db.StateUpdatesByBlockNumber.Key(), func(k, _ []byte) error {
+			iterated = append(iterated, k)
+			return nil
+		})
+	}))
+	assert.Len(t, iterated, 4)
+
+	require.NoError(t, tieredDB.Update(func(txn db.Transaction) error {
+		return txn.DeletePrefix(db.TransactionsByBlockNumberAndIndex.Key())
+	}))
+	assert.Equal(t, "<not found>", get(t, cold, keys[0]))
+	assert.Equal(t, "<not found>", get(t, tieredDB, keys[2]))
+	assert.Equal(t, string(keys[1]), get(t, tieredDB, keys[1]))
+}
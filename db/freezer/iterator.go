@@ -0,0 +1,223 @@
+package freezer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var _ db.Iterator = (*iterator)(nil)
+
+// iterator iterates over the keys of the tables in the order of their buckets, block numbers and suffixes. It
+// holds the entries of the block it is positioned in, pos is the position of its key in them.
+type iterator struct {
+	tables   []*table
+	snapshot []index
+
+	table   int
+	block   uint64
+	entries []entry
+	pos     int
+	// positioned is set once the iterator is moved, valid once it is positioned at a key
+	positioned bool
+	valid      bool
+	// err is the error of the last read of an item, the iterator is invalid once it is set
+	err error
+}
+
+// Valid : see db.Transaction.Iterator.Valid
+func (i *iterator) Valid() bool {
+	return i.valid
+}
+
+// Key : see db.Transaction.Iterator.Key
+func (i *iterator) Key() []byte {
+	if !i.valid {
+		return nil
+	}
+	return i.key()
+}
+
+// key returns the key the iterator is positioned at
+func (i *iterator) key() []byte {
+	key := make([]byte, 0, blockKeyLen+len(i.entries[i.pos].suffix))
+	key = append(key, byte(i.tables[i.table].bucket))
+	key = binary.BigEndian.AppendUint64(key, i.block)
+	return append(key, i.entries[i.pos].suffix...)
+}
+
+// Value : see db.Transaction.Iterator.Value
+func (i *iterator) Value() ([]byte, error) {
+	if !i.valid {
+		return nil, i.err
+	}
+	return append([]byte{}, i.entries[i.pos].value...), nil
+}
+
+// Next : see db.Transaction.Iterator.Next
+func (i *iterator) Next() bool {
+	if !i.positioned {
+		return i.Seek(nil)
+	}
+	if !i.valid {
+		return false
+	}
+	if i.pos++; i.pos < len(i.entries) {
+		return true
+	}
+	return i.loadFirstFrom(i.table, i.block+1)
+}
+
+// Seek : see db.Transaction.Iterator.Seek
+func (i *iterator) Seek(key []byte) bool {
+	i.positioned = true
+	t, exact := i.tableOf(key)
+	if !exact {
+		return i.loadFirstFrom(t, 0)
+	}
+
+	block, suffix := splitPadded(key)
+	if !i.loadFirstFrom(t, block) || i.block != block {
+		return i.valid
+	}
+	if i.pos = search(i.entries, suffix); i.pos < len(i.entries) {
+		return true
+	}
+	return i.loadFirstFrom(t, block+1)
+}
+
+// Prev : see db.Transaction.Iterator.Prev
+func (i *iterator) Prev() bool {
+	if !i.positioned {
+		return i.seekBefore(nil)
+	}
+	if !i.valid {
+		return false
+	}
+	if i.pos--; i.pos >= 0 {
+		return true
+	}
+	return i.loadLastBefore(i.table, i.block)
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *iterator) SeekLast(prefix []byte) bool {
+	if i.seekBefore(db.PrefixEnd(prefix)) && !bytes.HasPrefix(i.key(), prefix) {
+		i.valid = false
+	}
+	return i.valid
+}
+
+// Close : see db.Transaction.Iterator.Close
+func (i *iterator) Close() error {
+	i.valid = false
+	i.entries = nil
+	return i.err
+}
+
+// seekBefore positions the iterator at the last key before end, or at the last key if end is nil
+func (i *iterator) seekBefore(end []byte) bool {
+	i.positioned = true
+	if end == nil {
+		return i.loadLastBefore(len(i.tables)-1, math.MaxUint64)
+	}
+	t, exact := i.tableOf(end)
+	if !exact {
+		return i.loadLastBefore(t-1, math.MaxUint64)
+	}
+
+	block, suffix := splitPadded(end)
+	entries, err := i.read(t, block)
+	if err != nil {
+		return false
+	}
+	if pos := search(entries, suffix) - 1; pos >= 0 {
+		i.table, i.block, i.entries, i.pos, i.valid = t, block, entries, pos, true
+		return true
+	}
+	return i.loadLastBefore(t, block)
+}
+
+// tableOf returns the first table whose keys are not all before key, and whether key starts with its bucket
+func (i *iterator) tableOf(key []byte) (int, bool) {
+	if len(key) == 0 {
+		return 0, false
+	}
+	for t, table := range i.tables {
+		if byte(table.bucket) >= key[0] {
+			return t, byte(table.bucket) == key[0]
+		}
+	}
+	return len(i.tables), false
+}
+
+// loadFirstFrom positions the iterator at the first key of the first block from block of table t that has one, or
+// of the tables after it
+func (i *iterator) loadFirstFrom(t int, block uint64) bool {
+	for ; t < len(i.tables); t, block = t+1, 0 {
+		for ; block < i.snapshot[t].blocks(); block++ {
+			entries, err := i.read(t, block)
+			if err != nil {
+				return false
+			}
+			if len(entries) > 0 {
+				i.table, i.block, i.entries, i.pos, i.valid = t, block, entries, 0, true
+				return true
+			}
+		}
+	}
+	i.valid = false
+	return false
+}
+
+// loadLastBefore positions the iterator at the last key of the last block before end of table t that has one, or
+// of the tables before it
+func (i *iterator) loadLastBefore(t int, end uint64) bool {
+	for ; t >= 0; t, end = t-1, math.MaxUint64 {
+		if blocks := i.snapshot[t].blocks(); end > blocks {
+			end = blocks
+		}
+		for block := end; block > 0; block-- {
+			entries, err := i.read(t, block-1)
+			if err != nil {
+				return false
+			}
+			if len(entries) > 0 {
+				i.table, i.block, i.entries, i.pos, i.valid = t, block-1, entries, len(entries)-1, true
+				return true
+			}
+		}
+	}
+	i.valid = false
+	return false
+}
+
+// read returns the entries of block in table t of the snapshot, the iterator is invalid if it fails
+func (i *iterator) read(t int, block uint64) ([]entry, error) {
+	entries, err := i.tables[t].read(i.snapshot[t].get(block))
+	if err != nil {
+		i.err, i.valid = err, false
+	}
+	return entries, err
+}
+
+// splitPadded returns the block number and the suffix of a key of a table, the block number of a key that is too
+// short for one is padded with zeros
+func splitPadded(key []byte) (uint64, []byte) {
+	var blockBytes [8]byte
+	copy(blockBytes[:], key[1:])
+	if len(key) <= blockKeyLen {
+		return binary.BigEndian.Uint64(blockBytes[:]), nil
+	}
+	return binary.BigEndian.Uint64(blockBytes[:]), key[blockKeyLen:]
+}
+
+// search returns the position of the first of entries whose suffix is not before suffix
+func search(entries []entry, suffix []byte) int {
+	return sort.Search(len(entries), func(i int) bool {
+		return bytes.Compare(entries[i].suffix, suffix) >= 0
+	})
+}
@@ -0,0 +1,273 @@
+package freezer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+const (
+	// pageSize is the number of blocks of a page of the index of a table, the pages are copied on write
+	pageSize = 4096
+	// locationSize is the size of the location of an item in the index file
+	locationSize = 12
+	// crcSize is the size of the checksum that ends each item
+	crcSize = 4
+)
+
+// location is where the item of a block is in the data file of a table, the block has no item if length is 0
+type location struct {
+	offset uint64
+	length uint32
+}
+
+type page [pageSize]location
+
+// index holds the locations of the items of a table by block number. The pages of an index are never modified once
+// it is stored, a commit stores a new index with copies of the pages it changes.
+type index []*page
+
+// blocks returns the number of blocks the index has room for, the blocks after the last item have no item
+func (ix index) blocks() uint64 {
+	return uint64(len(ix)) * pageSize
+}
+
+func (ix index) get(block uint64) location {
+	if block >= ix.blocks() {
+		return location{}
+	}
+	return ix[block/pageSize][block%pageSize]
+}
+
+// set returns ix with the location of block updated, copying the pages that are not in copied
+func (ix index) set(block uint64, loc location, copied map[uint64]bool) index {
+	p := block / pageSize
+	for uint64(len(ix)) <= p {
+		copied[uint64(len(ix))] = true
+		ix = append(ix, new(page))
+	}
+	if !copied[p] {
+		pageCopy := *ix[p]
+		ix[p] = &pageCopy
+		copied[p] = true
+	}
+	ix[p][block%pageSize] = loc
+	return ix
+}
+
+// entry is a key of an item without its bucket and block number, and its value
+type entry struct {
+	suffix []byte
+	value  []byte
+}
+
+// table stores the keys of a bucket keyed by block number, an item per block
+type table struct {
+	bucket db.Bucket
+	index  *os.File
+	data   *os.File
+	// dataSize is the size of the data the items are in, the data after it is not referenced by the index and is
+	// overwritten by the next commit. It is only accessed with the write lock held.
+	dataSize uint64
+	items    atomic.Pointer[index]
+}
+
+// openTable opens the table of bucket in dir, whose files start with name
+func openTable(dir, name string, bucket db.Bucket) (t *table, err error) {
+	t = &table{bucket: bucket}
+	if t.index, err = os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return nil, err
+	}
+	if t.data, err = os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return nil, errors.Join(err, t.index.Close())
+	}
+	if err = t.load(); err != nil {
+		return nil, errors.Join(fmt.Errorf("open table %s: %w", name, err), t.close())
+	}
+	return t, nil
+}
+
+// load reads the index of the table into memory. A location that was partially written when the node stopped is
+// dropped, it is the location of a block that had no item before.
+func (t *table) load() error {
+	info, err := t.data.Stat()
+	if err != nil {
+		return err
+	}
+	t.dataSize = uint64(info.Size())
+
+	if info, err = t.index.Stat(); err != nil {
+		return err
+	}
+	blocks := uint64(info.Size()) / locationSize
+	if err = t.index.Truncate(int64(blocks * locationSize)); err != nil {
+		return err
+	}
+
+	ix := make(index, 0, (blocks+pageSize-1)/pageSize)
+	copied := make(map[uint64]bool)
+	reader := bufio.NewReader(io.NewSectionReader(t.index, 0, int64(blocks*locationSize)))
+	var buf [locationSize]byte
+	for block := uint64(0); block < blocks; block++ {
+		if _, err = io.ReadFull(reader, buf[:]); err != nil {
+			return err
+		}
+		loc := location{offset: binary.BigEndian.Uint64(buf[:8]), length: binary.BigEndian.Uint32(buf[8:])}
+		if loc.length > 0 && loc.offset+uint64(loc.length) > t.dataSize {
+			return fmt.Errorf("item of block %d is past the end of the data file", block)
+		}
+		ix = ix.set(block, loc, copied)
+	}
+	t.items.Store(&ix)
+	return nil
+}
+
+// read returns the entries of the item at loc
+func (t *table) read(loc location) ([]entry, error) {
+	if loc.length == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, loc.length)
+	if _, err := t.data.ReadAt(buf, int64(loc.offset)); err != nil {
+		return nil, err
+	}
+	return decodeItem(buf)
+}
+
+// write writes the locations of blocks in ix to the index file
+func (t *table) write(ix index, blocks []uint64) error {
+	var buf [locationSize]byte
+	for _, block := range blocks {
+		loc := ix.get(block)
+		binary.BigEndian.PutUint64(buf[:8], loc.offset)
+		binary.BigEndian.PutUint32(buf[8:], loc.length)
+		if _, err := t.index.WriteAt(buf[:], int64(block*locationSize)); err != nil {
+			return err
+		}
+	}
+	return t.index.Sync()
+}
+
+// commit appends the items of the blocks that writes, by block and suffix, change and writes their locations to the
+// index file. It returns the index of the table with these locations, for the caller to store.
+func (t *table) commit(writes map[uint64]map[string][]byte) (index, error) {
+	blocks := make([]uint64, 0, len(writes))
+	for block := range writes {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(a, b int) bool { return blocks[a] < blocks[b] })
+
+	current := *t.items.Load()
+	ix := append(index(nil), current...)
+	copied := make(map[uint64]bool)
+	var data bytes.Buffer
+	for _, block := range blocks {
+		entries, err := t.read(current.get(block))
+		if err != nil {
+			return nil, fmt.Errorf("read block %d: %w", block, err)
+		}
+		var loc location
+		if entries = applyWrites(entries, writes[block]); len(entries) > 0 {
+			item := encodeItem(entries)
+			loc = location{offset: t.dataSize + uint64(data.Len()), length: uint32(len(item))}
+			data.Write(item)
+		}
+		ix = ix.set(block, loc, copied)
+	}
+
+	if _, err := t.data.WriteAt(data.Bytes(), int64(t.dataSize)); err != nil {
+		return nil, err
+	}
+	if err := t.data.Sync(); err != nil {
+		return nil, err
+	}
+	if err := t.write(ix, blocks); err != nil {
+		return nil, err
+	}
+	t.dataSize += uint64(data.Len())
+	return ix, nil
+}
+
+func (t *table) close() error {
+	return errors.Join(t.index.Close(), t.data.Close())
+}
+
+// encodeItem encodes entries, which are sorted by suffix, followed by their checksum
+func encodeItem(entries []entry) []byte {
+	var buf []byte
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(len(e.suffix)))
+		buf = append(buf, e.suffix...)
+		buf = binary.AppendUvarint(buf, uint64(len(e.value)))
+		buf = append(buf, e.value...)
+	}
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+func decodeItem(buf []byte) ([]entry, error) {
+	if len(buf) < crcSize {
+		return nil, errors.New("item is too short")
+	}
+	payload := buf[:len(buf)-crcSize]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(buf[len(payload):]) {
+		return nil, errors.New("checksum of item does not match")
+	}
+
+	var entries []entry
+	for len(payload) > 0 {
+		var e entry
+		var err error
+		if e.suffix, payload, err = decodeBytes(payload); err != nil {
+			return nil, err
+		}
+		if e.value, payload, err = decodeBytes(payload); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// decodeBytes decodes the length-prefixed bytes at the start of buf and returns the rest of buf
+func decodeBytes(buf []byte) ([]byte, []byte, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf)-n) < length {
+		return nil, nil, errors.New("item is truncated")
+	}
+	return buf[n : n+int(length)], buf[n+int(length):], nil
+}
+
+// applyWrites returns entries, which are sorted by suffix, updated with writes by suffix, the deleted suffixes have
+// a nil value
+func applyWrites(entries []entry, writes map[string][]byte) []entry {
+	bySuffix := make(map[string][]byte, len(entries)+len(writes))
+	for _, e := range entries {
+		bySuffix[string(e.suffix)] = e.value
+	}
+	for suffix, value := range writes {
+		if value == nil {
+			delete(bySuffix, suffix)
+		} else {
+			bySuffix[suffix] = value
+		}
+	}
+
+	updated := make([]entry, 0, len(bySuffix))
+	for suffix, value := range bySuffix {
+		updated = append(updated, entry{suffix: []byte(suffix), value: value})
+	}
+	sort.Slice(updated, func(i, j int) bool {
+		return bytes.Compare(updated[i].suffix, updated[j].suffix) < 0
+	})
+	return updated
+}
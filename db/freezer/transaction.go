@@ -0,0 +1,169 @@
+package freezer
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var ErrDiscardedTransaction = errors.New("discarded txn")
+
+var _ db.Transaction = (*Transaction)(nil)
+
+type Transaction struct {
+	db *DB
+	// snapshot is the index of each table when the transaction started
+	snapshot []index
+	// writes holds the keys set or deleted by an update transaction until it is committed, it is nil for read-only
+	// transactions
+	writes    map[string][]byte
+	discarded bool
+}
+
+// Discard : see db.Transaction.Discard
+func (t *Transaction) Discard() error {
+	if t.discarded {
+		return nil
+	}
+	t.discarded = true
+	t.snapshot = nil
+	if t.writes != nil {
+		t.writes = nil
+		t.db.wMutex.Unlock()
+	}
+	return nil
+}
+
+// Commit : see db.Transaction.Commit
+func (t *Transaction) Commit() error {
+	if t.discarded || t.writes == nil {
+		return db.CloseAndWrapOnError(t.Discard, ErrDiscardedTransaction)
+	}
+	return db.CloseAndWrapOnError(t.Discard, t.db.commit(t.writes))
+}
+
+// Set : see db.Transaction.Set, it returns ErrNotStored for the keys that the freezer does not store
+func (t *Transaction) Set(key, val []byte) error {
+	if err := t.writable(); err != nil {
+		return err
+	}
+	if _, _, _, err := splitKey(key); err != nil {
+		return err
+	}
+	t.writes[string(key)] = append([]byte{}, val...)
+	return nil
+}
+
+// Delete : see db.Transaction.Delete
+func (t *Transaction) Delete(key []byte) error {
+	if err := t.writable(); err != nil {
+		return err
+	}
+	if _, _, _, err := splitKey(key); err != nil {
+		// the freezer does not have the key
+		return nil
+	}
+	t.writes[string(key)] = nil
+	return nil
+}
+
+// DeletePrefix : see db.Transaction.DeletePrefix
+func (t *Transaction) DeletePrefix(prefix []byte) error {
+	if err := t.writable(); err != nil {
+		return err
+	}
+	for key := range t.writes {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			t.writes[key] = nil
+		}
+	}
+
+	it := t.newIterator()
+	defer it.Close()
+	for it.Seek(prefix); it.Valid() && bytes.HasPrefix(it.key(), prefix); it.Next() {
+		t.writes[string(it.key())] = nil
+	}
+	return it.err
+}
+
+// MultiGet : see db.Transaction.MultiGet, the keys are read one by one
+func (t *Transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	return db.MultiGetByKey(t, keys, cb)
+}
+
+// Get : see db.Transaction.Get
+func (t *Transaction) Get(key []byte, cb func([]byte) error) error {
+	if err := t.readable(); err != nil {
+		return err
+	}
+	if value, found := t.writes[string(key)]; found {
+		if value == nil {
+			return db.ErrKeyNotFound
+		}
+		return cb(value)
+	}
+
+	i, block, suffix, err := splitKey(key)
+	if err != nil {
+		return db.ErrKeyNotFound
+	}
+	entries, err := t.db.tables[i].read(t.snapshot[i].get(block))
+	if err != nil {
+		return err
+	}
+	if pos := search(entries, suffix); pos < len(entries) && bytes.Equal(entries[pos].suffix, suffix) {
+		return cb(entries[pos].value)
+	}
+	return db.ErrKeyNotFound
+}
+
+// Impl : see db.Transaction.Impl
+func (t *Transaction) Impl() any {
+	return t
+}
+
+// NewIterator : see db.Transaction.NewIterator. The iterator reads the keys that are committed, so it cannot be
+// created while the transaction has writes that are not.
+func (t *Transaction) NewIterator() (db.Iterator, error) {
+	if err := t.readable(); err != nil {
+		return nil, err
+	}
+	if len(t.writes) > 0 {
+		return nil, errors.New("the freezer does not iterate over the writes of a transaction before they are committed")
+	}
+	return t.newIterator(), nil
+}
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds
+func (t *Transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	it, err := t.NewIterator()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBoundedIterator(it, lower, upper), nil
+}
+
+func (t *Transaction) newIterator() *iterator {
+	return &iterator{tables: t.db.tables, snapshot: t.snapshot}
+}
+
+func (t *Transaction) readable() error {
+	if t.discarded {
+		return ErrDiscardedTransaction
+	}
+	if t.db.closed.Load() {
+		return ErrClosed
+	}
+	return nil
+}
+
+func (t *Transaction) writable() error {
+	if err := t.readable(); err != nil {
+		return err
+	}
+	if t.writes == nil {
+		return errors.New("read only transaction")
+	}
+	return nil
+}
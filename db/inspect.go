@@ -0,0 +1,53 @@
+package db
+
+// BucketStats are the number and the size of the keys of a bucket
+type BucketStats struct {
+	Keys uint64 `json:"keys"`
+	// ValueBytes is the size of the values of the keys. If Estimated is set, it is the disk space of the bucket,
+	// which includes its keys and is compressed.
+	ValueBytes uint64 `json:"value_bytes"`
+	Estimated  bool   `json:"estimated"`
+}
+
+// AverageValueSize returns the average size of the values of the bucket, or 0 if it has no keys
+func (s BucketStats) AverageValueSize() uint64 {
+	if s.Keys == 0 {
+		return 0
+	}
+	return s.ValueBytes / s.Keys
+}
+
+// InspectBucket counts the keys of bucket and the size of their values by reading all of them
+func InspectBucket(txn Transaction, bucket Bucket) (BucketStats, error) {
+	prefix := bucket.Key()
+	it, err := txn.NewIteratorWithBounds(prefix, PrefixEnd(prefix))
+	if err != nil {
+		return BucketStats{}, err
+	}
+
+	var stats BucketStats
+	for it.Seek(prefix); it.Valid(); it.Next() {
+		value, err := it.Value()
+		if err != nil {
+			return BucketStats{}, CloseAndWrapOnError(it.Close, err)
+		}
+		stats.Keys++
+		stats.ValueBytes += uint64(len(value))
+	}
+	return stats, it.Close()
+}
+
+// EstimateBucket estimates the keys of bucket and their size from the metadata of the database, without reading
+// them, see Estimator
+func EstimateBucket(estimator Estimator, bucket Bucket) (BucketStats, error) {
+	start, end := bucket.Key(), (bucket + 1).Key()
+	keys, err := estimator.RangeKeyCount(start, end)
+	if err != nil {
+		return BucketStats{}, err
+	}
+	size, err := estimator.RangeSize(start, end)
+	if err != nil {
+		return BucketStats{}, err
+	}
+	return BucketStats{Keys: keys, ValueBytes: size, Estimated: true}, nil
+}
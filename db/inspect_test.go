@@ -0,0 +1,55 @@
+package db_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	pebbledb "github.com/cockroachdb/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectBucket(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := uint64(0); i < 1000; i++ {
+			key := binary.BigEndian.AppendUint64(nil, i)
+			if err := txn.Set(db.Class.Key(key), make([]byte, 64)); err != nil {
+				return err
+			}
+			if err := txn.Set(db.ContractNonce.Key(key), make([]byte, 8)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	t.Run("exact", func(t *testing.T) {
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			stats, err := db.InspectBucket(txn, db.Class)
+			require.NoError(t, err)
+			assert.Equal(t, db.BucketStats{Keys: 1000, ValueBytes: 64000}, stats)
+			assert.Equal(t, uint64(64), stats.AverageValueSize())
+
+			stats, err = db.InspectBucket(txn, db.ABIs)
+			require.NoError(t, err)
+			assert.Equal(t, db.BucketStats{}, stats)
+			assert.Zero(t, stats.AverageValueSize())
+			return nil
+		}))
+	})
+
+	t.Run("estimated", func(t *testing.T) {
+		require.NoError(t, testDB.Impl().(*pebbledb.DB).Flush())
+		stats, err := db.EstimateBucket(testDB.(db.Estimator), db.Class)
+		require.NoError(t, err)
+		assert.True(t, stats.Estimated)
+		assert.InEpsilon(t, 1000, stats.Keys, 0.5)
+		assert.NotZero(t, stats.ValueBytes)
+	})
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrStopIteration can be returned by the function IterateBucket and IteratePrefix call with every pair to stop the
+// iteration, the iteration then returns no error
+var ErrStopIteration = errors.New("stop iteration")
+
+type iterateOptions struct {
+	start    []byte
+	reverse  bool
+	keysOnly bool
+}
+
+// IterateOption changes the keys IterateBucket and IteratePrefix go through, or their order
+type IterateOption func(*iterateOptions)
+
+// From starts the iteration at key, inclusive, instead of the first key, or the last key in reverse. The key is a
+// full key, that is the bucket or the prefix followed by the rest of the key.
+func From(key []byte) IterateOption {
+	return func(opts *iterateOptions) {
+		opts.start = key
+	}
+}
+
+// Reverse goes through the keys from the last to the first
+func Reverse() IterateOption {
+	return func(opts *iterateOptions) {
+		opts.reverse = true
+	}
+}
+
+// KeysOnly calls fn with nil values, which are not read from the database, for the iterations that only go through
+// the keys. The values are neither copied out of the database nor decompressed, which is most of the cost of going
+// through the buckets of large values, such as those of the classes and the blocks.
+func KeysOnly() IterateOption {
+	return func(opts *iterateOptions) {
+		opts.keysOnly = true
+	}
+}
+
+// IterateBucket calls fn with the keys under bucket and their values, in the order of the keys, until fn returns an
+// error. fn can keep the slices it is called with, and the iterator is closed before IterateBucket returns so that
+// txn can be written to afterwards.
+func IterateBucket(txn Transaction, bucket Bucket, fn func(key, value []byte) error, opts ...IterateOption) error {
+	return IteratePrefix(txn, bucket.Key(), fn, opts...)
+}
+
+// IteratePrefix is IterateBucket for the keys that start with prefix
+func IteratePrefix(txn Transaction, prefix []byte, fn func(key, value []byte) error, opts ...IterateOption) error {
+	var options iterateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	it, err := txn.NewIteratorWithBounds(prefix, PrefixEnd(prefix))
+	if err != nil {
+		return err
+	}
+	if options.reverse {
+		err = iterateReverse(it, prefix, &options, fn)
+	} else {
+		err = iterateForward(it, prefix, &options, fn)
+	}
+	if errors.Is(err, ErrStopIteration) {
+		err = nil
+	}
+	return CloseAndWrapOnError(it.Close, err)
+}
+
+func iterateForward(it Iterator, prefix []byte, options *iterateOptions, fn func(key, value []byte) error) error {
+	seek := prefix
+	if bytes.Compare(options.start, prefix) > 0 {
+		seek = options.start
+	}
+	for it.Seek(seek); it.Valid(); it.Next() {
+		if err := visit(it, options, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterateReverse goes through the keys of prefix backwards from the start of options
+func iterateReverse(it Iterator, prefix []byte, options *iterateOptions, fn func(key, value []byte) error) error {
+	start := options.start
+	if end := PrefixEnd(prefix); start == nil || (end != nil && bytes.Compare(start, end) >= 0) {
+		it.SeekLast(prefix)
+	} else if !it.Seek(start) {
+		// all the keys are before start
+		it.SeekLast(prefix)
+	} else if bytes.Compare(it.Key(), start) > 0 {
+		it.Prev()
+	}
+
+	for ; it.Valid(); it.Prev() {
+		if err := visit(it, options, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visit calls fn with the pair it is positioned at, without its value if options are KeysOnly
+func visit(it Iterator, options *iterateOptions, fn func(key, value []byte) error) error {
+	var value []byte
+	if !options.keysOnly {
+		var err error
+		if value, err = it.Value(); err != nil {
+			return err
+		}
+	}
+	return fn(it.Key(), value)
+}
+
+// deletePrefixChunk is the number of keys DeletePrefixByKey reads before it deletes them
+const deletePrefixChunk = 1024
+
+// DeletePrefixByKey deletes the keys that start with prefix one by one, for the transactions that cannot delete a
+// range of keys at once. The keys are read and deleted in chunks, so that they are not all held in memory.
+func DeletePrefixByKey(txn Transaction, prefix []byte) error {
+	var from []byte
+	for {
+		keys := make([][]byte, 0, deletePrefixChunk)
+		err := IteratePrefix(txn, prefix, func(key, _ []byte) error {
+			keys = append(keys, key)
+			if len(keys) == deletePrefixChunk {
+				return ErrStopIteration
+			}
+			return nil
+		}, From(from), KeysOnly())
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		if len(keys) < deletePrefixChunk {
+			return nil
+		}
+		// the next chunk starts after the last deleted key, without going through the deleted ones again
+		from = append(keys[len(keys)-1], 0)
+	}
+}
+
+// PrefixEnd returns the smallest key that is after all the keys that start with prefix, or nil if there is none
+func PrefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
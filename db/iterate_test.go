@@ -0,0 +1,205 @@
+package db_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterateBucket(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for _, key := range [][]byte{
+			db.StateTrie.Key([]byte{0xff}),
+			db.Class.Key(),
+			db.Class.Key([]byte{1}),
+			db.Class.Key([]byte{2}),
+			db.Class.Key([]byte{2, 0}),
+			db.Class.Key([]byte{3}),
+			db.ContractNonce.Key([]byte{0}),
+		} {
+			if err := txn.Set(key, append([]byte("value"), key...)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	txn := testDB.NewTransaction(false)
+	t.Cleanup(func() {
+		require.NoError(t, txn.Discard())
+	})
+	iterate := func(t *testing.T, txn db.Transaction, opts ...db.IterateOption) [][]byte {
+		t.Helper()
+		var keys [][]byte
+		require.NoError(t, db.IterateBucket(txn, db.Class, func(key, value []byte) error {
+			assert.Equal(t, append([]byte("value"), key...), value)
+			keys = append(keys, key)
+			return nil
+		}, opts...))
+		return keys
+	}
+	classKeys := [][]byte{
+		db.Class.Key(),
+		db.Class.Key([]byte{1}),
+		db.Class.Key([]byte{2}),
+		db.Class.Key([]byte{2, 0}),
+		db.Class.Key([]byte{3}),
+	}
+	reversed := func(keys [][]byte) [][]byte {
+		out := make([][]byte, 0, len(keys))
+		for i := len(keys) - 1; i >= 0; i-- {
+			out = append(out, keys[i])
+		}
+		return out
+	}
+
+	t.Run("forward", func(t *testing.T) {
+		assert.Equal(t, classKeys, iterate(t, txn))
+		assert.Equal(t, classKeys[2:], iterate(t, txn, db.From(db.Class.Key([]byte{2}))))
+		assert.Equal(t, classKeys, iterate(t, txn, db.From(db.StateTrie.Key([]byte{0xff}))))
+		assert.Empty(t, iterate(t, txn, db.From(db.ContractNonce.Key())))
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		assert.Equal(t, reversed(classKeys), iterate(t, txn, db.Reverse()))
+		assert.Equal(t, reversed(classKeys[:3]), iterate(t, txn, db.Reverse(), db.From(db.Class.Key([]byte{2}))))
+		assert.Equal(t, reversed(classKeys[:4]), iterate(t, txn, db.Reverse(), db.From(db.Class.Key([]byte{2, 1}))))
+		assert.Equal(t, reversed(classKeys), iterate(t, txn, db.Reverse(), db.From(db.Class.Key([]byte{4}))))
+		assert.Equal(t, reversed(classKeys), iterate(t, txn, db.Reverse(), db.From(db.ContractNonce.Key())))
+		assert.Empty(t, iterate(t, txn, db.Reverse(), db.From(db.StateTrie.Key([]byte{0xff}))))
+	})
+
+	t.Run("keys only", func(t *testing.T) {
+		var keys [][]byte
+		require.NoError(t, db.IterateBucket(valuelessTxn{txn}, db.Class, func(key, value []byte) error {
+			assert.Nil(t, value)
+			keys = append(keys, key)
+			return nil
+		}, db.KeysOnly()))
+		assert.Equal(t, classKeys, keys)
+		require.Error(t, db.IterateBucket(valuelessTxn{txn}, db.Class, func(_, _ []byte) error {
+			return nil
+		}))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var keys [][]byte
+		require.NoError(t, db.IterateBucket(txn, db.Class, func(key, _ []byte) error {
+			keys = append(keys, key)
+			if len(keys) == 2 {
+				return db.ErrStopIteration
+			}
+			return nil
+		}))
+		assert.Equal(t, classKeys[:2], keys)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		assert.ErrorIs(t, db.IterateBucket(txn, db.Class, func(_, _ []byte) error {
+			return db.ErrKeyNotFound
+		}), db.ErrKeyNotFound)
+	})
+}
+
+// valuelessTxn is a transaction whose iterators fail to read the values
+type valuelessTxn struct {
+	db.Transaction
+}
+
+func (t valuelessTxn) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	it, err := t.Transaction.NewIteratorWithBounds(lower, upper)
+	return valuelessIterator{it}, err
+}
+
+type valuelessIterator struct {
+	db.Iterator
+}
+
+func (valuelessIterator) Value() ([]byte, error) {
+	return nil, errors.New("value read")
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte{2}, db.PrefixEnd([]byte{1}))
+	assert.Equal(t, []byte{1, 3}, db.PrefixEnd([]byte{1, 2}))
+	assert.Equal(t, []byte{2}, db.PrefixEnd([]byte{1, 0xff}))
+	assert.Nil(t, db.PrefixEnd([]byte{0xff}))
+	assert.Nil(t, db.PrefixEnd(nil))
+}
+
+func TestDeletePrefix(t *testing.T) {
+	databases := map[string]db.DB{
+		// pebble deletes the prefixes with range tombstones and the in-memory database key by key
+		"pebble": pebble.NewMemTest(),
+		"memory": memory.New(),
+	}
+	for name, database := range databases {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() {
+				require.NoError(t, database.Close())
+			})
+			// more keys than a chunk of db.DeletePrefixByKey
+			require.NoError(t, database.Update(func(txn db.Transaction) error {
+				for i := uint64(0); i < 3000; i++ {
+					if err := txn.Set(db.Class.Key(binary.BigEndian.AppendUint64(nil, i)), []byte("value")); err != nil {
+						return err
+					}
+				}
+				for _, key := range [][]byte{db.StateTrie.Key([]byte{0xff}), db.ContractNonce.Key()} {
+					if err := txn.Set(key, []byte("value")); err != nil {
+						return err
+					}
+				}
+				return nil
+			}))
+			count := func(txn db.Transaction, bucket db.Bucket) int {
+				var keys int
+				require.NoError(t, db.IterateBucket(txn, bucket, func(_, _ []byte) error {
+					keys++
+					return nil
+				}))
+				return keys
+			}
+
+			require.NoError(t, database.Update(func(txn db.Transaction) error {
+				require.NoError(t, txn.DeletePrefix(db.Class.Key()))
+				// the deletion is visible in the transaction
+				assert.Zero(t, count(txn, db.Class))
+				assert.ErrorIs(t, txn.Get(db.Class.Key(make([]byte, 8)), func([]byte) error { return nil }),
+					db.ErrKeyNotFound)
+				return nil
+			}))
+			require.NoError(t, database.View(func(txn db.Transaction) error {
+				assert.Zero(t, count(txn, db.Class))
+				assert.Equal(t, 1, count(txn, db.StateTrie))
+				assert.Equal(t, 1, count(txn, db.ContractNonce))
+				return nil
+			}))
+
+			// an empty prefix has no end key, its keys are deleted one by one
+			require.NoError(t, database.Update(func(txn db.Transaction) error {
+				return txn.DeletePrefix(nil)
+			}))
+			require.NoError(t, database.View(func(txn db.Transaction) error {
+				it, err := txn.NewIterator()
+				require.NoError(t, err)
+				assert.False(t, it.Next())
+				return it.Close()
+			}))
+
+			require.Error(t, database.View(func(txn db.Transaction) error {
+				return txn.DeletePrefix(db.Class.Key())
+			}), "read-only transactions cannot delete")
+		})
+	}
+}
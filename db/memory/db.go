@@ -0,0 +1,166 @@
+// Package memory implements a db.DB that holds its keys in memory, for the tests and the nodes that do not keep their
+// data, such as a devnet. It is registered as the "memory" backend, which ignores the path of the database.
+//
+// The keys are kept in a sorted slice that is replaced by a new one on each commit, so that the read transactions
+// keep the slice they started with as their snapshot without locking it. A commit copies the slice, which is cheap
+// for the databases of the tests and a devnet but not for a database the size of a network.
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+// Backend is the name the in-memory database is registered under, see db.Register
+const Backend = "memory"
+
+// ErrClosed is returned by the transactions of a database that is closed
+var ErrClosed = errors.New("database is closed")
+
+var _ db.DB = (*DB)(nil)
+
+func init() {
+	db.Register(Backend, func(string, db.Options) (db.DB, error) {
+		return New(), nil
+	})
+}
+
+// entry is a key and its value, the value of a deleted key is nil in the writes of a transaction
+type entry struct {
+	key   []byte
+	value []byte
+}
+
+type DB struct {
+	// entries holds the committed keys, in order. The slice is never modified once it is stored.
+	entries   atomic.Pointer[[]entry]
+	closed    atomic.Bool
+	wMutex    *sync.Mutex
+	wLockedAt *atomic.Int64
+}
+
+// New returns an empty in-memory database
+func New() *DB {
+	d := &DB{wMutex: new(sync.Mutex), wLockedAt: new(atomic.Int64)}
+	d.entries.Store(new([]entry))
+	return d
+}
+
+// NewTransaction : see db.DB.NewTransaction
+func (d *DB) NewTransaction(update bool) db.Transaction {
+	txn := &Transaction{db: d}
+	if update {
+		d.wMutex.Lock()
+		d.wLockedAt.Store(time.Now().UnixNano())
+		txn.writes = make(map[string][]byte)
+	}
+	// the snapshot is taken once the write lock is held, so that no commit happens between them
+	txn.snapshot = *d.entries.Load()
+	return txn
+}
+
+// WriteLockHeld returns for how long the write transaction that is in progress has been held, or 0 if there is none
+func (d *DB) WriteLockHeld() time.Duration {
+	lockedAt := d.wLockedAt.Load()
+	if lockedAt == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, lockedAt))
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(true)
+	if err := fn(txn); err != nil {
+		return db.CloseAndWrapOnError(txn.Discard, err)
+	}
+	return db.CloseAndWrapOnError(txn.Discard, txn.Commit())
+}
+
+// Close : see io.Closer.Close. The keys are dropped and the transactions that are started after it fail.
+func (d *DB) Close() error {
+	d.closed.Store(true)
+	d.entries.Store(new([]entry))
+	return nil
+}
+
+// Backup : see db.DB.Backup. The keys of an in-memory database are not kept, so it is not backed up either.
+func (d *DB) Backup(dir string) error {
+	return errors.New("an in-memory database cannot be backed up")
+}
+
+// CompactRange : see db.DB.CompactRange. The deleted keys are dropped on commit, so there is nothing to compact.
+func (d *DB) CompactRange(start, end []byte) error {
+	return nil
+}
+
+// Impl : see db.DB.Impl
+func (d *DB) Impl() any {
+	return d
+}
+
+// commit stores the entries of snapshot updated with writes
+func (d *DB) commit(snapshot []entry, writes map[string][]byte) error {
+	if d.closed.Load() {
+		return ErrClosed
+	}
+	merged := merge(snapshot, writes)
+	d.entries.Store(&merged)
+	return nil
+}
+
+// merge returns a new slice of the entries of snapshot updated with writes, in order, without the deleted keys
+func merge(snapshot []entry, writes map[string][]byte) []entry {
+	sorted := make([]entry, 0, len(writes))
+	for key, value := range writes {
+		sorted = append(sorted, entry{key: []byte(key), value: value})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].key, sorted[j].key) < 0
+	})
+
+	merged := make([]entry, 0, len(snapshot)+len(sorted))
+	i, j := 0, 0
+	for i < len(snapshot) || j < len(sorted) {
+		var next entry
+		switch {
+		case j == len(sorted):
+			next, i = snapshot[i], i+1
+		case i == len(snapshot):
+			next, j = sorted[j], j+1
+		default:
+			switch cmp := bytes.Compare(snapshot[i].key, sorted[j].key); {
+			case cmp < 0:
+				next, i = snapshot[i], i+1
+			case cmp > 0:
+				next, j = sorted[j], j+1
+			default:
+				// the write replaces the committed value
+				next, i, j = sorted[j], i+1, j+1
+			}
+		}
+		if next.value != nil {
+			merged = append(merged, next)
+		}
+	}
+	return merged
+}
+
+// search returns the index of the first entry of entries whose key is greater than or equal to key
+func search(entries []entry, key []byte) int {
+	return sort.Search(len(entries), func(i int) bool {
+		return bytes.Compare(entries[i].key, key) >= 0
+	})
+}
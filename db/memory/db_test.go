@@ -0,0 +1,220 @@
+package memory_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/dbtest"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, txn db.Transaction, key string) string {
+	t.Helper()
+	var value string
+	err := txn.Get([]byte(key), func(v []byte) error {
+		value = string(v)
+		return nil
+	})
+	if err == db.ErrKeyNotFound {
+		return "<not found>"
+	}
+	require.NoError(t, err)
+	return value
+}
+
+func TestTransaction(t *testing.T) {
+	testDB := memory.New()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		require.NoError(t, txn.Set([]byte("a"), []byte("1")))
+		require.NoError(t, txn.Set([]byte("b"), []byte("2")))
+		assert.Equal(t, "1", get(t, txn, "a"))
+		return nil
+	}))
+
+	t.Run("read only", func(t *testing.T) {
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, "2", get(t, txn, "b"))
+			assert.Equal(t, "<not found>", get(t, txn, "c"))
+			assert.Error(t, txn.Set([]byte("c"), []byte("3")))
+			assert.Error(t, txn.Delete([]byte("a")))
+			return nil
+		}))
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		snapshot := testDB.NewTransaction(false)
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			require.NoError(t, txn.Delete([]byte("a")))
+			return txn.Set([]byte("b"), []byte("3"))
+		}))
+		assert.Equal(t, "1", get(t, snapshot, "a"))
+		assert.Equal(t, "2", get(t, snapshot, "b"))
+		require.NoError(t, snapshot.Discard())
+
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, "<not found>", get(t, txn, "a"))
+			assert.Equal(t, "3", get(t, txn, "b"))
+			return nil
+		}))
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		txn := testDB.NewTransaction(true)
+		require.NoError(t, txn.Set([]byte("d"), []byte("4")))
+		assert.NotZero(t, txn.(db.PendingSizer).PendingSize())
+		require.NoError(t, txn.Discard())
+		assert.ErrorIs(t, txn.Commit(), memory.ErrDiscardedTransaction)
+		assert.ErrorIs(t, txn.Get([]byte("b"), func([]byte) error { return nil }), memory.ErrDiscardedTransaction)
+
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, "<not found>", get(t, txn, "d"))
+			return nil
+		}))
+	})
+
+	t.Run("writes are serialized", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, testDB.Update(func(txn db.Transaction) error {
+					var count []byte
+					if err := txn.Get([]byte("count"), func(v []byte) error {
+						count = append(count, v...)
+						return nil
+					}); err != nil && err != db.ErrKeyNotFound {
+						return err
+					}
+					return txn.Set([]byte("count"), append(count, 'x'))
+				}))
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			assert.Equal(t, "xxxxxxxxxx", get(t, txn, "count"))
+			return nil
+		}))
+	})
+}
+
+// TestIterator checks that the iterators of the in-memory database move like those of Pebble
+func TestIterator(t *testing.T) {
+	pebbleDB := pebble.NewMemTest()
+	memDB := memory.New()
+	t.Cleanup(func() {
+		require.NoError(t, pebbleDB.Close())
+		require.NoError(t, memDB.Close())
+	})
+	for _, database := range []db.DB{pebbleDB, memDB} {
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			for _, key := range []string{"b", "d", "d0", "f", "h"} {
+				if err := txn.Set([]byte(key), []byte("value "+key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+
+	type move func(it db.Iterator) bool
+	next := func(it db.Iterator) bool { return it.Next() }
+	prev := func(it db.Iterator) bool { return it.Prev() }
+	seek := func(key string) move {
+		return func(it db.Iterator) bool { return it.Seek([]byte(key)) }
+	}
+	seekLast := func(prefix string) move {
+		return func(it db.Iterator) bool { return it.SeekLast([]byte(prefix)) }
+	}
+	tests := map[string][]move{
+		"forward":               {next, next, next, next, next, next, next},
+		"backward":              {prev, prev, prev, prev, prev, prev},
+		"seek":                  {seek("c"), next, seek("d"), seek("z"), next},
+		"seek last":             {seekLast("d"), prev, seekLast(""), prev, seekLast("a"), prev, next},
+		"back and forth":        {seek("d0"), prev, next, next, seekLast("b"), next, prev, prev},
+		"from the ends":         {seek("z"), prev, seekLast("z"), next},
+		"seek after exhaustion": {next, next, next, next, next, next, seek("a")},
+	}
+	// the updates of a transaction are iterated over along with the committed keys
+	update := func(txn db.Transaction) {
+		require.NoError(t, txn.Delete([]byte("d")))
+		require.NoError(t, txn.Set([]byte("e"), []byte("value e")))
+		require.NoError(t, txn.Set([]byte("h"), []byte("new value h")))
+	}
+
+	for name, moves := range tests {
+		for _, withUpdates := range []bool{false, true} {
+			var positions [2][]string
+			for d, database := range []db.DB{pebbleDB, memDB} {
+				txn := database.NewTransaction(withUpdates)
+				if withUpdates {
+					update(txn)
+				}
+				it, err := txn.NewIterator()
+				require.NoError(t, err)
+				for _, m := range moves {
+					valid := m(it)
+					require.Equal(t, valid, it.Valid())
+					position := "<invalid>"
+					if valid {
+						value, err := it.Value()
+						require.NoError(t, err)
+						position = string(it.Key()) + "=" + string(value)
+					}
+					positions[d] = append(positions[d], position)
+				}
+				require.NoError(t, it.Close())
+				require.NoError(t, txn.Discard())
+			}
+			assert.Equal(t, positions[0], positions[1], "%s, with updates %t", name, withUpdates)
+		}
+	}
+}
+
+func TestIterateBucket(t *testing.T) {
+	testDB := memory.New()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for _, key := range [][]byte{db.Class.Key([]byte{2}), db.Class.Key([]byte{1}), db.ContractNonce.Key()} {
+			if err := txn.Set(key, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	var keys [][]byte
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		return db.IterateBucket(txn, db.Class, func(key, _ []byte) error {
+			keys = append(keys, key)
+			return nil
+		}, db.Reverse())
+	}))
+	assert.Equal(t, [][]byte{db.Class.Key([]byte{2}), db.Class.Key([]byte{1})}, keys)
+}
+
+func TestBackend(t *testing.T) {
+	database, err := db.Open(memory.Backend, "", db.Options{})
+	require.NoError(t, err)
+	assert.IsType(t, &memory.DB{}, database)
+
+	require.NoError(t, database.Close())
+	txn := database.NewTransaction(false)
+	assert.ErrorIs(t, txn.Get([]byte("a"), func([]byte) error { return nil }), memory.ErrClosed)
+	require.NoError(t, txn.Discard())
+}
+
+func TestConformance(t *testing.T) {
+	dbtest.TestBackend(t, func(*testing.T) db.DB {
+		return memory.New()
+	})
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"bytes"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var _ db.Iterator = (*iterator)(nil)
+
+// iterator iterates over a sorted slice of entries, pos is -1 before the first entry and len(entries) after the last
+type iterator struct {
+	entries    []entry
+	pos        int
+	positioned bool
+}
+
+// Valid : see db.Transaction.Iterator.Valid
+func (i *iterator) Valid() bool {
+	return i.pos >= 0 && i.pos < len(i.entries)
+}
+
+// Key : see db.Transaction.Iterator.Key
+func (i *iterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return append([]byte{}, i.entries[i.pos].key...)
+}
+
+// Value : see db.Transaction.Iterator.Value
+func (i *iterator) Value() ([]byte, error) {
+	if !i.Valid() {
+		return nil, nil
+	}
+	return append([]byte{}, i.entries[i.pos].value...), nil
+}
+
+// Next : see db.Transaction.Iterator.Next
+func (i *iterator) Next() bool {
+	if !i.positioned {
+		i.positioned = true
+		i.pos = 0
+	} else if i.Valid() {
+		i.pos++
+	}
+	return i.Valid()
+}
+
+// Seek : see db.Transaction.Iterator.Seek
+func (i *iterator) Seek(key []byte) bool {
+	i.positioned = true
+	i.pos = search(i.entries, key)
+	return i.Valid()
+}
+
+// Prev : see db.Transaction.Iterator.Prev
+func (i *iterator) Prev() bool {
+	if !i.positioned {
+		i.positioned = true
+		i.pos = len(i.entries) - 1
+	} else if i.Valid() {
+		i.pos--
+	}
+	return i.Valid()
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *iterator) SeekLast(prefix []byte) bool {
+	i.positioned = true
+	i.pos = len(i.entries) - 1
+	if end := db.PrefixEnd(prefix); end != nil {
+		i.pos = search(i.entries, end) - 1
+	}
+	if i.Valid() && !bytes.HasPrefix(i.entries[i.pos].key, prefix) {
+		i.pos = -1
+	}
+	return i.Valid()
+}
+
+// Close : see db.Transaction.Iterator.Close
+func (i *iterator) Close() error {
+	i.entries = nil
+	return nil
+}
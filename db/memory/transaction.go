@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var ErrDiscardedTransaction = errors.New("discarded txn")
+
+var (
+	_ db.Transaction  = (*Transaction)(nil)
+	_ db.PendingSizer = (*Transaction)(nil)
+)
+
+type Transaction struct {
+	db       *DB
+	snapshot []entry
+	// writes holds the keys set or deleted by an update transaction until it is committed, it is nil for read-only
+	// transactions
+	writes     map[string][]byte
+	pendingLen uint64
+	discarded  bool
+}
+
+// Discard : see db.Transaction.Discard
+func (t *Transaction) Discard() error {
+	if t.discarded {
+		return nil
+	}
+	t.discarded = true
+	t.snapshot = nil
+	if t.writes != nil {
+		t.writes = nil
+		t.db.wLockedAt.Store(0)
+		t.db.wMutex.Unlock()
+	}
+	return nil
+}
+
+// Commit : see db.Transaction.Commit
+func (t *Transaction) Commit() error {
+	if t.discarded || t.writes == nil {
+		return db.CloseAndWrapOnError(t.Discard, ErrDiscardedTransaction)
+	}
+	return db.CloseAndWrapOnError(t.Discard, t.db.commit(t.snapshot, t.writes))
+}
+
+// PendingSize : see db.PendingSizer.PendingSize, it is 0 for read-only transactions
+func (t *Transaction) PendingSize() uint64 {
+	return t.pendingLen
+}
+
+// Set : see db.Transaction.Set
+func (t *Transaction) Set(key, val []byte) error {
+	if err := t.writable(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return errors.New("empty key")
+	}
+	t.writes[string(key)] = append([]byte{}, val...)
+	t.pendingLen += uint64(len(key) + len(val))
+	return nil
+}
+
+// Delete : see db.Transaction.Delete
+func (t *Transaction) Delete(key []byte) error {
+	if err := t.writable(); err != nil {
+		return err
+	}
+	t.writes[string(key)] = nil
+	t.pendingLen += uint64(len(key))
+	return nil
+}
+
+// DeletePrefix : see db.Transaction.DeletePrefix, the keys are deleted one by one
+func (t *Transaction) DeletePrefix(prefix []byte) error {
+	if err := t.writable(); err != nil {
+		return err
+	}
+	return db.DeletePrefixByKey(t, prefix)
+}
+
+// MultiGet : see db.Transaction.MultiGet, the keys are read one by one
+func (t *Transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	return db.MultiGetByKey(t, keys, cb)
+}
+
+// Get : see db.Transaction.Get
+func (t *Transaction) Get(key []byte, cb func([]byte) error) error {
+	if err := t.readable(); err != nil {
+		return err
+	}
+	if value, found := t.writes[string(key)]; found {
+		if value == nil {
+			return db.ErrKeyNotFound
+		}
+		return cb(value)
+	}
+	if i := search(t.snapshot, key); i < len(t.snapshot) && string(t.snapshot[i].key) == string(key) {
+		return cb(t.snapshot[i].value)
+	}
+	return db.ErrKeyNotFound
+}
+
+// Impl : see db.Transaction.Impl
+func (t *Transaction) Impl() any {
+	return t
+}
+
+// NewIterator : see db.Transaction.NewIterator. The iterator does not see the writes of the transaction that
+// follow its creation.
+func (t *Transaction) NewIterator() (db.Iterator, error) {
+	return t.NewIteratorWithBounds(nil, nil)
+}
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds
+func (t *Transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	if err := t.readable(); err != nil {
+		return nil, err
+	}
+	entries := t.snapshot
+	if len(t.writes) > 0 {
+		entries = merge(t.snapshot, t.writes)
+	}
+	if upper != nil {
+		entries = entries[:search(entries, upper)]
+	}
+	if lower != nil {
+		entries = entries[search(entries, lower):]
+	}
+	return &iterator{entries: entries, pos: -1}, nil
+}
+
+func (t *Transaction) readable() error {
+	if t.discarded {
+		return ErrDiscardedTransaction
+	}
+	if t.db.closed.Load() {
+		return ErrClosed
+	}
+	return nil
+}
+
+func (t *Transaction) writable() error {
+	if err := t.readable(); err != nil {
+		return err
+	}
+	if t.writes == nil {
+		return errors.New("read only transaction")
+	}
+	return nil
+}
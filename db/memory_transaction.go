@@ -2,6 +2,7 @@ package db
 
 import (
 	"errors"
+	"strings"
 )
 
 var _ Transaction = (*memTransaction)(nil)
@@ -18,6 +19,10 @@ func (t *memTransaction) NewIterator() (Iterator, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (t *memTransaction) NewIteratorWithBounds(lower, upper []byte) (Iterator, error) {
+	return t.NewIterator()
+}
+
 func (t *memTransaction) Discard() error {
 	t.storage = make(map[string][]byte)
 	return nil
@@ -37,6 +42,15 @@ func (t *memTransaction) Delete(key []byte) error {
 	return nil
 }
 
+func (t *memTransaction) DeletePrefix(prefix []byte) error {
+	for key := range t.storage {
+		if strings.HasPrefix(key, string(prefix)) {
+			delete(t.storage, key)
+		}
+	}
+	return nil
+}
+
 func (t *memTransaction) Get(key []byte, cb func([]byte) error) error {
 	value, found := t.storage[string(key)]
 	if !found {
@@ -45,6 +59,10 @@ func (t *memTransaction) Get(key []byte, cb func([]byte) error) error {
 	return cb(value)
 }
 
+func (t *memTransaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	return MultiGetByKey(t, keys, cb)
+}
+
 func (t *memTransaction) Impl() any {
 	return t.storage
 }
@@ -0,0 +1,250 @@
+package pebble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/record"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+func init() {
+	db.RegisterReplayer(db.DefaultBackend, ReplayArchive)
+}
+
+// commitTimePrefix starts the log data record each commit of a database that archives its write-ahead logs is
+// stamped with, which is followed by the unix nano time of the commit
+var commitTimePrefix = []byte("juno commit time ")
+
+// walSuffix is the suffix of the names of the write-ahead logs, which are named after their file numbers
+const walSuffix = ".log"
+
+// walArchiver moves the write-ahead logs to its directory once they are obsolete instead of deleting them, see
+// db.Options.WALArchiveDir. It embeds pebble.ArchiveCleaner so that pebble does not recycle the logs, which would
+// reuse them before they are archived. T is the type of the files of pebble, which is internal to it, see
+// newWALArchiver.
+type walArchiver[T any] struct {
+	pebble.ArchiveCleaner
+	dir string
+}
+
+// newWALArchiver returns the walArchiver to dir of the files of the type of the files of clean
+func newWALArchiver[T any](_ func(vfs.FS, T, string) error, dir string) walArchiver[T] {
+	return walArchiver[T]{dir: dir}
+}
+
+// Clean : see pebble.Cleaner.Clean. The other files are deleted. The logs are copied when the archive is on another
+// filesystem, under a temporary name so that the archive never holds part of a log.
+func (a walArchiver[T]) Clean(fs vfs.FS, _ T, path string) error {
+	if !strings.HasSuffix(path, walSuffix) {
+		return fs.Remove(path)
+	}
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return err
+	}
+	archived := filepath.Join(a.dir, filepath.Base(path))
+	if _, err := os.Stat(archived); err == nil {
+		return fmt.Errorf("%s is already archived", archived)
+	}
+	if err := os.Rename(path, archived); err == nil {
+		return nil
+	}
+
+	tmp := archived + ".tmp"
+	if err := vfs.Copy(fs, path, tmp); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	if err := os.Rename(tmp, archived); err != nil {
+		return err
+	}
+	return fs.Remove(path)
+}
+
+// String : see fmt.Stringer.String
+func (a walArchiver[T]) String() string {
+	return "archive to " + a.dir
+}
+
+// stampCommit adds the time of the commit of batch to its write-ahead log record, which ReplayArchive replays the
+// commits up to a point in time with
+func stampCommit(batch *pebble.Batch) error {
+	return batch.LogData(binary.BigEndian.AppendUint64(bytes.Clone(commitTimePrefix), uint64(time.Now().UnixNano())),
+		nil)
+}
+
+// commitTime returns the time batch was stamped with by stampCommit, if it was
+func commitTime(batch *pebble.Batch) (time.Time, bool) {
+	reader := batch.Reader()
+	for {
+		kind, data, _, ok := reader.Next()
+		if !ok {
+			return time.Time{}, false
+		}
+		if kind == pebble.InternalKeyKindLogData && len(data) == len(commitTimePrefix)+8 &&
+			bytes.HasPrefix(data, commitTimePrefix) {
+			return time.Unix(0, int64(binary.BigEndian.Uint64(data[len(commitTimePrefix):]))), true
+		}
+	}
+}
+
+// ReplayArchive replays onto the database at path the commits of the write-ahead logs archived in archive that were
+// made up to point, see db.Replayer. The logs are replayed from the first log of the database, which the commits of
+// the database that were not flushed are replayed from, so that the commits of the database are replayed again and
+// leave it as it is. The logs have to follow each other without a gap, which is checked with the sequence numbers of
+// their commits.
+func ReplayArchive(path, archive string, point time.Time) (time.Time, error) {
+	logs, err := listWALs(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(logs) == 0 {
+		return time.Time{}, errors.New("the database has no write-ahead log")
+	}
+	// the time of the database is that of the last commit of its logs
+	var restoredTo time.Time
+	for _, log := range logs {
+		if err = readWAL(filepath.Join(path, log.name), log.num, func(batch *pebble.Batch) (bool, error) {
+			if stamp, ok := commitTime(batch); ok {
+				restoredTo = stamp
+			}
+			return true, nil
+		}); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if restoredTo.After(point) {
+		return time.Time{}, fmt.Errorf("the database was committed to at %s, after %s", restoredTo, point)
+	}
+
+	archived, err := listWALs(archive)
+	if err != nil {
+		return time.Time{}, err
+	}
+	first := sort.Search(len(archived), func(i int) bool {
+		return archived[i].num >= logs[0].num
+	})
+	archived = archived[first:]
+	if len(archived) == 0 {
+		// the first log of the database is not archived yet
+		return restoredTo, nil
+	}
+	if archived[0].num != logs[0].num {
+		return time.Time{}, fmt.Errorf("log %s of the database is not archived", logs[0].name)
+	}
+
+	pDB, err := pebble.Open(path, &pebble.Options{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nextSeqNum uint64
+	// reached is set once a commit after point is read
+	var reached bool
+	for _, log := range archived {
+		err = readWAL(filepath.Join(archive, log.name), log.num, func(batch *pebble.Batch) (bool, error) {
+			if nextSeqNum != 0 && batch.SeqNum() != nextSeqNum {
+				return false, fmt.Errorf("commits are missing before log %s", log.name)
+			}
+			nextSeqNum = batch.SeqNum() + uint64(batch.Count())
+			stamp, ok := commitTime(batch)
+			if ok && stamp.After(point) {
+				reached = true
+				return false, nil
+			}
+			if err := pDB.Apply(batch, pebble.Sync); err != nil {
+				return false, err
+			}
+			if ok {
+				restoredTo = stamp
+			}
+			return true, nil
+		})
+		if err != nil || reached {
+			break
+		}
+	}
+	return restoredTo, errors.Join(err, pDB.Close())
+}
+
+// archivedWAL is a write-ahead log found by listWALs
+type archivedWAL struct {
+	name string
+	num  pebble.FileNum
+}
+
+// listWALs returns the write-ahead logs in dir, sorted by file number
+func listWALs(dir string) ([]archivedWAL, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var logs []archivedWAL
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, walSuffix) {
+			continue
+		}
+		num, err := strconv.ParseUint(strings.TrimSuffix(name, walSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		logs = append(logs, archivedWAL{name: name, num: pebble.FileNum(num)})
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].num < logs[j].num
+	})
+	return logs, nil
+}
+
+// readWAL calls fn with the batches of the write-ahead log at path until it returns false. The log ends at the
+// first record that is torn, like when pebble replays it.
+func readWAL(path string, num pebble.FileNum, fn func(batch *pebble.Batch) (bool, error)) error {
+	_, err := readWALRecords(path, num, func(repr []byte) (bool, error) {
+		batch := new(pebble.Batch)
+		if err := batch.SetRepr(repr); err != nil {
+			return false, fmt.Errorf("read %s: %w", path, err)
+		}
+		return fn(batch)
+	})
+	return err
+}
+
+// readWALRecords calls fn with the records of the write-ahead log at path until it returns false, and returns
+// whether the log ended with a torn record rather than at the end of the file
+func readWALRecords(path string, num pebble.FileNum, fn func(repr []byte) (bool, error)) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	reader := record.NewReader(file, num)
+	for {
+		r, err := reader.Next()
+		var repr []byte
+		if err == nil {
+			repr, err = io.ReadAll(r)
+		}
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		} else if record.IsInvalidRecord(err) {
+			return true, nil
+		} else if err != nil {
+			return false, fmt.Errorf("read %s: %w", path, err)
+		}
+
+		if next, err := fn(repr); err != nil || !next {
+			return false, err
+		}
+	}
+}
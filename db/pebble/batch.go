@@ -0,0 +1,93 @@
+package pebble
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/cockroachdb/pebble"
+)
+
+var (
+	_ db.Batcher = (*DB)(nil)
+	_ db.Batch   = (*Batch)(nil)
+)
+
+// Batch is a write-only batch of the database, which unlike a Transaction does not index its writes nor hold the
+// write lock until it is committed
+type Batch struct {
+	db    *DB
+	batch *pebble.Batch
+}
+
+// NewBatch : see db.Batcher.NewBatch
+func (d *DB) NewBatch() db.Batch {
+	return &Batch{db: d, batch: d.pebble.NewBatch()}
+}
+
+// Set : see db.Batch.Set
+func (b *Batch) Set(key, val []byte) error {
+	if b.batch == nil {
+		return db.ErrBatchDone
+	}
+	if len(key) == 0 {
+		return errors.New("empty key")
+	}
+
+	if b.db.writeCounter != nil {
+		b.db.writeCounter.Inc()
+	}
+	return b.batch.Set(key, b.db.checksums.Seal(key, b.db.compression.Compress(key, val)), nil)
+}
+
+// Delete : see db.Batch.Delete
+func (b *Batch) Delete(key []byte) error {
+	if b.batch == nil {
+		return db.ErrBatchDone
+	}
+
+	if b.db.writeCounter != nil {
+		b.db.writeCounter.Inc()
+	}
+	return b.batch.Delete(key, nil)
+}
+
+// Size : see db.Batch.Size
+func (b *Batch) Size() uint64 {
+	if b.batch == nil {
+		return 0
+	}
+	return uint64(b.batch.Len())
+}
+
+// Commit : see db.Batch.Commit. The write lock is held while the batch is committed, so that it is not applied in
+// the middle of an update transaction.
+func (b *Batch) Commit() error {
+	if b.batch == nil {
+		return db.ErrBatchDone
+	}
+	b.db.wMutex.Lock()
+	start := time.Now()
+	b.db.wLockedAt.Store(start.UnixNano())
+	var err error
+	if b.db.stampCommits {
+		err = stampCommit(b.batch)
+	}
+	if err == nil {
+		err = b.batch.Commit(pebble.Sync)
+	}
+	b.db.wLockedAt.Store(0)
+	b.db.meter.commitLatency.Observe(time.Since(start).Seconds())
+	b.db.wMutex.Unlock()
+	return db.CloseAndWrapOnError(b.Discard, err)
+}
+
+// Discard : see db.Batch.Discard
+func (b *Batch) Discard() error {
+	if b.batch == nil {
+		return nil
+	}
+	err := b.batch.Close()
+	b.batch = nil
+	return err
+}
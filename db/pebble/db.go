@@ -1,7 +1,11 @@
 package pebble
 
 import (
+	"bytes"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/metrics"
@@ -10,32 +14,87 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var _ db.DB = (*DB)(nil)
+var (
+	_ db.DB        = (*DB)(nil)
+	_ db.Estimator = (*DB)(nil)
+)
+
+func init() {
+	db.Register(db.DefaultBackend, func(path string, opts db.Options) (db.DB, error) {
+		if opts.Namespace == "" {
+			opts.Namespace = "db"
+		}
+		return open(path, opts)
+	})
+}
 
 type DB struct {
 	pebble *pebble.DB
 	wMutex *sync.Mutex
+	// wLockedAt is the unix nano time the write lock was acquired at, or 0 if it is not held
+	wLockedAt *atomic.Int64
+
+	// compression is the set of buckets whose values are compressed when they are written, the one the database
+	// records
+	compression db.Compression
+	// checksums are the buckets whose values are checksummed, those the database records, see db.Checksums
+	checksums db.Checksums
+	// flushOnClose flushes the memtables when the database is closed, so that its write-ahead log is empty when it
+	// is kept in a directory of its own, which the tools that open the database without the tuning of the node do
+	// not replay
+	flushOnClose bool
+	// stampCommits stamps the commits with their time in the write-ahead log when it is archived, see ReplayArchive
+	stampCommits bool
 
 	// metrics
 	readCounter  prometheus.Counter
 	writeCounter prometheus.Counter
+	meter        *meter
 }
 
-// New opens a new database at the given path
-func New(path string, logger pebble.Logger) (db.DB, error) {
-	pDB, err := newPebble(path, &pebble.Options{
-		Logger: logger,
-	})
+// New opens a new database at the given path with a block cache of cacheSize bytes
+func New(path string, cacheSize uint64, logger pebble.Logger) (db.DB, error) {
+	return NewNamespaced(path, cacheSize, logger, "db")
+}
+
+// NewNamespaced opens a new database like New, with its metrics in namespace so that several databases can be
+// opened by a node. A rewrite of the database that was interrupted is completed first, see FinishRewrite.
+func NewNamespaced(path string, cacheSize uint64, logger pebble.Logger, namespace string) (db.DB, error) {
+	return open(path, db.Options{CacheSize: cacheSize, Namespace: namespace, Logger: logger})
+}
+
+// open opens the database at path with the options of a backend, see db.Register
+func open(path string, opts db.Options) (*DB, error) {
+	if err := FinishRewrite(path); err != nil {
+		return nil, fmt.Errorf("finish the rewrite of the database: %w", err)
+	}
+	cache := pebble.NewCache(int64(opts.CacheSize))
+	// the DB holds its own reference to the cache
+	defer cache.Unref()
+	pebbleOpts := &pebble.Options{
+		Logger: opts.Logger,
+		Cache:  cache,
+	}
+	tune(pebbleOpts, opts.Tuning)
+	if opts.WALArchiveDir != "" {
+		pebbleOpts.Cleaner = newWALArchiver(pebble.DeleteCleaner{}.Clean, opts.WALArchiveDir)
+	}
+	pDB, err := newPebble(path, pebbleOpts, opts.Namespace)
 	if err != nil {
 		return nil, err
 	}
+	if err = pDB.openEncoding(opts); err != nil {
+		return nil, db.CloseAndWrapOnError(pDB.pebble.Close, fmt.Errorf("reencode the values: %w", err))
+	}
+	pDB.flushOnClose = opts.Tuning.WALDir != ""
+	pDB.stampCommits = opts.WALArchiveDir != ""
 
 	pDB.readCounter = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: "db",
+		Namespace: opts.Namespace,
 		Name:      "read",
 	})
 	pDB.writeCounter = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: "db",
+		Namespace: opts.Namespace,
 		Name:      "write",
 	})
 	metrics.MustRegister(pDB.readCounter, pDB.writeCounter)
@@ -43,11 +102,29 @@ func New(path string, logger pebble.Logger) (db.DB, error) {
 	return pDB, nil
 }
 
+// tune sets the settings of tuning that are not zero on pebbleOpts
+func tune(pebbleOpts *pebble.Options, tuning db.Tuning) {
+	if tuning.MemTableSize > 0 {
+		pebbleOpts.MemTableSize = int(tuning.MemTableSize)
+	}
+	if tuning.MaxOpenFiles > 0 {
+		pebbleOpts.MaxOpenFiles = int(tuning.MaxOpenFiles)
+	}
+	if tuning.CompactionConcurrency > 0 {
+		concurrency := int(tuning.CompactionConcurrency)
+		pebbleOpts.MaxConcurrentCompactions = func() int {
+			return concurrency
+		}
+	}
+	pebbleOpts.WALDir = tuning.WALDir
+	pebbleOpts.WALBytesPerSync = int(tuning.WALBytesPerSync)
+}
+
 // NewMem opens a new in-memory database
 func NewMem() (db.DB, error) {
 	return newPebble("", &pebble.Options{
 		FS: vfs.NewMem(),
-	})
+	}, "db")
 }
 
 // NewMemTest opens a new in-memory database, panics on error
@@ -59,23 +136,31 @@ func NewMemTest() db.DB {
 	return memDB
 }
 
-func newPebble(path string, options *pebble.Options) (*DB, error) {
+// newPebble opens the database at path, with the metrics of its meter in namespace
+func newPebble(path string, options *pebble.Options, namespace string) (*DB, error) {
 	pDB, err := pebble.Open(path, options)
 	if err != nil {
 		return nil, err
 	}
-	return &DB{pebble: pDB, wMutex: new(sync.Mutex)}, nil
+	return &DB{pebble: pDB, wMutex: new(sync.Mutex), wLockedAt: new(atomic.Int64), meter: newMeter(pDB, namespace)}, nil
 }
 
 // NewTransaction : see db.DB.NewTransaction
 func (d *DB) NewTransaction(update bool) db.Transaction {
 	txn := &Transaction{
-		readCounter:  d.readCounter,
-		writeCounter: d.writeCounter,
+		compression:   d.compression,
+		checksums:     d.checksums,
+		stampCommits:  d.stampCommits,
+		readCounter:   d.readCounter,
+		writeCounter:  d.writeCounter,
+		readLatency:   d.meter.readLatency,
+		commitLatency: d.meter.commitLatency,
 	}
 	if update {
 		d.wMutex.Lock()
+		d.wLockedAt.Store(time.Now().UnixNano())
 		txn.lock = d.wMutex
+		txn.lockedAt = d.wLockedAt
 		txn.batch = d.pebble.NewIndexedBatch()
 	} else {
 		txn.snapshot = d.pebble.NewSnapshot()
@@ -84,8 +169,23 @@ func (d *DB) NewTransaction(update bool) db.Transaction {
 	return txn
 }
 
+// WriteLockHeld returns for how long the write transaction that is in progress has been held, or 0 if
+// there is none. A write transaction that is held for long blocks every other write to the database.
+func (d *DB) WriteLockHeld() time.Duration {
+	lockedAt := d.wLockedAt.Load()
+	if lockedAt == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, lockedAt))
+}
+
 // Close : see io.Closer.Close
 func (d *DB) Close() error {
+	if d.flushOnClose {
+		if err := d.pebble.Flush(); err != nil {
+			return db.CloseAndWrapOnError(d.pebble.Close, err)
+		}
+	}
 	return d.pebble.Close()
 }
 
@@ -108,3 +208,61 @@ func (d *DB) Update(fn func(txn db.Transaction) error) error {
 func (d *DB) Impl() any {
 	return d.pebble
 }
+
+// BucketSize returns an estimate of the disk space used by the keys of bucket. Keys that were not flushed
+// to disk yet are not counted.
+func (d *DB) BucketSize(bucket db.Bucket) (uint64, error) {
+	return d.RangeSize(bucket.Key(), (bucket + 1).Key())
+}
+
+// RangeSize returns an estimate of the disk space used by the keys from start, inclusive, to end, exclusive.
+// Keys that were not flushed to disk yet are not counted.
+func (d *DB) RangeSize(start, end []byte) (uint64, error) {
+	return d.pebble.EstimateDiskUsage(start, end)
+}
+
+// RangeKeyCount returns an estimate of the number of keys from start, inclusive, to end, exclusive, from their
+// disk space and the average size of the entries of the tables they are in. Keys that were not flushed to disk yet
+// are not counted.
+func (d *DB) RangeKeyCount(start, end []byte) (uint64, error) {
+	size, err := d.RangeSize(start, end)
+	if err != nil || size == 0 {
+		return 0, err
+	}
+	levels, err := d.pebble.SSTables(pebble.WithProperties())
+	if err != nil {
+		return 0, err
+	}
+
+	var entries, tablesSize uint64
+	for _, tables := range levels {
+		for i := range tables {
+			table := &tables[i]
+			if bytes.Compare(table.Largest.UserKey, start) < 0 || bytes.Compare(table.Smallest.UserKey, end) >= 0 {
+				continue
+			}
+			entries += table.Properties.NumEntries
+			tablesSize += table.Size
+		}
+	}
+	if tablesSize == 0 {
+		return 0, nil
+	}
+	return uint64(float64(size) * float64(entries) / float64(tablesSize)), nil
+}
+
+// DiskUsage returns the disk space used by the database, including its logs
+func (d *DB) DiskUsage() uint64 {
+	return d.pebble.Metrics().DiskSpaceUsage()
+}
+
+// Backup : see db.DB.Backup. The backup is a checkpoint of the database, see Checkpoint.
+func (d *DB) Backup(dir string) error {
+	return d.Checkpoint(dir)
+}
+
+// Checkpoint writes a consistent copy of the database to dir, which must not exist. Immutable files are
+// hard linked when dir is on the same filesystem, so it is cheap to create.
+func (d *DB) Checkpoint(dir string) error {
+	return d.pebble.Checkpoint(dir, pebble.WithFlushedWAL())
+}
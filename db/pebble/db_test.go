@@ -1,13 +1,25 @@
 package pebble_test
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/dbtest"
 	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	pebbledb "github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/record"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -391,3 +403,434 @@ func TestNext(t *testing.T) {
 		require.NoError(t, it.Close())
 	})
 }
+
+func TestWriteLockHeld(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	pebbleDB := testDB.(*pebble.DB)
+	assert.Zero(t, pebbleDB.WriteLockHeld())
+
+	readTxn := testDB.NewTransaction(false)
+	assert.Zero(t, pebbleDB.WriteLockHeld())
+	require.NoError(t, readTxn.Discard())
+
+	txn := testDB.NewTransaction(true)
+	time.Sleep(time.Millisecond)
+	assert.GreaterOrEqual(t, pebbleDB.WriteLockHeld(), time.Millisecond)
+	require.NoError(t, txn.Discard())
+	assert.Zero(t, pebbleDB.WriteLockHeld())
+}
+
+func TestMeter(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		return txn.Set([]byte("key"), []byte("value"))
+	}))
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		return txn.Get([]byte("key"), func([]byte) error { return nil })
+	}))
+	batch := db.NewBatch(testDB)
+	require.NoError(t, batch.Set([]byte("key"), []byte("value")))
+	require.NoError(t, batch.Commit())
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(testDB.(db.Meterer).Meter()))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	got := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		got[family.GetName()] = family
+	}
+
+	for _, name := range []string{
+		"db_compaction_debt_bytes", "db_compactions_in_progress", "db_block_cache_hits_total",
+		"db_block_cache_misses_total", "db_block_cache_hit_ratio",
+	} {
+		assert.Contains(t, got, name)
+	}
+	assert.Equal(t, uint64(1), got["db_read_latency_seconds"].GetMetric()[0].GetHistogram().GetSampleCount())
+	assert.Equal(t, uint64(2), got["db_commit_latency_seconds"].GetMetric()[0].GetHistogram().GetSampleCount())
+	// a size and a number of tables for each level
+	assert.Len(t, got["db_level_size_bytes"].GetMetric(), 7)
+	assert.Len(t, got["db_level_files"].GetMetric(), 7)
+}
+
+func TestRangeKeyCount(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	estimator := testDB.(db.Estimator)
+
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := uint64(0); i < 1000; i++ {
+			for _, bucket := range []db.Bucket{db.StateTrie, db.ClassesTrie} {
+				if err := txn.Set(bucket.Key(binary.BigEndian.AppendUint64(nil, i)), make([]byte, 64)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}))
+	count, err := estimator.RangeKeyCount(db.StateTrie.Key(), (db.StateTrie + 1).Key())
+	require.NoError(t, err)
+	assert.Zero(t, count, "the keys are not flushed to disk yet")
+
+	require.NoError(t, testDB.Impl().(*pebbledb.DB).Flush())
+	count, err = estimator.RangeKeyCount(db.StateTrie.Key(), (db.StateTrie + 1).Key())
+	require.NoError(t, err)
+	assert.InEpsilon(t, 1000, count, 0.5)
+	count, err = estimator.RangeKeyCount(db.BackgroundMigrations.Key(), (db.BackgroundMigrations + 1).Key())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestCompactRange(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	estimator := testDB.(db.Estimator)
+	keyCount := func(bucket db.Bucket) uint64 {
+		t.Helper()
+		require.NoError(t, testDB.Impl().(*pebbledb.DB).Flush())
+		count, err := estimator.RangeKeyCount(bucket.Key(), (bucket + 1).Key())
+		require.NoError(t, err)
+		return count
+	}
+
+	for _, del := range []bool{false, true} {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			for i := uint64(0); i < 1000; i++ {
+				if del {
+					if err := txn.Delete(db.StateTrie.Key(binary.BigEndian.AppendUint64(nil, i))); err != nil {
+						return err
+					}
+					continue
+				}
+				for _, bucket := range []db.Bucket{db.StateTrie, db.ClassesTrie} {
+					if err := txn.Set(bucket.Key(binary.BigEndian.AppendUint64(nil, i)), make([]byte, 64)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}))
+		require.NoError(t, testDB.Impl().(*pebbledb.DB).Flush())
+	}
+
+	require.NoError(t, db.CompactPrefix(testDB, db.StateTrie.Key()))
+	assert.Zero(t, keyCount(db.StateTrie))
+	assert.InEpsilon(t, 1000, keyCount(db.ClassesTrie), 0.5)
+
+	// empty ranges and ranges up to the last key are compacted too
+	require.NoError(t, testDB.CompactRange(db.Class.Key(), db.Class.Key()))
+	require.NoError(t, testDB.CompactRange(nil, nil))
+	assert.InEpsilon(t, 1000, keyCount(db.ClassesTrie), 0.5)
+}
+
+func TestRewrite(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	testDB, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := uint64(0); i < 100; i++ {
+			if err := txn.Set(binary.BigEndian.AppendUint64(nil, i), []byte("old")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := uint64(0); i < 100; i += 2 {
+			if err := txn.Set(binary.BigEndian.AppendUint64(nil, i), []byte("new")); err != nil {
+				return err
+			}
+			if err := txn.Delete(binary.BigEndian.AppendUint64(nil, i+1)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, testDB.Close())
+
+	assertKeys := func(t *testing.T) {
+		t.Helper()
+		testDB, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, testDB.Close())
+		}()
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			for i := uint64(0); i < 100; i += 2 {
+				require.NoError(t, txn.Get(binary.BigEndian.AppendUint64(nil, i), func(val []byte) error {
+					assert.Equal(t, []byte("new"), val)
+					return nil
+				}))
+				require.ErrorIs(t, txn.Get(binary.BigEndian.AppendUint64(nil, i+1), noop), db.ErrKeyNotFound)
+			}
+			return nil
+		}))
+	}
+
+	before, after, err := pebble.Rewrite(dbPath, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	assert.Positive(t, before)
+	assert.Positive(t, after)
+	assertKeys(t)
+	assert.NoDirExists(t, dbPath+".rewrite")
+	assert.NoDirExists(t, dbPath+".replaced")
+
+	t.Run("interrupted before the copy is complete", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(dbPath+".rewrite", 0o755))
+		assertKeys(t)
+		_, _, err := pebble.Rewrite(dbPath, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		assert.NoDirExists(t, dbPath+".rewrite")
+		assertKeys(t)
+	})
+
+	t.Run("interrupted while the copy replaces the database", func(t *testing.T) {
+		require.NoError(t, os.Rename(dbPath, dbPath+".rewrite"))
+		require.NoError(t, os.MkdirAll(dbPath+".replaced", 0o755))
+		assertKeys(t)
+		assert.NoDirExists(t, dbPath+".rewrite")
+		assert.NoDirExists(t, dbPath+".replaced")
+	})
+
+	t.Run("missing database", func(t *testing.T) {
+		_, _, err := pebble.Rewrite(filepath.Join(t.TempDir(), "missing"), utils.NewNopZapLogger())
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestTuning(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	walDir := filepath.Join(t.TempDir(), "wal")
+	testDB, err := db.Open(db.DefaultBackend, dbPath, db.Options{
+		CacheSize: 1 << 20,
+		Tuning: db.Tuning{
+			MemTableSize:          4 << 20,
+			MaxOpenFiles:          100,
+			CompactionConcurrency: 2,
+			WALDir:                walDir,
+			WALBytesPerSync:       1 << 20,
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		return txn.Set([]byte("key"), []byte("value"))
+	}))
+	logs, err := filepath.Glob(filepath.Join(walDir, "*.log"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, logs)
+	require.NoError(t, testDB.Close())
+
+	// the memtables are flushed on close, so the database opens without the directory of its write-ahead log
+	testDB, err = pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		return txn.Get([]byte("key"), func(val []byte) error {
+			assert.Equal(t, []byte("value"), val)
+			return nil
+		})
+	}))
+}
+
+func TestWALArchive(t *testing.T) {
+	dbPath, archive := filepath.Join(t.TempDir(), "juno"), filepath.Join(t.TempDir(), "archive")
+	open := func() db.DB {
+		testDB, err := db.Open(db.DefaultBackend, dbPath, db.Options{CacheSize: 1 << 20, WALArchiveDir: archive})
+		require.NoError(t, err)
+		return testDB
+	}
+	set := func(testDB db.DB, key string) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set([]byte(key), []byte(key))
+		}))
+	}
+
+	testDB := open()
+	set(testDB, "before backup")
+	var backup bytes.Buffer
+	require.NoError(t, db.WriteBackup(testDB, &backup, t.TempDir()))
+	set(testDB, "after backup")
+	point := time.Now()
+	batch := testDB.(db.Batcher).NewBatch()
+	require.NoError(t, batch.Set([]byte("after point"), []byte("after point")))
+	require.NoError(t, batch.Commit())
+	require.NoError(t, testDB.Close())
+	// the write-ahead log is obsolete once it is replayed and flushed when the database opens
+	require.NoError(t, open().Close())
+
+	// keys returns the keys of the database at path
+	keys := func(path string) []string {
+		restored, err := pebble.New(path, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, restored.Close())
+		}()
+		var keys []string
+		require.NoError(t, restored.View(func(txn db.Transaction) error {
+			return db.IteratePrefix(txn, nil, func(key, _ []byte) error {
+				keys = append(keys, string(key))
+				return nil
+			})
+		}))
+		return keys
+	}
+
+	t.Run("restore to a point in time", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "restored")
+		restoredTo, err := db.RestoreToPoint(db.DefaultBackend, bytes.NewReader(backup.Bytes()), archive, path, point)
+		require.NoError(t, err)
+		assert.False(t, restoredTo.After(point))
+		assert.Equal(t, []string{"after backup", "before backup"}, keys(path))
+	})
+
+	t.Run("restore to the last archived commit", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "restored")
+		restoredTo, err := db.RestoreToPoint(db.DefaultBackend, bytes.NewReader(backup.Bytes()), archive, path,
+			time.Now())
+		require.NoError(t, err)
+		assert.True(t, restoredTo.After(point))
+		assert.Equal(t, []string{"after backup", "after point", "before backup"}, keys(path))
+	})
+
+	t.Run("point before the backup", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "restored")
+		_, err := db.RestoreToPoint(db.DefaultBackend, bytes.NewReader(backup.Bytes()), archive, path,
+			time.Unix(0, 0))
+		require.Error(t, err)
+		assert.NoDirExists(t, path)
+	})
+
+	t.Run("missing logs", func(t *testing.T) {
+		logs, err := filepath.Glob(filepath.Join(archive, "*.log"))
+		require.NoError(t, err)
+		require.NotEmpty(t, logs)
+		emptyArchive := t.TempDir()
+		path := filepath.Join(t.TempDir(), "restored")
+		restoredTo, err := db.RestoreToPoint(db.DefaultBackend, bytes.NewReader(backup.Bytes()), emptyArchive, path,
+			time.Now())
+		require.NoError(t, err)
+		assert.False(t, restoredTo.After(point), "only the commits of the backup are restored")
+		assert.Equal(t, []string{"before backup"}, keys(path))
+	})
+}
+
+func TestRepair(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db")
+	require.NoError(t, os.Mkdir(path, 0o755))
+	// writeLog writes a log of records, followed by a torn record if torn is set
+	writeLog := func(name string, torn bool, records ...string) {
+		var buf bytes.Buffer
+		writer := record.NewWriter(&buf)
+		for _, r := range records {
+			w, err := writer.Next()
+			require.NoError(t, err)
+			_, err = w.Write([]byte(r))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+		if torn {
+			buf.WriteString("a torn record")
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(path, name), buf.Bytes(), 0o600))
+	}
+	readLog := func(path string, num pebbledb.FileNum) []string {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+		var records []string
+		reader := record.NewReader(file, num)
+		for {
+			r, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				return records
+			}
+			require.NoError(t, err)
+			repr, err := io.ReadAll(r)
+			require.NoError(t, err)
+			records = append(records, string(repr))
+		}
+	}
+
+	writeLog("000001.log", false, "commit 1")
+	// the torn tail of the last log is dropped by pebble
+	writeLog("000003.log", true, "commit 4")
+	repairs, err := pebble.Repair(path)
+	require.NoError(t, err)
+	assert.Empty(t, repairs)
+
+	writeLog("000002.log", true, "commit 2", "commit 3")
+	repairs, err = pebble.Repair(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"truncated write-ahead log 000002.log after 2 commits",
+		"dropped write-ahead log 000003.log",
+		"the logs are kept in " + path + ".torn",
+	}, repairs)
+	assert.Equal(t, []string{"commit 2", "commit 3"}, readLog(filepath.Join(path, "000002.log"), 2))
+	assert.NoFileExists(t, filepath.Join(path, "000003.log"))
+	assert.FileExists(t, filepath.Join(path+".torn", "000002.log"))
+	assert.FileExists(t, filepath.Join(path+".torn", "000003.log"))
+}
+
+func TestReencodeResumes(t *testing.T) {
+	path := t.TempDir()
+	checksums, err := db.NewChecksums([]string{"trie"}, db.ChecksumFail)
+	require.NoError(t, err)
+	node := []byte("node")
+
+	// a rewrite that checksums the state trie was interrupted once it rewrote the nodes up to the second one
+	raw, err := pebbledb.Open(path, &pebbledb.Options{})
+	require.NoError(t, err)
+	for i := byte(1); i <= 4; i++ {
+		value := node
+		if i <= 2 {
+			value = checksums.Seal(db.StateTrie.Key([]byte{i}), node)
+		}
+		require.NoError(t, raw.Set(db.StateTrie.Key([]byte{i}), value, pebbledb.Sync))
+	}
+	record := make([]byte, 128)
+	// the state trie is the first bucket, the first bit of the checksummed buckets of the target encoding
+	record[64] = 1
+	record = append(record, db.StateTrie.Key([]byte{2})...)
+	require.NoError(t, raw.Set(db.ValueEncoding.Key(), record, pebbledb.Sync))
+	require.NoError(t, raw.Close())
+
+	// the rewrite is completed whatever the options the database is opened with
+	testDB, err := db.Open(db.DefaultBackend, path, db.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		return db.IterateBucket(txn, db.StateTrie, func(key, value []byte) error {
+			assert.Equal(t, node, value, "key %x", key)
+			return nil
+		})
+	}))
+	value, closer, err := testDB.Impl().(*pebbledb.DB).Get(db.StateTrie.Key([]byte{4}))
+	require.NoError(t, err)
+	assert.Equal(t, checksums.Seal(db.StateTrie.Key([]byte{4}), node), value)
+	require.NoError(t, closer.Close())
+}
+
+func TestConformance(t *testing.T) {
+	dbtest.TestBackend(t, func(t *testing.T) db.DB {
+		testDB, err := db.Open(db.DefaultBackend, t.TempDir(), db.Options{})
+		require.NoError(t, err)
+		return testDB
+	})
+}
@@ -0,0 +1,233 @@
+package pebble
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/cockroachdb/pebble"
+)
+
+// reencodeBatchSize is the size of the writes the values of the buckets whose encoding changes are rewritten in
+const reencodeBatchSize = 16 << 20
+
+// The sizes of a set of buckets as it is recorded, a bit per bucket, and of an encoding, its checksummed buckets
+// followed by its compressed buckets
+const (
+	bucketSetSize = 256 / 8
+	encodingSize  = 2 * bucketSetSize
+)
+
+// bucketSet is a set of buckets
+type bucketSet [256]bool
+
+func setOf(buckets []db.Bucket) bucketSet {
+	var set bucketSet
+	for _, bucket := range buckets {
+		set[bucket] = true
+	}
+	return set
+}
+
+// buckets returns the buckets of s, in order
+func (s *bucketSet) buckets() []db.Bucket {
+	var buckets []db.Bucket
+	for bucket, in := range s {
+		if in {
+			buckets = append(buckets, db.Bucket(bucket))
+		}
+	}
+	return buckets
+}
+
+func (s *bucketSet) marshal() []byte {
+	data := make([]byte, bucketSetSize)
+	for bucket, in := range s {
+		if in {
+			data[bucket/8] |= 1 << (bucket % 8)
+		}
+	}
+	return data
+}
+
+func (s *bucketSet) unmarshal(data []byte) {
+	for bucket := range s {
+		s[bucket] = data[bucket/8]&(1<<(bucket%8)) != 0
+	}
+}
+
+// encoding is how the values of the buckets are stored. It is recorded under db.ValueEncoding, so that how a value
+// is stored is known from its bucket rather than guessed from its contents, and so that the values of the buckets
+// whose encoding changes are rewritten whenever it does.
+type encoding struct {
+	checksummed bucketSet
+	compressed  bucketSet
+}
+
+// encodingOf returns the encoding the values are stored with according to opts
+func encodingOf(opts db.Options) encoding {
+	return encoding{
+		checksummed: setOf(opts.Checksums.Buckets()),
+		compressed:  setOf(opts.Compression.Buckets()),
+	}
+}
+
+// differs reports whether the values of bucket are encoded differently by e and other
+func (e *encoding) differs(other *encoding, bucket int) bool {
+	return e.checksummed[bucket] != other.checksummed[bucket] || e.compressed[bucket] != other.compressed[bucket]
+}
+
+func (e *encoding) marshal() []byte {
+	return append(e.checksummed.marshal(), e.compressed.marshal()...)
+}
+
+func (e *encoding) unmarshal(data []byte) {
+	e.checksummed.unmarshal(data)
+	e.compressed.unmarshal(data[bucketSetSize:])
+}
+
+// encodingRecord is the value of db.ValueEncoding: the encoding the values are stored with, followed by the encoding
+// they are rewritten to and the last key rewritten while they are
+type encodingRecord struct {
+	current encoding
+	// target is the encoding of the rewrite that is in progress, nil if there is none
+	target *encoding
+	// cursor is the last key the rewrite in progress rewrote, the values of the keys that follow it are stored with
+	// the current encoding
+	cursor []byte
+}
+
+func (r *encodingRecord) marshal() []byte {
+	data := r.current.marshal()
+	if r.target != nil {
+		data = append(data, r.target.marshal()...)
+		data = append(data, r.cursor...)
+	}
+	return data
+}
+
+// readEncoding returns the record of the encoding of the values of p, which is empty if it was never recorded
+func readEncoding(p *pebble.DB) (encodingRecord, error) {
+	var record encodingRecord
+	data, closer, err := p.Get(db.ValueEncoding.Key())
+	if errors.Is(err, pebble.ErrNotFound) {
+		return record, nil
+	} else if err != nil {
+		return record, err
+	}
+	defer closer.Close()
+
+	if len(data) != encodingSize && len(data) < 2*encodingSize {
+		return record, fmt.Errorf("the encoding of the values is recorded in %d bytes", len(data))
+	}
+	record.current.unmarshal(data)
+	if len(data) > encodingSize {
+		record.target = new(encoding)
+		record.target.unmarshal(data[encodingSize:])
+		record.cursor = bytes.Clone(data[2*encodingSize:])
+	}
+	return record, nil
+}
+
+// openEncoding completes the rewrite of the values that was interrupted, if any, then rewrites the values whose
+// encoding differs from that of opts if opts.Reencode. The values are then read and written with the encoding that
+// is recorded.
+func (d *DB) openEncoding(opts db.Options) error {
+	record, err := readEncoding(d.pebble)
+	if err != nil {
+		return err
+	}
+	if record.target != nil {
+		// the values the interrupted rewrite did not reach are stored with the current encoding
+		if err = d.reencode(record.current, *record.target, record.cursor, opts); err != nil {
+			return err
+		}
+		record.current = *record.target
+	}
+	if target := encodingOf(opts); opts.Reencode && target != record.current {
+		if err = d.reencode(record.current, target, nil, opts); err != nil {
+			return err
+		}
+		record.current = target
+	}
+
+	d.checksums = opts.Checksums.WithBuckets(record.current.checksummed.buckets())
+	d.compression = db.Compression{}.WithBuckets(record.current.compressed.buckets())
+	return nil
+}
+
+// reencode rewrites the values of the buckets whose encoding differs between from and to, following cursor if it is
+// not nil, and records the progress of the rewrite with the values it rewrites so that it resumes from there if it is
+// interrupted. The checksums of the values are verified according to the mode of opts.Checksums.
+func (d *DB) reencode(from, to encoding, cursor []byte, opts db.Options) error {
+	fromChecksums := opts.Checksums.WithBuckets(from.checksummed.buckets())
+	toChecksums := opts.Checksums.WithBuckets(to.checksummed.buckets())
+	toCompression := db.Compression{}.WithBuckets(to.compressed.buckets())
+	record := encodingRecord{current: from, target: &to}
+
+	batch := d.pebble.NewBatch()
+	for bucket := 0; bucket <= 0xff; bucket++ {
+		if !from.differs(&to, bucket) || (cursor != nil && bucket < int(cursor[0])) {
+			continue
+		}
+		if opts.Logger != nil {
+			opts.Logger.Infof("Rewriting the values of bucket %d, whose compression or checksums changed", bucket)
+		}
+
+		prefix := db.Bucket(bucket).Key()
+		lower := prefix
+		if cursor != nil && int(cursor[0]) == bucket {
+			lower = append(bytes.Clone(cursor), 0)
+		}
+		it := d.pebble.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: db.PrefixEnd(prefix)})
+		for it.First(); it.Valid(); it.Next() {
+			value, err := decode(fromChecksums, it.Key(), it.Value())
+			if err != nil {
+				return errors.Join(fmt.Errorf("rewrite the values of bucket %d, run juno db repair: %w", bucket, err),
+					it.Close(), batch.Close())
+			}
+			if err = batch.Set(it.Key(), encode(toChecksums, toCompression, it.Key(), value), nil); err != nil {
+				return errors.Join(err, it.Close(), batch.Close())
+			}
+			if batch.Len() < reencodeBatchSize {
+				continue
+			}
+
+			record.cursor = it.Key()
+			if err = commitEncoding(batch, &record); err != nil {
+				return errors.Join(err, it.Close())
+			}
+			batch = d.pebble.NewBatch()
+		}
+		if err := errors.Join(it.Error(), it.Close()); err != nil {
+			return errors.Join(err, batch.Close())
+		}
+	}
+
+	return commitEncoding(batch, &encodingRecord{current: to})
+}
+
+// encode returns the value of key as it is stored by a database that checksums and compresses the buckets of
+// checksums and compression
+func encode(checksums db.Checksums, compression db.Compression, key, value []byte) []byte {
+	return checksums.Seal(key, compression.Compress(key, value))
+}
+
+// decode returns the value of key as it was set from the value that is stored by a database that checksums the
+// buckets of checksums, see db.Checksums.Open and db.Decompress
+func decode(checksums db.Checksums, key, stored []byte) ([]byte, error) {
+	val, err := checksums.Open(key, stored)
+	if err != nil {
+		return nil, err
+	}
+	return db.Decompress(key, val)
+}
+
+// commitEncoding commits batch with record, and closes it
+func commitEncoding(batch *pebble.Batch, record *encodingRecord) error {
+	if err := batch.Set(db.ValueEncoding.Key(), record.marshal(), nil); err != nil {
+		return errors.Join(err, batch.Close())
+	}
+	return errors.Join(batch.Commit(pebble.Sync), batch.Close())
+}
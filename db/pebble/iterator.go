@@ -1,6 +1,8 @@
 package pebble
 
 import (
+	"bytes"
+
 	"github.com/NethermindEth/juno/db"
 	"github.com/cockroachdb/pebble"
 )
@@ -9,16 +11,23 @@ var _ db.Iterator = (*iterator)(nil)
 
 type iterator struct {
 	iter       *pebble.Iterator
+	checksums  db.Checksums
 	positioned bool
+	// exhausted is set when the iterator moved past the keys it was asked for, in which case it is invalid
+	// whatever key the pebble iterator is at
+	exhausted bool
 }
 
 // Valid : see db.Transaction.Iterator.Valid
 func (i *iterator) Valid() bool {
-	return i.iter.Valid()
+	return !i.exhausted && i.iter.Valid()
 }
 
 // Key : see db.Transaction.Iterator.Key
 func (i *iterator) Key() []byte {
+	if i.exhausted {
+		return nil
+	}
 	key := i.iter.Key()
 	if key == nil {
 		return nil
@@ -30,13 +39,19 @@ func (i *iterator) Key() []byte {
 
 // Value : see db.Transaction.Iterator.Value
 func (i *iterator) Value() ([]byte, error) {
+	if i.exhausted {
+		return nil, nil
+	}
 	val, err := i.iter.ValueAndErr()
 	if err != nil || val == nil {
 		return nil, err
 	}
 	buf := make([]byte, len(val))
 	copy(buf, val)
-	return buf, nil
+	if buf, err = i.checksums.Open(i.iter.Key(), buf); err != nil {
+		return nil, err
+	}
+	return db.Decompress(i.iter.Key(), buf)
 }
 
 // Next : see db.Transaction.Iterator.Next
@@ -45,15 +60,42 @@ func (i *iterator) Next() bool {
 		i.positioned = true
 		return i.iter.First()
 	}
+	if !i.Valid() {
+		return false
+	}
 	return i.iter.Next()
 }
 
 // Seek : see db.Transaction.Iterator.Seek
 func (i *iterator) Seek(key []byte) bool {
-	i.positioned = true
+	i.positioned, i.exhausted = true, false
 	return i.iter.SeekGE(key)
 }
 
+// Prev : see db.Transaction.Iterator.Prev
+func (i *iterator) Prev() bool {
+	if !i.positioned {
+		i.positioned = true
+		return i.iter.Last()
+	}
+	if !i.Valid() {
+		return false
+	}
+	return i.iter.Prev()
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *iterator) SeekLast(prefix []byte) bool {
+	i.positioned, i.exhausted = true, false
+	if end := db.PrefixEnd(prefix); end != nil {
+		i.iter.SeekLT(end)
+	} else {
+		i.iter.Last()
+	}
+	i.exhausted = !i.iter.Valid() || !bytes.HasPrefix(i.iter.Key(), prefix)
+	return i.Valid()
+}
+
 // Close : see db.Transaction.Iterator.Close
 func (i *iterator) Close() error {
 	return i.iter.Close()
@@ -0,0 +1,106 @@
+package pebble
+
+import (
+	"strconv"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/cockroachdb/pebble"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ db.Meterer = (*DB)(nil)
+
+// latencyBuckets range from a microsecond, for the reads served by the block cache, to seconds, for the commits of
+// the blocks that rewrite large parts of the state
+var latencyBuckets = prometheus.ExponentialBuckets(1e-6, 4, 12)
+
+// meter collects the metrics of the engine of a database when they are scraped, along with the latencies that the
+// transactions observe
+type meter struct {
+	pebble        *pebble.DB
+	readLatency   prometheus.Histogram
+	commitLatency prometheus.Histogram
+
+	compactionDebt        *prometheus.Desc
+	compactionsInProgress *prometheus.Desc
+	cacheHits             *prometheus.Desc
+	cacheMisses           *prometheus.Desc
+	cacheHitRatio         *prometheus.Desc
+	levelSize             *prometheus.Desc
+	levelFiles            *prometheus.Desc
+}
+
+func newMeter(pDB *pebble.DB, namespace string) *meter {
+	return &meter{
+		pebble: pDB,
+		readLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "read_latency_seconds",
+			Help:      "The latency of the reads of a key",
+			Buckets:   latencyBuckets,
+		}),
+		commitLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "commit_latency_seconds",
+			Help:      "The latency of the commits of the update transactions and of the batches",
+			Buckets:   latencyBuckets,
+		}),
+		compactionDebt: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "compaction_debt_bytes"),
+			"The estimated number of bytes to compact for the database to reach a stable state", nil, nil),
+		compactionsInProgress: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "compactions_in_progress"),
+			"The number of compactions in progress", nil, nil),
+		cacheHits: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "block_cache_hits_total"),
+			"The number of reads of the block cache that found the block", nil, nil),
+		cacheMisses: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "block_cache_misses_total"),
+			"The number of reads of the block cache that did not find the block", nil, nil),
+		cacheHitRatio: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "block_cache_hit_ratio"),
+			"The ratio of the reads of the block cache that found the block since the database was opened", nil, nil),
+		levelSize: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "level_size_bytes"),
+			"The size of the live tables of a level of the LSM tree", []string{"level"}, nil),
+		levelFiles: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "level_files"),
+			"The number of live tables of a level of the LSM tree", []string{"level"}, nil),
+	}
+}
+
+// Describe : see prometheus.Collector.Describe
+func (m *meter) Describe(ch chan<- *prometheus.Desc) {
+	m.readLatency.Describe(ch)
+	m.commitLatency.Describe(ch)
+	for _, desc := range []*prometheus.Desc{
+		m.compactionDebt, m.compactionsInProgress, m.cacheHits, m.cacheMisses, m.cacheHitRatio, m.levelSize, m.levelFiles,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect : see prometheus.Collector.Collect
+func (m *meter) Collect(ch chan<- prometheus.Metric) {
+	m.readLatency.Collect(ch)
+	m.commitLatency.Collect(ch)
+
+	metrics := m.pebble.Metrics()
+	ch <- prometheus.MustNewConstMetric(m.compactionDebt, prometheus.GaugeValue, float64(metrics.Compact.EstimatedDebt))
+	ch <- prometheus.MustNewConstMetric(m.compactionsInProgress, prometheus.GaugeValue,
+		float64(metrics.Compact.NumInProgress))
+
+	hits, misses := metrics.BlockCache.Hits, metrics.BlockCache.Misses
+	ch <- prometheus.MustNewConstMetric(m.cacheHits, prometheus.CounterValue, float64(hits))
+	ch <- prometheus.MustNewConstMetric(m.cacheMisses, prometheus.CounterValue, float64(misses))
+	var ratio float64
+	if hits+misses > 0 {
+		ratio = float64(hits) / float64(hits+misses)
+	}
+	ch <- prometheus.MustNewConstMetric(m.cacheHitRatio, prometheus.GaugeValue, ratio)
+
+	for level := range metrics.Levels {
+		label := strconv.Itoa(level)
+		ch <- prometheus.MustNewConstMetric(m.levelSize, prometheus.GaugeValue, float64(metrics.Levels[level].Size), label)
+		ch <- prometheus.MustNewConstMetric(m.levelFiles, prometheus.GaugeValue, float64(metrics.Levels[level].NumFiles),
+			label)
+	}
+}
+
+// Meter : see db.Meterer.Meter. The metrics are in the namespace of the database, see NewNamespaced.
+func (d *DB) Meter() prometheus.Collector {
+	return d.meter
+}
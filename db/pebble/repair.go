@@ -0,0 +1,102 @@
+package pebble
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/cockroachdb/pebble/record"
+)
+
+func init() {
+	db.RegisterRepairer(db.DefaultBackend, Repair)
+}
+
+// tornSuffix is appended to the path of a database for the directory Repair keeps the torn logs it truncated in
+const tornSuffix = ".torn"
+
+// Repair truncates the write-ahead logs of the database at path at their first torn record, see db.Repairer. Pebble
+// replays the last log up to its first torn record, but does not open a database whose earlier logs are torn, which
+// a power loss leaves behind on the filesystems that do not preserve the order of writes. The commits after the
+// torn record are lost, including those of the later logs, so that the database is left as it was at a commit. The
+// logs are kept in the directory of the path of the database with the .torn suffix.
+func Repair(path string) ([]string, error) {
+	logs, err := listWALs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := path + tornSuffix
+
+	// the torn tail of the last log is dropped by pebble
+	for i := 0; i < len(logs)-1; i++ {
+		var records [][]byte
+		logPath := filepath.Join(path, logs[i].name)
+		torn, err := readWALRecords(logPath, logs[i].num, func(repr []byte) (bool, error) {
+			records = append(records, repr)
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !torn {
+			continue
+		}
+
+		if err = truncateWAL(logPath, dir, records); err != nil {
+			return nil, fmt.Errorf("truncate %s: %w", logs[i].name, err)
+		}
+		repairs := []string{fmt.Sprintf("truncated write-ahead log %s after %d commits", logs[i].name, len(records))}
+		for _, later := range logs[i+1:] {
+			if err = os.Rename(filepath.Join(path, later.name), filepath.Join(dir, later.name)); err != nil {
+				return nil, err
+			}
+			repairs = append(repairs, "dropped write-ahead log "+later.name)
+		}
+		return append(repairs, "the logs are kept in "+dir), nil
+	}
+	return nil, nil
+}
+
+// truncateWAL replaces the write-ahead log at path with one that holds records, and moves the log to dir
+func truncateWAL(path, dir string, records [][]byte) error {
+	tmp := path + ".tmp"
+	if err := writeWAL(tmp, records); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeWAL writes a write-ahead log of records to path
+func writeWAL(path string, records [][]byte) (err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := record.NewWriter(file)
+	for _, repr := range records {
+		w, err := writer.Next()
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(repr); err != nil {
+			return err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
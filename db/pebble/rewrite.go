@@ -0,0 +1,155 @@
+package pebble
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// The suffixes of the paths of the copy of a database that is rewritten and of the database it replaces
+const (
+	rewriteSuffix  = ".rewrite"
+	replacedSuffix = ".replaced"
+)
+
+// rewriteBatchSize is the size of the writes the keys of a rewritten database are copied in
+const rewriteBatchSize = 64 << 20
+
+// Compact compacts all the keys of the database, which drops the overwritten and the deleted keys that its files
+// still hold
+func (d *DB) Compact() error {
+	it := d.pebble.NewIter(nil)
+	var first, last []byte
+	if it.First() {
+		first = bytes.Clone(it.Key())
+		if it.Last() {
+			// the end of the range is exclusive
+			last = append(bytes.Clone(it.Key()), 0)
+		}
+	}
+	if err := it.Close(); err != nil || first == nil {
+		return err
+	}
+	return d.pebble.Compact(first, last, true)
+}
+
+// CompactRange : see db.DB.CompactRange
+func (d *DB) CompactRange(start, end []byte) error {
+	if end == nil {
+		it := d.pebble.NewIter(nil)
+		if it.Last() {
+			end = append(bytes.Clone(it.Key()), 0)
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+	}
+	if start == nil {
+		start = []byte{}
+	}
+	if bytes.Compare(start, end) >= 0 {
+		// pebble does not compact empty ranges
+		return nil
+	}
+	return d.pebble.Compact(start, end, true)
+}
+
+// Rewrite copies the keys of the database at path, which must not be open by a node, to a new database and replaces
+// it with the copy. Unlike Compact, it reclaims all the space held by the overwritten and the deleted keys, at the cost of
+// the space of a copy while it runs. It returns the disk space used by the database before and after.
+//
+// The database is replaced by renaming the directories of the database and of its copy, a rewrite interrupted
+// while they are renamed is completed by FinishRewrite the next time the database is opened.
+func Rewrite(path string, logger pebble.Logger) (before, after uint64, err error) {
+	if err = FinishRewrite(path); err != nil {
+		return 0, 0, err
+	}
+	if _, err = os.Stat(path); err != nil {
+		return 0, 0, err
+	}
+
+	// the database is not opened read-only so that it stays locked while it is copied
+	src, err := pebble.Open(path, &pebble.Options{Logger: logger})
+	if err != nil {
+		return 0, 0, fmt.Errorf("open the database: %w", err)
+	}
+	before = src.Metrics().DiskSpaceUsage()
+	copyPath := path + rewriteSuffix
+	// the copy of a rewrite that was interrupted before it was complete
+	if err = os.RemoveAll(copyPath); err != nil {
+		return 0, 0, errors.Join(err, src.Close())
+	}
+	dst, err := pebble.Open(copyPath, &pebble.Options{Logger: logger})
+	if err != nil {
+		return 0, 0, errors.Join(fmt.Errorf("create the copy of the database: %w", err), src.Close())
+	}
+
+	if err = copyKeys(src, dst); err == nil {
+		// the copy is complete once its keys are flushed to its files, since its writes are not synced
+		err = dst.Flush()
+	}
+	if err == nil {
+		after = dst.Metrics().DiskSpaceUsage()
+	}
+	if err = errors.Join(err, dst.Close(), src.Close()); err != nil {
+		return 0, 0, errors.Join(err, os.RemoveAll(copyPath))
+	}
+
+	replacedPath := path + replacedSuffix
+	if err = os.Rename(path, replacedPath); err != nil {
+		return 0, 0, errors.Join(err, os.RemoveAll(copyPath))
+	}
+	if err = os.Rename(copyPath, path); err != nil {
+		return 0, 0, err
+	}
+	return before, after, os.RemoveAll(replacedPath)
+}
+
+// copyKeys copies the keys of src to dst in batches of rewriteBatchSize
+func copyKeys(src, dst *pebble.DB) error {
+	it := src.NewIter(nil)
+	batch := dst.NewBatch()
+	for it.First(); it.Valid(); it.Next() {
+		if err := batch.Set(it.Key(), it.Value(), nil); err != nil {
+			return errors.Join(err, batch.Close(), it.Close())
+		}
+		if batch.Len() < rewriteBatchSize {
+			continue
+		}
+		if err := batch.Commit(pebble.NoSync); err != nil {
+			return errors.Join(err, batch.Close(), it.Close())
+		}
+		if err := batch.Close(); err != nil {
+			return errors.Join(err, it.Close())
+		}
+		batch = dst.NewBatch()
+	}
+	if err := it.Error(); err != nil {
+		return errors.Join(err, batch.Close(), it.Close())
+	}
+	return errors.Join(batch.Commit(pebble.NoSync), batch.Close(), it.Close())
+}
+
+// FinishRewrite completes the rewrite of the database at path that was interrupted while its copy replaced it, so
+// that the database is found at path. The copy of a rewrite that was interrupted before is left to the next rewrite.
+func FinishRewrite(path string) error {
+	copyPath, replacedPath := path+rewriteSuffix, path+replacedSuffix
+	if _, err := os.Stat(replacedPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		// the database was moved away once its copy was complete
+		if err = os.Rename(copyPath, path); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return os.RemoveAll(replacedPath)
+}
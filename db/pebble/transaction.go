@@ -1,9 +1,13 @@
 package pebble
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/NethermindEth/juno/db"
 	"github.com/cockroachdb/pebble"
@@ -12,16 +16,28 @@ import (
 
 var ErrDiscardedTransaction = errors.New("discarded txn")
 
-var _ db.Transaction = (*Transaction)(nil)
+var (
+	_ db.Transaction  = (*Transaction)(nil)
+	_ db.PendingSizer = (*Transaction)(nil)
+)
 
 type Transaction struct {
 	batch    *pebble.Batch
 	snapshot *pebble.Snapshot
 	lock     *sync.Mutex
+	lockedAt *atomic.Int64
+	// compression is the set of buckets whose values are compressed when they are set
+	compression db.Compression
+	// checksums are the buckets whose values are checksummed when they are set, after they are compressed
+	checksums db.Checksums
+	// stampCommits stamps the commit with its time, see DB.stampCommits
+	stampCommits bool
 
 	// metrics
-	readCounter  prometheus.Counter
-	writeCounter prometheus.Counter
+	readCounter   prometheus.Counter
+	writeCounter  prometheus.Counter
+	readLatency   prometheus.Observer
+	commitLatency prometheus.Observer
 }
 
 // Discard : see db.Transaction.Discard
@@ -40,6 +56,7 @@ func (t *Transaction) Discard() error {
 	}
 
 	if t.lock != nil {
+		t.lockedAt.Store(0)
 		t.lock.Unlock()
 		t.lock = nil
 	}
@@ -49,11 +66,29 @@ func (t *Transaction) Discard() error {
 // Commit : see db.Transaction.Commit
 func (t *Transaction) Commit() error {
 	if t.batch != nil {
-		return db.CloseAndWrapOnError(t.Discard, t.batch.Commit(pebble.Sync))
+		if t.stampCommits {
+			if err := stampCommit(t.batch); err != nil {
+				return db.CloseAndWrapOnError(t.Discard, err)
+			}
+		}
+		start := time.Now()
+		err := t.batch.Commit(pebble.Sync)
+		if t.commitLatency != nil {
+			t.commitLatency.Observe(time.Since(start).Seconds())
+		}
+		return db.CloseAndWrapOnError(t.Discard, err)
 	}
 	return db.CloseAndWrapOnError(t.Discard, ErrDiscardedTransaction)
 }
 
+// PendingSize : see db.PendingSizer.PendingSize, it is 0 for read-only transactions
+func (t *Transaction) PendingSize() uint64 {
+	if t.batch == nil {
+		return 0
+	}
+	return uint64(t.batch.Len())
+}
+
 // Set : see db.Transaction.Set
 func (t *Transaction) Set(key, val []byte) error {
 	if t.batch == nil {
@@ -66,7 +101,7 @@ func (t *Transaction) Set(key, val []byte) error {
 	if t.writeCounter != nil {
 		t.writeCounter.Inc()
 	}
-	return t.batch.Set(key, val, pebble.Sync)
+	return t.batch.Set(key, t.checksums.Seal(key, t.compression.Compress(key, val)), pebble.Sync)
 }
 
 // Delete : see db.Transaction.Delete
@@ -81,12 +116,30 @@ func (t *Transaction) Delete(key []byte) error {
 	return t.batch.Delete(key, pebble.Sync)
 }
 
+// DeletePrefix : see db.Transaction.DeletePrefix. The keys are deleted with a range tombstone, or one by one if prefix
+// is empty or only made of 0xff bytes, since no key ends their range then.
+func (t *Transaction) DeletePrefix(prefix []byte) error {
+	if t.batch == nil {
+		return errors.New("read only transaction")
+	}
+	end := db.PrefixEnd(prefix)
+	if end == nil {
+		return db.DeletePrefixByKey(t, prefix)
+	}
+
+	if t.writeCounter != nil {
+		t.writeCounter.Inc()
+	}
+	return t.batch.DeleteRange(prefix, end, pebble.Sync)
+}
+
 // Get : see db.Transaction.Get
 func (t *Transaction) Get(key []byte, cb func([]byte) error) error {
 	var val []byte
 	var closer io.Closer
 
 	var err error
+	start := time.Now()
 	if t.batch != nil {
 		val, closer, err = t.batch.Get(key)
 	} else if t.snapshot != nil {
@@ -98,6 +151,9 @@ func (t *Transaction) Get(key []byte, cb func([]byte) error) error {
 	if t.readCounter != nil {
 		t.readCounter.Inc()
 	}
+	if t.readLatency != nil {
+		t.readLatency.Observe(time.Since(start).Seconds())
+	}
 	if err != nil {
 		if errors.Is(err, pebble.ErrNotFound) {
 			return db.ErrKeyNotFound
@@ -105,9 +161,60 @@ func (t *Transaction) Get(key []byte, cb func([]byte) error) error {
 
 		return err
 	}
+	val, err = t.open(key, val)
+	if err != nil {
+		return db.CloseAndWrapOnError(closer.Close, err)
+	}
 	return db.CloseAndWrapOnError(closer.Close, cb(val))
 }
 
+// MultiGet : see db.Transaction.MultiGet. The keys are sought in ascending order with a single iterator, which reads
+// the blocks that neighbouring keys share once instead of looking each key up from the top of the LSM tree.
+func (t *Transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	var iter *pebble.Iterator
+	if t.batch != nil {
+		iter = t.batch.NewIter(nil)
+	} else if t.snapshot != nil {
+		iter = t.snapshot.NewIter(nil)
+	} else {
+		return ErrDiscardedTransaction
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bytes.Compare(keys[order[a]], keys[order[b]]) < 0
+	})
+
+	if t.readCounter != nil {
+		t.readCounter.Add(float64(len(keys)))
+	}
+	for _, i := range order {
+		if !iter.SeekGE(keys[i]) || !bytes.Equal(iter.Key(), keys[i]) {
+			continue
+		}
+		val, err := t.open(keys[i], iter.Value())
+		if err == nil {
+			err = cb(i, val)
+		}
+		if err != nil {
+			return db.CloseAndWrapOnError(iter.Close, err)
+		}
+	}
+	return iter.Close()
+}
+
+// open returns the value of key as it was set from the value that is stored, see db.Checksums.Open and db.Decompress
+func (t *Transaction) open(key, stored []byte) ([]byte, error) {
+	val, err := t.checksums.Open(key, stored)
+	if err != nil {
+		return nil, err
+	}
+	return db.Decompress(key, val)
+}
+
 // Impl : see db.Transaction.Impl
 func (t *Transaction) Impl() any {
 	if t.batch != nil {
@@ -122,14 +229,28 @@ func (t *Transaction) Impl() any {
 
 // NewIterator : see db.Transaction.NewIterator
 func (t *Transaction) NewIterator() (db.Iterator, error) {
+	return t.newIterator(nil)
+}
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds. The bounds are passed to Pebble, which skips the
+// tables whose keys are out of them.
+func (t *Transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	return t.newIterator(&pebble.IterOptions{
+		// pebble keeps the bounds until the iterator is closed
+		LowerBound: bytes.Clone(lower),
+		UpperBound: bytes.Clone(upper),
+	})
+}
+
+func (t *Transaction) newIterator(opts *pebble.IterOptions) (db.Iterator, error) {
 	var iter *pebble.Iterator
 	if t.batch != nil {
-		iter = t.batch.NewIter(nil)
+		iter = t.batch.NewIter(opts)
 	} else if t.snapshot != nil {
-		iter = t.snapshot.NewIter(nil)
+		iter = t.snapshot.NewIter(opts)
 	} else {
 		return nil, ErrDiscardedTransaction
 	}
 
-	return &iterator{iter: iter}, nil
+	return &iterator{iter: iter, checksums: t.checksums}, nil
 }
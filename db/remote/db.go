@@ -0,0 +1,199 @@
+// Package remote implements a read-only db.DB that reads the database of another node through its gRPC KV
+// service, which lets read replicas serve RPC requests from the database of a primary node.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/grpc/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ErrReadOnly is returned by the writes to a remote database
+var ErrReadOnly = errors.New("remote database is read-only")
+
+var _ db.DB = (*DB)(nil)
+
+type DB struct {
+	conn   *grpc.ClientConn
+	client gen.KVClient
+}
+
+// New connects to the gRPC KV service at addr, a host:port. The connection is established lazily, so an
+// unreachable service fails the first transaction rather than New.
+func New(addr string) (*DB, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	return &DB{conn: conn, client: gen.NewKVClient(conn)}, nil
+}
+
+// NewTransaction : see db.DB.NewTransaction. Transactions read a consistent snapshot of the remote database,
+// writes fail with ErrReadOnly.
+func (d *DB) NewTransaction(_ bool) db.Transaction {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := d.client.Tx(ctx)
+	if err != nil {
+		cancel()
+		return &transaction{err: err}
+	}
+	return &transaction{stream: stream, cancel: cancel, getCursor: -1}
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(_ func(txn db.Transaction) error) error {
+	return ErrReadOnly
+}
+
+// Backup : see db.DB.Backup. A remote database is backed up by the node that serves it.
+func (d *DB) Backup(_ string) error {
+	return errors.New("a remote database cannot be backed up")
+}
+
+// CompactRange : see db.DB.CompactRange. A remote database is compacted by the node that serves it.
+func (d *DB) CompactRange(_, _ []byte) error {
+	return errors.New("a remote database cannot be compacted")
+}
+
+// Impl : see db.DB.Impl
+func (d *DB) Impl() any {
+	return d.client
+}
+
+// Close : see io.Closer.Close
+func (d *DB) Close() error {
+	return d.conn.Close()
+}
+
+var _ db.Transaction = (*transaction)(nil)
+
+type transaction struct {
+	// the requests of a stream are answered in order, mu keeps a request and its response together
+	mu        sync.Mutex
+	stream    gen.KV_TxClient
+	cancel    context.CancelFunc
+	getCursor int64
+	// err is the error the transaction failed to open with
+	err error
+}
+
+// do sends cursor to the remote database and returns the response
+func (t *transaction) do(cursor *gen.Cursor) (*gen.Pair, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return nil, t.err
+	}
+	if t.stream == nil {
+		return nil, errors.New("discarded txn")
+	}
+
+	if err := t.stream.Send(cursor); err != nil {
+		return nil, err
+	}
+	return t.stream.Recv()
+}
+
+func (t *transaction) openCursor() (uint32, error) {
+	pair, err := t.do(&gen.Cursor{Op: gen.Op_OPEN})
+	if err != nil {
+		return 0, err
+	}
+	return pair.CursorId, nil
+}
+
+// Discard : see db.Transaction.Discard
+func (t *transaction) Discard() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stream == nil {
+		return nil
+	}
+
+	// the remote database releases its snapshot once the stream ends
+	err := t.stream.CloseSend()
+	t.cancel()
+	t.stream = nil
+	return err
+}
+
+// Commit : see db.Transaction.Commit
+func (t *transaction) Commit() error {
+	return db.CloseAndWrapOnError(t.Discard, ErrReadOnly)
+}
+
+// Set : see db.Transaction.Set
+func (t *transaction) Set(_, _ []byte) error {
+	return ErrReadOnly
+}
+
+// Delete : see db.Transaction.Delete
+func (t *transaction) Delete(_ []byte) error {
+	return ErrReadOnly
+}
+
+// DeletePrefix : see db.Transaction.DeletePrefix
+func (t *transaction) DeletePrefix(_ []byte) error {
+	return ErrReadOnly
+}
+
+// MultiGet : see db.Transaction.MultiGet, the keys are read one by one
+func (t *transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	return db.MultiGetByKey(t, keys, cb)
+}
+
+// Get : see db.Transaction.Get
+func (t *transaction) Get(key []byte, cb func([]byte) error) error {
+	if t.getCursor < 0 {
+		cursor, err := t.openCursor()
+		if err != nil {
+			return err
+		}
+		t.getCursor = int64(cursor)
+	}
+
+	pair, err := t.do(&gen.Cursor{Op: gen.Op_SEEK_EXACT, Cursor: uint32(t.getCursor), K: key})
+	if err != nil {
+		return err
+	}
+	if len(pair.K) == 0 {
+		return db.ErrKeyNotFound
+	}
+	return cb(pair.V)
+}
+
+// NewIterator : see db.Transaction.NewIterator
+func (t *transaction) NewIterator() (db.Iterator, error) {
+	cursor, err := t.openCursor()
+	if err != nil {
+		return nil, err
+	}
+	return &iterator{txn: t, cursor: cursor}, nil
+}
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds. The cursors of the remote database are not
+// bounded, so the keys out of the bounds are skipped by the iterator.
+func (t *transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	it, err := t.NewIterator()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBoundedIterator(it, lower, upper), nil
+}
+
+// Impl : see db.Transaction.Impl
+func (t *transaction) Impl() any {
+	return t.stream
+}
@@ -0,0 +1,119 @@
+package remote_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/remote"
+	"github.com/NethermindEth/juno/grpc"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRemoteDB returns a remote database that reads primary through a gRPC server
+func newRemoteDB(t *testing.T, primary db.DB) *remote.DB {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(0, "1.0.0", primary, utils.NewNopZapLogger()).WithListener(listener)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, server.Run(ctx))
+	}()
+
+	remoteDB, err := remote.New(listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, remoteDB.Close())
+		cancel()
+		<-done
+	})
+	return remoteDB
+}
+
+func TestRemoteDB(t *testing.T) {
+	primary := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, primary.Close())
+	})
+	require.NoError(t, primary.Update(func(txn db.Transaction) error {
+		for _, key := range []string{"a", "b", "c"} {
+			if err := txn.Set([]byte(key), []byte("value-"+key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	remoteDB := newRemoteDB(t, primary)
+
+	t.Run("get", func(t *testing.T) {
+		require.NoError(t, remoteDB.View(func(txn db.Transaction) error {
+			require.NoError(t, txn.Get([]byte("b"), func(value []byte) error {
+				assert.Equal(t, []byte("value-b"), value)
+				return nil
+			}))
+			require.ErrorIs(t, txn.Get([]byte("d"), func([]byte) error { return nil }), db.ErrKeyNotFound)
+			return nil
+		}))
+	})
+
+	t.Run("iterate", func(t *testing.T) {
+		require.NoError(t, remoteDB.View(func(txn db.Transaction) error {
+			it, err := txn.NewIterator()
+			require.NoError(t, err)
+
+			var keys []string
+			for it.Next() {
+				keys = append(keys, string(it.Key()))
+			}
+			assert.Equal(t, []string{"a", "b", "c"}, keys)
+			assert.False(t, it.Valid())
+
+			require.True(t, it.Seek([]byte("bb")))
+			assert.Equal(t, []byte("c"), it.Key())
+			value, err := it.Value()
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value-c"), value)
+
+			require.True(t, it.Prev())
+			assert.Equal(t, []byte("b"), it.Key())
+			require.True(t, it.SeekLast(nil))
+			assert.Equal(t, []byte("c"), it.Key())
+			require.True(t, it.SeekLast([]byte("a")))
+			assert.Equal(t, []byte("a"), it.Key())
+			assert.False(t, it.Prev())
+			assert.False(t, it.SeekLast([]byte("d")))
+			return it.Close()
+		}))
+	})
+
+	t.Run("transactions read a snapshot", func(t *testing.T) {
+		txn := remoteDB.NewTransaction(false)
+		require.NoError(t, txn.Get([]byte("a"), func([]byte) error { return nil }))
+		require.NoError(t, primary.Update(func(txn db.Transaction) error {
+			return txn.Set([]byte("e"), []byte("value-e"))
+		}))
+		require.ErrorIs(t, txn.Get([]byte("e"), func([]byte) error { return nil }), db.ErrKeyNotFound)
+		require.NoError(t, txn.Discard())
+
+		require.NoError(t, remoteDB.View(func(txn db.Transaction) error {
+			return txn.Get([]byte("e"), func([]byte) error { return nil })
+		}))
+	})
+
+	t.Run("writes fail", func(t *testing.T) {
+		require.ErrorIs(t, remoteDB.Update(func(db.Transaction) error { return nil }), remote.ErrReadOnly)
+
+		txn := remoteDB.NewTransaction(true)
+		require.ErrorIs(t, txn.Set([]byte("f"), nil), remote.ErrReadOnly)
+		require.ErrorIs(t, txn.Delete([]byte("a")), remote.ErrReadOnly)
+		require.ErrorIs(t, txn.Commit(), remote.ErrReadOnly)
+	})
+}
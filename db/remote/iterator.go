@@ -0,0 +1,70 @@
+package remote
+
+import (
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/grpc/gen"
+)
+
+var _ db.Iterator = (*iterator)(nil)
+
+// iterator is a cursor of the remote database, it holds the pair the cursor is positioned at
+type iterator struct {
+	txn    *transaction
+	cursor uint32
+	key    []byte
+	value  []byte
+	// err is the error the last move of the cursor failed with
+	err error
+}
+
+func (i *iterator) move(op gen.Op, key []byte) bool {
+	pair, err := i.txn.do(&gen.Cursor{Op: op, Cursor: i.cursor, K: key})
+	i.err = err
+	if err != nil || len(pair.K) == 0 {
+		i.key, i.value = nil, nil
+		return false
+	}
+	i.key, i.value = pair.K, pair.V
+	return true
+}
+
+// Valid : see db.Transaction.Iterator.Valid
+func (i *iterator) Valid() bool {
+	return i.key != nil
+}
+
+// Key : see db.Transaction.Iterator.Key
+func (i *iterator) Key() []byte {
+	return i.key
+}
+
+// Value : see db.Transaction.Iterator.Value
+func (i *iterator) Value() ([]byte, error) {
+	return i.value, i.err
+}
+
+// Next : see db.Transaction.Iterator.Next
+func (i *iterator) Next() bool {
+	return i.move(gen.Op_NEXT, nil)
+}
+
+// Seek : see db.Transaction.Iterator.Seek
+func (i *iterator) Seek(key []byte) bool {
+	return i.move(gen.Op_SEEK, key)
+}
+
+// Prev : see db.Transaction.Iterator.Prev
+func (i *iterator) Prev() bool {
+	return i.move(gen.Op_PREV, nil)
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *iterator) SeekLast(prefix []byte) bool {
+	return i.move(gen.Op_LAST, prefix)
+}
+
+// Close : see db.Transaction.Iterator.Close
+func (i *iterator) Close() error {
+	// the remote database closes the cursors of a transaction once it is discarded
+	return i.err
+}
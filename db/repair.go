@@ -0,0 +1,174 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// maxReportedEntries bounds the number of the quarantined entries a RepairReport lists
+	maxReportedEntries = 100
+	// quarantineBatchSize is the number of entries Repair moves to the Quarantine bucket in a transaction
+	quarantineBatchSize = 1000
+)
+
+// Repairer runs the repair routines of the engine of a backend on the database at path, which make a database that
+// the engine cannot open after a crash, such as one whose write-ahead log was torn by a power loss, open again at the
+// cost of the writes that are lost. It returns a description of each repair it made.
+type Repairer func(path string) ([]string, error)
+
+var (
+	repairersMu sync.RWMutex
+	repairers   = make(map[string]Repairer)
+)
+
+// RegisterRepairer makes the repairer of the backend registered under name available to Repair, like Register, and
+// panics if name is taken
+func RegisterRepairer(name string, repairer Repairer) {
+	repairersMu.Lock()
+	defer repairersMu.Unlock()
+	if repairer == nil {
+		panic("db: RegisterRepairer repairer is nil")
+	}
+	if _, found := repairers[name]; found {
+		panic(fmt.Sprintf("db: RegisterRepairer called twice for backend %s", name))
+	}
+	repairers[name] = repairer
+}
+
+// QuarantinedEntry is an entry Repair moved to the Quarantine bucket, with the reason its value was rejected
+type QuarantinedEntry struct {
+	Key []byte
+	Err error
+}
+
+// RepairReport is what Repair did to a database
+type RepairReport struct {
+	// Engine are the repairs of the engine of the database, see Repairer
+	Engine []string
+	// Scanned is the number of entries that were checked
+	Scanned uint64
+	// Quarantined is the number of entries moved to the Quarantine bucket, the first of which are listed in Entries
+	Quarantined uint64
+	Entries     []QuarantinedEntry
+	// Unreadable are the errors of the buckets that could not be read to the end, whose keys after the last one
+	// that was read are neither checked nor quarantined
+	Unreadable map[Bucket]error
+}
+
+// Degraded returns whether the database is missing entries after the repair
+func (r *RepairReport) Degraded() bool {
+	return r.Quarantined > 0 || len(r.Unreadable) > 0
+}
+
+// Repair repairs the database of backend at path, which must not be in use, so that a node can start on it after a
+// crash left it corrupted instead of syncing again. The repair routines of the engine are run first, see Repairer,
+// then every entry of the database is read, and the entries whose values cannot be read or are rejected by check are
+// moved to the Quarantine bucket, where they are kept by their keys for their values to be inspected. check returns
+// nil for the entries of the buckets it does not know. The database is missing the quarantined entries afterwards,
+// which the node serves as missing until it syncs them again or the database is restored.
+func Repair(backend, path string, check func(key, value []byte) error) (*RepairReport, error) {
+	repairersMu.RLock()
+	repair, found := repairers[backend]
+	repairersMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("the %s backend cannot be repaired", backend)
+	}
+
+	report := &RepairReport{Unreadable: make(map[Bucket]error)}
+	engine, err := repair(path)
+	if err != nil {
+		return nil, fmt.Errorf("repair the engine: %w", err)
+	}
+	report.Engine = engine
+
+	database, err := Open(backend, path, Options{})
+	if err != nil {
+		return nil, err
+	}
+	for bucket := 0; bucket <= 0xff; bucket++ {
+		if Bucket(bucket) == Quarantine {
+			continue
+		}
+		if err = quarantineBucket(database, Bucket(bucket), check, report); err != nil {
+			return nil, errors.Join(err, database.Close())
+		}
+	}
+	return report, database.Close()
+}
+
+// quarantineBucket moves the entries of bucket that cannot be read or are rejected by check to the Quarantine bucket
+func quarantineBucket(database DB, bucket Bucket, check func(key, value []byte) error, report *RepairReport) error {
+	var pending []QuarantinedEntry
+	var values [][]byte
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		err := database.Update(func(txn Transaction) error {
+			for i, entry := range pending {
+				if err := txn.Set(Quarantine.Key(entry.Key), values[i]); err != nil {
+					return err
+				}
+				if err := txn.Delete(entry.Key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("quarantine the entries of bucket %d: %w", bucket, err)
+		}
+		for _, entry := range pending {
+			if len(report.Entries) < maxReportedEntries {
+				report.Entries = append(report.Entries, entry)
+			}
+		}
+		report.Quarantined += uint64(len(pending))
+		pending, values = pending[:0], values[:0]
+		return nil
+	}
+
+	var readErr error
+	err := database.View(func(txn Transaction) error {
+		prefix := bucket.Key()
+		it, err := txn.NewIteratorWithBounds(prefix, PrefixEnd(prefix))
+		if err != nil {
+			return err
+		}
+		for it.Seek(prefix); it.Valid(); it.Next() {
+			report.Scanned++
+			key := bytes.Clone(it.Key())
+			value, err := it.Value()
+			if err != nil {
+				// the value is stored but cannot be read, it is quarantined without it
+				value = nil
+			} else if err = check(key, value); err != nil {
+				value = bytes.Clone(value)
+			}
+			if err == nil {
+				continue
+			}
+
+			pending = append(pending, QuarantinedEntry{Key: key, Err: err})
+			values = append(values, value)
+			if len(pending) == quarantineBatchSize {
+				if err = flush(); err != nil {
+					return CloseAndWrapOnError(it.Close, err)
+				}
+			}
+		}
+		// an iterator that stops on an error of the engine returns it when it is closed
+		readErr = it.Close()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		report.Unreadable[bucket] = readErr
+	}
+	return flush()
+}
@@ -0,0 +1,77 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepair(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	database, err := pebble.New(path, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	good := db.BlockHeadersByNumber.Key([]byte{1})
+	bad := db.BlockHeadersByNumber.Key([]byte{2})
+	other := db.ReceiptsByBlockNumberAndIndex.Key([]byte{1})
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		for key, value := range map[string]string{string(good): "good", string(bad): "bad", string(other): "bad"} {
+			if err := txn.Set([]byte(key), []byte(value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, database.Close())
+
+	errBad := errors.New("bad header")
+	// only the headers are checked
+	check := func(key, value []byte) error {
+		if db.Bucket(key[0]) == db.BlockHeadersByNumber && string(value) == "bad" {
+			return errBad
+		}
+		return nil
+	}
+	report, err := db.Repair(db.DefaultBackend, path, check)
+	require.NoError(t, err)
+	assert.Empty(t, report.Engine)
+	assert.Equal(t, uint64(3), report.Scanned)
+	assert.Equal(t, uint64(1), report.Quarantined)
+	assert.Equal(t, []db.QuarantinedEntry{{Key: bad, Err: errBad}}, report.Entries)
+	assert.Empty(t, report.Unreadable)
+	assert.True(t, report.Degraded())
+
+	database, err = pebble.New(path, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, database.Close())
+	})
+	get := func(key []byte) string {
+		var value string
+		err := database.View(func(txn db.Transaction) error {
+			return txn.Get(key, func(val []byte) error {
+				value = string(val)
+				return nil
+			})
+		})
+		if err != nil {
+			require.ErrorIs(t, err, db.ErrKeyNotFound)
+			return "<missing>"
+		}
+		return value
+	}
+	assert.Equal(t, "good", get(good))
+	assert.Equal(t, "<missing>", get(bad))
+	assert.Equal(t, "bad", get(other))
+	assert.Equal(t, "bad", get(db.Quarantine.Key(bad)))
+
+	t.Run("unknown backend", func(t *testing.T) {
+		_, err := db.Repair("nonexistent", path, check)
+		assert.EqualError(t, err, "the nonexistent backend cannot be repaired")
+	})
+}
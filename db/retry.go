@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrConflict is returned by the transactions that cannot commit because of a concurrent write, which the same
+// transaction run again can commit
+var ErrConflict = errors.New("transaction conflict")
+
+// retryableError marks an error as one that a retry can recover from, see Retryable
+type retryableError struct {
+	error
+}
+
+func (e retryableError) Unwrap() error {
+	return e.error
+}
+
+// Retryable marks err as an error that a retry of the transaction that failed with it can recover from, such as the
+// contention of the commits of a backend, so that UpdateWithRetry retries the transaction
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retryableError{err}
+}
+
+// IsRetryable returns whether err is ErrConflict or an error marked with Retryable
+func IsRetryable(err error) bool {
+	var retryable retryableError
+	return errors.Is(err, ErrConflict) || errors.As(err, &retryable)
+}
+
+// RetryPolicy is how often and how soon a transaction that failed with a retryable error is retried, see
+// UpdateWithRetry
+type RetryPolicy struct {
+	// Attempts is the number of times the transaction is run at most, including the first
+	Attempts int
+	// Backoff is the delay before the first retry, which doubles with each retry up to MaxBackoff. A random jitter of
+	// up to half of the delay is added, so that the transactions that conflicted are not retried at the same time.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy retries a transaction for about a second
+var DefaultRetryPolicy = RetryPolicy{Attempts: 6, Backoff: 20 * time.Millisecond, MaxBackoff: 500 * time.Millisecond}
+
+// IdempotentFunc is a function of a transaction that can run several times with the same effect as running once,
+// see Idempotent
+type IdempotentFunc struct {
+	fn func(txn Transaction) error
+}
+
+// Idempotent marks fn as safe to run again when its transaction fails, which UpdateWithRetry requires. The writes
+// of the transaction are discarded when it fails, so fn is idempotent unless it has other effects, such as
+// updating the variables of its callers or notifying subscribers, which it should only do once the transaction is
+// committed.
+func Idempotent(fn func(txn Transaction) error) IdempotentFunc {
+	return IdempotentFunc{fn: fn}
+}
+
+// UpdateWithRetry runs fn in an update transaction of database like DB.Update, and runs it again in a new transaction
+// with the backoff of policy when the transaction fails with a retryable error, see IsRetryable, until it commits,
+// it fails with another error, the attempts of policy run out or ctx is done.
+func UpdateWithRetry(ctx context.Context, database DB, policy RetryPolicy, fn IdempotentFunc) error {
+	return Retry(ctx, policy, func() error {
+		return database.Update(fn.fn)
+	})
+}
+
+// Retry calls fn until it succeeds, it fails with an error that is not retryable, see IsRetryable, the attempts of
+// policy run out or ctx is done, for the writes that are not made in a transaction, such as the commits of a Batch.
+// It returns the last error of fn, or the error of ctx if it is done before.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= policy.Attempts {
+			return err
+		}
+
+		delay := backoff
+		if delay > 0 {
+			delay += time.Duration(rand.Int63n(int64(delay)/2 + 1)) //nolint:gosec
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictingDB fails the commits of its first conflicts update transactions with err
+type conflictingDB struct {
+	db.DB
+	conflicts int
+	err       error
+}
+
+func (d *conflictingDB) Update(fn func(txn db.Transaction) error) error {
+	return d.DB.Update(func(txn db.Transaction) error {
+		if err := fn(txn); err != nil {
+			return err
+		}
+		if d.conflicts > 0 {
+			d.conflicts--
+			return d.err
+		}
+		return nil
+	})
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	policy := db.RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+
+	// update sets key in conflicting, and returns the number of times its function ran
+	update := func(ctx context.Context, conflicting *conflictingDB, key string) (int, error) {
+		var runs int
+		err := db.UpdateWithRetry(ctx, conflicting, policy, db.Idempotent(func(txn db.Transaction) error {
+			runs++
+			return txn.Set([]byte(key), []byte{1})
+		}))
+		return runs, err
+	}
+	has := func(key string) bool {
+		err := testDB.View(func(txn db.Transaction) error {
+			return txn.Get([]byte(key), func([]byte) error { return nil })
+		})
+		return err == nil
+	}
+
+	t.Run("conflicts are retried", func(t *testing.T) {
+		runs, err := update(context.Background(), &conflictingDB{DB: testDB, conflicts: 2, err: db.ErrConflict}, "a")
+		require.NoError(t, err)
+		assert.Equal(t, 3, runs)
+		assert.True(t, has("a"))
+	})
+
+	t.Run("errors marked retryable are retried", func(t *testing.T) {
+		conflicting := &conflictingDB{DB: testDB, conflicts: 1, err: fmt.Errorf("commit: %w", db.Retryable(errors.New("busy")))}
+		runs, err := update(context.Background(), conflicting, "b")
+		require.NoError(t, err)
+		assert.Equal(t, 2, runs)
+		assert.True(t, has("b"))
+	})
+
+	t.Run("attempts run out", func(t *testing.T) {
+		runs, err := update(context.Background(), &conflictingDB{DB: testDB, conflicts: 5, err: db.ErrConflict}, "c")
+		require.ErrorIs(t, err, db.ErrConflict)
+		assert.Equal(t, 3, runs)
+		assert.False(t, has("c"))
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		failure := errors.New("failure")
+		runs, err := update(context.Background(), &conflictingDB{DB: testDB, conflicts: 5, err: failure}, "d")
+		require.ErrorIs(t, err, failure)
+		assert.Equal(t, 1, runs)
+		assert.False(t, db.IsRetryable(failure))
+		assert.Nil(t, db.Retryable(nil))
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		runs, err := update(ctx, &conflictingDB{DB: testDB, conflicts: 5, err: db.ErrConflict}, "e")
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, runs)
+	})
+}
@@ -0,0 +1,180 @@
+// Package slowlog wraps a database to log its operations that take longer than a threshold, with the bucket and the
+// size of their keys, so that the time a slow request spends in the database is told apart from the time it spends
+// in the code that reads it. The time spent in the callbacks of Get and MultiGet is not counted.
+package slowlog
+
+import (
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+var _ db.DB = (*DB)(nil)
+
+// DB is a database whose operations are logged when they are slow, its other methods are those of the database it
+// wraps
+type DB struct {
+	db.DB
+	threshold time.Duration
+	log       utils.SimpleLogger
+}
+
+// New returns database with its operations that take threshold or longer logged to log
+func New(database db.DB, threshold time.Duration, log utils.SimpleLogger) *DB {
+	return &DB{DB: database, threshold: threshold, log: log}
+}
+
+// NewTransaction : see db.DB.NewTransaction
+func (d *DB) NewTransaction(update bool) db.Transaction {
+	return &transaction{Transaction: d.DB.NewTransaction(update), db: d}
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(true)
+	if err := fn(txn); err != nil {
+		return db.CloseAndWrapOnError(txn.Discard, err)
+	}
+	return db.CloseAndWrapOnError(txn.Discard, txn.Commit())
+}
+
+// observe logs op on key if it took the threshold or longer since start, less the time spent out of the database
+func (d *DB) observe(op string, key []byte, start time.Time, outside time.Duration) {
+	took := time.Since(start) - outside
+	if took < d.threshold {
+		return
+	}
+	fields := []any{"op", op, "took", took}
+	if len(key) > 0 {
+		fields = append(fields, "bucket", key[0], "keySize", len(key))
+	}
+	d.log.Warnw("Slow database operation", fields...)
+}
+
+type transaction struct {
+	db.Transaction
+	db *DB
+}
+
+func (t *transaction) Get(key []byte, cb func([]byte) error) error {
+	var inCallback time.Duration
+	start := time.Now()
+	err := t.Transaction.Get(key, func(val []byte) error {
+		callbackStart := time.Now()
+		defer func() {
+			inCallback += time.Since(callbackStart)
+		}()
+		return cb(val)
+	})
+	t.db.observe("get", key, start, inCallback)
+	return err
+}
+
+func (t *transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	var inCallback time.Duration
+	start := time.Now()
+	err := t.Transaction.MultiGet(keys, func(i int, val []byte) error {
+		callbackStart := time.Now()
+		defer func() {
+			inCallback += time.Since(callbackStart)
+		}()
+		return cb(i, val)
+	})
+	var first []byte
+	if len(keys) > 0 {
+		first = keys[0]
+	}
+	t.db.observe("multi-get", first, start, inCallback)
+	return err
+}
+
+func (t *transaction) Set(key, val []byte) error {
+	start := time.Now()
+	err := t.Transaction.Set(key, val)
+	t.db.observe("set", key, start, 0)
+	return err
+}
+
+func (t *transaction) Delete(key []byte) error {
+	start := time.Now()
+	err := t.Transaction.Delete(key)
+	t.db.observe("delete", key, start, 0)
+	return err
+}
+
+func (t *transaction) DeletePrefix(prefix []byte) error {
+	start := time.Now()
+	err := t.Transaction.DeletePrefix(prefix)
+	t.db.observe("delete-prefix", prefix, start, 0)
+	return err
+}
+
+func (t *transaction) Commit() error {
+	start := time.Now()
+	err := t.Transaction.Commit()
+	t.db.observe("commit", nil, start, 0)
+	return err
+}
+
+func (t *transaction) NewIterator() (db.Iterator, error) {
+	it, err := t.Transaction.NewIterator()
+	if err != nil {
+		return nil, err
+	}
+	return &iterator{Iterator: it, db: t.db}, nil
+}
+
+func (t *transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	it, err := t.Transaction.NewIteratorWithBounds(lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	return &iterator{Iterator: it, db: t.db}, nil
+}
+
+// iterator logs the moves that are slow, with the key they move to, the time between the moves is spent out of the
+// database
+type iterator struct {
+	db.Iterator
+	db *DB
+}
+
+func (it *iterator) move(op string, move func() bool, key []byte) bool {
+	start := time.Now()
+	valid := move()
+	if valid {
+		key = it.Key()
+	}
+	it.db.observe(op, key, start, 0)
+	return valid
+}
+
+func (it *iterator) Next() bool {
+	return it.move("iterator-next", it.Iterator.Next, nil)
+}
+
+func (it *iterator) Prev() bool {
+	return it.move("iterator-prev", it.Iterator.Prev, nil)
+}
+
+func (it *iterator) Seek(key []byte) bool {
+	return it.move("iterator-seek", func() bool { return it.Iterator.Seek(key) }, key)
+}
+
+func (it *iterator) SeekLast(prefix []byte) bool {
+	return it.move("iterator-seek-last", func() bool { return it.Iterator.SeekLast(prefix) }, prefix)
+}
+
+func (it *iterator) Value() ([]byte, error) {
+	start := time.Now()
+	value, err := it.Iterator.Value()
+	it.db.observe("iterator-value", it.Key(), start, 0)
+	return value, err
+}
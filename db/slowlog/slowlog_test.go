@@ -0,0 +1,74 @@
+package slowlog_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/slowlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the operations logged as slow
+type recorder struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+func (r *recorder) Debugw(string, ...any) {}
+func (r *recorder) Infow(string, ...any)  {}
+func (r *recorder) Errorw(string, ...any) {}
+
+func (r *recorder) Warnw(_ string, keysAndValues ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, keysAndValues[1].(string))
+}
+
+func (r *recorder) logged() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ops := r.ops
+	r.ops = nil
+	return ops
+}
+
+func TestDB(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+
+	t.Run("every operation is slow", func(t *testing.T) {
+		log := new(recorder)
+		slowDB := slowlog.New(testDB, 0, log)
+		require.NoError(t, slowDB.Update(func(txn db.Transaction) error {
+			require.NoError(t, txn.Set(db.Class.Key([]byte{1}), []byte("class")))
+			require.NoError(t, txn.Delete(db.Class.Key([]byte{2})))
+			return txn.Get(db.Class.Key([]byte{1}), func([]byte) error { return nil })
+		}))
+		require.NoError(t, slowDB.View(func(txn db.Transaction) error {
+			require.NoError(t, txn.MultiGet([][]byte{db.Class.Key([]byte{1})}, func(int, []byte) error { return nil }))
+			return db.IterateBucket(txn, db.Class, func(_, _ []byte) error { return nil })
+		}))
+		assert.Equal(t, []string{
+			"set", "delete", "get", "commit", "multi-get", "iterator-seek", "iterator-value", "iterator-next",
+		}, log.logged())
+	})
+
+	t.Run("the callbacks are not counted", func(t *testing.T) {
+		log := new(recorder)
+		slowDB := slowlog.New(testDB, 50*time.Millisecond, log)
+		require.NoError(t, slowDB.View(func(txn db.Transaction) error {
+			return txn.Get(db.Class.Key([]byte{1}), func(val []byte) error {
+				time.Sleep(100 * time.Millisecond)
+				assert.Equal(t, []byte("class"), val)
+				return nil
+			})
+		}))
+		assert.Empty(t, log.logged())
+	})
+}
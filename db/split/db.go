@@ -0,0 +1,157 @@
+// Package split implements a database that keeps the state in a store of its own, usually on a faster volume than
+// the history of the chain, which grows much larger but is read much less. The keys of the StateBuckets are kept in
+// the state store and the other keys in the chain store, so the split is transparent to the users of the database,
+// and each store is compacted on its own.
+package split
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	_ db.DB        = (*DB)(nil)
+	_ db.Estimator = (*DB)(nil)
+	_ db.Meterer   = (*DB)(nil)
+)
+
+// StateBuckets are the buckets that are kept in the state store: the nodes of the tries and the classes and nonces
+// of the contracts, which are read by every execution, and the history of their values, which is written with them
+// so that the tries and the history a block is reverted with are committed at once
+var StateBuckets = []db.Bucket{
+	db.StateTrie,
+	db.ContractClassHash,
+	db.ContractStorage,
+	db.ContractNonce,
+	db.ClassesTrie,
+	db.ContractDeploymentHeight,
+	db.ContractStorageHistory,
+	db.ContractNonceHistory,
+	db.ContractClassHashHistory,
+	db.StateHistoryStart,
+}
+
+type DB struct {
+	chain db.DB
+	state db.DB
+	// inState are the buckets of the state store
+	inState [256]bool
+}
+
+// New returns a database whose StateBuckets are kept in state and whose other buckets are kept in chain. The
+// commits of the database are not atomic across the stores, the chain recovers from those that are interrupted, see
+// blockchain.Blockchain.RecoverInterruptedCommit.
+func New(chain, state db.DB) *DB {
+	d := &DB{chain: chain, state: state}
+	for _, bucket := range StateBuckets {
+		d.inState[bucket] = true
+	}
+	return d
+}
+
+// Chain returns the store of the history of the chain
+func (d *DB) Chain() db.DB {
+	return d.chain
+}
+
+// State returns the store of the state
+func (d *DB) State() db.DB {
+	return d.state
+}
+
+// NewTransaction : see db.DB.NewTransaction. The transactions of the stores are always opened in the same order, so
+// that the write transactions do not deadlock on their locks.
+func (d *DB) NewTransaction(update bool) db.Transaction {
+	state := d.state.NewTransaction(update)
+	return &transaction{
+		db:    d,
+		state: state,
+		chain: d.chain.NewTransaction(update),
+	}
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(true)
+	if err := fn(txn); err != nil {
+		return db.CloseAndWrapOnError(txn.Discard, err)
+	}
+	return db.CloseAndWrapOnError(txn.Discard, txn.Commit())
+}
+
+// Backup : see db.DB.Backup. The chain and the state stores are backed up to the chain and state directories of dir.
+func (d *DB) Backup(dir string) error {
+	if err := d.chain.Backup(filepath.Join(dir, "chain")); err != nil {
+		return err
+	}
+	return d.state.Backup(filepath.Join(dir, "state"))
+}
+
+// CompactRange : see db.DB.CompactRange. The range is compacted in both stores.
+func (d *DB) CompactRange(start, end []byte) error {
+	if err := d.chain.CompactRange(start, end); err != nil {
+		return err
+	}
+	return d.state.CompactRange(start, end)
+}
+
+// Close : see io.Closer.Close
+func (d *DB) Close() error {
+	return errors.Join(d.chain.Close(), d.state.Close())
+}
+
+// Impl : see db.DB.Impl
+func (d *DB) Impl() any {
+	return d.chain.Impl()
+}
+
+// RangeKeyCount : see db.Estimator.RangeKeyCount. The keys of both stores are counted, those of a store that does
+// not estimate them are not.
+func (d *DB) RangeKeyCount(start, end []byte) (uint64, error) {
+	return d.estimate(func(estimator db.Estimator) (uint64, error) {
+		return estimator.RangeKeyCount(start, end)
+	})
+}
+
+// RangeSize : see db.Estimator.RangeSize. Like RangeKeyCount, the sizes of both stores are added up.
+func (d *DB) RangeSize(start, end []byte) (uint64, error) {
+	return d.estimate(func(estimator db.Estimator) (uint64, error) {
+		return estimator.RangeSize(start, end)
+	})
+}
+
+func (d *DB) estimate(fn func(db.Estimator) (uint64, error)) (uint64, error) {
+	var total uint64
+	for _, database := range []db.DB{d.chain, d.state} {
+		estimator, ok := database.(db.Estimator)
+		if !ok {
+			continue
+		}
+		estimate, err := fn(estimator)
+		if err != nil {
+			return 0, err
+		}
+		total += estimate
+	}
+	return total, nil
+}
+
+// Meter : see db.Meterer.Meter. The metrics of both stores are collected together, those of a store that does not
+// report them are not, and are told apart by the namespaces the stores were opened with.
+func (d *DB) Meter() prometheus.Collector {
+	return db.MetersOf(d.chain, d.state)
+}
+
+// inStateStore returns whether key is kept in the state store
+func (d *DB) inStateStore(key []byte) bool {
+	return len(key) > 0 && d.inState[key[0]]
+}
@@ -0,0 +1,170 @@
+package split_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/split"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// has returns whether key is in database
+func has(t *testing.T, database db.DB, key []byte) bool {
+	err := database.View(func(txn db.Transaction) error {
+		return txn.Get(key, func([]byte) error { return nil })
+	})
+	if err != nil {
+		require.ErrorIs(t, err, db.ErrKeyNotFound)
+	}
+	return err == nil
+}
+
+func TestDB(t *testing.T) {
+	chain, state := pebble.NewMemTest(), pebble.NewMemTest()
+	splitDB := split.New(chain, state)
+	t.Cleanup(func() {
+		require.NoError(t, splitDB.Close())
+	})
+
+	keys := [][]byte{
+		db.BlockHeadersByNumber.Key([]byte{1}),
+		db.StateTrie.Key([]byte{1}),
+		db.StateTrie.Key([]byte{2}),
+		db.ContractStorage.Key([]byte{1}),
+		db.ReceiptsByBlockNumberAndIndex.Key([]byte{1}),
+		db.ReceiptsByBlockNumberAndIndex.Key([]byte{2}),
+	}
+	require.NoError(t, splitDB.Update(func(txn db.Transaction) error {
+		for _, key := range keys {
+			if err := txn.Set(key, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// iterate returns the keys of database in order and checks their values, and that they are iterated backwards
+	// in the reverse order
+	iterate := func(database db.DB) [][]byte {
+		var iterated, reversed [][]byte
+		require.NoError(t, database.View(func(txn db.Transaction) error {
+			it, err := txn.NewIterator()
+			if err != nil {
+				return err
+			}
+			for it.Next() {
+				value, err := it.Value()
+				require.NoError(t, err)
+				assert.Equal(t, it.Key(), value)
+				iterated = append(iterated, it.Key())
+			}
+			for valid := it.SeekLast(nil); valid; valid = it.Prev() {
+				reversed = append([][]byte{it.Key()}, reversed...)
+			}
+			return it.Close()
+		}))
+		assert.Equal(t, iterated, reversed)
+		return iterated
+	}
+
+	t.Run("state keys are kept in the state store", func(t *testing.T) {
+		for _, key := range keys {
+			inState := key[0] == byte(db.StateTrie) || key[0] == byte(db.ContractStorage)
+			assert.Equal(t, inState, has(t, state, key), "%x", key)
+			assert.Equal(t, !inState, has(t, chain, key), "%x", key)
+			assert.True(t, has(t, splitDB, key), "%x", key)
+		}
+	})
+
+	t.Run("the keys of both stores are iterated in order", func(t *testing.T) {
+		sorted := [][]byte{keys[1], keys[2], keys[3], keys[0], keys[4], keys[5]}
+		assert.Equal(t, sorted, iterate(splitDB))
+
+		require.NoError(t, splitDB.View(func(txn db.Transaction) error {
+			it, err := txn.NewIterator()
+			if err != nil {
+				return err
+			}
+			assert.True(t, it.Seek(db.BlockHeadersByNumber.Key()))
+			assert.Equal(t, keys[0], it.Key())
+			assert.True(t, it.Prev())
+			assert.Equal(t, keys[3], it.Key())
+			assert.True(t, it.Next())
+			assert.Equal(t, keys[0], it.Key())
+			assert.True(t, it.Next())
+			assert.Equal(t, keys[4], it.Key())
+
+			assert.True(t, it.SeekLast(db.StateTrie.Key()))
+			assert.Equal(t, keys[2], it.Key())
+			return it.Close()
+		}))
+	})
+
+	t.Run("keys of both stores are read at once", func(t *testing.T) {
+		values := make(map[int][]byte)
+		require.NoError(t, splitDB.View(func(txn db.Transaction) error {
+			return txn.MultiGet([][]byte{keys[3], db.StateTrie.Key([]byte{3}), keys[0]}, func(i int, value []byte) error {
+				values[i] = append([]byte{}, value...)
+				return nil
+			})
+		}))
+		assert.Equal(t, map[int][]byte{0: keys[3], 2: keys[0]}, values)
+	})
+
+	t.Run("deleted prefixes are deleted from their store", func(t *testing.T) {
+		require.NoError(t, splitDB.Update(func(txn db.Transaction) error {
+			if err := txn.DeletePrefix(db.StateTrie.Key()); err != nil {
+				return err
+			}
+			return txn.DeletePrefix(db.BlockHeadersByNumber.Key())
+		}))
+		assert.Equal(t, [][]byte{keys[3], keys[4], keys[5]}, iterate(splitDB))
+
+		require.NoError(t, splitDB.Update(func(txn db.Transaction) error {
+			return txn.DeletePrefix(nil)
+		}))
+		assert.Empty(t, iterate(chain))
+		assert.Empty(t, iterate(state))
+	})
+}
+
+func TestMoveState(t *testing.T) {
+	chain := pebble.NewMemTest()
+	keys := [][]byte{
+		db.BlockHeadersByNumber.Key([]byte{1}),
+		db.ClassesTrie.Key([]byte{1}),
+		db.ContractNonce.Key([]byte{1}),
+		// the history of the state is moved with it
+		db.ContractStorageHistory.Key([]byte{1}),
+	}
+	require.NoError(t, chain.Update(func(txn db.Transaction) error {
+		for _, key := range keys {
+			if err := txn.Set(key, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	state := pebble.NewMemTest()
+	splitDB := split.New(chain, state)
+	t.Cleanup(func() {
+		require.NoError(t, splitDB.Close())
+	})
+	moved, err := splitDB.MoveState()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), moved)
+
+	assert.True(t, has(t, chain, keys[0]))
+	for _, key := range keys[1:] {
+		assert.False(t, has(t, chain, key))
+		assert.True(t, has(t, state, key))
+		assert.True(t, has(t, splitDB, key))
+	}
+
+	moved, err = splitDB.MoveState()
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+}
@@ -0,0 +1,144 @@
+package split
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var _ db.Iterator = (*iterator)(nil)
+
+// iterator merges the iterators of the chain and the state stores in the order of their keys, which are never in
+// both stores
+type iterator struct {
+	chain      db.Iterator
+	state      db.Iterator
+	chainValid bool
+	stateValid bool
+	positioned bool
+	// reverse is set while the iterator moves backwards, the iterators of both stores are then positioned at or
+	// before the current key instead of at or after it
+	reverse bool
+}
+
+// Valid : see db.Transaction.Iterator.Valid
+func (i *iterator) Valid() bool {
+	return i.chainValid || i.stateValid
+}
+
+// Key : see db.Transaction.Iterator.Key
+func (i *iterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.current().Key()
+}
+
+// Value : see db.Transaction.Iterator.Value
+func (i *iterator) Value() ([]byte, error) {
+	if !i.Valid() {
+		return nil, nil
+	}
+	return i.current().Value()
+}
+
+// Next : see db.Transaction.Iterator.Next
+func (i *iterator) Next() bool {
+	if !i.positioned {
+		i.positioned = true
+		i.chainValid, i.stateValid = i.chain.Next(), i.state.Next()
+		return i.Valid()
+	}
+	if !i.Valid() {
+		return false
+	}
+
+	if i.reverse {
+		// the iterators are moved to the first keys after the current one
+		key := i.Key()
+		i.reverse = false
+		i.chainValid, i.stateValid = seekAfter(i.chain, key), seekAfter(i.state, key)
+		return i.Valid()
+	}
+	if i.current() == i.chain {
+		i.chainValid = i.chain.Next()
+	} else {
+		i.stateValid = i.state.Next()
+	}
+	return i.Valid()
+}
+
+// Seek : see db.Transaction.Iterator.Seek
+func (i *iterator) Seek(key []byte) bool {
+	i.positioned, i.reverse = true, false
+	i.chainValid, i.stateValid = i.chain.Seek(key), i.state.Seek(key)
+	return i.Valid()
+}
+
+// Prev : see db.Transaction.Iterator.Prev
+func (i *iterator) Prev() bool {
+	if !i.positioned {
+		return i.SeekLast(nil)
+	}
+	if !i.Valid() {
+		return false
+	}
+
+	if !i.reverse {
+		// the iterators are moved to the last keys before the current one
+		key := i.Key()
+		i.reverse = true
+		i.chainValid, i.stateValid = seekBefore(i.chain, key), seekBefore(i.state, key)
+		return i.Valid()
+	}
+	if i.current() == i.chain {
+		i.chainValid = i.chain.Prev()
+	} else {
+		i.stateValid = i.state.Prev()
+	}
+	return i.Valid()
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *iterator) SeekLast(prefix []byte) bool {
+	i.positioned, i.reverse = true, true
+	i.chainValid, i.stateValid = i.chain.SeekLast(prefix), i.state.SeekLast(prefix)
+	return i.Valid()
+}
+
+// Close : see db.Transaction.Iterator.Close
+func (i *iterator) Close() error {
+	return errors.Join(i.chain.Close(), i.state.Close())
+}
+
+// current returns the iterator that is positioned at the smallest key, or at the largest one in reverse
+func (i *iterator) current() db.Iterator {
+	if !i.chainValid || !i.stateValid {
+		if i.chainValid {
+			return i.chain
+		}
+		return i.state
+	}
+	if (bytes.Compare(i.chain.Key(), i.state.Key()) < 0) != i.reverse {
+		return i.chain
+	}
+	return i.state
+}
+
+// seekAfter moves it to the first key after key
+func seekAfter(it db.Iterator, key []byte) bool {
+	if it.Seek(key) && bytes.Equal(it.Key(), key) {
+		return it.Next()
+	}
+	return it.Valid()
+}
+
+// seekBefore moves it to the last key before key
+func seekBefore(it db.Iterator, key []byte) bool {
+	if !it.Seek(key) {
+		// all the keys are before key
+		return it.SeekLast(nil)
+	}
+	return it.Prev()
+}
@@ -0,0 +1,53 @@
+package split
+
+import (
+	"github.com/NethermindEth/juno/db"
+)
+
+// moveBatchSize is the size of the writes to the state store that are committed at once by MoveState
+const moveBatchSize = 64 << 20
+
+// MoveState moves the keys of the StateBuckets that are in the chain store to the state store, which is how the
+// state of a database that was not split is moved to its own store, and returns the number of keys it moved. The
+// keys are deleted from the chain store once they are all copied, so MoveState moves them again if it is
+// interrupted.
+func (d *DB) MoveState() (uint64, error) {
+	var moved uint64
+	batch := db.NewBatch(d.state)
+	err := d.chain.View(func(txn db.Transaction) error {
+		for _, bucket := range StateBuckets {
+			if err := db.IterateBucket(txn, bucket, func(key, value []byte) error {
+				if err := batch.Set(key, value); err != nil {
+					return err
+				}
+				moved++
+				if batch.Size() < moveBatchSize {
+					return nil
+				}
+				if err := batch.Commit(); err != nil {
+					return err
+				}
+				batch = db.NewBatch(d.state)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, db.CloseAndWrapOnError(batch.Discard, err)
+	}
+	if err = batch.Commit(); err != nil || moved == 0 {
+		return 0, err
+	}
+
+	return moved, d.chain.Update(func(txn db.Transaction) error {
+		for _, bucket := range StateBuckets {
+			if err := txn.DeletePrefix(bucket.Key()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+package split
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var (
+	_ db.Transaction  = (*transaction)(nil)
+	_ db.PendingSizer = (*transaction)(nil)
+)
+
+type transaction struct {
+	db    *DB
+	chain db.Transaction
+	state db.Transaction
+}
+
+// Discard : see db.Transaction.Discard
+func (t *transaction) Discard() error {
+	return errors.Join(t.state.Discard(), t.chain.Discard())
+}
+
+// Commit : see db.Transaction.Commit. The state is committed before the chain, the writes to the chain are discarded
+// if the commit of the state fails.
+func (t *transaction) Commit() error {
+	err := t.state.Commit()
+	if err == nil {
+		err = t.chain.Commit()
+	}
+	return db.CloseAndWrapOnError(t.Discard, err)
+}
+
+// PendingSize : see db.PendingSizer.PendingSize, it is the size of the writes to both stores
+func (t *transaction) PendingSize() uint64 {
+	var size uint64
+	for _, txn := range []db.Transaction{t.chain, t.state} {
+		if sizer, ok := txn.(db.PendingSizer); ok {
+			size += sizer.PendingSize()
+		}
+	}
+	return size
+}
+
+// Set : see db.Transaction.Set
+func (t *transaction) Set(key, val []byte) error {
+	return t.of(key).Set(key, val)
+}
+
+// Delete : see db.Transaction.Delete
+func (t *transaction) Delete(key []byte) error {
+	return t.of(key).Delete(key)
+}
+
+// DeletePrefix : see db.Transaction.DeletePrefix. An empty prefix deletes the keys of both stores.
+func (t *transaction) DeletePrefix(prefix []byte) error {
+	if len(prefix) == 0 {
+		return errors.Join(t.chain.DeletePrefix(prefix), t.state.DeletePrefix(prefix))
+	}
+	return t.of(prefix).DeletePrefix(prefix)
+}
+
+// Get : see db.Transaction.Get
+func (t *transaction) Get(key []byte, cb func([]byte) error) error {
+	return t.of(key).Get(key, cb)
+}
+
+// MultiGet : see db.Transaction.MultiGet. The keys of each store are read from it at once.
+func (t *transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	var chainKeys, stateKeys [][]byte
+	var chainIndexes, stateIndexes []int
+	for i, key := range keys {
+		if t.db.inStateStore(key) {
+			stateKeys, stateIndexes = append(stateKeys, key), append(stateIndexes, i)
+		} else {
+			chainKeys, chainIndexes = append(chainKeys, key), append(chainIndexes, i)
+		}
+	}
+	if err := t.chain.MultiGet(chainKeys, func(i int, val []byte) error {
+		return cb(chainIndexes[i], val)
+	}); err != nil {
+		return err
+	}
+	return t.state.MultiGet(stateKeys, func(i int, val []byte) error {
+		return cb(stateIndexes[i], val)
+	})
+}
+
+// NewIterator : see db.Transaction.NewIterator. The iterator merges the keys of both stores.
+func (t *transaction) NewIterator() (db.Iterator, error) {
+	return t.NewIteratorWithBounds(nil, nil)
+}
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds, the bounds are passed to both stores
+func (t *transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	chain, err := t.chain.NewIteratorWithBounds(lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	state, err := t.state.NewIteratorWithBounds(lower, upper)
+	if err != nil {
+		return nil, db.CloseAndWrapOnError(chain.Close, err)
+	}
+	return &iterator{chain: chain, state: state}, nil
+}
+
+// Impl : see db.Transaction.Impl
+func (t *transaction) Impl() any {
+	return t.chain.Impl()
+}
+
+// of returns the transaction of the store key is kept in
+func (t *transaction) of(key []byte) db.Transaction {
+	if t.db.inStateStore(key) {
+		return t.state
+	}
+	return t.chain
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/encoder"
+)
+
+// KeyCodec encodes the keys of a Table, which follow its bucket. The encodings of the keys sort like the keys, so
+// that a Table iterates over them in order.
+type KeyCodec[K any] interface {
+	EncodeKey(key K) []byte
+	DecodeKey(encoded []byte) (K, error)
+}
+
+// ValueCodec encodes the values of a Table
+type ValueCodec[V any] interface {
+	EncodeValue(value V) ([]byte, error)
+	DecodeValue(encoded []byte) (V, error)
+}
+
+// Table is a bucket whose keys are K and whose values are V, which it encodes with its codecs so that its users do
+// not build the keys or marshal the values themselves
+type Table[K, V any] struct {
+	bucket Bucket
+	key    KeyCodec[K]
+	value  ValueCodec[V]
+}
+
+// NewTable returns the Table of bucket, whose keys are encoded with key and values with value
+func NewTable[K, V any](bucket Bucket, key KeyCodec[K], value ValueCodec[V]) *Table[K, V] {
+	return &Table[K, V]{bucket: bucket, key: key, value: value}
+}
+
+// Bucket returns the bucket of t
+func (t *Table[K, V]) Bucket() Bucket {
+	return t.bucket
+}
+
+// Key returns the key of key in the database
+func (t *Table[K, V]) Key(key K) []byte {
+	return t.bucket.Key(t.key.EncodeKey(key))
+}
+
+// Get returns the value of key, or ErrKeyNotFound
+func (t *Table[K, V]) Get(txn Transaction, key K) (V, error) {
+	var value V
+	err := txn.Get(t.Key(key), func(encoded []byte) error {
+		var err error
+		value, err = t.value.DecodeValue(encoded)
+		return err
+	})
+	return value, err
+}
+
+// GetMany calls fn with the index in keys and the value of each of keys that is present, which are read at once,
+// see Transaction.MultiGet
+func (t *Table[K, V]) GetMany(txn Transaction, keys []K, fn func(i int, value V) error) error {
+	encodedKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		encodedKeys[i] = t.Key(key)
+	}
+	return txn.MultiGet(encodedKeys, func(i int, encoded []byte) error {
+		value, err := t.value.DecodeValue(encoded)
+		if err != nil {
+			return fmt.Errorf("decode the value of key %x: %w", encodedKeys[i], err)
+		}
+		return fn(i, value)
+	})
+}
+
+// Put sets the value of key
+func (t *Table[K, V]) Put(txn Transaction, key K, value V) error {
+	encoded, err := t.value.EncodeValue(value)
+	if err != nil {
+		return err
+	}
+	return txn.Set(t.Key(key), encoded)
+}
+
+// Delete deletes key
+func (t *Table[K, V]) Delete(txn Transaction, key K) error {
+	return txn.Delete(t.Key(key))
+}
+
+// Iterate calls fn with the keys of t and their values, see IterateBucket. The key From starts at is a key of the
+// database, see Key.
+func (t *Table[K, V]) Iterate(txn Transaction, fn func(key K, value V) error, opts ...IterateOption) error {
+	return t.IteratePrefix(txn, nil, fn, opts...)
+}
+
+// IteratePrefix is Iterate for the keys whose encodings start with prefix, such as the encoding of the first part
+// of a composite key
+func (t *Table[K, V]) IteratePrefix(txn Transaction, prefix []byte, fn func(key K, value V) error,
+	opts ...IterateOption,
+) error {
+	return IteratePrefix(txn, t.bucket.Key(prefix), func(encodedKey, encodedValue []byte) error {
+		key, err := t.key.DecodeKey(encodedKey[1:])
+		if err != nil {
+			return fmt.Errorf("decode key %x: %w", encodedKey, err)
+		}
+		value, err := t.value.DecodeValue(encodedValue)
+		if err != nil {
+			return fmt.Errorf("decode the value of key %x: %w", encodedKey, err)
+		}
+		return fn(key, value)
+	}, opts...)
+}
+
+// Value is a bucket that holds a single value, whose key is the bucket itself
+type Value[V any] struct {
+	bucket Bucket
+	value  ValueCodec[V]
+}
+
+// NewValue returns the Value of bucket, which is encoded with value
+func NewValue[V any](bucket Bucket, value ValueCodec[V]) *Value[V] {
+	return &Value[V]{bucket: bucket, value: value}
+}
+
+// Key returns the key of the value in the database
+func (v *Value[V]) Key() []byte {
+	return v.bucket.Key()
+}
+
+// Get returns the value, or ErrKeyNotFound if it is not set
+func (v *Value[V]) Get(txn Transaction) (V, error) {
+	var value V
+	err := txn.Get(v.Key(), func(encoded []byte) error {
+		var err error
+		value, err = v.value.DecodeValue(encoded)
+		return err
+	})
+	return value, err
+}
+
+// Put sets the value
+func (v *Value[V]) Put(txn Transaction, value V) error {
+	encoded, err := v.value.EncodeValue(value)
+	if err != nil {
+		return err
+	}
+	return txn.Set(v.Key(), encoded)
+}
+
+// Delete deletes the value
+func (v *Value[V]) Delete(txn Transaction) error {
+	return txn.Delete(v.Key())
+}
+
+// Uint64Codec encodes uint64s as 8 big-endian bytes, like block numbers
+type Uint64Codec struct{}
+
+func (Uint64Codec) EncodeKey(key uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, key)
+}
+
+func (Uint64Codec) DecodeKey(encoded []byte) (uint64, error) {
+	if len(encoded) != 8 {
+		return 0, fmt.Errorf("uint64 is %d bytes", len(encoded))
+	}
+	return binary.BigEndian.Uint64(encoded), nil
+}
+
+func (c Uint64Codec) EncodeValue(value uint64) ([]byte, error) {
+	return c.EncodeKey(value), nil
+}
+
+func (c Uint64Codec) DecodeValue(encoded []byte) (uint64, error) {
+	return c.DecodeKey(encoded)
+}
+
+// FeltCodec encodes felts as their felt.Bytes big-endian bytes, like hashes and addresses
+type FeltCodec struct{}
+
+func (FeltCodec) EncodeKey(key *felt.Felt) []byte {
+	return key.Marshal()
+}
+
+func (FeltCodec) DecodeKey(encoded []byte) (*felt.Felt, error) {
+	if len(encoded) != felt.Bytes {
+		return nil, fmt.Errorf("felt is %d bytes", len(encoded))
+	}
+	return new(felt.Felt).SetBytes(encoded), nil
+}
+
+func (c FeltCodec) EncodeValue(value *felt.Felt) ([]byte, error) {
+	return c.EncodeKey(value), nil
+}
+
+func (c FeltCodec) DecodeValue(encoded []byte) (*felt.Felt, error) {
+	return c.DecodeKey(encoded)
+}
+
+// BytesCodec stores bytes as they are, the values it decodes are copies since the values a transaction passes to
+// the callback of Get are only valid until it returns
+type BytesCodec struct{}
+
+func (BytesCodec) EncodeKey(key []byte) []byte {
+	return key
+}
+
+func (BytesCodec) DecodeKey(encoded []byte) ([]byte, error) {
+	return encoded, nil
+}
+
+func (BytesCodec) EncodeValue(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+func (BytesCodec) DecodeValue(encoded []byte) ([]byte, error) {
+	return bytes.Clone(encoded), nil
+}
+
+// EncodedCodec encodes values with the encoder package, the types of the interfaces it decodes have to be registered
+// with encoder.RegisterType
+type EncodedCodec[V any] struct{}
+
+func (EncodedCodec[V]) EncodeValue(value V) ([]byte, error) {
+	return encoder.Marshal(value)
+}
+
+func (EncodedCodec[V]) DecodeValue(encoded []byte) (V, error) {
+	var value V
+	err := encoder.Unmarshal(encoded, &value)
+	return value, err
+}
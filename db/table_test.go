@@ -0,0 +1,146 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tableValue struct {
+	Name   string
+	Number uint64
+}
+
+func TestTable(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	table := db.NewTable[uint64, *tableValue](db.BlockHeadersByNumber, db.Uint64Codec{},
+		db.EncodedCodec[*tableValue]{})
+	assert.Equal(t, db.BlockHeadersByNumber.Key([]byte{0, 0, 0, 0, 0, 0, 0, 2}), table.Key(2))
+
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for _, number := range []uint64{3, 1, 2} {
+			if err := table.Put(txn, number, &tableValue{Name: "block", Number: number}); err != nil {
+				return err
+			}
+		}
+		// a key of another bucket
+		return txn.Set(db.StateUpdatesByBlockNumber.Key([]byte{0}), []byte{1})
+	}))
+
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		value, err := table.Get(txn, 2)
+		require.NoError(t, err)
+		assert.Equal(t, &tableValue{Name: "block", Number: 2}, value)
+
+		_, err = table.Get(txn, 4)
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+
+		var numbers []uint64
+		require.NoError(t, table.Iterate(txn, func(number uint64, value *tableValue) error {
+			assert.Equal(t, number, value.Number)
+			numbers = append(numbers, number)
+			return nil
+		}))
+		assert.Equal(t, []uint64{1, 2, 3}, numbers)
+
+		values := make(map[int]uint64)
+		require.NoError(t, table.GetMany(txn, []uint64{4, 3, 1}, func(i int, value *tableValue) error {
+			values[i] = value.Number
+			return nil
+		}))
+		assert.Equal(t, map[int]uint64{1: 3, 2: 1}, values)
+
+		numbers = nil
+		require.NoError(t, table.Iterate(txn, func(number uint64, _ *tableValue) error {
+			numbers = append(numbers, number)
+			return nil
+		}, db.From(table.Key(2)), db.Reverse()))
+		assert.Equal(t, []uint64{2, 1}, numbers)
+		return nil
+	}))
+
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		return table.Delete(txn, 2)
+	}))
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		_, err := table.Get(txn, 2)
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+		return nil
+	}))
+
+	t.Run("prefix", func(t *testing.T) {
+		bytesTable := db.NewTable[[]byte, []byte](db.MigrationCheckpoints, db.BytesCodec{}, db.BytesCodec{})
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			for _, key := range []string{"a1", "b1", "b2", "c1"} {
+				if err := bytesTable.Put(txn, []byte(key), []byte("value "+key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+		values := map[string]string{}
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			return bytesTable.IteratePrefix(txn, []byte("b"), func(key, value []byte) error {
+				values[string(key)] = string(value)
+				return nil
+			})
+		}))
+		assert.Equal(t, map[string]string{"b1": "value b1", "b2": "value b2"}, values)
+	})
+
+	t.Run("keys that do not decode", func(t *testing.T) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.BlockHeadersByNumber.Key([]byte{1}), []byte{})
+		}))
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			err := table.Iterate(txn, func(uint64, *tableValue) error { return nil })
+			assert.ErrorContains(t, err, "uint64 is 1 bytes")
+			return nil
+		}))
+	})
+}
+
+func TestValue(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	value := db.NewValue[*felt.Felt](db.ChainHeight, db.FeltCodec{})
+	assert.Equal(t, db.ChainHeight.Key(), value.Key())
+
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		_, err := value.Get(txn)
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+		return value.Put(txn, new(felt.Felt).SetUint64(42))
+	}))
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		got, err := value.Get(txn)
+		require.NoError(t, err)
+		assert.Equal(t, new(felt.Felt).SetUint64(42), got)
+		return value.Delete(txn)
+	}))
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		_, err := value.Get(txn)
+		assert.ErrorIs(t, err, db.ErrKeyNotFound)
+		return nil
+	}))
+
+	t.Run("value that does not decode", func(t *testing.T) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.ChainHeight.Key(), []byte{1, 2})
+		}))
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			_, err := value.Get(txn)
+			assert.ErrorContains(t, err, "felt is 2 bytes")
+			return nil
+		}))
+	})
+}
@@ -0,0 +1,243 @@
+// Package tiered implements a database that keeps its cold data in a second database, usually on a slower and
+// cheaper volume. The keys under the tiered prefixes are moved to the cold database with Demote, and reads of the
+// keys that are not in the hot database fall through to the cold one, so the tiering is transparent to the users of
+// the database.
+package tiered
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	_ db.DB        = (*DB)(nil)
+	_ db.Estimator = (*DB)(nil)
+	_ db.Meterer   = (*DB)(nil)
+)
+
+// maxTrackedKeys bounds the number of keys whose cold reads are counted between two calls to ColdReads
+const maxTrackedKeys = 1 << 16
+
+type DB struct {
+	hot  db.DB
+	cold db.DB
+	// prefixes are the prefixes of the keys that may be in the cold database, the reads of the other keys do not
+	// fall through to it
+	prefixes [][]byte
+
+	coldReadsMu sync.Mutex
+	coldReads   map[string]uint64
+}
+
+// New returns a database whose keys under prefixes may be moved from hot to cold
+func New(hot, cold db.DB, prefixes [][]byte) *DB {
+	return &DB{
+		hot:       hot,
+		cold:      cold,
+		prefixes:  prefixes,
+		coldReads: make(map[string]uint64),
+	}
+}
+
+// Hot returns the database the new data is written to
+func (d *DB) Hot() db.DB {
+	return d.hot
+}
+
+// Cold returns the database the cold data is moved to
+func (d *DB) Cold() db.DB {
+	return d.cold
+}
+
+// NewTransaction : see db.DB.NewTransaction
+func (d *DB) NewTransaction(update bool) db.Transaction {
+	return &transaction{
+		db:  d,
+		hot: d.hot.NewTransaction(update),
+	}
+}
+
+// View : see db.DB.View
+func (d *DB) View(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(false)
+	return db.CloseAndWrapOnError(txn.Discard, fn(txn))
+}
+
+// Update : see db.DB.Update
+func (d *DB) Update(fn func(txn db.Transaction) error) error {
+	txn := d.NewTransaction(true)
+	if err := fn(txn); err != nil {
+		return db.CloseAndWrapOnError(txn.Discard, err)
+	}
+	return db.CloseAndWrapOnError(txn.Discard, txn.Commit())
+}
+
+// Backup : see db.DB.Backup. The hot and the cold databases are backed up to the hot and cold directories of dir.
+func (d *DB) Backup(dir string) error {
+	if err := d.hot.Backup(filepath.Join(dir, "hot")); err != nil {
+		return err
+	}
+	return d.cold.Backup(filepath.Join(dir, "cold"))
+}
+
+// CompactRange : see db.DB.CompactRange. The range is compacted in the hot and the cold databases.
+func (d *DB) CompactRange(start, end []byte) error {
+	if err := d.hot.CompactRange(start, end); err != nil {
+		return err
+	}
+	return d.cold.CompactRange(start, end)
+}
+
+// Close : see io.Closer.Close
+func (d *DB) Close() error {
+	return errors.Join(d.hot.Close(), d.cold.Close())
+}
+
+// Impl : see db.DB.Impl
+func (d *DB) Impl() any {
+	return d.hot.Impl()
+}
+
+// RangeKeyCount : see db.Estimator.RangeKeyCount. The keys of the hot and the cold databases are counted, those
+// of a database that does not estimate them are not.
+func (d *DB) RangeKeyCount(start, end []byte) (uint64, error) {
+	return d.estimate(func(estimator db.Estimator) (uint64, error) {
+		return estimator.RangeKeyCount(start, end)
+	})
+}
+
+// RangeSize : see db.Estimator.RangeSize. Like RangeKeyCount, the sizes of both databases are added up.
+func (d *DB) RangeSize(start, end []byte) (uint64, error) {
+	return d.estimate(func(estimator db.Estimator) (uint64, error) {
+		return estimator.RangeSize(start, end)
+	})
+}
+
+func (d *DB) estimate(fn func(db.Estimator) (uint64, error)) (uint64, error) {
+	var total uint64
+	for _, database := range []db.DB{d.hot, d.cold} {
+		estimator, ok := database.(db.Estimator)
+		if !ok {
+			continue
+		}
+		estimate, err := fn(estimator)
+		if err != nil {
+			return 0, err
+		}
+		total += estimate
+	}
+	return total, nil
+}
+
+// Meter : see db.Meterer.Meter. The metrics of the hot and the cold databases are collected together, those of a
+// database that does not report them are not, and are told apart by the namespaces the databases were opened with.
+func (d *DB) Meter() prometheus.Collector {
+	return db.MetersOf(d.hot, d.cold)
+}
+
+// tiered returns whether key may be in the cold database
+func (d *DB) tiered(key []byte) bool {
+	for _, prefix := range d.prefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsTiered returns whether some of the keys that start with prefix may be in the cold database
+func (d *DB) overlapsTiered(prefix []byte) bool {
+	for _, tieredPrefix := range d.prefixes {
+		if bytes.HasPrefix(prefix, tieredPrefix) || bytes.HasPrefix(tieredPrefix, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DB) countColdRead(key []byte) {
+	d.coldReadsMu.Lock()
+	defer d.coldReadsMu.Unlock()
+	if _, found := d.coldReads[string(key)]; found || len(d.coldReads) < maxTrackedKeys {
+		d.coldReads[string(key)]++
+	}
+}
+
+// ColdReads returns the number of reads that fell through to the cold database by key since the last call
+func (d *DB) ColdReads() map[string]uint64 {
+	d.coldReadsMu.Lock()
+	defer d.coldReadsMu.Unlock()
+	reads := d.coldReads
+	d.coldReads = make(map[string]uint64)
+	return reads
+}
+
+// Demote moves keys from the hot database to the cold one, the keys that are not in the hot database are skipped.
+// The keys are written to the cold database before they are deleted from the hot one, so that they can always be
+// read from either.
+func (d *DB) Demote(keys [][]byte) error {
+	return d.hot.Update(func(hotTxn db.Transaction) error {
+		values := make(map[string][]byte, len(keys))
+		for _, key := range keys {
+			err := hotTxn.Get(key, func(value []byte) error {
+				values[string(key)] = bytes.Clone(value)
+				return nil
+			})
+			if errors.Is(err, db.ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+		if len(values) == 0 {
+			return nil
+		}
+
+		batch := db.NewBatch(d.cold)
+		for key, value := range values {
+			if err := batch.Set([]byte(key), value); err != nil {
+				return db.CloseAndWrapOnError(batch.Discard, err)
+			}
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+		for key := range values {
+			if err := hotTxn.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Promote copies keys from the cold database back to the hot one, the keys that are already in the hot database
+// or that are not in the cold one are skipped. The cold copies are kept, so that the transactions that read the
+// keys from the cold database still find them, and Demote overwrites them.
+func (d *DB) Promote(keys [][]byte) error {
+	return d.hot.Update(func(hotTxn db.Transaction) error {
+		return d.cold.View(func(coldTxn db.Transaction) error {
+			for _, key := range keys {
+				err := hotTxn.Get(key, func([]byte) error { return nil })
+				if err == nil {
+					continue
+				} else if !errors.Is(err, db.ErrKeyNotFound) {
+					return err
+				}
+
+				err = coldTxn.Get(key, func(value []byte) error {
+					return hotTxn.Set(key, value)
+				})
+				if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
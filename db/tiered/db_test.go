@@ -0,0 +1,149 @@
+package tiered_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/tiered"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB(t *testing.T) {
+	hot, cold := pebble.NewMemTest(), pebble.NewMemTest()
+	tieredDB := tiered.New(hot, cold, [][]byte{{1}})
+	t.Cleanup(func() {
+		require.NoError(t, tieredDB.Close())
+	})
+
+	keys := [][]byte{{1, 0}, {1, 1}, {1, 2}, {1, 3}, {2, 0}}
+	require.NoError(t, tieredDB.Update(func(txn db.Transaction) error {
+		for _, key := range keys {
+			if err := txn.Set(key, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// has returns whether key is in database
+	has := func(database db.DB, key []byte) bool {
+		err := database.View(func(txn db.Transaction) error {
+			return txn.Get(key, func([]byte) error { return nil })
+		})
+		if err != nil {
+			require.ErrorIs(t, err, db.ErrKeyNotFound)
+		}
+		return err == nil
+	}
+	// iterate returns the keys of database in order and checks their values, and that they are iterated backwards
+	// in the reverse order
+	iterate := func(database db.DB) [][]byte {
+		var iterated, reversed [][]byte
+		require.NoError(t, database.View(func(txn db.Transaction) error {
+			it, err := txn.NewIterator()
+			if err != nil {
+				return err
+			}
+			for it.Next() {
+				value, err := it.Value()
+				require.NoError(t, err)
+				assert.Equal(t, it.Key(), value)
+				iterated = append(iterated, it.Key())
+			}
+			for valid := it.SeekLast(nil); valid; valid = it.Prev() {
+				reversed = append([][]byte{it.Key()}, reversed...)
+			}
+			return it.Close()
+		}))
+		assert.Equal(t, iterated, reversed)
+		return iterated
+	}
+
+	require.NoError(t, tieredDB.Demote([][]byte{{1, 0}, {1, 2}, {1, 4}}))
+
+	t.Run("demoted keys are moved to the cold database", func(t *testing.T) {
+		assert.False(t, has(hot, []byte{1, 0}))
+		assert.True(t, has(cold, []byte{1, 0}))
+		assert.True(t, has(hot, []byte{1, 1}))
+		assert.False(t, has(cold, []byte{1, 1}))
+		assert.False(t, has(cold, []byte{1, 4}), "keys that are not in the hot database are not demoted")
+	})
+
+	t.Run("reads fall through to the cold database", func(t *testing.T) {
+		for _, key := range keys {
+			require.NoError(t, tieredDB.View(func(txn db.Transaction) error {
+				return txn.Get(key, func(value []byte) error {
+					assert.Equal(t, key, value)
+					return nil
+				})
+			}))
+		}
+		assert.Equal(t, keys, iterate(tieredDB))
+
+		reads := tieredDB.ColdReads()
+		assert.Equal(t, map[string]uint64{string([]byte{1, 0}): 1, string([]byte{1, 2}): 1}, reads)
+		assert.Empty(t, tieredDB.ColdReads())
+	})
+
+	t.Run("promoted keys are read from the hot database", func(t *testing.T) {
+		require.NoError(t, tieredDB.Promote([][]byte{{1, 2}}))
+		assert.True(t, has(hot, []byte{1, 2}))
+		assert.Equal(t, keys, iterate(tieredDB), "keys in both databases are iterated once")
+		assert.True(t, has(tieredDB, []byte{1, 2}))
+		assert.Empty(t, tieredDB.ColdReads())
+	})
+
+	t.Run("deleted keys are deleted from both databases", func(t *testing.T) {
+		require.NoError(t, tieredDB.Update(func(txn db.Transaction) error {
+			for _, key := range [][]byte{{1, 0}, {1, 2}} {
+				if err := txn.Delete(key); err != nil {
+					return err
+				}
+				err := txn.Get(key, func([]byte) error { return nil })
+				require.ErrorIs(t, err, db.ErrKeyNotFound)
+			}
+			it, err := txn.NewIterator()
+			if err != nil {
+				return err
+			}
+			assert.True(t, it.Seek([]byte{1}))
+			assert.Equal(t, []byte{1, 1}, it.Key())
+			assert.False(t, it.Prev())
+
+			assert.True(t, it.SeekLast([]byte{1}))
+			assert.Equal(t, []byte{1, 3}, it.Key())
+			assert.True(t, it.Prev())
+			assert.Equal(t, []byte{1, 1}, it.Key())
+			assert.True(t, it.Next())
+			assert.Equal(t, []byte{1, 3}, it.Key())
+			assert.True(t, it.Next())
+			assert.Equal(t, []byte{2, 0}, it.Key())
+			return it.Close()
+		}))
+
+		for _, key := range [][]byte{{1, 0}, {1, 2}} {
+			assert.False(t, has(tieredDB, key))
+			assert.False(t, has(cold, key))
+		}
+		assert.Equal(t, [][]byte{{1, 1}, {1, 3}, {2, 0}}, iterate(tieredDB))
+	})
+
+	t.Run("deleted prefixes are deleted from both databases", func(t *testing.T) {
+		require.NoError(t, tieredDB.Demote([][]byte{{1, 1}}))
+		require.True(t, has(cold, []byte{1, 1}))
+		require.NoError(t, tieredDB.Update(func(txn db.Transaction) error {
+			if err := txn.DeletePrefix([]byte{1}); err != nil {
+				return err
+			}
+			err := txn.Get([]byte{1, 1}, func([]byte) error { return nil })
+			require.ErrorIs(t, err, db.ErrKeyNotFound)
+			return nil
+		}))
+
+		assert.False(t, has(cold, []byte{1, 1}))
+		assert.False(t, has(hot, []byte{1, 3}))
+		assert.Equal(t, [][]byte{{2, 0}}, iterate(tieredDB))
+	})
+}
@@ -0,0 +1,166 @@
+package tiered
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var _ db.Iterator = (*iterator)(nil)
+
+// iterator merges the iterators of the hot and the cold databases in the order of their keys. The hot value of a key
+// that is in both databases takes precedence over its cold copy.
+type iterator struct {
+	txn        *transaction
+	hot        db.Iterator
+	cold       db.Iterator
+	hotValid   bool
+	coldValid  bool
+	positioned bool
+	// reverse is set while the iterator moves backwards, the iterators of both databases are then positioned at or
+	// before the current key instead of at or after it
+	reverse bool
+}
+
+// Valid : see db.Transaction.Iterator.Valid
+func (i *iterator) Valid() bool {
+	return i.hotValid || i.coldValid
+}
+
+// Key : see db.Transaction.Iterator.Key
+func (i *iterator) Key() []byte {
+	if !i.Valid() {
+		return nil
+	}
+	return i.current().Key()
+}
+
+// Value : see db.Transaction.Iterator.Value
+func (i *iterator) Value() ([]byte, error) {
+	if !i.Valid() {
+		return nil, nil
+	}
+	return i.current().Value()
+}
+
+// Next : see db.Transaction.Iterator.Next
+func (i *iterator) Next() bool {
+	if !i.positioned {
+		i.positioned = true
+		i.hotValid, i.coldValid = i.hot.Next(), i.cold.Next()
+		i.skipDeleted()
+		return i.Valid()
+	}
+	if !i.Valid() {
+		return false
+	}
+
+	key := i.Key()
+	if i.reverse {
+		// the iterators are moved to the first keys after key
+		i.reverse = false
+		i.hotValid, i.coldValid = seekAfter(i.hot, key), seekAfter(i.cold, key)
+		i.skipDeleted()
+		return i.Valid()
+	}
+	if i.hotValid && bytes.Equal(i.hot.Key(), key) {
+		i.hotValid = i.hot.Next()
+	}
+	if i.coldValid && bytes.Equal(i.cold.Key(), key) {
+		i.coldValid = i.cold.Next()
+	}
+	i.skipDeleted()
+	return i.Valid()
+}
+
+// Seek : see db.Transaction.Iterator.Seek
+func (i *iterator) Seek(key []byte) bool {
+	i.positioned, i.reverse = true, false
+	i.hotValid, i.coldValid = i.hot.Seek(key), i.cold.Seek(key)
+	i.skipDeleted()
+	return i.Valid()
+}
+
+// Prev : see db.Transaction.Iterator.Prev
+func (i *iterator) Prev() bool {
+	if !i.positioned {
+		return i.SeekLast(nil)
+	}
+	if !i.Valid() {
+		return false
+	}
+
+	key := i.Key()
+	if !i.reverse {
+		// the iterators are moved to the last keys before key
+		i.reverse = true
+		i.hotValid, i.coldValid = seekBefore(i.hot, key), seekBefore(i.cold, key)
+		i.skipDeleted()
+		return i.Valid()
+	}
+	if i.hotValid && bytes.Equal(i.hot.Key(), key) {
+		i.hotValid = i.hot.Prev()
+	}
+	if i.coldValid && bytes.Equal(i.cold.Key(), key) {
+		i.coldValid = i.cold.Prev()
+	}
+	i.skipDeleted()
+	return i.Valid()
+}
+
+// SeekLast : see db.Transaction.Iterator.SeekLast
+func (i *iterator) SeekLast(prefix []byte) bool {
+	i.positioned, i.reverse = true, true
+	i.hotValid, i.coldValid = i.hot.SeekLast(prefix), i.cold.SeekLast(prefix)
+	i.skipDeleted()
+	return i.Valid()
+}
+
+// Close : see db.Transaction.Iterator.Close
+func (i *iterator) Close() error {
+	return errors.Join(i.hot.Close(), i.cold.Close())
+}
+
+// current returns the iterator that is positioned at the smallest key, or at the largest one in reverse
+func (i *iterator) current() db.Iterator {
+	if !i.coldValid || !i.hotValid {
+		if i.hotValid {
+			return i.hot
+		}
+		return i.cold
+	}
+	cmp := bytes.Compare(i.hot.Key(), i.cold.Key())
+	if cmp == 0 || (cmp < 0) != i.reverse {
+		return i.hot
+	}
+	return i.cold
+}
+
+// skipDeleted moves the cold iterator past the keys deleted by the transaction
+func (i *iterator) skipDeleted() {
+	for i.coldValid && i.txn.deletedFromCold(i.cold.Key()) {
+		if i.reverse {
+			i.coldValid = i.cold.Prev()
+		} else {
+			i.coldValid = i.cold.Next()
+		}
+	}
+}
+
+// seekAfter moves it to the first key after key
+func seekAfter(it db.Iterator, key []byte) bool {
+	if it.Seek(key) && bytes.Equal(it.Key(), key) {
+		return it.Next()
+	}
+	return it.Valid()
+}
+
+// seekBefore moves it to the last key before key
+func seekBefore(it db.Iterator, key []byte) bool {
+	if !it.Seek(key) {
+		// all the keys are before key
+		return it.SeekLast(nil)
+	}
+	return it.Prev()
+}
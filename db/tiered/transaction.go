@@ -0,0 +1,184 @@
+package tiered
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+var (
+	_ db.Transaction  = (*transaction)(nil)
+	_ db.PendingSizer = (*transaction)(nil)
+)
+
+type transaction struct {
+	db  *DB
+	hot db.Transaction
+	// cold is a read-only transaction on the cold database, it is opened on the first read that falls through to
+	// it, which is always after the hot transaction is opened, so that the keys demoted since are found in it
+	cold db.Transaction
+	// deleted are the keys deleted by the transaction that are in the cold database, they are deleted from it once
+	// the transaction is committed
+	deleted map[string]struct{}
+}
+
+// Discard : see db.Transaction.Discard
+func (t *transaction) Discard() error {
+	err := t.hot.Discard()
+	if t.cold != nil {
+		err = errors.Join(err, t.cold.Discard())
+		t.cold = nil
+	}
+	return err
+}
+
+// Commit : see db.Transaction.Commit. The keys deleted by the transaction are deleted from the cold database after
+// they are deleted from the hot one, they cannot be lost if the commit fails.
+func (t *transaction) Commit() error {
+	err := t.hot.Commit()
+	if err == nil && len(t.deleted) > 0 {
+		err = t.db.cold.Update(func(coldTxn db.Transaction) error {
+			for key := range t.deleted {
+				if err := coldTxn.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return db.CloseAndWrapOnError(t.Discard, err)
+}
+
+// PendingSize : see db.PendingSizer.PendingSize, it is the size of the writes to the hot database
+func (t *transaction) PendingSize() uint64 {
+	if sizer, ok := t.hot.(db.PendingSizer); ok {
+		return sizer.PendingSize()
+	}
+	return 0
+}
+
+// Set : see db.Transaction.Set
+func (t *transaction) Set(key, val []byte) error {
+	return t.hot.Set(key, val)
+}
+
+// Delete : see db.Transaction.Delete
+func (t *transaction) Delete(key []byte) error {
+	if err := t.hot.Delete(key); err != nil {
+		return err
+	}
+	if !t.db.tiered(key) {
+		return nil
+	}
+
+	err := t.coldTxn().Get(key, func([]byte) error { return nil })
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if t.deleted == nil {
+		t.deleted = make(map[string]struct{})
+	}
+	t.deleted[string(key)] = struct{}{}
+	return nil
+}
+
+// DeletePrefix : see db.Transaction.DeletePrefix. The keys are deleted from the hot database as it deletes a prefix,
+// and the keys of the cold database are deleted one by one like those of Delete.
+func (t *transaction) DeletePrefix(prefix []byte) error {
+	if err := t.hot.DeletePrefix(prefix); err != nil {
+		return err
+	}
+	if !t.db.overlapsTiered(prefix) {
+		return nil
+	}
+
+	return db.IteratePrefix(t.coldTxn(), prefix, func(key, _ []byte) error {
+		if t.deleted == nil {
+			t.deleted = make(map[string]struct{})
+		}
+		t.deleted[string(key)] = struct{}{}
+		return nil
+	}, db.KeysOnly())
+}
+
+// Get : see db.Transaction.Get. The keys that are not in the hot database are read from the cold one.
+func (t *transaction) Get(key []byte, cb func([]byte) error) error {
+	err := t.hot.Get(key, cb)
+	if !errors.Is(err, db.ErrKeyNotFound) || !t.db.tiered(key) {
+		return err
+	}
+	if t.deletedFromCold(key) {
+		return err
+	}
+
+	if err = t.coldTxn().Get(key, cb); err == nil {
+		t.db.countColdRead(key)
+	}
+	return err
+}
+
+// MultiGet : see db.Transaction.MultiGet. The keys are read from the hot database at once, and the tiered keys it
+// does not hold from the cold database at once.
+func (t *transaction) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	found := make([]bool, len(keys))
+	if err := t.hot.MultiGet(keys, func(i int, val []byte) error {
+		found[i] = true
+		return cb(i, val)
+	}); err != nil {
+		return err
+	}
+
+	var coldKeys [][]byte
+	var indexes []int
+	for i, key := range keys {
+		if !found[i] && t.db.tiered(key) && !t.deletedFromCold(key) {
+			coldKeys = append(coldKeys, key)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(coldKeys) == 0 {
+		return nil
+	}
+	return t.coldTxn().MultiGet(coldKeys, func(i int, val []byte) error {
+		t.db.countColdRead(coldKeys[i])
+		return cb(indexes[i], val)
+	})
+}
+
+// NewIterator : see db.Transaction.NewIterator. The iterator merges the keys of the hot and the cold databases.
+func (t *transaction) NewIterator() (db.Iterator, error) {
+	return t.NewIteratorWithBounds(nil, nil)
+}
+
+// NewIteratorWithBounds : see db.Transaction.NewIteratorWithBounds, the bounds are passed to both databases
+func (t *transaction) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	hot, err := t.hot.NewIteratorWithBounds(lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := t.coldTxn().NewIteratorWithBounds(lower, upper)
+	if err != nil {
+		return nil, db.CloseAndWrapOnError(hot.Close, err)
+	}
+	return &iterator{txn: t, hot: hot, cold: cold}, nil
+}
+
+// Impl : see db.Transaction.Impl
+func (t *transaction) Impl() any {
+	return t.hot.Impl()
+}
+
+func (t *transaction) coldTxn() db.Transaction {
+	if t.cold == nil {
+		t.cold = t.db.cold.NewTransaction(false)
+	}
+	return t.cold
+}
+
+// deletedFromCold returns whether key is deleted by the transaction while it is in the cold database
+func (t *transaction) deletedFromCold(key []byte) bool {
+	_, deleted := t.deleted[string(key)]
+	return deleted
+}
@@ -0,0 +1,282 @@
+// Package devnet produces the blocks of a local test network from the transactions submitted to the node, which are
+// executed with the integrated VM instead of being synced from a feeder gateway. The blocks are stored like synced
+// blocks, so that applications can be tested against the storage and the RPC of Juno.
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/vm"
+)
+
+var _ service.Service = (*Devnet)(nil)
+
+const (
+	// Name and ChainID identify the network of a devnet, so that its transactions cannot be replayed on another
+	// network
+	Name    = "devnet"
+	ChainID = "SN_DEVNET"
+	// ProtocolVersion is the version of the Starknet protocol the devnet blocks are produced with
+	ProtocolVersion = "0.12.0"
+	// maxQueuedTransactions is the number of submitted transactions that wait for a block at most
+	maxQueuedTransactions = 1024
+)
+
+// ErrQueueFull is returned when a transaction is submitted while too many transactions wait for a block
+var ErrQueueFull = errors.New("too many transactions are waiting for a block")
+
+// Network registers the network of a devnet, which has no feeder or gateway
+func Network() (utils.Network, error) {
+	return utils.RegisterNetwork(utils.NetworkDefinition{
+		Name:    Name,
+		ChainID: ChainID,
+		// the devnet does not request its feeder or gateway, they are only set because they are required
+		FeederURL:  "http://localhost/feeder_gateway/",
+		GatewayURL: "http://localhost/gateway/",
+	})
+}
+
+// queued is a submitted transaction that waits for a block
+type queued struct {
+	txn   core.Transaction
+	class core.Class
+}
+
+// Devnet produces a block of the transactions submitted since the previous one every block time
+type Devnet struct {
+	database  db.DB
+	chain     *blockchain.Blockchain
+	vm        vm.VM
+	network   utils.Network
+	genesis   *Genesis
+	blockTime time.Duration
+	log       utils.SimpleLogger
+
+	mu    sync.Mutex
+	queue []queued
+}
+
+// New returns a Devnet that stores genesis and then the blocks it produces to chain, whose database is database
+func New(database db.DB, chain *blockchain.Blockchain, virtualMachine vm.VM, network utils.Network, genesis *Genesis,
+	blockTime time.Duration, log utils.SimpleLogger,
+) *Devnet {
+	return &Devnet{
+		database:  database,
+		chain:     chain,
+		vm:        virtualMachine,
+		network:   network,
+		genesis:   genesis,
+		blockTime: blockTime,
+		log:       log,
+	}
+}
+
+// Accounts returns the prefunded accounts of the devnet
+func (d *Devnet) Accounts() []*Account {
+	return d.genesis.Accounts
+}
+
+// Submit queues txn for the next block, class is the class declared by txn if it is a declare transaction
+func (d *Devnet) Submit(txn core.Transaction, class core.Class) error {
+	if _, ok := txn.(*core.L1HandlerTransaction); ok {
+		return errors.New("L1 handler transactions are not supported by the devnet")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.queue) >= maxQueuedTransactions {
+		return ErrQueueFull
+	}
+	d.queue = append(d.queue, queued{txn: txn, class: class})
+	return nil
+}
+
+// Run stores the genesis block if the chain is empty, and then produces a block every block time until ctx is
+// cancelled
+func (d *Devnet) Run(ctx context.Context) error {
+	if _, err := d.chain.Height(); errors.Is(err, db.ErrKeyNotFound) {
+		if err = d.storeGenesis(); err != nil {
+			return fmt.Errorf("store genesis block: %w", err)
+		}
+		d.log.Infow("Stored the genesis block of the devnet", "accounts", len(d.genesis.Accounts))
+	} else if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d.blockTime):
+		}
+
+		if err := d.Produce(); err != nil {
+			return fmt.Errorf("produce block: %w", err)
+		}
+	}
+}
+
+func (d *Devnet) storeGenesis() error {
+	diff, classes, err := d.genesis.stateDiff()
+	if err != nil {
+		return err
+	}
+	header := &core.Header{
+		ParentHash:       &felt.Zero,
+		Number:           0,
+		SequencerAddress: SequencerAddress,
+		Timestamp:        uint64(time.Now().Unix()),
+		ProtocolVersion:  ProtocolVersion,
+		GasPrice:         &felt.Zero,
+	}
+	return d.store(&core.Block{Header: header}, &felt.Zero, diff, classes)
+}
+
+// Produce produces a block of the queued transactions, if any. The transactions that fail are dropped.
+func (d *Devnet) Produce() error {
+	d.mu.Lock()
+	txns := d.queue
+	d.queue = nil
+	d.mu.Unlock()
+	if len(txns) == 0 {
+		return nil
+	}
+
+	head, err := d.chain.Head()
+	if err != nil {
+		return err
+	}
+	header := &core.Header{
+		ParentHash:       head.Hash,
+		Number:           head.Number + 1,
+		SequencerAddress: SequencerAddress,
+		Timestamp:        uint64(time.Now().Unix()),
+		ProtocolVersion:  ProtocolVersion,
+		GasPrice:         &felt.Zero,
+	}
+	if header.Timestamp < head.Timestamp {
+		header.Timestamp = head.Timestamp
+	}
+
+	block, diff, classes, err := d.execute(header, txns)
+	if err != nil {
+		return err
+	}
+	if len(block.Transactions) == 0 {
+		return nil
+	}
+	if err = d.store(block, head.GlobalStateRoot, diff, classes); err != nil {
+		return err
+	}
+	d.log.Infow("Produced block", "number", block.Number, "hash", block.Hash.ShortString(),
+		"transactions", len(block.Transactions))
+	return nil
+}
+
+// execute executes txns on the head state as the transactions of the block of header. If the transactions fail
+// together, they are executed one by one to drop the ones that fail.
+func (d *Devnet) execute(header *core.Header, txns []queued) (*core.Block, *core.StateDiff, map[felt.Felt]core.Class,
+	error,
+) {
+	state, closer, err := d.chain.HeadState()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer func() {
+		if closeErr := closer(); closeErr != nil {
+			d.log.Warnw("Failed to close head state", "err", closeErr)
+		}
+	}()
+
+	run := func(txns []queued) (*core.Block, *core.StateDiff, map[felt.Felt]core.Class, error) {
+		block := &core.Block{Header: header}
+		var declared []core.Class
+		classes := make(map[felt.Felt]core.Class)
+		for _, q := range txns {
+			block.Transactions = append(block.Transactions, q.txn)
+			if declare, ok := q.txn.(*core.DeclareTransaction); ok {
+				declared = append(declared, q.class)
+				classes[*declare.ClassHash] = q.class
+			}
+		}
+		diff, fees, traces, err := d.vm.ExecuteBlock(block.Transactions, declared, header.Number, header.Timestamp,
+			SequencerAddress, state, d.network, vm.Limits{})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if block.Receipts, err = receipts(block.Transactions, fees, traces); err != nil {
+			return nil, nil, nil, err
+		}
+		return block, diff, classes, nil
+	}
+
+	block, diff, classes, err := run(txns)
+	if err == nil {
+		return block, diff, classes, nil
+	}
+	d.log.Debugw("Executing the transactions one by one", "err", err)
+
+	accepted := make([]queued, 0, len(txns))
+	for _, q := range txns {
+		if _, _, _, err = run(append(accepted, q)); err != nil {
+			d.log.Infow("Dropped failed transaction", "hash", q.txn.Hash(), "err", err)
+			continue
+		}
+		accepted = append(accepted, q)
+	}
+	if len(accepted) == 0 {
+		return &core.Block{Header: header}, nil, nil, nil
+	}
+	return run(accepted)
+}
+
+// store computes the hash and the state root of block and stores it
+func (d *Devnet) store(block *core.Block, oldRoot *felt.Felt, diff *core.StateDiff,
+	classes map[felt.Felt]core.Class,
+) error {
+	newRoot, err := d.newRoot(block.Number, diff, classes)
+	if err != nil {
+		return err
+	}
+
+	block.GlobalStateRoot = newRoot
+	block.TransactionCount = uint64(len(block.Transactions))
+	for _, receipt := range block.Receipts {
+		block.EventCount += uint64(len(receipt.Events))
+	}
+	block.EventsBloom = core.EventsBloom(block.Receipts)
+	hash, commitments, err := core.BlockHash(block, d.network)
+	if err != nil {
+		return err
+	}
+	block.Hash = hash
+
+	return d.chain.Store(block, commitments, &core.StateUpdate{
+		BlockHash: hash,
+		OldRoot:   oldRoot,
+		NewRoot:   newRoot,
+		StateDiff: diff,
+	}, classes)
+}
+
+// newRoot returns the state root after diff is applied to the head state, which is not changed
+func (d *Devnet) newRoot(number uint64, diff *core.StateDiff, classes map[felt.Felt]core.Class) (*felt.Felt, error) {
+	var root *felt.Felt
+	err := d.database.View(func(txn db.Transaction) error {
+		var err error
+		// the changes are only buffered, they are stored with the block
+		root, err = core.NewState(db.NewBufferedTransaction(txn)).Apply(number, diff, classes)
+		return err
+	})
+	return root, err
+}
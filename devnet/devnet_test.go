@@ -0,0 +1,147 @@
+package devnet_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/devnet"
+	"github.com/NethermindEth/juno/mocks"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevnet(t *testing.T) {
+	network, err := devnet.Network()
+	require.NoError(t, err)
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, network, utils.NewNopZapLogger())
+	mockCtrl := gomock.NewController(t)
+	mockVM := mocks.NewMockVM(mockCtrl)
+
+	accountClass := &devnet.Class{Hash: new(felt.Felt).SetUint64(1), Class: &core.Cairo0Class{}}
+	genesis := &devnet.Genesis{
+		FeeTokenClass: &devnet.Class{Hash: new(felt.Felt).SetUint64(2), Class: &core.Cairo0Class{}},
+		AccountClass:  accountClass,
+		Accounts:      devnet.NewAccounts(accountClass, 3, 0),
+		Balance:       devnet.DefaultBalance,
+	}
+	net := devnet.New(testDB, chain, mockVM, network, genesis, time.Hour, utils.NewNopZapLogger())
+
+	t.Run("accounts are derived from the seed", func(t *testing.T) {
+		assert.Equal(t, genesis.Accounts, devnet.NewAccounts(accountClass, 3, 0))
+		assert.NotEqual(t, genesis.Accounts, devnet.NewAccounts(accountClass, 3, 1))
+	})
+
+	t.Run("genesis block deploys the fee token and the accounts", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, net.Run(ctx))
+
+		head, err := chain.Head()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), head.Number)
+
+		state, closer, err := chain.HeadState()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, closer())
+		})
+		classHash, err := state.ContractClassHash(devnet.FeeTokenAddress)
+		require.NoError(t, err)
+		assert.Equal(t, genesis.FeeTokenClass.Hash, classHash)
+		for _, account := range genesis.Accounts {
+			classHash, err = state.ContractClassHash(account.Address)
+			require.NoError(t, err)
+			assert.Equal(t, accountClass.Hash, classHash)
+		}
+
+		update, err := chain.StateUpdateByNumber(0)
+		require.NoError(t, err)
+		// the metadata and the total supply of the token, and the balance of every account
+		assert.Len(t, update.StateDiff.StorageDiffs[*devnet.FeeTokenAddress], 5+2*len(genesis.Accounts))
+	})
+
+	t.Run("submitted transactions are produced into a block", func(t *testing.T) {
+		require.NoError(t, net.Produce())
+		height, err := chain.Height()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), height, "no block without transactions")
+
+		sender := genesis.Accounts[0].Address
+		txn := &core.InvokeTransaction{
+			TransactionHash: new(felt.Felt).SetUint64(0xabc),
+			SenderAddress:   sender,
+			Nonce:           &felt.Zero,
+			MaxFee:          new(felt.Felt).SetUint64(100),
+			Version:         new(felt.Felt).SetUint64(1),
+		}
+		failing := &core.InvokeTransaction{
+			TransactionHash: new(felt.Felt).SetUint64(0xdef),
+			SenderAddress:   sender,
+			Version:         new(felt.Felt).SetUint64(1),
+		}
+		require.NoError(t, net.Submit(txn, nil))
+		require.NoError(t, net.Submit(failing, nil))
+		require.Error(t, net.Submit(&core.L1HandlerTransaction{}, nil))
+
+		key, value := new(felt.Felt).SetUint64(7), new(felt.Felt).SetUint64(8)
+		diff := &core.StateDiff{
+			StorageDiffs: map[felt.Felt][]core.StorageDiff{*sender: {{Key: key, Value: value}}},
+			Nonces:       map[felt.Felt]*felt.Felt{*sender: new(felt.Felt).SetUint64(1)},
+		}
+		fee := new(felt.Felt).SetUint64(42)
+		trace := json.RawMessage(`{"execute_invocation": {"contract_address": "0x5", "events": [{"keys": ["0x1"],
+			"data": ["0x2"]}], "calls": [{"contract_address": "0x6", "events": [{"keys": [], "data": []}]}]}}`)
+		gomock.InOrder(
+			mockVM.EXPECT().ExecuteBlock([]core.Transaction{txn, failing}, gomock.Any(), uint64(1), gomock.Any(),
+				devnet.SequencerAddress, gomock.Any(), network, gomock.Any()).
+				Return(nil, nil, nil, errors.New("failed")),
+			mockVM.EXPECT().ExecuteBlock([]core.Transaction{txn}, gomock.Any(), uint64(1), gomock.Any(),
+				devnet.SequencerAddress, gomock.Any(), network, gomock.Any()).
+				Return(diff, []*felt.Felt{fee}, []json.RawMessage{trace}, nil),
+			mockVM.EXPECT().ExecuteBlock([]core.Transaction{txn, failing}, gomock.Any(), uint64(1), gomock.Any(),
+				devnet.SequencerAddress, gomock.Any(), network, gomock.Any()).
+				Return(nil, nil, nil, errors.New("failed")),
+			mockVM.EXPECT().ExecuteBlock([]core.Transaction{txn}, gomock.Any(), uint64(1), gomock.Any(),
+				devnet.SequencerAddress, gomock.Any(), network, gomock.Any()).
+				Return(diff, []*felt.Felt{fee}, []json.RawMessage{trace}, nil),
+		)
+		require.NoError(t, net.Produce())
+
+		block, err := chain.Head()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), block.Number)
+		require.Len(t, block.Transactions, 1, "the failing transaction is dropped")
+		assert.Equal(t, txn.Hash(), block.Transactions[0].Hash())
+		require.Len(t, block.Receipts, 1)
+		assert.Equal(t, fee, block.Receipts[0].Fee)
+		require.Len(t, block.Receipts[0].Events, 2)
+		assert.Equal(t, new(felt.Felt).SetUint64(5), block.Receipts[0].Events[0].From)
+		assert.Equal(t, new(felt.Felt).SetUint64(6), block.Receipts[0].Events[1].From)
+		assert.Equal(t, uint64(2), block.EventCount)
+
+		state, closer, err := chain.HeadState()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, closer())
+		})
+		stored, err := state.ContractStorage(sender, key)
+		require.NoError(t, err)
+		assert.Equal(t, value, stored)
+		nonce, err := state.ContractNonce(sender)
+		require.NoError(t, err)
+		assert.Equal(t, new(felt.Felt).SetUint64(1), nonce)
+	})
+}
@@ -0,0 +1,192 @@
+package devnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/NethermindEth/juno/adapters/feeder2core"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/vm"
+	starkcurve "github.com/consensys/gnark-crypto/ecc/stark-curve"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fr"
+)
+
+var (
+	// FeeTokenAddress is the address of the fee token, which the VM charges the fees in on every network
+	FeeTokenAddress = mustHexToFelt("0x49d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7")
+	// SequencerAddress is the address of the sequencer of the devnet blocks, the fees are paid to it
+	SequencerAddress = mustHexToFelt("0x1000")
+	// DefaultBalance is 1000 ETH in wei
+	DefaultBalance = mustHexToFelt("0x3635c9adc5dea00000")
+)
+
+func mustHexToFelt(hex string) *felt.Felt {
+	f, err := new(felt.Felt).SetString(hex)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Class is a class declared by the genesis block of a devnet
+type Class struct {
+	Hash  *felt.Felt
+	Class core.Class
+}
+
+// LoadClass reads the definition of a Cairo 0 class from path, in the format the feeder gateway serves it in
+func LoadClass(path string) (*Class, error) {
+	definitionJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var definition feeder.Cairo0Definition
+	if err = json.Unmarshal(definitionJSON, &definition); err != nil {
+		return nil, fmt.Errorf("class %s: %w", path, err)
+	}
+	class, err := feeder2core.AdaptCairo0Class(&definition)
+	if err != nil {
+		return nil, fmt.Errorf("class %s: %w", path, err)
+	}
+	hash, err := vm.Cairo0ClassHash(class.(*core.Cairo0Class))
+	if err != nil {
+		return nil, fmt.Errorf("hash class %s: %w", path, err)
+	}
+	return &Class{Hash: hash, Class: class}, nil
+}
+
+// Account is a prefunded account of a devnet
+type Account struct {
+	Address    *felt.Felt
+	PublicKey  *felt.Felt
+	PrivateKey *felt.Felt
+}
+
+// NewAccounts derives count accounts of class from seed, the same seed always derives the same accounts. The
+// accounts are deployed as if by a deploy account transaction with their public key as salt and only constructor
+// argument.
+func NewAccounts(class *Class, count, seed uint64) []*Account {
+	accounts := make([]*Account, 0, count)
+	seedFelt := new(felt.Felt).SetUint64(seed)
+	for i := uint64(0); i < count; i++ {
+		key := crypto.Pedersen(seedFelt, new(felt.Felt).SetUint64(i)).BigInt(new(big.Int))
+		key.Mod(key, fr.Modulus())
+
+		var publicKey starkcurve.G1Affine
+		publicKey.ScalarMultiplicationBase(key)
+		publicKeyX := publicKey.X.Bytes()
+		publicKeyFelt := new(felt.Felt).SetBytes(publicKeyX[:])
+
+		accounts = append(accounts, &Account{
+			Address:    core.ContractAddress(&felt.Zero, class.Hash, publicKeyFelt, []*felt.Felt{publicKeyFelt}),
+			PublicKey:  publicKeyFelt,
+			PrivateKey: new(felt.Felt).SetBigInt(key),
+		})
+	}
+	return accounts
+}
+
+// Genesis is the first block of a devnet, which deploys the fee token and the prefunded accounts. The fee token
+// and the accounts are expected to be the Cairo 0 contracts of OpenZeppelin, whose storage is set directly.
+type Genesis struct {
+	FeeTokenClass *Class
+	AccountClass  *Class
+	Accounts      []*Account
+	// Balance is the amount of the fee token every account is funded with
+	Balance *felt.Felt
+}
+
+// stateDiff returns the state diff of the genesis block and the classes it declares
+func (g *Genesis) stateDiff() (*core.StateDiff, map[felt.Felt]core.Class, error) {
+	diff := &core.StateDiff{
+		StorageDiffs: make(map[felt.Felt][]core.StorageDiff),
+		Nonces:       make(map[felt.Felt]*felt.Felt),
+		DeployedContracts: []core.DeployedContract{{
+			Address:   FeeTokenAddress,
+			ClassHash: g.FeeTokenClass.Hash,
+		}},
+	}
+	classes := make(map[felt.Felt]core.Class)
+	for _, class := range []*Class{g.FeeTokenClass, g.AccountClass} {
+		if _, ok := classes[*class.Hash]; ok {
+			continue
+		}
+		if _, ok := class.Class.(*core.Cairo0Class); !ok {
+			return nil, nil, fmt.Errorf("class %s is not a Cairo 0 class", class.Hash)
+		}
+		classes[*class.Hash] = class.Class
+		diff.DeclaredV0Classes = append(diff.DeclaredV0Classes, class.Hash)
+	}
+
+	balanceLow, balanceHigh := uint256(g.Balance.BigInt(new(big.Int)))
+	supply := new(big.Int).Mul(g.Balance.BigInt(new(big.Int)), big.NewInt(int64(len(g.Accounts))))
+	supplyLow, supplyHigh := uint256(supply)
+
+	tokenStorage := []core.StorageDiff{
+		storageVar("ERC20_name", new(felt.Felt).SetBytes([]byte("Ether"))),
+		storageVar("ERC20_symbol", new(felt.Felt).SetBytes([]byte("ETH"))),
+		storageVar("ERC20_decimals", new(felt.Felt).SetUint64(18)),
+		storageVar("ERC20_total_supply", supplyLow),
+		storageVarHigh("ERC20_total_supply", supplyHigh),
+	}
+	for _, account := range g.Accounts {
+		diff.DeployedContracts = append(diff.DeployedContracts, core.DeployedContract{
+			Address:   account.Address,
+			ClassHash: g.AccountClass.Hash,
+		})
+		diff.StorageDiffs[*account.Address] = []core.StorageDiff{
+			storageVar("Account_public_key", account.PublicKey),
+		}
+		tokenStorage = append(tokenStorage,
+			storageVar("ERC20_balances", balanceLow, account.Address),
+			storageVarHigh("ERC20_balances", balanceHigh, account.Address))
+	}
+	diff.StorageDiffs[*FeeTokenAddress] = tokenStorage
+	return diff, classes, nil
+}
+
+// uint256 splits a value into the low and high 128 bits of a Cairo Uint256
+func uint256(value *big.Int) (low, high *felt.Felt) {
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	low = new(felt.Felt).SetBigInt(new(big.Int).And(value, mask))
+	high = new(felt.Felt).SetBigInt(new(big.Int).Rsh(value, 128))
+	return low, high
+}
+
+// storageVar returns the storage diff that sets the storage variable name of a Cairo 0 contract, for keys if it is
+// a mapping
+func storageVar(name string, value *felt.Felt, keys ...*felt.Felt) core.StorageDiff {
+	return core.StorageDiff{Key: storageVarAddress(name, keys...), Value: value}
+}
+
+// storageVarHigh returns the storage diff that sets the second felt of the storage variable name, such as the high
+// bits of a Uint256
+func storageVarHigh(name string, value *felt.Felt, keys ...*felt.Felt) core.StorageDiff {
+	address := storageVarAddress(name, keys...)
+	return core.StorageDiff{Key: address.Add(address, new(felt.Felt).SetUint64(1)), Value: value}
+}
+
+// storageVarAddress returns the address of the storage variable name of a Cairo 0 contract, for keys if it is a
+// mapping
+func storageVarAddress(name string, keys ...*felt.Felt) *felt.Felt {
+	address, err := crypto.StarknetKeccak([]byte(name))
+	if err != nil {
+		panic(err)
+	}
+	for _, key := range keys {
+		address = crypto.Pedersen(address, key)
+	}
+
+	// the addresses are normalized below 2^251 - 256
+	bound := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 251), big.NewInt(256))
+	addressInt := address.BigInt(new(big.Int))
+	if addressInt.Cmp(bound) >= 0 {
+		address.SetBigInt(addressInt.Sub(addressInt, bound))
+	}
+	return address
+}
@@ -0,0 +1,79 @@
+package devnet
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// trace is the part of the trace of a transaction that its receipt is built from
+type trace struct {
+	ExecuteInvocation     *invocation `json:"execute_invocation"`
+	FeeTransferInvocation *invocation `json:"fee_transfer_invocation"`
+}
+
+type invocation struct {
+	ContractAddress felt.Felt     `json:"contract_address"`
+	Calls           []*invocation `json:"calls"`
+	Events          []struct {
+		Keys []*felt.Felt `json:"keys"`
+		Data []*felt.Felt `json:"data"`
+	} `json:"events"`
+	Messages []struct {
+		ToAddress common.Address `json:"to_address"`
+		Payload   []*felt.Felt   `json:"payload"`
+	} `json:"messages"`
+}
+
+// collect appends the events and the messages of the invocation and its calls to receipt, in the order of the
+// invocations
+func (i *invocation) collect(receipt *core.TransactionReceipt) {
+	if i == nil {
+		return
+	}
+	for _, event := range i.Events {
+		receipt.Events = append(receipt.Events, &core.Event{
+			From: &i.ContractAddress,
+			Keys: event.Keys,
+			Data: event.Data,
+		})
+	}
+	for _, message := range i.Messages {
+		receipt.L2ToL1Message = append(receipt.L2ToL1Message, &core.L2ToL1Message{
+			From:    &i.ContractAddress,
+			To:      message.ToAddress,
+			Payload: message.Payload,
+		})
+	}
+	for _, call := range i.Calls {
+		call.collect(receipt)
+	}
+}
+
+// receipts builds the receipts of txns from the fees and the traces of their execution
+func receipts(txns []core.Transaction, fees []*felt.Felt, traces []json.RawMessage) ([]*core.TransactionReceipt, error) {
+	if len(fees) != len(txns) || len(traces) != len(txns) {
+		return nil, fmt.Errorf("%d transactions were executed into %d fees and %d traces", len(txns), len(fees),
+			len(traces))
+	}
+
+	receipts := make([]*core.TransactionReceipt, 0, len(txns))
+	for i, txn := range txns {
+		var t trace
+		if err := json.Unmarshal(traces[i], &t); err != nil {
+			return nil, fmt.Errorf("trace of transaction %s: %w", txn.Hash(), err)
+		}
+		receipt := &core.TransactionReceipt{
+			Fee:                fees[i],
+			TransactionHash:    txn.Hash(),
+			ExecutionResources: &core.ExecutionResources{},
+		}
+		t.ExecuteInvocation.collect(receipt)
+		t.FeeTransferInvocation.collect(receipt)
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
@@ -0,0 +1,336 @@
+// Package eventfilters keeps the event filters that clients install in the database, so that the clients that poll
+// for new events instead of holding a subscription consume every event of the chain, across restarts of the node
+// and reorgs. Every filter has a cursor, which moves past the events it returns and back to the first reverted block
+// when the blocks it returned events of are reverted.
+package eventfilters
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+)
+
+const (
+	// TTL is how long a filter that is not polled is kept for
+	TTL = 24 * time.Hour
+	// historyBlocks is the number of the hashes of the blocks a filter consumed that are kept to find where the
+	// chain forked, the filter moves back to the oldest of them after a deeper reorg
+	historyBlocks = 64
+)
+
+var (
+	// ErrNotFound is returned for the filters that are not installed, or were uninstalled because they expired
+	ErrNotFound = errors.New("event filter not found")
+	// ErrTooManyFilters is returned when a filter is installed while the maximum number of filters is installed
+	ErrTooManyFilters = errors.New("too many event filters are installed")
+)
+
+func init() {
+	// the filters are also pruned as new ones are installed, the sweeps delete those of the clients that went away
+	db.RegisterExpiry(db.EventFilters, func(_, value []byte) (time.Time, error) {
+		f := new(filter)
+		if err := encoder.Unmarshal(value, f); err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(f.LastPolled, 0).Add(TTL), nil
+	})
+}
+
+// Changes are the events of a filter since it was last polled
+type Changes struct {
+	Events []*blockchain.FilteredEvent
+	// Reverted is the first block that was reverted since the filter was last polled, if any. The events the filter
+	// returned for it and the blocks after it are no longer part of the chain, and the events of the blocks that
+	// replaced them follow.
+	Reverted *uint64
+	// More reports whether more events are ready to be polled
+	More bool
+}
+
+// block is a block a filter consumed, to detect the reorgs that revert it
+type block struct {
+	Number uint64
+	Hash   *felt.Felt
+}
+
+// filter is an installed filter of the events from the block From, and its cursor, which is at the Skip-th event of
+// the block Next. History is the blocks consumed by the filter, in ascending order.
+type filter struct {
+	Address    *felt.Felt
+	Keys       [][]felt.Felt
+	From       uint64
+	Next       uint64
+	Skip       uint64
+	History    []block
+	LastPolled int64
+}
+
+// Store is the store of the filters of a chain
+type Store struct {
+	database   db.DB
+	chain      blockchain.Reader
+	maxFilters int
+
+	// mu serialises the changes to the filters, so that a filter is not polled twice at once
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// New returns the Store of the filters of chain in database, which holds at most maxFilters filters
+func New(database db.DB, chain blockchain.Reader, maxFilters int) *Store {
+	return &Store{
+		database:   database,
+		chain:      chain,
+		maxFilters: maxFilters,
+		now:        time.Now,
+	}
+}
+
+// Install installs a filter of the events of address with keys, from the block from on, and returns its id. A nil
+// address matches the events of all contracts.
+func (s *Store) Install(address *felt.Felt, keys [][]felt.Felt, from uint64) (*felt.Felt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	installed, err := s.prune()
+	if err != nil {
+		return nil, err
+	}
+	if installed >= s.maxFilters {
+		return nil, ErrTooManyFilters
+	}
+
+	id, err := new(felt.Felt).SetRandom()
+	if err != nil {
+		return nil, err
+	}
+	f := &filter{Address: address, Keys: keys, From: from, Next: from, LastPolled: s.now().Unix()}
+	if from > 0 {
+		// the filter is moved back if the block before from is reverted before it is polled
+		if parent, headerErr := s.chain.BlockHeaderByNumber(from - 1); headerErr == nil {
+			f.History = []block{{Number: parent.Number, Hash: parent.Hash}}
+		} else if !errors.Is(headerErr, db.ErrKeyNotFound) {
+			return nil, headerErr
+		}
+	}
+	return id, s.database.Update(func(txn db.Transaction) error {
+		return put(txn, id, f)
+	})
+}
+
+// Uninstall uninstalls the filter id, and reports whether it was installed
+func (s *Store) Uninstall(id *felt.Felt) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var installed bool
+	return installed, s.database.Update(func(txn db.Transaction) error {
+		if _, err := get(txn, id); errors.Is(err, ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		installed = true
+		return txn.Delete(db.EventFilters.Key(id.Marshal()))
+	})
+}
+
+// Changes returns at most maxEvents events of the filter id since it was last polled, and moves its cursor past them.
+// The events of the pending block are not returned, they are returned once the block is stored.
+func (s *Store) Changes(id *felt.Felt, maxEvents uint64) (*Changes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var f *filter
+	err := s.database.View(func(txn db.Transaction) error {
+		var err error
+		f, err = get(txn, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.expired(f) {
+		return nil, errors.Join(ErrNotFound, s.database.Update(func(txn db.Transaction) error {
+			return txn.Delete(db.EventFilters.Key(id.Marshal()))
+		}))
+	}
+
+	changes, err := s.poll(f, maxEvents)
+	if err != nil {
+		return nil, err
+	}
+	f.LastPolled = s.now().Unix()
+	return changes, s.database.Update(func(txn db.Transaction) error {
+		return put(txn, id, f)
+	})
+}
+
+// poll reads the events of f from its cursor, and moves the cursor past them. The chain is read from a single
+// snapshot, so that the hashes f keeps are the hashes of the blocks its events were read from.
+func (s *Store) poll(f *filter, maxEvents uint64) (*Changes, error) {
+	eventFilter, err := s.chain.EventFilter(f.Address, f.Keys)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		// there are no blocks yet
+		return &Changes{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer eventFilter.Close()
+
+	changes := new(Changes)
+	if changes.Reverted, err = f.rewind(eventFilter); err != nil {
+		return nil, err
+	}
+
+	head, err := eventFilter.HeadsHeader()
+	if err != nil {
+		return nil, err
+	}
+	if f.Next > head.Number {
+		return changes, nil
+	}
+
+	if err = eventFilter.SetRangeEndBlockByNumber(blockchain.EventFilterFrom, f.Next); err != nil {
+		return nil, err
+	}
+	if err = eventFilter.SetRangeEndBlockByNumber(blockchain.EventFilterTo, head.Number); err != nil {
+		return nil, err
+	}
+	var token *blockchain.ContinuationToken
+	if f.Skip > 0 {
+		token = blockchain.NewContinuationToken(f.Next, f.Skip)
+	}
+	if changes.Events, token, err = eventFilter.Events(token, maxEvents); err != nil {
+		return nil, err
+	}
+
+	consumed := head
+	if token == nil {
+		f.Next, f.Skip = head.Number+1, 0
+	} else {
+		changes.More = true
+		f.Next, f.Skip = token.Block(), token.ProcessedEvents()
+		// the events of Next that were returned are reverted with it
+		if consumed, err = eventFilter.BlockHeaderByNumber(f.Next); err != nil {
+			return nil, err
+		}
+	}
+	f.consumed(block{Number: consumed.Number, Hash: consumed.Hash})
+	return changes, nil
+}
+
+// rewind moves the cursor of f back to the first block it consumed that is no longer part of the chain, if any,
+// and returns that block
+func (f *filter) rewind(eventFilter *blockchain.EventFilter) (*uint64, error) {
+	if len(f.History) == 0 {
+		return nil, nil
+	}
+
+	// the chain forked before the blocks f keeps the hashes of, unless one of them is still part of it
+	reverted, kept := f.History[0].Number, 0
+	for i := len(f.History) - 1; i >= 0; i-- {
+		consumed := f.History[i]
+		header, err := eventFilter.BlockHeaderByNumber(consumed.Number)
+		if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+			return nil, err
+		}
+		if err == nil && header.Hash.Equal(consumed.Hash) {
+			if i == len(f.History)-1 {
+				return nil, nil
+			}
+			// the chain forked after consumed
+			reverted, kept = consumed.Number+1, i+1
+			break
+		}
+	}
+
+	// the block before From is only kept to detect the reorgs that happen before the filter is polled
+	if reverted < f.From {
+		reverted = f.From
+	}
+	f.Next, f.Skip, f.History = reverted, 0, f.History[:kept]
+	return &reverted, nil
+}
+
+// consumed adds consumed to the history of f, replacing the blocks from its number on
+func (f *filter) consumed(consumed block) {
+	history := f.History
+	for len(history) > 0 && history[len(history)-1].Number >= consumed.Number {
+		history = history[:len(history)-1]
+	}
+	history = append(history, consumed)
+	if len(history) > historyBlocks {
+		history = history[len(history)-historyBlocks:]
+	}
+	f.History = history
+}
+
+// prune uninstalls the filters that expired, and returns the number of the filters that remain
+func (s *Store) prune() (int, error) {
+	var installed int
+	return installed, s.database.Update(func(txn db.Transaction) error {
+		it, err := txn.NewIterator()
+		if err != nil {
+			return err
+		}
+
+		prefix := db.EventFilters.Key()
+		var expired [][]byte
+		for it.Seek(prefix); it.Valid() && bytes.HasPrefix(it.Key(), prefix); it.Next() {
+			key := it.Key()
+			value, valueErr := it.Value()
+			if valueErr != nil {
+				return errors.Join(valueErr, it.Close())
+			}
+			f := new(filter)
+			if err = encoder.Unmarshal(value, f); err != nil {
+				return errors.Join(err, it.Close())
+			}
+			if s.expired(f) {
+				expired = append(expired, append([]byte{}, key...))
+			} else {
+				installed++
+			}
+		}
+		if err = it.Close(); err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err = txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) expired(f *filter) bool {
+	return s.now().Sub(time.Unix(f.LastPolled, 0)) > TTL
+}
+
+func get(txn db.Transaction, id *felt.Felt) (*filter, error) {
+	f := new(filter)
+	err := txn.Get(db.EventFilters.Key(id.Marshal()), func(value []byte) error {
+		return encoder.Unmarshal(value, f)
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func put(txn db.Transaction, id *felt.Felt, f *filter) error {
+	value, err := encoder.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return txn.Set(db.EventFilters.Key(id.Marshal()), value)
+}
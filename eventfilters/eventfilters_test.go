@@ -0,0 +1,118 @@
+package eventfilters_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/eventfilters"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	blocks := make([]*core.Block, 7)
+	stateUpdates := make([]*core.StateUpdate, len(blocks))
+	eventCounts := make([]int, len(blocks))
+	for i := range blocks {
+		var err error
+		blocks[i], err = gw.BlockByNumber(context.Background(), uint64(i))
+		require.NoError(t, err)
+		stateUpdates[i], err = gw.StateUpdate(context.Background(), uint64(i))
+		require.NoError(t, err)
+		for _, receipt := range blocks[i].Receipts {
+			eventCounts[i] += len(receipt.Events)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		require.NoError(t, chain.Store(blocks[i], &core.BlockCommitments{}, stateUpdates[i], nil))
+	}
+
+	store := eventfilters.New(testDB, chain, 2)
+	id, err := store.Install(nil, nil, 4)
+	require.NoError(t, err)
+
+	t.Run("events are polled in chunks", func(t *testing.T) {
+		var events []*blockchain.FilteredEvent
+		for {
+			changes, err := store.Changes(id, 1)
+			require.NoError(t, err)
+			assert.Nil(t, changes.Reverted)
+			events = append(events, changes.Events...)
+			if !changes.More {
+				break
+			}
+		}
+		require.Len(t, events, eventCounts[4]+eventCounts[5])
+		for _, event := range events {
+			assert.Equal(t, blocks[event.BlockNumber].Hash, event.BlockHash)
+		}
+
+		changes, err := store.Changes(id, 10)
+		require.NoError(t, err)
+		assert.Empty(t, changes.Events)
+	})
+
+	t.Run("filters are kept in the database", func(t *testing.T) {
+		require.NoError(t, chain.Store(blocks[6], &core.BlockCommitments{}, stateUpdates[6], nil))
+
+		changes, err := eventfilters.New(testDB, chain, 2).Changes(id, 1000)
+		require.NoError(t, err)
+		assert.Nil(t, changes.Reverted)
+		assert.Len(t, changes.Events, eventCounts[6])
+	})
+
+	t.Run("reverted blocks are reported and their events polled again", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		require.NoError(t, chain.RevertHead())
+		changes, err := store.Changes(id, 1000)
+		require.NoError(t, err)
+		require.NotNil(t, changes.Reverted)
+		assert.Equal(t, uint64(5), *changes.Reverted)
+		assert.Len(t, changes.Events, 0, "block 5 was reverted and not replaced yet")
+
+		replaced := *blocks[5]
+		replacedHeader := *blocks[5].Header
+		replacedHeader.Hash = new(felt.Felt).SetUint64(5)
+		replaced.Header = &replacedHeader
+		require.NoError(t, chain.Store(&replaced, &core.BlockCommitments{}, stateUpdates[5], nil))
+
+		changes, err = store.Changes(id, 1000)
+		require.NoError(t, err)
+		assert.Nil(t, changes.Reverted)
+		require.Len(t, changes.Events, eventCounts[5])
+		for _, event := range changes.Events {
+			assert.Equal(t, replacedHeader.Hash, event.BlockHash)
+		}
+	})
+
+	t.Run("at most maxFilters filters are installed", func(t *testing.T) {
+		other, err := store.Install(nil, nil, 0)
+		require.NoError(t, err)
+		_, err = store.Install(nil, nil, 0)
+		require.ErrorIs(t, err, eventfilters.ErrTooManyFilters)
+
+		installed, err := store.Uninstall(other)
+		require.NoError(t, err)
+		assert.True(t, installed)
+		installed, err = store.Uninstall(other)
+		require.NoError(t, err)
+		assert.False(t, installed)
+
+		_, err = store.Changes(other, 1)
+		require.ErrorIs(t, err, eventfilters.ErrNotFound)
+	})
+}
@@ -0,0 +1,284 @@
+package feedergateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/adapters/core2feeder"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+)
+
+const urlPrefix = "/feeder_gateway/"
+
+// Error codes used by the Starknet feeder gateway
+const (
+	BlockNotFound    = "StarknetErrorCode.BLOCK_NOT_FOUND"
+	UndeclaredClass  = "StarknetErrorCode.UNDECLARED_CLASS"
+	MalformedRequest = "StarknetErrorCode.MALFORMED_REQUEST"
+	InternalError    = "StarknetErrorCode.INTERNAL_ERROR"
+)
+
+// Block statuses used by the Starknet feeder gateway
+const (
+	statusPending      = "PENDING"
+	statusAcceptedOnL2 = "ACCEPTED_ON_L2"
+	statusAcceptedOnL1 = "ACCEPTED_ON_L1"
+)
+
+var _ service.Service = (*Server)(nil)
+
+// Server serves a subset of the Starknet feeder gateway REST API from local data, so that tooling
+// written against the gateway, including other Juno nodes, can use a Juno node as its data source.
+type Server struct {
+	bcReader blockchain.Reader
+	listener net.Listener
+	log      utils.SimpleLogger
+}
+
+func New(bcReader blockchain.Reader, listener net.Listener, log utils.SimpleLogger) *Server {
+	return &Server{
+		bcReader: bcReader,
+		listener: listener,
+		log:      log,
+	}
+}
+
+// Run starts to listen for feeder gateway requests
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error)
+
+	srv := &http.Server{
+		Addr:    s.listener.Addr().String(),
+		Handler: s,
+		// ReadTimeout also sets ReadHeaderTimeout and IdleTimeout.
+		ReadTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		errCh <- srv.Shutdown(context.Background())
+		close(errCh)
+	}()
+
+	if err := srv.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-errCh
+}
+
+// Error is the body returned by the feeder gateway when a request fails
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ServeHTTP dispatches an incoming request to the matching feeder gateway endpoint
+func (s *Server) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	endpoint, found := strings.CutPrefix(req.URL.Path, urlPrefix)
+	if !found {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var (
+		resp any
+		err  *Error
+	)
+	query := req.URL.Query()
+	switch endpoint {
+	case "get_block":
+		resp, err = s.block(query)
+	case "get_state_update":
+		resp, err = s.stateUpdate(query)
+	case "get_class_by_hash":
+		resp, err = s.classByHash(query)
+	case "get_compiled_class_by_class_hash":
+		resp, err = s.compiledClassByClassHash(query)
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusBadRequest
+		if err.Code == InternalError {
+			status = http.StatusInternalServerError
+		}
+		resp = err
+	}
+
+	body, marshalErr := json.Marshal(resp)
+	if marshalErr != nil {
+		s.log.Errorw("Failed to marshal feeder gateway response", "endpoint", endpoint, "err", marshalErr)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	if _, writeErr := writer.Write(body); writeErr != nil {
+		s.log.Debugw("Failed to write feeder gateway response", "endpoint", endpoint, "err", writeErr)
+	}
+}
+
+func internalError(err error) *Error {
+	return &Error{Code: InternalError, Message: err.Error()}
+}
+
+// parseBlockID reads the blockNumber and blockHash query parameters, defaulting to the latest block
+func parseBlockID(query map[string][]string) (*blockchain.BlockID, *Error) {
+	id := new(blockchain.BlockID)
+	if hashes, ok := query["blockHash"]; ok && len(hashes) > 0 {
+		hash, err := new(felt.Felt).SetString(hashes[0])
+		if err != nil {
+			return nil, &Error{Code: MalformedRequest, Message: fmt.Sprintf("Invalid block hash %q", hashes[0])}
+		}
+		id.Hash = hash
+		return id, nil
+	}
+
+	numbers, ok := query["blockNumber"]
+	if !ok || len(numbers) == 0 {
+		id.Latest = true
+		return id, nil
+	}
+
+	switch numbers[0] {
+	case "latest":
+		id.Latest = true
+	case "pending":
+		id.Pending = true
+	default:
+		number, err := strconv.ParseUint(numbers[0], 10, 64)
+		if err != nil {
+			return nil, &Error{Code: MalformedRequest, Message: fmt.Sprintf("Invalid block number %q", numbers[0])}
+		}
+		id.Number = number
+	}
+	return id, nil
+}
+
+func (s *Server) block(query map[string][]string) (any, *Error) {
+	id, rErr := parseBlockID(query)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	block, err := blockchain.BlockByID(s.bcReader, *id)
+	if err != nil {
+		return nil, &Error{Code: BlockNotFound, Message: "Block was not found"}
+	}
+
+	status := statusPending
+	if !id.Pending {
+		status = statusAcceptedOnL2
+		l1Head, l1Err := s.bcReader.L1Head()
+		if l1Err != nil && !errors.Is(l1Err, db.ErrKeyNotFound) {
+			return nil, internalError(l1Err)
+		}
+		if l1Head != nil && l1Head.BlockNumber >= block.Number {
+			status = statusAcceptedOnL1
+		}
+	}
+
+	feederBlock, err := core2feeder.AdaptBlock(block, status)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return feederBlock, nil
+}
+
+func (s *Server) stateUpdate(query map[string][]string) (any, *Error) {
+	id, rErr := parseBlockID(query)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	update, err := blockchain.StateUpdateByID(s.bcReader, *id)
+	if err != nil {
+		return nil, &Error{Code: BlockNotFound, Message: "Block was not found"}
+	}
+
+	return core2feeder.AdaptStateUpdate(update), nil
+}
+
+func (s *Server) declaredClass(query map[string][]string) (*core.DeclaredClass, *Error) {
+	hashes, ok := query["classHash"]
+	if !ok || len(hashes) == 0 {
+		return nil, &Error{Code: MalformedRequest, Message: "Missing classHash parameter"}
+	}
+
+	classHash, err := new(felt.Felt).SetString(hashes[0])
+	if err != nil {
+		return nil, &Error{Code: MalformedRequest, Message: fmt.Sprintf("Invalid class hash %q", hashes[0])}
+	}
+
+	// the class is looked up in the state of the requested block, the classes declared after it are not declared
+	id, rErr := parseBlockID(query)
+	if rErr != nil {
+		return nil, rErr
+	}
+	state, closer, err := blockchain.StateByID(s.bcReader, *id)
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) && !id.Latest {
+			return nil, &Error{Code: BlockNotFound, Message: "Block was not found"}
+		}
+		return nil, &Error{Code: UndeclaredClass, Message: fmt.Sprintf("Class with hash %s is not declared", classHash)}
+	}
+	defer func() {
+		if closeErr := closer(); closeErr != nil {
+			s.log.Errorw("Error closing state reader in feeder gateway", "err", closeErr)
+		}
+	}()
+
+	declared, err := state.Class(classHash)
+	if err != nil {
+		return nil, &Error{Code: UndeclaredClass, Message: fmt.Sprintf("Class with hash %s is not declared", classHash)}
+	}
+	return declared, nil
+}
+
+func (s *Server) classByHash(query map[string][]string) (any, *Error) {
+	declared, rErr := s.declaredClass(query)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	definition, err := core2feeder.AdaptClass(declared.Class)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return definition, nil
+}
+
+func (s *Server) compiledClassByClassHash(query map[string][]string) (any, *Error) {
+	declared, rErr := s.declaredClass(query)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	class, ok := declared.Class.(*core.Cairo1Class)
+	if !ok || len(class.Compiled) == 0 {
+		return nil, &Error{Code: UndeclaredClass, Message: "Compiled class is not available"}
+	}
+	return class.Compiled, nil
+}
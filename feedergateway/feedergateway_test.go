@@ -0,0 +1,289 @@
+package feedergateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/feedergateway"
+	"github.com/NethermindEth/juno/mocks"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGatewayClient returns a feeder client that talks to a feeder gateway server backed by bcReader
+func newGatewayClient(t *testing.T, bcReader blockchain.Reader) (*adaptfeeder.Feeder, string) {
+	t.Helper()
+
+	srv := httptest.NewServer(feedergateway.New(bcReader, nil, utils.NewNopZapLogger()))
+	t.Cleanup(srv.Close)
+
+	url := srv.URL + "/feeder_gateway/"
+	return adaptfeeder.New(feeder.NewClient(url).WithMaxRetries(0)), url
+}
+
+func TestBlockAndStateUpdate(t *testing.T) {
+	ctx := context.Background()
+	upstream := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+
+	chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, utils.NewNopZapLogger())
+	var blocks []*core.Block
+	var updates []*core.StateUpdate
+	for i := uint64(0); i < 3; i++ {
+		block, err := upstream.BlockByNumber(ctx, i)
+		require.NoError(t, err)
+		update, err := upstream.StateUpdate(ctx, i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, update, nil))
+
+		blocks = append(blocks, block)
+		updates = append(updates, update)
+	}
+
+	local, url := newGatewayClient(t, chain)
+
+	t.Run("blocks and state updates match the stored data", func(t *testing.T) {
+		for i := range blocks {
+			block, err := local.BlockByNumber(ctx, uint64(i))
+			require.NoError(t, err)
+			assert.Equal(t, blocks[i], block)
+
+			update, err := local.StateUpdate(ctx, uint64(i))
+			require.NoError(t, err)
+			assert.Equal(t, updates[i], update)
+		}
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		block, err := local.BlockLatest(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, blocks[len(blocks)-1], block)
+	})
+
+	t.Run("by hash", func(t *testing.T) {
+		resp, err := http.Get(url + "get_block?blockHash=" + blocks[1].Hash.String())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var block feeder.Block
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&block))
+		assert.Equal(t, blocks[1].Number, block.Number)
+		assert.Equal(t, "ACCEPTED_ON_L2", block.Status)
+	})
+
+	t.Run("block status reflects the l1 head", func(t *testing.T) {
+		require.NoError(t, chain.SetL1Head(&core.L1Head{BlockNumber: 1}))
+
+		resp, err := http.Get(url + "get_block?blockNumber=1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var block feeder.Block
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&block))
+		assert.Equal(t, "ACCEPTED_ON_L1", block.Status)
+	})
+
+	t.Run("block not found", func(t *testing.T) {
+		_, err := local.BlockByNumber(ctx, 42)
+		require.Error(t, err)
+
+		resp, err := http.Get(url + "get_state_update?blockNumber=42")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var gwErr feedergateway.Error
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&gwErr))
+		assert.Equal(t, feedergateway.BlockNotFound, gwErr.Code)
+	})
+
+	t.Run("malformed block number", func(t *testing.T) {
+		resp, err := http.Get(url + "get_block?blockNumber=abc")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var gwErr feedergateway.Error
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&gwErr))
+		assert.Equal(t, feedergateway.MalformedRequest, gwErr.Code)
+	})
+}
+
+func TestPending(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	ctx := context.Background()
+	upstream := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	block, err := upstream.BlockByNumber(ctx, 1)
+	require.NoError(t, err)
+	update, err := upstream.StateUpdate(ctx, 1)
+	require.NoError(t, err)
+
+	mockReader := mocks.NewMockReader(mockCtrl)
+	_, url := newGatewayClient(t, mockReader)
+
+	mockReader.EXPECT().Pending().Return(blockchain.Pending{Block: block, StateUpdate: update}, nil).Times(2)
+
+	resp, err := http.Get(url + "get_block?blockNumber=pending")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var pendingBlock feeder.Block
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&pendingBlock))
+	assert.Equal(t, "PENDING", pendingBlock.Status)
+	assert.Equal(t, block.Number, pendingBlock.Number)
+
+	resp, err = http.Get(url + "get_state_update?blockNumber=pending")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestClass(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	ctx := context.Background()
+	upstream := adaptfeeder.New(feeder.NewTestClient(t, utils.INTEGRATION))
+
+	mockReader := mocks.NewMockReader(mockCtrl)
+	mockState := mocks.NewMockStateHistoryReader(mockCtrl)
+	local, url := newGatewayClient(t, mockReader)
+
+	mockReader.EXPECT().HeadState().Return(mockState, func() error { return nil }, nil).AnyTimes()
+
+	for _, hash := range []string{
+		"0x1cd2edfb485241c4403254d550de0a097fa76743cd30696f714a491a454bad5",
+		"0x4631b6b3fa31e140524b7d21ba784cea223e618bffe60b5bbdca44a8b45be04",
+	} {
+		t.Run(hash, func(t *testing.T) {
+			classHash := utils.HexToFelt(t, hash)
+			class, err := upstream.Class(ctx, classHash)
+			require.NoError(t, err)
+
+			mockState.EXPECT().Class(classHash).Return(&core.DeclaredClass{Class: class}, nil).AnyTimes()
+
+			got, err := local.Class(ctx, classHash)
+			require.NoError(t, err)
+
+			// JSON fields are served re-encoded, so only their content is expected to match
+			switch expected := class.(type) {
+			case *core.Cairo0Class:
+				actual, ok := got.(*core.Cairo0Class)
+				require.True(t, ok)
+				assert.JSONEq(t, string(expected.Abi), string(actual.Abi))
+				assertProgramsEq(t, expected.Program, actual.Program)
+
+				expectedCopy, actualCopy := *expected, *actual
+				expectedCopy.Abi, actualCopy.Abi = nil, nil
+				expectedCopy.Program, actualCopy.Program = "", ""
+				assert.Equal(t, expectedCopy, actualCopy)
+			case *core.Cairo1Class:
+				actual, ok := got.(*core.Cairo1Class)
+				require.True(t, ok)
+				assert.JSONEq(t, string(expected.Compiled), string(actual.Compiled))
+
+				expectedCopy, actualCopy := *expected, *actual
+				expectedCopy.Compiled, actualCopy.Compiled = nil, nil
+				assert.Equal(t, expectedCopy, actualCopy)
+			default:
+				t.Fatalf("unexpected class type %T", class)
+			}
+		})
+	}
+
+	t.Run("undeclared class", func(t *testing.T) {
+		mockState.EXPECT().Class(gomock.Any()).Return(nil, db.ErrKeyNotFound)
+
+		resp, err := http.Get(url + "get_class_by_hash?classHash=0x1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var gwErr feedergateway.Error
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&gwErr))
+		assert.Equal(t, feedergateway.UndeclaredClass, gwErr.Code)
+	})
+}
+
+func TestClassAtBlock(t *testing.T) {
+	ctx := context.Background()
+	upstream := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	classHash := utils.HexToFelt(t, "0x1cd2edfb485241c4403254d550de0a097fa76743cd30696f714a491a454bad5")
+	class, err := adaptfeeder.New(feeder.NewTestClient(t, utils.INTEGRATION)).Class(ctx, classHash)
+	require.NoError(t, err)
+
+	// the class is declared in block 1
+	chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, utils.NewNopZapLogger())
+	for i := uint64(0); i < 2; i++ {
+		block, err := upstream.BlockByNumber(ctx, i)
+		require.NoError(t, err)
+		update, err := upstream.StateUpdate(ctx, i)
+		require.NoError(t, err)
+		var declared map[felt.Felt]core.Class
+		if i == 1 {
+			declared = map[felt.Felt]core.Class{*classHash: class}
+		}
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, update, declared))
+	}
+	_, url := newGatewayClient(t, chain)
+
+	for name, test := range map[string]struct {
+		query string
+		code  string
+	}{
+		"latest":                   {query: ""},
+		"declared in the block":    {query: "&blockNumber=1"},
+		"declared after the block": {query: "&blockNumber=0", code: feedergateway.UndeclaredClass},
+		"unknown block":            {query: "&blockNumber=42", code: feedergateway.BlockNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(url + "get_class_by_hash?classHash=" + classHash.String() + test.query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			if test.code == "" {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			var gwErr feedergateway.Error
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&gwErr))
+			assert.Equal(t, test.code, gwErr.Code)
+		})
+	}
+}
+
+func assertProgramsEq(t *testing.T, expected, actual string) {
+	t.Helper()
+
+	expectedProgram, err := utils.Gzip64Decode(expected)
+	require.NoError(t, err)
+	actualProgram, err := utils.Gzip64Decode(actual)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expectedProgram), string(actualProgram))
+}
+
+func TestUnknownEndpoint(t *testing.T) {
+	_, url := newGatewayClient(t, nil)
+
+	resp, err := http.Get(url + "get_nonce")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,245 @@
+// Package forensics writes the reports that are needed to investigate the blocks whose state root the node
+// computes differently than the network, so that they can be attached to bug reports.
+package forensics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+)
+
+// Report is the state of the contracts and the classes a block changes, before and after its state diff is applied
+// to the local state
+type Report struct {
+	BlockNumber     uint64     `json:"block_number"`
+	BlockHash       *felt.Felt `json:"block_hash"`
+	ParentHash      *felt.Felt `json:"parent_hash"`
+	ProtocolVersion string     `json:"protocol_version"`
+	Error           string     `json:"error"`
+
+	// OldRoot and NewRoot are the roots of the state update, which are compared to the computed roots
+	OldRoot         *felt.Felt `json:"old_root"`
+	NewRoot         *felt.Felt `json:"new_root"`
+	ComputedOldRoot *felt.Felt `json:"computed_old_root"`
+	ComputedNewRoot *felt.Felt `json:"computed_new_root"`
+	Before          Roots      `json:"before"`
+	After           Roots      `json:"after"`
+
+	Contracts []*Contract `json:"contracts"`
+	Classes   []*Class    `json:"classes"`
+}
+
+// Roots are the roots of the tries the state commitment is computed from
+type Roots struct {
+	StorageRoot *felt.Felt `json:"storage_root"`
+	ClassesRoot *felt.Felt `json:"classes_root"`
+}
+
+// Contract is a contract changed by a block, its leaf in the global state trie is Commitment
+type Contract struct {
+	Address *felt.Felt `json:"address"`
+	// Deployed or Replaced is the class hash the contract is deployed with or replaced by, if any
+	Deployed *felt.Felt       `json:"deployed,omitempty"`
+	Replaced *felt.Felt       `json:"replaced,omitempty"`
+	Nonce    *felt.Felt       `json:"nonce,omitempty"`
+	Storage  []*StorageChange `json:"storage,omitempty"`
+	// Before is nil if the contract is deployed by the block
+	Before *ContractState `json:"before"`
+	After  *ContractState `json:"after"`
+}
+
+// ContractState is the state of a contract, its Commitment is computed from the other fields
+type ContractState struct {
+	ClassHash   *felt.Felt `json:"class_hash"`
+	Nonce       *felt.Felt `json:"nonce"`
+	StorageRoot *felt.Felt `json:"storage_root"`
+	Commitment  *felt.Felt `json:"commitment"`
+}
+
+// StorageChange is a storage slot set by a block
+type StorageChange struct {
+	Key *felt.Felt `json:"key"`
+	Old *felt.Felt `json:"old"`
+	New *felt.Felt `json:"new"`
+}
+
+// Class is a class declared by a block. ComputedHash is the hash computed from the definition of a Cairo 1 class,
+// which should be Hash.
+type Class struct {
+	Hash              *felt.Felt `json:"hash"`
+	Version           uint64     `json:"version"`
+	CompiledClassHash *felt.Felt `json:"compiled_class_hash,omitempty"`
+	ComputedHash      *felt.Felt `json:"computed_hash,omitempty"`
+	Definition        bool       `json:"definition"`
+}
+
+// Dump writes the report of block, whose state update failed with cause, to a new file in dir and returns its path.
+// The state diff is applied to the head state of database in a transaction that is discarded.
+func Dump(database db.DB, block *core.Block, update *core.StateUpdate, classes map[felt.Felt]core.Class,
+	cause error, dir string,
+) (string, error) {
+	var report *Report
+	err := database.View(func(txn db.Transaction) error {
+		var err error
+		report, err = newReport(db.NewBufferedTransaction(txn), block, update, classes)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	report.Error = cause.Error()
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("root-mismatch-%d-%s.json", block.Number,
+		time.Now().UTC().Format("20060102T150405Z")))
+	return path, os.WriteFile(path, reportJSON, 0o600)
+}
+
+func newReport(txn db.Transaction, block *core.Block, update *core.StateUpdate,
+	classes map[felt.Felt]core.Class,
+) (*Report, error) {
+	report := &Report{
+		BlockNumber:     block.Number,
+		BlockHash:       block.Hash,
+		ParentHash:      block.ParentHash,
+		ProtocolVersion: block.ProtocolVersion,
+		OldRoot:         update.OldRoot,
+		NewRoot:         update.NewRoot,
+	}
+	diff := update.StateDiff
+	contracts := changedContracts(diff)
+
+	state := core.NewState(txn)
+	var err error
+	if report.Before.StorageRoot, report.Before.ClassesRoot, err = state.Roots(); err != nil {
+		return nil, err
+	}
+	if report.ComputedOldRoot, err = state.Root(); err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		if contract.Before, err = contractState(txn, contract.Address); err != nil {
+			return nil, err
+		}
+		for _, change := range contract.Storage {
+			if contract.Before == nil {
+				change.Old = &felt.Zero
+			} else if change.Old, err = state.ContractStorage(contract.Address, change.Key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if report.ComputedNewRoot, err = state.Apply(block.Number, diff, classes); err != nil {
+		return nil, err
+	}
+	if report.After.StorageRoot, report.After.ClassesRoot, err = state.Roots(); err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		if contract.After, err = contractState(txn, contract.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	report.Contracts = contracts
+	report.Classes = declaredClasses(diff, classes)
+	return report, nil
+}
+
+// changedContracts returns the contracts changed by diff, sorted by address
+func changedContracts(diff *core.StateDiff) []*Contract {
+	byAddress := make(map[felt.Felt]*Contract)
+	contract := func(address *felt.Felt) *Contract {
+		c, ok := byAddress[*address]
+		if !ok {
+			c = &Contract{Address: address}
+			byAddress[*address] = c
+		}
+		return c
+	}
+
+	for _, deployed := range diff.DeployedContracts {
+		contract(deployed.Address).Deployed = deployed.ClassHash
+	}
+	for _, replaced := range diff.ReplacedClasses {
+		contract(replaced.Address).Replaced = replaced.ClassHash
+	}
+	for address, nonce := range diff.Nonces {
+		address := address
+		contract(&address).Nonce = nonce
+	}
+	for address, storageDiffs := range diff.StorageDiffs {
+		address := address
+		c := contract(&address)
+		for _, storageDiff := range storageDiffs {
+			c.Storage = append(c.Storage, &StorageChange{Key: storageDiff.Key, New: storageDiff.Value})
+		}
+	}
+
+	contracts := make([]*Contract, 0, len(byAddress))
+	for _, c := range byAddress {
+		contracts = append(contracts, c)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Address.Cmp(contracts[j].Address) < 0
+	})
+	return contracts
+}
+
+// contractState returns the state of the contract at address, or nil if it is not deployed
+func contractState(txn db.Transaction, address *felt.Felt) (*ContractState, error) {
+	contract, err := core.NewContract(address, txn)
+	if errors.Is(err, core.ErrContractNotDeployed) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	state := new(ContractState)
+	if state.ClassHash, err = contract.ClassHash(); err != nil {
+		return nil, err
+	}
+	if state.Nonce, err = contract.Nonce(); err != nil {
+		return nil, err
+	}
+	if state.StorageRoot, err = contract.Root(); err != nil {
+		return nil, err
+	}
+	if state.Commitment, err = contract.Commitment(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// declaredClasses returns the classes declared by diff, whose definitions are classes
+func declaredClasses(diff *core.StateDiff, classes map[felt.Felt]core.Class) []*Class {
+	declared := make([]*Class, 0, len(diff.DeclaredV0Classes)+len(diff.DeclaredV1Classes))
+	for _, hash := range diff.DeclaredV0Classes {
+		_, ok := classes[*hash]
+		declared = append(declared, &Class{Hash: hash, Version: 0, Definition: ok})
+	}
+	for _, v1 := range diff.DeclaredV1Classes {
+		class := &Class{Hash: v1.ClassHash, Version: 1, CompiledClassHash: v1.CompiledClassHash}
+		if definition, ok := classes[*v1.ClassHash].(*core.Cairo1Class); ok {
+			class.Definition = true
+			class.ComputedHash = definition.Hash()
+		}
+		declared = append(declared, class)
+	}
+	return declared
+}
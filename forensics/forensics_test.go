@@ -0,0 +1,99 @@
+package forensics_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/forensics"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	fetch := func(number uint64) (*core.Block, *core.StateUpdate, map[felt.Felt]core.Class) {
+		block, err := gw.BlockByNumber(context.Background(), number)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), number)
+		require.NoError(t, err)
+		// the classes of the test blocks are not fetched, their contents do not matter
+		classes := make(map[felt.Felt]core.Class)
+		for _, deployed := range stateUpdate.StateDiff.DeployedContracts {
+			classes[*deployed.ClassHash] = &core.Cairo0Class{}
+		}
+		return block, stateUpdate, classes
+	}
+	for i := uint64(0); i < 2; i++ {
+		block, stateUpdate, classes := fetch(i)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, classes))
+	}
+
+	// the state update of the block is corrupted, so its root does not match the state
+	block, stateUpdate, classes := fetch(2)
+	newRoot := stateUpdate.NewRoot
+	stateUpdate.NewRoot = new(felt.Felt).SetUint64(1)
+	storeErr := chain.Store(block, &core.BlockCommitments{}, stateUpdate, classes)
+	require.ErrorIs(t, storeErr, core.ErrMismatchedRoot)
+
+	dir := t.TempDir()
+	path, err := forensics.Dump(testDB, block, stateUpdate, classes, storeErr, dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+
+	reportJSON, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var report forensics.Report
+	require.NoError(t, json.Unmarshal(reportJSON, &report))
+
+	assert.Equal(t, block.Number, report.BlockNumber)
+	assert.Equal(t, storeErr.Error(), report.Error)
+	assert.Equal(t, stateUpdate.OldRoot, report.ComputedOldRoot)
+	assert.Equal(t, newRoot, report.ComputedNewRoot, "the computed root is the root of the valid state update")
+	assert.NotEqual(t, report.Before.StorageRoot, report.After.StorageRoot)
+
+	diff := stateUpdate.StateDiff
+	require.NotEmpty(t, diff.DeployedContracts)
+	deployed := diff.DeployedContracts[0]
+	var found bool
+	for _, contract := range report.Contracts {
+		assert.NotNil(t, contract.After, "every contract exists after the block")
+		if !contract.Address.Equal(deployed.Address) {
+			continue
+		}
+		found = true
+		assert.Nil(t, contract.Before, "the contract is deployed by the block")
+		assert.Equal(t, deployed.ClassHash, contract.Deployed)
+		assert.Equal(t, deployed.ClassHash, contract.After.ClassHash)
+		for _, change := range contract.Storage {
+			assert.Equal(t, &felt.Zero, change.Old)
+		}
+	}
+	assert.True(t, found)
+
+	t.Run("the state is not changed", func(t *testing.T) {
+		height, err := chain.Height()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), height)
+		state, closer, err := chain.HeadState()
+		require.NoError(t, err)
+		_, err = state.ContractClassHash(deployed.Address)
+		require.ErrorIs(t, err, core.ErrContractNotDeployed)
+		require.NoError(t, closer())
+	})
+}
@@ -0,0 +1,59 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+)
+
+// blockLoader loads the blocks a request needs by their number, each once however many of its resolvers need it,
+// such as the events of a page that were emitted in the same block
+type blockLoader struct {
+	bcReader blockchain.Reader
+
+	mu     sync.Mutex
+	blocks map[uint64]*blockLoad
+}
+
+type blockLoad struct {
+	once  sync.Once
+	block *core.Block
+	err   error
+}
+
+func newBlockLoader(bcReader blockchain.Reader) *blockLoader {
+	return &blockLoader{bcReader: bcReader, blocks: make(map[uint64]*blockLoad)}
+}
+
+// load returns the block with number, the resolvers of a request are resolved concurrently
+func (l *blockLoader) load(number uint64) (*core.Block, error) {
+	l.mu.Lock()
+	load, ok := l.blocks[number]
+	if !ok {
+		load = new(blockLoad)
+		l.blocks[number] = load
+	}
+	l.mu.Unlock()
+
+	load.once.Do(func() {
+		load.block, load.err = l.bcReader.BlockByNumber(number)
+	})
+	return load.block, load.err
+}
+
+type blockLoaderKey struct{}
+
+// withBlockLoader returns ctx with the blockLoader of the request ctx is the context of
+func withBlockLoader(ctx context.Context, bcReader blockchain.Reader) context.Context {
+	return context.WithValue(ctx, blockLoaderKey{}, newBlockLoader(bcReader))
+}
+
+// loaderOf returns the blockLoader of the request ctx is the context of, or a loader of its own if there is none
+func (r *resolver) loaderOf(ctx context.Context) *blockLoader {
+	if loader, ok := ctx.Value(blockLoaderKey{}).(*blockLoader); ok {
+		return loader
+	}
+	return newBlockLoader(r.bcReader)
+}
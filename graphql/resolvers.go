@@ -0,0 +1,511 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/NethermindEth/juno/utils"
+)
+
+const maxPageSize = 1000
+
+var (
+	errPageSize     = fmt.Errorf("first must be between 1 and %d", maxPageSize)
+	errInvalidAfter = errors.New("invalid after cursor")
+)
+
+// resolver is the root of the query tree, every resolver below holds on to it to load more data
+type resolver struct {
+	bcReader blockchain.Reader
+	labels   *labels.Registry
+	// nil unless set with Server.WithDecoder
+	decoder *abi.Decoder
+	log     utils.SimpleLogger
+}
+
+type blockArgs struct {
+	Number *Long
+	Hash   *Felt
+}
+
+func (r *resolver) Block(ctx context.Context, args blockArgs) (*blockResolver, error) {
+	var block *core.Block
+	var err error
+	switch {
+	case args.Hash != nil:
+		block, err = r.bcReader.BlockByHash(&args.Hash.Felt)
+	case args.Number != nil:
+		block, err = r.loaderOf(ctx).load(uint64(*args.Number))
+	default:
+		block, err = r.bcReader.Head()
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &blockResolver{r: r, block: block}, nil
+}
+
+func (r *resolver) Transaction(ctx context.Context, args struct{ Hash Felt }) (*transactionResolver, error) {
+	_, blockHash, blockNumber, err := r.bcReader.Receipt(&args.Hash.Felt)
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if blockHash == nil {
+		// pending transactions are not served
+		return nil, nil
+	}
+
+	block, err := r.loaderOf(ctx).load(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return transactionInBlock(r, block, &args.Hash.Felt)
+}
+
+func transactionInBlock(r *resolver, block *core.Block, hash *felt.Felt) (*transactionResolver, error) {
+	for i, txn := range block.Transactions {
+		if txn.Hash().Equal(hash) {
+			return &transactionResolver{r: r, block: block, index: i}, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s not found in block %d", hash, block.Number)
+}
+
+type eventFilterInput struct {
+	FromBlock *Long
+	ToBlock   *Long
+	Address   *Felt
+	Keys      *[][]Felt
+}
+
+type eventsArgs struct {
+	Filter eventFilterInput
+	First  int32
+	After  *string
+}
+
+func (r *resolver) Events(args eventsArgs) (*eventConnection, error) {
+	if args.First < 1 || args.First > maxPageSize {
+		return nil, errPageSize
+	}
+
+	height, err := r.bcReader.Height()
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return &eventConnection{}, nil
+		}
+		return nil, err
+	}
+
+	var address *felt.Felt
+	if args.Filter.Address != nil {
+		address = &args.Filter.Address.Felt
+	}
+	var keys [][]felt.Felt
+	if args.Filter.Keys != nil {
+		keys = make([][]felt.Felt, len(*args.Filter.Keys))
+		for i, options := range *args.Filter.Keys {
+			for _, key := range options {
+				keys[i] = append(keys[i], key.Felt)
+			}
+		}
+	}
+
+	filter, err := r.bcReader.EventFilter(address, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := filter.Close(); closeErr != nil {
+			r.log.Errorw("Error closing event filter in GraphQL events query", "err", closeErr)
+		}
+	}()
+
+	toBlock := height
+	if args.Filter.ToBlock != nil && uint64(*args.Filter.ToBlock) < height {
+		toBlock = uint64(*args.Filter.ToBlock)
+	}
+	if err = filter.SetRangeEndBlockByNumber(blockchain.EventFilterTo, toBlock); err != nil {
+		return nil, err
+	}
+	if args.Filter.FromBlock != nil {
+		if err = filter.SetRangeEndBlockByNumber(blockchain.EventFilterFrom, uint64(*args.Filter.FromBlock)); err != nil {
+			return nil, err
+		}
+	}
+
+	var cToken *blockchain.ContinuationToken
+	if args.After != nil {
+		cToken = new(blockchain.ContinuationToken)
+		if err = cToken.FromString(*args.After); err != nil {
+			return nil, errInvalidAfter
+		}
+	}
+
+	events, next, err := filter.Events(cToken, uint64(args.First))
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &eventConnection{nodes: make([]*eventResolver, len(events))}
+	for i, event := range events {
+		connection.nodes[i] = &eventResolver{
+			r:           r,
+			event:       event.Event,
+			blockNumber: event.BlockNumber,
+			txnHash:     event.TransactionHash,
+		}
+	}
+	if next != nil {
+		cursor := next.String()
+		connection.pageInfo = pageInfo{hasNextPage: true, endCursor: &cursor}
+	}
+	return connection, nil
+}
+
+type blockResolver struct {
+	r     *resolver
+	block *core.Block
+}
+
+func (b *blockResolver) Hash() Felt {
+	return *newFelt(b.block.Hash)
+}
+
+func (b *blockResolver) ParentHash() Felt {
+	return *newFelt(b.block.ParentHash)
+}
+
+func (b *blockResolver) Number() Long {
+	return Long(b.block.Number)
+}
+
+func (b *blockResolver) NewRoot() Felt {
+	return *newFelt(b.block.GlobalStateRoot)
+}
+
+func (b *blockResolver) Timestamp() Long {
+	return Long(b.block.Timestamp)
+}
+
+func (b *blockResolver) SequencerAddress() *Felt {
+	return newFelt(b.block.SequencerAddress)
+}
+
+func (b *blockResolver) GasPrice() *Felt {
+	return newFelt(b.block.GasPrice)
+}
+
+func (b *blockResolver) ProtocolVersion() string {
+	return b.block.ProtocolVersion
+}
+
+func (b *blockResolver) TransactionCount() int32 {
+	return int32(len(b.block.Transactions))
+}
+
+func (b *blockResolver) EventCount() int32 {
+	return int32(b.block.EventCount)
+}
+
+type connectionArgs struct {
+	First int32
+	After *string
+}
+
+func (b *blockResolver) Transactions(args connectionArgs) (*transactionConnection, error) {
+	if args.First < 1 || args.First > maxPageSize {
+		return nil, errPageSize
+	}
+
+	start := 0
+	if args.After != nil {
+		after, err := strconv.Atoi(*args.After)
+		// the cursor is the index of a transaction of the block
+		if err != nil || after < 0 || after >= len(b.block.Transactions) {
+			return nil, errInvalidAfter
+		}
+		start = after + 1
+	}
+
+	end := start + int(args.First)
+	if end > len(b.block.Transactions) {
+		end = len(b.block.Transactions)
+	}
+
+	connection := new(transactionConnection)
+	for i := start; i < end; i++ {
+		connection.nodes = append(connection.nodes, &transactionResolver{r: b.r, block: b.block, index: i})
+	}
+	if end < len(b.block.Transactions) {
+		cursor := strconv.Itoa(end - 1)
+		connection.pageInfo = pageInfo{hasNextPage: true, endCursor: &cursor}
+	}
+	return connection, nil
+}
+
+type transactionResolver struct {
+	r     *resolver
+	block *core.Block
+	index int
+}
+
+func (t *transactionResolver) transaction() core.Transaction {
+	return t.block.Transactions[t.index]
+}
+
+func (t *transactionResolver) receipt() *core.TransactionReceipt {
+	return t.block.Receipts[t.index]
+}
+
+func (t *transactionResolver) Hash() Felt {
+	return *newFelt(t.transaction().Hash())
+}
+
+func (t *transactionResolver) Type() string {
+	switch t.transaction().(type) {
+	case *core.DeployTransaction:
+		return "DEPLOY"
+	case *core.DeployAccountTransaction:
+		return "DEPLOY_ACCOUNT"
+	case *core.DeclareTransaction:
+		return "DECLARE"
+	case *core.InvokeTransaction:
+		return "INVOKE"
+	case *core.L1HandlerTransaction:
+		return "L1_HANDLER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (t *transactionResolver) Index() int32 {
+	return int32(t.index)
+}
+
+func (t *transactionResolver) Block() *blockResolver {
+	return &blockResolver{r: t.r, block: t.block}
+}
+
+func (t *transactionResolver) ActualFee() *Felt {
+	return newFelt(t.receipt().Fee)
+}
+
+func (t *transactionResolver) Reverted() bool {
+	return t.receipt().Reverted
+}
+
+func (t *transactionResolver) RevertReason() *string {
+	if !t.receipt().Reverted {
+		return nil
+	}
+	return &t.receipt().RevertReason
+}
+
+func (t *transactionResolver) DecodedCalldata() (*decodedResolver, error) {
+	var contract, selector *felt.Felt
+	var calldata []*felt.Felt
+	switch txn := t.transaction().(type) {
+	case *core.InvokeTransaction:
+		contract, selector, calldata = txn.ContractAddress, txn.EntryPointSelector, txn.CallData
+		if !txn.Version.IsZero() {
+			// the invoke transactions after v0 call the __execute__ entry point of their account
+			contract, selector = txn.SenderAddress, executeSelector
+		}
+	case *core.L1HandlerTransaction:
+		contract, selector, calldata = txn.ContractAddress, txn.EntryPointSelector, txn.CallData
+	default:
+		return nil, nil
+	}
+	if t.r.decoder == nil {
+		return nil, nil
+	}
+	return decodedOrNil(t.r.decoder.DecodeCall(contract, selector, calldata, t.block.Number))
+}
+
+func (t *transactionResolver) Events() []*eventResolver {
+	events := t.receipt().Events
+	resolvers := make([]*eventResolver, len(events))
+	for i, event := range events {
+		resolvers[i] = &eventResolver{
+			r:           t.r,
+			event:       event,
+			blockNumber: t.block.Number,
+			txnHash:     t.transaction().Hash(),
+			block:       t.block,
+		}
+	}
+	return resolvers
+}
+
+type eventResolver struct {
+	r           *resolver
+	event       *core.Event
+	blockNumber uint64
+	txnHash     *felt.Felt
+	block       *core.Block // loaded on demand, see blockLoader
+}
+
+func (e *eventResolver) From() Felt {
+	return *newFelt(e.event.From)
+}
+
+func (e *eventResolver) FromLabel() *string {
+	return labelOrNil(e.r.labels.Address(e.event.From))
+}
+
+// Name is the label of the selector of the event, which is its first key
+func (e *eventResolver) Name() *string {
+	if len(e.event.Keys) == 0 {
+		return nil
+	}
+	return labelOrNil(e.r.labels.Selector(e.event.Keys[0]))
+}
+
+func labelOrNil(label string, ok bool) *string {
+	if !ok {
+		return nil
+	}
+	return &label
+}
+
+func (e *eventResolver) Keys() []Felt {
+	return newFelts(e.event.Keys)
+}
+
+func (e *eventResolver) Data() []Felt {
+	return newFelts(e.event.Data)
+}
+
+func (e *eventResolver) Decoded() (*decodedResolver, error) {
+	if e.r.decoder == nil {
+		return nil, nil
+	}
+	return decodedOrNil(e.r.decoder.DecodeEvent(e.event, e.blockNumber))
+}
+
+func (e *eventResolver) loadBlock(ctx context.Context) (*core.Block, error) {
+	if e.block != nil {
+		return e.block, nil
+	}
+	return e.r.loaderOf(ctx).load(e.blockNumber)
+}
+
+func (e *eventResolver) Transaction(ctx context.Context) (*transactionResolver, error) {
+	block, err := e.loadBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return transactionInBlock(e.r, block, e.txnHash)
+}
+
+func (e *eventResolver) Block(ctx context.Context) (*blockResolver, error) {
+	block, err := e.loadBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &blockResolver{r: e.r, block: block}, nil
+}
+
+type pageInfo struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (p pageInfo) HasNextPage() bool {
+	return p.hasNextPage
+}
+
+func (p pageInfo) EndCursor() *string {
+	return p.endCursor
+}
+
+type transactionConnection struct {
+	nodes    []*transactionResolver
+	pageInfo pageInfo
+}
+
+func (c *transactionConnection) Nodes() []*transactionResolver {
+	return c.nodes
+}
+
+func (c *transactionConnection) PageInfo() pageInfo {
+	return c.pageInfo
+}
+
+type eventConnection struct {
+	nodes    []*eventResolver
+	pageInfo pageInfo
+}
+
+func (c *eventConnection) Nodes() []*eventResolver {
+	return c.nodes
+}
+
+func (c *eventConnection) PageInfo() pageInfo {
+	return c.pageInfo
+}
+
+// executeSelector is the selector of the __execute__ entry point of the accounts
+var executeSelector = func() *felt.Felt {
+	selector, err := crypto.StarknetKeccak([]byte("__execute__"))
+	if err != nil {
+		panic(err)
+	}
+	return selector
+}()
+
+// decodedOrNil returns the resolver of decoded, or nil if it could not be decoded with the ABI of its contract
+func decodedOrNil(decoded *abi.Decoded, err error) (*decodedResolver, error) {
+	if err != nil || decoded == nil {
+		return nil, err
+	}
+	return &decodedResolver{decoded: decoded}, nil
+}
+
+type decodedResolver struct {
+	decoded *abi.Decoded
+}
+
+func (d *decodedResolver) Name() string {
+	return d.decoded.Name
+}
+
+func (d *decodedResolver) Fields() []*decodedFieldResolver {
+	resolvers := make([]*decodedFieldResolver, len(d.decoded.Fields))
+	for i := range d.decoded.Fields {
+		resolvers[i] = &decodedFieldResolver{field: &d.decoded.Fields[i]}
+	}
+	return resolvers
+}
+
+type decodedFieldResolver struct {
+	field *abi.Field
+}
+
+func (f *decodedFieldResolver) Name() string {
+	return f.field.Name
+}
+
+func (f *decodedFieldResolver) Type() string {
+	return f.field.Type
+}
+
+func (f *decodedFieldResolver) Value() *JSON {
+	return &JSON{Value: f.field.Value}
+}
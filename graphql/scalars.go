@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Felt implements the Felt scalar
+type Felt struct {
+	felt.Felt
+}
+
+func newFelt(f *felt.Felt) *Felt {
+	if f == nil {
+		return nil
+	}
+	return &Felt{Felt: *f}
+}
+
+func newFelts(felts []*felt.Felt) []Felt {
+	result := make([]Felt, len(felts))
+	for i, f := range felts {
+		result[i] = Felt{Felt: *f}
+	}
+	return result
+}
+
+func (Felt) ImplementsGraphQLType(name string) bool {
+	return name == "Felt"
+}
+
+func (f *Felt) UnmarshalGraphQL(input any) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("wrong type for Felt: %T", input)
+	}
+	_, err := f.Felt.SetString(str)
+	return err
+}
+
+func (f Felt) MarshalJSON() ([]byte, error) {
+	return f.Felt.MarshalJSON()
+}
+
+// Long implements the Long scalar, GraphQL's Int only holds 32 bit signed integers
+type Long uint64
+
+func (Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+func (l *Long) UnmarshalGraphQL(input any) error {
+	switch input := input.(type) {
+	case int32:
+		if input < 0 {
+			return errors.New("negative Long")
+		}
+		*l = Long(input)
+	case int64:
+		if input < 0 {
+			return errors.New("negative Long")
+		}
+		*l = Long(input)
+	case float64:
+		if input < 0 || input != float64(uint64(input)) {
+			return fmt.Errorf("invalid Long %v", input)
+		}
+		*l = Long(input)
+	case string:
+		value, err := strconv.ParseUint(input, 0, 64)
+		if err != nil {
+			return err
+		}
+		*l = Long(value)
+	default:
+		return fmt.Errorf("wrong type for Long: %T", input)
+	}
+	return nil
+}
+
+func (l Long) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(l), 10)), nil
+}
+
+// JSON implements the JSON scalar, for the values whose type is not known to the schema
+type JSON struct {
+	Value any
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input any) error {
+	j.Value = input
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Value)
+}
@@ -0,0 +1,115 @@
+package graphql
+
+const schema = `
+schema {
+	query: Query
+}
+
+"A field element, encoded as a 0x-prefixed hex string"
+scalar Felt
+
+"An unsigned 64 bit integer"
+scalar Long
+
+"A JSON value"
+scalar JSON
+
+type Query {
+	"The block with the given number or hash, or the latest block if neither is given"
+	block(number: Long, hash: Felt): Block
+	"The transaction with the given hash"
+	transaction(hash: Felt!): Transaction
+	"The events that match filter, in the order they were emitted"
+	events(filter: EventFilter!, first: Int = 100, after: String): EventConnection!
+}
+
+input EventFilter {
+	"The first block to search, the genesis block if not given"
+	fromBlock: Long
+	"The last block to search, the latest block if not given"
+	toBlock: Long
+	"Only match events emitted by this contract"
+	address: Felt
+	"Only match events whose n-th key is one of keys[n], an empty list matches any key"
+	keys: [[Felt!]!]
+}
+
+type Block {
+	hash: Felt!
+	parentHash: Felt!
+	number: Long!
+	newRoot: Felt!
+	timestamp: Long!
+	sequencerAddress: Felt
+	gasPrice: Felt
+	protocolVersion: String!
+	transactionCount: Int!
+	eventCount: Int!
+	transactions(first: Int = 100, after: String): TransactionConnection!
+}
+
+type Transaction {
+	hash: Felt!
+	type: String!
+	index: Int!
+	block: Block!
+	actualFee: Felt
+	reverted: Boolean!
+	revertReason: String
+	events: [Event!]!
+	"""
+	The calldata of an invoke or L1 handler transaction decoded with the ABI of the class of the contract it calls,
+	if the node decodes with ABIs and the ABI has the entry point
+	"""
+	decodedCalldata: Decoded
+}
+
+type Event {
+	from: Felt!
+	"The label of the contract that emitted the event, if the node has one"
+	fromLabel: String
+	"The label of the selector of the event, its first key, if the node has one"
+	name: String
+	keys: [Felt!]!
+	data: [Felt!]!
+	"""
+	The event decoded with the ABI of the class of the contract that emitted it, if the node decodes with ABIs and
+	the ABI has the event
+	"""
+	decoded: Decoded
+	transaction: Transaction!
+	block: Block!
+}
+
+"An event or a call decoded with an ABI"
+type Decoded {
+	name: String!
+	fields: [DecodedField!]!
+}
+
+type DecodedField {
+	name: String!
+	type: String!
+	"""
+	A felt as a hex string, an array as a list of its elements, a struct as a list of its fields and an enum as the
+	field of its variant
+	"""
+	value: JSON
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	"Pass as the after argument to fetch the next page"
+	endCursor: String
+}
+
+type TransactionConnection {
+	nodes: [Transaction!]!
+	pageInfo: PageInfo!
+}
+
+type EventConnection {
+	nodes: [Event!]!
+	pageInfo: PageInfo!
+}
+`
@@ -0,0 +1,112 @@
+// Package graphql serves a GraphQL API over the blockchain and its event index, so that clients can
+// fetch nested data such as a block, its transactions and their events in a single request.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	path               = "/graphql"
+	maxQueryDepth      = 10
+	maxRequestBodySize = 1024 * 1024 // 1MB
+)
+
+var _ service.Service = (*Server)(nil)
+
+type Server struct {
+	schema   *graphql.Schema
+	resolver *resolver
+	listener net.Listener
+	log      utils.SimpleLogger
+}
+
+// New returns a Server of the blocks of bcReader, whose responses include the labels of registry
+func New(bcReader blockchain.Reader, registry *labels.Registry, listener net.Listener, log utils.SimpleLogger) *Server {
+	r := &resolver{bcReader: bcReader, labels: registry, log: log}
+	return &Server{
+		schema:   graphql.MustParseSchema(schema, r, graphql.MaxDepth(maxQueryDepth)),
+		resolver: r,
+		listener: listener,
+		log:      log,
+	}
+}
+
+// WithDecoder decodes the events and the calldata the server responds with, see abi.Decoder
+func (s *Server) WithDecoder(decoder *abi.Decoder) *Server {
+	s.resolver.decoder = decoder
+	return s
+}
+
+// Run starts to listen for GraphQL requests
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error)
+
+	mux := http.NewServeMux()
+	mux.Handle(path, s)
+	srv := &http.Server{
+		Addr:    s.listener.Addr().String(),
+		Handler: mux,
+		// ReadTimeout also sets ReadHeaderTimeout and IdleTimeout.
+		ReadTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		errCh <- srv.Shutdown(context.Background())
+		close(errCh)
+	}()
+
+	if err := srv.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-errCh
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP executes the GraphQL query in the body of a POST request
+func (s *Server) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var query request
+	if err := json.NewDecoder(http.MaxBytesReader(writer, req.Body, maxRequestBodySize)).Decode(&query); err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the blocks are loaded once per request, see blockLoader
+	ctx := withBlockLoader(req.Context(), s.resolver.bcReader)
+	resp := s.schema.Exec(ctx, query.Query, query.OperationName, query.Variables)
+	body, err := json.Marshal(resp)
+	if err != nil {
+		s.log.Errorw("Failed to marshal GraphQL response", "err", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if _, err = writer.Write(body); err != nil {
+		s.log.Debugw("Failed to write GraphQL response", "err", err)
+	}
+}
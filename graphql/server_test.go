@@ -0,0 +1,427 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/graphql"
+	"github.com/NethermindEth/juno/labels"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// newTestServer stores the first blocks of network and returns a function to query them over GraphQL
+func newTestServer(t *testing.T, network utils.Network, blockCount uint64, registry *labels.Registry) (
+	func(query string, vars map[string]any) response,
+	[]*core.Block,
+) {
+	t.Helper()
+
+	chain, blocks := storeBlocks(t, network, blockCount)
+	return serve(t, chain, registry), blocks
+}
+
+// storeBlocks stores the first blocks of network
+func storeBlocks(t *testing.T, network utils.Network, blockCount uint64) (*blockchain.Blockchain, []*core.Block) {
+	t.Helper()
+
+	ctx := context.Background()
+	upstream := adaptfeeder.New(feeder.NewTestClient(t, network))
+	chain := blockchain.New(pebble.NewMemTest(), network, utils.NewNopZapLogger())
+
+	var blocks []*core.Block
+	for i := uint64(0); i < blockCount; i++ {
+		block, err := upstream.BlockByNumber(ctx, i)
+		require.NoError(t, err)
+		update, err := upstream.StateUpdate(ctx, i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, update, nil))
+		blocks = append(blocks, block)
+	}
+	return chain, blocks
+}
+
+// serve returns a function to query the blocks of bcReader over GraphQL
+func serve(t *testing.T, bcReader blockchain.Reader, registry *labels.Registry) func(string, map[string]any) response {
+	t.Helper()
+
+	ctx := context.Background()
+	srv := httptest.NewServer(graphql.New(bcReader, registry, nil, utils.NewNopZapLogger()))
+	t.Cleanup(srv.Close)
+
+	return func(query string, vars map[string]any) response {
+		t.Helper()
+
+		body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+		require.NoError(t, err)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result response
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+}
+
+func TestBlockQuery(t *testing.T) {
+	query, blocks := newTestServer(t, utils.MAINNET, 3, nil)
+
+	t.Run("nested transactions and events", func(t *testing.T) {
+		resp := query(`query($number: Long) {
+			block(number: $number) {
+				hash
+				number
+				transactionCount
+				transactions(first: 2) {
+					nodes { hash index events { from } }
+					pageInfo { hasNextPage endCursor }
+				}
+			}
+		}`, map[string]any{"number": 0})
+		require.Empty(t, resp.Errors)
+
+		var data struct {
+			Block struct {
+				Hash             string
+				Number           uint64
+				TransactionCount int
+				Transactions     struct {
+					Nodes []struct {
+						Hash   string
+						Index  int
+						Events []struct{ From string }
+					}
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   *string
+					}
+				}
+			}
+		}
+		require.NoError(t, json.Unmarshal(resp.Data, &data))
+
+		block := blocks[0]
+		assert.Equal(t, block.Hash.String(), data.Block.Hash)
+		assert.Equal(t, uint64(0), data.Block.Number)
+		assert.Equal(t, len(block.Transactions), data.Block.TransactionCount)
+		require.Len(t, data.Block.Transactions.Nodes, 2)
+		for i, node := range data.Block.Transactions.Nodes {
+			assert.Equal(t, block.Transactions[i].Hash().String(), node.Hash)
+			assert.Equal(t, i, node.Index)
+			assert.Len(t, node.Events, len(block.Receipts[i].Events))
+		}
+		assert.True(t, data.Block.Transactions.PageInfo.HasNextPage)
+		require.NotNil(t, data.Block.Transactions.PageInfo.EndCursor)
+
+		resp = query(`query($after: String) {
+			block(number: 0) { transactions(first: 1000, after: $after) { nodes { index } pageInfo { hasNextPage } } }
+		}`, map[string]any{"after": *data.Block.Transactions.PageInfo.EndCursor})
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"block": {"transactions": {
+			"nodes": [`+indexNodes(2, len(block.Transactions))+`],
+			"pageInfo": {"hasNextPage": false}
+		}}}`, string(resp.Data))
+	})
+
+	t.Run("latest and by hash", func(t *testing.T) {
+		resp := query(`{ block { number } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"block": {"number": 2}}`, string(resp.Data))
+
+		resp = query(`query($hash: Felt) { block(hash: $hash) { number } }`,
+			map[string]any{"hash": blocks[1].Hash.String()})
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"block": {"number": 1}}`, string(resp.Data))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		resp := query(`{ block(number: 42) { number } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"block": null}`, string(resp.Data))
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		resp := query(`{ block { transactions(first: 0) { nodes { index } } } }`, nil)
+		assert.NotEmpty(t, resp.Errors)
+	})
+
+	t.Run("cursor out of the block", func(t *testing.T) {
+		for _, after := range []string{strconv.Itoa(len(blocks[0].Transactions)), strconv.Itoa(math.MaxInt)} {
+			resp := query(`query($after: String) {
+				block(number: 0) { transactions(first: 10, after: $after) { nodes { index } } }
+			}`, map[string]any{"after": after})
+			require.Len(t, resp.Errors, 1, after)
+			assert.Equal(t, "invalid after cursor", resp.Errors[0].Message)
+		}
+	})
+}
+
+func indexNodes(from, to int) string {
+	var nodes []string
+	for i := from; i < to; i++ {
+		nodes = append(nodes, fmt.Sprintf(`{"index": %d}`, i))
+	}
+	return strings.Join(nodes, ",")
+}
+
+func TestTransactionQuery(t *testing.T) {
+	query, blocks := newTestServer(t, utils.MAINNET, 3, nil)
+
+	txn := blocks[1].Transactions[0]
+	resp := query(`query($hash: Felt!) { transaction(hash: $hash) { hash index reverted block { number } } }`,
+		map[string]any{"hash": txn.Hash().String()})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"transaction": {"hash": "`+txn.Hash().String()+`", "index": 0, "reverted": false,
+		"block": {"number": 1}}}`, string(resp.Data))
+
+	resp = query(`{ transaction(hash: "0x1") { hash } }`, nil)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"transaction": null}`, string(resp.Data))
+}
+
+func TestEventsQuery(t *testing.T) {
+	query, blocks := newTestServer(t, utils.GOERLI2, 6, nil)
+
+	var expected []*core.Event
+	for _, block := range blocks {
+		for _, receipt := range block.Receipts {
+			expected = append(expected, receipt.Events...)
+		}
+	}
+	require.NotEmpty(t, expected)
+
+	var got []string
+	var after *string
+	for {
+		resp := query(`query($after: String) {
+			events(filter: {fromBlock: 0}, first: 1, after: $after) {
+				nodes { from transaction { hash } block { number } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}`, map[string]any{"after": after})
+		require.Empty(t, resp.Errors)
+
+		var data struct {
+			Events struct {
+				Nodes []struct {
+					From string
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   *string
+				}
+			}
+		}
+		require.NoError(t, json.Unmarshal(resp.Data, &data))
+		for _, node := range data.Events.Nodes {
+			got = append(got, node.From)
+		}
+		if !data.Events.PageInfo.HasNextPage {
+			break
+		}
+		after = data.Events.PageInfo.EndCursor
+	}
+
+	require.Len(t, got, len(expected))
+	for i, event := range expected {
+		assert.Equal(t, event.From.String(), got[i])
+	}
+}
+
+// countingReader counts the blocks read by their number
+type countingReader struct {
+	blockchain.Reader
+
+	mu    sync.Mutex
+	reads map[uint64]int
+}
+
+func (r *countingReader) BlockByNumber(number uint64) (*core.Block, error) {
+	r.mu.Lock()
+	r.reads[number]++
+	r.mu.Unlock()
+	return r.Reader.BlockByNumber(number)
+}
+
+func TestEventsQueryLoadsBlocksOnce(t *testing.T) {
+	chain, blocks := storeBlocks(t, utils.GOERLI2, 6)
+	reader := &countingReader{Reader: chain, reads: make(map[uint64]int)}
+	query := serve(t, reader, nil)
+
+	var expected int
+	emitting := make(map[uint64]int)
+	for _, block := range blocks {
+		for _, receipt := range block.Receipts {
+			expected += len(receipt.Events)
+			if len(receipt.Events) > 0 {
+				emitting[block.Number] = 1
+			}
+		}
+	}
+	require.Greater(t, expected, len(emitting), "a block emitted several events")
+
+	resp := query(`{
+		events(filter: {fromBlock: 0}, first: 1000) {
+			nodes { transaction { hash } block { number } }
+		}
+	}`, nil)
+	require.Empty(t, resp.Errors)
+	var data struct {
+		Events struct {
+			Nodes []struct {
+				Block struct{ Number uint64 }
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.Len(t, data.Events.Nodes, expected)
+	assert.Equal(t, emitting, reader.reads, "each block is read once per request")
+
+	t.Run("per request", func(t *testing.T) {
+		reader.reads = make(map[uint64]int)
+		txn := blocks[1].Transactions[0]
+		for i := 0; i < 2; i++ {
+			resp := query(`query($hash: Felt!) { transaction(hash: $hash) { hash } }`,
+				map[string]any{"hash": txn.Hash().String()})
+			require.Empty(t, resp.Errors)
+		}
+		assert.Equal(t, map[uint64]int{1: 2}, reader.reads)
+	})
+}
+
+func TestEventLabels(t *testing.T) {
+	registry := labels.New()
+	query, blocks := newTestServer(t, utils.GOERLI2, 6, registry)
+
+	var labelled, unlabelled *core.Event
+	for _, block := range blocks {
+		for _, receipt := range block.Receipts {
+			for _, event := range receipt.Events {
+				if labelled == nil {
+					labelled = event
+				} else if unlabelled == nil && !event.From.Equal(labelled.From) {
+					unlabelled = event
+				}
+			}
+		}
+	}
+	require.NotNil(t, labelled)
+	require.NotNil(t, unlabelled)
+	require.NotEmpty(t, labelled.Keys)
+	require.NoError(t, registry.Add(&labels.File{
+		Addresses: map[string]string{labelled.From.String(): "Token"},
+		Selectors: map[string]string{labelled.Keys[0].String(): "Transfer"},
+	}))
+
+	eventsOf := func(address string) []struct {
+		FromLabel *string
+		Name      *string
+	} {
+		resp := query(`query($address: Felt) {
+			events(filter: {address: $address}, first: 1) { nodes { fromLabel name } }
+		}`, map[string]any{"address": address})
+		require.Empty(t, resp.Errors)
+		var data struct {
+			Events struct {
+				Nodes []struct {
+					FromLabel *string
+					Name      *string
+				}
+			}
+		}
+		require.NoError(t, json.Unmarshal(resp.Data, &data))
+		require.Len(t, data.Events.Nodes, 1)
+		return data.Events.Nodes
+	}
+
+	nodes := eventsOf(labelled.From.String())
+	require.NotNil(t, nodes[0].FromLabel)
+	assert.Equal(t, "Token", *nodes[0].FromLabel)
+	require.NotNil(t, nodes[0].Name)
+	assert.Equal(t, "Transfer", *nodes[0].Name)
+
+	nodes = eventsOf(unlabelled.From.String())
+	assert.Nil(t, nodes[0].FromLabel)
+}
+
+func TestDecodedCalldata(t *testing.T) {
+	ctx := context.Background()
+	upstream := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	testDB := pebble.NewMemTest()
+	chain := blockchain.New(testDB, utils.MAINNET, utils.NewNopZapLogger()).WithABIs()
+
+	block, err := upstream.BlockByNumber(ctx, 0)
+	require.NoError(t, err)
+	update, err := upstream.StateUpdate(ctx, 0)
+	require.NoError(t, err)
+	classHash := utils.HexToFelt(t, "0x10455c752b86932ce552f2b0fe81a880746649b9aee7e0d842bf3f52378f9f8")
+	class, err := upstream.Class(ctx, classHash)
+	require.NoError(t, err)
+	require.NoError(t, chain.Store(block, &core.BlockCommitments{}, update, map[felt.Felt]core.Class{*classHash: class}))
+
+	for name, decoder := range map[string]*abi.Decoder{"disabled": nil, "enabled": abi.NewDecoder(testDB)} {
+		t.Run(name, func(t *testing.T) {
+			server := graphql.New(chain, nil, nil, utils.NewNopZapLogger())
+			if decoder != nil {
+				server = server.WithDecoder(decoder)
+			}
+			srv := httptest.NewServer(server)
+			t.Cleanup(srv.Close)
+
+			invoke, ok := block.Transactions[3].(*core.InvokeTransaction)
+			require.True(t, ok)
+			body, err := json.Marshal(map[string]any{
+				"query":     `query($hash: Felt!) { transaction(hash: $hash) { decodedCalldata { fields { type value } } } }`,
+				"variables": map[string]any{"hash": invoke.Hash().String()},
+			})
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewReader(body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var result response
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			require.Empty(t, result.Errors)
+
+			if decoder == nil {
+				assert.JSONEq(t, `{"transaction": {"decodedCalldata": null}}`, string(result.Data))
+				return
+			}
+			var fields []string
+			for _, calldata := range invoke.CallData {
+				fields = append(fields, fmt.Sprintf(`{"type": "felt", "value": %q}`, calldata.String()))
+			}
+			assert.JSONEq(t, `{"transaction": {"decodedCalldata": {"fields": [`+strings.Join(fields, ",")+`]}}}`,
+				string(result.Data))
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 
 	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/grpc/gen"
@@ -12,31 +13,46 @@ import (
 )
 
 type Server struct {
-	port    uint16
-	version string
-	srv     *grpc.Server
-	db      db.DB
-	log     utils.SimpleLogger
+	port     uint16
+	listener net.Listener
+	version  string
+	srv      *grpc.Server
+	db       db.DB
+	log      utils.SimpleLogger
+	// streams are the handlers in progress, they read the database so Run waits for them before returning
+	streams sync.WaitGroup
 }
 
 func NewServer(port uint16, version string, database db.DB, log utils.SimpleLogger) *Server {
-	srv := grpc.NewServer()
-
-	return &Server{
-		srv:     srv,
+	s := &Server{
 		db:      database,
 		port:    port,
 		version: version,
 		log:     log,
 	}
+	s.srv = grpc.NewServer(grpc.StreamInterceptor(s.trackStream))
+	return s
 }
 
-func (s *Server) Run(ctx context.Context) error {
-	addr := fmt.Sprintf(":%d", s.port)
+func (s *Server) trackStream(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	s.streams.Add(1)
+	defer s.streams.Done()
+	return handler(srv, stream)
+}
 
-	lis, err := net.Listen("tcp", addr)
-	if err != nil {
-		return err
+// WithListener serves on listener instead of the port of the server
+func (s *Server) WithListener(listener net.Listener) *Server {
+	s.listener = listener
+	return s
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	lis := s.listener
+	if lis == nil {
+		var err error
+		if lis, err = net.Listen("tcp", fmt.Sprintf(":%d", s.port)); err != nil {
+			return err
+		}
 	}
 
 	go func() {
@@ -46,5 +62,8 @@ func (s *Server) Run(ctx context.Context) error {
 
 	gen.RegisterKVServer(s.srv, handlers{s.db, s.version})
 
-	return s.srv.Serve(lis)
+	err := s.srv.Serve(lis)
+	// Stop cancels the streams but does not wait for their handlers
+	s.streams.Wait()
+	return err
 }
@@ -91,6 +91,17 @@ func (h handlers) handleTxCursor(
 			responsePair.K = it.Key()
 			responsePair.V, err = it.Value()
 		}
+	case gen.Op_LAST:
+		prefix := utils.Flatten(cur.BucketName, cur.K)
+		if it.SeekLast(prefix) {
+			responsePair.K = it.Key()
+			responsePair.V, err = it.Value()
+		}
+	case gen.Op_PREV:
+		if it.Prev() {
+			responsePair.K = it.Key()
+			responsePair.V, err = it.Value()
+		}
 	case gen.Op_CURRENT:
 		if it.Valid() {
 			responsePair.K = it.Key()
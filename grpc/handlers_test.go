@@ -55,6 +55,8 @@ func TestHandlers_Tx(t *testing.T) {
 		gen.Op_SEEK_EXACT,
 		gen.Op_NEXT,
 		gen.Op_CURRENT,
+		gen.Op_LAST,
+		gen.Op_PREV,
 	}
 
 	for _, op := range ops {
@@ -45,5 +45,5 @@ func (t *tx) cleanup() error {
 		err = errors.Join(err, it.Close())
 	}
 
-	return err
+	return errors.Join(err, t.dbTx.Discard())
 }
@@ -0,0 +1,265 @@
+// Package indexer builds the optional indexes of the blockchain as a background job. It backfills an index over
+// the stored blocks at a limited rate and then keeps it up to date with the new blocks, recording its progress
+// with every block, so that it resumes where it stopped when the node restarts.
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ jobs.Job = (*Scheduler)(nil)
+
+const (
+	// HeadPollInterval is how frequently the head should be checked for new blocks once the indexes caught up
+	// with it
+	HeadPollInterval = 10 * time.Second
+	// undoDepth is the number of recent blocks whose keys are kept to unindex them if they are reverted, an
+	// index is rebuilt from genesis if a reorg reverts more blocks than that
+	undoDepth = 128
+	// logInterval is the number of blocks between the logs of the progress of a backfill
+	logInterval = 10000
+)
+
+// Scheduler builds a set of optional indexes, one block at a time
+type Scheduler struct {
+	database db.DB
+	indexes  []blockchain.Index
+	log      utils.SimpleLogger
+	// started is whether the progress of the indexes was logged
+	started bool
+
+	// metrics
+	indexedBlocks *prometheus.GaugeVec
+}
+
+// New returns a Scheduler that builds indexes, its steps are rate limited by the jobs.Manager that runs it
+func New(database db.DB, indexes []blockchain.Index, log utils.SimpleLogger) *Scheduler {
+	s := &Scheduler{
+		database: database,
+		indexes:  indexes,
+		log:      log,
+		indexedBlocks: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "indexer",
+			Name:      "blocks",
+			Help:      "The number of blocks an optional index covers",
+		}, []string{"index"}),
+	}
+	metrics.MustRegister(s.indexedBlocks)
+	return s
+}
+
+// Step logs the progress of the indexes on the first step, and then indexes the next block of every index that
+// is behind the head. It returns whether an index is still behind it.
+func (s *Scheduler) Step(ctx context.Context) (bool, error) {
+	if !s.started {
+		for _, index := range s.indexes {
+			indexed, err := s.progress(index)
+			if err != nil {
+				return false, err
+			}
+			s.log.Infow("Building optional index in the background", "index", index.Name(), "indexed", indexed)
+		}
+		s.started = true
+	}
+	caughtUp, err := s.indexNext(ctx)
+	return !caughtUp, err
+}
+
+// indexNext indexes the next block of every index that is behind the head, and returns whether they all
+// caught up with it
+func (s *Scheduler) indexNext(ctx context.Context) (bool, error) {
+	caughtUp := true
+	for _, index := range s.indexes {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		indexed, err := s.step(index)
+		if err != nil {
+			return false, fmt.Errorf("index %s: %w", index.Name(), err)
+		}
+		if indexed == nil {
+			continue
+		}
+
+		caughtUp = false
+		s.indexedBlocks.WithLabelValues(index.Name()).Set(float64(*indexed))
+		if *indexed%logInterval == 0 {
+			s.log.Infow("Building optional index", "index", index.Name(), "indexed", *indexed)
+		}
+	}
+	return caughtUp, nil
+}
+
+func (s *Scheduler) progress(index blockchain.Index) (uint64, error) {
+	var indexed uint64
+	return indexed, s.database.View(func(txn db.Transaction) error {
+		var err error
+		indexed, _, err = blockchain.IndexProgress(txn, index.Name())
+		return err
+	})
+}
+
+// step unindexes the last block of index if it was reverted, or indexes the next block. It returns the number
+// of blocks the index covers after the step, or nil if the index is up to date with the head.
+func (s *Scheduler) step(index blockchain.Index) (*uint64, error) {
+	var indexed *uint64
+	return indexed, s.database.Update(func(txn db.Transaction) error {
+		progress, lastHash, err := blockchain.IndexProgress(txn, index.Name())
+		if err != nil {
+			return err
+		}
+
+		if progress > 0 {
+			last, headerErr := blockchain.BlockHeaderByNumber(txn, progress-1)
+			if headerErr != nil && !errors.Is(headerErr, db.ErrKeyNotFound) {
+				return headerErr
+			}
+			if headerErr != nil || !last.Hash.Equal(lastHash) {
+				progress, err = unindex(txn, index, progress)
+				indexed = &progress
+				return err
+			}
+		}
+
+		block, err := blockchain.BlockByNumber(txn, progress)
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		keys := index.Keys(block)
+		for _, key := range keys {
+			if err = txn.Set(key, nil); err != nil {
+				return err
+			}
+		}
+		if err = setUndo(txn, index, block, keys); err != nil {
+			return err
+		}
+		progress++
+		indexed = &progress
+		return blockchain.SetIndexProgress(txn, index.Name(), progress, block.Hash)
+	})
+}
+
+// undo is what is kept of the recent blocks an index covers to unindex them if they are reverted
+type undo struct {
+	// Hash is the hash of the block the keys were indexed from
+	Hash *felt.Felt
+	Keys [][]byte
+}
+
+// unindex removes the last block an index covers, which was reverted, and returns the number of blocks the
+// index covers after that. The index is dropped if the block is too old to be unindexed.
+func unindex(txn db.Transaction, index blockchain.Index, indexed uint64) (uint64, error) {
+	number := indexed - 1
+	last, err := undoOf(txn, index, number)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, drop(txn, index)
+	} else if err != nil {
+		return 0, err
+	}
+
+	for _, key := range last.Keys {
+		if err = txn.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	if err = txn.Delete(undoKey(index, number)); err != nil {
+		return 0, err
+	}
+	if number == 0 {
+		return 0, blockchain.SetIndexProgress(txn, index.Name(), 0, nil)
+	}
+
+	// the parent may have been reverted too, which the next step finds out from the hash it was indexed from
+	parent, err := undoOf(txn, index, number-1)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, drop(txn, index)
+	} else if err != nil {
+		return 0, err
+	}
+	return number, blockchain.SetIndexProgress(txn, index.Name(), number, parent.Hash)
+}
+
+func undoOf(txn db.Transaction, index blockchain.Index, number uint64) (*undo, error) {
+	u := new(undo)
+	return u, txn.Get(undoKey(index, number), func(val []byte) error {
+		return encoder.Unmarshal(val, u)
+	})
+}
+
+// drop deletes the entries, the undo keys and the progress of an index, so that it is built again from genesis
+func drop(txn db.Transaction, index blockchain.Index) error {
+	for _, prefix := range [][]byte{index.Bucket().Key(), undoPrefix(index)} {
+		if err := deletePrefix(txn, prefix); err != nil {
+			return err
+		}
+	}
+	return blockchain.SetIndexProgress(txn, index.Name(), 0, nil)
+}
+
+func deletePrefix(txn db.Transaction, prefix []byte) error {
+	it, err := txn.NewIterator()
+	if err != nil {
+		return err
+	}
+
+	var keys [][]byte
+	for it.Seek(prefix); it.Valid() && bytes.HasPrefix(it.Key(), prefix); it.Next() {
+		keys = append(keys, append([]byte(nil), it.Key()...))
+	}
+	if err = it.Close(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err = txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setUndo records the keys that index block, and forgets the keys of the block that is now too old to be
+// reverted
+func setUndo(txn db.Transaction, index blockchain.Index, block *core.Block, keys [][]byte) error {
+	undoBytes, err := encoder.Marshal(undo{Hash: block.Hash, Keys: keys})
+	if err != nil {
+		return err
+	}
+	if err = txn.Set(undoKey(index, block.Number), undoBytes); err != nil {
+		return err
+	}
+	if block.Number >= undoDepth {
+		return txn.Delete(undoKey(index, block.Number-undoDepth))
+	}
+	return nil
+}
+
+// undoPrefix is the prefix of the undo keys of an index, the name is prefixed by its length so that the
+// prefix of an index does not match the names of other indexes
+func undoPrefix(index blockchain.Index) []byte {
+	name := index.Name()
+	return db.IndexUndo.Key([]byte{byte(len(name))}, []byte(name))
+}
+
+func undoKey(index blockchain.Index, number uint64) []byte {
+	return binary.BigEndian.AppendUint64(undoPrefix(index), number)
+}
@@ -0,0 +1,152 @@
+package indexer_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/indexer"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	const blocks = 6
+	stored := make([]*core.Block, 0, blocks)
+	stateUpdates := make([]*core.StateUpdate, 0, blocks)
+	for i := uint64(0); i < blocks; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+		stored = append(stored, block)
+		stateUpdates = append(stateUpdates, stateUpdate)
+	}
+
+	// emitters are the contracts that emitted events in each block
+	emitters := make([]map[felt.Felt]struct{}, blocks)
+	var address *felt.Felt
+	for i, block := range stored {
+		emitters[i] = make(map[felt.Felt]struct{})
+		for _, receipt := range block.Receipts {
+			for _, event := range receipt.Events {
+				emitters[i][*event.From] = struct{}{}
+				address = event.From
+			}
+		}
+	}
+	require.NotNil(t, address, "the blocks have no events")
+
+	// filter returns the events of address, event filters use the index over the blocks it covers
+	filter := func() []*blockchain.FilteredEvent {
+		eventFilter, err := chain.EventFilter(address, nil)
+		require.NoError(t, err)
+		events, _, err := eventFilter.Events(nil, 1000)
+		require.NoError(t, err)
+		require.NoError(t, eventFilter.Close())
+		return events
+	}
+	unindexedEvents := filter()
+	require.NotEmpty(t, unindexedEvents)
+
+	// build steps the scheduler until the index covers the head
+	build := func() {
+		head, err := chain.HeadsHeader()
+		require.NoError(t, err)
+
+		scheduler := indexer.New(testDB, []blockchain.Index{blockchain.EventsIndex{}}, utils.NewNopZapLogger())
+		for more := true; more; {
+			more, err = scheduler.Step(context.Background())
+			require.NoError(t, err)
+		}
+		var (
+			indexed uint64
+			hash    *felt.Felt
+		)
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			indexed, hash, err = blockchain.IndexProgress(txn, blockchain.EventsIndexName)
+			return err
+		}))
+		assert.Equal(t, head.Number+1, indexed)
+		assert.Equal(t, head.Hash, hash)
+	}
+	indexedBlock := func(address felt.Felt, number uint64) bool {
+		var found bool
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			err := txn.Get(db.EventBlocksByAddress.Key(address.Marshal(), binary.BigEndian.AppendUint64(nil, number)),
+				func([]byte) error {
+					found = true
+					return nil
+				})
+			if err == db.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}))
+		return found
+	}
+
+	t.Run("backfill", func(t *testing.T) {
+		build()
+		for i := range stored {
+			for emitter := range emitters[i] {
+				assert.True(t, indexedBlock(emitter, uint64(i)))
+			}
+		}
+		assert.Equal(t, unindexedEvents, filter())
+	})
+
+	last := stored[blocks-1]
+	t.Run("reverted block", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		build()
+		for emitter := range emitters[blocks-1] {
+			assert.False(t, indexedBlock(emitter, last.Number))
+		}
+	})
+
+	t.Run("replaced block", func(t *testing.T) {
+		// the replacement emits no events
+		replacement := *last
+		replacement.Header = new(core.Header)
+		*replacement.Header = *last.Header
+		replacement.Hash = new(felt.Felt).SetUint64(1)
+		replacement.Receipts = make([]*core.TransactionReceipt, len(last.Receipts))
+		for i, receipt := range last.Receipts {
+			replacementReceipt := *receipt
+			replacementReceipt.Events = nil
+			replacement.Receipts[i] = &replacementReceipt
+		}
+
+		require.NoError(t, chain.Store(last, &core.BlockCommitments{}, stateUpdates[blocks-1], nil))
+		build()
+		require.NoError(t, chain.RevertHead())
+		require.NoError(t, chain.Store(&replacement, &core.BlockCommitments{}, stateUpdates[blocks-1], nil))
+		build()
+
+		for emitter := range emitters[blocks-1] {
+			assert.False(t, indexedBlock(emitter, last.Number))
+		}
+		for i := 0; i < blocks-1; i++ {
+			for emitter := range emitters[i] {
+				assert.True(t, indexedBlock(emitter, uint64(i)))
+			}
+		}
+	})
+}
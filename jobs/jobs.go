@@ -0,0 +1,331 @@
+// Package jobs runs the background work of the node, such as the backfill of the optional indexes, the moves of
+// the tiered database and the re-execution audit, as the jobs of a Manager. The manager steps the ready jobs by
+// priority, with a limited number of steps at once so that the background work does not starve the sync of the
+// disk, and records the history of every job in the database so that it survives restarts.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ service.Service = (*Manager)(nil)
+
+// persistInterval is how frequently the histories of the jobs that stepped are written to the database
+const persistInterval = 10 * time.Second
+
+// Priority orders the jobs that are ready to step, the ones with a higher priority step first
+type Priority uint8
+
+const (
+	Low Priority = iota
+	Normal
+	High
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	default:
+		return fmt.Sprintf("priority %d", uint8(p))
+	}
+}
+
+// Job is background work that is done one step at a time
+type Job interface {
+	// Step does the next unit of work of the job, such as indexing a block, and returns whether the job has more
+	// work to do right away. A job that has no more work, or whose step fails, steps again after its interval.
+	Step(ctx context.Context) (bool, error)
+}
+
+// Options are how a job is scheduled
+type Options struct {
+	Priority Priority
+	// Interval is how long the job waits before it steps again once it has no more work or its step failed
+	Interval time.Duration
+	// StepInterval is the minimum time between the starts of two steps of the job, zero if its rate is not limited
+	StepInterval time.Duration
+}
+
+// History is what the steps of a job did over all the runs of the node
+type History struct {
+	Steps     uint64
+	Failures  uint64
+	LastStep  time.Time
+	LastError string
+}
+
+// State is the state of a job
+type State struct {
+	Name     string
+	Priority Priority
+	Running  bool
+	// NextStep is when the job is ready to step again, it is zero while the job is running
+	NextStep time.Time
+	History
+}
+
+type job struct {
+	name    string
+	job     Job
+	opts    Options
+	history History
+	running bool
+	next    time.Time
+	// dirty is whether history changed since it was persisted
+	dirty bool
+}
+
+// Manager runs jobs, at most concurrency steps at once
+type Manager struct {
+	database    db.DB
+	concurrency int
+	log         utils.SimpleLogger
+
+	mu   sync.Mutex
+	jobs []*job
+
+	// metrics
+	steps *prometheus.CounterVec
+}
+
+// New returns a Manager that runs at most concurrency steps at once, one if it is not positive, and records the
+// histories of its jobs in database, or only keeps them in memory if database is nil
+func New(database db.DB, concurrency int, log utils.SimpleLogger) *Manager {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	m := &Manager{
+		database:    database,
+		concurrency: concurrency,
+		log:         log,
+		steps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "jobs",
+			Name:      "steps",
+			Help:      "The number of steps of the background jobs, by job and result",
+		}, []string{"job", "result"}),
+	}
+	metrics.MustRegister(m.steps)
+	return m
+}
+
+// Add schedules job under name, it must be called before Run
+func (m *Manager) Add(name string, j Job, opts Options) *Manager {
+	m.jobs = append(m.jobs, &job{name: name, job: j, opts: opts})
+	return m
+}
+
+// Len returns the number of jobs of the manager
+func (m *Manager) Len() int {
+	return len(m.jobs)
+}
+
+// States returns the states of the jobs, ordered by priority and then by name
+func (m *Manager) States() []State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make([]State, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		state := State{Name: j.name, Priority: j.opts.Priority, Running: j.running, History: j.history}
+		if !j.running {
+			state.NextStep = j.next
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, k int) bool {
+		if states[i].Priority != states[k].Priority {
+			return states[i].Priority > states[k].Priority
+		}
+		return states[i].Name < states[k].Name
+	})
+	return states
+}
+
+// Run steps the jobs until ctx is cancelled, it waits for the steps in progress before it returns
+func (m *Manager) Run(ctx context.Context) error {
+	if err := m.load(); err != nil {
+		return fmt.Errorf("load the histories of the jobs: %w", err)
+	}
+
+	done := make(chan struct{})
+	running := 0
+	persistTicker := time.NewTicker(persistInterval)
+	defer persistTicker.Stop()
+	for {
+		for running < m.concurrency {
+			j := m.nextReady(time.Now())
+			if j == nil {
+				break
+			}
+			running++
+			go func() {
+				m.step(ctx, j)
+				done <- struct{}{}
+			}()
+		}
+
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if next, ok := m.earliest(); ok && running < m.concurrency {
+			timer = time.NewTimer(time.Until(next))
+			wait = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			for ; running > 0; running-- {
+				<-done
+			}
+			return m.persist()
+		case <-done:
+			running--
+		case <-persistTicker.C:
+			if err := m.persist(); err != nil {
+				m.log.Warnw("Failed to persist the histories of the jobs", "err", err)
+			}
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// nextReady marks the ready job with the highest priority as running and returns it, or nil if no job is ready
+func (m *Manager) nextReady(now time.Time) *job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ready *job
+	for _, j := range m.jobs {
+		if j.running || j.next.After(now) {
+			continue
+		}
+		if ready == nil || j.opts.Priority > ready.opts.Priority ||
+			(j.opts.Priority == ready.opts.Priority && j.next.Before(ready.next)) {
+			ready = j
+		}
+	}
+	if ready != nil {
+		ready.running = true
+	}
+	return ready
+}
+
+// earliest returns when the next job that is not running is ready to step
+func (m *Manager) earliest() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var next time.Time
+	found := false
+	for _, j := range m.jobs {
+		if !j.running && (!found || j.next.Before(next)) {
+			next, found = j.next, true
+		}
+	}
+	return next, found
+}
+
+func (m *Manager) step(ctx context.Context, j *job) {
+	start := time.Now()
+	more, err := j.job.Step(ctx)
+	// a step that is interrupted by ctx is not a failure
+	failed := err != nil && ctx.Err() == nil
+	if failed {
+		m.log.Warnw("Background job failed", "job", j.name, "err", err)
+		m.steps.WithLabelValues(j.name, "failed").Inc()
+	} else {
+		m.steps.WithLabelValues(j.name, "ok").Inc()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j.running = false
+	j.dirty = true
+	j.history.Steps++
+	j.history.LastStep = start
+	if failed {
+		j.history.Failures++
+		j.history.LastError = err.Error()
+	}
+	if more && !failed {
+		j.next = start.Add(j.opts.StepInterval)
+	} else {
+		j.next = time.Now().Add(j.opts.Interval)
+	}
+}
+
+func (m *Manager) load() error {
+	if m.database == nil {
+		return nil
+	}
+	return m.database.View(func(txn db.Transaction) error {
+		for _, j := range m.jobs {
+			err := txn.Get(db.Jobs.Key([]byte(j.name)), func(val []byte) error {
+				return encoder.Unmarshal(val, &j.history)
+			})
+			if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+				return fmt.Errorf("job %s: %w", j.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// persist writes the histories of the jobs that stepped since they were last persisted
+func (m *Manager) persist() error {
+	if m.database == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histories := make(map[string][]byte)
+	for _, j := range m.jobs {
+		if !j.dirty {
+			continue
+		}
+		historyBytes, err := encoder.Marshal(j.history)
+		if err != nil {
+			return err
+		}
+		histories[j.name] = historyBytes
+	}
+	if len(histories) == 0 {
+		return nil
+	}
+	err := m.database.Update(func(txn db.Transaction) error {
+		for name, historyBytes := range histories {
+			if err := txn.Set(db.Jobs.Key([]byte(name)), historyBytes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, j := range m.jobs {
+		if _, ok := histories[j.name]; ok {
+			j.dirty = false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countdown is a job that has work for a number of steps
+type countdown struct {
+	name  string
+	steps atomic.Int64
+	err   error
+	// step is called with the name of the job at every step
+	step func(name string)
+}
+
+func (c *countdown) Step(context.Context) (bool, error) {
+	if c.step != nil {
+		c.step(c.name)
+	}
+	return c.steps.Add(-1) > 0, c.err
+}
+
+func newCountdown(name string, steps int64, step func(string)) *countdown {
+	c := &countdown{name: name, step: step}
+	c.steps.Store(steps)
+	return c
+}
+
+// run runs manager until done returns true
+func run(t *testing.T, manager *jobs.Manager, done func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		errs <- manager.Run(ctx)
+	}()
+	assert.Eventually(t, done, 5*time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-errs)
+}
+
+func TestManager(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		var mu sync.Mutex
+		var order []string
+		step := func(name string) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+		}
+		low := newCountdown("low", 2, step)
+		high := newCountdown("high", 3, step)
+		manager := jobs.New(testDB, 1, utils.NewNopZapLogger()).
+			Add("low", low, jobs.Options{Priority: jobs.Low, Interval: time.Hour}).
+			Add("high", high, jobs.Options{Priority: jobs.High, Interval: time.Hour})
+		run(t, manager, func() bool {
+			return low.steps.Load() <= 0 && high.steps.Load() <= 0
+		})
+
+		assert.Equal(t, []string{"high", "high", "high", "low", "low"}, order)
+		states := manager.States()
+		require.Len(t, states, 2)
+		assert.Equal(t, "high", states[0].Name)
+		assert.Equal(t, uint64(3), states[0].Steps)
+		assert.Equal(t, "low", states[1].Name)
+		assert.Equal(t, uint64(2), states[1].Steps)
+		assert.True(t, states[1].NextStep.After(time.Now()))
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		var running, maxRunning atomic.Int64
+		step := func(string) {
+			current := running.Add(1)
+			for {
+				seen := maxRunning.Load()
+				if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}
+		manager := jobs.New(testDB, 2, utils.NewNopZapLogger())
+		countdowns := make([]*countdown, 0, 4)
+		for _, name := range []string{"a", "b", "c", "d"} {
+			c := newCountdown(name, 3, step)
+			countdowns = append(countdowns, c)
+			manager.Add(name, c, jobs.Options{Priority: jobs.Normal, Interval: time.Hour})
+		}
+		run(t, manager, func() bool {
+			for _, c := range countdowns {
+				if c.steps.Load() > 0 {
+					return false
+				}
+			}
+			return true
+		})
+		assert.Equal(t, int64(2), maxRunning.Load())
+	})
+
+	t.Run("history survives restarts", func(t *testing.T) {
+		for i := uint64(1); i <= 2; i++ {
+			failing := newCountdown("failing", 1, nil)
+			failing.err = errors.New("disk full")
+			manager := jobs.New(testDB, 1, utils.NewNopZapLogger()).
+				Add("failing", failing, jobs.Options{Interval: time.Hour})
+			run(t, manager, func() bool {
+				return failing.steps.Load() <= 0
+			})
+
+			states := manager.States()
+			require.Len(t, states, 1)
+			assert.Equal(t, i, states[0].Steps)
+			assert.Equal(t, i, states[0].Failures)
+			assert.Equal(t, "disk full", states[0].LastError)
+			assert.False(t, states[0].Running)
+		}
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/NethermindEth/juno/service"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 const MaxRequestBodySize = 10 * 1024 * 1024 // 10MB
@@ -22,6 +24,7 @@ type HTTP struct {
 	log       utils.SimpleLogger
 	listener  net.Listener
 	urlPrefix string
+	paths     map[string]*Server
 
 	// metrics
 	requests prometheus.Counter
@@ -33,6 +36,7 @@ func NewHTTP(urlPrefix string, listener net.Listener, rpc *Server, log utils.Sim
 		rpc:       rpc,
 		log:       log,
 		listener:  listener,
+		paths:     make(map[string]*Server),
 
 		requests: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: "rpc",
@@ -44,6 +48,12 @@ func NewHTTP(urlPrefix string, listener net.Listener, rpc *Server, log utils.Sim
 	return h
 }
 
+// WithPath serves the requests sent to path with rpc instead of the default server
+func (h *HTTP) WithPath(path string, rpc *Server) *HTTP {
+	h.paths[path] = rpc
+	return h
+}
+
 // Run starts to listen for HTTP requests
 func (h *HTTP) Run(ctx context.Context) error {
 	errCh := make(chan error)
@@ -51,6 +61,12 @@ func (h *HTTP) Run(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.Handle("/", h)
 	mux.Handle(h.urlPrefix, h)
+	for path, rpc := range h.paths {
+		rpc := rpc
+		mux.HandleFunc(path, func(writer http.ResponseWriter, req *http.Request) {
+			h.serve(rpc, writer, req)
+		})
+	}
 	srv := &http.Server{
 		Addr:    h.listener.Addr().String(),
 		Handler: mux,
@@ -73,6 +89,10 @@ func (h *HTTP) Run(ctx context.Context) error {
 
 // ServeHTTP processes an incoming HTTP request
 func (h *HTTP) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	h.serve(h.rpc, writer, req)
+}
+
+func (h *HTTP) serve(rpc *Server, writer http.ResponseWriter, req *http.Request) {
 	if req.Method == "GET" {
 		status := http.StatusNotFound
 		if req.URL.Path == "/" {
@@ -87,7 +107,9 @@ func (h *HTTP) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
 
 	req.Body = http.MaxBytesReader(writer, req.Body, MaxRequestBodySize)
 	h.requests.Inc()
-	resp, err := h.rpc.HandleReader(req.Body)
+	// continue the trace of the caller, if it sent one
+	ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+	resp, err := rpc.HandleReaderWithContext(ctx, req.Body)
 	writer.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		writer.WriteHeader(http.StatusInternalServerError)
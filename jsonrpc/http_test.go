@@ -28,7 +28,15 @@ func TestHTTP(t *testing.T) {
 	log := utils.NewNopZapLogger()
 	rpc := jsonrpc.NewServer(log)
 	require.NoError(t, rpc.RegisterMethod(method))
-	server := jsonrpc.NewHTTP("/vX.Y.Z", listener, rpc, log)
+	versionedRPC := jsonrpc.NewServer(log)
+	require.NoError(t, versionedRPC.RegisterMethod(jsonrpc.Method{
+		Name: "echo",
+		Handler: func(msg string) (string, *jsonrpc.Error) {
+			return "versioned " + msg, nil
+		},
+		Params: []jsonrpc.Parameter{{Name: "msg"}},
+	}))
+	server := jsonrpc.NewHTTP("/vX.Y.Z", listener, rpc, log).WithPath("/rpc/vX", versionedRPC)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	t.Cleanup(func() {
@@ -56,6 +64,19 @@ func TestHTTP(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, want, string(got))
 
+	t.Run("versioned path", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url+"/rpc/vX", bytes.NewReader([]byte(msg)))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"jsonrpc":"2.0","result":"versioned abc123","id":1}`, string(got))
+	})
+
 	t.Run("GET", func(t *testing.T) {
 		t.Run("root path", func(t *testing.T) {
 			req, err := http.NewRequestWithContext(ctx, "GET", url, http.NoBody)
@@ -4,6 +4,7 @@ package jsonrpc
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -12,8 +13,11 @@ import (
 	"time"
 
 	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/tracing"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 const (
@@ -98,10 +102,25 @@ type Method struct {
 	Handler any
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// needsContext reports whether handlerT takes a context.Context as its first argument
+func needsContext(handlerT reflect.Type) bool {
+	return handlerT.NumIn() > 0 && handlerT.In(0) == contextType
+}
+
+// Handler serves a call of method with params, the parameters of the request as they were decoded
+type Handler func(ctx context.Context, method string, params any) (any, *Error)
+
+// Middleware wraps the Handler that calls the methods of a Server, to observe or change the calls and
+// their results. Requests with invalid parameters are rejected before they reach the middlewares.
+type Middleware func(next Handler) Handler
+
 type Server struct {
-	methods   map[string]Method
-	validator Validator
-	log       utils.SimpleLogger
+	methods     map[string]Method
+	validator   Validator
+	middlewares []Middleware
+	log         utils.SimpleLogger
 
 	// metrics
 	requests *prometheus.CounterVec
@@ -133,11 +152,18 @@ func (s *Server) WithValidator(validator Validator) *Server {
 	return s
 }
 
+// WithMiddleware wraps the calls of methods in middlewares, the first one is the outermost
+func (s *Server) WithMiddleware(middlewares ...Middleware) *Server {
+	s.middlewares = append(s.middlewares, middlewares...)
+	return s
+}
+
 // RegisterMethod verifies and creates an endpoint that the server recognises.
 //
 // - name is the method name
 // - handler is the function to be called when a request is received for the
-// associated method. It should have (any, *jsonrpc.Error) as its return type
+// associated method. It should have (any, *jsonrpc.Error) as its return type.
+// If its first argument is a context.Context, it receives the context of the request
 // - paramNames are the names of parameters in the order that they are expected
 // by the handler
 func (s *Server) RegisterMethod(method Method) error {
@@ -145,7 +171,11 @@ func (s *Server) RegisterMethod(method Method) error {
 	if handlerT.Kind() != reflect.Func {
 		return errors.New("handler must be a function")
 	}
-	if handlerT.NumIn() != len(method.Params) {
+	numArgs := handlerT.NumIn()
+	if needsContext(handlerT) {
+		numArgs--
+	}
+	if numArgs != len(method.Params) {
 		return errors.New("number of function params and param names must match")
 	}
 	if handlerT.NumOut() != 2 {
@@ -164,13 +194,23 @@ func (s *Server) RegisterMethod(method Method) error {
 // It returns the response in a byte array, only returns an
 // error if it can not create the response byte array
 func (s *Server) Handle(data []byte) ([]byte, error) {
-	return s.HandleReader(bytes.NewReader(data))
+	return s.HandleWithContext(context.Background(), data)
+}
+
+// HandleWithContext is Handle with ctx passed down to the handlers that accept a context
+func (s *Server) HandleWithContext(ctx context.Context, data []byte) ([]byte, error) {
+	return s.HandleReaderWithContext(ctx, bytes.NewReader(data))
 }
 
 // HandleReader processes a request to the server
 // It returns the response in a byte array, only returns an
 // error if it can not create the response byte array
 func (s *Server) HandleReader(reader io.Reader) ([]byte, error) {
+	return s.HandleReaderWithContext(context.Background(), reader)
+}
+
+// HandleReaderWithContext is HandleReader with ctx passed down to the handlers that accept a context
+func (s *Server) HandleReaderWithContext(ctx context.Context, reader io.Reader) ([]byte, error) {
 	bufferedReader := bufio.NewReader(reader)
 	requestIsBatch := isBatch(bufferedReader)
 	res := &response{
@@ -184,7 +224,7 @@ func (s *Server) HandleReader(reader io.Reader) ([]byte, error) {
 		req := new(request)
 		if jsonErr := dec.Decode(req); jsonErr != nil {
 			res.Error = Err(InvalidJSON, jsonErr.Error())
-		} else if resObject, handleErr := s.handleRequest(req); handleErr != nil {
+		} else if resObject, handleErr := s.handleRequest(ctx, req); handleErr != nil {
 			if !errors.Is(handleErr, ErrInvalidID) {
 				res.ID = req.ID
 			}
@@ -215,7 +255,7 @@ func (s *Server) HandleReader(reader io.Reader) ([]byte, error) {
 					}
 				} else {
 					var handleErr error
-					resObject, handleErr = s.handleRequest(req)
+					resObject, handleErr = s.handleRequest(ctx, req)
 					if handleErr != nil {
 						resObject = &response{
 							Version: "2.0",
@@ -270,7 +310,7 @@ func isNil(i any) bool {
 	return i == nil || reflect.ValueOf(i).IsNil()
 }
 
-func (s *Server) handleRequest(req *request) (*response, error) {
+func (s *Server) handleRequest(ctx context.Context, req *request) (*response, error) {
 	start := time.Now()
 	reqJSON, err := json.Marshal(req)
 	if err == nil {
@@ -295,28 +335,66 @@ func (s *Server) handleRequest(req *request) (*response, error) {
 		return res, nil
 	}
 
-	args, err := s.buildArguments(req.Params, calledMethod.Handler, calledMethod.Params)
+	ctx, span := tracing.StartSpan(ctx, req.Method,
+		semconv.RPCSystemKey.String("jsonrpc"),
+		semconv.RPCMethodKey.String(req.Method),
+	)
+	defer span.End()
+
+	args, err := s.buildArguments(req.Params, calledMethod)
 	if err != nil {
 		res.Error = Err(InvalidParams, err.Error())
+		span.SetAttributes(semconv.RPCJsonrpcErrorCodeKey.Int(InvalidParams))
+		span.SetStatus(codes.Error, res.Error.Message)
 		return res, nil
 	}
 
 	s.requests.WithLabelValues(req.Method).Inc()
-	tuple := reflect.ValueOf(calledMethod.Handler).Call(args)
+	handler := callHandler(calledMethod, args)
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	result, rpcErr := handler(ctx, req.Method, req.Params)
+
+	if rpcErr != nil {
+		res.Error = rpcErr
+		span.SetAttributes(
+			semconv.RPCJsonrpcErrorCodeKey.Int(res.Error.Code),
+			semconv.RPCJsonrpcErrorMessageKey.String(res.Error.Message),
+		)
+		span.SetStatus(codes.Error, res.Error.Message)
+	}
+
 	if res.ID == nil { // notification
 		return nil, nil
 	}
 
-	if errAny := tuple[1].Interface(); !isNil(errAny) {
-		res.Error = errAny.(*Error)
+	if res.Error != nil {
 		return res, nil
 	}
 
-	res.Result = tuple[0].Interface()
+	res.Result = result
 	return res, nil
 }
 
-func (s *Server) buildArguments(params, handler any, configuredParams []Parameter) ([]reflect.Value, error) {
+// callHandler returns a Handler that calls the handler of method with args, and the context it is given
+// if the handler takes one
+func callHandler(method Method, args []reflect.Value) Handler {
+	return func(ctx context.Context, _ string, _ any) (any, *Error) {
+		callArgs := args
+		if needsContext(reflect.TypeOf(method.Handler)) {
+			callArgs = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+		}
+		tuple := reflect.ValueOf(method.Handler).Call(callArgs)
+		if errAny := tuple[1].Interface(); !isNil(errAny) {
+			return nil, errAny.(*Error)
+		}
+		return tuple[0].Interface(), nil
+	}
+}
+
+func (s *Server) buildArguments(params any, method Method) ([]reflect.Value, error) {
+	configuredParams := method.Params
 	args := make([]reflect.Value, 0, len(configuredParams))
 	if isNil(params) {
 		if len(configuredParams) > 0 {
@@ -326,18 +404,23 @@ func (s *Server) buildArguments(params, handler any, configuredParams []Paramete
 		return args, nil
 	}
 
-	handlerType := reflect.TypeOf(handler)
+	handlerType := reflect.TypeOf(method.Handler)
+	// handler arguments that are filled from the request params start after the context, if any
+	argOffset := 0
+	if needsContext(handlerType) {
+		argOffset = 1
+	}
 
 	switch reflect.TypeOf(params).Kind() {
 	case reflect.Slice:
 		paramsList := params.([]any)
 
-		if len(paramsList) != handlerType.NumIn() {
+		if len(paramsList) != handlerType.NumIn()-argOffset {
 			return nil, errors.New("missing/unexpected params in list")
 		}
 
 		for i, param := range paramsList {
-			v, err := s.parseParam(param, handlerType.In(i))
+			v, err := s.parseParam(param, handlerType.In(i+argOffset))
 			if err != nil {
 				return nil, err
 			}
@@ -350,13 +433,13 @@ func (s *Server) buildArguments(params, handler any, configuredParams []Paramete
 			var v reflect.Value
 			if param, found := paramsMap[configuredParam.Name]; found {
 				var err error
-				v, err = s.parseParam(param, handlerType.In(i))
+				v, err = s.parseParam(param, handlerType.In(i+argOffset))
 				if err != nil {
 					return nil, err
 				}
 			} else if configuredParam.Optional {
 				// optional parameter
-				v = reflect.New(handlerType.In(i)).Elem()
+				v = reflect.New(handlerType.In(i + argOffset)).Elem()
 			} else {
 				return nil, errors.New("missing non-optional param")
 			}
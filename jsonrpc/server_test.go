@@ -1,6 +1,8 @@
 package jsonrpc_test
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/NethermindEth/juno/jsonrpc"
@@ -55,6 +57,15 @@ func TestServer_RegisterMethod(t *testing.T) {
 		})
 	}
 
+	t.Run("context param is not counted as a param name", func(t *testing.T) {
+		err := server.RegisterMethod(jsonrpc.Method{
+			"method",
+			[]jsonrpc.Parameter{{Name: "param1"}},
+			func(ctx context.Context, param1 int) (int, *jsonrpc.Error) { return 0, nil },
+		})
+		assert.NoError(t, err)
+	})
+
 	t.Run("should not fail", func(t *testing.T) {
 		err := server.RegisterMethod(jsonrpc.Method{
 			"method",
@@ -408,3 +419,110 @@ func TestHandle(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleWithContext(t *testing.T) {
+	type ctxKey struct{}
+
+	server := jsonrpc.NewServer(utils.NewNopZapLogger())
+	require.NoError(t, server.RegisterMethod(jsonrpc.Method{
+		"contextValue",
+		[]jsonrpc.Parameter{{Name: "suffix", Optional: true}},
+		func(ctx context.Context, suffix string) (string, *jsonrpc.Error) {
+			value, ok := ctx.Value(ctxKey{}).(string)
+			if !ok {
+				return "", &jsonrpc.Error{Code: 44, Message: "missing context value"}
+			}
+			return value + suffix, nil
+		},
+	}))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	tests := map[string]struct {
+		req string
+		res string
+	}{
+		"no params": {
+			req: `{"jsonrpc": "2.0", "method": "contextValue", "id": 1}`,
+			res: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid Params","data":"missing non-optional param field"},"id":1}`,
+		},
+		"list params": {
+			req: `{"jsonrpc": "2.0", "method": "contextValue", "params": ["-list"], "id": 1}`,
+			res: `{"jsonrpc":"2.0","result":"value-list","id":1}`,
+		},
+		"too many list params": {
+			req: `{"jsonrpc": "2.0", "method": "contextValue", "params": ["-list", 2], "id": 1}`,
+			res: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid Params","data":"missing/unexpected params in list"},"id":1}`,
+		},
+		"named params": {
+			req: `{"jsonrpc": "2.0", "method": "contextValue", "params": {"suffix": "-named"}, "id": 1}`,
+			res: `{"jsonrpc":"2.0","result":"value-named","id":1}`,
+		},
+		"optional param": {
+			req: `{"jsonrpc": "2.0", "method": "contextValue", "params": {}, "id": 1}`,
+			res: `{"jsonrpc":"2.0","result":"value","id":1}`,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			res, err := server.HandleWithContext(ctx, []byte(test.req))
+			require.NoError(t, err)
+			assert.Equal(t, test.res, string(res))
+		})
+	}
+
+	t.Run("background context is used without one", func(t *testing.T) {
+		res, err := server.Handle([]byte(`{"jsonrpc": "2.0", "method": "contextValue", "params": {}, "id": 1}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":44,"message":"missing context value"},"id":1}`, string(res))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	type ctxKey struct{}
+
+	var calls []string
+	record := func(name string) jsonrpc.Middleware {
+		return func(next jsonrpc.Handler) jsonrpc.Handler {
+			return func(ctx context.Context, method string, params any) (any, *jsonrpc.Error) {
+				calls = append(calls, name+" "+method)
+				return next(context.WithValue(ctx, ctxKey{}, name), method, params)
+			}
+		}
+	}
+	deny := func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx context.Context, method string, params any) (any, *jsonrpc.Error) {
+			if method == "denied" {
+				return nil, &jsonrpc.Error{Code: 1, Message: "denied"}
+			}
+			return next(ctx, method, params)
+		}
+	}
+
+	server := jsonrpc.NewServer(utils.NewNopZapLogger()).WithMiddleware(record("outer"), record("inner"), deny)
+	for _, name := range []string{"allowed", "denied"} {
+		require.NoError(t, server.RegisterMethod(jsonrpc.Method{
+			Name:   name,
+			Params: []jsonrpc.Parameter{{Name: "n"}},
+			Handler: func(ctx context.Context, n int) (string, *jsonrpc.Error) {
+				return fmt.Sprintf("%v %d", ctx.Value(ctxKey{}), n), nil
+			},
+		}))
+	}
+
+	res, err := server.Handle([]byte(`{"jsonrpc": "2.0", "method": "allowed", "params": [1], "id": 1}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"inner 1","id":1}`, string(res))
+
+	res, err = server.Handle([]byte(`{"jsonrpc": "2.0", "method": "denied", "params": [1], "id": 2}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":1,"message":"denied"},"id":2}`, string(res))
+
+	t.Run("invalid params do not reach the middlewares", func(t *testing.T) {
+		res, err = server.Handle([]byte(`{"jsonrpc": "2.0", "method": "allowed", "id": 3}`))
+		require.NoError(t, err)
+		assert.Contains(t, string(res), `"code":-32602`)
+	})
+
+	assert.Equal(t, []string{"outer allowed", "inner allowed", "outer denied", "inner denied"}, calls)
+}
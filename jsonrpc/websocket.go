@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -22,6 +23,8 @@ type Websocket struct {
 	connParams *WebsocketConnParams
 	listener   net.Listener
 	urlPrefix  string
+	paths      map[string]*Server
+	streams    map[string]Stream
 
 	// metrics
 	requests prometheus.Counter
@@ -34,6 +37,8 @@ func NewWebsocket(urlPrefix string, listener net.Listener, rpc *Server, log util
 		connParams: DefaultWebsocketConnParams(),
 		listener:   listener,
 		urlPrefix:  urlPrefix,
+		paths:      make(map[string]*Server),
+		streams:    make(map[string]Stream),
 
 		requests: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: "rpc",
@@ -52,9 +57,30 @@ func (ws *Websocket) WithConnParams(p *WebsocketConnParams) *Websocket {
 	return ws
 }
 
+// WithPath serves the connections opened on path with rpc instead of the default server
+func (ws *Websocket) WithPath(path string, rpc *Server) *Websocket {
+	ws.paths[path] = rpc
+	return ws
+}
+
+// Stream sends messages to the client of a connection until ctx is cancelled or it fails. query is the query of
+// the URL the connection was opened with.
+type Stream func(ctx context.Context, query url.Values, send func(msg []byte) error) error
+
+// WithStream serves the connections opened on path with stream instead of an rpc server. The messages of the
+// clients are discarded and the connections are closed when stream returns.
+func (ws *Websocket) WithStream(path string, stream Stream) *Websocket {
+	ws.streams[path] = stream
+	return ws
+}
+
 // Handler processes an HTTP request and upgrades it to a websocket connection.
 // The connection's entire "lifetime" is spent in this function.
 func (ws *Websocket) Handler(ctx context.Context) http.Handler {
+	return ws.handler(ctx, ws.rpc)
+}
+
+func (ws *Websocket) handler(ctx context.Context, rpc *Server) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		conn, err := websocket.Accept(w, r, nil /* TODO: options */)
 		if err != nil {
@@ -64,7 +90,7 @@ func (ws *Websocket) Handler(ctx context.Context) http.Handler {
 
 		// TODO include connection information, such as the remote address, in the logs.
 
-		wsc := newWebsocketConn(conn, ws.rpc, ws.connParams, ws.requests)
+		wsc := newWebsocketConn(conn, rpc, ws.connParams, ws.requests)
 
 		err = wsc.ReadWriteLoop(ctx)
 
@@ -75,21 +101,54 @@ func (ws *Websocket) Handler(ctx context.Context) http.Handler {
 		}
 
 		ws.log.Warnw("Closing websocket connection due to internal error", "err", err)
-		errString := err.Error()
-		if len(errString) > closeReasonMaxBytes {
-			errString = errString[:closeReasonMaxBytes]
+		ws.closeWithError(conn, err)
+	})
+}
+
+func (ws *Websocket) streamHandler(ctx context.Context, stream Stream) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			ws.log.Errorw("Failed to upgrade connection", "err", err)
+			return
 		}
-		if err = wsc.conn.Close(websocket.StatusInternalError, errString); err != nil {
-			// Don't log an error if the connection is already closed, which can happen
-			// in benign scenarios like timeouts. Unfortunately the error is not exported
-			// from the websocket package so we match the string instead.
-			if !strings.Contains(err.Error(), "already wrote close") {
-				ws.log.Errorw("Failed to close websocket connection", "err", err)
+
+		// the context is cancelled when the client closes the connection
+		streamCtx := conn.CloseRead(ctx)
+		err = stream(streamCtx, r.URL.Query(), func(msg []byte) error {
+			writeCtx, writeCancel := context.WithTimeout(streamCtx, ws.connParams.WriteDuration)
+			defer writeCancel()
+			return conn.Write(writeCtx, websocket.MessageText, msg)
+		})
+		switch {
+		case streamCtx.Err() != nil && ctx.Err() == nil:
+			ws.log.Infow("Client closed websocket stream", "path", r.URL.Path)
+		case err != nil:
+			ws.log.Warnw("Closing websocket stream due to error", "path", r.URL.Path, "err", err)
+			ws.closeWithError(conn, err)
+		default:
+			if err = conn.Close(websocket.StatusNormalClosure, ""); err != nil {
+				ws.log.Debugw("Failed to close websocket stream", "err", err)
 			}
 		}
 	})
 }
 
+func (ws *Websocket) closeWithError(conn *websocket.Conn, err error) {
+	errString := err.Error()
+	if len(errString) > closeReasonMaxBytes {
+		errString = errString[:closeReasonMaxBytes]
+	}
+	if err = conn.Close(websocket.StatusInternalError, errString); err != nil {
+		// Don't log an error if the connection is already closed, which can happen
+		// in benign scenarios like timeouts. Unfortunately the error is not exported
+		// from the websocket package so we match the string instead.
+		if !strings.Contains(err.Error(), "already wrote close") {
+			ws.log.Errorw("Failed to close websocket connection", "err", err)
+		}
+	}
+}
+
 func (ws *Websocket) Run(ctx context.Context) error {
 	errCh := make(chan error)
 
@@ -97,6 +156,12 @@ func (ws *Websocket) Run(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.Handle("/", handler)
 	mux.Handle(ws.urlPrefix, handler)
+	for path, rpc := range ws.paths {
+		mux.Handle(path, ws.handler(ctx, rpc))
+	}
+	for path, stream := range ws.streams {
+		mux.Handle(path, ws.streamHandler(ctx, stream))
+	}
 	srv := &http.Server{
 		Handler:           mux,
 		ReadHeaderTimeout: 1 * time.Second,
@@ -159,7 +224,7 @@ func (wsc *websocketConn) ReadWriteLoop(ctx context.Context) error {
 
 		wsc.requests.Inc()
 		// Decode the message, call the handler, encode the response.
-		resp, err := wsc.rpc.Handle(r)
+		resp, err := wsc.rpc.HandleWithContext(ctx, r)
 		if err != nil {
 			// RPC handling issues should not affect the connection.
 			// Ignore the request and let the client close the connection.
@@ -2,6 +2,7 @@ package jsonrpc_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -60,3 +61,57 @@ func TestHandler(t *testing.T) {
 
 	require.NoError(t, conn.Close(websocket.StatusNormalClosure, ""))
 }
+
+func TestStream(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	ws := jsonrpc.NewWebsocket("/vX.Y.Z", l, jsonrpc.NewServer(utils.NewNopZapLogger()), utils.NewNopZapLogger()).
+		WithStream("/count", func(ctx context.Context, query url.Values, send func([]byte) error) error {
+			if query.Get("to") == "" {
+				return errors.New("missing to")
+			}
+			for i := 0; i < 3; i++ {
+				if err := send([]byte(fmt.Sprint(i))); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ws.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	dial := func(rawQuery string) *websocket.Conn {
+		remote := url.URL{
+			Scheme:   "ws",
+			Host:     fmt.Sprintf("localhost:%d", l.Addr().(*net.TCPAddr).Port),
+			Path:     "/count",
+			RawQuery: rawQuery,
+		}
+		conn, _, err := websocket.Dial(context.Background(), remote.String(), nil) //nolint:bodyclose
+		require.NoError(t, err)
+		return conn
+	}
+
+	conn := dial("to=3")
+	for i := 0; i < 3; i++ {
+		_, msg, err := conn.Read(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprint(i), string(msg))
+	}
+	_, _, err = conn.Read(context.Background())
+	assert.Equal(t, websocket.StatusNormalClosure, websocket.CloseStatus(err))
+
+	conn = dial("")
+	_, _, err = conn.Read(context.Background())
+	var closeErr websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.StatusInternalError, closeErr.Code)
+	assert.Equal(t, "missing to", closeErr.Reason)
+}
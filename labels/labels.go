@@ -0,0 +1,126 @@
+// Package labels maps contract addresses and event selectors to human-readable labels set by the operator, such
+// as the names of the protocols they monitor, so that the APIs and logs of the node can show them.
+package labels
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/spf13/viper"
+)
+
+// File is a file of labels, in any format viper reads such as YAML or JSON. The selectors of the event names of
+// Events are computed, so that events can be labelled by their name without looking up their selector.
+type File struct {
+	Addresses map[string]string `mapstructure:"addresses"`
+	Selectors map[string]string `mapstructure:"selectors"`
+	Events    []string          `mapstructure:"events"`
+}
+
+// Registry holds the labels of addresses and selectors. A nil Registry has no labels.
+type Registry struct {
+	addresses map[felt.Felt]string
+	selectors map[felt.Felt]string
+}
+
+// New returns an empty Registry
+func New() *Registry {
+	return &Registry{
+		addresses: make(map[felt.Felt]string),
+		selectors: make(map[felt.Felt]string),
+	}
+}
+
+// Load returns a Registry of the labels of files, the labels of a file replace those of the files before it
+func Load(files ...string) (*Registry, error) {
+	r := New()
+	for _, path := range files {
+		v := viper.New()
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("read labels file %s: %w", path, err)
+		}
+		var file File
+		if err := v.Unmarshal(&file); err != nil {
+			return nil, fmt.Errorf("decode labels file %s: %w", path, err)
+		}
+		if err := r.Add(&file); err != nil {
+			return nil, fmt.Errorf("labels file %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Add adds the labels of file to r, replacing the labels r had for the same addresses and selectors
+func (r *Registry) Add(file *File) error {
+	for address, label := range file.Addresses {
+		addressFelt, err := new(felt.Felt).SetString(address)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		r.addresses[*addressFelt] = label
+	}
+	for selector, label := range file.Selectors {
+		selectorFelt, err := new(felt.Felt).SetString(selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		r.selectors[*selectorFelt] = label
+	}
+	for _, name := range file.Events {
+		selector, err := crypto.StarknetKeccak([]byte(name))
+		if err != nil {
+			return err
+		}
+		r.selectors[*selector] = name
+	}
+	return nil
+}
+
+// Address returns the label of address, if it has one
+func (r *Registry) Address(address *felt.Felt) (string, bool) {
+	if r == nil || address == nil {
+		return "", false
+	}
+	label, ok := r.addresses[*address]
+	return label, ok
+}
+
+// Selector returns the label of an event or entry point selector, if it has one
+func (r *Registry) Selector(selector *felt.Felt) (string, bool) {
+	if r == nil || selector == nil {
+		return "", false
+	}
+	label, ok := r.selectors[*selector]
+	return label, ok
+}
+
+// Describe returns address followed by its label, if it has one, to be logged
+func (r *Registry) Describe(address *felt.Felt) string {
+	if label, ok := r.Address(address); ok {
+		return fmt.Sprintf("%s (%s)", address, label)
+	}
+	return address.String()
+}
+
+// Addresses returns the labels of the addresses
+func (r *Registry) Addresses() map[felt.Felt]string {
+	return r.copyOf(func(r *Registry) map[felt.Felt]string { return r.addresses })
+}
+
+// Selectors returns the labels of the selectors
+func (r *Registry) Selectors() map[felt.Felt]string {
+	return r.copyOf(func(r *Registry) map[felt.Felt]string { return r.selectors })
+}
+
+func (r *Registry) copyOf(labels func(r *Registry) map[felt.Felt]string) map[felt.Felt]string {
+	copied := make(map[felt.Felt]string)
+	if r == nil {
+		return copied
+	}
+	for key, label := range labels(r) {
+		copied[key] = label
+	}
+	return copied
+}
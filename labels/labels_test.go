@@ -0,0 +1,75 @@
+package labels_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "labels.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+addresses:
+  "0x1": ETH token
+  "0x2": Bridge
+selectors:
+  "0x3": custom
+events:
+  - Transfer
+`), 0o600))
+	jsonFile := filepath.Join(dir, "labels.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"addresses": {"0x2": "StarkGate"}}`), 0o600))
+
+	registry, err := labels.Load(yamlFile, jsonFile)
+	require.NoError(t, err)
+
+	one, two, three := new(felt.Felt).SetUint64(1), new(felt.Felt).SetUint64(2), new(felt.Felt).SetUint64(3)
+	label, ok := registry.Address(one)
+	assert.True(t, ok)
+	assert.Equal(t, "ETH token", label)
+	// the labels of the later files replace the others
+	label, ok = registry.Address(two)
+	assert.True(t, ok)
+	assert.Equal(t, "StarkGate", label)
+	_, ok = registry.Address(three)
+	assert.False(t, ok)
+
+	label, ok = registry.Selector(three)
+	assert.True(t, ok)
+	assert.Equal(t, "custom", label)
+	transfer, err := crypto.StarknetKeccak([]byte("Transfer"))
+	require.NoError(t, err)
+	label, ok = registry.Selector(transfer)
+	assert.True(t, ok)
+	assert.Equal(t, "Transfer", label)
+
+	assert.Equal(t, "0x1 (ETH token)", registry.Describe(one))
+	assert.Equal(t, "0x3", registry.Describe(three))
+	assert.Len(t, registry.Addresses(), 2)
+	assert.Len(t, registry.Selectors(), 2)
+
+	t.Run("nil registry has no labels", func(t *testing.T) {
+		var registry *labels.Registry
+		_, ok := registry.Address(one)
+		assert.False(t, ok)
+		assert.Equal(t, "0x1", registry.Describe(one))
+		assert.Empty(t, registry.Addresses())
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.yaml")
+		require.NoError(t, os.WriteFile(invalid, []byte(`addresses: {"not a felt": label}`), 0o600))
+		_, err := labels.Load(invalid)
+		require.Error(t, err)
+
+		_, err = labels.Load(filepath.Join(dir, "missing.yaml"))
+		require.Error(t, err)
+	})
+}
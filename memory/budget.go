@@ -0,0 +1,255 @@
+// Package memory apportions a configured amount of memory between the caches of the node, so that their
+// combined size stays within what the machine can afford.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Consumer is a cache whose size is set by a Budget
+type Consumer interface {
+	// Usage returns the number of bytes the consumer currently holds
+	Usage() uint64
+	// Resize sets the number of bytes the consumer may hold, evicting entries if it holds more
+	Resize(limit uint64)
+}
+
+// statsConsumer is implemented by the consumers that report their hit rate
+type statsConsumer interface {
+	Stats() (hits, misses uint64)
+}
+
+const rebalanceInterval = time.Minute
+
+var _ service.Service = (*Budget)(nil)
+
+// Budget splits a total number of bytes into shares proportional to the weights of the consumers.
+//
+// Consumers that cannot be resized once created, such as the block cache of the DB, are sized with Share
+// and keep their share. Consumers that are registered are resized periodically: a consumer that uses less
+// than half of its limit lends what it does not need to the registered consumers that are full, so that
+// memory goes to the caches that are being used.
+type Budget struct {
+	mu      sync.Mutex
+	total   uint64
+	weights map[string]uint64
+
+	fixed     map[string]uint64
+	consumers map[string]Consumer
+	limits    map[string]uint64
+
+	log utils.SimpleLogger
+}
+
+// NewBudget returns a budget of total bytes shared between the consumers in weights
+func NewBudget(total uint64, weights map[string]uint64, log utils.SimpleLogger) *Budget {
+	b := &Budget{
+		total:     total,
+		weights:   weights,
+		fixed:     make(map[string]uint64),
+		consumers: make(map[string]Consumer),
+		limits:    make(map[string]uint64),
+		log:       log,
+	}
+	metrics.MustRegister(&budgetCollector{budget: b})
+	return b
+}
+
+// Share returns the bytes apportioned to a consumer that cannot be resized, which keeps them from then on
+func (b *Budget) Share(name string) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if size, ok := b.fixed[name]; ok {
+		return size
+	}
+	var totalWeight uint64
+	for _, weight := range b.weights {
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+	b.fixed[name] = b.total * b.weights[name] / totalWeight
+	return b.fixed[name]
+}
+
+// share returns the bytes apportioned to a registered consumer, out of what is left by the fixed ones
+func (b *Budget) share(name string) uint64 {
+	total := b.total
+	var totalWeight uint64
+	for other, weight := range b.weights {
+		if size, ok := b.fixed[other]; ok {
+			if size > total {
+				size = total
+			}
+			total -= size
+		} else {
+			totalWeight += weight
+		}
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+	return total * b.weights[name] / totalWeight
+}
+
+// Register sizes consumer to its share and resizes it on every rebalance
+func (b *Budget) Register(name string, consumer Consumer) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.weights[name]; !ok {
+		return fmt.Errorf("no weight for memory consumer %s", name)
+	}
+	b.consumers[name] = consumer
+	b.limits[name] = b.share(name)
+	consumer.Resize(b.limits[name])
+	return nil
+}
+
+// SetTotal changes the size of the budget. The consumers that cannot be resized keep their share, the
+// registered ones share what is left and are resized right away.
+func (b *Budget) SetTotal(total uint64) {
+	b.mu.Lock()
+	b.total = total
+	b.mu.Unlock()
+	b.Rebalance()
+}
+
+// Limits returns the number of bytes each registered consumer may currently hold
+func (b *Budget) Limits() map[string]uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	limits := make(map[string]uint64, len(b.limits))
+	for name, limit := range b.limits {
+		limits[name] = limit
+	}
+	return limits
+}
+
+// Rebalance moves the memory that idle registered consumers do not need to the ones that are full
+func (b *Budget) Rebalance() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	names := make([]string, 0, len(b.consumers))
+	for name := range b.consumers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pool, busyWeight uint64
+	var busy []string
+	for _, name := range names {
+		share := b.share(name)
+		pool += share
+
+		// a consumer is idle if it uses less than half of its limit, it keeps twice what it uses so that
+		// it can grow until the next rebalance, and never less than a quarter of its share
+		usage := b.consumers[name].Usage()
+		if usage >= b.limits[name]/2 {
+			busy = append(busy, name)
+			busyWeight += b.weights[name]
+			continue
+		}
+		limit := 2 * usage
+		if limit < share/4 {
+			limit = share / 4
+		}
+		if limit > share {
+			limit = share
+		}
+		b.limits[name] = limit
+	}
+
+	spare := pool
+	for _, name := range names {
+		if !contains(busy, name) {
+			spare -= b.limits[name]
+		}
+	}
+	for _, name := range busy {
+		b.limits[name] = spare * b.weights[name] / busyWeight
+	}
+
+	for _, name := range names {
+		b.consumers[name].Resize(b.limits[name])
+	}
+	b.log.Debugw("Rebalanced memory budget", "limits", b.limits)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run rebalances the budget periodically
+func (b *Budget) Run(ctx context.Context) error {
+	ticker := time.NewTicker(rebalanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			b.Rebalance()
+		}
+	}
+}
+
+var (
+	limitDesc = prometheus.NewDesc("memory_budget_limit_bytes",
+		"The number of bytes a cache may hold", []string{"consumer"}, nil)
+	usageDesc = prometheus.NewDesc("memory_budget_usage_bytes",
+		"The number of bytes a cache holds", []string{"consumer"}, nil)
+	hitsDesc = prometheus.NewDesc("memory_budget_hits_total",
+		"The number of lookups that were served by a cache", []string{"consumer"}, nil)
+	missesDesc = prometheus.NewDesc("memory_budget_misses_total",
+		"The number of lookups that were not served by a cache", []string{"consumer"}, nil)
+)
+
+// budgetCollector reports the limits and usage of the consumers when metrics are scraped
+type budgetCollector struct {
+	budget *Budget
+}
+
+func (c *budgetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- limitDesc
+	ch <- usageDesc
+	ch <- hitsDesc
+	ch <- missesDesc
+}
+
+func (c *budgetCollector) Collect(ch chan<- prometheus.Metric) {
+	c.budget.mu.Lock()
+	defer c.budget.mu.Unlock()
+
+	for name, size := range c.budget.fixed {
+		ch <- prometheus.MustNewConstMetric(limitDesc, prometheus.GaugeValue, float64(size), name)
+	}
+	for name, consumer := range c.budget.consumers {
+		ch <- prometheus.MustNewConstMetric(limitDesc, prometheus.GaugeValue, float64(c.budget.limits[name]), name)
+		ch <- prometheus.MustNewConstMetric(usageDesc, prometheus.GaugeValue, float64(consumer.Usage()), name)
+		if stats, ok := consumer.(statsConsumer); ok {
+			hits, misses := stats.Stats()
+			ch <- prometheus.MustNewConstMetric(hitsDesc, prometheus.CounterValue, float64(hits), name)
+			ch <- prometheus.MustNewConstMetric(missesDesc, prometheus.CounterValue, float64(misses), name)
+		}
+	}
+}
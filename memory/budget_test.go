@@ -0,0 +1,64 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/memory"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type consumer struct {
+	usage uint64
+	limit uint64
+}
+
+func (c *consumer) Usage() uint64 {
+	return c.usage
+}
+
+func (c *consumer) Resize(limit uint64) {
+	c.limit = limit
+	if c.usage > limit {
+		c.usage = limit
+	}
+}
+
+func TestBudget(t *testing.T) {
+	budget := memory.NewBudget(1000, map[string]uint64{"db": 50, "headers": 20, "events": 30}, utils.NewNopZapLogger())
+	assert.Equal(t, uint64(500), budget.Share("db"))
+
+	headers, events := new(consumer), new(consumer)
+	require.NoError(t, budget.Register("headers", headers))
+	require.NoError(t, budget.Register("events", events))
+	require.Error(t, budget.Register("trie", new(consumer)))
+	assert.Equal(t, uint64(200), headers.limit)
+	assert.Equal(t, uint64(300), events.limit)
+
+	t.Run("idle consumer lends to the full one", func(t *testing.T) {
+		headers.usage = 30
+		events.usage = 300
+		budget.Rebalance()
+		// headers keep twice their usage, but no less than a quarter of their share
+		assert.Equal(t, uint64(60), headers.limit)
+		assert.Equal(t, uint64(440), events.limit)
+		assert.Equal(t, map[string]uint64{"headers": 60, "events": 440}, budget.Limits())
+	})
+
+	t.Run("consumer that fills up gets its share back", func(t *testing.T) {
+		headers.usage = 60
+		budget.Rebalance()
+		assert.Equal(t, uint64(200), headers.limit)
+		assert.Equal(t, uint64(300), events.limit)
+	})
+
+	t.Run("set total", func(t *testing.T) {
+		headers.usage, events.usage = 200, 300
+		budget.SetTotal(2000)
+		// the DB keeps its share, the rest is shared by the registered consumers
+		assert.Equal(t, uint64(500), budget.Share("db"))
+		assert.Equal(t, uint64(600), headers.limit)
+		assert.Equal(t, uint64(900), events.limit)
+	})
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"container/list"
+	"sync"
+)
+
+var _ Consumer = (*Cache[int, int])(nil)
+
+// Cache is a least recently used cache that holds up to a number of bytes, as estimated by the size
+// function it was created with. A nil Cache holds nothing, so that callers do not need to check whether
+// caching is enabled.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List // front is the most recently used
+	size    func(V) uint64
+	limit   uint64
+	usage   uint64
+
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+	size  uint64
+}
+
+// NewCache returns an empty cache that holds up to limit bytes
+func NewCache[K comparable, V any](limit uint64, size func(V) uint64) *Cache[K, V] {
+	return &Cache[K, V]{
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+		size:    size,
+		limit:   limit,
+	}
+}
+
+// Get returns the value cached for key
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var value V
+	if c == nil {
+		return value, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return value, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry[K, V]).value, true
+}
+
+// Add caches value for key, evicting the least recently used values if the cache is full. Values larger
+// than the limit of the cache are not cached.
+func (c *Cache[K, V]) Add(key K, value V) {
+	if c == nil {
+		return
+	}
+
+	size := c.size(value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+	if size > c.limit {
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry[K, V]{key: key, value: value, size: size})
+	c.usage += size
+	c.evict()
+}
+
+// Remove drops the value cached for key, if any
+func (c *Cache[K, V]) Remove(key K) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// Clear drops all the values of the cache
+func (c *Cache[K, V]) Clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]*list.Element)
+	c.order.Init()
+	c.usage = 0
+}
+
+// Usage : see Consumer.Usage
+func (c *Cache[K, V]) Usage() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// Resize : see Consumer.Resize
+func (c *Cache[K, V]) Resize(limit uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	c.evict()
+}
+
+// Stats returns the number of lookups that found a value and of those that did not
+func (c *Cache[K, V]) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *Cache[K, V]) evict() {
+	for c.usage > c.limit {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *Cache[K, V]) removeElement(element *list.Element) {
+	entry := c.order.Remove(element).(*cacheEntry[K, V])
+	delete(c.entries, entry.key)
+	c.usage -= entry.size
+}
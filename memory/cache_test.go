@@ -0,0 +1,74 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	cache := memory.NewCache[int, string](10, func(v string) uint64 {
+		return uint64(len(v))
+	})
+
+	cache.Add(1, "aaaa")
+	cache.Add(2, "bbbb")
+	assert.Equal(t, uint64(8), cache.Usage())
+
+	// 1 becomes the most recently used, so 2 is evicted
+	value, ok := cache.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "aaaa", value)
+	cache.Add(3, "cccc")
+	_, ok = cache.Get(2)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(8), cache.Usage())
+
+	t.Run("replace", func(t *testing.T) {
+		cache.Add(3, "cc")
+		value, ok = cache.Get(3)
+		assert.True(t, ok)
+		assert.Equal(t, "cc", value)
+		assert.Equal(t, uint64(6), cache.Usage())
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		cache.Add(4, "ddddddddddd")
+		_, ok = cache.Get(4)
+		assert.False(t, ok)
+	})
+
+	t.Run("resize evicts", func(t *testing.T) {
+		cache.Resize(2)
+		assert.Equal(t, uint64(2), cache.Usage())
+		_, ok = cache.Get(1)
+		assert.False(t, ok)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		cache.Remove(3)
+		assert.Zero(t, cache.Usage())
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		cache.Add(1, "a")
+		cache.Clear()
+		assert.Zero(t, cache.Usage())
+		_, ok = cache.Get(1)
+		assert.False(t, ok)
+	})
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, uint64(2), hits)
+	assert.Equal(t, uint64(4), misses)
+
+	t.Run("nil cache", func(t *testing.T) {
+		var nilCache *memory.Cache[int, string]
+		nilCache.Add(1, "a")
+		_, ok = nilCache.Get(1)
+		assert.False(t, ok)
+		nilCache.Remove(1)
+		nilCache.Clear()
+	})
+}
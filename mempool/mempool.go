@@ -0,0 +1,225 @@
+// Package mempool holds the transactions submitted through the node until the gateway accepts them. The
+// transactions that the gateway could not be reached for are submitted again until it accepts or rejects them,
+// and the transactions that are submitted again are not relayed to the gateway twice.
+package mempool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/clients/gateway"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ service.Service = (*Pool)(nil)
+
+const (
+	// maxTransactions is the number of transactions the pool holds at most
+	maxTransactions = 1024
+	// pendingExpiry is how long a transaction the gateway could not be reached for is submitted again
+	pendingExpiry = time.Hour
+	// acceptedRetention is how long a transaction the gateway accepted is kept to deduplicate its resubmissions
+	acceptedRetention = 10 * time.Minute
+	// minRetryInterval and maxRetryInterval bound the interval between the submissions of a transaction, which
+	// doubles with every failure
+	minRetryInterval = time.Second
+	maxRetryInterval = time.Minute
+	// checkInterval is how frequently the pool checks for transactions to submit again and to drop
+	checkInterval = time.Second
+)
+
+// ErrFull is returned when a transaction cannot be submitted because the pool holds too many transactions
+var ErrFull = errors.New("the transaction pool is full")
+
+type Gateway interface {
+	AddTransaction(json.RawMessage) (json.RawMessage, error)
+}
+
+type Status string
+
+const (
+	// Pending transactions are submitted to the gateway until it accepts or rejects them
+	Pending Status = "PENDING"
+	// Accepted transactions were accepted by the gateway
+	Accepted Status = "ACCEPTED"
+)
+
+// Transaction is a transaction of the pool
+type Transaction struct {
+	Transaction core.Transaction
+	Status      Status
+	SubmittedAt time.Time
+	// Attempts is the number of times the transaction was submitted to the gateway
+	Attempts uint
+	// LastError is the error of the last failed submission
+	LastError string
+
+	// request is what is sent to the gateway, and response its response once it accepted the transaction
+	request     json.RawMessage
+	response    json.RawMessage
+	acceptedAt  time.Time
+	nextAttempt time.Time
+}
+
+// Pool relays transactions to the gateway
+type Pool struct {
+	gateway Gateway
+	log     utils.SimpleLogger
+
+	mu           sync.Mutex
+	transactions map[felt.Felt]*Transaction
+
+	// metrics
+	size *prometheus.GaugeVec
+}
+
+func New(gw Gateway, log utils.SimpleLogger) *Pool {
+	p := &Pool{
+		gateway:      gw,
+		log:          log,
+		transactions: make(map[felt.Felt]*Transaction),
+		size: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "mempool",
+			Name:      "transactions",
+			Help:      "The number of transactions in the pool",
+		}, []string{"status"}),
+	}
+	metrics.MustRegister(p.size)
+	return p
+}
+
+// Submit submits txn to the gateway, with the request of the gateway, and returns its response. If the gateway
+// cannot be reached, txn is kept and submitted again in the background, and Submit returns a nil response without
+// an error. Transactions that were submitted before are not submitted again, Submit returns the response of the
+// gateway to their previous submission, or a nil response if they are pending.
+func (p *Pool) Submit(txn core.Transaction, request json.RawMessage) (json.RawMessage, error) {
+	hash := *txn.Hash()
+	p.mu.Lock()
+	if pooled, ok := p.transactions[hash]; ok {
+		response := pooled.response
+		p.mu.Unlock()
+		return response, nil
+	}
+	if len(p.transactions) >= maxTransactions {
+		p.mu.Unlock()
+		return nil, ErrFull
+	}
+	pooled := &Transaction{
+		Transaction: txn,
+		Status:      Pending,
+		SubmittedAt: time.Now(),
+		request:     request,
+	}
+	p.transactions[hash] = pooled
+	p.mu.Unlock()
+
+	return p.submit(pooled)
+}
+
+// submit submits a pending transaction to the gateway, and fails if the gateway rejects it
+func (p *Pool) submit(pooled *Transaction) (json.RawMessage, error) {
+	response, err := p.gateway.AddTransaction(pooled.request)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.updateMetrics()
+	pooled.Attempts++
+	var gatewayErr *gateway.Error
+	switch {
+	case err == nil:
+		pooled.Status, pooled.response, pooled.acceptedAt = Accepted, response, time.Now()
+		return response, nil
+	case errors.As(err, &gatewayErr) && gatewayErr.Code == gateway.DuplicatedTransaction && pooled.Attempts > 1:
+		// an earlier submission reached the gateway even though it failed
+		pooled.Status, pooled.acceptedAt = Accepted, time.Now()
+		return nil, nil
+	case errors.As(err, &gatewayErr):
+		delete(p.transactions, *pooled.Transaction.Hash())
+		return nil, err
+	default:
+		pooled.LastError = err.Error()
+		retryInterval := minRetryInterval << (pooled.Attempts - 1)
+		if retryInterval > maxRetryInterval || retryInterval <= 0 {
+			retryInterval = maxRetryInterval
+		}
+		pooled.nextAttempt = time.Now().Add(retryInterval)
+		p.log.Warnw("Failed to submit transaction to the gateway, it is submitted again later",
+			"hash", pooled.Transaction.Hash(), "attempts", pooled.Attempts, "err", err)
+		return nil, nil
+	}
+}
+
+// Run submits the pending transactions again and drops the expired transactions until ctx is cancelled
+func (p *Pool) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(checkInterval):
+		}
+
+		for _, pooled := range p.due() {
+			if _, err := p.submit(pooled); err != nil {
+				p.log.Warnw("Gateway rejected pooled transaction", "hash", pooled.Transaction.Hash(), "err", err)
+			}
+		}
+	}
+}
+
+// due drops the expired transactions and returns the pending transactions to submit again
+func (p *Pool) due() []*Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.updateMetrics()
+
+	var due []*Transaction
+	now := time.Now()
+	for hash, pooled := range p.transactions {
+		switch {
+		case pooled.Status == Accepted && now.Sub(pooled.acceptedAt) > acceptedRetention:
+			delete(p.transactions, hash)
+		case pooled.Status == Pending && now.Sub(pooled.SubmittedAt) > pendingExpiry:
+			p.log.Warnw("Dropping pooled transaction the gateway could not be reached for", "hash", &hash,
+				"attempts", pooled.Attempts, "err", pooled.LastError)
+			delete(p.transactions, hash)
+		case pooled.Status == Pending && !pooled.nextAttempt.IsZero() && !now.Before(pooled.nextAttempt):
+			// it is not submitted concurrently by a later check
+			pooled.nextAttempt = time.Time{}
+			due = append(due, pooled)
+		}
+	}
+	return due
+}
+
+func (p *Pool) updateMetrics() {
+	counts := map[Status]int{Pending: 0, Accepted: 0}
+	for _, pooled := range p.transactions {
+		counts[pooled.Status]++
+	}
+	for status, count := range counts {
+		p.size.WithLabelValues(string(status)).Set(float64(count))
+	}
+}
+
+// Transactions returns copies of the transactions of the pool in the order they were submitted
+func (p *Pool) Transactions() []Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	transactions := make([]Transaction, 0, len(p.transactions))
+	for _, pooled := range p.transactions {
+		transactions = append(transactions, *pooled)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].SubmittedAt.Before(transactions[j].SubmittedAt)
+	})
+	return transactions
+}
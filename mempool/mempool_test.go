@@ -0,0 +1,112 @@
+package mempool_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/clients/gateway"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/mempool"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGateway responds to the transactions with the result of respond, and counts the submissions
+type fakeGateway struct {
+	mu          sync.Mutex
+	submissions int
+	respond     func() (json.RawMessage, error)
+}
+
+func (g *fakeGateway) AddTransaction(json.RawMessage) (json.RawMessage, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.submissions++
+	return g.respond()
+}
+
+func (g *fakeGateway) set(respond func() (json.RawMessage, error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.respond = respond
+}
+
+func (g *fakeGateway) count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.submissions
+}
+
+func invoke(hash uint64) core.Transaction {
+	return &core.InvokeTransaction{TransactionHash: new(felt.Felt).SetUint64(hash)}
+}
+
+func accept() (json.RawMessage, error) {
+	return json.RawMessage(`{"transaction_hash": "0x1"}`), nil
+}
+
+func TestPool(t *testing.T) {
+	gw := &fakeGateway{respond: accept}
+	pool := mempool.New(gw, utils.NewNopZapLogger())
+
+	t.Run("accepted transactions are deduplicated", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			response, err := pool.Submit(invoke(1), json.RawMessage(`{}`))
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"transaction_hash": "0x1"}`, string(response))
+		}
+		assert.Equal(t, 1, gw.count())
+	})
+
+	t.Run("rejected transactions are not pooled", func(t *testing.T) {
+		gw.set(func() (json.RawMessage, error) {
+			return nil, &gateway.Error{Code: gateway.InvalidTransactionNonce, Message: "invalid nonce"}
+		})
+		_, err := pool.Submit(invoke(2), json.RawMessage(`{}`))
+		var gatewayErr *gateway.Error
+		require.ErrorAs(t, err, &gatewayErr)
+		assert.Equal(t, gateway.InvalidTransactionNonce, gatewayErr.Code)
+		assert.Len(t, pool.Transactions(), 1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- pool.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("transactions are submitted again until the gateway accepts them", func(t *testing.T) {
+		gw.set(func() (json.RawMessage, error) {
+			return nil, errors.New("connection refused")
+		})
+		submissions := gw.count()
+		response, err := pool.Submit(invoke(3), json.RawMessage(`{}`))
+		require.NoError(t, err)
+		assert.Nil(t, response, "the transaction is pending")
+
+		transactions := pool.Transactions()
+		require.Len(t, transactions, 2)
+		assert.Equal(t, mempool.Pending, transactions[1].Status)
+		assert.Equal(t, "connection refused", transactions[1].LastError)
+
+		// an earlier submission reached the gateway
+		gw.set(func() (json.RawMessage, error) {
+			return nil, &gateway.Error{Code: gateway.DuplicatedTransaction}
+		})
+		require.Eventually(t, func() bool {
+			return pool.Transactions()[1].Status == mempool.Accepted
+		}, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, submissions+2, gw.count())
+		assert.Equal(t, uint(2), pool.Transactions()[1].Attempts)
+	})
+}
@@ -21,20 +21,31 @@ func MustRegister(collectors ...prometheus.Collector) {
 
 type Metrics struct {
 	listener net.Listener
+	mux      *http.ServeMux
 }
 
 func New(listener net.Listener) *Metrics {
+	mux := http.NewServeMux()
+	mux.Handle("/", promhttp.HandlerFor(prometheus.DefaultGatherer,
+		promhttp.HandlerOpts{Registry: prometheus.DefaultRegisterer}))
 	return &Metrics{
 		listener: listener,
+		mux:      mux,
 	}
 }
 
+// WithHandler serves handler on pattern next to the metrics
+func (m *Metrics) WithHandler(pattern string, handler http.Handler) *Metrics {
+	m.mux.Handle(pattern, handler)
+	return m
+}
+
 func (m *Metrics) Run(ctx context.Context) error {
 	errCh := make(chan error)
 
 	srv := &http.Server{
 		Addr:    m.listener.Addr().String(),
-		Handler: promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{Registry: prometheus.DefaultRegisterer}),
+		Handler: m.mux,
 		// ReadTimeout is also treated as ReadHeaderTimeout and IdleTimeout.
 		ReadTimeout: 30 * time.Second,
 	}
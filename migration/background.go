@@ -0,0 +1,247 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/sourcegraph/conc/pool"
+)
+
+var _ service.Service = (*Background)(nil)
+
+// backgroundBatchSize is the number of blocks a background migration migrates in a transaction, unless
+// MigrationOptions.BatchSize is set, so that the transactions hold the write lock of the database briefly
+const backgroundBatchSize = 64
+
+// blockMigrationFunc returns the writes that migrate block. It is called concurrently and without a transaction, the
+// writes are applied to the database if block was not reverted in between.
+type blockMigrationFunc func(block *core.Block, network utils.Network) (func(db.Transaction) error, error)
+
+// backgroundMigration is a migration that only rewrites the data of each block, which the node reads whether it
+// was migrated or not, so that it can be applied while the node serves, see MigrationOptions.Background. The blocks
+// stored after it is deferred are stored migrated.
+type backgroundMigration struct {
+	Migration
+	migrateBlock blockMigrationFunc
+}
+
+func inBackground(m Migration, migrateBlock blockMigrationFunc) backgroundMigration {
+	return backgroundMigration{Migration: m, migrateBlock: migrateBlock}
+}
+
+// unwrap returns the migration m runs when it is not deferred, whose optional interfaces apply to m
+func unwrap(m Migration) Migration {
+	if background, ok := m.(backgroundMigration); ok {
+		return background.Migration
+	}
+	return m
+}
+
+// foreground returns the migrations, unwrapped, that MigrateTo applies itself with opts
+func foreground(migrations []Migration, opts MigrationOptions) []Migration {
+	applied := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if _, ok := m.(backgroundMigration); ok && opts.Background {
+			continue
+		}
+		applied = append(applied, unwrap(m))
+	}
+	return applied
+}
+
+// backgroundStatus is the progress of a deferred migration, which migrates the blocks before Until from Next
+type backgroundStatus struct {
+	Next  uint64
+	Until uint64
+}
+
+// Done returns whether the migration migrated all its blocks
+func (s *backgroundStatus) Done() bool {
+	return s.Next >= s.Until
+}
+
+// deferMigration records that the migration to version is applied in the background, from the first block to the head of
+// targetDB, and sets the schema version of targetDB to version
+func deferMigration(targetDB db.DB, version uint64, opts MigrationOptions) error {
+	return targetDB.Update(func(txn db.Transaction) error {
+		total, err := blockCount(txn)
+		if err != nil {
+			return err
+		}
+		if err = putBackgroundStatus(txn, version, &backgroundStatus{Until: total}); err != nil {
+			return err
+		}
+		entry := historyEntry(version, opts)
+		entry.Background = true
+		if total == 0 {
+			// there are no blocks to migrate
+			entry.Finished = &entry.Started
+		}
+		if _, err = appendHistory(txn, entry); err != nil {
+			return err
+		}
+		return schemaVersionValue.Put(txn, version)
+	})
+}
+
+func backgroundStatusOf(txn db.Transaction, version uint64) (*backgroundStatus, error) {
+	return backgroundStatusTable.Get(txn, version)
+}
+
+func putBackgroundStatus(txn db.Transaction, version uint64, status *backgroundStatus) error {
+	return backgroundStatusTable.Put(txn, version, status)
+}
+
+// Background applies the migrations MigrateTo deferred with MigrationOptions.Background while the node serves. The
+// blocks are migrated in small batches, each committed along with the progress of the migration, so that the
+// migrations resume from their last batch after a restart.
+type Background struct {
+	targetDB db.DB
+	network  utils.Network
+	log      utils.SimpleLogger
+	opts     MigrationOptions
+}
+
+// NewBackground returns a Background that applies the deferred migrations of targetDB, tuned with opts
+func NewBackground(targetDB db.DB, network utils.Network, log utils.SimpleLogger, opts MigrationOptions) *Background {
+	return &Background{targetDB: targetDB, network: network, log: log, opts: opts}
+}
+
+// Run applies the deferred migrations, oldest first, until they complete or ctx is cancelled
+func (b *Background) Run(ctx context.Context) error {
+	version, err := SchemaVersion(b.targetDB)
+	if err != nil {
+		return err
+	}
+	for v := uint64(1); v <= version && v <= LatestSchemaVersion(); v++ {
+		migration, ok := migrations[v-1].(backgroundMigration)
+		if !ok {
+			continue
+		}
+		if err = b.migrate(ctx, v, migration); errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			migrationFailures.Inc()
+			return fmt.Errorf("background migration to schema version %d: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// migrate migrates the blocks of the deferred migration to version, if it is not done
+func (b *Background) migrate(ctx context.Context, version uint64, migration backgroundMigration) error {
+	var status *backgroundStatus
+	err := b.targetDB.View(func(txn db.Transaction) error {
+		var err error
+		status, err = backgroundStatusOf(txn, version)
+		return err
+	})
+	if errors.Is(err, db.ErrKeyNotFound) || err == nil && status.Done() {
+		// the migration was applied before the node started, or completed
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	b.log.Infow("Applying database migration in the background", "version", version, "from", status.Next,
+		"until", status.Until)
+	blockchain.RegisterCoreTypesToEncoder()
+	progress := progressReporter(version, b.log, b.opts.OnProgress)
+	opts := b.opts
+	if opts.BatchSize == 0 {
+		opts.BatchSize = backgroundBatchSize
+	}
+	for !status.Done() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = b.migrateBatch(ctx, status, version, migration.migrateBlock, opts); err != nil {
+			return err
+		}
+		migrationCommits.Inc()
+		progress.report(status.Next, status.Until)
+	}
+	b.log.Infow("Completed database migration in the background", "version", version)
+	compact(b.targetDB, migration, version, b.log)
+	return nil
+}
+
+// migrateBatch migrates the next batch of blocks of status and moves it past them. The blocks are read and
+// migrated from a snapshot, and the writes of those that were not reverted since are committed with status, in a
+// transaction that is retried if it conflicts with the writes of the node.
+func (b *Background) migrateBatch(ctx context.Context, status *backgroundStatus, version uint64,
+	migrateBlock blockMigrationFunc, opts MigrationOptions,
+) error {
+	var blocks []*core.Block
+	err := b.targetDB.View(func(txn db.Transaction) error {
+		total, err := blockCount(txn)
+		if err != nil {
+			return err
+		}
+		if total > status.Until {
+			total = status.Until
+		}
+		blocks, err = readBlockBatch(txn, status.Next, total, opts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	writes := make([]func(db.Transaction) error, len(blocks))
+	workerPool := pool.New().WithErrors().WithMaxGoroutines(opts.workers())
+	for i, block := range blocks {
+		i, block := i, block
+		workerPool.Go(func() error {
+			var err error
+			writes[i], err = migrateBlock(block, b.network)
+			return err
+		})
+	}
+	if err = workerPool.Wait(); err != nil {
+		return err
+	}
+
+	// status is only moved once the transaction is committed, so that it is the same for each attempt
+	next := *status
+	if len(blocks) == 0 {
+		// the blocks from Next were reverted, the blocks that replaced them were stored migrated
+		next.Next = next.Until
+	} else {
+		next.Next = blocks[len(blocks)-1].Number + 1
+	}
+	err = db.UpdateWithRetry(ctx, b.targetDB, db.DefaultRetryPolicy, db.Idempotent(func(txn db.Transaction) error {
+		for i, block := range blocks {
+			header, err := blockchain.BlockHeaderByNumber(txn, block.Number)
+			if errors.Is(err, db.ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			} else if !header.Hash.Equal(block.Hash) {
+				// the block was reverted, the block that replaced it was stored migrated
+				continue
+			}
+			if err = writes[i](txn); err != nil {
+				return err
+			}
+		}
+
+		if next.Done() {
+			if err := finishBackgroundHistory(txn, version); err != nil {
+				return err
+			}
+		}
+		return putBackgroundStatus(txn, version, &next)
+	}))
+	if err != nil {
+		return err
+	}
+	*status = next
+	return nil
+}
@@ -0,0 +1,120 @@
+package migration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackground(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	latest := migration.LatestSchemaVersion()
+	// the migration that calculates the commitments is followed by the purge of the deprecated buckets
+	commitmentsVersion := latest - 1
+	// the blocks are stored before the migration that calculates their commitments
+	require.NoError(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET, commitmentsVersion-1,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+
+	chain := blockchain.New(testDB, utils.MAINNET, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	for i := uint64(0); i < 3; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+	}
+	deleteCommitments := func() {
+		t.Helper()
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			for i := uint64(0); i < 3; i++ {
+				if err := txn.Delete(db.BlockCommitments.Key(core.MarshalBlockNumber(i))); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	deleteCommitments()
+	storedCommitments := func(number uint64) error {
+		t.Helper()
+		return testDB.View(func(txn db.Transaction) error {
+			return txn.Get(db.BlockCommitments.Key(core.MarshalBlockNumber(number)), func([]byte) error {
+				return nil
+			})
+		})
+	}
+
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{Background: true}))
+	version, err := migration.SchemaVersion(testDB)
+	require.NoError(t, err)
+	require.Equal(t, latest, version, "the deferred migrations are recorded as applied")
+	require.ErrorIs(t, storedCommitments(0), db.ErrKeyNotFound, "the commitments are calculated in the background")
+	commitments, err := chain.BlockCommitmentsByNumber(0)
+	require.NoError(t, err, "the commitments of the blocks that were not migrated are calculated when read")
+	assert.NotNil(t, commitments.TransactionCommitment)
+
+	status, err := migration.GetStatus(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), status.Applied[commitmentsVersion-1].RemainingBlocks)
+
+	t.Run("the reverted blocks are skipped", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		var progress []migration.Progress
+		require.NoError(t, migration.NewBackground(testDB, utils.MAINNET, utils.NewNopZapLogger(),
+			migration.MigrationOptions{
+				BatchSize: 2,
+				OnProgress: func(p migration.Progress) {
+					progress = append(progress, p)
+				},
+			}).Run(context.Background()))
+		require.NotEmpty(t, progress)
+		assert.Equal(t, commitmentsVersion, progress[len(progress)-1].Version)
+		assert.Equal(t, status.Applied[commitmentsVersion-1].Name, progress[len(progress)-1].Name)
+		assert.Equal(t, progress[len(progress)-1].Total, progress[len(progress)-1].Current)
+
+		for i := uint64(0); i < 2; i++ {
+			require.NoError(t, storedCommitments(i))
+		}
+		status, err := migration.GetStatus(testDB)
+		require.NoError(t, err)
+		for _, applied := range status.Applied {
+			assert.Zero(t, applied.RemainingBlocks)
+		}
+		history, err := migration.History(testDB)
+		require.NoError(t, err)
+		commitments := history[commitmentsVersion-1]
+		assert.Equal(t, commitmentsVersion, commitments.Version)
+		assert.True(t, commitments.Background)
+		assert.NotNil(t, commitments.Finished, "the migration completed in the background")
+	})
+
+	t.Run("completed migrations are not applied again", func(t *testing.T) {
+		deleteCommitments()
+		require.NoError(t, migration.NewBackground(testDB, utils.MAINNET, utils.NewNopZapLogger(),
+			migration.MigrationOptions{}).Run(context.Background()))
+		require.ErrorIs(t, storedCommitments(0), db.ErrKeyNotFound)
+	})
+
+	t.Run("reverted migrations are no longer applied in the background", func(t *testing.T) {
+		require.NoError(t, migration.RevertTo(context.Background(), testDB, utils.MAINNET, commitmentsVersion-1,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		require.NoError(t, storedCommitments(0), "the migration was applied before the node started")
+	})
+}
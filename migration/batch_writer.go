@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"github.com/NethermindEth/juno/db"
+)
+
+var (
+	_ db.Transaction  = (*BatchWriter)(nil)
+	_ db.PendingSizer = (*BatchWriter)(nil)
+)
+
+// defaultBatchWriteSize is the size of the writes a BatchWriter of a migration commits at, if the migrations are not
+// given a memory budget
+const defaultBatchWriteSize = 128 << 20
+
+// BatchWriter is a db.Transaction that writes to a database through a series of transactions: once the writes of
+// the current transaction reach a size, it is committed and a new one is opened. A migration that writes through a
+// BatchWriter does not have to split itself into steps with ErrCallWithNewTransaction to bound the memory of its
+// writes.
+//
+// An iterator reads the transaction it was created on, so the transaction is not committed while an iterator of the
+// BatchWriter is open, the writes made while iterating are committed once it is closed. The writes committed before
+// a failure are kept, so the writes through a BatchWriter have to be idempotent.
+type BatchWriter struct {
+	database   db.DB
+	txn        db.Transaction
+	maxPending uint64
+	// pending is the approximate size of the writes of txn, the sizes of their keys and values
+	pending   uint64
+	iterators int
+}
+
+// NewBatchWriter returns a BatchWriter that commits its writes to database once they reach maxPending bytes, or
+// only when it is committed if maxPending is 0
+func NewBatchWriter(database db.DB, maxPending uint64) *BatchWriter {
+	return &BatchWriter{
+		database:   database,
+		txn:        database.NewTransaction(true),
+		maxPending: maxPending,
+	}
+}
+
+func (w *BatchWriter) NewIterator() (db.Iterator, error) {
+	return w.NewIteratorWithBounds(nil, nil)
+}
+
+func (w *BatchWriter) NewIteratorWithBounds(lower, upper []byte) (db.Iterator, error) {
+	it, err := w.txn.NewIteratorWithBounds(lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	w.iterators++
+	return &batchIterator{Iterator: it, writer: w}, nil
+}
+
+// Discard discards the writes that were not committed yet
+func (w *BatchWriter) Discard() error {
+	return w.txn.Discard()
+}
+
+// Commit commits the writes that were not committed yet, the BatchWriter cannot be used afterwards
+func (w *BatchWriter) Commit() error {
+	return db.CloseAndWrapOnError(w.txn.Discard, w.txn.Commit())
+}
+
+func (w *BatchWriter) Set(key, val []byte) error {
+	if err := w.txn.Set(key, val); err != nil {
+		return err
+	}
+	w.pending += uint64(len(key) + len(val))
+	return w.commitIfFull()
+}
+
+func (w *BatchWriter) Delete(key []byte) error {
+	if err := w.txn.Delete(key); err != nil {
+		return err
+	}
+	w.pending += uint64(len(key))
+	return w.commitIfFull()
+}
+
+// DeletePrefix deletes the keys that start with prefix in the current transaction, which counts for the size of
+// prefix since the database deletes them with a range deletion if it can
+func (w *BatchWriter) DeletePrefix(prefix []byte) error {
+	if err := w.txn.DeletePrefix(prefix); err != nil {
+		return err
+	}
+	w.pending += uint64(len(prefix))
+	return w.commitIfFull()
+}
+
+func (w *BatchWriter) Get(key []byte, cb func([]byte) error) error {
+	return w.txn.Get(key, cb)
+}
+
+func (w *BatchWriter) MultiGet(keys [][]byte, cb func(int, []byte) error) error {
+	return w.txn.MultiGet(keys, cb)
+}
+
+// Impl returns the underlying transaction object of the current transaction
+func (w *BatchWriter) Impl() any {
+	return w.txn.Impl()
+}
+
+// PendingSize : see db.PendingSizer.PendingSize, it is the approximate size of the writes that were not
+// committed yet
+func (w *BatchWriter) PendingSize() uint64 {
+	return w.pending
+}
+
+// commitIfFull commits the current transaction and opens a new one if its writes reached maxPending and no
+// iterator reads it
+func (w *BatchWriter) commitIfFull() error {
+	if w.maxPending == 0 || w.pending < w.maxPending || w.iterators > 0 {
+		return nil
+	}
+	if err := w.Commit(); err != nil {
+		return err
+	}
+	migrationCommits.Inc()
+	w.txn = w.database.NewTransaction(true)
+	w.pending = 0
+	return nil
+}
+
+// batchIterator is an iterator of a BatchWriter, which holds the commits of the BatchWriter until it is closed
+type batchIterator struct {
+	db.Iterator
+	writer *BatchWriter
+	closed bool
+}
+
+func (it *batchIterator) Close() error {
+	err := it.Iterator.Close()
+	if !it.closed {
+		it.closed = true
+		it.writer.iterators--
+	}
+	if err != nil {
+		return err
+	}
+	return it.writer.commitIfFull()
+}
+
+// batchWriter is implemented by the migrations that are applied with a BatchWriter, which commits their writes once
+// they reach the memory budget of the migrations, defaultBatchWriteSize if it is not set. Such a migration that is
+// interrupted is applied again from the start.
+type batchWriter interface {
+	writesInBatches()
+}
+
+// batchedMigration is a migration that is applied with a BatchWriter
+type batchedMigration struct {
+	Migration
+}
+
+func batched(m Migration) batchedMigration {
+	return batchedMigration{Migration: m}
+}
+
+func (m batchedMigration) writesInBatches() {}
+
+// batchWriteSize returns the size of the writes the BatchWriter m is applied with commits at, or 0 if m is not a
+// batchWriter and is applied in a single transaction per step
+func batchWriteSize(m Migration, opts MigrationOptions) uint64 {
+	if _, ok := find[batchWriter](m); !ok {
+		return 0
+	}
+	if opts.MemoryBudget > 0 {
+		return opts.MemoryBudget
+	}
+	return defaultBatchWriteSize
+}
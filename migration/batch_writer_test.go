@@ -0,0 +1,64 @@
+package migration_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchWriter(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	bucket := db.Bucket(0)
+	// committed returns whether key was committed
+	committed := func(key []byte) bool {
+		err := testDB.View(func(txn db.Transaction) error {
+			return txn.Get(key, func([]byte) error { return nil })
+		})
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	// the writes are committed once they reach 4 bytes
+	writer := migration.NewBatchWriter(testDB, 4)
+	require.NoError(t, writer.Set(bucket.Key([]byte{0}), []byte{0}))
+	assert.Equal(t, uint64(3), writer.PendingSize())
+	assert.False(t, committed(bucket.Key([]byte{0})))
+	require.NoError(t, writer.Set(bucket.Key([]byte{1}), []byte{1}))
+	assert.Zero(t, writer.PendingSize())
+	assert.True(t, committed(bucket.Key([]byte{1})))
+
+	t.Run("the writes are not committed while iterating", func(t *testing.T) {
+		it, err := writer.NewIterator()
+		require.NoError(t, err)
+		var keys [][]byte
+		for it.Seek(bucket.Key()); it.Valid(); it.Next() {
+			key := bytes.Clone(it.Key())
+			keys = append(keys, key)
+			require.NoError(t, writer.Delete(key))
+		}
+		assert.Len(t, keys, 2)
+		assert.True(t, committed(keys[1]))
+
+		require.NoError(t, it.Close())
+		assert.False(t, committed(keys[1]))
+	})
+
+	t.Run("the writes that were not committed are discarded", func(t *testing.T) {
+		require.NoError(t, writer.Set(bucket.Key([]byte{2}), nil))
+		require.NoError(t, writer.Get(bucket.Key([]byte{2}), func([]byte) error { return nil }))
+		require.NoError(t, writer.Discard())
+		assert.False(t, committed(bucket.Key([]byte{2})))
+	})
+}
@@ -1,7 +1,7 @@
 package migration
 
 import (
-	"bytes"
+	"context"
 
 	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/utils"
@@ -14,6 +14,9 @@ type (
 	BucketMigratorKeyFilter func([]byte) (bool, error)
 )
 
+// BucketMigrator migrates the entries of a bucket in batches, each of which is committed in its own transaction
+// along with a checkpoint of where the next batch starts in MigrationCheckpoints. A migration that was interrupted
+// resumes from the last committed batch, so the entries of the committed batches are not migrated twice.
 type BucketMigrator struct {
 	target db.Bucket
 
@@ -23,14 +26,12 @@ type BucketMigrator struct {
 	keyFilter BucketMigratorKeyFilter
 	do        BucketMigratorDoFunc
 
-	// key to seek to when starting the migration
-	startFrom []byte
+	progress ProgressFunc
 }
 
 func NewBucketMigrator(target db.Bucket, do BucketMigratorDoFunc) *BucketMigrator {
 	return &BucketMigrator{
 		target:    target,
-		startFrom: target.Key(),
 		batchSize: 1_000_000,
 
 		before:    func() {},
@@ -66,42 +67,67 @@ func (m *BucketMigrator) WithKeyFilter(keyFilter BucketMigratorKeyFilter) *Bucke
 	return m
 }
 
+func (m *BucketMigrator) rewritesBuckets() {}
+
+// SpaceEstimate returns the size of the bucket the migration rewrites
+func (m *BucketMigrator) SpaceEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesSize(targetDB, m.target.Key())
+}
+
 func (m *BucketMigrator) Before() {
 	m.before()
 }
 
-func (m *BucketMigrator) Migrate(txn db.Transaction, network utils.Network) error {
-	remainingInBatch := m.batchSize
-	iterator, err := txn.NewIterator()
+// OnProgress sets the function the migrated entries are reported to, their total is not known
+func (m *BucketMigrator) OnProgress(progress ProgressFunc) {
+	m.progress = progress
+}
+
+// SetOptions is a no-op, the batch size is set with WithBatchSize.
+func (m *BucketMigrator) SetOptions(MigrationOptions) {}
+
+// checkpointKey is the key of the checkpoint of the migrations of the target bucket
+func (m *BucketMigrator) checkpointKey() []byte {
+	return append([]byte("bucket migrator"), byte(m.target))
+}
+
+// Migrate migrates the next batch of entries, the batch ends early once ctx is cancelled. It returns
+// ErrCallWithNewTransaction, with the checkpoint of the next batch set in txn, until the last batch, after which the
+// checkpoint is deleted.
+func (m *BucketMigrator) Migrate(ctx context.Context, txn db.Transaction, network utils.Network) error {
+	cp, err := loadCheckpoint(txn, m.checkpointKey())
 	if err != nil {
 		return err
 	}
 
-	for iterator.Seek(m.startFrom); iterator.Valid(); iterator.Next() {
-		key := iterator.Key()
-		if !bytes.HasPrefix(key, m.target.Key()) {
-			break
+	remainingInBatch := m.batchSize
+	batchEnded := false
+	err = db.IterateBucket(txn, m.target, func(key, value []byte) error {
+		if pass, err := m.keyFilter(key); err != nil || !pass {
+			return err
+		}
+
+		if remainingInBatch == 0 || (remainingInBatch < m.batchSize && ctx.Err() != nil) {
+			cp.Next, batchEnded = key, true
+			return db.ErrStopIteration
 		}
 
-		if pass, err := m.keyFilter(key); err != nil {
-			return db.CloseAndWrapOnError(iterator.Close, err)
-		} else if pass {
-			if remainingInBatch == 0 {
-				m.startFrom = key
-				return db.CloseAndWrapOnError(iterator.Close, ErrCallWithNewTransaction)
-			}
-
-			remainingInBatch--
-			value, err := iterator.Value()
-			if err != nil {
-				return db.CloseAndWrapOnError(iterator.Close, err)
-			}
-
-			if err = m.do(txn, key, value, network); err != nil {
-				return db.CloseAndWrapOnError(iterator.Close, err)
-			}
+		remainingInBatch--
+		if err := m.do(txn, key, value, network); err != nil {
+			return err
+		}
+		cp.Migrated++
+		m.progress.report(cp.Migrated, 0)
+		return nil
+	}, db.From(cp.Next))
+	if err != nil {
+		return err
+	}
+	if batchEnded {
+		if err = storeCheckpoint(txn, m.checkpointKey(), cp); err != nil {
+			return err
 		}
+		return ErrCallWithNewTransaction
 	}
-
-	return iterator.Close()
+	return checkpointTable.Delete(txn, m.checkpointKey())
 }
@@ -2,6 +2,7 @@ package migration_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"testing"
 
@@ -34,19 +35,25 @@ func TestBucketMover(t *testing.T) {
 
 	mover.Before()
 	require.True(t, beforeCalled)
+	var migrated uint64
+	mover.OnProgress(func(current, total uint64) {
+		migrated = current
+		require.Zero(t, total)
+	})
 
 	err := testDB.Update(func(txn db.Transaction) error {
-		err := mover.Migrate(txn, utils.MAINNET)
+		err := mover.Migrate(context.Background(), txn, utils.MAINNET)
 		require.ErrorIs(t, err, migration.ErrCallWithNewTransaction)
 		return nil
 	})
 	require.NoError(t, err)
 	err = testDB.Update(func(txn db.Transaction) error {
-		err = mover.Migrate(txn, utils.MAINNET)
+		err = mover.Migrate(context.Background(), txn, utils.MAINNET)
 		require.NoError(t, err)
 		return nil
 	})
 	require.NoError(t, err)
+	require.Equal(t, uint64(3), migrated)
 
 	err = testDB.View(func(txn db.Transaction) error {
 		err = txn.Get(sourceBucket.Key(), func(b []byte) error {
@@ -77,3 +84,64 @@ func TestBucketMover(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestBucketMigratorResumes(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	bucket := db.Bucket(0)
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := byte(0); i < 5; i++ {
+			if err := txn.Set(bucket.Key([]byte{i}), []byte{i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	// newMigrator increments the values of the entries in place, which must not happen twice
+	newMigrator := func() *migration.BucketMigrator {
+		return migration.NewBucketMigrator(bucket, func(txn db.Transaction, key, value []byte, _ utils.Network) error {
+			return txn.Set(key, []byte{value[0] + 1})
+		}).WithBatchSize(2)
+	}
+
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		require.ErrorIs(t, newMigrator().Migrate(context.Background(), txn, utils.MAINNET),
+			migration.ErrCallWithNewTransaction)
+		return nil
+	}))
+
+	// the node restarts, the migration resumes from the checkpoint of the committed batch
+	migrator := newMigrator()
+	var migrated uint64
+	migrator.OnProgress(func(current, _ uint64) {
+		migrated = current
+	})
+	for done := false; !done; {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			err := migrator.Migrate(context.Background(), txn, utils.MAINNET)
+			if errors.Is(err, migration.ErrCallWithNewTransaction) {
+				return nil
+			}
+			done = true
+			return err
+		}))
+	}
+	require.Equal(t, uint64(5), migrated)
+
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		for i := byte(0); i < 5; i++ {
+			require.NoError(t, txn.Get(bucket.Key([]byte{i}), func(b []byte) error {
+				require.Equal(t, []byte{i + 1}, b)
+				return nil
+			}))
+		}
+		// the checkpoint is deleted once the migration is done
+		it, err := txn.NewIterator()
+		require.NoError(t, err)
+		it.Seek(db.MigrationCheckpoints.Key())
+		require.False(t, it.Valid() && bytes.HasPrefix(it.Key(), db.MigrationCheckpoints.Key()))
+		return it.Close()
+	}))
+}
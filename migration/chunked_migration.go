@@ -0,0 +1,250 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+var _ Migration = (*ChunkedMigration)(nil)
+
+// defaultChunkSize is the number of entries a ChunkedMigration migrates in a transaction by default. The more updates
+// are queued on a transaction the more memory it uses, a million entries fit in a transaction on most machines.
+const defaultChunkSize = 1_000_000
+
+// ChunkFunc migrates an entry of a ChunkedMigration
+type ChunkFunc func(txn db.Transaction, key, value []byte, network utils.Network) error
+
+// ChunkedMigration migrates the entries under a list of prefixes in chunks, each of which is committed in its own
+// transaction along with a checkpoint of where the next chunk starts. A migration that was interrupted, by a crash
+// for example, resumes from the last committed chunk instead of scanning its prefixes from the start again, which
+// also makes it safe for migrations whose entries cannot be migrated twice.
+type ChunkedMigration struct {
+	// name identifies the checkpoint of the migration, so it has to be unique
+	name      string
+	prefixes  [][]byte
+	chunkSize uint64
+	// maxPending is the size of the writes a chunk ends at, if the transactions report it
+	maxPending uint64
+	keyFilter  func(key []byte) bool
+	// countTotal is whether the entries to migrate are counted before the first chunk, to report the progress
+	// against their total
+	countTotal bool
+	do         ChunkFunc
+	// verify checks an entry of an interrupted migration, see WithVerifier
+	verify func(key, value []byte, migrated bool) error
+
+	progress ProgressFunc
+}
+
+// checkpoint is where a ChunkedMigration resumes from
+type checkpoint struct {
+	// Prefix is the index of the prefix whose entries are being migrated, from the key Next
+	Prefix int
+	Next   []byte
+	// Migrated is the number of entries migrated by the committed chunks, out of Total, which is 0 when the
+	// entries are not counted
+	Migrated uint64
+	Total    uint64
+}
+
+// NewChunkedMigration returns a ChunkedMigration that migrates the entries under prefixes with do, in the order of
+// prefixes
+func NewChunkedMigration(name string, prefixes [][]byte, do ChunkFunc) *ChunkedMigration {
+	return &ChunkedMigration{
+		name:      name,
+		prefixes:  prefixes,
+		chunkSize: defaultChunkSize,
+		keyFilter: func([]byte) bool { return true },
+		do:        do,
+	}
+}
+
+func (m *ChunkedMigration) WithChunkSize(chunkSize uint64) *ChunkedMigration {
+	m.chunkSize = chunkSize
+	return m
+}
+
+// WithKeyFilter skips the entries whose keys do not pass keyFilter
+func (m *ChunkedMigration) WithKeyFilter(keyFilter func(key []byte) bool) *ChunkedMigration {
+	m.keyFilter = keyFilter
+	return m
+}
+
+// WithCountedTotal counts the entries to migrate before the first chunk, so that the progress is reported against
+// their total
+func (m *ChunkedMigration) WithCountedTotal() *ChunkedMigration {
+	m.countTotal = true
+	return m
+}
+
+// WithVerifier checks the entries the migration left when it is interrupted with verify, which is told whether an
+// entry was migrated by the committed chunks, see Verify
+func (m *ChunkedMigration) WithVerifier(verify func(key, value []byte, migrated bool) error) *ChunkedMigration {
+	m.verify = verify
+	return m
+}
+
+func (m *ChunkedMigration) rewritesBuckets() {}
+
+// SpaceEstimate returns the size of the entries under the prefixes of the migration
+func (m *ChunkedMigration) SpaceEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesSize(targetDB, m.prefixes...)
+}
+
+// KeyEstimate returns the number of entries under the prefixes of the migration, including those it skips
+func (m *ChunkedMigration) KeyEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesKeyCount(targetDB, m.prefixes...)
+}
+
+// Before is a no-op, the state of the migration is its checkpoint in the database.
+func (m *ChunkedMigration) Before() {}
+
+func (m *ChunkedMigration) OnProgress(progress ProgressFunc) {
+	m.progress = progress
+}
+
+// SetOptions sets the chunk size to opts.ChunkSize, if it is set, and ends the chunks early once their writes reach
+// opts.MemoryBudget
+func (m *ChunkedMigration) SetOptions(opts MigrationOptions) {
+	if opts.ChunkSize > 0 {
+		m.chunkSize = opts.ChunkSize
+	}
+	m.maxPending = opts.MemoryBudget
+}
+
+// Migrate migrates the next chunk of entries. It returns ErrCallWithNewTransaction, with the checkpoint of the next
+// chunk set in txn, until the last chunk, after which the checkpoint is deleted. The chunk ends early once ctx is
+// cancelled, so that the entries it migrated are committed.
+func (m *ChunkedMigration) Migrate(ctx context.Context, txn db.Transaction, network utils.Network) error {
+	cp, err := m.checkpoint(txn)
+	if err != nil {
+		return err
+	}
+
+	if cp.Migrated == 0 && cp.Total == 0 && m.countTotal {
+		if cp.Total, err = m.count(txn); err != nil {
+			return err
+		}
+	}
+
+	var migrated uint64
+	for ; cp.Prefix < len(m.prefixes); cp.Prefix, cp.Next = cp.Prefix+1, nil {
+		chunkEnded := false
+		err = db.IteratePrefix(txn, m.prefixes[cp.Prefix], func(key, value []byte) error {
+			if !m.keyFilter(key) {
+				return nil
+			}
+
+			if migrated == m.chunkSize || (migrated > 0 && (ctx.Err() != nil || m.chunkFull(txn))) {
+				cp.Next, chunkEnded = key, true
+				return db.ErrStopIteration
+			}
+
+			if err := m.do(txn, key, value, network); err != nil {
+				return err
+			}
+			migrated++
+			cp.Migrated++
+			m.progress.report(cp.Migrated, cp.Total)
+			return nil
+		}, db.From(cp.Next))
+		if err != nil {
+			return err
+		}
+		if chunkEnded {
+			if err = m.setCheckpoint(txn, cp); err != nil {
+				return err
+			}
+			return ErrCallWithNewTransaction
+		}
+	}
+	return checkpointTable.Delete(txn, m.checkpointKey())
+}
+
+// Verify : see Verifier.Verify. The entries the migration does not skip are checked with the function set by
+// WithVerifier, the entries before its checkpoint are the migrated ones. It is a no-op if the function is not set.
+func (m *ChunkedMigration) Verify(ctx context.Context, txn db.Transaction, _ utils.Network) error {
+	if m.verify == nil {
+		return nil
+	}
+	cp, err := m.checkpoint(txn)
+	if err != nil {
+		return err
+	}
+	for i, prefix := range m.prefixes {
+		err = db.IteratePrefix(txn, prefix, func(key, value []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !m.keyFilter(key) {
+				return nil
+			}
+			migrated := i < cp.Prefix || (i == cp.Prefix && cp.Next != nil && bytes.Compare(key, cp.Next) < 0)
+			if err := m.verify(key, value, migrated); err != nil {
+				return fmt.Errorf("entry %x: %w", key, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkFull returns whether the writes of txn reached the size a chunk ends at
+func (m *ChunkedMigration) chunkFull(txn db.Transaction) bool {
+	if m.maxPending == 0 {
+		return false
+	}
+	sizer, ok := txn.(db.PendingSizer)
+	return ok && sizer.PendingSize() >= m.maxPending
+}
+
+// count returns the number of entries to migrate
+func (m *ChunkedMigration) count(txn db.Transaction) (uint64, error) {
+	var count uint64
+	for _, prefix := range m.prefixes {
+		err := db.IteratePrefix(txn, prefix, func(key, _ []byte) error {
+			if m.keyFilter(key) {
+				count++
+			}
+			return nil
+		}, db.KeysOnly())
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func (m *ChunkedMigration) checkpointKey() []byte {
+	return []byte(m.name)
+}
+
+// checkpoint returns the checkpoint of the migration, which is empty if no chunk was committed yet
+func (m *ChunkedMigration) checkpoint(txn db.Transaction) (*checkpoint, error) {
+	return loadCheckpoint(txn, m.checkpointKey())
+}
+
+func (m *ChunkedMigration) setCheckpoint(txn db.Transaction, cp *checkpoint) error {
+	return storeCheckpoint(txn, m.checkpointKey(), cp)
+}
+
+// loadCheckpoint returns the checkpoint at key in db.MigrationCheckpoints, which is empty if there is none
+func loadCheckpoint(txn db.Transaction, key []byte) (*checkpoint, error) {
+	cp, err := checkpointTable.Get(txn, key)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return new(checkpoint), nil
+	}
+	return cp, err
+}
+
+func storeCheckpoint(txn db.Transaction, key []byte, cp *checkpoint) error {
+	return checkpointTable.Put(txn, key, cp)
+}
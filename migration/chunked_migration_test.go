@@ -0,0 +1,191 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkedMigration(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+
+	buckets := []db.Bucket{db.Bucket(0), db.Bucket(1)}
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for _, bucket := range buckets {
+			for i := byte(0); i < 3; i++ {
+				if err := txn.Set(bucket.Key([]byte{i}), []byte{i}); err != nil {
+					return err
+				}
+			}
+			// the key filter skips the entries without a suffix
+			if err := txn.Set(bucket.Key(), []byte{44}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	errCrash := errors.New("crash")
+	// newMigration increments the values of the entries, it fails after crashAfter of them unless it is negative
+	newMigration := func(crashAfter int) *migration.ChunkedMigration {
+		return migration.NewChunkedMigration("test", [][]byte{buckets[0].Key(), buckets[1].Key()},
+			func(txn db.Transaction, key, value []byte, _ utils.Network) error {
+				if crashAfter == 0 {
+					return errCrash
+				}
+				crashAfter--
+				return txn.Set(key, []byte{value[0] + 1})
+			}).WithChunkSize(2).WithCountedTotal().WithKeyFilter(func(key []byte) bool {
+			return len(key) > 1
+		})
+	}
+	// migrate migrates a chunk, which is committed unless the migration fails
+	migrate := func(m *migration.ChunkedMigration) error {
+		var migrateErr error
+		if err := testDB.Update(func(txn db.Transaction) error {
+			migrateErr = m.Migrate(context.Background(), txn, utils.MAINNET)
+			if errors.Is(migrateErr, migration.ErrCallWithNewTransaction) {
+				return nil
+			}
+			return migrateErr
+		}); err != nil {
+			return err
+		}
+		return migrateErr
+	}
+
+	// the first chunk is committed, and the migration crashes in the second one
+	crashing := newMigration(3)
+	require.ErrorIs(t, migrate(crashing), migration.ErrCallWithNewTransaction)
+	require.ErrorIs(t, migrate(crashing), errCrash)
+
+	// the migration resumes from the second chunk once the node restarts
+	resumed := newMigration(-1)
+	var current, total uint64
+	resumed.OnProgress(func(c, t uint64) {
+		current, total = c, t
+	})
+	for chunks := 0; ; chunks++ {
+		require.Less(t, chunks, 3)
+		err := migrate(resumed)
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, migration.ErrCallWithNewTransaction)
+	}
+	assert.Equal(t, uint64(6), current)
+	assert.Equal(t, uint64(6), total)
+
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		for _, bucket := range buckets {
+			for i := byte(0); i < 3; i++ {
+				require.NoError(t, txn.Get(bucket.Key([]byte{i}), func(value []byte) error {
+					assert.Equal(t, []byte{i + 1}, value, "the entry is migrated exactly once")
+					return nil
+				}))
+			}
+			require.NoError(t, txn.Get(bucket.Key(), func(value []byte) error {
+				assert.Equal(t, []byte{44}, value)
+				return nil
+			}))
+		}
+		err := txn.Get(db.MigrationCheckpoints.Key([]byte("test")), func([]byte) error { return nil })
+		assert.ErrorIs(t, err, db.ErrKeyNotFound, "the checkpoint is deleted once the migration is done")
+		return nil
+	}))
+}
+
+func TestChunkedMigrationCancel(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	bucket := db.Bucket(0)
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := byte(0); i < 4; i++ {
+			if err := txn.Set(bucket.Key([]byte{i}), []byte{i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// the context is cancelled while the first entry is migrated
+	ctx, cancel := context.WithCancel(context.Background())
+	m := migration.NewChunkedMigration("cancel", [][]byte{bucket.Key()},
+		func(txn db.Transaction, key, value []byte, _ utils.Network) error {
+			cancel()
+			return txn.Set(key, []byte{value[0] + 1})
+		})
+	var migrateErr error
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		migrateErr = m.Migrate(ctx, txn, utils.MAINNET)
+		return nil
+	}))
+	require.ErrorIs(t, migrateErr, migration.ErrCallWithNewTransaction, "the chunk ends early")
+
+	// the migration resumes from the entry it stopped at
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		return m.Migrate(context.Background(), txn, utils.MAINNET)
+	}))
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		for i := byte(0); i < 4; i++ {
+			require.NoError(t, txn.Get(bucket.Key([]byte{i}), func(value []byte) error {
+				assert.Equal(t, []byte{i + 1}, value)
+				return nil
+			}))
+		}
+		return nil
+	}))
+}
+
+func TestChunkedMigrationOptions(t *testing.T) {
+	bucket := db.Bucket(0)
+	// chunks returns the number of transactions m takes to migrate 10 entries of 100 bytes
+	chunks := func(t *testing.T, opts migration.MigrationOptions) int {
+		testDB := pebble.NewMemTest()
+		t.Cleanup(func() {
+			require.NoError(t, testDB.Close())
+		})
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			for i := byte(0); i < 10; i++ {
+				if err := txn.Set(bucket.Key([]byte{i}), make([]byte, 100)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+		m := migration.NewChunkedMigration("options", [][]byte{bucket.Key()},
+			func(txn db.Transaction, key, value []byte, _ utils.Network) error {
+				return txn.Set(key, append(value, 1))
+			})
+		m.SetOptions(opts)
+		for chunks := 1; ; chunks++ {
+			var migrateErr error
+			require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+				migrateErr = m.Migrate(context.Background(), txn, utils.MAINNET)
+				return nil
+			}))
+			if migrateErr == nil {
+				return chunks
+			}
+			require.ErrorIs(t, migrateErr, migration.ErrCallWithNewTransaction)
+		}
+	}
+
+	assert.Equal(t, 1, chunks(t, migration.MigrationOptions{}))
+	assert.Equal(t, 4, chunks(t, migration.MigrationOptions{ChunkSize: 3}))
+	// the writes of every entry take more than 100 bytes
+	assert.Equal(t, 5, chunks(t, migration.MigrationOptions{MemoryBudget: 200}))
+}
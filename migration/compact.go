@@ -0,0 +1,66 @@
+package migration
+
+import (
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+// compacter is implemented by the migrations that overwrite or delete many keys. The database keeps the old versions
+// of the keys until its background compactions reach them, which can double its disk usage after such a migration,
+// so the prefixes of their keys are compacted once they are applied or reverted.
+type compacter interface {
+	compactedPrefixes() [][]byte
+}
+
+func (m rewritingMigration) compactedPrefixes() [][]byte {
+	return m.prefixes
+}
+
+func (m *changeTrieNodeEncoding) compactedPrefixes() [][]byte {
+	return trieNodePrefixes()
+}
+
+func (m *purgeMigration) compactedPrefixes() [][]byte {
+	prefixes := make([][]byte, 0, len(m.buckets))
+	for _, bucket := range m.buckets {
+		prefixes = append(prefixes, bucket.Key())
+	}
+	return prefixes
+}
+
+func (m *ChunkedMigration) compactedPrefixes() [][]byte {
+	return m.prefixes
+}
+
+func (m *BucketMigrator) compactedPrefixes() [][]byte {
+	return [][]byte{m.target.Key()}
+}
+
+// compactedPrefixesOf returns the prefixes that are compacted once m is applied or reverted, if it is a compacter
+func compactedPrefixesOf(m Migration) [][]byte {
+	if c, ok := find[compacter](m); ok {
+		return c.compactedPrefixes()
+	}
+	return nil
+}
+
+// compact compacts the prefixes of m in targetDB. The migration is committed already, so a compaction that fails is
+// only logged and left to the background compactions of the database.
+func compact(targetDB db.DB, m Migration, version uint64, log utils.SimpleLogger) {
+	prefixes := compactedPrefixesOf(m)
+	if len(prefixes) == 0 {
+		return
+	}
+	start := time.Now()
+	log.Infow("Compacting the keys rewritten by the migration", "version", version)
+	for _, prefix := range prefixes {
+		if err := db.CompactPrefix(targetDB, prefix); err != nil {
+			log.Warnw("Failed to compact the keys rewritten by the migration", "version", version, "err", err)
+			return
+		}
+	}
+	log.Infow("Compacted the keys rewritten by the migration", "version", version,
+		"elapsed", time.Since(start).Round(time.Second))
+}
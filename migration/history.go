@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+// HistoryEntry is a migration that was applied to a database, or reverted from it. A migration that was interrupted
+// has an entry without Finished, and so does a migration that is applied in the background until it completes.
+type HistoryEntry struct {
+	Version uint64 `json:"version"`
+	Name    string `json:"name"`
+	// JunoVersion is the version of Juno that applied or reverted the migration, see MigrationOptions.JunoVersion
+	JunoVersion string     `json:"juno_version"`
+	Started     time.Time  `json:"started"`
+	Finished    *time.Time `json:"finished,omitempty"`
+	Reverted    bool       `json:"reverted,omitempty"`
+	Background  bool       `json:"background,omitempty"`
+}
+
+// History returns the entries of the migrations applied to and reverted from targetDB, oldest first, which tell the
+// upgrades and downgrades a database went through when its schema version does not. The migrations applied before
+// the history was recorded have no entries.
+func History(targetDB db.DB) ([]HistoryEntry, error) {
+	var history []HistoryEntry
+	return history, targetDB.View(func(txn db.Transaction) error {
+		var err error
+		history, err = readHistory(txn)
+		return err
+	})
+}
+
+// readHistory returns the entries of the history, whose keys are their indexes since they are never deleted
+func readHistory(txn db.Transaction) ([]HistoryEntry, error) {
+	history := []HistoryEntry{}
+	err := historyTable.Iterate(txn, func(_ uint64, entry *HistoryEntry) error {
+		history = append(history, *entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// historyEntry returns the entry of the migration to version that starts now
+func historyEntry(version uint64, opts MigrationOptions) *HistoryEntry {
+	return &HistoryEntry{
+		Version:     version,
+		Name:        migrationNames[version-1],
+		JunoVersion: opts.JunoVersion,
+		Started:     time.Now().UTC(),
+	}
+}
+
+// startHistory appends entry to the history of targetDB in a transaction of its own, so that the entry of a
+// migration that is interrupted is kept
+func startHistory(targetDB db.DB, entry *HistoryEntry) (uint64, error) {
+	var index uint64
+	return index, targetDB.Update(func(txn db.Transaction) error {
+		var err error
+		index, err = appendHistory(txn, entry)
+		return err
+	})
+}
+
+// appendHistory appends entry to the history and returns its index, so that it is finished with finishHistory
+func appendHistory(txn db.Transaction, entry *HistoryEntry) (uint64, error) {
+	history, err := readHistory(txn)
+	if err != nil {
+		return 0, err
+	}
+	index := uint64(len(history))
+	return index, historyTable.Put(txn, index, entry)
+}
+
+// finishHistory records that the migration of the entry at index finished
+func finishHistory(txn db.Transaction, index uint64) error {
+	entry, err := historyTable.Get(txn, index)
+	if err != nil {
+		return err
+	}
+	finished := time.Now().UTC()
+	entry.Finished = &finished
+	return historyTable.Put(txn, index, entry)
+}
+
+// finishBackgroundHistory records that the migration to version, which was applied in the background, finished
+func finishBackgroundHistory(txn db.Transaction, version uint64) error {
+	history, err := readHistory(txn)
+	if err != nil {
+		return err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if entry := history[i]; entry.Version == version && entry.Background && entry.Finished == nil {
+			return finishHistory(txn, uint64(i))
+		}
+	}
+	// the migration was deferred before the history was recorded
+	return nil
+}
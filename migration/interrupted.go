@@ -0,0 +1,143 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+// ErrInterrupted is returned by MigrateTo when a migration was interrupted and MigrationOptions.OnInterrupted does
+// not let it resume
+var ErrInterrupted = errors.New("a database migration was interrupted")
+
+// InterruptedPolicy is what MigrateTo does with a migration that a previous run started and did not finish, because
+// the node crashed or was stopped halfway through it. The history entry of a migration is written before it starts
+// and finished along with the schema version, so an entry without Finished marks an interrupted migration.
+type InterruptedPolicy uint8
+
+const (
+	// Resume applies the interrupted migration again, the migrations that span several transactions resume from
+	// the last one they committed
+	Resume InterruptedPolicy = iota
+	// Verify checks the data the interrupted migration left with its Verifier before it is applied again, and fails
+	// with an InterruptedError if the data is inconsistent. The migrations that are not Verifiers are resumed.
+	Verify
+	// Fail fails with an InterruptedError, so that the operator chooses between resuming the migration and
+	// restoring a backup
+	Fail
+)
+
+func (p InterruptedPolicy) String() string {
+	switch p {
+	case Resume:
+		return "resume"
+	case Verify:
+		return "verify"
+	case Fail:
+		return "fail"
+	default:
+		return fmt.Sprintf("policy %d", uint8(p))
+	}
+}
+
+// ParseInterruptedPolicy returns the InterruptedPolicy named s
+func ParseInterruptedPolicy(s string) (InterruptedPolicy, error) {
+	for _, p := range []InterruptedPolicy{Resume, Verify, Fail} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown policy for the interrupted migrations %q, expected resume, verify or fail", s)
+}
+
+// Verifier is implemented by the migrations that can check the data an interrupted run of theirs left
+type Verifier interface {
+	// Verify returns an error if the data the interrupted migration left is inconsistent, for example if an entry
+	// it records as migrated is not in the new format
+	Verify(context.Context, db.Transaction, utils.Network) error
+}
+
+// InterruptedError is the ErrInterrupted of the migration to Version, whose interrupted run started at Started.
+// Err is why the data it left failed its verification, if it was verified.
+type InterruptedError struct {
+	Version uint64
+	Name    string
+	Started time.Time
+	Err     error
+}
+
+func (e *InterruptedError) Error() string {
+	msg := fmt.Sprintf("%s: the migration to schema version %d (%s) started at %s did not finish", ErrInterrupted,
+		e.Version, e.Name, e.Started.Format(time.RFC3339))
+	if e.Err != nil {
+		msg += fmt.Sprintf(" and the data it left is inconsistent: %v", e.Err)
+	}
+	return msg
+}
+
+func (e *InterruptedError) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// interruptedRun returns the history entry of the run of the migration to version that was interrupted, or nil if
+// its last run finished or it was never started
+func interruptedRun(targetDB db.DB, version uint64) (*HistoryEntry, error) {
+	history, err := History(targetDB)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		if entry.Version != version {
+			continue
+		}
+		if entry.Reverted || entry.Background || entry.Finished != nil {
+			return nil, nil
+		}
+		return &entry, nil
+	}
+	return nil, nil
+}
+
+// checkInterrupted reports the migration to version if its last run was interrupted, and verifies the data it left
+// or fails according to opts.OnInterrupted
+func checkInterrupted(ctx context.Context, targetDB db.DB, m Migration, version uint64, network utils.Network,
+	log utils.SimpleLogger, opts MigrationOptions,
+) error {
+	entry, err := interruptedRun(targetDB, version)
+	if err != nil || entry == nil {
+		return err
+	}
+	log.Warnw("The database migration was interrupted", "version", version, "name", entry.Name,
+		"started", entry.Started, "junoVersion", entry.JunoVersion, "policy", opts.OnInterrupted)
+
+	interruptedErr := &InterruptedError{Version: version, Name: entry.Name, Started: entry.Started}
+	switch opts.OnInterrupted {
+	case Fail:
+		return interruptedErr
+	case Verify:
+		verifier, ok := find[Verifier](m)
+		if !ok {
+			log.Infow("The database migration cannot be verified, resuming it", "version", version)
+			return nil
+		}
+		if err = targetDB.View(func(txn db.Transaction) error {
+			return verifier.Verify(ctx, txn, network)
+		}); ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			interruptedErr.Err = err
+			return interruptedErr
+		}
+		log.Infow("Verified the data of the interrupted database migration, resuming it", "version", version)
+	}
+	return nil
+}
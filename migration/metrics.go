@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"sync/atomic"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The metrics of the migrations applied by MigrateTo, so that the nodes stuck in a migration can be alerted on
+var (
+	migratingVersion = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "migration",
+		Name:      "in_progress",
+		Help:      "The schema version the migration in progress migrates the database to, 0 if none is",
+	})
+	migratedUnits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "migration",
+		Name:      "migrated",
+		Help:      "The units of work the migrations reported, such as the blocks or the trie nodes they migrated",
+	})
+	migrationCommits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "migration",
+		Name:      "commits",
+		Help:      "The number of transactions the migrations committed",
+	})
+	migrationFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "migration",
+		Name:      "failures",
+		Help:      "The number of migrations that failed",
+	})
+)
+
+// RegisterMetrics registers the metrics of the migrations, along with the gauges of the schema version of targetDB
+// and of its pending migrations, which are read from targetDB when they are collected
+func RegisterMetrics(targetDB db.DB) {
+	// the gauges are -1 when the schema version cannot be read. Once it is the latest version, which only
+	// RevertTo lowers while the node is stopped, it is not read again on every scrape.
+	var upToDate atomic.Bool
+	schemaVersion := func() float64 {
+		if upToDate.Load() {
+			return float64(LatestSchemaVersion())
+		}
+		version, err := SchemaVersion(targetDB)
+		if err != nil {
+			return -1
+		}
+		upToDate.Store(version == LatestSchemaVersion())
+		return float64(version)
+	}
+	metrics.MustRegister(migratingVersion, migratedUnits, migrationCommits, migrationFailures,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "migration",
+			Name:      "schema_version",
+			Help:      "The schema version of the database",
+		}, schemaVersion),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "migration",
+			Name:      "latest_schema_version",
+			Help:      "The schema version of the databases all the migrations of this version of Juno were applied to",
+		}, func() float64 {
+			return float64(LatestSchemaVersion())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "migration",
+			Name:      "pending",
+			Help:      "The number of migrations that were not applied to the database yet",
+		}, func() float64 {
+			version := schemaVersion()
+			if version < 0 {
+				return -1
+			}
+			if pending := float64(LatestSchemaVersion()) - version; pending > 0 {
+				return pending
+			}
+			return 0
+		}),
+	)
+}
@@ -2,10 +2,14 @@ package migration
 
 import (
 	"bytes"
-	"encoding/binary"
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
-	"sync"
+	"strings"
+	"time"
 
 	"github.com/NethermindEth/juno/blockchain"
 	"github.com/NethermindEth/juno/core"
@@ -20,32 +24,362 @@ import (
 
 type Migration interface {
 	Before()
-	Migrate(db.Transaction, utils.Network) error
+	// OnProgress sets the function the migration reports its progress to
+	OnProgress(ProgressFunc)
+	// SetOptions sets the options the migration is tuned with, it is called after Before
+	SetOptions(MigrationOptions)
+	// Migrate migrates targetDB with txn. Migrations that span several transactions stop at the end of the
+	// transaction once ctx is cancelled, those that fit in one transaction may stop at any point since the
+	// transaction is then discarded.
+	Migrate(context.Context, db.Transaction, utils.Network) error
+}
+
+// ProgressFunc is called by migrations with the units of work they did, such as the blocks or the trie nodes they
+// migrated, out of the units they have in total. total is 0 when it is not known.
+type ProgressFunc func(current, total uint64)
+
+// report calls f, if it is set
+func (f ProgressFunc) report(current, total uint64) {
+	if f != nil {
+		f(current, total)
+	}
+}
+
+// withEstimate returns f with estimate as the total of the migrations that do not report one, the total is at least
+// the units they did. f is returned if estimate is 0.
+func (f ProgressFunc) withEstimate(estimate uint64) ProgressFunc {
+	if estimate == 0 {
+		return f
+	}
+	return func(current, total uint64) {
+		if total == 0 {
+			total = estimate
+			if current > total {
+				total = current
+			}
+		}
+		f(current, total)
+	}
+}
+
+// MigrationOptions tune the migrations to the machine they run on, the zero value suits most machines
+type MigrationOptions struct {
+	// BackupDir is the directory the database is backed up to before the first migration that rewrites existing
+	// buckets, the path of the backup is recorded in the database. The database is not backed up if it is empty.
+	BackupDir string
+	// OnProgress, if set, is passed the progress of the migrations as they report it
+	OnProgress func(Progress)
+	// Workers is the number of goroutines the migrations process blocks with in parallel, GOMAXPROCS if 0
+	Workers int
+	// BatchSize is the number of blocks the migrations hold in memory at once, defaultBatchSize if 0
+	BatchSize uint64
+	// DataDir is the directory of the database, whose volume must have the free disk space the migrations are
+	// estimated to need. The free space is not checked if it is empty.
+	DataDir string
+	// MemoryBudget is the estimated memory in bytes the blocks the migrations hold at once may take, the batches
+	// of blocks end early once they reach it. It also bounds the writes a chunk of a chunked migration holds until
+	// it is committed. The memory is not bounded if it is 0.
+	MemoryBudget uint64
+	// ChunkSize is the number of entries the chunked migrations, such as the re-encoding of the trie nodes, migrate
+	// in a transaction, defaultChunkSize if 0
+	ChunkSize uint64
+	// Background defers the migrations that only rewrite the data of each block to Background, which applies them
+	// while the node serves, instead of applying them before the node starts
+	Background bool
+	// Refuse fails with a SchemaBehindError instead of applying the migrations if the database is behind the
+	// target schema version, so that a database that is shared, such as a snapshot that replicas are started from,
+	// is never migrated implicitly
+	Refuse bool
+	// JunoVersion is the version of Juno that applies the migrations, which the history of the database records
+	JunoVersion string
+	// OnInterrupted is what is done with a migration that was interrupted, see InterruptedPolicy
+	OnInterrupted InterruptedPolicy
+
+	// log is the logger of MigrateTo, which the migrations log their summaries with
+	log utils.SimpleLogger
+}
+
+// defaultBatchSize is the number of blocks the migrations hold in memory at once by default
+const defaultBatchSize = 1024
+
+func (o MigrationOptions) workers() int {
+	if o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+func (o MigrationOptions) batchSize() uint64 {
+	if o.BatchSize == 0 {
+		return defaultBatchSize
+	}
+	return o.BatchSize
+}
+
+// Reverter is implemented by the migrations that can be reverted, so that a database can be used again by the
+// versions of Juno that precede them without syncing it again
+type Reverter interface {
+	Revert(context.Context, db.Transaction, utils.Network) error
+}
+
+// Hooks is implemented by the migrations that act once MigrateTo applied them or once they failed, for example to
+// release the state they hold or to log a summary of what they migrated
+type Hooks interface {
+	// After is called with the last transaction of the migration once Migrate is done with it, its writes are
+	// committed along with the schema version
+	After(db.Transaction) error
+	// OnError is called with the error the migration failed with, which is the error of the context if it was
+	// cancelled. The migration resumes from its last committed transaction when it is applied again.
+	OnError(error)
+}
+
+// reversibleMigration is a migration that is reverted by revert
+type reversibleMigration struct {
+	Migration
+	revert func(db.Transaction, utils.Network) error
+}
+
+func reversible(m Migration, revert func(db.Transaction, utils.Network) error) reversibleMigration {
+	return reversibleMigration{Migration: m, revert: revert}
+}
+
+func (m reversibleMigration) Revert(_ context.Context, txn db.Transaction, network utils.Network) error {
+	return m.revert(txn, network)
+}
+
+// rewriter is implemented by the migrations that rewrite the entries of existing buckets. A failure halfway
+// through leaves them partially rewritten, so the database is backed up before them.
+type rewriter interface {
+	rewritesBuckets()
+}
+
+// rewritingMigration is a migration that rewrites the entries under prefixes
+type rewritingMigration struct {
+	Migration
+	prefixes [][]byte
+}
+
+func rewriting(m Migration, buckets ...db.Bucket) rewritingMigration {
+	return rewritingMigration{Migration: m, prefixes: bucketPrefixes(buckets)}
+}
+
+func bucketPrefixes(buckets []db.Bucket) [][]byte {
+	prefixes := make([][]byte, 0, len(buckets))
+	for _, bucket := range buckets {
+		prefixes = append(prefixes, bucket.Key())
+	}
+	return prefixes
+}
+
+func (m rewritingMigration) rewritesBuckets() {}
+
+// SpaceEstimate returns the size of the entries the migration rewrites, whose old versions take space until they
+// are compacted away
+func (m rewritingMigration) SpaceEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesSize(targetDB, m.prefixes...)
+}
+
+// KeyEstimate returns the number of the entries the migration rewrites
+func (m rewritingMigration) KeyEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesKeyCount(targetDB, m.prefixes...)
+}
+
+// inner returns the migration m wraps if it is one of the wrappers of this package, which do not promote the
+// optional interfaces of the migrations they wrap
+func inner(m Migration) (Migration, bool) {
+	switch wrapper := m.(type) {
+	case backgroundMigration:
+		return wrapper.Migration, true
+	case rewritingMigration:
+		return wrapper.Migration, true
+	case reversibleMigration:
+		return wrapper.Migration, true
+	case batchedMigration:
+		return wrapper.Migration, true
+	default:
+		return nil, false
+	}
+}
+
+// find returns the first of m and the migrations it wraps that implements T
+func find[T any](m Migration) (T, bool) {
+	for {
+		if t, ok := m.(T); ok {
+			return t, true
+		}
+		var ok bool
+		if m, ok = inner(m); !ok {
+			var zero T
+			return zero, false
+		}
+	}
 }
 
 type MigrationFunc func(db.Transaction, utils.Network) error
 
-// Migrate returns f(txn).
-func (f MigrationFunc) Migrate(txn db.Transaction, network utils.Network) error {
+// Migrate returns f(txn), MigrationFuncs are short so they are not cancelled.
+func (f MigrationFunc) Migrate(_ context.Context, txn db.Transaction, network utils.Network) error {
 	return f(txn, network)
 }
 
 // Before is a no-op.
 func (f MigrationFunc) Before() {}
 
+// OnProgress is a no-op, MigrationFuncs do not report their progress.
+func (f MigrationFunc) OnProgress(ProgressFunc) {}
+
+// SetOptions is a no-op, MigrationFuncs are not tuned.
+func (f MigrationFunc) SetOptions(MigrationOptions) {}
+
+// progressMigration is a migration function that reports its progress
+type progressMigration struct {
+	migrate  progressMigrationFunc
+	progress ProgressFunc
+	opts     MigrationOptions
+}
+
+type progressMigrationFunc func(context.Context, db.Transaction, utils.Network, ProgressFunc, MigrationOptions) error
+
+func withProgress(migrate progressMigrationFunc) *progressMigration {
+	return &progressMigration{migrate: migrate}
+}
+
+// Before is a no-op.
+func (m *progressMigration) Before() {}
+
+func (m *progressMigration) OnProgress(progress ProgressFunc) {
+	m.progress = progress
+}
+
+func (m *progressMigration) SetOptions(opts MigrationOptions) {
+	m.opts = opts
+}
+
+func (m *progressMigration) Migrate(ctx context.Context, txn db.Transaction, network utils.Network) error {
+	return m.migrate(ctx, txn, network, m.progress, m.opts)
+}
+
+// Progress is the progress of the migration to a schema version
+type Progress struct {
+	// Version is the schema version the database has once the migration is done
+	Version uint64
+	// Name is the name of the migration
+	Name string
+	// Current and Total are the units of work the migration did and has in total, Total is 0 when it is not known.
+	// Total is the estimated number of entries the migration processes if it does not count its units.
+	Current uint64
+	Total   uint64
+	Elapsed time.Duration
+}
+
+// Percent returns the percentage of the work the migration did, or 0 when its total is not known
+func (p Progress) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return 100 * float64(p.Current) / float64(p.Total)
+}
+
+// ETA returns the time the migration is estimated to take until it is done from its rate so far, or 0 when it
+// cannot be estimated
+func (p Progress) ETA() time.Duration {
+	if p.Total == 0 || p.Current == 0 || p.Current >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Elapsed) * float64(p.Total-p.Current) / float64(p.Current))
+}
+
 // migrations contains a set of migrations that can be applied to a database.
-// After making breaking changes to the DB layout, add new migrations to this list.
+// After making breaking changes to the DB layout, add new migrations to this list. Forks append theirs with Register.
 var migrations = []Migration{
 	MigrationFunc(migration0000),
-	MigrationFunc(relocateContractStorageRootKeys),
-	MigrationFunc(recalculateBloomFilters),
+	rewriting(MigrationFunc(relocateContractStorageRootKeys), db.Unused),
+	// the bloom filters are not recalculated in the background, the event filters would skip the blocks whose
+	// bloom filters were not recalculated yet
+	rewriting(batched(withProgress(recalculateBloomFilters)), db.BlockHeadersByNumber),
 	new(changeTrieNodeEncoding),
-	MigrationFunc(calculateBlockCommitments),
+	inBackground(reversible(withProgress(calculateBlockCommitments), deleteBlockCommitments), calculateCommitments),
+	purgeDeprecatedBuckets(),
 }
 
-var ErrCallWithNewTransaction = errors.New("call with new transaction")
+// migrationNames are the names of the migrations, which the history of a database records
+var migrationNames = []string{
+	"migration0000",
+	"relocateContractStorageRootKeys",
+	"recalculateBloomFilters",
+	"changeTrieNodeEncoding",
+	"calculateBlockCommitments",
+	"purgeDeprecatedBuckets",
+}
+
+// progressLogInterval is how often the progress of a migration is logged
+const progressLogInterval = 30 * time.Second
+
+var (
+	// ErrCallWithNewTransaction is returned by a migration to be called again with a new transaction, once the one
+	// it was called with is committed. The migrations whose writes are idempotent are applied with a BatchWriter
+	// instead, see batchWriter.
+	ErrCallWithNewTransaction = errors.New("call with new transaction")
+	// ErrSchemaTooNew is returned when the database was migrated by a newer version of Juno, which has to revert
+	// the migrations that this version does not know
+	ErrSchemaTooNew = errors.New("the database was migrated by a newer version of Juno")
+	// ErrIrreversible is returned when the migrations to revert include one that cannot be reverted
+	ErrIrreversible = errors.New("the migration cannot be reverted")
+)
 
-func MigrateIfNeeded(targetDB db.DB, network utils.Network) error {
+// ErrSchemaBehind is returned when the database is behind the target schema version and the migrations are refused
+var ErrSchemaBehind = errors.New("the database has pending migrations, which are refused")
+
+// PendingMigration is a migration that was not applied to the database yet
+type PendingMigration struct {
+	Version uint64
+	Name    string
+}
+
+// pendingMigrations returns the migrations from the schema version to target
+func pendingMigrations(version, target uint64) []PendingMigration {
+	pending := make([]PendingMigration, 0, target-version)
+	for v := version + 1; v <= target; v++ {
+		pending = append(pending, PendingMigration{Version: v, Name: migrationNames[v-1]})
+	}
+	return pending
+}
+
+// SchemaBehindError is the ErrSchemaBehind of a database at SchemaVersion, Pending are the migrations up to the
+// target schema version in the order they are applied
+type SchemaBehindError struct {
+	SchemaVersion uint64
+	Pending       []PendingMigration
+}
+
+func (e *SchemaBehindError) Error() string {
+	pending := make([]string, 0, len(e.Pending))
+	for _, m := range e.Pending {
+		pending = append(pending, fmt.Sprintf("%d (%s)", m.Version, m.Name))
+	}
+	return fmt.Sprintf("%s: schema version %d has to be migrated to %s", ErrSchemaBehind, e.SchemaVersion,
+		strings.Join(pending, ", "))
+}
+
+func (e *SchemaBehindError) Is(target error) bool {
+	return target == ErrSchemaBehind
+}
+
+// MigrateIfNeeded applies the migrations that were not applied to targetDB yet. Once ctx is cancelled, no more
+// migrations are started and the one in progress stops at the end of its current transaction, the transactions it
+// committed are kept so that it resumes from them when it is applied again.
+// The migrations are tuned with opts, and their progress is logged.
+func MigrateIfNeeded(ctx context.Context, targetDB db.DB, network utils.Network, log utils.SimpleLogger,
+	opts MigrationOptions,
+) error {
+	return MigrateTo(ctx, targetDB, network, LatestSchemaVersion(), log, opts)
+}
+
+// MigrateTo applies the migrations up to the schema version target that were not applied to targetDB yet, like
+// MigrateIfNeeded does for all of them. Migrations are not reverted if targetDB is newer than target.
+func MigrateTo(ctx context.Context, targetDB db.DB, network utils.Network, target uint64, log utils.SimpleLogger,
+	opts MigrationOptions,
+) error {
 	/*
 		Schema version of the targetDB determines which set of migrations need to be applied to the database.
 		After a migration is successfully executed, which may update the database, the schema version is incremented
@@ -66,45 +400,287 @@ func MigrateIfNeeded(targetDB db.DB, network utils.Network) error {
 	if err != nil {
 		return err
 	}
+	if latest := LatestSchemaVersion(); version > latest {
+		// the migrations this version does not know can only be reverted by the version that applied them
+		return fmt.Errorf("%w: schema version %d is newer than the latest version %d, revert it with "+
+			"`juno db revert --to %d` of the newer version", ErrSchemaTooNew, version, latest, latest)
+	} else if target > latest {
+		return fmt.Errorf("the target schema version %d is newer than the latest version %d", target, latest)
+	}
+	if err = targetDB.View(func(txn db.Transaction) error {
+		return checkHistory(txn, version)
+	}); err != nil {
+		return err
+	}
+	if version > target {
+		return fmt.Errorf("schema version %d is newer than the target version %d, revert it with "+
+			"`juno db revert --to %d`", version, target, target)
+	} else if version == target {
+		// an up to date database is only read for its schema version
+		return nil
+	}
+	if opts.Refuse {
+		return &SchemaBehindError{SchemaVersion: version, Pending: pendingMigrations(version, target)}
+	}
+
+	if opts.DataDir != "" {
+		if err = checkSpace(targetDB, opts.DataDir, foreground(migrations[version:target], opts)); err != nil {
+			return err
+		}
+	}
 
-	for i := version; i < uint64(len(migrations)); i++ {
+	var backupPath string
+	for i := version; i < target; i++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
 		migration := migrations[i]
+		if _, ok := migration.(backgroundMigration); ok && opts.Background {
+			log.Infow("Deferring database migration to the background", "version", i+1, "total", len(migrations))
+			if err = deferMigration(targetDB, i+1, opts); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := unwrap(migration).(rewriter); ok && opts.BackupDir != "" && backupPath == "" && version > 0 {
+			if backupPath, err = backup(targetDB, opts.BackupDir, i, log); err != nil {
+				return err
+			}
+		}
+
+		keys, size, err := estimate(targetDB, unwrap(migration))
+		if err != nil {
+			return fmt.Errorf("estimate the entries of the migration to schema version %d: %w", i+1, err)
+		}
+		log.Infow("Applying database migration", "version", i+1, "total", len(migrations), "estimatedEntries", keys,
+			"estimatedMiB", size>>20)
 		migration.Before()
-		for {
-			var migrationErr error
-			if dbErr := targetDB.Update(func(txn db.Transaction) error {
-				migrationErr = migration.Migrate(txn, network)
-				if migrationErr != nil {
-					if errors.Is(migrationErr, ErrCallWithNewTransaction) {
-						return nil // Run the migration again with a new transaction.
-					}
-					return migrationErr
+		migration.OnProgress(progressReporter(i+1, log, opts.OnProgress).withEstimate(keys))
+		opts.log = log
+		migration.SetOptions(opts)
+		if err = checkInterrupted(ctx, targetDB, migration, i+1, network, log, opts); err != nil {
+			return err
+		}
+		historyIndex, err := startHistory(targetDB, historyEntry(i+1, opts))
+		if err != nil {
+			return err
+		}
+		step := migration.Migrate
+		hooks, hasHooks := find[Hooks](migration)
+		if hasHooks {
+			step = withAfter(step, hooks)
+		}
+		migratingVersion.Set(float64(i + 1))
+		err = apply(ctx, targetDB, step, network, i+1, historyIndex, batchWriteSize(migration, opts))
+		migratingVersion.Set(0)
+		if err != nil {
+			if hasHooks {
+				hooks.OnError(err)
+			}
+			if !errors.Is(err, context.Canceled) {
+				migrationFailures.Inc()
+			}
+			if backupPath != "" {
+				return fmt.Errorf("%w (the database was backed up to %s before the migrations)", err, backupPath)
+			}
+			return err
+		}
+		compact(targetDB, migration, i+1, log)
+	}
+
+	return nil
+}
+
+// withAfter returns a step that calls the After hook of hooks once migrate is done, with its last transaction
+func withAfter(migrate func(context.Context, db.Transaction, utils.Network) error, hooks Hooks,
+) func(context.Context, db.Transaction, utils.Network) error {
+	return func(ctx context.Context, txn db.Transaction, network utils.Network) error {
+		if err := migrate(ctx, txn, network); err != nil {
+			return err
+		}
+		return hooks.After(txn)
+	}
+}
+
+// backup backs targetDB, at schema version version, up to a new directory in backupDir and records its path
+func backup(targetDB db.DB, backupDir string, version uint64, log utils.SimpleLogger) (string, error) {
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(backupDir, fmt.Sprintf("schema-%d-%s", version, time.Now().UTC().Format("20060102T150405Z")))
+	log.Infow("Backing up the database before migrating it", "version", version, "path", path)
+	if err := targetDB.Backup(path); err != nil {
+		return "", fmt.Errorf("back up the database to %s: %w", path, err)
+	}
+	return path, targetDB.Update(func(txn db.Transaction) error {
+		return backupTable.Put(txn, version, []byte(path))
+	})
+}
+
+// Backups returns the paths of the backups taken by MigrateIfNeeded by the schema version they were taken at
+func Backups(targetDB db.DB) (map[uint64]string, error) {
+	backups := make(map[uint64]string)
+	return backups, targetDB.View(func(txn db.Transaction) error {
+		return backupTable.Iterate(txn, func(version uint64, path []byte) error {
+			backups[version] = string(path)
+			return nil
+		})
+	})
+}
+
+// RevertTo reverts the migrations applied to targetDB after the schema version target, newest first, so that the
+// versions of Juno whose latest schema version is target can use it. Nothing is reverted if one of the migrations
+// cannot be reverted. Like MigrateIfNeeded, no more migrations are reverted once ctx is cancelled, and the reverts
+// are tuned with opts.
+func RevertTo(ctx context.Context, targetDB db.DB, network utils.Network, target uint64,
+	log utils.SimpleLogger, opts MigrationOptions,
+) error {
+	version, err := SchemaVersion(targetDB)
+	if err != nil {
+		return err
+	}
+	if latest := LatestSchemaVersion(); version > latest {
+		return fmt.Errorf("%w: schema version %d is newer than the latest version %d", ErrSchemaTooNew, version, latest)
+	}
+	if target > version {
+		return fmt.Errorf("schema version %d is older than the target version %d, the migrations are applied "+
+			"when the node starts", version, target)
+	}
+	for i := version; i > target; i-- {
+		if _, ok := unwrap(migrations[i-1]).(Reverter); !ok {
+			return fmt.Errorf("%w: migration to schema version %d", ErrIrreversible, i)
+		}
+	}
+
+	for i := version; i > target; i-- {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		log.Infow("Reverting database migration", "version", i, "target", target)
+		migration := migrations[i-1]
+		migration.Before()
+		migration.OnProgress(progressReporter(i, log, opts.OnProgress))
+		entry := historyEntry(i, opts)
+		entry.Reverted = true
+		historyIndex, err := startHistory(targetDB, entry)
+		if err != nil {
+			return err
+		}
+		if err = apply(ctx, targetDB, unwrap(migration).(Reverter).Revert, network, i-1, historyIndex, 0); err != nil {
+			return err
+		}
+		compact(targetDB, migration, i, log)
+		if _, ok := migration.(backgroundMigration); ok {
+			// the migration is no longer applied in the background if it was deferred
+			if err = targetDB.Update(func(txn db.Transaction) error {
+				return backgroundStatusTable.Delete(txn, i)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply runs step until it does not fail with ErrCallWithNewTransaction, each time with a new transaction, and
+// sets the schema version of targetDB to version and finishes the history entry at historyIndex along with the last
+// transaction. The transactions are BatchWriters that commit their writes once they reach batchSize, unless it is 0.
+// It returns the error of ctx once it is cancelled, between the transactions.
+func apply(ctx context.Context, targetDB db.DB, step func(context.Context, db.Transaction, utils.Network) error,
+	network utils.Network, version uint64, historyIndex, batchSize uint64,
+) error {
+	update := targetDB.Update
+	if batchSize > 0 {
+		update = func(fn func(db.Transaction) error) error {
+			writer := NewBatchWriter(targetDB, batchSize)
+			if err := fn(writer); err != nil {
+				return db.CloseAndWrapOnError(writer.Discard, err)
+			}
+			return writer.Commit()
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var stepErr error
+		if dbErr := update(func(txn db.Transaction) error {
+			stepErr = step(ctx, txn, network)
+			if stepErr != nil {
+				if errors.Is(stepErr, ErrCallWithNewTransaction) {
+					return nil // Run the migration again with a new transaction.
 				}
+				return stepErr
+			}
 
-				// Migration successful. Bump the version.
-				var versionBytes [8]byte
-				binary.BigEndian.PutUint64(versionBytes[:], i+1)
-				return txn.Set(db.SchemaVersion.Key(), versionBytes[:])
-			}); dbErr != nil {
-				return dbErr
-			} else if migrationErr == nil {
-				break
-			} else if !errors.Is(migrationErr, ErrCallWithNewTransaction) {
-				return migrationErr
+			// Migration successful. Set the version.
+			if err := schemaVersionValue.Put(txn, version); err != nil {
+				return err
 			}
+			return finishHistory(txn, historyIndex)
+		}); dbErr != nil {
+			return dbErr
+		}
+		migrationCommits.Inc()
+		if stepErr == nil {
+			return nil
+		} else if !errors.Is(stepErr, ErrCallWithNewTransaction) {
+			return stepErr
 		}
 	}
+}
 
-	return nil
+// progressReporter returns the ProgressFunc of the migration to version, which passes its progress to onProgress and
+// logs it every progressLogInterval
+func progressReporter(version uint64, log utils.SimpleLogger, onProgress func(Progress)) ProgressFunc {
+	started := time.Now()
+	logged := started
+	var reported, loggedCurrent uint64
+	first := true
+	return func(current, total uint64) {
+		if first {
+			// the units a resumed migration did before it was interrupted do not count towards its throughput
+			loggedCurrent, first = current, false
+		}
+		if current > reported {
+			migratedUnits.Add(float64(current - reported))
+			reported = current
+		}
+		progress := Progress{
+			Version: version,
+			Name:    migrationNames[version-1],
+			Current: current,
+			Total:   total,
+			Elapsed: time.Since(started),
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+		sinceLogged := time.Since(logged)
+		if sinceLogged < progressLogInterval {
+			return
+		}
+		// the throughput since the last log tells a slow migration from a stuck one
+		perSecond := float64(current-loggedCurrent) / sinceLogged.Seconds()
+		logged, loggedCurrent = time.Now(), current
+
+		fields := []any{"version", version, "migrated", current, "perSecond", fmt.Sprintf("%.1f", perSecond)}
+		if total > 0 {
+			fields = append(fields, "total", total, "percent", fmt.Sprintf("%.1f", progress.Percent()),
+				"eta", progress.ETA().Round(time.Second))
+		}
+		log.Infow("Migrating the database", fields...)
+	}
+}
+
+// LatestSchemaVersion returns the schema version of a database that all the migrations were applied to
+func LatestSchemaVersion() uint64 {
+	return uint64(len(migrations))
 }
 
 func SchemaVersion(targetDB db.DB) (uint64, error) {
-	version := uint64(0)
 	txn := targetDB.NewTransaction(false)
-	err := txn.Get(db.SchemaVersion.Key(), func(bytes []byte) error {
-		version = binary.BigEndian.Uint64(bytes)
-		return nil
-	})
+	version, err := schemaVersionValue.Get(txn)
 	if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
 		return 0, db.CloseAndWrapOnError(txn.Discard, err)
 	}
@@ -119,9 +695,12 @@ func migration0000(txn db.Transaction, _ utils.Network) error {
 		return err
 	}
 
-	// not empty if valid
-	if it.Next() {
-		return db.CloseAndWrapOnError(it.Close, errors.New("initial DB should be empty"))
+	// not empty if it holds keys besides the history, which records this migration as it starts, and the encoding of
+	// the values, which the database records as it is opened
+	for it.Next() {
+		if !bytes.HasPrefix(it.Key(), db.SchemaMetadata.Key()) && !bytes.HasPrefix(it.Key(), db.ValueEncoding.Key()) {
+			return db.CloseAndWrapOnError(it.Close, errors.New("initial DB should be empty"))
+		}
 	}
 	return it.Close()
 }
@@ -133,11 +712,6 @@ func migration0000(txn db.Transaction, _ utils.Network) error {
 //
 // This enables us to remove the db.ContractRootKey prefix.
 func relocateContractStorageRootKeys(txn db.Transaction, _ utils.Network) error {
-	it, err := txn.NewIterator()
-	if err != nil {
-		return err
-	}
-
 	// Modifying the db with txn.Set/Delete while iterating can cause consistency issues,
 	// so we do them separately.
 
@@ -145,22 +719,11 @@ func relocateContractStorageRootKeys(txn db.Transaction, _ utils.Network) error
 	// Even with millions of contracts, this shouldn't be too expensive.
 	oldEntries := make(map[string][]byte)
 	oldPrefix := db.Unused.Key()
-	var value []byte
-	for it.Seek(oldPrefix); it.Valid(); it.Next() {
-		// Stop iterating once we're out of the old bucket.
-		if !bytes.Equal(it.Key()[:len(oldPrefix)], oldPrefix) {
-			break
-		}
-
-		oldKey := it.Key()
-		value, err = it.Value()
-		if err != nil {
-			return db.CloseAndWrapOnError(it.Close, err)
-		}
+	err := db.IterateBucket(txn, db.Unused, func(oldKey, value []byte) error {
 		oldEntries[string(oldKey)] = value
-	}
-
-	if err = it.Close(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
@@ -184,152 +747,308 @@ func relocateContractStorageRootKeys(txn db.Transaction, _ utils.Network) error
 	return nil
 }
 
-// recalculateBloomFilters updates bloom filters in block headers to match what the most recent implementation expects
-func recalculateBloomFilters(txn db.Transaction, _ utils.Network) error {
+// blockCount returns the number of blocks stored in the database
+func blockCount(txn db.Transaction) (uint64, error) {
+	height, err := chainHeightValue.Get(txn)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return height + 1, nil
+}
+
+// recalculateBloomFilters updates bloom filters in block headers to match what the most recent implementation expects.
+// Like calculateBlockCommitments, the blocks are read in batches whose bloom filters are calculated by opts.Workers
+// goroutines and then stored by the calling goroutine, which is the only one that uses txn.
+func recalculateBloomFilters(ctx context.Context, txn db.Transaction, _ utils.Network, progress ProgressFunc,
+	opts MigrationOptions,
+) error {
 	blockchain.RegisterCoreTypesToEncoder()
-	for blockNumber := uint64(0); ; blockNumber++ {
-		block, err := blockchain.BlockByNumber(txn, blockNumber)
-		if err != nil {
-			if errors.Is(err, db.ErrKeyNotFound) {
-				return nil
-			}
+	total, err := blockCount(txn)
+	if err != nil {
+		return err
+	}
+
+	for done := uint64(0); done < total; {
+		if err = ctx.Err(); err != nil {
 			return err
 		}
-		block.EventsBloom = core.EventsBloom(block.Receipts)
-		if err = blockchain.StoreBlockHeader(txn, block.Header); err != nil {
+		blocks, err := readBlockBatch(txn, done, total, opts)
+		if err != nil {
 			return err
 		}
+
+		workerPool := pool.New().WithMaxGoroutines(opts.workers())
+		for _, block := range blocks {
+			block := block
+			workerPool.Go(func() {
+				block.EventsBloom = core.EventsBloom(block.Receipts)
+			})
+		}
+		workerPool.Wait()
+
+		for _, block := range blocks {
+			if err = blockchain.StoreBlockHeader(txn, block.Header); err != nil {
+				return err
+			}
+		}
+		done += uint64(len(blocks))
+		progress.report(done, total)
 	}
+	return nil
 }
 
 // changeTrieNodeEncoding migrates to using a custom encoding for trie nodes
 // that minimises memory allocations. Always use new(changeTrieNodeEncoding)
 // before calling Before(), otherwise it will panic.
 type changeTrieNodeEncoding struct {
-	trieNodeBuckets map[db.Bucket]*struct {
-		seekTo  []byte
-		skipLen int
+	migrate *ChunkedMigration
+	revert  *ChunkedMigration
+	// converted is the number of trie nodes the migration re-encoded
+	converted uint64
+	log       utils.SimpleLogger
+}
+
+func (m *changeTrieNodeEncoding) rewritesBuckets() {}
+
+func (m *changeTrieNodeEncoding) OnProgress(progress ProgressFunc) {
+	m.migrate.OnProgress(func(current, total uint64) {
+		m.converted = current
+		progress.report(current, total)
+	})
+	m.revert.OnProgress(progress)
+}
+
+func (m *changeTrieNodeEncoding) SetOptions(opts MigrationOptions) {
+	m.migrate.SetOptions(opts)
+	m.revert.SetOptions(opts)
+	m.log = opts.log
+}
+
+// After logs the number of trie nodes the migration re-encoded and releases the chunked migrations, which Before
+// creates again
+func (m *changeTrieNodeEncoding) After(db.Transaction) error {
+	if m.log != nil {
+		m.log.Infow("Re-encoded the trie nodes", "nodes", m.converted)
 	}
+	m.release()
+	return nil
+}
+
+// OnError releases the chunked migrations, the migration resumes from their checkpoint
+func (m *changeTrieNodeEncoding) OnError(error) {
+	m.release()
+}
+
+func (m *changeTrieNodeEncoding) release() {
+	m.migrate, m.revert, m.converted = nil, nil, 0
+}
+
+// SpaceEstimate returns the size of the trie buckets, whose nodes are re-encoded
+func (m *changeTrieNodeEncoding) SpaceEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesSize(targetDB, trieNodePrefixes()...)
+}
+
+// KeyEstimate returns the number of the entries of the trie buckets, which are mostly trie nodes
+func (m *changeTrieNodeEncoding) KeyEstimate(targetDB db.DB) (uint64, error) {
+	return prefixesKeyCount(targetDB, trieNodePrefixes()...)
+}
+
+func trieNodePrefixes() [][]byte {
+	return [][]byte{db.ClassesTrie.Key(), db.StateTrie.Key(), db.ContractStorage.Key()}
 }
 
 func (m *changeTrieNodeEncoding) Before() {
-	m.trieNodeBuckets = map[db.Bucket]*struct {
-		seekTo  []byte
-		skipLen int
-	}{
-		db.ClassesTrie: {
-			seekTo:  db.ClassesTrie.Key(),
-			skipLen: 1,
-		},
-		db.StateTrie: {
-			seekTo:  db.StateTrie.Key(),
-			skipLen: 1,
-		},
-		db.ContractStorage: {
-			seekTo:  db.ContractStorage.Key(),
-			skipLen: 1 + felt.Bytes,
-		},
-	}
-}
-
-func (m *changeTrieNodeEncoding) Migrate(txn db.Transaction, _ utils.Network) error {
-	// If we made n a trie.Node, the encoder would fall back to the custom encoding methods.
-	// We instead define a cutom struct to force the encoder to use the default encoding.
-	var n struct {
-		Value *felt.Felt
-		Left  *bitset.BitSet
-		Right *bitset.BitSet
+	prefixes := trieNodePrefixes()
+	m.migrate = NewChunkedMigration("trie node encoding", prefixes, migrateTrieNode).
+		WithKeyFilter(isTrieNode).WithCountedTotal().WithVerifier(verifyTrieNode)
+	m.revert = NewChunkedMigration("trie node encoding revert", prefixes, revertTrieNode).
+		WithKeyFilter(isTrieNode).WithCountedTotal()
+}
+
+// isTrieNode returns whether the entry of a trie bucket is a trie node, and not the key of the root of a trie
+func isTrieNode(key []byte) bool {
+	if key[0] == byte(db.ContractStorage) {
+		return len(key) != 1+felt.Bytes
+	}
+	return len(key) != 1
+}
+
+// defaultEncodedNode is a trie.Node without its custom encoding methods. If we used a trie.Node, the encoder
+// would fall back to the custom encoding methods, this type forces the encoder to use the default encoding.
+type defaultEncodedNode struct {
+	Value *felt.Felt
+	Left  *bitset.BitSet
+	Right *bitset.BitSet
+}
+
+func (m *changeTrieNodeEncoding) Migrate(ctx context.Context, txn db.Transaction, network utils.Network) error {
+	return m.migrate.Migrate(ctx, txn, network)
+}
+
+// Revert encodes the trie nodes with the default encoding again
+func (m *changeTrieNodeEncoding) Revert(ctx context.Context, txn db.Transaction, network utils.Network) error {
+	return m.revert.Migrate(ctx, txn, network)
+}
+
+// Verify checks that the trie nodes the interrupted migration re-encoded decode with the custom encoding, and the
+// others with the default one
+func (m *changeTrieNodeEncoding) Verify(ctx context.Context, txn db.Transaction, network utils.Network) error {
+	return m.migrate.Verify(ctx, txn, network)
+}
+
+func verifyTrieNode(_, value []byte, migrated bool) error {
+	if migrated {
+		var coreNode trie.Node
+		return coreNode.UnmarshalBinary(value)
+	}
+	var n defaultEncodedNode
+	if err := encoder.Unmarshal(value, &n); err != nil && !reencoded(value) {
+		return err
+	}
+	return nil
+}
+
+// reencoded returns whether the trie node value, which does not decode with the default encoding, is encoded with
+// the custom encoding already. The nodes after the checkpoint of a chunked migration may have been re-encoded when
+// the database is split, whose state store, which holds the tries, is committed before the chain store, which holds
+// the checkpoint: the interrupted chunk is migrated again.
+func reencoded(value []byte) bool {
+	var coreNode trie.Node
+	return coreNode.UnmarshalBinary(value) == nil
+}
+
+func migrateTrieNode(txn db.Transaction, key, value []byte, _ utils.Network) error {
+	var n defaultEncodedNode
+	if err := encoder.Unmarshal(value, &n); err != nil {
+		if reencoded(value) {
+			return nil
+		}
+		return err
 	}
 
 	var buf bytes.Buffer
-	var updatedNodes uint64
-
-	migrateF := func(it db.Iterator, bucket db.Bucket, seekTo []byte, skipLen int) error {
-		bucketPrefix := bucket.Key()
-		for it.Seek(seekTo); it.Valid(); it.Next() {
-			key := it.Key()
-			if !bytes.HasPrefix(key, bucketPrefix) {
-				delete(m.trieNodeBuckets, bucket)
-				break
-			}
+	coreNode := trie.Node(n)
+	if _, err := coreNode.WriteTo(&buf); err != nil {
+		return err
+	}
+	return txn.Set(key, buf.Bytes())
+}
 
-			if len(key) == skipLen {
-				// this entry is not a TrieNode, it is the root key
-				continue
-			}
+func revertTrieNode(txn db.Transaction, key, value []byte, _ utils.Network) error {
+	var coreNode trie.Node
+	if err := coreNode.UnmarshalBinary(value); err != nil {
+		return err
+	}
 
-			// We cant fit the entire migration in a single transaction but
-			// the actual limit is much higher than 1M. But the more updates
-			// you queue on a transaction the more memory it uses. 1M is just
-			// an arbitrary number that we can both fit in a transaction and
-			// dont use too much memory.
-			const updatedNodesBatch = 1_000_000
-			if updatedNodes >= updatedNodesBatch {
-				m.trieNodeBuckets[bucket].seekTo = key
-				return ErrCallWithNewTransaction
-			}
+	encoded, err := encoder.Marshal(defaultEncodedNode(coreNode))
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, encoded)
+}
 
-			v, err := it.Value()
-			if err != nil {
-				return err
-			}
+// calculateBlockCommitments calculates the txn and event commitments for each block and stores them separately.
+// The blocks are read in batches, whose commitments are calculated by opts.Workers goroutines and then stored by
+// the calling goroutine, which is the only one that uses txn.
+func calculateBlockCommitments(ctx context.Context, txn db.Transaction, network utils.Network,
+	progress ProgressFunc, opts MigrationOptions,
+) error {
+	total, err := blockCount(txn)
+	if err != nil {
+		return err
+	}
 
-			if err = encoder.Unmarshal(v, &n); err != nil {
-				return err
-			}
+	for done := uint64(0); done < total; {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		blocks, err := readBlockBatch(txn, done, total, opts)
+		if err != nil {
+			return err
+		}
 
-			coreNode := trie.Node(n)
-			if _, err = coreNode.WriteTo(&buf); err != nil {
+		commitments := make([]*core.BlockCommitments, len(blocks))
+		workerPool := pool.New().WithErrors().WithMaxGoroutines(opts.workers())
+		for i, block := range blocks {
+			i, block := i, block
+			workerPool.Go(func() error {
+				var err error
+				commitments[i], err = core.VerifyBlockHash(block, network)
 				return err
-			}
+			})
+		}
+		if err = workerPool.Wait(); err != nil {
+			return err
+		}
 
-			if err = txn.Set(key, buf.Bytes()); err != nil {
+		for i, block := range blocks {
+			if err = blockchain.StoreBlockCommitments(txn, block.Number, commitments[i]); err != nil {
 				return err
 			}
-			buf.Reset()
-
-			updatedNodes++
 		}
-		return nil
+		done += uint64(len(blocks))
+		progress.report(done, total)
 	}
+	return nil
+}
 
-	iterator, err := txn.NewIterator()
+// calculateCommitments is the calculateBlockCommitments of block, for Background
+func calculateCommitments(block *core.Block, network utils.Network) (func(db.Transaction) error, error) {
+	commitments, err := core.VerifyBlockHash(block, network)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return func(txn db.Transaction) error {
+		return blockchain.StoreBlockCommitments(txn, block.Number, commitments)
+	}, nil
+}
 
-	for bucket, info := range m.trieNodeBuckets {
-		if err := migrateF(iterator, bucket, info.seekTo, info.skipLen); err != nil {
-			return db.CloseAndWrapOnError(iterator.Close, err)
+// readBlockBatch reads the blocks from number start, up to opts.BatchSize blocks or until their estimated memory
+// reaches opts.MemoryBudget. It reads at least one block if start is less than total.
+func readBlockBatch(txn db.Transaction, start, total uint64, opts MigrationOptions) ([]*core.Block, error) {
+	var (
+		blocks []*core.Block
+		memory uint64
+	)
+	for number := start; number < total && uint64(len(blocks)) < opts.batchSize(); number++ {
+		if opts.MemoryBudget > 0 && memory >= opts.MemoryBudget {
+			break
+		}
+		block, err := blockchain.BlockByNumber(txn, number)
+		if err != nil {
+			return nil, err
 		}
+		blocks = append(blocks, block)
+		memory += blockMemory(block)
 	}
-	return iterator.Close()
+	return blocks, nil
 }
 
-// calculateBlockCommitments calculates the txn and event commitments for each block and stores them separately
-func calculateBlockCommitments(txn db.Transaction, network utils.Network) error {
-	var txnLock sync.RWMutex
-	workerPool := pool.New().WithErrors().WithMaxGoroutines(runtime.GOMAXPROCS(0))
-
-	for blockNumber := 0; ; blockNumber++ {
-		txnLock.RLock()
-		block, err := blockchain.BlockByNumber(txn, uint64(blockNumber))
-		txnLock.RUnlock()
+// The sizes below are estimates of the memory held by a decoded block, the calldata of the transactions is
+// included in their overhead.
+const (
+	blockOverhead       = 1024
+	transactionOverhead = 1024
+	eventOverhead       = 64
+)
 
-		if errors.Is(err, db.ErrKeyNotFound) {
-			break
+// blockMemory estimates the memory held by block, in bytes
+func blockMemory(block *core.Block) uint64 {
+	size := uint64(blockOverhead)
+	for _, txn := range block.Transactions {
+		size += transactionOverhead + uint64(len(txn.Signature()))*felt.Bytes
+	}
+	for _, receipt := range block.Receipts {
+		for _, event := range receipt.Events {
+			size += eventOverhead + uint64(len(event.Keys)+len(event.Data))*felt.Bytes
 		}
-
-		workerPool.Go(func() error {
-			commitments, err := core.VerifyBlockHash(block, network)
-			if err != nil {
-				return err
-			}
-			txnLock.Lock()
-			defer txnLock.Unlock()
-			return blockchain.StoreBlockCommitments(txn, block.Number, commitments)
-		})
 	}
+	return size
+}
 
-	return workerPool.Wait()
+// deleteBlockCommitments reverts calculateBlockCommitments
+func deleteBlockCommitments(txn db.Transaction, _ utils.Network) error {
+	return txn.DeletePrefix(db.BlockCommitments.Key())
 }
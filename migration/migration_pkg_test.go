@@ -2,7 +2,10 @@ package migration
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/juno/blockchain"
 	"github.com/NethermindEth/juno/clients/feeder"
@@ -11,10 +14,13 @@ import (
 	"github.com/NethermindEth/juno/core/trie"
 	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/split"
 	"github.com/NethermindEth/juno/encoder"
 	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/bits-and-blooms/bitset"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -79,6 +85,53 @@ func TestRelocateContractStorageRootKeys(t *testing.T) {
 	}
 }
 
+func TestPurgeDeprecatedBuckets(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+		for i := byte(0); i < 5; i++ {
+			if err := txn.Set(db.Unused.Key([]byte{i}), []byte{i}); err != nil {
+				return err
+			}
+		}
+		// the keys of the next bucket are kept
+		return txn.Set(db.ContractClassHash.Key([]byte{0}), []byte{0})
+	}))
+
+	m := purgeDeprecatedBuckets()
+	m.SetOptions(MigrationOptions{})
+	var progress []uint64
+	m.OnProgress(func(current, _ uint64) {
+		progress = append(progress, current)
+	})
+	assert.Equal(t, uint64(defaultBatchWriteSize), batchWriteSize(m, MigrationOptions{}))
+	// the deletion of every bucket is committed
+	writer := NewBatchWriter(testDB, 1)
+	require.NoError(t, m.Migrate(context.Background(), writer, utils.MAINNET))
+	require.NoError(t, writer.Commit())
+	assert.Equal(t, []uint64{5}, progress)
+
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		err := txn.Get(db.Unused.Key([]byte{4}), func([]byte) error { return nil })
+		require.ErrorIs(t, err, db.ErrKeyNotFound)
+		return txn.Get(db.ContractClassHash.Key([]byte{0}), func([]byte) error { return nil })
+	}))
+
+	t.Run("PurgeBucket without a limit", func(t *testing.T) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			purged, err := PurgeBucket(txn, db.ContractClassHash, 0)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), purged)
+			purged, err = PurgeBucket(txn, db.ContractClassHash, 0)
+			require.NoError(t, err)
+			assert.Zero(t, purged)
+			return nil
+		}))
+	})
+}
+
 func TestRecalculateBloomFilters(t *testing.T) {
 	testdb := pebble.NewMemTest()
 	t.Cleanup(func() {
@@ -98,14 +151,30 @@ func TestRecalculateBloomFilters(t *testing.T) {
 		require.NoError(t, chain.Store(b, &core.BlockCommitments{}, su, nil))
 	}
 
-	require.NoError(t, testdb.Update(func(txn db.Transaction) error {
-		return recalculateBloomFilters(txn, utils.MAINNET)
-	}))
+	// the progress is reported once the filters of a batch are stored
+	for batchSize, expected := range map[uint64][][2]uint64{
+		1: {{1, 3}, {2, 3}, {3, 3}},
+		2: {{2, 3}, {3, 3}},
+	} {
+		var progress [][2]uint64
+		require.NoError(t, testdb.Update(func(txn db.Transaction) error {
+			for i := uint64(0); i < 3; i++ {
+				header, err := blockchain.BlockHeaderByNumber(txn, i)
+				require.NoError(t, err)
+				header.EventsBloom = nil
+				require.NoError(t, blockchain.StoreBlockHeader(txn, header))
+			}
+			return recalculateBloomFilters(context.Background(), txn, utils.MAINNET, func(current, total uint64) {
+				progress = append(progress, [2]uint64{current, total})
+			}, MigrationOptions{BatchSize: batchSize, Workers: 2})
+		}))
+		assert.Equal(t, expected, progress)
 
-	for i := uint64(0); i < 3; i++ {
-		b, err := chain.BlockByNumber(i)
-		require.NoError(t, err)
-		assert.Equal(t, core.EventsBloom(b.Receipts), b.EventsBloom)
+		for i := uint64(0); i < 3; i++ {
+			b, err := chain.BlockByNumber(i)
+			require.NoError(t, err)
+			assert.Equal(t, core.EventsBloom(b.Receipts), b.EventsBloom)
+		}
 	}
 }
 
@@ -149,9 +218,43 @@ func TestChangeTrieNodeEncoding(t *testing.T) {
 
 	m := new(changeTrieNodeEncoding)
 	m.Before()
+	var current, total uint64
+	m.OnProgress(func(c, t uint64) {
+		current, total = c, t
+	})
 	require.NoError(t, testdb.Update(func(txn db.Transaction) error {
-		return m.Migrate(txn, utils.MAINNET)
+		return m.Migrate(context.Background(), txn, utils.MAINNET)
 	}))
+	// the root keys are not trie nodes
+	assert.Equal(t, uint64(15), current)
+	assert.Equal(t, uint64(15), total)
+
+	t.Run("revert", func(t *testing.T) {
+		reverted := new(changeTrieNodeEncoding)
+		reverted.Before()
+		require.NoError(t, testdb.Update(func(txn db.Transaction) error {
+			return reverted.Revert(context.Background(), txn, utils.MAINNET)
+		}))
+		require.NoError(t, testdb.View(func(txn db.Transaction) error {
+			for _, bucket := range buckets {
+				for i := 0; i < 5; i++ {
+					var node defaultEncodedNode
+					if err := txn.Get(bucket.Key([]byte{byte(i)}), func(v []byte) error {
+						return encoder.Unmarshal(v, &node)
+					}); err != nil {
+						return err
+					}
+					assert.Equal(t, new(felt.Felt).SetUint64(uint64(i)), node.Value)
+				}
+			}
+			return nil
+		}))
+		// the nodes are migrated again
+		m.Before()
+		require.NoError(t, testdb.Update(func(txn db.Transaction) error {
+			return m.Migrate(context.Background(), txn, utils.MAINNET)
+		}))
+	})
 
 	require.NoError(t, testdb.Update(func(txn db.Transaction) error {
 		for _, bucket := range buckets {
@@ -168,6 +271,95 @@ func TestChangeTrieNodeEncoding(t *testing.T) {
 	}))
 }
 
+// errInterrupted is returned by the commits of an interruptedDB
+var errInterrupted = errors.New("interrupted")
+
+// interruptedDB fails the commits of its write transactions while interrupted is set, as if the node stopped before
+// they were committed
+type interruptedDB struct {
+	db.DB
+	interrupted *bool
+}
+
+func (d interruptedDB) NewTransaction(update bool) db.Transaction {
+	txn := d.DB.NewTransaction(update)
+	if update && *d.interrupted {
+		return interruptedTransaction{txn}
+	}
+	return txn
+}
+
+type interruptedTransaction struct {
+	db.Transaction
+}
+
+func (t interruptedTransaction) Commit() error {
+	return errors.Join(errInterrupted, t.Discard())
+}
+
+func TestChangeTrieNodeEncodingInterruptedSplitCommit(t *testing.T) {
+	interrupted := false
+	splitDB := split.New(interruptedDB{DB: pebble.NewMemTest(), interrupted: &interrupted}, pebble.NewMemTest())
+	t.Cleanup(func() {
+		require.NoError(t, splitDB.Close())
+	})
+
+	const nodes = 10
+	require.NoError(t, splitDB.Update(func(txn db.Transaction) error {
+		for i := 0; i < nodes; i++ {
+			encodedNode, err := encoder.Marshal(defaultEncodedNode{Value: new(felt.Felt).SetUint64(uint64(i))})
+			if err != nil {
+				return err
+			}
+			if err = txn.Set(db.StateTrie.Key([]byte{byte(i)}), encodedNode); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// migrate applies the migration in chunks of 4 nodes, each committed with its checkpoint
+	m := new(changeTrieNodeEncoding)
+	migrate := func() error {
+		m.Before()
+		m.SetOptions(MigrationOptions{ChunkSize: 4})
+		for {
+			txn := splitDB.NewTransaction(true)
+			err := m.Migrate(context.Background(), txn, utils.MAINNET)
+			if err != nil && !errors.Is(err, ErrCallWithNewTransaction) {
+				return db.CloseAndWrapOnError(txn.Discard, err)
+			}
+			if commitErr := txn.Commit(); commitErr != nil {
+				return commitErr
+			}
+			if err == nil {
+				return nil
+			}
+		}
+	}
+
+	// the first chunk is committed to the state store, which holds the trie nodes, but not to the chain store, which
+	// holds its checkpoint
+	interrupted = true
+	require.ErrorIs(t, migrate(), errInterrupted)
+	interrupted = false
+	require.NoError(t, splitDB.View(func(txn db.Transaction) error {
+		return m.Verify(context.Background(), txn, utils.MAINNET)
+	}), "the nodes of the interrupted chunk are re-encoded")
+
+	require.NoError(t, migrate(), "the re-encoded nodes are skipped")
+	require.NoError(t, splitDB.View(func(txn db.Transaction) error {
+		for i := 0; i < nodes; i++ {
+			var coreNode trie.Node
+			if err := txn.Get(db.StateTrie.Key([]byte{byte(i)}), coreNode.UnmarshalBinary); err != nil {
+				return err
+			}
+			assert.Equal(t, new(felt.Felt).SetUint64(uint64(i)), coreNode.Value)
+		}
+		return nil
+	}))
+}
+
 func TestCalculateBlockCommitments(t *testing.T) {
 	testdb := pebble.NewMemTest()
 	t.Cleanup(func() {
@@ -185,8 +377,27 @@ func TestCalculateBlockCommitments(t *testing.T) {
 		require.NoError(t, chain.Store(b, &core.BlockCommitments{}, su, nil))
 	}
 
+	for name, test := range map[string]struct {
+		opts     MigrationOptions
+		progress [][2]uint64
+	}{
+		"batches":       {MigrationOptions{Workers: 2, BatchSize: 2}, [][2]uint64{{2, 3}, {3, 3}}},
+		"memory budget": {MigrationOptions{MemoryBudget: 1}, [][2]uint64{{1, 3}, {2, 3}, {3, 3}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var progress [][2]uint64
+			require.NoError(t, testdb.Update(func(txn db.Transaction) error {
+				return calculateBlockCommitments(context.Background(), txn, utils.MAINNET,
+					func(current, total uint64) {
+						progress = append(progress, [2]uint64{current, total})
+					}, test.opts)
+			}))
+			assert.Equal(t, test.progress, progress)
+		})
+	}
+
 	require.NoError(t, testdb.Update(func(txn db.Transaction) error {
-		return calculateBlockCommitments(txn, utils.MAINNET)
+		return calculateBlockCommitments(context.Background(), txn, utils.MAINNET, nil, MigrationOptions{})
 	}))
 
 	for i := uint64(0); i < 3; i++ {
@@ -194,4 +405,301 @@ func TestCalculateBlockCommitments(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, b.TransactionCommitment)
 	}
+
+	require.NoError(t, testdb.Update(func(txn db.Transaction) error {
+		return deleteBlockCommitments(txn, utils.MAINNET)
+	}))
+	for i := uint64(0); i < 3; i++ {
+		require.ErrorIs(t, testdb.View(func(txn db.Transaction) error {
+			return txn.Get(db.BlockCommitments.Key(core.MarshalBlockNumber(i)), func([]byte) error {
+				return nil
+			})
+		}), db.ErrKeyNotFound)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	progress := Progress{Current: 25, Total: 100, Elapsed: time.Minute}
+	assert.Equal(t, 25.0, progress.Percent())
+	assert.Equal(t, 3*time.Minute, progress.ETA())
+
+	progress.Total = 0
+	assert.Zero(t, progress.Percent())
+	assert.Zero(t, progress.ETA(), "the total is not known")
+
+	var reported []Progress
+	reporter := progressReporter(4, utils.NewNopZapLogger(), func(p Progress) {
+		reported = append(reported, p)
+	})
+	reporter(1, 2)
+	reporter(2, 2)
+	require.Len(t, reported, 2)
+	assert.Equal(t, uint64(4), reported[1].Version)
+	assert.Equal(t, 100.0, reported[1].Percent())
+
+	estimated := reporter.withEstimate(10)
+	estimated(5, 0)
+	assert.Equal(t, uint64(10), reported[2].Total, "the estimate is the total of the migrations without one")
+	estimated(12, 0)
+	assert.Equal(t, uint64(12), reported[3].Total, "the total is at least the units done")
+	estimated(5, 8)
+	assert.Equal(t, uint64(8), reported[4].Total)
+}
+
+type estimatedMigration struct {
+	MigrationFunc
+	estimate uint64
+}
+
+func (m estimatedMigration) SpaceEstimate(db.DB) (uint64, error) {
+	return m.estimate, nil
+}
+
+func TestCheckSpace(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	dir := t.TempDir()
+
+	require.NoError(t, checkSpace(testDB, dir, []Migration{MigrationFunc(migration0000)}))
+	require.NoError(t, checkSpace(testDB, "/does/not/exist", []Migration{estimatedMigration{}}),
+		"the free space is not read when no space is needed")
+	require.NoError(t, checkSpace(testDB, dir, []Migration{estimatedMigration{estimate: 1}}))
+
+	err := checkSpace(testDB, dir, []Migration{estimatedMigration{estimate: 1 << 62}, estimatedMigration{estimate: 1}})
+	require.ErrorIs(t, err, ErrInsufficientSpace)
+	var spaceErr *InsufficientSpaceError
+	require.ErrorAs(t, err, &spaceErr)
+	assert.Equal(t, uint64(1<<62+1), spaceErr.Required)
+	assert.Equal(t, dir, spaceErr.Dir)
+}
+
+func TestMetrics(t *testing.T) {
+	value := func(c prometheus.Collector) float64 {
+		ch := make(chan prometheus.Metric, 1)
+		c.Collect(ch)
+		var m dto.Metric
+		require.NoError(t, (<-ch).Write(&m))
+		if m.Counter != nil {
+			return m.Counter.GetValue()
+		}
+		return m.Gauge.GetValue()
+	}
+	commits, migrated, failures := value(migrationCommits), value(migratedUnits), value(migrationFailures)
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(),
+		MigrationOptions{}))
+	assert.GreaterOrEqual(t, value(migrationCommits)-commits, float64(LatestSchemaVersion()),
+		"every migration commits at least once")
+	assert.Equal(t, migrated, value(migratedUnits), "the empty database has nothing to migrate")
+	assert.Equal(t, failures, value(migrationFailures))
+	assert.Zero(t, value(migratingVersion))
+
+	// the first migration fails on a database that is not empty
+	nonEmptyDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, nonEmptyDB.Close())
+	})
+	require.NoError(t, nonEmptyDB.Update(func(txn db.Transaction) error {
+		return txn.Set([]byte("asd"), []byte("123"))
+	}))
+	require.Error(t, MigrateIfNeeded(context.Background(), nonEmptyDB, utils.MAINNET, utils.NewNopZapLogger(),
+		MigrationOptions{}))
+	assert.Equal(t, failures+1, value(migrationFailures))
+	assert.Zero(t, value(migratingVersion))
+}
+
+func TestRegister(t *testing.T) {
+	registered, registeredNames := migrations, migrationNames
+	t.Cleanup(func() {
+		migrations, migrationNames = registered, registeredNames
+	})
+	latest := LatestSchemaVersion()
+
+	assert.Panics(t, func() {
+		Register(latest, "test", MigrationFunc(migration0000))
+	}, "the version collides with a migration of Juno")
+	assert.Panics(t, func() {
+		Register(latest+2, "test", MigrationFunc(migration0000))
+	}, "the version skips one")
+	assert.Panics(t, func() {
+		Register(latest+1, "test", nil)
+	})
+
+	var applied bool
+	Register(latest+1, "test", MigrationFunc(func(db.Transaction, utils.Network) error {
+		applied = true
+		return nil
+	}))
+	assert.Equal(t, latest+1, LatestSchemaVersion())
+	assert.Panics(t, func() {
+		Register(latest+1, "test", MigrationFunc(migration0000))
+	}, "the version was registered")
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(),
+		MigrationOptions{}))
+	assert.True(t, applied)
+	version, err := SchemaVersion(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, latest+1, version)
+	history, err := History(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, "test", history[len(history)-1].Name)
+
+	t.Run("a version migrated by another migration is refused", func(t *testing.T) {
+		// the fork upgraded to a version of Juno whose migration to the version of its own is another one
+		migrationNames[latest] = "upstream"
+		require.ErrorIs(t, MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(),
+			MigrationOptions{}), ErrMigrationMismatch)
+	})
+}
+
+func TestCompactedPrefixes(t *testing.T) {
+	expected := [][][]byte{
+		nil,
+		{db.Unused.Key()},
+		{db.BlockHeadersByNumber.Key()},
+		trieNodePrefixes(),
+		nil,
+		{db.Unused.Key()},
+	}
+	require.Len(t, migrations, len(expected))
+	for i, m := range migrations {
+		assert.Equal(t, expected[i], compactedPrefixesOf(m), "migration to schema version %d", i+1)
+	}
+}
+
+// hookedMigration fails with err, if it is set, and records the calls of its hooks
+type hookedMigration struct {
+	MigrationFunc
+	err     error
+	after   int
+	onError []error
+}
+
+func (m *hookedMigration) Migrate(_ context.Context, txn db.Transaction, _ utils.Network) error {
+	if m.err != nil {
+		return m.err
+	}
+	return txn.Set(db.Class.Key([]byte("migrated")), []byte{1})
+}
+
+func (m *hookedMigration) After(txn db.Transaction) error {
+	m.after++
+	return txn.Set(db.Class.Key([]byte("after")), []byte{1})
+}
+
+func (m *hookedMigration) OnError(err error) {
+	m.onError = append(m.onError, err)
+}
+
+func TestHooks(t *testing.T) {
+	registered, registeredNames := migrations, migrationNames
+	t.Cleanup(func() {
+		migrations, migrationNames = registered, registeredNames
+	})
+	latest := LatestSchemaVersion()
+	hooked := &hookedMigration{err: errors.New("migration failed")}
+	Register(latest+1, "hooked", batched(hooked))
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	err := MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(), MigrationOptions{})
+	require.ErrorIs(t, err, hooked.err)
+	assert.Equal(t, []error{hooked.err}, hooked.onError)
+	assert.Zero(t, hooked.after)
+
+	hooked.err = nil
+	require.NoError(t, MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(),
+		MigrationOptions{}))
+	assert.Equal(t, 1, hooked.after)
+	assert.Len(t, hooked.onError, 1)
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		for _, key := range []string{"migrated", "after"} {
+			if err := txn.Get(db.Class.Key([]byte(key)), func([]byte) error { return nil }); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func TestInterrupted(t *testing.T) {
+	registered, registeredNames := migrations, migrationNames
+	t.Cleanup(func() {
+		migrations, migrationNames = registered, registeredNames
+	})
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	migrate := func(opts MigrationOptions) error {
+		return MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(), opts)
+	}
+	require.NoError(t, migrate(MigrationOptions{}))
+	setEntry := func(i byte, value string) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.Class.Key([]byte{i}), []byte(value))
+		}))
+	}
+	for i := byte(1); i <= 3; i++ {
+		setEntry(i, "old")
+	}
+
+	// the migration crashes on the last entry, once the others are committed
+	crash := true
+	version := LatestSchemaVersion() + 1
+	Register(version, "test", NewChunkedMigration("test", [][]byte{db.Class.Key()},
+		func(txn db.Transaction, key, _ []byte, _ utils.Network) error {
+			if crash && key[1] == 3 {
+				return errors.New("crash")
+			}
+			return txn.Set(key, []byte("new"))
+		}).WithChunkSize(1).WithVerifier(func(_, value []byte, migrated bool) error {
+		if migrated != (string(value) == "new") {
+			return fmt.Errorf("unexpected value %s", value)
+		}
+		return nil
+	}))
+	require.Error(t, migrate(MigrationOptions{}))
+	crash = false
+
+	err := migrate(MigrationOptions{OnInterrupted: Fail})
+	require.ErrorIs(t, err, ErrInterrupted)
+	var interruptedErr *InterruptedError
+	require.ErrorAs(t, err, &interruptedErr)
+	assert.Equal(t, version, interruptedErr.Version)
+	assert.Equal(t, "test", interruptedErr.Name)
+	assert.NoError(t, interruptedErr.Err)
+
+	setEntry(1, "old")
+	err = migrate(MigrationOptions{OnInterrupted: Verify})
+	require.ErrorAs(t, err, &interruptedErr)
+	assert.ErrorContains(t, interruptedErr.Err, "unexpected value old")
+
+	setEntry(1, "new")
+	require.NoError(t, migrate(MigrationOptions{OnInterrupted: Verify}))
+	require.NoError(t, testDB.View(func(txn db.Transaction) error {
+		for i := byte(1); i <= 3; i++ {
+			require.NoError(t, txn.Get(db.Class.Key([]byte{i}), func(value []byte) error {
+				assert.Equal(t, "new", string(value))
+				return nil
+			}))
+		}
+		return nil
+	}))
+	entry, err := interruptedRun(testDB, version)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
 }
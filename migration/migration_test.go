@@ -1,22 +1,66 @@
 package migration_test
 
 import (
+	"context"
+	"encoding/binary"
+	"path/filepath"
 	"testing"
 
+	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/db/pebble"
 	"github.com/NethermindEth/juno/migration"
 	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestMigrateIfNeeded(t *testing.T) {
+	t.Run("no migration is started once the context is cancelled", func(t *testing.T) {
+		cancelledDB := pebble.NewMemTest()
+		t.Cleanup(func() {
+			require.NoError(t, cancelledDB.Close())
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, migration.MigrateIfNeeded(ctx, cancelledDB, utils.MAINNET, utils.NewNopZapLogger(),
+			migration.MigrationOptions{}),
+			context.Canceled)
+		version, err := migration.SchemaVersion(cancelledDB)
+		require.NoError(t, err)
+		require.Zero(t, version)
+	})
+
+	t.Run("a fresh DB with compressed and checksummed values is migrated", func(t *testing.T) {
+		compression, err := db.NewCompression([]string{"headers"})
+		require.NoError(t, err)
+		checksums, err := db.NewChecksums([]string{"headers"}, db.ChecksumFail)
+		require.NoError(t, err)
+		freshDB, err := db.Open(db.DefaultBackend, t.TempDir(), db.Options{
+			Compression: compression,
+			Checksums:   checksums,
+			Reencode:    true,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, freshDB.Close())
+		})
+
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), freshDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		version, err := migration.SchemaVersion(freshDB)
+		require.NoError(t, err)
+		require.Equal(t, migration.LatestSchemaVersion(), version)
+	})
+
 	testDB := pebble.NewMemTest()
 	t.Cleanup(func() {
 		require.NoError(t, testDB.Close())
 	})
 
 	t.Run("Migration should happen on empty DB", func(t *testing.T) {
-		require.NoError(t, migration.MigrateIfNeeded(testDB, utils.MAINNET))
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
 	})
 
 	version, err := migration.SchemaVersion(testDB)
@@ -24,9 +68,251 @@ func TestMigrateIfNeeded(t *testing.T) {
 	require.NotEqual(t, 0, version)
 
 	t.Run("subsequent calls to MigrateIfNeeded should not change the DB version", func(t *testing.T) {
-		require.NoError(t, migration.MigrateIfNeeded(testDB, utils.MAINNET))
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
 		postVersion, postErr := migration.SchemaVersion(testDB)
 		require.NoError(t, postErr)
 		require.Equal(t, version, postVersion)
 	})
 }
+
+func TestMigrateTo(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+
+	require.NoError(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET, 2, utils.NewNopZapLogger(),
+		migration.MigrationOptions{}))
+	version, err := migration.SchemaVersion(testDB)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), version)
+
+	require.ErrorContains(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET, 1,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}), "juno db revert --to 1")
+	require.Error(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET,
+		migration.LatestSchemaVersion()+1, utils.NewNopZapLogger(), migration.MigrationOptions{}))
+
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{DataDir: t.TempDir()}))
+	version, err = migration.SchemaVersion(testDB)
+	require.NoError(t, err)
+	require.Equal(t, migration.LatestSchemaVersion(), version)
+}
+
+func TestMigrateIfNeededBackup(t *testing.T) {
+	database, err := pebble.New(t.TempDir(), 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, database.Close())
+	})
+
+	// the database was migrated by a version of Juno that preceded the migrations that rewrite buckets
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		return txn.Set(db.SchemaVersion.Key(), binary.BigEndian.AppendUint64(nil, 1))
+	}))
+	backupDir := t.TempDir()
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), database, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{BackupDir: backupDir}))
+
+	backups, err := migration.Backups(database)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	path, ok := backups[1]
+	require.True(t, ok, "the database is backed up before the first migration")
+	require.Equal(t, backupDir, filepath.Dir(path))
+
+	backup, err := pebble.New(path, 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	version, err := migration.SchemaVersion(backup)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), version)
+	require.NoError(t, backup.Close())
+
+	t.Run("a database that is up to date is not backed up", func(t *testing.T) {
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), database, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{BackupDir: backupDir}))
+		backups, err = migration.Backups(database)
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+	})
+}
+
+func TestRevertTo(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+	latest := migration.LatestSchemaVersion()
+
+	requireVersion := func(expected uint64) {
+		t.Helper()
+		version, err := migration.SchemaVersion(testDB)
+		require.NoError(t, err)
+		require.Equal(t, expected, version)
+	}
+
+	t.Run("nothing is reverted if a migration cannot be reverted", func(t *testing.T) {
+		err := migration.RevertTo(context.Background(), testDB, utils.MAINNET, 0, utils.NewNopZapLogger(),
+			migration.MigrationOptions{})
+		require.ErrorIs(t, err, migration.ErrIrreversible)
+		requireVersion(latest)
+	})
+
+	t.Run("reverted migrations are applied again", func(t *testing.T) {
+		require.NoError(t, migration.RevertTo(context.Background(), testDB, utils.MAINNET, latest-2,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		requireVersion(latest - 2)
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		requireVersion(latest)
+	})
+
+	t.Run("migrations of a newer version", func(t *testing.T) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			var versionBytes [8]byte
+			binary.BigEndian.PutUint64(versionBytes[:], latest+1)
+			return txn.Set(db.SchemaVersion.Key(), versionBytes[:])
+		}))
+		err := migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(),
+			migration.MigrationOptions{})
+		require.ErrorIs(t, err, migration.ErrSchemaTooNew)
+		err = migration.RevertTo(context.Background(), testDB, utils.MAINNET, latest, utils.NewNopZapLogger(),
+			migration.MigrationOptions{})
+		require.ErrorIs(t, err, migration.ErrSchemaTooNew)
+		requireVersion(latest + 1)
+	})
+}
+
+func TestRefuse(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	latest := migration.LatestSchemaVersion()
+	require.NoError(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET, latest-2,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+	status, err := migration.GetStatus(testDB)
+	require.NoError(t, err)
+
+	err = migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET, utils.NewNopZapLogger(),
+		migration.MigrationOptions{Refuse: true})
+	require.ErrorIs(t, err, migration.ErrSchemaBehind)
+	var behindErr *migration.SchemaBehindError
+	require.ErrorAs(t, err, &behindErr)
+	assert.Equal(t, latest-2, behindErr.SchemaVersion)
+	require.Len(t, behindErr.Pending, 2)
+	for i, pending := range behindErr.Pending {
+		assert.Equal(t, status.Pending[i], pending.Version)
+		assert.NotEmpty(t, pending.Name)
+		assert.Contains(t, err.Error(), pending.Name)
+	}
+	version, err := migration.SchemaVersion(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, latest-2, version)
+
+	t.Run("a database that is up to date is not refused", func(t *testing.T) {
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{Refuse: true}))
+	})
+}
+
+// readOnlyDB fails the test if it is written to
+type readOnlyDB struct {
+	db.DB
+	t *testing.T
+}
+
+func (d readOnlyDB) NewTransaction(update bool) db.Transaction {
+	require.False(d.t, update, "the database is written to")
+	return d.DB.NewTransaction(update)
+}
+
+func (d readOnlyDB) Update(func(txn db.Transaction) error) error {
+	require.FailNow(d.t, "the database is written to")
+	return nil
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	latest := migration.LatestSchemaVersion()
+	check := func(t *testing.T) migration.SchemaStatus {
+		t.Helper()
+		status, err := migration.CheckCompatibility(readOnlyDB{DB: testDB, t: t})
+		require.NoError(t, err)
+		assert.Equal(t, latest, status.LatestSchemaVersion)
+		return status
+	}
+
+	t.Run("behind", func(t *testing.T) {
+		require.NoError(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET, latest-2,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		status := check(t)
+		assert.Equal(t, migration.Behind, status.Compatibility)
+		assert.Equal(t, latest-2, status.SchemaVersion)
+		require.Len(t, status.Pending, 2)
+		assert.Equal(t, latest-1, status.Pending[0].Version)
+		assert.Equal(t, latest, status.Pending[1].Version)
+		assert.NotEmpty(t, status.Pending[1].Name)
+	})
+
+	t.Run("current", func(t *testing.T) {
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		status := check(t)
+		assert.Equal(t, migration.Current, status.Compatibility)
+		assert.Equal(t, latest, status.SchemaVersion)
+		assert.Empty(t, status.Pending)
+	})
+
+	t.Run("ahead", func(t *testing.T) {
+		require.NoError(t, testDB.Update(func(txn db.Transaction) error {
+			return txn.Set(db.SchemaVersion.Key(), binary.BigEndian.AppendUint64(nil, latest+1))
+		}))
+		status := check(t)
+		assert.Equal(t, migration.Ahead, status.Compatibility)
+		assert.Equal(t, latest+1, status.SchemaVersion)
+		assert.Empty(t, status.Pending)
+	})
+}
+
+func TestHistory(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	history, err := migration.History(testDB)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	latest := migration.LatestSchemaVersion()
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{JunoVersion: "v1.0.0"}))
+	require.NoError(t, migration.RevertTo(context.Background(), testDB, utils.MAINNET, latest-1,
+		utils.NewNopZapLogger(), migration.MigrationOptions{JunoVersion: "v2.0.0"}))
+
+	history, err = migration.History(testDB)
+	require.NoError(t, err)
+	require.Len(t, history, int(latest)+1)
+	for i, entry := range history[:latest] {
+		assert.Equal(t, uint64(i+1), entry.Version)
+		assert.NotEmpty(t, entry.Name)
+		assert.Equal(t, "v1.0.0", entry.JunoVersion)
+		assert.False(t, entry.Reverted)
+		require.NotNil(t, entry.Finished)
+		assert.False(t, entry.Finished.Before(entry.Started))
+	}
+	reverted := history[latest]
+	assert.Equal(t, latest, reverted.Version)
+	assert.Equal(t, "purgeDeprecatedBuckets", reverted.Name)
+	assert.Equal(t, "v2.0.0", reverted.JunoVersion)
+	assert.True(t, reverted.Reverted)
+	assert.NotNil(t, reverted.Finished)
+}
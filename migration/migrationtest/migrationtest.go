@@ -0,0 +1,216 @@
+// Package migrationtest tests migrations against fixtures, which are small databases serialized to text files: a
+// fixture of a database before a migration is loaded, the migration is applied to it, and the result is compared to
+// the fixture of the database expected after it. This spares the tests of the migrations from building the data of
+// an old format by hand.
+//
+// A fixture holds a pair per line, the key and the value in hex separated by a space, in the order of the keys.
+// Empty lines and the lines that start with # are skipped. The history of the migrations in db.SchemaMetadata,
+// which records when they ran, is neither saved to fixtures nor compared.
+package migrationtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/memory"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// update makes Run write the fixtures of the expected databases from the migrated ones instead of comparing them
+var update = flag.Bool("update-fixtures", false, "write the expected fixtures of the migration tests")
+
+// ignoredBuckets are the buckets that are not saved to fixtures nor compared
+var ignoredBuckets = []db.Bucket{db.SchemaMetadata}
+
+// Pair is a key and its value in a fixture
+type Pair struct {
+	Key   []byte
+	Value []byte
+}
+
+// Read returns the pairs of the fixture at path
+func Read(path string) ([]Pair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pairs []Pair
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		keyHex, valueHex, found := strings.Cut(text, " ")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected a key and a value", path, line)
+		}
+		key, keyErr := hex.DecodeString(keyHex)
+		value, valueErr := hex.DecodeString(strings.TrimSpace(valueHex))
+		if err = errors.Join(keyErr, valueErr); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		pairs = append(pairs, Pair{Key: key, Value: value})
+	}
+	return pairs, scanner.Err()
+}
+
+// Write writes pairs to the fixture at path, in the order of their keys
+func Write(path string, pairs []Pair) error {
+	sorted := append([]Pair(nil), pairs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Key, sorted[j].Key) < 0
+	})
+
+	var buf bytes.Buffer
+	for _, pair := range sorted {
+		fmt.Fprintf(&buf, "%x %x\n", pair.Key, pair.Value)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// Pairs returns the pairs of database, but those of the ignored buckets
+func Pairs(database db.DB) ([]Pair, error) {
+	var pairs []Pair
+	return pairs, database.View(func(txn db.Transaction) error {
+		it, err := txn.NewIterator()
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			if ignored(it.Key()) {
+				continue
+			}
+			value, valueErr := it.Value()
+			if valueErr != nil {
+				return db.CloseAndWrapOnError(it.Close, valueErr)
+			}
+			pairs = append(pairs, Pair{Key: it.Key(), Value: value})
+		}
+		return it.Close()
+	})
+}
+
+// Save writes the pairs of database to the fixture at path, for example to create the fixture of a database before
+// a migration from a database built with an older version of Juno
+func Save(database db.DB, path string) error {
+	pairs, err := Pairs(database)
+	if err != nil {
+		return err
+	}
+	return Write(path, pairs)
+}
+
+// Load returns an in-memory database holding the pairs of the fixture at path, which is closed once t completes
+func Load(t testing.TB, path string) db.DB {
+	t.Helper()
+	pairs, err := Read(path)
+	require.NoError(t, err)
+
+	database := memory.New()
+	t.Cleanup(func() {
+		require.NoError(t, database.Close())
+	})
+	batch := db.NewBatch(database)
+	for _, pair := range pairs {
+		require.NoError(t, batch.Set(pair.Key, pair.Value))
+	}
+	require.NoError(t, batch.Commit())
+	return database
+}
+
+// Diff returns the differences between the pairs of database and expected, a line per key that is missing,
+// unexpected or has another value, or nil if they match
+func Diff(database db.DB, expected []Pair) ([]string, error) {
+	actual, err := Pairs(database)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string][]byte, len(expected))
+	for _, pair := range expected {
+		if !ignored(pair.Key) {
+			want[string(pair.Key)] = pair.Value
+		}
+	}
+
+	var diff []string
+	for _, pair := range actual {
+		value, found := want[string(pair.Key)]
+		switch {
+		case !found:
+			diff = append(diff, fmt.Sprintf("+ %x %x", pair.Key, pair.Value))
+		case !bytes.Equal(value, pair.Value):
+			diff = append(diff, fmt.Sprintf("~ %x %x, expected %x", pair.Key, pair.Value, value))
+		}
+		delete(want, string(pair.Key))
+	}
+	for key, value := range want {
+		diff = append(diff, fmt.Sprintf("- %x %x", key, value))
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		// the lines are ordered by key, which follows the marker
+		return diff[i][2:] < diff[j][2:]
+	})
+	return diff, nil
+}
+
+// Run loads the fixture before, applies the migration to schema version version to it and checks the result
+// against the fixture after. The fixture before has to be at the schema version before version. With
+// -update-fixtures, the fixture after is written from the result instead.
+func Run(t *testing.T, version uint64, before, after string, network utils.Network) {
+	t.Helper()
+	database := Load(t, before)
+	schemaVersion, err := migration.SchemaVersion(database)
+	require.NoError(t, err)
+	require.Equal(t, version-1, schemaVersion, "the schema version of %s", before)
+
+	require.NoError(t, migration.MigrateTo(context.Background(), database, network, version,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+	schemaVersion, err = migration.SchemaVersion(database)
+	require.NoError(t, err)
+	require.Equal(t, version, schemaVersion)
+
+	if *update {
+		require.NoError(t, Save(database, after))
+		return
+	}
+	expected, err := Read(after)
+	require.NoError(t, err)
+	diff, err := Diff(database, expected)
+	require.NoError(t, err)
+	require.Empty(t, diff, "the migrated database does not match %s (+ unexpected, - missing, ~ changed pairs)", after)
+}
+
+// SchemaVersionPair returns the pair that sets the schema version of a database to version, to build fixtures
+func SchemaVersionPair(version uint64) Pair {
+	return Pair{Key: db.SchemaVersion.Key(), Value: binary.BigEndian.AppendUint64(nil, version)}
+}
+
+func ignored(key []byte) bool {
+	for _, bucket := range ignoredBuckets {
+		if bytes.HasPrefix(key, bucket.Key()) {
+			return true
+		}
+	}
+	return false
+}
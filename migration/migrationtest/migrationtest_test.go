@@ -0,0 +1,59 @@
+package migrationtest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/migration/migrationtest"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	tests := map[string]uint64{
+		"relocate_contract_storage_root_keys": 2,
+		"purge_deprecated_buckets":            6,
+	}
+	for name, version := range tests {
+		t.Run(name, func(t *testing.T) {
+			migrationtest.Run(t, version, filepath.Join("testdata", name+".before"),
+				filepath.Join("testdata", name+".after"), utils.MAINNET)
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture")
+	require.NoError(t, migrationtest.Write(path, []migrationtest.Pair{
+		{Key: db.Class.Key([]byte{2}), Value: []byte{2}},
+		migrationtest.SchemaVersionPair(1),
+		{Key: db.Class.Key([]byte{1}), Value: []byte{1}},
+	}))
+	database := migrationtest.Load(t, path)
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		return txn.Set(db.SchemaMetadata.Key([]byte{1}), []byte("ignored"))
+	}))
+
+	expected, err := migrationtest.Read(path)
+	require.NoError(t, err)
+	require.Len(t, expected, 3)
+	assert.Equal(t, db.Class.Key([]byte{1}), expected[0].Key, "the pairs are written in the order of their keys")
+	diff, err := migrationtest.Diff(database, expected)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		if err := txn.Set(db.Class.Key([]byte{1}), []byte{3}); err != nil {
+			return err
+		}
+		if err := txn.Delete(db.Class.Key([]byte{2})); err != nil {
+			return err
+		}
+		return txn.Set(db.ContractNonce.Key(), []byte{4})
+	}))
+	diff, err = migrationtest.Diff(database, expected)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"~ 0401 03, expected 01", "- 0402 02", "+ 05 04"}, diff)
+}
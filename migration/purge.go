@@ -0,0 +1,105 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+// deprecatedBuckets are the buckets no version of Juno writes anymore. They keep the keys old migrations did not
+// move out of them, or that a run of them that crashed left behind, until purgeDeprecatedBuckets deletes them.
+var deprecatedBuckets = []db.Bucket{db.Unused}
+
+// PurgeBucket deletes up to limit keys under bucket with txn, all of them if limit is 0, and returns the number of
+// keys it deleted. The bucket is empty once fewer than limit keys are deleted. Without a limit, the keys are only
+// counted and the bucket is deleted at once, see db.Transaction.DeletePrefix.
+func PurgeBucket(txn db.Transaction, bucket db.Bucket, limit uint64) (uint64, error) {
+	if limit == 0 {
+		var count uint64
+		err := db.IterateBucket(txn, bucket, func(_, _ []byte) error {
+			count++
+			return nil
+		}, db.KeysOnly())
+		if err != nil {
+			return 0, err
+		}
+		return count, txn.DeletePrefix(bucket.Key())
+	}
+
+	// the keys are deleted once the iteration is over, like relocateContractStorageRootKeys does
+	var keys [][]byte
+	err := db.IterateBucket(txn, bucket, func(key, _ []byte) error {
+		if limit > 0 && uint64(len(keys)) == limit {
+			return db.ErrStopIteration
+		}
+		keys = append(keys, bytes.Clone(key))
+		return nil
+	}, db.KeysOnly())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err = txn.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return uint64(len(keys)), nil
+}
+
+// purgeMigration deletes the keys under a list of buckets, a bucket at a time. It is applied with a BatchWriter,
+// which commits the deletion of each bucket, and it resumes from the buckets that are left once it is interrupted.
+type purgeMigration struct {
+	buckets  []db.Bucket
+	purged   uint64
+	progress ProgressFunc
+}
+
+// purgeDeprecatedBuckets reclaims the space of the keys left in deprecatedBuckets
+func purgeDeprecatedBuckets() *purgeMigration {
+	return &purgeMigration{buckets: deprecatedBuckets}
+}
+
+func (m *purgeMigration) writesInBatches() {}
+
+// Before is a no-op, the state of the migration is the keys left in its buckets.
+func (m *purgeMigration) Before() {}
+
+func (m *purgeMigration) OnProgress(progress ProgressFunc) {
+	m.progress = progress
+}
+
+// SetOptions is a no-op, the keys of a bucket are deleted at once whatever their number
+func (m *purgeMigration) SetOptions(MigrationOptions) {}
+
+// KeyEstimate returns the number of keys under the buckets of the migration
+func (m *purgeMigration) KeyEstimate(targetDB db.DB) (uint64, error) {
+	prefixes := make([][]byte, 0, len(m.buckets))
+	for _, bucket := range m.buckets {
+		prefixes = append(prefixes, bucket.Key())
+	}
+	return prefixesKeyCount(targetDB, prefixes...)
+}
+
+// Revert is a no-op, the previous versions of Juno do not read the purged buckets either
+func (m *purgeMigration) Revert(context.Context, db.Transaction, utils.Network) error {
+	return nil
+}
+
+// Migrate deletes the keys of the buckets, a bucket at a time
+func (m *purgeMigration) Migrate(ctx context.Context, txn db.Transaction, _ utils.Network) error {
+	for _, bucket := range m.buckets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		purged, err := PurgeBucket(txn, bucket, 0)
+		if err != nil {
+			return err
+		}
+		m.purged += purged
+		m.progress.report(m.purged, 0)
+	}
+	return nil
+}
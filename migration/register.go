@@ -0,0 +1,61 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+// ErrMigrationMismatch is returned when the history of a database records that a schema version was migrated by
+// another migration than the one registered to it, see Register
+var ErrMigrationMismatch = errors.New("the database was migrated by other migrations")
+
+// Register appends m to the migrations as the migration to schema version version, so that forks of Juno can add
+// their own migrations to the ones of this package, with the name the history of a database records it by. It is
+// called from an init function of a package that is imported by the main package, before any migration is applied.
+//
+// It panics unless version is the version after the latest one. Two migrations registered to the same version
+// collide, and so does a migration of a fork whose version Juno used for a migration of its own after an upgrade:
+// the migrations of the fork then have to be renumbered after the latest version of Juno, and the databases they
+// were applied to are refused with ErrMigrationMismatch until they are reverted by the version that applied them.
+func Register(version uint64, name string, m Migration) {
+	if m == nil {
+		panic(fmt.Sprintf("migration to schema version %d is nil", version))
+	}
+	latest := LatestSchemaVersion()
+	if version <= latest {
+		panic(fmt.Sprintf("schema version %d is already the version of a migration, the latest version is %d",
+			version, latest))
+	} else if version > latest+1 {
+		panic(fmt.Sprintf("migration to schema version %d is registered before the ones to versions %d to %d",
+			version, latest+1, version-1))
+	}
+	migrations = append(migrations, m)
+	migrationNames = append(migrationNames, name)
+}
+
+// checkHistory returns ErrMigrationMismatch if the history of the database records that one of the schema versions up
+// to version was last migrated by another migration than the one registered to it. The versions that were applied
+// before the history was recorded are not checked.
+func checkHistory(txn db.Transaction, version uint64) error {
+	history, err := readHistory(txn)
+	if err != nil {
+		return err
+	}
+	last := make(map[uint64]HistoryEntry, len(history))
+	for _, entry := range history {
+		last[entry.Version] = entry
+	}
+	for v := uint64(1); v <= version && v <= LatestSchemaVersion(); v++ {
+		entry, ok := last[v]
+		if !ok || entry.Reverted {
+			continue
+		}
+		if name := migrationNames[v-1]; entry.Name != name {
+			return fmt.Errorf("%w: schema version %d was migrated by %s, not %s", ErrMigrationMismatch, v,
+				entry.Name, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+// SpaceEstimator is implemented by the migrations that duplicate data while they run, such as the ones that
+// relocate or re-encode entries, whose old entries only free their disk space once they are compacted away
+type SpaceEstimator interface {
+	// SpaceEstimate returns an estimate of the free disk space, in bytes, the migration needs to migrate targetDB
+	SpaceEstimate(targetDB db.DB) (uint64, error)
+}
+
+// KeyEstimator is implemented by the migrations that process the entries of buckets, whose number is estimated
+// before they run so that the progress of the migrations that do not count them has a total
+type KeyEstimator interface {
+	// KeyEstimate returns an estimate of the number of entries the migration processes in targetDB
+	KeyEstimate(targetDB db.DB) (uint64, error)
+}
+
+// ErrInsufficientSpace is returned when the volume of the database does not have enough free space for the
+// migrations to apply, which are not started
+var ErrInsufficientSpace = errors.New("not enough free disk space for the migrations")
+
+// InsufficientSpaceError is the ErrInsufficientSpace of the volume of Dir
+type InsufficientSpaceError struct {
+	Dir       string
+	Required  uint64
+	Available uint64
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("%s: %d MiB are needed on the volume of %s but %d MiB are available", ErrInsufficientSpace,
+		e.Required>>20, e.Dir, e.Available>>20)
+}
+
+func (e *InsufficientSpaceError) Is(target error) bool {
+	return target == ErrInsufficientSpace
+}
+
+// checkSpace returns an InsufficientSpaceError if the volume of dir has less free space than the migrations of
+// targetDB need
+func checkSpace(targetDB db.DB, dir string, migrations []Migration) error {
+	var required uint64
+	for _, migration := range migrations {
+		estimator, ok := migration.(SpaceEstimator)
+		if !ok {
+			continue
+		}
+		estimate, err := estimator.SpaceEstimate(targetDB)
+		if err != nil {
+			return fmt.Errorf("estimate the disk space of the migrations: %w", err)
+		}
+		required += estimate
+	}
+	if required == 0 {
+		return nil
+	}
+
+	available, err := freeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("read the free disk space of %s: %w", dir, err)
+	}
+	if available < required {
+		return &InsufficientSpaceError{Dir: dir, Required: required, Available: available}
+	}
+	return nil
+}
+
+// freeSpace returns the free space of the volume of dir that is available to the process, in bytes
+func freeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// prefixesSize returns an estimate of the disk space used by the entries under prefixes in targetDB, which is 0
+// if targetDB does not estimate it
+func prefixesSize(targetDB db.DB, prefixes ...[]byte) (uint64, error) {
+	return estimatePrefixes(targetDB, db.Estimator.RangeSize, prefixes)
+}
+
+// prefixesKeyCount returns an estimate of the number of entries under prefixes in targetDB, which is 0 if targetDB
+// does not estimate it
+func prefixesKeyCount(targetDB db.DB, prefixes ...[]byte) (uint64, error) {
+	return estimatePrefixes(targetDB, db.Estimator.RangeKeyCount, prefixes)
+}
+
+func estimatePrefixes(targetDB db.DB, estimate func(db.Estimator, []byte, []byte) (uint64, error),
+	prefixes [][]byte,
+) (uint64, error) {
+	estimator, ok := targetDB.(db.Estimator)
+	if !ok {
+		return 0, nil
+	}
+
+	var total uint64
+	for _, prefix := range prefixes {
+		prefixEstimate, err := estimate(estimator, prefix, db.PrefixEnd(prefix))
+		if err != nil {
+			return 0, err
+		}
+		total += prefixEstimate
+	}
+	return total, nil
+}
+
+// estimate returns the estimates of the entries migration processes in targetDB and of their disk space, which are
+// 0 if they are not known
+func estimate(targetDB db.DB, migration Migration) (keys, size uint64, err error) {
+	if estimator, ok := migration.(KeyEstimator); ok {
+		if keys, err = estimator.KeyEstimate(targetDB); err != nil {
+			return 0, 0, err
+		}
+	}
+	if estimator, ok := migration.(SpaceEstimator); ok {
+		if size, err = estimator.SpaceEstimate(targetDB); err != nil {
+			return 0, 0, err
+		}
+	}
+	return keys, size, nil
+}
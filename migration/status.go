@@ -0,0 +1,132 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/db"
+)
+
+// Status is the schema version of a database along with the migrations applied to it and the ones this version of
+// Juno still has to apply
+type Status struct {
+	SchemaVersion       uint64
+	LatestSchemaVersion uint64
+	Applied             []AppliedMigration
+	// Pending are the schema versions the pending migrations migrate the database to, in the order they are applied
+	Pending []uint64
+}
+
+// AppliedMigration is the migration to schema version Version, Backup is the path of the backup taken before it, if
+// any. RemainingBlocks is the number of blocks the migration still has to migrate if it is applied in the background.
+// Name is empty for the migrations of a newer version of Juno.
+type AppliedMigration struct {
+	Version         uint64
+	Name            string
+	Backup          string
+	RemainingBlocks uint64
+}
+
+// GetStatus returns the Status of targetDB
+func GetStatus(targetDB db.DB) (*Status, error) {
+	version, err := SchemaVersion(targetDB)
+	if err != nil {
+		return nil, err
+	}
+	backups, err := Backups(targetDB)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make(map[uint64]uint64)
+	if err = targetDB.View(func(txn db.Transaction) error {
+		for v := uint64(1); v <= version && v <= LatestSchemaVersion(); v++ {
+			background, statusErr := backgroundStatusOf(txn, v)
+			if errors.Is(statusErr, db.ErrKeyNotFound) {
+				continue
+			} else if statusErr != nil {
+				return statusErr
+			} else if !background.Done() {
+				remaining[v] = background.Until - background.Next
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		SchemaVersion:       version,
+		LatestSchemaVersion: LatestSchemaVersion(),
+		Applied:             make([]AppliedMigration, 0, version),
+		Pending:             []uint64{},
+	}
+	for v := uint64(1); v <= version; v++ {
+		// the backups are recorded by the version they were taken at, which is the one before the migration
+		applied := AppliedMigration{
+			Version:         v,
+			Backup:          backups[v-1],
+			RemainingBlocks: remaining[v],
+		}
+		if v <= status.LatestSchemaVersion {
+			applied.Name = migrationNames[v-1]
+		}
+		status.Applied = append(status.Applied, applied)
+	}
+	for v := version + 1; v <= status.LatestSchemaVersion; v++ {
+		status.Pending = append(status.Pending, v)
+	}
+	return status, nil
+}
+
+// Compatibility is how the schema version of a database compares with the latest schema version
+type Compatibility uint8
+
+const (
+	// Current databases are used as they are
+	Current Compatibility = iota
+	// Behind databases are migrated when the node starts
+	Behind
+	// Ahead databases were migrated by a newer version of Juno, which has to revert them to be used
+	Ahead
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Current:
+		return "current"
+	case Behind:
+		return "behind"
+	case Ahead:
+		return "ahead"
+	default:
+		return fmt.Sprintf("compatibility %d", uint8(c))
+	}
+}
+
+// SchemaStatus is the Compatibility of a database at SchemaVersion. Pending are the migrations a database that is
+// Behind has to be migrated with, in the order they are applied.
+type SchemaStatus struct {
+	Compatibility       Compatibility
+	SchemaVersion       uint64
+	LatestSchemaVersion uint64
+	Pending             []PendingMigration
+}
+
+// CheckCompatibility returns whether this version of Juno can use targetDB as it is. It only reads the schema
+// version of targetDB, so that the tools that open a database read-only can check it.
+func CheckCompatibility(targetDB db.DB) (SchemaStatus, error) {
+	version, err := SchemaVersion(targetDB)
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+
+	status := SchemaStatus{SchemaVersion: version, LatestSchemaVersion: LatestSchemaVersion(), Pending: []PendingMigration{}}
+	switch {
+	case version > status.LatestSchemaVersion:
+		status.Compatibility = Ahead
+	case version < status.LatestSchemaVersion:
+		status.Compatibility = Behind
+		status.Pending = pendingMigrations(version, status.LatestSchemaVersion)
+	}
+	return status, nil
+}
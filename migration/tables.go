@@ -0,0 +1,17 @@
+package migration
+
+import "github.com/NethermindEth/juno/db"
+
+// The buckets of the migrations, see db.Table
+var (
+	schemaVersionValue = db.NewValue[uint64](db.SchemaVersion, db.Uint64Codec{})
+	chainHeightValue   = db.NewValue[uint64](db.ChainHeight, db.Uint64Codec{})
+
+	historyTable = db.NewTable[uint64, *HistoryEntry](db.SchemaMetadata, db.Uint64Codec{},
+		db.EncodedCodec[*HistoryEntry]{})
+	backgroundStatusTable = db.NewTable[uint64, *backgroundStatus](db.BackgroundMigrations, db.Uint64Codec{},
+		db.EncodedCodec[*backgroundStatus]{})
+	backupTable     = db.NewTable[uint64, []byte](db.MigrationBackups, db.Uint64Codec{}, db.BytesCodec{})
+	checkpointTable = db.NewTable[[]byte, *checkpoint](db.MigrationCheckpoints, db.BytesCodec{},
+		db.EncodedCodec[*checkpoint]{})
+)
@@ -297,6 +297,21 @@ func (mr *MockReaderMockRecorder) StateUpdateByNumber(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateUpdateByNumber", reflect.TypeOf((*MockReader)(nil).StateUpdateByNumber), arg0)
 }
 
+// StorageProof mocks base method.
+func (m *MockReader) StorageProof(arg0, arg1 *felt.Felt) (*core.StorageProof, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorageProof", arg0, arg1)
+	ret0, _ := ret[0].(*core.StorageProof)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StorageProof indicates an expected call of StorageProof.
+func (mr *MockReaderMockRecorder) StorageProof(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageProof", reflect.TypeOf((*MockReader)(nil).StorageProof), arg0, arg1)
+}
+
 // TransactionByBlockNumberAndIndex mocks base method.
 func (m *MockReader) TransactionByBlockNumberAndIndex(arg0, arg1 uint64) (core.Transaction, error) {
 	m.ctrl.T.Helper()
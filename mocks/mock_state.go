@@ -154,3 +154,33 @@ func (mr *MockStateHistoryReaderMockRecorder) ContractStorageAt(arg0, arg1, arg2
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContractStorageAt", reflect.TypeOf((*MockStateHistoryReader)(nil).ContractStorageAt), arg0, arg1, arg2)
 }
+
+// ContractStorageIterator mocks base method.
+func (m *MockStateHistoryReader) ContractStorageIterator(arg0, arg1 *felt.Felt) (core.StorageIterator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContractStorageIterator", arg0, arg1)
+	ret0, _ := ret[0].(core.StorageIterator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContractStorageIterator indicates an expected call of ContractStorageIterator.
+func (mr *MockStateHistoryReaderMockRecorder) ContractStorageIterator(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContractStorageIterator", reflect.TypeOf((*MockStateHistoryReader)(nil).ContractStorageIterator), arg0, arg1)
+}
+
+// ContractStorageLogIterator mocks base method.
+func (m *MockStateHistoryReader) ContractStorageLogIterator(arg0, arg1 *felt.Felt) (core.KeyIterator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContractStorageLogIterator", arg0, arg1)
+	ret0, _ := ret[0].(core.KeyIterator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContractStorageLogIterator indicates an expected call of ContractStorageLogIterator.
+func (mr *MockStateHistoryReaderMockRecorder) ContractStorageLogIterator(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContractStorageLogIterator", reflect.TypeOf((*MockStateHistoryReader)(nil).ContractStorageLogIterator), arg0, arg1)
+}
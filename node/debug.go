@@ -0,0 +1,137 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/webhook"
+	"github.com/cockroachdb/pebble"
+)
+
+// The handlers below are served next to the profiles of the pprof server, for `juno diag` to collect.
+
+type debugConfig struct {
+	Version string            `json:"version"`
+	Config  map[string]string `json:"config"`
+}
+
+// serveConfig serves the version and the settings of the node. The URLs are reduced to their scheme and host,
+// since providers embed credentials in their user info, path or query, and the values of the webhook headers are
+// redacted.
+func (n *Node) serveConfig(writer http.ResponseWriter, _ *http.Request) {
+	cfg := n.Config()
+	cfg.EthNode = redactURL(cfg.EthNode)
+	cfg.OTLPEndpoint = redactURL(cfg.OTLPEndpoint)
+	cfg.TelemetryEndpoint = redactURL(cfg.TelemetryEndpoint)
+	cfg.ReplicaOf = redactURL(cfg.ReplicaOf)
+
+	// The slices are shared with the config of the node, so they are copied before they are redacted
+	webhooks := make([]webhook.Config, len(cfg.Webhooks))
+	for i, hook := range cfg.Webhooks {
+		hook.URL = redactURL(hook.URL)
+		headers := make(map[string]string, len(hook.Headers))
+		for name := range hook.Headers {
+			headers[name] = redacted
+		}
+		hook.Headers = headers
+		webhooks[i] = hook
+	}
+	cfg.Webhooks = webhooks
+
+	networks := make([]utils.NetworkDefinition, len(cfg.Networks))
+	for i, network := range cfg.Networks {
+		network.FeederURL = redactURL(network.FeederURL)
+		network.GatewayURL = redactURL(network.GatewayURL)
+		networks[i] = network
+	}
+	cfg.Networks = networks
+
+	n.writeJSON(writer, debugConfig{Version: n.version, Config: cfg.Settings()})
+}
+
+const redacted = "REDACTED"
+
+// redactURL reduces rawURL to its scheme and host. A value without a host, such as a host:port, is kept unless
+// it has a path, a query or user info.
+func redactURL(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		return parsed.Scheme + "://" + parsed.Host
+	}
+	if strings.ContainsAny(rawURL, "/?@") {
+		return redacted
+	}
+	return rawURL
+}
+
+// serveRecentLogs serves the last log entries of the node, oldest first
+func (n *Node) serveRecentLogs(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := writer.Write([]byte(strings.Join(n.log.RecentLogs(), "\n"))); err != nil {
+		n.log.Debugw("Failed to write recent logs", "err", err)
+	}
+}
+
+// serveDBMetrics serves the internal metrics of the database engine, such as compactions and cache hits
+func (n *Node) serveDBMetrics(writer http.ResponseWriter, _ *http.Request) {
+	pebbleDB, ok := n.db.Impl().(*pebble.DB)
+	if !ok {
+		http.Error(writer, "database metrics are not available", http.StatusNotImplemented)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := writer.Write([]byte(pebbleDB.Metrics().String())); err != nil {
+		n.log.Debugw("Failed to write database metrics", "err", err)
+	}
+}
+
+// serveDBBackup streams a point-in-time backup of the database, to be restored with `juno db restore`. The backup is
+// taken next to the database so that its files are hard links. The errors after the first byte is written can only
+// be logged, and leave a truncated tarball.
+func (n *Node) serveDBBackup(writer http.ResponseWriter, _ *http.Request) {
+	var tmpDir string
+	if n.cfg.DatabasePath != "" {
+		tmpDir = filepath.Dir(filepath.Clean(n.cfg.DatabasePath))
+	}
+
+	n.log.Infow("Streaming a backup of the database")
+	writer.Header().Set("Content-Type", "application/x-tar")
+	writer.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=juno-backup-%s.tar", time.Now().UTC().Format("20060102T150405Z")))
+	if err := db.WriteBackup(n.db, writer, tmpDir); err != nil {
+		n.log.Errorw("Failed to stream a backup of the database", "err", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// serveMigrationHistory serves the history of the migrations applied to and reverted from the database
+func (n *Node) serveMigrationHistory(writer http.ResponseWriter, _ *http.Request) {
+	history, err := migration.History(n.db)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	n.writeJSON(writer, history)
+}
+
+func (n *Node) writeJSON(writer http.ResponseWriter, resp any) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		n.log.Errorw("Failed to marshal debug response", "err", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if _, err = writer.Write(body); err != nil {
+		n.log.Debugw("Failed to write debug response", "err", err)
+	}
+}
@@ -5,36 +5,98 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	stdsync "sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/analytics"
+	"github.com/NethermindEth/juno/audit"
 	"github.com/NethermindEth/juno/blockchain"
 	"github.com/NethermindEth/juno/clients/feeder"
 	"github.com/NethermindEth/juno/clients/gateway"
 	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/cached"
+	"github.com/NethermindEth/juno/db/freezer"
 	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/remote"
+	"github.com/NethermindEth/juno/db/slowlog"
+	"github.com/NethermindEth/juno/db/split"
+	"github.com/NethermindEth/juno/db/tiered"
+	"github.com/NethermindEth/juno/devnet"
+	"github.com/NethermindEth/juno/eventfilters"
+	"github.com/NethermindEth/juno/feedergateway"
+	"github.com/NethermindEth/juno/graphql"
 	"github.com/NethermindEth/juno/grpc"
+	"github.com/NethermindEth/juno/indexer"
+	"github.com/NethermindEth/juno/jobs"
 	"github.com/NethermindEth/juno/jsonrpc"
 	"github.com/NethermindEth/juno/l1"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/NethermindEth/juno/memory"
+	"github.com/NethermindEth/juno/mempool"
 	"github.com/NethermindEth/juno/metrics"
 	"github.com/NethermindEth/juno/migration"
 	"github.com/NethermindEth/juno/p2p"
+	"github.com/NethermindEth/juno/plugin"
 	"github.com/NethermindEth/juno/pprof"
+	"github.com/NethermindEth/juno/prune"
+	"github.com/NethermindEth/juno/recentevents"
 	"github.com/NethermindEth/juno/rpc"
+	"github.com/NethermindEth/juno/selfcheck"
 	"github.com/NethermindEth/juno/service"
 	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/statediff"
+	"github.com/NethermindEth/juno/sweeper"
 	"github.com/NethermindEth/juno/sync"
+	"github.com/NethermindEth/juno/telemetry"
+	"github.com/NethermindEth/juno/tiering"
+	"github.com/NethermindEth/juno/tracing"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/juno/validator"
 	"github.com/NethermindEth/juno/vm"
+	"github.com/NethermindEth/juno/watchdog"
+	"github.com/NethermindEth/juno/webhook"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/sourcegraph/conc"
 )
 
-const defaultPprofPort = 9080
+const (
+	defaultPprofPort = 9080
+	// serviceStopTimeout is how long each stage of services is given to stop on shutdown
+	serviceStopTimeout = 30 * time.Second
+
+	dbBlockCacheName = "db-block-cache"
+	mebibyte         = 1 << 20
+	// coldDBBlockCacheSize is the size of the block cache of the cold database, the cold data is rarely read so
+	// the cache is not part of the memory budget
+	coldDBBlockCacheSize = 32 * mebibyte
+
+	// watchdogInterval is how frequently the watchdog checks the subsystems
+	watchdogInterval = time.Minute
+	// migrationStallTimeout is how long a single transaction of a migration may take, migrations rewrite
+	// large parts of the database so they are given much more time than the other commits
+	migrationStallTimeout = 6 * time.Hour
+	// telemetryInterval is how frequently telemetry is reported
+	telemetryInterval = time.Hour
+)
+
+// memoryWeights apportion the memory budget. The trie nodes are read through the DB block cache, which gets the
+// largest share for that reason, unless they are cached with DatabaseReadCache.
+var memoryWeights = map[string]uint64{
+	dbBlockCacheName:           55,
+	blockchain.HeaderCacheName: 10,
+	blockchain.EventCacheName:  20,
+	blockchain.ProofCacheName:  5,
+	vm.ClassCacheName:          10,
+}
+
+// dbReadCacheWeight is the weight of each of the caches of Config.DatabaseReadCache in the memory budget
+const dbReadCacheWeight = 10
 
 // Config is the top-level juno configuration.
 type Config struct {
@@ -43,11 +105,33 @@ type Config struct {
 	WSPort              uint16         `mapstructure:"ws-port"`
 	GRPCPort            uint16         `mapstructure:"grpc-port"`
 	DatabasePath        string         `mapstructure:"db-path"`
-	Network             utils.Network  `mapstructure:"network"`
-	EthNode             string         `mapstructure:"eth-node"`
-	Pprof               bool           `mapstructure:"pprof"`
-	Colour              bool           `mapstructure:"colour"`
-	PendingPollInterval time.Duration  `mapstructure:"pending-poll-interval"`
+	DatabaseBackend     string         `mapstructure:"db-backend"`
+	DatabaseCompression []string       `mapstructure:"db-compress"`
+	// DatabaseChecksum are the names of the db.ChecksummableBuckets whose values are checksummed when they are
+	// written, and DatabaseChecksumVerify what is done when a checksum does not match, fail, log or off
+	DatabaseChecksum       []string      `mapstructure:"db-checksum"`
+	DatabaseChecksumVerify string        `mapstructure:"db-checksum-verify"`
+	Network                utils.Network `mapstructure:"network"`
+	EthNode                string        `mapstructure:"eth-node"`
+	Pprof                  bool          `mapstructure:"pprof"`
+	Colour                 bool          `mapstructure:"colour"`
+	PendingPollInterval    time.Duration `mapstructure:"pending-poll-interval"`
+
+	// DatabaseCacheSize, DatabaseMemTableSize and DatabaseWALSyncSize, in MiB, DatabaseMaxOpenFiles,
+	// DatabaseCompactionConcurrency and DatabaseWALDir tune the storage engine of the database, see db.Tuning. The
+	// block cache is given its share of the memory budget unless DatabaseCacheSize is set.
+	DatabaseCacheSize             uint   `mapstructure:"db-cache-size"`
+	DatabaseMemTableSize          uint   `mapstructure:"db-memtable-size"`
+	DatabaseMaxOpenFiles          uint   `mapstructure:"db-max-open-files"`
+	DatabaseCompactionConcurrency uint   `mapstructure:"db-compaction-concurrency"`
+	DatabaseWALDir                string `mapstructure:"db-wal-dir"`
+	DatabaseWALSyncSize           uint   `mapstructure:"db-wal-sync-size"`
+	// DatabaseSlowThreshold is the time after which the operations of the chain on the database are logged as slow,
+	// see slowlog.DB. Zero disables the logging.
+	DatabaseSlowThreshold time.Duration `mapstructure:"db-slow-threshold"`
+	// DatabaseWALArchiveDir is the directory the write-ahead logs of the database are archived to, so that it can be
+	// restored to a point in time, see db.RestoreToPoint. The logs are deleted if it is empty.
+	DatabaseWALArchiveDir string `mapstructure:"db-wal-archive-dir"`
 
 	Metrics     bool   `mapstructure:"metrics"`
 	MetricsPort uint16 `mapstructure:"metrics-port"`
@@ -55,24 +139,183 @@ type Config struct {
 	P2P          bool   `mapstructure:"p2p"`
 	P2PAddr      string `mapstructure:"p2p-addr"`
 	P2PBootPeers string `mapstructure:"p2p-boot-peers"`
+
+	FeederGatewayPort uint16 `mapstructure:"feeder-gateway-port"`
+
+	OTLPEndpoint    string  `mapstructure:"otlp-endpoint"`
+	OTLPInsecure    bool    `mapstructure:"otlp-insecure"`
+	OTLPSampleRatio float64 `mapstructure:"otlp-sample-ratio"`
+
+	GraphQLPort uint16 `mapstructure:"graphql-port"`
+
+	// ABIDecoding stores the ABIs of the declared classes and decodes events and calldata with them in the GraphQL
+	// API and the webhooks that set decode
+	ABIDecoding bool `mapstructure:"abi-decoding"`
+
+	// MemoryBudget is the memory in MiB shared by the DB block cache and the caches of the blockchain
+	MemoryBudget uint `mapstructure:"memory-budget"`
+
+	// StallTimeout is how long sync may store no block while the network advances, or a database commit may
+	// take, before the watchdog reports them as stalled. Zero disables the watchdog.
+	StallTimeout    time.Duration `mapstructure:"stall-timeout"`
+	WatchdogRestart bool          `mapstructure:"watchdog-restart"`
+
+	// Audit re-executes the stored blocks from AuditFrom and reports those that do not match their receipts
+	Audit     bool   `mapstructure:"audit"`
+	AuditFrom uint64 `mapstructure:"audit-from"`
+
+	// TelemetryEndpoint is the URL anonymous telemetry is reported to, telemetry is disabled if it is empty
+	TelemetryEndpoint string `mapstructure:"telemetry-endpoint"`
+
+	// ReplicaOf is the host:port of the gRPC server of a primary node. A replica reads the database of its
+	// primary instead of its own and does not sync, so that RPC reads can be spread over several nodes.
+	ReplicaOf string `mapstructure:"replica-of"`
+
+	// RPC methods that run the VM are capped by the RPCMaxSteps, RPCMaxMemory and RPCExecutionTimeout limits,
+	// the non-zero limits of RPCMethodLimits override them for the methods they are keyed by
+	RPCMaxSteps         uint64               `mapstructure:"rpc-max-steps"`
+	RPCMaxMemory        uint                 `mapstructure:"rpc-max-memory"`
+	RPCExecutionTimeout time.Duration        `mapstructure:"rpc-execution-timeout"`
+	RPCMethodLimits     map[string]vm.Limits `mapstructure:"rpc-method-limits"`
+	// The responses of the RPC methods that can return large responses are capped to RPCMaxResponseSize MiB, the
+	// non-zero sizes of RPCMethodResponseSizes override it for the methods they are keyed by
+	RPCMaxResponseSize     uint            `mapstructure:"rpc-max-response-size"`
+	RPCMethodResponseSizes map[string]uint `mapstructure:"rpc-method-response-sizes"`
+
+	// IndexEvents builds the optional index of the blocks with events of each contract, optional indexes are
+	// built in the background at up to IndexBackfillRate blocks per second
+	IndexEvents       bool `mapstructure:"index-events"`
+	IndexBackfillRate uint `mapstructure:"index-backfill-rate"`
+	// JobConcurrency is the number of steps of the background jobs that run at once, see jobs.Manager
+	JobConcurrency int `mapstructure:"job-concurrency"`
+
+	// Labels are the files of the labels of contract addresses and selectors, which are shown by the APIs and
+	// logs
+	Labels []string `mapstructure:"labels"`
+
+	// RecentEventsBlocks is the number of recent blocks whose events are kept in memory for juno_getRecentEvents,
+	// which may be called RecentEventsRate times per second
+	RecentEventsBlocks uint64 `mapstructure:"recent-events-blocks"`
+	RecentEventsRate   uint   `mapstructure:"recent-events-rate"`
+	// EventFilters is the number of the event filters that clients can install at most, they are stored in the
+	// database
+	EventFilters uint `mapstructure:"event-filters"`
+
+	// AnalyticsDays is the number of days whose aggregates of the activity of the chain are kept for
+	// juno_getDailyAnalytics, zero disables them
+	AnalyticsDays uint64 `mapstructure:"analytics-days"`
+
+	// MigrationBackupDir is the directory the database is backed up to before the migrations that rewrite it, it
+	// is not backed up if it is empty
+	MigrationBackupDir string `mapstructure:"migration-backup-dir"`
+	// MigrationWorkers, MigrationBatchSize, MigrationMemoryBudget, in MiB, and MigrationChunkSize tune the migrations
+	// that process blocks and the ones that rewrite buckets, see migration.MigrationOptions
+	MigrationWorkers      int    `mapstructure:"migration-workers"`
+	MigrationBatchSize    uint64 `mapstructure:"migration-batch-size"`
+	MigrationMemoryBudget uint64 `mapstructure:"migration-memory-budget"`
+	MigrationChunkSize    uint64 `mapstructure:"migration-chunk-size"`
+	// MigrateInBackground applies the migrations that only rewrite the data of each block while the node serves,
+	// see migration.Background
+	MigrateInBackground bool `mapstructure:"migrate-in-background"`
+	// RefuseToMigrate fails the start of the node if the database has pending migrations instead of applying them,
+	// and does not resume the migrations deferred to the background, see migration.MigrationOptions.Refuse
+	RefuseToMigrate bool `mapstructure:"refuse-to-migrate"`
+	// MigrationOnInterrupted is what is done with a migration that was interrupted, the name of a
+	// migration.InterruptedPolicy
+	MigrationOnInterrupted string `mapstructure:"migration-on-interrupted"`
+
+	// ColdDatabasePath is the path of the database the data of the categories of ColdAfter is moved to once it is
+	// older than their ages in blocks, as category=age pairs. The cold data is not moved if it is empty.
+	ColdDatabasePath string   `mapstructure:"cold-db-path"`
+	ColdAfter        []string `mapstructure:"cold-after"`
+	// ColdDatabaseBackend is the backend of the cold database, that of the database if it is empty. The freezer
+	// backend only holds the bodies and the state updates of the blocks.
+	ColdDatabaseBackend string `mapstructure:"cold-db-backend"`
+	// StateDatabasePath is the path of the database the state is kept in, apart from the history of the chain, see
+	// split.DB. The state is kept with the chain if it is empty.
+	StateDatabasePath string `mapstructure:"state-db-path"`
+	// DatabaseReadCache are the caches of the values of the database that are read, see cached.Caches. Each is given
+	// dbReadCacheWeight of the memory budget. The reads of a replica are not cached.
+	DatabaseReadCache []string `mapstructure:"db-read-cache"`
+	// StateHistoryBlocks is the number of blocks behind the head whose state is kept, the history of the state of the
+	// blocks before is pruned, see prune.Pruner. The whole history is kept if it is zero.
+	StateHistoryBlocks uint64 `mapstructure:"state-history-blocks"`
+
+	// Devnet runs a local network whose blocks are produced every DevnetBlockTime from the submitted transactions,
+	// instead of syncing Network. Its genesis block funds DevnetAccounts accounts of DevnetAccountClass, derived
+	// from DevnetSeed, with the fee token of DevnetFeeTokenClass.
+	Devnet              bool          `mapstructure:"devnet"`
+	DevnetBlockTime     time.Duration `mapstructure:"devnet-block-time"`
+	DevnetAccounts      uint64        `mapstructure:"devnet-accounts"`
+	DevnetSeed          uint64        `mapstructure:"devnet-seed"`
+	DevnetAccountClass  string        `mapstructure:"devnet-account-class"`
+	DevnetFeeTokenClass string        `mapstructure:"devnet-fee-token-class"`
+
+	// ForensicsDir is the directory the forensic reports of the blocks whose state root does not match the local
+	// state are written to, the forensics directory next to the database if it is empty
+	ForensicsDir string `mapstructure:"forensics-dir"`
+
+	// Webhooks are the URLs notified of the chain, they are only set in the configuration file
+	Webhooks []webhook.Config `mapstructure:"webhooks"`
+
+	// Networks are the networks that are not built into Juno, Network can be set to any of them
+	Networks []utils.NetworkDefinition `mapstructure:"networks"`
+	// Verification overrides how the blocks of the networks are verified, by network name
+	Verification map[string]utils.Verification `mapstructure:"verification"`
+}
+
+// Settings returns the values of the options of c by their name in the configuration file
+func (c *Config) Settings() map[string]string {
+	settings := make(map[string]string)
+	cfgValue := reflect.ValueOf(*c)
+	for i := 0; i < cfgValue.NumField(); i++ {
+		settings[cfgValue.Type().Field(i).Tag.Get("mapstructure")] = fmt.Sprint(cfgValue.Field(i).Interface())
+	}
+	return settings
 }
 
 type Node struct {
-	cfg        *Config
+	cfg *Config
+	// cfgLock guards the settings of cfg that Reload changes while the node runs, the others are not changed once
+	// the node is created
+	cfgLock    stdsync.RWMutex
 	db         db.DB
 	blockchain *blockchain.Blockchain
 
-	services []service.Service
-	log      utils.Logger
+	// services are stopped in stages: the servers stop accepting requests and drain the ones in flight,
+	// then sync finishes the block it is committing, and finally telemetry flushes what was reported
+	lifecycle       *service.Lifecycle
+	apiServices     *service.Stage
+	syncServices    *service.Stage
+	monitorServices *service.Stage
+	synchronizer    *sync.Synchronizer
+	budget          *memory.Budget
+	watchdog        *watchdog.Watchdog
+	metricServer    *metrics.Metrics
+	migrating       atomic.Bool
+	migrations      *migrationTracker
+	ready           atomic.Bool
+	log             *utils.ZapLogger
+	migrationLog    utils.SimpleLogger
 
 	version string
 }
 
 // New sets the config and logger to the StarknetNode.
 // Any errors while parsing the config on creating logger will be returned.
-func New(cfg *Config, version string) (*Node, error) { //nolint:gocyclo
+func New(cfg *Config, version string) (*Node, error) { //nolint:gocyclo,funlen
 	metrics.Enabled = cfg.Metrics
 
+	if cfg.Devnet {
+		if cfg.ReplicaOf != "" {
+			return nil, errors.New("a replica cannot run a devnet")
+		}
+		network, err := devnet.Network()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Network = network
+	}
 	if cfg.DatabasePath == "" {
 		dirPrefix, err := utils.DefaultDataDir()
 		if err != nil {
@@ -85,38 +328,276 @@ func New(cfg *Config, version string) (*Node, error) { //nolint:gocyclo
 		return nil, err
 	}
 
-	dbLog, err := utils.NewZapLogger(utils.ERROR, cfg.Colour)
+	// the DB is only noisy below the error level, unless it is being debugged
+	dbLog := log.Module("db")
+	if err = log.SetModuleLevel("db", utils.ERROR); err != nil {
+		return nil, fmt.Errorf("set DB log level: %w", err)
+	}
+
+	registry, err := labels.Load(cfg.Labels...)
+	if err != nil {
+		return nil, fmt.Errorf("load labels: %w", err)
+	}
+
+	coldPolicy, err := tiering.ParsePolicy(cfg.ColdAfter)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := db.NewCompression(cfg.DatabaseCompression)
+	if err != nil {
+		return nil, err
+	}
+	checksumMode, err := db.ParseChecksumMode(cfg.DatabaseChecksumVerify)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := db.NewChecksums(cfg.DatabaseChecksum, checksumMode)
 	if err != nil {
-		return nil, fmt.Errorf("create DB logger: %w", err)
+		return nil, err
 	}
+	checksums = checksums.WithReport(func(key []byte, err error) {
+		dbLog.Errorw("Read a corrupted value from the DB", "bucket", key[0], "err", err)
+	})
 
-	database, err := pebble.New(cfg.DatabasePath, dbLog)
+	weights := memoryWeights
+	if len(cfg.DatabaseReadCache) > 0 && cfg.ReplicaOf == "" {
+		weights = make(map[string]uint64, len(memoryWeights)+len(cfg.DatabaseReadCache))
+		for name, weight := range memoryWeights {
+			weights[name] = weight
+		}
+		for _, name := range cfg.DatabaseReadCache {
+			weights[cached.ConsumerName(name)] = dbReadCacheWeight
+		}
+	}
+	budget := memory.NewBudget(uint64(cfg.MemoryBudget)*mebibyte, weights, log)
+	database, err := openDB(cfg, coldPolicy, compression, checksums, budget, dbLog)
 	if err != nil {
 		return nil, fmt.Errorf("open DB: %w", err)
 	}
+	replica := cfg.ReplicaOf != ""
+
+	lifecycle := service.NewLifecycle(serviceStopTimeout, log)
+	apiServices := lifecycle.Stage("api")
+	syncServices := lifecycle.Stage("sync")
+	monitorServices := lifecycle.Stage("monitoring")
+
+	if cfg.OTLPEndpoint != "" {
+		// the exporter is set up first, so that all the other services trace to it
+		exporter, err := tracing.New(cfg.OTLPEndpoint, cfg.OTLPInsecure, cfg.OTLPSampleRatio, version, log)
+		if err != nil {
+			return nil, fmt.Errorf("set up tracing: %w", err)
+		}
+		monitorServices.Add(exporter)
+	}
 
-	chain := blockchain.New(database, cfg.Network, log)
+	headerCache, receiptCache := blockchain.NewHeaderCache(), blockchain.NewReceiptCache()
+	if err = budget.Register(blockchain.HeaderCacheName, headerCache); err != nil {
+		return nil, err
+	}
+	if err = budget.Register(blockchain.EventCacheName, receiptCache); err != nil {
+		return nil, err
+	}
+	proofCache := blockchain.NewProofCache()
+	if err = budget.Register(blockchain.ProofCacheName, proofCache); err != nil {
+		return nil, err
+	}
+	classCache := vm.NewClassCache()
+	if err = budget.Register(vm.ClassCacheName, classCache); err != nil {
+		return nil, err
+	}
+	monitorServices.Add(budget)
+
+	chainDB := database
+	if cfg.DatabaseSlowThreshold > 0 {
+		// only the operations of the chain are logged, the migrations and the background jobs are slow by design
+		chainDB = slowlog.New(database, cfg.DatabaseSlowThreshold, dbLog)
+	}
+	// the proofs are cached by state commitment, so those of a replica are never stale even though they are not
+	// dropped when the primary changes its head
+	chain := blockchain.New(chainDB, cfg.Network, log).WithProofCache(proofCache)
+	if !replica {
+		// the caches of a replica would not be invalidated when the primary reverts blocks
+		chain = chain.WithCaches(headerCache, receiptCache)
+	}
+	var decoder *abi.Decoder
+	if cfg.ABIDecoding {
+		// the ABIs of a replica are stored by its primary, and read by the decoder all the same
+		if !replica {
+			chain = chain.WithABIs()
+		}
+		decoder = abi.NewDecoder(database)
+	}
 	client := feeder.NewClient(cfg.Network.FeederURL())
 
-	synchronizer := sync.New(chain, adaptfeeder.New(client), log, cfg.PendingPollInterval)
+	hooks := plugin.NewHooks(plugin.Registered(), log.Module("plugin"))
+	if err = hooks.Init(chain); err != nil {
+		return nil, err
+	}
+
+	starknetData := adaptfeeder.New(client)
+	forensicsDir := cfg.ForensicsDir
+	if forensicsDir == "" {
+		forensicsDir = filepath.Join(filepath.Dir(cfg.DatabasePath), "forensics")
+	}
+	synchronizer := sync.New(chain, starknetData, log.Module("sync"), cfg.PendingPollInterval).WithHooks(hooks).
+		WithForensics(database, forensicsDir)
 	gatewayClient := gateway.NewClient(cfg.Network.GatewayURL(), log)
 
-	rpcHandler := rpc.New(chain, synchronizer, cfg.Network, gatewayClient, client, vm.New(), version, log)
-	services, err := makeRPC(cfg.HTTPPort, cfg.WSPort, rpcHandler, log)
+	jobsDB := database
+	if replica {
+		// the database of a replica is read-only, the histories of its jobs are only kept in memory
+		jobsDB = nil
+	}
+	jobManager := jobs.New(jobsDB, cfg.JobConcurrency, log.Module("jobs"))
+
+	rpcLog := log.Module("rpc")
+	virtualMachine := vm.New(classCache)
+	rpcHandler := rpc.New(chain, synchronizer, cfg.Network, gatewayClient, client, virtualMachine, version, rpcLog).
+		WithExecutionLimits(vm.Limits{
+			MaxSteps:  cfg.RPCMaxSteps,
+			MaxMemory: cfg.RPCMaxMemory,
+			Timeout:   cfg.RPCExecutionTimeout,
+		}, cfg.RPCMethodLimits).
+		WithMaxResponseSizes(uint64(cfg.RPCMaxResponseSize)<<20, responseSizes(cfg.RPCMethodResponseSizes)).
+		WithLabels(registry).
+		WithSchema(database).
+		WithJobs(jobManager)
+	migration.RegisterMetrics(database)
+	if meterer, ok := database.(db.Meterer); ok {
+		metrics.MustRegister(meterer.Meter())
+	}
+	if !replica {
+		rpcHandler = rpcHandler.WithSubmittedTransactions(chain)
+	}
+	pool := mempool.New(gatewayClient, log.Module("mempool"))
+	rpcHandler = rpcHandler.WithPool(pool)
+	var recentEvents *recentevents.Ring
+	if cfg.RecentEventsBlocks > 0 {
+		recentEvents = recentevents.New(chain, cfg.RecentEventsBlocks, log.Module("recentevents"))
+		rpcHandler = rpcHandler.WithRecentEvents(recentEvents, cfg.RecentEventsRate)
+	}
+	if cfg.EventFilters > 0 && !replica {
+		rpcHandler = rpcHandler.WithEventFilters(eventfilters.New(database, chain, int(cfg.EventFilters)))
+	}
+	var localNet *devnet.Devnet
+	if cfg.Devnet {
+		if localNet, err = newDevnet(cfg, database, chain, virtualMachine, log.Module("devnet")); err != nil {
+			return nil, fmt.Errorf("set up devnet: %w", err)
+		}
+		rpcHandler = rpcHandler.WithDevnet(localNet)
+	}
+	var aggregator *analytics.Aggregator
+	if cfg.AnalyticsDays > 0 {
+		aggregator = analytics.New(database, cfg.AnalyticsDays, log.Module("analytics"))
+		rpcHandler = rpcHandler.WithAnalytics(aggregator)
+	}
+	streams := map[string]jsonrpc.Stream{
+		"/state_diffs": statediff.New(chain, log.Module("statediff")).Stream,
+	}
+	rpcServices, err := makeRPC(cfg.HTTPPort, cfg.WSPort, rpcHandler, streams, hooks.RPCMiddlewares(), rpcLog)
 	if err != nil {
 		return nil, fmt.Errorf("create RPC servers: %w", err)
 	}
+	apiServices.Add(rpcServices...)
+	apiServices.Add(pool)
+	restartableSync := watchdog.NewRestartable(synchronizer)
+	if localNet != nil {
+		// the blocks of a devnet are produced instead of synced
+		syncServices.Add(localNet)
+	} else if !replica {
+		// replicas serve the blocks that their primary syncs
+		syncServices.Add(restartableSync)
+	}
+	syncServices.Add(hooks.Services()...)
+	migrations := new(migrationTracker)
+	if !replica && !cfg.RefuseToMigrate {
+		// the migrations deferred to the background resume even if the node is restarted without
+		// MigrateInBackground, they do nothing once they are complete
+		syncServices.Add(migration.NewBackground(database, cfg.Network, log.Module("migration"),
+			migration.MigrationOptions{
+				Workers:      cfg.MigrationWorkers,
+				BatchSize:    cfg.MigrationBatchSize,
+				MemoryBudget: cfg.MigrationMemoryBudget << 20,
+				OnProgress:   migrations.onProgress(true),
+			}))
+	}
+	if indexes := optionalIndexes(cfg); len(indexes) > 0 && !replica {
+		// the primary of a replica builds the indexes it reads
+		options := jobs.Options{Priority: jobs.High, Interval: indexer.HeadPollInterval}
+		if cfg.IndexBackfillRate > 0 {
+			options.StepInterval = time.Second / time.Duration(cfg.IndexBackfillRate)
+		}
+		jobManager.Add("indexer", indexer.New(database, indexes, log.Module("indexer")), options)
+	}
+	if len(cfg.Webhooks) > 0 && !replica {
+		// the journal of the webhooks is written to the database, so the primary of a replica sends them
+		dispatcher, webhookErr := webhook.New(database, chain, cfg.Webhooks, log.Module("webhook"))
+		if webhookErr != nil {
+			return nil, fmt.Errorf("set up webhooks: %w", webhookErr)
+		}
+		if decoder != nil {
+			dispatcher = dispatcher.WithDecoder(decoder)
+		}
+		syncServices.Add(dispatcher)
+	}
+	if aggregator != nil && !replica {
+		// the aggregates are written to the database, so the primary of a replica keeps them up to date
+		syncServices.Add(aggregator)
+	}
+	if recentEvents != nil {
+		syncServices.Add(recentEvents)
+	}
+	if tieredDB, ok := database.(*tiered.DB); ok {
+		jobManager.Add("tiering", tiering.New(tieredDB, chain, coldPolicy, log.Module("tiering")),
+			jobs.Options{Priority: jobs.Normal, Interval: tiering.MoveInterval})
+	}
+	if !replica {
+		// the transient data of a replica is that of its primary
+		jobManager.Add("sweeper", sweeper.New(database, log.Module("sweeper")),
+			jobs.Options{Priority: jobs.Low, Interval: sweeper.SweepInterval})
+	}
+	if cfg.StateHistoryBlocks > 0 && !replica {
+		jobManager.Add("prune", prune.New(database, chain, cfg.StateHistoryBlocks, log.Module("prune")),
+			jobs.Options{Priority: jobs.Low, Interval: prune.PruneInterval})
+	}
+	if cfg.Audit {
+		jobManager.Add("audit", audit.New(chain, virtualMachine, cfg.Network, cfg.AuditFrom, log.Module("audit")).
+			WithLabels(registry), jobs.Options{Priority: jobs.Low, Interval: audit.HeadPollInterval})
+	}
+	if jobManager.Len() > 0 {
+		syncServices.Add(jobManager)
+	}
 
 	n := &Node{
-		cfg:        cfg,
-		log:        log,
-		version:    version,
-		db:         database,
-		blockchain: chain,
-		services:   append(services, synchronizer),
+		cfg:             cfg,
+		log:             log,
+		version:         version,
+		db:              database,
+		blockchain:      chain,
+		lifecycle:       lifecycle,
+		apiServices:     apiServices,
+		syncServices:    syncServices,
+		monitorServices: monitorServices,
+		synchronizer:    synchronizer,
+		budget:          budget,
+		migrationLog:    log.Module("migration"),
+		migrations:      migrations,
+	}
+
+	if cfg.StallTimeout > 0 {
+		n.watchdog = n.newWatchdog(starknetData, restartableSync)
 	}
 
-	if n.cfg.EthNode == "" {
+	if verification, ok := cfg.Network.Verification(); ok &&
+		(verification.TrustTransactionHashes || verification.TrustBlockHashes) {
+		n.log.Warnw("Trusting the hashes of the feeder gateway, the blocks are not fully verified",
+			"transactionHashes", verification.TrustTransactionHashes, "blockHashes", verification.TrustBlockHashes)
+	}
+	if replica {
+		n.log.Infow("Reading the database of the primary node", "primary", cfg.ReplicaOf)
+	} else if localNet != nil {
+		n.log.Infow("Running a devnet", "blockTime", cfg.DevnetBlockTime, "accounts", cfg.DevnetAccounts)
+	} else if n.cfg.EthNode == "" {
 		n.log.Warnw("Ethereum node address not found; will not verify against L1")
 	} else {
 		ethNodeURL, err := url.Parse(n.cfg.EthNode)
@@ -131,15 +612,21 @@ func New(cfg *Config, version string) (*Node, error) { //nolint:gocyclo
 			return nil, fmt.Errorf("create L1 client: %w", err)
 		}
 
-		n.services = append(n.services, l1Client)
+		n.syncServices.Add(l1Client)
 	}
 
 	if n.cfg.Pprof {
-		n.services = append(n.services, pprof.New(defaultPprofPort, n.log))
+		n.monitorServices.Add(pprof.New(defaultPprofPort, n.log).
+			WithHandler("/debug/log/level", n.log.LevelHandler()).
+			WithHandler("/debug/logs", http.HandlerFunc(n.serveRecentLogs)).
+			WithHandler("/debug/config", http.HandlerFunc(n.serveConfig)).
+			WithHandler("/debug/db", http.HandlerFunc(n.serveDBMetrics)).
+			WithHandler("/debug/db/backup", http.HandlerFunc(n.serveDBBackup)).
+			WithHandler("/debug/migrations", http.HandlerFunc(n.serveMigrationHistory)))
 	}
 
 	if n.cfg.GRPCPort > 0 {
-		n.services = append(n.services, grpc.NewServer(n.cfg.GRPCPort, n.version, n.db, n.log))
+		n.apiServices.Add(grpc.NewServer(n.cfg.GRPCPort, n.version, n.db, n.log))
 	}
 
 	if cfg.P2P {
@@ -149,7 +636,7 @@ func New(cfg *Config, version string) (*Node, error) { //nolint:gocyclo
 			return nil, fmt.Errorf("set up p2p service: %w", err)
 		}
 
-		n.services = append(n.services, p2pService)
+		n.syncServices.Add(p2pService)
 	}
 
 	if n.cfg.Metrics {
@@ -157,15 +644,258 @@ func New(cfg *Config, version string) (*Node, error) { //nolint:gocyclo
 		if err != nil {
 			return nil, fmt.Errorf("listen on metric port %d: %w", n.cfg.MetricsPort, err)
 		}
-		metricServer := metrics.New(metricsListener)
+		// the metrics are served during the migrations too, so the server runs apart from the other services
+		n.metricServer = metrics.New(metricsListener).WithHandler("/status", http.HandlerFunc(n.serveStatus))
+	}
+
+	if n.cfg.FeederGatewayPort > 0 {
+		feederGatewayListener, err := net.Listen("tcp", fmt.Sprintf(":%d", n.cfg.FeederGatewayPort))
+		if err != nil {
+			return nil, fmt.Errorf("listen on feeder gateway port %d: %w", n.cfg.FeederGatewayPort, err)
+		}
+
+		n.apiServices.Add(feedergateway.New(chain, feederGatewayListener, log))
+	}
+
+	if n.cfg.TelemetryEndpoint != "" {
+		reporter, err := telemetry.New(n.cfg.TelemetryEndpoint, telemetryInterval, chain, cfg.Network, version,
+			log.Module("telemetry"))
+		if err != nil {
+			return nil, fmt.Errorf("set up telemetry: %w", err)
+		}
 
-		n.services = append(n.services, metricServer)
+		n.monitorServices.Add(reporter)
+	}
+
+	if n.cfg.GraphQLPort > 0 {
+		graphQLListener, err := net.Listen("tcp", fmt.Sprintf(":%d", n.cfg.GraphQLPort))
+		if err != nil {
+			return nil, fmt.Errorf("listen on graphql port %d: %w", n.cfg.GraphQLPort, err)
+		}
+
+		graphQLServer := graphql.New(chain, registry, graphQLListener, log)
+		if decoder != nil {
+			graphQLServer = graphQLServer.WithDecoder(decoder)
+		}
+		n.apiServices.Add(graphQLServer)
 	}
 
 	return n, nil
 }
 
-func makeRPC(httpPort, wsPort uint16, rpcHandler *rpc.Handler, log utils.SimpleLogger) ([]service.Service, error) { //nolint: funlen
+// responseSizes converts the response sizes of methods from MiB to bytes
+func responseSizes(methodSizes map[string]uint) map[string]uint64 {
+	sizes := make(map[string]uint64, len(methodSizes))
+	for method, size := range methodSizes {
+		sizes[method] = uint64(size) << 20
+	}
+	return sizes
+}
+
+// newDevnet returns the devnet of cfg, whose genesis block funds accounts derived from the seed of cfg
+func newDevnet(cfg *Config, database db.DB, chain *blockchain.Blockchain, virtualMachine vm.VM,
+	log utils.SimpleLogger,
+) (*devnet.Devnet, error) {
+	if cfg.DevnetAccountClass == "" || cfg.DevnetFeeTokenClass == "" {
+		return nil, errors.New("the account and the fee token classes of the devnet are required")
+	}
+	if cfg.DevnetBlockTime <= 0 {
+		return nil, errors.New("the block time of the devnet must be positive")
+	}
+	accountClass, err := devnet.LoadClass(cfg.DevnetAccountClass)
+	if err != nil {
+		return nil, err
+	}
+	feeTokenClass, err := devnet.LoadClass(cfg.DevnetFeeTokenClass)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := &devnet.Genesis{
+		FeeTokenClass: feeTokenClass,
+		AccountClass:  accountClass,
+		Accounts:      devnet.NewAccounts(accountClass, cfg.DevnetAccounts, cfg.DevnetSeed),
+		Balance:       devnet.DefaultBalance,
+	}
+	return devnet.New(database, chain, virtualMachine, cfg.Network, genesis, cfg.DevnetBlockTime, log), nil
+}
+
+// optionalIndexes returns the optional indexes that are enabled by cfg
+func optionalIndexes(cfg *Config) []blockchain.Index {
+	var indexes []blockchain.Index
+	if cfg.IndexEvents {
+		indexes = append(indexes, blockchain.EventsIndex{})
+	}
+	return indexes
+}
+
+// openDB opens the database of the node, or connects to the database of its primary if it is a replica. The state
+// is kept in the state database if it is set, and the data that coldPolicy moves is read from the cold database too
+// if it is set. The values of the buckets of compression are compressed and those of the buckets of checksums are
+// checksummed by the backends that support it, and the storage engines are tuned with the settings of cfg.
+func openDB(cfg *Config, coldPolicy tiering.Policy, compression db.Compression, checksums db.Checksums,
+	budget *memory.Budget, log *utils.ZapLogger,
+) (db.DB, error) {
+	if cfg.ReplicaOf != "" {
+		return remote.New(cfg.ReplicaOf)
+	}
+	backend := cfg.DatabaseBackend
+	if backend == "" {
+		backend = db.DefaultBackend
+	}
+	if backend == freezer.Backend {
+		return nil, errors.New("the freezer backend can only be used for the cold database")
+	}
+	coldBackend := cfg.ColdDatabaseBackend
+	if coldBackend == "" {
+		coldBackend = backend
+	}
+	if coldBackend == freezer.Backend {
+		for name, age := range coldPolicy {
+			for _, prefix := range (tiering.Policy{name: age}).Prefixes() {
+				if !freezer.Stores(prefix) {
+					return nil, fmt.Errorf("the %s category cannot be moved to the freezer", name)
+				}
+			}
+		}
+	}
+
+	cacheSize := budget.Share(dbBlockCacheName)
+	if cfg.DatabaseCacheSize > 0 {
+		cacheSize = uint64(cfg.DatabaseCacheSize) * mebibyte
+	}
+	tuning := db.Tuning{
+		MemTableSize:          uint64(cfg.DatabaseMemTableSize) * mebibyte,
+		MaxOpenFiles:          cfg.DatabaseMaxOpenFiles,
+		CompactionConcurrency: cfg.DatabaseCompactionConcurrency,
+		WALDir:                cfg.DatabaseWALDir,
+		WALBytesPerSync:       uint64(cfg.DatabaseWALSyncSize) * mebibyte,
+	}
+	if cfg.StateDatabasePath != "" {
+		// the block cache is shared by the chain and the state stores
+		cacheSize /= 2
+	}
+	database, err := db.Open(backend, cfg.DatabasePath, db.Options{
+		CacheSize:     cacheSize,
+		Logger:        log,
+		Compression:   compression,
+		Checksums:     checksums,
+		Reencode:      true,
+		Tuning:        tuning,
+		WALArchiveDir: cfg.DatabaseWALArchiveDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the write-ahead logs of the state and the cold databases are kept with them, the logs of two databases cannot
+	// share a directory
+	tuning.WALDir = ""
+	if cfg.StateDatabasePath != "" {
+		state, err := db.Open(backend, cfg.StateDatabasePath, db.Options{
+			CacheSize:   cacheSize,
+			Namespace:   "state_db",
+			Logger:      log,
+			Compression: compression,
+			Checksums:   checksums,
+			Reencode:    true,
+			Tuning:      tuning,
+		})
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("open state DB: %w", err), database.Close())
+		}
+		splitDB := split.New(database, state)
+		// the state of a database that was not split is moved to the state database the first time it is opened
+		if _, err = splitDB.MoveState(); err != nil {
+			return nil, errors.Join(fmt.Errorf("move the state to the state DB: %w", err), splitDB.Close())
+		}
+		database = splitDB
+	}
+	if len(cfg.DatabaseReadCache) > 0 {
+		cachedDB, err := cached.New(database, cfg.DatabaseReadCache)
+		if err != nil {
+			return nil, errors.Join(err, database.Close())
+		}
+		for name, consumer := range cachedDB.Consumers() {
+			if err = budget.Register(name, consumer); err != nil {
+				return nil, errors.Join(err, database.Close())
+			}
+		}
+		database = cachedDB
+	}
+	if cfg.ColdDatabasePath == "" {
+		return database, nil
+	}
+
+	cold, err := db.Open(coldBackend, cfg.ColdDatabasePath, db.Options{
+		CacheSize:   coldDBBlockCacheSize,
+		Namespace:   "cold_db",
+		Logger:      log,
+		Compression: compression,
+		Checksums:   checksums,
+		Reencode:    true,
+		Tuning:      tuning,
+	})
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("open cold DB: %w", err), database.Close())
+	}
+	return tiered.New(database, cold, coldPolicy.Prefixes()), nil
+}
+
+// newWatchdog watches sync, the migrations and the commits to the database
+func (n *Node) newWatchdog(starknetData *adaptfeeder.Feeder, restartableSync *watchdog.Restartable) *watchdog.Watchdog {
+	w := watchdog.New(watchdogInterval, n.log.Module("watchdog"))
+
+	var restartSync func()
+	if n.cfg.WatchdogRestart && n.cfg.ReplicaOf == "" {
+		restartSync = restartableSync.Restart
+	}
+	head := func() (uint64, error) {
+		return n.blockchain.Height()
+	}
+	networkHead := func() (uint64, bool) {
+		ctx, cancel := context.WithTimeout(context.Background(), watchdogInterval/2)
+		defer cancel()
+		block, err := starknetData.BlockLatest(ctx)
+		if err != nil {
+			return 0, false
+		}
+		return block.Number, true
+	}
+	w.Watch("sync", watchdog.When(n.migrated, watchdog.HeadStall(n.cfg.StallTimeout, head, networkHead)), restartSync)
+
+	// a database that is stuck on a commit cannot be recovered by restarting a subsystem, it is only reported
+	database := n.db
+	if tieredDB, ok := database.(*tiered.DB); ok {
+		// the writes are locked by the hot database
+		database = tieredDB.Hot()
+	}
+	if cachedDB, ok := database.(*cached.DB); ok {
+		database = cachedDB.Uncached()
+	}
+	if splitDB, ok := database.(*split.DB); ok {
+		// and by its chain store, which is locked for as long as the state store is
+		database = splitDB.Chain()
+	}
+	pebbleDB, ok := database.(*pebble.DB)
+	if !ok {
+		return w
+	}
+	w.Watch("migration", watchdog.When(n.migrating.Load,
+		watchdog.HeldFor(migrationStallTimeout, "migration transaction", pebbleDB.WriteLockHeld)), nil)
+	w.Watch("db-commit", watchdog.When(n.migrated,
+		watchdog.HeldFor(n.cfg.StallTimeout, "write transaction", pebbleDB.WriteLockHeld)), nil)
+	return w
+}
+
+// migrated returns whether the migrations are done, sync does not run before that
+func (n *Node) migrated() bool {
+	return !n.migrating.Load()
+}
+
+func makeRPC(httpPort, wsPort uint16, rpcHandler *rpc.Handler, streams map[string]jsonrpc.Stream, //nolint: funlen
+	middlewares []jsonrpc.Middleware, log utils.SimpleLogger,
+) ([]service.Service, error) {
 	methods := []jsonrpc.Method{
 		{
 			Name:    "starknet_chainId",
@@ -189,6 +919,11 @@ func makeRPC(httpPort, wsPort uint16, rpcHandler *rpc.Handler, log utils.SimpleL
 			Params:  []jsonrpc.Parameter{{Name: "block_id"}},
 			Handler: rpcHandler.BlockWithTxs,
 		},
+		{
+			Name:    "starknet_getBlockWithReceipts",
+			Params:  []jsonrpc.Parameter{{Name: "block_id"}},
+			Handler: rpcHandler.BlockWithReceipts,
+		},
 		{
 			Name:    "starknet_getTransactionByHash",
 			Params:  []jsonrpc.Parameter{{Name: "transaction_hash"}},
@@ -276,6 +1011,66 @@ func makeRPC(httpPort, wsPort uint16, rpcHandler *rpc.Handler, log utils.SimpleL
 			Params:  []jsonrpc.Parameter{{Name: "transaction_hash"}},
 			Handler: rpcHandler.TransactionStatus,
 		},
+		{
+			Name:    "juno_getContractStorage",
+			Params:  []jsonrpc.Parameter{{Name: "contract_address"}, {Name: "block_id"}, {Name: "result_page_request"}},
+			Handler: rpcHandler.ContractStorage,
+		},
+		{
+			Name:    "juno_getStorageProof",
+			Params:  []jsonrpc.Parameter{{Name: "contract_address"}, {Name: "key"}},
+			Handler: rpcHandler.StorageProof,
+		},
+		{
+			Name:    "juno_verifyClassHash",
+			Params:  []jsonrpc.Parameter{{Name: "block_id"}, {Name: "class_hash"}},
+			Handler: rpcHandler.VerifyClassHash,
+		},
+		{
+			Name:    "juno_getDailyAnalytics",
+			Params:  []jsonrpc.Parameter{{Name: "days", Optional: true}},
+			Handler: rpcHandler.DailyAnalytics,
+		},
+		{
+			Name:    "juno_getDevnetAccounts",
+			Handler: rpcHandler.DevnetAccounts,
+		},
+		{
+			Name:    "juno_getSchemaVersion",
+			Handler: rpcHandler.SchemaStatus,
+		},
+		{
+			Name:    "juno_getLabels",
+			Handler: rpcHandler.Labels,
+		},
+		{
+			Name:    "juno_getJobs",
+			Handler: rpcHandler.Jobs,
+		},
+		{
+			Name:    "juno_getPoolTransactions",
+			Handler: rpcHandler.PoolTransactions,
+		},
+		{
+			Name:    "juno_getRecentEvents",
+			Params:  []jsonrpc.Parameter{{Name: "continuation_token", Optional: true}},
+			Handler: rpcHandler.RecentEvents,
+		},
+		{
+			Name:    "juno_newEventFilter",
+			Params:  []jsonrpc.Parameter{{Name: "filter"}},
+			Handler: rpcHandler.NewEventFilter,
+		},
+		{
+			Name:    "juno_getEventFilterChanges",
+			Params:  []jsonrpc.Parameter{{Name: "filter_id"}, {Name: "chunk_size"}},
+			Handler: rpcHandler.EventFilterChanges,
+		},
+		{
+			Name:    "juno_uninstallEventFilter",
+			Params:  []jsonrpc.Parameter{{Name: "filter_id"}},
+			Handler: rpcHandler.UninstallEventFilter,
+		},
 		{
 			Name:    "starknet_call",
 			Params:  []jsonrpc.Parameter{{Name: "request"}, {Name: "block_id"}},
@@ -303,11 +1098,9 @@ func makeRPC(httpPort, wsPort uint16, rpcHandler *rpc.Handler, log utils.SimpleL
 		},
 	}
 
-	jsonrpcServer := jsonrpc.NewServer(log).WithValidator(validator.Validator())
-	for _, method := range methods {
-		if err := jsonrpcServer.RegisterMethod(method); err != nil {
-			return nil, err
-		}
+	jsonrpcServer, err := newJSONRPCServer(methods, middlewares, log)
+	if err != nil {
+		return nil, err
 	}
 
 	httpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", httpPort))
@@ -322,9 +1115,23 @@ func makeRPC(httpPort, wsPort uint16, rpcHandler *rpc.Handler, log utils.SimpleL
 	}
 	wsServer := jsonrpc.NewWebsocket("/v0_4", wsListener, jsonrpcServer, log)
 
+	for path, stream := range streams {
+		wsServer.WithStream(path, stream)
+	}
+
 	return []service.Service{httpServer, wsServer}, nil
 }
 
+func newJSONRPCServer(methods []jsonrpc.Method, middlewares []jsonrpc.Middleware, log utils.SimpleLogger) (*jsonrpc.Server, error) {
+	jsonrpcServer := jsonrpc.NewServer(log).WithValidator(validator.Validator()).WithMiddleware(middlewares...)
+	for _, method := range methods {
+		if err := jsonrpcServer.RegisterMethod(method); err != nil {
+			return nil, err
+		}
+	}
+	return jsonrpcServer, nil
+}
+
 func newL1Client(ethNode string, chain *blockchain.Blockchain, log utils.SimpleLogger) (*l1.Client, error) {
 	var coreContractAddress common.Address
 	coreContractAddress, err := chain.Network().CoreContractAddress()
@@ -342,38 +1149,139 @@ func newL1Client(ethNode string, chain *blockchain.Blockchain, log utils.SimpleL
 
 // Run starts Juno node by opening the DB, initialising services.
 // All the services blocking and any errors returned by service run function is logged.
-// Run will wait for all services to return before exiting.
+// Once ctx is cancelled, the services are stopped in stages before the DB is closed.
 func (n *Node) Run(ctx context.Context) {
-	n.log.Infow("Starting Juno...", "config", fmt.Sprintf("%+v", *n.cfg), "version", n.version)
+	n.log.Infow("Starting Juno...", "config", fmt.Sprintf("%+v", n.Config()), "version", n.version)
+	closeDB := true
 	defer func() {
+		if !closeDB {
+			n.log.Warnw("Leaving the DB open since services are still running, it will recover on the next start")
+			return
+		}
 		if closeErr := n.db.Close(); closeErr != nil {
 			n.log.Errorw("Error while closing the DB", "err", closeErr)
 		}
 	}()
 
-	if err := migration.MigrateIfNeeded(n.db, n.cfg.Network); err != nil {
-		n.log.Errorw("Error while migrating the DB", "err", err)
-		return
+	if n.watchdog != nil {
+		watchdogCtx, stopWatchdog := context.WithCancel(ctx)
+		watchdogDone := make(chan struct{})
+		go func() {
+			defer close(watchdogDone)
+			if err := n.watchdog.Run(watchdogCtx); err != nil {
+				n.log.Errorw("Watchdog stopped", "err", err)
+			}
+		}()
+		defer func() {
+			stopWatchdog()
+			<-watchdogDone
+		}()
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	wg := conc.NewWaitGroup()
-	for _, s := range n.services {
-		s := s
-		wg.Go(func() {
-			if err := s.Run(ctx); err != nil {
-				n.log.Errorw("Service error", "name", reflect.TypeOf(s), "err", err)
-				cancel()
+	if n.metricServer != nil {
+		metricsCtx, stopMetrics := context.WithCancel(context.Background())
+		metricsDone := make(chan struct{})
+		go func() {
+			defer close(metricsDone)
+			if err := n.metricServer.Run(metricsCtx); err != nil {
+				n.log.Errorw("Metrics server stopped", "err", err)
 			}
-		})
+		}()
+		defer func() {
+			stopMetrics()
+			<-metricsDone
+		}()
 	}
-	defer wg.Wait()
 
-	<-ctx.Done()
-	cancel()
+	version, problems := selfcheck.SchemaVersion(n.db)
+	if len(problems) > 0 {
+		n.logProblems(problems)
+		return
+	}
+	// a DB that is up to date is not written to on startup, unless a commit was interrupted
+	if version < migration.LatestSchemaVersion() && !n.migrate(ctx) {
+		return
+	}
+	recovery, err := n.blockchain.RecoverInterruptedCommit()
+	if err != nil {
+		n.log.Errorw("Error while recovering the interrupted commit of a block", "err", err)
+		return
+	} else if recovery != nil && recovery.RolledBack {
+		n.log.Warnw("Rolled back a block whose commit was interrupted, it is synced again", "number", recovery.Number,
+			"hash", recovery.Hash)
+	}
+	if problems := selfcheck.Run(n.db, n.cfg.Network); len(problems) > 0 {
+		n.logProblems(problems)
+		return
+	}
+
+	n.ready.Store(true)
+	if err = n.lifecycle.Run(ctx); errors.Is(err, service.ErrStopTimeout) {
+		closeDB = false
+	}
 	n.log.Infow("Shutting down Juno...")
 }
 
+// migrate applies the migrations that were not applied to the DB yet, and reports whether the node can start
+func (n *Node) migrate(ctx context.Context) bool {
+	onInterrupted, err := migration.ParseInterruptedPolicy(n.cfg.MigrationOnInterrupted)
+	if err != nil {
+		n.log.Errorw("Invalid migration option", "err", err)
+		return false
+	}
+	n.migrating.Store(true)
+	err = migration.MigrateIfNeeded(ctx, n.db, n.cfg.Network, n.migrationLog, migration.MigrationOptions{
+		BackupDir:     n.cfg.MigrationBackupDir,
+		DataDir:       n.cfg.DatabasePath,
+		Workers:       n.cfg.MigrationWorkers,
+		BatchSize:     n.cfg.MigrationBatchSize,
+		MemoryBudget:  n.cfg.MigrationMemoryBudget << 20,
+		ChunkSize:     n.cfg.MigrationChunkSize,
+		Background:    n.cfg.MigrateInBackground,
+		Refuse:        n.cfg.RefuseToMigrate,
+		OnInterrupted: onInterrupted,
+		JunoVersion:   n.version,
+		OnProgress:    n.migrations.onProgress(false),
+	})
+	n.migrating.Store(false)
+	n.migrations.current.Store(nil)
+	if errors.Is(err, context.Canceled) {
+		n.log.Infow("Stopped migrating the DB, the migrations resume from where they stopped on the next start")
+		return false
+	} else if spaceErr := new(migration.InsufficientSpaceError); errors.As(err, &spaceErr) {
+		n.log.Errorw("Not enough free disk space to migrate the DB, free some space and restart the node",
+			"dir", spaceErr.Dir, "requiredMiB", spaceErr.Required>>20, "availableMiB", spaceErr.Available>>20)
+		return false
+	} else if behindErr := new(migration.SchemaBehindError); errors.As(err, &behindErr) {
+		pending := make([]string, 0, len(behindErr.Pending))
+		for _, m := range behindErr.Pending {
+			pending = append(pending, fmt.Sprintf("%d (%s)", m.Version, m.Name))
+		}
+		n.log.Errorw("The DB has pending migrations and migrating it is refused, migrate it with `juno migrate`",
+			"schemaVersion", behindErr.SchemaVersion, "pending", pending)
+		return false
+	} else if interruptedErr := new(migration.InterruptedError); errors.As(err, &interruptedErr) {
+		n.log.Errorw("A migration of the DB was interrupted, restore the DB from a backup or resume the migration "+
+			"with --migration-on-interrupted resume", "version", interruptedErr.Version, "name", interruptedErr.Name,
+			"started", interruptedErr.Started, "err", interruptedErr.Err)
+		return false
+	} else if err != nil {
+		n.log.Errorw("Error while migrating the DB", "err", err)
+		return false
+	}
+	return true
+}
+
+// logProblems logs the problems found by the startup self-check along with how to recover from them
+func (n *Node) logProblems(problems []selfcheck.Problem) {
+	for _, problem := range problems {
+		n.log.Errorw("Startup self-check failed, the node cannot start", "check", problem.Check, "err", problem.Err,
+			"action", problem.Advice)
+	}
+}
+
 func (n *Node) Config() Config {
+	n.cfgLock.RLock()
+	defer n.cfgLock.RUnlock()
 	return *n.cfg
 }
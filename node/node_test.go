@@ -1,8 +1,10 @@
 package node_test
 
 import (
+	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/juno/node"
 	"github.com/NethermindEth/juno/utils"
@@ -30,3 +32,62 @@ func TestDefaultDbPath(t *testing.T) {
 		})
 	}
 }
+
+func TestReload(t *testing.T) {
+	cfg := &node.Config{Network: utils.MAINNET, DatabasePath: t.TempDir(), LogLevel: utils.INFO}
+	snNode, err := node.New(cfg, "1.2.3")
+	require.NoError(t, err)
+
+	t.Run("load error keeps the current configuration", func(t *testing.T) {
+		snNode.Reload(func(*node.Config) error {
+			return errors.New("invalid config")
+		})
+		assert.Equal(t, utils.INFO, snNode.Config().LogLevel)
+	})
+
+	t.Run("only safe settings are applied", func(t *testing.T) {
+		snNode.Reload(func(reloaded *node.Config) error {
+			*reloaded = node.Config{
+				Network:             utils.MAINNET,
+				LogLevel:            utils.DEBUG,
+				PendingPollInterval: time.Second,
+				HTTPPort:            1234,
+			}
+			return nil
+		})
+
+		got := snNode.Config()
+		assert.Equal(t, utils.DEBUG, got.LogLevel)
+		assert.Equal(t, time.Second, got.PendingPollInterval)
+		assert.Equal(t, uint16(0), got.HTTPPort)
+		assert.Equal(t, cfg.DatabasePath, got.DatabasePath)
+	})
+
+	t.Run("the configuration is read while it is reloaded", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				_ = snNode.Config()
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			snNode.Reload(func(reloaded *node.Config) error {
+				*reloaded = node.Config{Network: utils.MAINNET, LogLevel: utils.INFO, PendingPollInterval: time.Duration(i)}
+				return nil
+			})
+		}
+		<-done
+		assert.Equal(t, time.Duration(99), snNode.Config().PendingPollInterval)
+	})
+}
+
+func TestReplica(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "juno")
+	cfg := &node.Config{Network: utils.MAINNET, DatabasePath: dbPath, ReplicaOf: "localhost:6064"}
+	_, err := node.New(cfg, "1.2.3")
+	require.NoError(t, err)
+
+	// replicas read the database of their primary, the connection is only established once it is read
+	assert.NoDirExists(t, dbPath)
+}
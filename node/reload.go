@@ -0,0 +1,56 @@
+package node
+
+import (
+	"reflect"
+)
+
+// Reload loads a new configuration with load and applies the settings that can change while the node
+// is running, without interrupting sync:
+//
+//   - log-level
+//   - pending-poll-interval
+//   - memory-budget, except for the share of the DB block cache
+//
+// Changes to any other setting are logged and only take effect after a restart.
+func (n *Node) Reload(load func(*Config) error) {
+	cfg := new(Config)
+	if err := load(cfg); err != nil {
+		n.log.Errorw("Failed to load configuration, keeping the current one", "err", err)
+		return
+	}
+	if cfg.DatabasePath == "" {
+		// the default path was resolved when the node was created
+		cfg.DatabasePath = n.cfg.DatabasePath
+	}
+
+	n.cfgLock.Lock()
+	defer n.cfgLock.Unlock()
+
+	if err := n.log.SetLevel(cfg.LogLevel); err != nil {
+		n.log.Errorw("Failed to change log level", "err", err)
+	} else {
+		n.cfg.LogLevel = cfg.LogLevel
+	}
+	n.synchronizer.SetPendingPollInterval(cfg.PendingPollInterval)
+	n.cfg.PendingPollInterval = cfg.PendingPollInterval
+	n.budget.SetTotal(uint64(cfg.MemoryBudget) * mebibyte)
+	n.cfg.MemoryBudget = cfg.MemoryBudget
+
+	if changed := changedSettings(n.cfg, cfg); len(changed) > 0 {
+		n.log.Warnw("Ignoring configuration changes that require a restart", "settings", changed)
+	}
+	n.log.Infow("Reloaded configuration", "log-level", n.cfg.LogLevel, "pending-poll-interval", n.cfg.PendingPollInterval,
+		"memory-budget", n.cfg.MemoryBudget)
+}
+
+// changedSettings returns the names of the settings that differ between current and updated
+func changedSettings(current, updated *Config) []string {
+	var changed []string
+	currentValue, updatedValue := reflect.ValueOf(*current), reflect.ValueOf(*updated)
+	for i := 0; i < currentValue.NumField(); i++ {
+		if !reflect.DeepEqual(currentValue.Field(i).Interface(), updatedValue.Field(i).Interface()) {
+			changed = append(changed, currentValue.Type().Field(i).Tag.Get("mapstructure"))
+		}
+	}
+	return changed
+}
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/NethermindEth/juno/migration"
+)
+
+// nodeStatus is the status of the node served on /status of the metrics server, which runs during the migrations
+// too, so that orchestrators keep the node out of rotation until it serves
+type nodeStatus struct {
+	// Ready is whether the migrations applied on startup are done and the services of the node started
+	Ready               bool   `json:"ready"`
+	SchemaVersion       uint64 `json:"schema_version"`
+	TargetSchemaVersion uint64 `json:"target_schema_version"`
+	// Migration is the migration in progress, if any
+	Migration *migrationStatus `json:"migration,omitempty"`
+}
+
+// migrationStatus is the progress of a migration, Percent is nil when the work of the migration is not known.
+// Background is whether it is applied in the background while the node serves.
+type migrationStatus struct {
+	Version    uint64   `json:"version"`
+	Name       string   `json:"name"`
+	Percent    *float64 `json:"percent,omitempty"`
+	Background bool     `json:"background,omitempty"`
+}
+
+// migrationTracker keeps the progress of the migration in progress, for the status of the node
+type migrationTracker struct {
+	current atomic.Pointer[migrationStatus]
+}
+
+// onProgress returns the function the progress of the migrations is passed to, the migrations applied in the
+// background are dropped from the status once they are done
+func (t *migrationTracker) onProgress(background bool) func(migration.Progress) {
+	return func(p migration.Progress) {
+		if background && p.Total > 0 && p.Current >= p.Total {
+			t.current.Store(nil)
+			return
+		}
+		status := &migrationStatus{Version: p.Version, Name: p.Name, Background: background}
+		if p.Total > 0 {
+			percent := p.Percent()
+			status.Percent = &percent
+		}
+		t.current.Store(status)
+	}
+}
+
+// serveStatus serves the status of the node, with the 503 status code until it is ready
+func (n *Node) serveStatus(writer http.ResponseWriter, _ *http.Request) {
+	version, err := migration.SchemaVersion(n.db)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	status := nodeStatus{
+		Ready:               n.ready.Load(),
+		SchemaVersion:       version,
+		TargetSchemaVersion: migration.LatestSchemaVersion(),
+		Migration:           n.migrations.current.Load(),
+	}
+	if !status.Ready {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	n.writeJSON(writer, status)
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeStatus(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	n := &Node{db: testDB, log: utils.NewNopZapLogger(), migrations: new(migrationTracker)}
+
+	status := func(t *testing.T, expectedCode int) nodeStatus {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		n.serveStatus(recorder, httptest.NewRequest(http.MethodGet, "/status", http.NoBody))
+		require.Equal(t, expectedCode, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		var got nodeStatus
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+		return got
+	}
+
+	t.Run("migrating", func(t *testing.T) {
+		n.migrations.onProgress(false)(migration.Progress{Version: 3, Name: "a migration", Current: 1, Total: 4})
+		got := status(t, http.StatusServiceUnavailable)
+		assert.False(t, got.Ready)
+		assert.Equal(t, uint64(0), got.SchemaVersion)
+		assert.Equal(t, migration.LatestSchemaVersion(), got.TargetSchemaVersion)
+		require.NotNil(t, got.Migration)
+		assert.Equal(t, uint64(3), got.Migration.Version)
+		assert.Equal(t, "a migration", got.Migration.Name)
+		require.NotNil(t, got.Migration.Percent)
+		assert.Equal(t, 25.0, *got.Migration.Percent)
+		assert.False(t, got.Migration.Background)
+
+		// the work of the migration is not known
+		n.migrations.onProgress(false)(migration.Progress{Version: 3, Current: 1})
+		assert.Nil(t, status(t, http.StatusServiceUnavailable).Migration.Percent)
+	})
+
+	t.Run("ready with a background migration", func(t *testing.T) {
+		n.migrations.current.Store(nil)
+		n.ready.Store(true)
+		onProgress := n.migrations.onProgress(true)
+		onProgress(migration.Progress{Version: 5, Current: 2, Total: 10})
+		got := status(t, http.StatusOK)
+		assert.True(t, got.Ready)
+		require.NotNil(t, got.Migration)
+		assert.True(t, got.Migration.Background)
+
+		onProgress(migration.Progress{Version: 5, Current: 10, Total: 10})
+		assert.Nil(t, status(t, http.StatusOK).Migration)
+	})
+}
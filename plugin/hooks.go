@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+)
+
+// Hooks calls the hooks of plugins. The errors and panics of a hook are logged and do not stop the node,
+// nor the other plugins from being called. Hooks are called synchronously, a slow plugin slows down sync.
+//
+// A nil Hooks calls nothing, so that callers do not need to check whether there are plugins.
+type Hooks struct {
+	plugins []Plugin
+	log     utils.SimpleLogger
+}
+
+// NewHooks returns the Hooks of plugins
+func NewHooks(plugins []Plugin, log utils.SimpleLogger) *Hooks {
+	return &Hooks{plugins: plugins, log: log}
+}
+
+// Init initialises the plugins that implement Initialiser, unlike the other hooks it fails if any of them
+// does
+func (h *Hooks) Init(chain *blockchain.Blockchain) error {
+	if h == nil {
+		return nil
+	}
+	for _, p := range h.plugins {
+		if initialiser, ok := p.(Initialiser); ok {
+			if err := initialiser.Init(chain, h.log); err != nil {
+				return fmt.Errorf("initialise plugin %s: %w", p.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// NewBlock notifies the plugins of a block that was stored, and of its events
+func (h *Hooks) NewBlock(block *core.Block, stateUpdate *core.StateUpdate) {
+	if h == nil {
+		return
+	}
+	for _, p := range h.plugins {
+		if hook, ok := p.(BlockHook); ok {
+			h.call(p, "NewBlock", func() error { return hook.NewBlock(block, stateUpdate) })
+		}
+		if hook, ok := p.(EventHook); ok {
+			h.call(p, "NewEvent", func() error { return newEvents(hook, block) })
+		}
+	}
+}
+
+func newEvents(hook EventHook, block *core.Block) error {
+	var index uint64
+	for _, receipt := range block.Receipts {
+		for _, event := range receipt.Events {
+			if err := hook.NewEvent(&blockchain.FilteredEvent{
+				Event:           event,
+				BlockNumber:     block.Number,
+				BlockHash:       block.Hash,
+				TransactionHash: receipt.TransactionHash,
+				EventIndex:      index,
+			}); err != nil {
+				return err
+			}
+			index++
+		}
+	}
+	return nil
+}
+
+// RevertBlock notifies the plugins of a block that was reverted
+func (h *Hooks) RevertBlock(reverted *core.Header) {
+	if h == nil {
+		return
+	}
+	for _, p := range h.plugins {
+		if hook, ok := p.(ReorgHook); ok {
+			h.call(p, "RevertBlock", func() error { return hook.RevertBlock(reverted) })
+		}
+	}
+}
+
+// RPCMiddlewares returns the middlewares of the plugins that implement RPCHook, in the order the plugins
+// were registered
+func (h *Hooks) RPCMiddlewares() []jsonrpc.Middleware {
+	if h == nil {
+		return nil
+	}
+	var middlewares []jsonrpc.Middleware
+	for _, p := range h.plugins {
+		if hook, ok := p.(RPCHook); ok {
+			middlewares = append(middlewares, hook.RPCMiddleware)
+		}
+	}
+	return middlewares
+}
+
+// Services returns the plugins that implement service.Service, which run for as long as the node does
+func (h *Hooks) Services() []service.Service {
+	if h == nil {
+		return nil
+	}
+	var services []service.Service
+	for _, p := range h.plugins {
+		if s, ok := p.(service.Service); ok {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+func (h *Hooks) call(p Plugin, hook string, f func() error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			h.log.Errorw("Plugin panicked", "plugin", p.Name(), "hook", hook, "panic", recovered)
+		}
+	}()
+	if err := f(); err != nil {
+		h.log.Warnw("Plugin hook failed", "plugin", p.Name(), "hook", hook, "err", err)
+	}
+}
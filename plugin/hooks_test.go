@@ -0,0 +1,163 @@
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/plugin"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPlugin struct {
+	name     string
+	chain    *blockchain.Blockchain
+	blocks   []uint64
+	events   []*blockchain.FilteredEvent
+	reverted []uint64
+	err      error
+	panics   bool
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) Init(chain *blockchain.Blockchain, _ utils.SimpleLogger) error {
+	p.chain = chain
+	return p.err
+}
+
+func (p *testPlugin) NewBlock(block *core.Block, _ *core.StateUpdate) error {
+	if p.panics {
+		panic("new block")
+	}
+	p.blocks = append(p.blocks, block.Number)
+	return p.err
+}
+
+func (p *testPlugin) NewEvent(event *blockchain.FilteredEvent) error {
+	p.events = append(p.events, event)
+	return p.err
+}
+
+func (p *testPlugin) RevertBlock(reverted *core.Header) error {
+	p.reverted = append(p.reverted, reverted.Number)
+	return p.err
+}
+
+func (p *testPlugin) RPCMiddleware(next jsonrpc.Handler) jsonrpc.Handler {
+	return func(ctx context.Context, method string, params any) (any, *jsonrpc.Error) {
+		if method == p.name {
+			return p.name, nil
+		}
+		return next(ctx, method, params)
+	}
+}
+
+// blockOnly implements only one of the hooks
+type blockOnly struct {
+	blocks []uint64
+}
+
+func (p *blockOnly) Name() string {
+	return "block-only"
+}
+
+func (p *blockOnly) NewBlock(block *core.Block, _ *core.StateUpdate) error {
+	p.blocks = append(p.blocks, block.Number)
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	log := utils.NewNopZapLogger()
+	block := &core.Block{
+		Header: &core.Header{Number: 7, Hash: new(felt.Felt).SetUint64(70)},
+		Receipts: []*core.TransactionReceipt{
+			{TransactionHash: new(felt.Felt).SetUint64(1), Events: []*core.Event{{From: new(felt.Felt).SetUint64(2)}}},
+			{TransactionHash: new(felt.Felt).SetUint64(3)},
+			{TransactionHash: new(felt.Felt).SetUint64(4), Events: []*core.Event{{}, {}}},
+		},
+	}
+
+	t.Run("nil hooks call nothing", func(t *testing.T) {
+		var hooks *plugin.Hooks
+		require.NoError(t, hooks.Init(nil))
+		hooks.NewBlock(block, nil)
+		hooks.RevertBlock(block.Header)
+		assert.Empty(t, hooks.RPCMiddlewares())
+		assert.Empty(t, hooks.Services())
+	})
+
+	t.Run("hooks are called on the plugins that implement them", func(t *testing.T) {
+		full, partial := &testPlugin{name: "full"}, new(blockOnly)
+		hooks := plugin.NewHooks([]plugin.Plugin{full, partial}, log)
+
+		chain := blockchain.New(pebble.NewMemTest(), utils.MAINNET, log)
+		require.NoError(t, hooks.Init(chain))
+		assert.Same(t, chain, full.chain)
+
+		hooks.NewBlock(block, nil)
+		hooks.RevertBlock(block.Header)
+		assert.Equal(t, []uint64{7}, full.blocks)
+		assert.Equal(t, []uint64{7}, full.reverted)
+		assert.Equal(t, []uint64{7}, partial.blocks)
+
+		require.Len(t, full.events, 3)
+		assert.Equal(t, block.Receipts[0].Events[0], full.events[0].Event)
+		assert.Equal(t, uint64(7), full.events[0].BlockNumber)
+		assert.Equal(t, block.Hash, full.events[0].BlockHash)
+		assert.Equal(t, block.Receipts[0].TransactionHash, full.events[0].TransactionHash)
+		assert.Equal(t, block.Receipts[2].TransactionHash, full.events[2].TransactionHash)
+
+		assert.Len(t, hooks.RPCMiddlewares(), 1)
+	})
+
+	t.Run("failing plugins do not stop the others", func(t *testing.T) {
+		failing := &testPlugin{name: "failing", err: errors.New("failed"), panics: true}
+		other := &testPlugin{name: "other"}
+		hooks := plugin.NewHooks([]plugin.Plugin{failing, other}, log)
+
+		hooks.NewBlock(block, nil)
+		assert.Equal(t, []uint64{7}, other.blocks)
+		assert.Len(t, other.events, 3)
+		// the events hook failed on the first event
+		assert.Len(t, failing.events, 1)
+	})
+
+	t.Run("init errors are returned", func(t *testing.T) {
+		hooks := plugin.NewHooks([]plugin.Plugin{&testPlugin{name: "failing", err: errors.New("failed")}}, log)
+		require.ErrorContains(t, hooks.Init(nil), "initialise plugin failing: failed")
+	})
+
+	t.Run("rpc middlewares", func(t *testing.T) {
+		hooks := plugin.NewHooks([]plugin.Plugin{&testPlugin{name: "first"}, &testPlugin{name: "second"}}, log)
+		server := jsonrpc.NewServer(log).WithMiddleware(hooks.RPCMiddlewares()...)
+		for _, method := range []string{"first", "second", "method"} {
+			require.NoError(t, server.RegisterMethod(jsonrpc.Method{
+				Name:    method,
+				Handler: func() (string, *jsonrpc.Error) { return "method", nil },
+			}))
+		}
+
+		for method, want := range map[string]string{"first": "first", "second": "second", "method": "method"} {
+			res, err := server.Handle([]byte(`{"jsonrpc": "2.0", "method": "` + method + `", "id": 1}`))
+			require.NoError(t, err)
+			assert.Equal(t, `{"jsonrpc":"2.0","result":"`+want+`","id":1}`, string(res))
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	p := &testPlugin{name: "registered"}
+	plugin.Register(p)
+	assert.Contains(t, plugin.Registered(), plugin.Plugin(p))
+	assert.Panics(t, func() { plugin.Register(&testPlugin{name: "registered"}) })
+}
@@ -0,0 +1,73 @@
+// Package plugin lets forks of Juno embed indexers and custom logic in the node process without changing
+// it: a plugin is registered from an init function of a package that is imported by the main package,
+// and is notified of the blocks that are synced through the hooks it implements.
+package plugin
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/utils"
+)
+
+// Plugin is an extension of the node. It implements any of the hook interfaces below, and service.Service
+// if it runs in the background.
+type Plugin interface {
+	// Name identifies the plugin in logs, it must be unique
+	Name() string
+}
+
+// Initialiser is implemented by plugins that read from the blockchain, Init is called once when the node
+// is created
+type Initialiser interface {
+	Init(chain *blockchain.Blockchain, log utils.SimpleLogger) error
+}
+
+// BlockHook is implemented by plugins that are notified of the blocks stored by sync
+type BlockHook interface {
+	NewBlock(block *core.Block, stateUpdate *core.StateUpdate) error
+}
+
+// ReorgHook is implemented by plugins that are notified of the blocks reverted by sync because of a reorg
+type ReorgHook interface {
+	RevertBlock(reverted *core.Header) error
+}
+
+// EventHook is implemented by plugins that are notified of the events of the blocks stored by sync
+type EventHook interface {
+	NewEvent(event *blockchain.FilteredEvent) error
+}
+
+// RPCHook is implemented by plugins that wrap the calls of JSON-RPC methods, to add authentication or rate
+// limiting for example
+type RPCHook interface {
+	RPCMiddleware(next jsonrpc.Handler) jsonrpc.Handler
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Plugin
+)
+
+// Register makes a plugin part of every node created from then on. It panics if a plugin with the same
+// name is already registered.
+func Register(p Plugin) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, registered := range registry {
+		if registered.Name() == p.Name() {
+			panic(fmt.Sprintf("plugin %s is registered twice", p.Name()))
+		}
+	}
+	registry = append(registry, p)
+}
+
+// Registered returns the registered plugins, in the order they were registered
+func Registered() []Plugin {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Plugin(nil), registry...)
+}
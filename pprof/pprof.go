@@ -2,9 +2,12 @@ package pprof
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	// #nosec G108
 	_ "net/http/pprof"
+	"runtime"
+	"runtime/debug"
 	"strconv"
 	"time"
 
@@ -17,19 +20,32 @@ var _ service.Service = (*Profiler)(nil)
 type Profiler struct {
 	log    utils.SimpleLogger
 	server *http.Server
+	mux    *http.ServeMux
 }
 
 func New(port uint16, log utils.SimpleLogger) *Profiler {
+	mux := http.NewServeMux()
+	// net/http/pprof registers its handlers on the default mux
+	mux.Handle("/debug/pprof/", http.DefaultServeMux)
 	server := &http.Server{
 		Addr:              "0.0.0.0:" + strconv.Itoa(int(port)),
-		Handler:           http.DefaultServeMux,
+		Handler:           mux,
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	return &Profiler{
+	p := &Profiler{
 		server: server,
+		mux:    mux,
 		log:    log,
 	}
+	mux.HandleFunc("/debug/runtime", p.runtimeStats)
+	return p
+}
+
+// WithHandler serves handler on pattern next to the profiles
+func (p *Profiler) WithHandler(pattern string, handler http.Handler) *Profiler {
+	p.mux.Handle(pattern, handler)
+	return p
 }
 
 func (p *Profiler) Run(ctx context.Context) error {
@@ -43,3 +59,76 @@ func (p *Profiler) Run(ctx context.Context) error {
 	<-ctx.Done()
 	return p.server.Shutdown(context.Background())
 }
+
+type memoryStats struct {
+	Alloc       uint64 `json:"alloc"`
+	TotalAlloc  uint64 `json:"total_alloc"`
+	Sys         uint64 `json:"sys"`
+	HeapInuse   uint64 `json:"heap_inuse"`
+	HeapObjects uint64 `json:"heap_objects"`
+	NextGC      uint64 `json:"next_gc"`
+}
+
+type gcStats struct {
+	NumGC        int64           `json:"num_gc"`
+	LastGC       time.Time       `json:"last_gc"`
+	PauseTotal   time.Duration   `json:"pause_total"`
+	CPUFraction  float64         `json:"cpu_fraction"`
+	RecentPauses []time.Duration `json:"recent_pauses"`
+}
+
+type runtimeStatsResponse struct {
+	GoVersion  string      `json:"go_version"`
+	GOOS       string      `json:"goos"`
+	GOARCH     string      `json:"goarch"`
+	CPUs       int         `json:"cpus"`
+	Goroutines int         `json:"goroutines"`
+	Memory     memoryStats `json:"memory"`
+	GC         gcStats     `json:"gc"`
+}
+
+// maxRecentPauses is the number of the most recent GC pauses that are reported
+const maxRecentPauses = 16
+
+// runtimeStats serves the goroutine count, memory and GC statistics of the process
+func (p *Profiler) runtimeStats(writer http.ResponseWriter, _ *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	gc := debug.GCStats{Pause: make([]time.Duration, maxRecentPauses)}
+	debug.ReadGCStats(&gc)
+
+	resp := runtimeStatsResponse{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: memoryStats{
+			Alloc:       mem.Alloc,
+			TotalAlloc:  mem.TotalAlloc,
+			Sys:         mem.Sys,
+			HeapInuse:   mem.HeapInuse,
+			HeapObjects: mem.HeapObjects,
+			NextGC:      mem.NextGC,
+		},
+		GC: gcStats{
+			NumGC:        gc.NumGC,
+			LastGC:       gc.LastGC,
+			PauseTotal:   gc.PauseTotal,
+			CPUFraction:  mem.GCCPUFraction,
+			RecentPauses: gc.Pause,
+		},
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		p.log.Errorw("Failed to marshal runtime stats", "err", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if _, err = writer.Write(body); err != nil {
+		p.log.Debugw("Failed to write runtime stats", "err", err)
+	}
+}
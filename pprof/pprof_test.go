@@ -2,13 +2,16 @@ package pprof_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/NethermindEth/juno/pprof"
 	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,6 +31,43 @@ func TestPprofServer(t *testing.T) {
 	waitForServerReady(t, url, 5*time.Second)
 }
 
+func TestPprofServerHandlers(t *testing.T) {
+	port := uint16(9051)
+	profiler := pprof.New(port, utils.NewNopZapLogger()).
+		WithHandler("/debug/hello", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("hello"))
+		}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	go func() {
+		err := profiler.Run(ctx)
+		require.NoError(t, err)
+	}()
+
+	baseURL := fmt.Sprintf("http://localhost:%d", port)
+	waitForServerReady(t, baseURL+"/debug/hello", 5*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/debug/runtime", http.NoBody)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stats struct {
+		GoVersion  string `json:"go_version"`
+		Goroutines int    `json:"goroutines"`
+		Memory     struct {
+			Sys uint64 `json:"sys"`
+		} `json:"memory"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Equal(t, runtime.Version(), stats.GoVersion)
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.Memory.Sys)
+}
+
 func waitForServerReady(t *testing.T, url string, timeout time.Duration) {
 	t.Helper()
 
@@ -0,0 +1,121 @@
+// Package prune deletes the history of the state of the blocks older than a retention window. The tries only hold
+// their latest nodes, the state of the earlier blocks is read through the logs of the old values of the storage, the
+// nonces and the class hashes that each block changed, see core.History. The keys a block logged are those of its
+// state diff, which is stored with the block, so that the logs of a block are deleted by the keys of its state diff
+// once the block is out of the window. The state updates themselves are kept, they are served by the RPC.
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ jobs.Job = (*Pruner)(nil)
+
+const (
+	// PruneInterval is how frequently the history should be pruned once it is within the retention window
+	PruneInterval = time.Minute
+	// pruneBatch is the number of blocks whose history is pruned per transaction
+	pruneBatch = 32
+)
+
+// Pruner prunes the history of the state of the blocks that are more than its retention behind the head, the state
+// of those blocks can no longer be read and the blocks can no longer be reverted
+type Pruner struct {
+	database  db.DB
+	chain     *blockchain.Blockchain
+	retention uint64
+	log       utils.SimpleLogger
+
+	// metrics
+	pruned prometheus.Counter
+}
+
+// New returns a Pruner that keeps the history of the state of the last retention blocks of chain, which is stored in
+// database
+func New(database db.DB, chain *blockchain.Blockchain, retention uint64, log utils.SimpleLogger) *Pruner {
+	p := &Pruner{
+		database:  database,
+		chain:     chain,
+		retention: retention,
+		log:       log,
+		pruned: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "prune",
+			Name:      "blocks",
+			Help:      "The number of blocks whose history of the state was pruned",
+		}),
+	}
+	metrics.MustRegister(p.pruned)
+	return p
+}
+
+// Step prunes a batch of blocks, the next step is due right away while there are blocks left to prune and after
+// PruneInterval otherwise
+func (p *Pruner) Step(ctx context.Context) (bool, error) {
+	pruned, err := p.pruneBatch()
+	return pruned == pruneBatch && ctx.Err() == nil, err
+}
+
+// Prune prunes the history of all the blocks out of the retention window and returns the number of blocks pruned
+func (p *Pruner) Prune(ctx context.Context) (uint64, error) {
+	var total uint64
+	for ctx.Err() == nil {
+		pruned, err := p.pruneBatch()
+		total += pruned
+		if err != nil || pruned < pruneBatch {
+			return total, err
+		}
+	}
+	return total, ctx.Err()
+}
+
+// pruneBatch prunes the history of up to pruneBatch blocks and returns the number of blocks pruned
+func (p *Pruner) pruneBatch() (uint64, error) {
+	height, err := p.chain.Height()
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if height < p.retention {
+		return 0, nil
+	}
+	// the state is kept from the block height-retention, whose state needs the logs of the blocks after it
+	end := height - p.retention + 1
+
+	var start, pruned uint64
+	err = p.database.Update(func(txn db.Transaction) error {
+		var err error
+		if start, err = core.HistoryStart(txn); err != nil {
+			return err
+		}
+		history := core.NewHistory(txn)
+		for number := start; number < end && pruned < pruneBatch; number++ {
+			update, err := blockchain.StateUpdateByNumber(txn, number)
+			if err != nil {
+				return fmt.Errorf("state update of block %d: %w", number, err)
+			}
+			if err = history.PruneBlock(number, update.StateDiff); err != nil {
+				return fmt.Errorf("prune block %d: %w", number, err)
+			}
+			pruned++
+		}
+		return nil
+	})
+	if err != nil || pruned == 0 {
+		return 0, err
+	}
+	p.pruned.Add(float64(pruned))
+	p.log.Debugw("Pruned the history of the state", "from", start, "to", start+pruned-1)
+	return pruned, nil
+}
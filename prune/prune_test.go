@@ -0,0 +1,121 @@
+package prune_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/prune"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruner(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	const blocks = 5
+	var diffs []*core.StateDiff
+	for i := uint64(0); i < blocks; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		classes := make(map[felt.Felt]core.Class)
+		for _, deployed := range stateUpdate.StateDiff.DeployedContracts {
+			classes[*deployed.ClassHash] = &core.Cairo0Class{}
+		}
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, classes))
+		diffs = append(diffs, stateUpdate.StateDiff)
+	}
+
+	// storageAt returns the values of the storage of all the diffs at the block number
+	storageAt := func(number uint64) map[string]*felt.Felt {
+		state, closer, err := chain.StateAtBlockNumber(number)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, closer())
+		}()
+		values := make(map[string]*felt.Felt)
+		for _, diff := range diffs {
+			for addr, storageDiffs := range diff.StorageDiffs {
+				for _, storageDiff := range storageDiffs {
+					value, err := state.ContractStorage(&addr, storageDiff.Key)
+					if !errors.Is(err, core.ErrContractNotDeployed) {
+						require.NoError(t, err)
+					}
+					values[addr.String()+storageDiff.Key.String()] = value
+				}
+			}
+		}
+		return values
+	}
+	before := make(map[uint64]map[string]*felt.Felt)
+	for number := uint64(0); number < blocks; number++ {
+		before[number] = storageAt(number)
+	}
+
+	pruner := prune.New(testDB, chain, 2, utils.NewNopZapLogger())
+	pruned, err := pruner.Prune(context.Background())
+	require.NoError(t, err)
+	// the head is the last block, the state is kept from head-2, which needs the history of the blocks after it
+	const kept = blocks - 1 - 2
+	assert.Equal(t, uint64(kept+1), pruned)
+
+	t.Run("the history of the blocks out of the window is deleted", func(t *testing.T) {
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			for _, bucket := range []db.Bucket{db.ContractStorageHistory, db.ContractNonceHistory, db.ContractClassHashHistory} {
+				prefix := bucket.Key()
+				it, err := txn.NewIteratorWithBounds(prefix, db.PrefixEnd(prefix))
+				require.NoError(t, err)
+				for it.Seek(prefix); it.Valid(); it.Next() {
+					key := it.Key()
+					assert.Greater(t, binary.BigEndian.Uint64(key[len(key)-8:]), uint64(kept), bucket)
+				}
+				require.NoError(t, it.Close())
+			}
+			start, err := core.HistoryStart(txn)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(kept+1), start)
+			return nil
+		}))
+
+		pruned, err = pruner.Prune(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, pruned)
+	})
+
+	t.Run("the state is read within the window", func(t *testing.T) {
+		for number := uint64(0); number < blocks; number++ {
+			if number < kept {
+				_, _, err = chain.StateAtBlockNumber(number)
+				require.ErrorIs(t, err, core.ErrStatePruned)
+				continue
+			}
+			assert.Equal(t, before[number], storageAt(number), number)
+		}
+	})
+
+	t.Run("the blocks out of the window are not reverted", func(t *testing.T) {
+		for height := uint64(blocks - 1); height > kept; height-- {
+			require.NoError(t, chain.RevertHead())
+		}
+		require.ErrorIs(t, chain.RevertHead(), core.ErrStatePruned)
+		height, err := chain.Height()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(kept), height)
+	})
+}
@@ -0,0 +1,206 @@
+// Package recentevents keeps the events of the most recent blocks in memory, so that the clients that poll for
+// what happened since their last request are served without reading the database or the event filters.
+package recentevents
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+)
+
+var _ service.Service = (*Ring)(nil)
+
+// headPollInterval is how frequently the head is checked for new blocks
+const headPollInterval = time.Second
+
+// ErrNotRecent is returned for the blocks that are older than the recent blocks
+var ErrNotRecent = errors.New("the block is older than the recent blocks")
+
+// Event is an event of a recent block
+type Event struct {
+	*core.Event
+	BlockNumber     uint64
+	BlockHash       *felt.Felt
+	TransactionHash *felt.Felt
+}
+
+type recentBlock struct {
+	number uint64
+	hash   *felt.Felt
+	events []Event
+}
+
+// Ring holds the events of a bounded number of the most recent blocks, and drops the blocks that are reverted
+type Ring struct {
+	chain    blockchain.Reader
+	capacity uint64
+	log      utils.SimpleLogger
+
+	mu sync.RWMutex
+	// blocks are the recent blocks in ascending order, at most capacity of them
+	blocks []*recentBlock
+}
+
+// New returns a Ring of the events of the last blocks blocks of chain
+func New(chain blockchain.Reader, blocks uint64, log utils.SimpleLogger) *Ring {
+	return &Ring{
+		chain:    chain,
+		capacity: blocks,
+		log:      log,
+	}
+}
+
+// Run keeps the recent blocks up to date with the head until ctx is cancelled
+func (r *Ring) Run(ctx context.Context) error {
+	for {
+		if err := r.update(); err != nil {
+			r.log.Warnw("Failed to update the recent events", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(headPollInterval):
+		}
+	}
+}
+
+// update drops the recent blocks that were reverted and adds the blocks stored since the last update
+func (r *Ring) update() error {
+	head, err := r.chain.HeadsHeader()
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var newest *recentBlock
+	for newest = r.newest(); newest != nil; newest = r.newest() {
+		if newest.number <= head.Number {
+			header, headerErr := r.chain.BlockHeaderByNumber(newest.number)
+			if headerErr != nil && !errors.Is(headerErr, db.ErrKeyNotFound) {
+				return headerErr
+			} else if headerErr == nil && header.Hash.Equal(newest.hash) {
+				break
+			}
+		}
+		r.dropNewest()
+	}
+
+	next := uint64(0)
+	if head.Number+1 > r.capacity {
+		next = head.Number + 1 - r.capacity
+	}
+	if newest != nil && newest.number+1 > next {
+		next = newest.number + 1
+	}
+	for ; next <= head.Number; next++ {
+		block, blockErr := r.chain.BlockByNumber(next)
+		if blockErr != nil {
+			return blockErr
+		}
+		if newest != nil && newest.number+1 == next && !block.ParentHash.Equal(newest.hash) {
+			// the head was reverted since it was read, the next update catches up with it
+			return nil
+		}
+		newest = blockOf(block)
+		r.add(newest)
+	}
+	return nil
+}
+
+func blockOf(block *core.Block) *recentBlock {
+	// the events are held until the block is no longer recent
+	core.InternEvents(block.Receipts)
+	recent := &recentBlock{
+		number: block.Number,
+		hash:   block.Hash,
+	}
+	for _, receipt := range block.Receipts {
+		for _, event := range receipt.Events {
+			recent.events = append(recent.events, Event{
+				Event:           event,
+				BlockNumber:     block.Number,
+				BlockHash:       block.Hash,
+				TransactionHash: receipt.TransactionHash,
+			})
+		}
+	}
+	return recent
+}
+
+func (r *Ring) newest() *recentBlock {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.blocks) == 0 {
+		return nil
+	}
+	return r.blocks[len(r.blocks)-1]
+}
+
+func (r *Ring) dropNewest() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blocks[len(r.blocks)-1] = nil
+	r.blocks = r.blocks[:len(r.blocks)-1]
+}
+
+// add adds block after the newest block, and drops the oldest block if there are more than capacity
+func (r *Ring) add(block *recentBlock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if uint64(len(r.blocks)) >= r.capacity {
+		r.blocks[0] = nil
+		r.blocks = r.blocks[1:]
+	}
+	r.blocks = append(r.blocks, block)
+}
+
+// Since returns the events of the recent blocks from the block from, and the number of the block after the newest
+// recent block, to be passed to Since to get the events of the blocks that follow. It fails with ErrNotRecent if
+// the block from is older than the recent blocks. Blocks that are replaced by a reorg are not returned again.
+func (r *Ring) Since(from uint64) ([]Event, uint64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.blocks) == 0 {
+		return nil, from, nil
+	}
+	if from < r.blocks[0].number {
+		return nil, 0, ErrNotRecent
+	}
+
+	events := []Event{}
+	for _, block := range r.blocks {
+		if block.number < from {
+			continue
+		}
+		for _, event := range block.events {
+			// the recent events share interned felts, see core.InternEvents
+			event.Event = event.Event.Copy()
+			events = append(events, event)
+		}
+	}
+	next := r.blocks[len(r.blocks)-1].number + 1
+	if from > next {
+		next = from
+	}
+	return events, next, nil
+}
+
+// Oldest returns the number of the oldest recent block, and false if there are no recent blocks
+func (r *Ring) Oldest() (uint64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.blocks) == 0 {
+		return 0, false
+	}
+	return r.blocks[0].number, true
+}
@@ -0,0 +1,126 @@
+package recentevents_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/recentevents"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	blocks := make([]*core.Block, 6)
+	eventCounts := make([]int, len(blocks))
+	store := func(i int) {
+		stateUpdate, err := gw.StateUpdate(context.Background(), uint64(i))
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(blocks[i], &core.BlockCommitments{}, stateUpdate, nil))
+	}
+	for i := range blocks {
+		var err error
+		blocks[i], err = gw.BlockByNumber(context.Background(), uint64(i))
+		require.NoError(t, err)
+		for _, receipt := range blocks[i].Receipts {
+			eventCounts[i] += len(receipt.Events)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		store(i)
+	}
+
+	ring := recentevents.New(chain, 3, utils.NewNopZapLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ring.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	// awaitNext waits for the recent blocks to end with the block before next
+	awaitNext := func(next uint64) {
+		require.Eventually(t, func() bool {
+			oldest, ok := ring.Oldest()
+			if !ok {
+				return false
+			}
+			_, ringNext, err := ring.Since(oldest)
+			return err == nil && ringNext == next
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+	awaitNext(5)
+
+	oldest, ok := ring.Oldest()
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), oldest)
+
+	events, next, err := ring.Since(2)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), next)
+	assert.Len(t, events, eventCounts[2]+eventCounts[3]+eventCounts[4])
+	for _, event := range events {
+		assert.Equal(t, blocks[event.BlockNumber].Hash, event.BlockHash)
+	}
+
+	events, next, err = ring.Since(4)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), next)
+	assert.Len(t, events, eventCounts[4])
+
+	events, next, err = ring.Since(5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), next)
+	assert.Empty(t, events)
+
+	_, _, err = ring.Since(1)
+	require.ErrorIs(t, err, recentevents.ErrNotRecent)
+
+	t.Run("returned events are copies", func(t *testing.T) {
+		events, _, err := ring.Since(2)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		from := *events[0].From
+		events[0].From.SetUint64(0xdead)
+
+		events, _, err = ring.Since(2)
+		require.NoError(t, err)
+		assert.Equal(t, from, *events[0].From)
+	})
+
+	t.Run("reverted blocks are dropped", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		awaitNext(4)
+		events, _, err := ring.Since(2)
+		require.NoError(t, err)
+		assert.Len(t, events, eventCounts[2]+eventCounts[3])
+	})
+
+	t.Run("new blocks replace the oldest", func(t *testing.T) {
+		store(4)
+		store(5)
+		awaitNext(6)
+		oldest, ok := ring.Oldest()
+		require.True(t, ok)
+		assert.Equal(t, uint64(3), oldest)
+		events, _, err := ring.Since(3)
+		require.NoError(t, err)
+		assert.Len(t, events, eventCounts[3]+eventCounts[4]+eventCounts[5])
+	})
+}
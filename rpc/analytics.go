@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"github.com/NethermindEth/juno/analytics"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+// defaultAnalyticsDays is the number of days returned by juno_getDailyAnalytics when it is called without a count
+const defaultAnalyticsDays = 7
+
+// DailyAnalytics is the activity of the chain on a UTC day
+type DailyAnalytics struct {
+	Date            string     `json:"date"`
+	Blocks          uint64     `json:"blocks"`
+	Transactions    uint64     `json:"transactions"`
+	Events          uint64     `json:"events"`
+	Fees            *felt.Felt `json:"fees"`
+	ActiveContracts uint64     `json:"active_contracts"`
+}
+
+// WithAnalytics serves the daily aggregates of aggregator
+func (h *Handler) WithAnalytics(aggregator *analytics.Aggregator) *Handler {
+	h.analytics = aggregator
+	return h
+}
+
+// DailyAnalytics returns the activity of the chain on the last days with blocks, the most recent first, and 7 days
+// if days is zero. It is served as juno_getDailyAnalytics.
+func (h *Handler) DailyAnalytics(days uint64) ([]*DailyAnalytics, *jsonrpc.Error) {
+	if h.analytics == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "analytics are not enabled")
+	}
+	if days == 0 {
+		days = defaultAnalyticsDays
+	}
+
+	aggregates, err := h.analytics.Days(days)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	dailyAnalytics := make([]*DailyAnalytics, 0, len(aggregates))
+	for i := range aggregates {
+		day := &aggregates[i]
+		dailyAnalytics = append(dailyAnalytics, &DailyAnalytics{
+			Date:            day.Date().Format("2006-01-02"),
+			Blocks:          day.Blocks,
+			Transactions:    day.Transactions,
+			Events:          day.Events,
+			Fees:            day.Fees,
+			ActiveContracts: day.ActiveContracts,
+		})
+	}
+	return dailyAnalytics, nil
+}
@@ -0,0 +1,63 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/analytics"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/rpc"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyAnalytics(t *testing.T) {
+	t.Run("not enabled", func(t *testing.T) {
+		handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger())
+		_, rpcErr := handler.DailyAnalytics(0)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+	})
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.MAINNET, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	block, err := gw.BlockByNumber(context.Background(), 0)
+	require.NoError(t, err)
+	stateUpdate, err := gw.StateUpdate(context.Background(), 0)
+	require.NoError(t, err)
+	require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+
+	aggregator := analytics.New(testDB, 1<<20, utils.NewNopZapLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- aggregator.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	handler := rpc.New(chain, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger()).WithAnalytics(aggregator)
+
+	var days []*rpc.DailyAnalytics
+	require.Eventually(t, func() bool {
+		var rpcErr *jsonrpc.Error
+		days, rpcErr = handler.DailyAnalytics(0)
+		require.Nil(t, rpcErr)
+		return len(days) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, time.Unix(int64(block.Timestamp), 0).UTC().Format("2006-01-02"), days[0].Date)
+	assert.Equal(t, uint64(1), days[0].Blocks)
+	assert.Equal(t, uint64(len(block.Transactions)), days[0].Transactions)
+}
@@ -95,3 +95,15 @@ type BlockWithTxHashes struct {
 	BlockHeader
 	TxnHashes []*felt.Felt `json:"transactions"`
 }
+
+// https://github.com/starkware-libs/starknet-specs/blob/v0.7.0/api/starknet_api_openrpc.json#L1557
+type BlockWithReceipts struct {
+	Status BlockStatus `json:"status"`
+	BlockHeader
+	Transactions []TransactionWithReceipt `json:"transactions"`
+}
+
+type TransactionWithReceipt struct {
+	Transaction *Transaction        `json:"transaction"`
+	Receipt     *TransactionReceipt `json:"receipt"`
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/vm"
+)
+
+// ClassHashVerification compares the hashes a class was declared with to the hashes recomputed from its
+// definition. Only Sierra classes have a compiled class hash.
+type ClassHashVerification struct {
+	ClassHash                 *felt.Felt `json:"class_hash"`
+	ComputedClassHash         *felt.Felt `json:"computed_class_hash"`
+	CompiledClassHash         *felt.Felt `json:"compiled_class_hash,omitempty"`
+	ComputedCompiledClassHash *felt.Felt `json:"computed_compiled_class_hash,omitempty"`
+	Valid                     bool       `json:"valid"`
+}
+
+// VerifyClassHash recomputes the class hash of a class declared at a block, and the hash of its compiled
+// class for Sierra classes, and compares them to the hashes it was declared with.
+//
+// It is a Juno-specific method (juno_verifyClassHash), which catches classes that were corrupted by the
+// gateway they were synced from.
+func (h *Handler) VerifyClassHash(id BlockID, classHash felt.Felt) (*ClassHashVerification, *jsonrpc.Error) {
+	state, stateCloser, err := h.stateByBlockID(&id)
+	if err != nil {
+		return nil, ErrBlockNotFound
+	}
+	declared, err := state.Class(&classHash)
+	h.callAndLogErr(stateCloser, "Error closing state reader in verifyClassHash")
+	if err != nil {
+		return nil, ErrClassHashNotFound
+	}
+
+	computed, err := vm.ClassHash(declared.Class)
+	if err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+	}
+	verification := &ClassHashVerification{
+		ClassHash:         &classHash,
+		ComputedClassHash: computed,
+		Valid:             computed.Equal(&classHash),
+	}
+
+	if class, ok := declared.Class.(*core.Cairo1Class); ok {
+		if verification.CompiledClassHash, err = h.declaredCompiledClassHash(&id, &classHash, declared.At); err != nil {
+			return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+		}
+		if verification.ComputedCompiledClassHash, err = class.CompiledClassHash(); err != nil {
+			return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+		}
+		verification.Valid = verification.Valid &&
+			verification.ComputedCompiledClassHash.Equal(verification.CompiledClassHash)
+	}
+	return verification, nil
+}
+
+// declaredCompiledClassHash returns the compiled class hash the Sierra class was declared with, from the state
+// update of the block it was declared at or from the pending state update
+func (h *Handler) declaredCompiledClassHash(id *BlockID, classHash *felt.Felt, declaredAt uint64) (*felt.Felt, error) {
+	if id.Pending {
+		pending, err := h.bcReader.Pending()
+		if err != nil {
+			return nil, err
+		}
+		if compiledClassHash := findDeclaredV1Class(pending.StateUpdate, classHash); compiledClassHash != nil {
+			return compiledClassHash, nil
+		}
+	}
+
+	stateUpdate, err := h.bcReader.StateUpdateByNumber(declaredAt)
+	if err != nil {
+		return nil, err
+	}
+	if compiledClassHash := findDeclaredV1Class(stateUpdate, classHash); compiledClassHash != nil {
+		return compiledClassHash, nil
+	}
+	return nil, errors.New("class is not declared by the state update of the block it was declared at")
+}
+
+func findDeclaredV1Class(stateUpdate *core.StateUpdate, classHash *felt.Felt) *felt.Felt {
+	for _, declaredClass := range stateUpdate.StateDiff.DeclaredV1Classes {
+		if declaredClass.ClassHash.Equal(classHash) {
+			return declaredClass.CompiledClassHash
+		}
+	}
+	return nil
+}
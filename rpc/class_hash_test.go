@@ -0,0 +1,123 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/mocks"
+	"github.com/NethermindEth/juno/rpc"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyClassHash(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockReader := mocks.NewMockReader(mockCtrl)
+	mockState := mocks.NewMockStateHistoryReader(mockCtrl)
+	handler := rpc.New(mockReader, nil, utils.INTEGRATION, nil, nil, nil, "", utils.NewNopZapLogger())
+
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.INTEGRATION))
+	classHash := utils.HexToFelt(t, "0x1cd2edfb485241c4403254d550de0a097fa76743cd30696f714a491a454bad5")
+	class, err := gw.Class(context.Background(), classHash)
+	require.NoError(t, err)
+	compiledClassHash, err := class.(*core.Cairo1Class).CompiledClassHash()
+	require.NoError(t, err)
+
+	const declaredAt = 5
+	stateUpdate := func(compiledClassHash *felt.Felt) *core.StateUpdate {
+		return &core.StateUpdate{StateDiff: &core.StateDiff{
+			DeclaredV1Classes: []core.DeclaredV1Class{{ClassHash: classHash, CompiledClassHash: compiledClassHash}},
+		}}
+	}
+	latest := rpc.BlockID{Latest: true}
+	nopCloser := func() error { return nil }
+
+	t.Run("block not found", func(t *testing.T) {
+		mockReader.EXPECT().HeadState().Return(nil, nil, errors.New("empty"))
+
+		_, rpcErr := handler.VerifyClassHash(latest, *classHash)
+		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
+	})
+
+	t.Run("class not found", func(t *testing.T) {
+		mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil)
+		mockState.EXPECT().Class(classHash).Return(nil, errors.New("not found"))
+
+		_, rpcErr := handler.VerifyClassHash(latest, *classHash)
+		assert.Equal(t, rpc.ErrClassHashNotFound, rpcErr)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil)
+		mockState.EXPECT().Class(classHash).Return(&core.DeclaredClass{At: declaredAt, Class: class}, nil)
+		mockReader.EXPECT().StateUpdateByNumber(uint64(declaredAt)).Return(stateUpdate(compiledClassHash), nil)
+
+		verification, rpcErr := handler.VerifyClassHash(latest, *classHash)
+		require.Nil(t, rpcErr)
+		assert.Equal(t, &rpc.ClassHashVerification{
+			ClassHash:                 classHash,
+			ComputedClassHash:         classHash,
+			CompiledClassHash:         compiledClassHash,
+			ComputedCompiledClassHash: compiledClassHash,
+			Valid:                     true,
+		}, verification)
+	})
+
+	t.Run("compiled class hash mismatch", func(t *testing.T) {
+		declaredCompiledClassHash := utils.HexToFelt(t, "0xab")
+		mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil)
+		mockState.EXPECT().Class(classHash).Return(&core.DeclaredClass{At: declaredAt, Class: class}, nil)
+		mockReader.EXPECT().StateUpdateByNumber(uint64(declaredAt)).Return(stateUpdate(declaredCompiledClassHash), nil)
+
+		verification, rpcErr := handler.VerifyClassHash(latest, *classHash)
+		require.Nil(t, rpcErr)
+		assert.Equal(t, declaredCompiledClassHash, verification.CompiledClassHash)
+		assert.Equal(t, compiledClassHash, verification.ComputedCompiledClassHash)
+		assert.False(t, verification.Valid)
+	})
+
+	t.Run("class hash mismatch", func(t *testing.T) {
+		otherHash := utils.HexToFelt(t, "0xcd")
+		mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil)
+		mockState.EXPECT().Class(otherHash).Return(&core.DeclaredClass{At: declaredAt, Class: class}, nil)
+		mockReader.EXPECT().StateUpdateByNumber(uint64(declaredAt)).Return(&core.StateUpdate{StateDiff: &core.StateDiff{
+			DeclaredV1Classes: []core.DeclaredV1Class{{ClassHash: otherHash, CompiledClassHash: compiledClassHash}},
+		}}, nil)
+
+		verification, rpcErr := handler.VerifyClassHash(latest, *otherHash)
+		require.Nil(t, rpcErr)
+		assert.Equal(t, classHash, verification.ComputedClassHash)
+		assert.False(t, verification.Valid)
+	})
+
+	t.Run("declared by pending block", func(t *testing.T) {
+		mockReader.EXPECT().PendingState().Return(mockState, nopCloser, nil)
+		mockState.EXPECT().Class(classHash).Return(&core.DeclaredClass{At: 0, Class: class}, nil)
+		mockReader.EXPECT().Pending().Return(blockchain.Pending{StateUpdate: stateUpdate(compiledClassHash)}, nil)
+
+		verification, rpcErr := handler.VerifyClassHash(rpc.BlockID{Pending: true}, *classHash)
+		require.Nil(t, rpcErr)
+		assert.True(t, verification.Valid)
+	})
+
+	t.Run("not declared by the state update", func(t *testing.T) {
+		mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil)
+		mockState.EXPECT().Class(classHash).Return(&core.DeclaredClass{At: declaredAt, Class: class}, nil)
+		mockReader.EXPECT().StateUpdateByNumber(uint64(declaredAt)).Return(&core.StateUpdate{StateDiff: new(core.StateDiff)}, nil)
+
+		_, rpcErr := handler.VerifyClassHash(latest, *classHash)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.InternalError, rpcErr.Code)
+	})
+}
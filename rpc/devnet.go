@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/devnet"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+// DevnetAccount is a prefunded account of the devnet
+type DevnetAccount struct {
+	Address    *felt.Felt `json:"address"`
+	PublicKey  *felt.Felt `json:"public_key"`
+	PrivateKey *felt.Felt `json:"private_key"`
+}
+
+// WithDevnet submits transactions to net instead of the gateway
+func (h *Handler) WithDevnet(net *devnet.Devnet) *Handler {
+	h.devnet = net
+	return h
+}
+
+// DevnetAccounts returns the prefunded accounts of the devnet, it is served as juno_getDevnetAccounts
+func (h *Handler) DevnetAccounts() ([]*DevnetAccount, *jsonrpc.Error) {
+	if h.devnet == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "the node is not running a devnet")
+	}
+
+	accounts := make([]*DevnetAccount, 0, len(h.devnet.Accounts()))
+	for _, account := range h.devnet.Accounts() {
+		accounts = append(accounts, &DevnetAccount{
+			Address:    account.Address,
+			PublicKey:  account.PublicKey,
+			PrivateKey: account.PrivateKey,
+		})
+	}
+	return accounts, nil
+}
+
+// submitToDevnet queues the transaction of txnJSON for the next devnet block
+func (h *Handler) submitToDevnet(txnJSON json.RawMessage) (*AddTxResponse, *jsonrpc.Error) {
+	var broadcastedTxn BroadcastedTransaction
+	if err := json.Unmarshal(txnJSON, &broadcastedTxn); err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InvalidParams, err.Error())
+	}
+	txn, class, _, err := adaptBroadcastedTransaction(&broadcastedTxn, h.network)
+	if err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InvalidParams, err.Error())
+	}
+
+	if err = h.devnet.Submit(txn, class); err != nil {
+		if errors.Is(err, devnet.ErrQueueFull) {
+			return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+		}
+		return nil, jsonrpc.Err(jsonrpc.InvalidParams, err.Error())
+	}
+	response := pooledTxResponse(txn)
+	return &response, nil
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/eventfilters"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+var (
+	ErrEventFilterNotFound = &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "Event filter not found"}
+	// ErrTooManyEventFilters is returned when the maximum number of filters is installed, it uses the code
+	// JSON-RPC reserves for servers to report exceeded limits
+	ErrTooManyEventFilters = &jsonrpc.Error{Code: -32005, Message: "Too many event filters are installed"}
+)
+
+// InstalledEventFilter is a filter of the events from a block on, whose changes are polled with
+// juno_getEventFilterChanges. The events of the pending block are not returned, FromBlock is the block after the
+// head by default and if it is pending.
+type InstalledEventFilter struct {
+	FromBlock *BlockID      `json:"from_block"`
+	Address   *felt.Felt    `json:"address"`
+	Keys      [][]felt.Felt `json:"keys"`
+}
+
+// EventFilterChanges are the events of a filter since it was last polled. RevertedFromBlock is the first block that
+// was reverted since, if any: the events returned before for it and the blocks after it are to be dropped, and the
+// events of the blocks that replaced them follow. HasMore reports whether more events are ready to be polled.
+type EventFilterChanges struct {
+	Events            []*EmittedEvent `json:"events"`
+	RevertedFromBlock *uint64         `json:"reverted_from_block,omitempty"`
+	HasMore           bool            `json:"has_more"`
+}
+
+// WithEventFilters serves the event filters of store
+func (h *Handler) WithEventFilters(store *eventfilters.Store) *Handler {
+	h.eventFilters = store
+	return h
+}
+
+// NewEventFilter installs filter and returns its id, it is served as juno_newEventFilter. The filter is kept across
+// restarts until it is uninstalled or is not polled for a day.
+func (h *Handler) NewEventFilter(filter InstalledEventFilter) (*felt.Felt, *jsonrpc.Error) {
+	if h.eventFilters == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "event filters are not enabled")
+	}
+	lenKeys := len(filter.Keys)
+	for _, keys := range filter.Keys {
+		lenKeys += len(keys)
+	}
+	if lenKeys > maxEventFilterKeys {
+		return nil, ErrTooManyKeysInFilter
+	}
+
+	var from uint64
+	if filter.FromBlock == nil || filter.FromBlock.Pending {
+		height, err := h.bcReader.Height()
+		if err == nil {
+			from = height + 1
+		} else if !errors.Is(err, db.ErrKeyNotFound) {
+			return nil, ErrInternal
+		}
+	} else {
+		header, err := h.blockHeaderByID(filter.FromBlock)
+		if err != nil {
+			return nil, ErrBlockNotFound
+		}
+		from = header.Number
+	}
+
+	id, err := h.eventFilters.Install(filter.Address, filter.Keys, from)
+	if errors.Is(err, eventfilters.ErrTooManyFilters) {
+		return nil, ErrTooManyEventFilters
+	} else if err != nil {
+		return nil, ErrInternal
+	}
+	return id, nil
+}
+
+// EventFilterChanges returns at most chunkSize events of the filter id since it was last polled, it is served as
+// juno_getEventFilterChanges. The filter moves past the events it returns, so that the next call returns the events
+// that follow them.
+func (h *Handler) EventFilterChanges(id felt.Felt, chunkSize uint64) (*EventFilterChanges, *jsonrpc.Error) {
+	if h.eventFilters == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "event filters are not enabled")
+	}
+	if chunkSize == 0 {
+		return nil, jsonrpc.Err(jsonrpc.InvalidParams, "chunk_size must be positive")
+	} else if chunkSize > maxEventChunkSize {
+		return nil, ErrPageSizeTooBig
+	}
+
+	changes, err := h.eventFilters.Changes(&id, chunkSize)
+	if errors.Is(err, eventfilters.ErrNotFound) {
+		return nil, ErrEventFilterNotFound
+	} else if err != nil {
+		return nil, ErrInternal
+	}
+
+	emittedEvents := make([]*EmittedEvent, 0, len(changes.Events))
+	for _, event := range changes.Events {
+		blockNumber := event.BlockNumber
+		emittedEvents = append(emittedEvents, &EmittedEvent{
+			BlockNumber:     &blockNumber,
+			BlockHash:       event.BlockHash,
+			TransactionHash: event.TransactionHash,
+			Event: &Event{
+				From: event.From,
+				Keys: event.Keys,
+				Data: event.Data,
+			},
+		})
+	}
+	return &EventFilterChanges{
+		Events:            emittedEvents,
+		RevertedFromBlock: changes.Reverted,
+		HasMore:           changes.More,
+	}, nil
+}
+
+// UninstallEventFilter uninstalls the filter id and reports whether it was installed, it is served as
+// juno_uninstallEventFilter
+func (h *Handler) UninstallEventFilter(id felt.Felt) (bool, *jsonrpc.Error) {
+	if h.eventFilters == nil {
+		return false, jsonrpc.Err(jsonrpc.MethodNotFound, "event filters are not enabled")
+	}
+	installed, err := h.eventFilters.Uninstall(&id)
+	if err != nil {
+		return false, ErrInternal
+	}
+	return installed, nil
+}
@@ -0,0 +1,91 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/eventfilters"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/rpc"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFilters(t *testing.T) {
+	t.Run("not enabled", func(t *testing.T) {
+		handler := rpc.New(nil, nil, utils.GOERLI2, nil, nil, nil, "", utils.NewNopZapLogger())
+		_, rpcErr := handler.NewEventFilter(rpc.InstalledEventFilter{})
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+		_, rpcErr = handler.EventFilterChanges(felt.Zero, 1)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+		_, rpcErr = handler.UninstallEventFilter(felt.Zero)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+	})
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+	for i := uint64(0); i < 6; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+	}
+	handler := rpc.New(chain, nil, utils.GOERLI2, nil, nil, nil, "", utils.NewNopZapLogger()).
+		WithEventFilters(eventfilters.New(testDB, chain, 10))
+
+	t.Run("events from a block", func(t *testing.T) {
+		id, rpcErr := handler.NewEventFilter(rpc.InstalledEventFilter{FromBlock: &rpc.BlockID{Number: 5}})
+		require.Nil(t, rpcErr)
+
+		changes, rpcErr := handler.EventFilterChanges(*id, 1)
+		require.Nil(t, rpcErr)
+		require.Len(t, changes.Events, 1)
+		assert.Equal(t, uint64(5), *changes.Events[0].BlockNumber)
+		assert.True(t, changes.HasMore)
+		assert.Nil(t, changes.RevertedFromBlock)
+
+		changes, rpcErr = handler.EventFilterChanges(*id, 10)
+		require.Nil(t, rpcErr)
+		require.Len(t, changes.Events, 1)
+		assert.False(t, changes.HasMore)
+
+		installed, rpcErr := handler.UninstallEventFilter(*id)
+		require.Nil(t, rpcErr)
+		assert.True(t, installed)
+		_, rpcErr = handler.EventFilterChanges(*id, 10)
+		assert.Equal(t, rpc.ErrEventFilterNotFound, rpcErr)
+	})
+
+	t.Run("new events only by default", func(t *testing.T) {
+		id, rpcErr := handler.NewEventFilter(rpc.InstalledEventFilter{})
+		require.Nil(t, rpcErr)
+		changes, rpcErr := handler.EventFilterChanges(*id, 10)
+		require.Nil(t, rpcErr)
+		assert.Empty(t, changes.Events)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, rpcErr := handler.NewEventFilter(rpc.InstalledEventFilter{FromBlock: &rpc.BlockID{Number: 100}})
+		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
+		_, rpcErr = handler.EventFilterChanges(felt.Zero, 0)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.InvalidParams, rpcErr.Code)
+		_, rpcErr = handler.EventFilterChanges(felt.Zero, 10)
+		assert.Equal(t, rpc.ErrEventFilterNotFound, rpcErr)
+	})
+}
@@ -4,17 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/NethermindEth/juno/analytics"
 	"github.com/NethermindEth/juno/blockchain"
 	"github.com/NethermindEth/juno/clients/feeder"
 	"github.com/NethermindEth/juno/clients/gateway"
 	"github.com/NethermindEth/juno/core"
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/devnet"
+	"github.com/NethermindEth/juno/eventfilters"
+	"github.com/NethermindEth/juno/jobs"
 	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/NethermindEth/juno/mempool"
+	"github.com/NethermindEth/juno/recentevents"
 	"github.com/NethermindEth/juno/sync"
+	"github.com/NethermindEth/juno/tracing"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/juno/vm"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 //go:generate mockgen -destination=../mocks/mock_gateway_handler.go -package=mocks github.com/NethermindEth/juno/rpc Gateway
@@ -48,6 +59,9 @@ var (
 	ErrUnsupportedTxVersion            = &jsonrpc.Error{Code: 61, Message: "the transaction version is not supported"}
 	ErrUnsupportedContractClassVersion = &jsonrpc.Error{Code: 62, Message: "the contract class version is not supported"}
 	ErrUnexpectedError                 = &jsonrpc.Error{Code: 63, Message: "An unexpected error occurred"}
+	// ErrExecutionResourcesExceeded is returned by the methods that run the VM when an execution exceeds the
+	// limits of the method, it uses the code JSON-RPC reserves for servers to report exceeded limits
+	ErrExecutionResourcesExceeded = &jsonrpc.Error{Code: -32005, Message: "Execution resources exceeded"}
 )
 
 const (
@@ -55,6 +69,12 @@ const (
 	maxEventFilterKeys = 1024
 )
 
+// SubmittedTransactionStore keeps track of the transactions that were submitted through this node
+type SubmittedTransactionStore interface {
+	StoreSubmittedTransaction(submitted *blockchain.SubmittedTransaction) error
+	SubmittedTransaction(hash *felt.Felt) (*blockchain.SubmittedTransaction, error)
+}
+
 type Handler struct {
 	bcReader      blockchain.Reader
 	synchronizer  *sync.Synchronizer
@@ -64,6 +84,23 @@ type Handler struct {
 	vm            vm.VM
 	log           utils.Logger
 	version       string
+	submittedTxns SubmittedTransactionStore
+	labels        *labels.Registry
+
+	pool                *mempool.Pool
+	recentEvents        *recentevents.Ring
+	recentEventsLimiter *rateLimiter
+	analytics           *analytics.Aggregator
+	devnet              *devnet.Devnet
+	schemaDB            db.DB
+	jobs                *jobs.Manager
+	eventFilters        *eventfilters.Store
+
+	executionLimits vm.Limits
+	methodLimits    map[string]vm.Limits
+
+	maxResponseSize     uint64
+	methodResponseSizes map[string]uint64
 }
 
 func New(bcReader blockchain.Reader, synchronizer *sync.Synchronizer, n utils.Network,
@@ -81,6 +118,53 @@ func New(bcReader blockchain.Reader, synchronizer *sync.Synchronizer, n utils.Ne
 	}
 }
 
+// WithSubmittedTransactions persists the transactions submitted through the handler in store,
+// so that their status can be reported before they are included in a block
+func (h *Handler) WithSubmittedTransactions(store SubmittedTransactionStore) *Handler {
+	h.submittedTxns = store
+	return h
+}
+
+// WithPool submits transactions through pool, which submits them again if the gateway cannot be reached
+func (h *Handler) WithPool(pool *mempool.Pool) *Handler {
+	h.pool = pool
+	return h
+}
+
+// WithLabels serves the labels of registry
+func (h *Handler) WithLabels(registry *labels.Registry) *Handler {
+	h.labels = registry
+	return h
+}
+
+// WithExecutionLimits caps the resources of the executions of the methods that run the VM. The non-zero
+// limits of methodLimits, which is keyed by method name such as starknet_call, override limits for their method.
+// Method names are not case-sensitive, since the keys of the configuration file are lowercased.
+func (h *Handler) WithExecutionLimits(limits vm.Limits, methodLimits map[string]vm.Limits) *Handler {
+	h.executionLimits = limits
+	h.methodLimits = make(map[string]vm.Limits, len(methodLimits))
+	for method, methodLimit := range methodLimits {
+		h.methodLimits[strings.ToLower(method)] = methodLimit
+	}
+	return h
+}
+
+// limits returns the execution limits of method
+func (h *Handler) limits(method string) vm.Limits {
+	return h.executionLimits.Override(h.methodLimits[strings.ToLower(method)])
+}
+
+// vmError returns the error of a response to a request whose execution failed with err
+func vmError(err error) *jsonrpc.Error {
+	rpcErr := *ErrContractError
+	var exceeded *vm.ResourcesExceededError
+	if errors.As(err, &exceeded) {
+		rpcErr = *ErrExecutionResourcesExceeded
+	}
+	rpcErr.Data = err.Error()
+	return &rpcErr
+}
+
 // ChainID returns the chain ID of the currently configured network.
 //
 // It follows the specification defined here:
@@ -129,18 +213,11 @@ func (h *Handler) BlockWithTxHashes(id BlockID) (*BlockWithTxHashes, *jsonrpc.Er
 		txnHashes[index] = txn.Hash()
 	}
 
-	l1H, jsonErr := h.l1Head()
+	status, jsonErr := h.blockStatus(id, block)
 	if jsonErr != nil {
 		return nil, jsonErr
 	}
 
-	status := BlockAcceptedL2
-	if id.Pending {
-		status = BlockPending
-	} else if isL1Verified(block.Number, l1H) {
-		status = BlockAcceptedL1
-	}
-
 	return &BlockWithTxHashes{
 		Status:      status,
 		BlockHeader: adaptBlockHeader(block.Header),
@@ -164,6 +241,22 @@ func isL1Verified(n uint64, l1 *core.L1Head) bool {
 	return false
 }
 
+func (h *Handler) blockStatus(id BlockID, block *core.Block) (BlockStatus, *jsonrpc.Error) {
+	l1H, jsonErr := h.l1Head()
+	if jsonErr != nil {
+		return 0, jsonErr
+	}
+
+	status := BlockAcceptedL2
+	if id.Pending {
+		status = BlockPending
+	} else if isL1Verified(block.Number, l1H) {
+		status = BlockAcceptedL1
+	}
+
+	return status, nil
+}
+
 func adaptBlockHeader(header *core.Header) BlockHeader {
 	var blockNumber *uint64
 	// if header.Hash == nil it's a pending block
@@ -196,18 +289,11 @@ func (h *Handler) BlockWithTxs(id BlockID) (*BlockWithTxs, *jsonrpc.Error) {
 		txs[index] = adaptTransaction(txn)
 	}
 
-	l1H, jsonErr := h.l1Head()
+	status, jsonErr := h.blockStatus(id, block)
 	if jsonErr != nil {
 		return nil, jsonErr
 	}
 
-	status := BlockAcceptedL2
-	if id.Pending {
-		status = BlockPending
-	} else if isL1Verified(block.Number, l1H) {
-		status = BlockAcceptedL1
-	}
-
 	return &BlockWithTxs{
 		Status:       status,
 		BlockHeader:  adaptBlockHeader(block.Header),
@@ -215,6 +301,43 @@ func (h *Handler) BlockWithTxs(id BlockID) (*BlockWithTxs, *jsonrpc.Error) {
 	}, nil
 }
 
+// BlockWithReceipts returns the block information with full transactions and their receipts given a block ID.
+//
+// It follows the specification defined here:
+// https://github.com/starkware-libs/starknet-specs/blob/v0.7.0/api/starknet_api_openrpc.json#L99
+func (h *Handler) BlockWithReceipts(id BlockID) (*BlockWithReceipts, *jsonrpc.Error) {
+	block, err := h.blockByID(&id)
+	if block == nil || err != nil {
+		return nil, ErrBlockNotFound
+	}
+
+	status, jsonErr := h.blockStatus(id, block)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	finalityStatus := TxnAcceptedOnL2
+	if status == BlockAcceptedL1 {
+		finalityStatus = TxnAcceptedOnL1
+	}
+
+	txsWithReceipts := make([]TransactionWithReceipt, len(block.Transactions))
+	for index, txn := range block.Transactions {
+		adaptedTxn := adaptTransaction(txn)
+		txsWithReceipts[index] = TransactionWithReceipt{
+			Transaction: adaptedTxn,
+			// block_hash and block_number are omitted from receipts embedded in a block
+			Receipt: adaptReceipt(block.Receipts[index], adaptedTxn, finalityStatus, nil, nil),
+		}
+	}
+
+	return &BlockWithReceipts{
+		Status:       status,
+		BlockHeader:  adaptBlockHeader(block.Header),
+		Transactions: txsWithReceipts,
+	}, nil
+}
+
 func adaptTransaction(t core.Transaction) *Transaction {
 	switch v := t.(type) {
 	case *core.DeployTransaction:
@@ -301,39 +424,15 @@ func adaptDeclareTransaction(t *core.DeclareTransaction) *Transaction {
 }
 
 func (h *Handler) blockByID(id *BlockID) (*core.Block, error) {
-	switch {
-	case id.Latest:
-		return h.bcReader.Head()
-	case id.Hash != nil:
-		return h.bcReader.BlockByHash(id.Hash)
-	case id.Pending:
-		pending, err := h.bcReader.Pending()
-		if err != nil {
-			return nil, err
-		}
-
-		return pending.Block, nil
-	default:
-		return h.bcReader.BlockByNumber(id.Number)
-	}
+	return blockchain.BlockByID(h.bcReader, blockchain.BlockID(*id))
 }
 
 func (h *Handler) blockHeaderByID(id *BlockID) (*core.Header, error) {
-	switch {
-	case id.Latest:
-		return h.bcReader.HeadsHeader()
-	case id.Hash != nil:
-		return h.bcReader.BlockHeaderByHash(id.Hash)
-	case id.Pending:
-		pending, err := h.bcReader.Pending()
-		if err != nil {
-			return nil, err
-		}
+	return blockchain.BlockHeaderByID(h.bcReader, blockchain.BlockID(*id))
+}
 
-		return pending.Block.Header, nil
-	default:
-		return h.bcReader.BlockHeaderByNumber(id.Number)
-	}
+func (h *Handler) stateUpdateByID(id *BlockID) (*core.StateUpdate, error) {
+	return blockchain.StateUpdateByID(h.bcReader, blockchain.BlockID(*id))
 }
 
 // TransactionByHash returns the details of a transaction identified by the given hash.
@@ -377,7 +476,7 @@ func (h *Handler) TransactionByBlockIDAndIndex(id BlockID, txIndex int) (*Transa
 			return nil, ErrBlockNotFound
 		}
 
-		if uint64(txIndex) > pending.Block.TransactionCount {
+		if uint64(txIndex) >= uint64(len(pending.Block.Transactions)) {
 			return nil, ErrInvalidTxIndex
 		}
 
@@ -411,6 +510,28 @@ func (h *Handler) TransactionReceiptByHash(hash felt.Felt) (*TransactionReceipt,
 		return nil, ErrTxnHashNotFound
 	}
 
+	var receiptBlockNumber *uint64
+	status := TxnAcceptedOnL2
+
+	if blockHash != nil {
+		receiptBlockNumber = &blockNumber
+
+		l1H, jsonErr := h.l1Head()
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+
+		if isL1Verified(blockNumber, l1H) {
+			status = TxnAcceptedOnL1
+		}
+	}
+
+	return adaptReceipt(receipt, txn, status, blockHash, receiptBlockNumber), nil
+}
+
+func adaptReceipt(receipt *core.TransactionReceipt, txn *Transaction, finalityStatus TxnFinalityStatus,
+	blockHash *felt.Felt, blockNumber *uint64,
+) *TransactionReceipt {
 	messages := make([]*MsgToL1, len(receipt.L2ToL1Message))
 	for idx, msg := range receipt.L2ToL1Message {
 		messages[idx] = &MsgToL1{
@@ -434,22 +555,6 @@ func (h *Handler) TransactionReceiptByHash(hash felt.Felt) (*TransactionReceipt,
 		contractAddress = nil
 	}
 
-	var receiptBlockNumber *uint64
-	status := TxnAcceptedOnL2
-
-	if blockHash != nil {
-		receiptBlockNumber = &blockNumber
-
-		l1H, jsonErr := h.l1Head()
-		if jsonErr != nil {
-			return nil, jsonErr
-		}
-
-		if isL1Verified(blockNumber, l1H) {
-			status = TxnAcceptedOnL1
-		}
-	}
-
 	var es TxnExecutionStatus
 	if receipt.Reverted {
 		es = TxnFailure
@@ -458,18 +563,18 @@ func (h *Handler) TransactionReceiptByHash(hash felt.Felt) (*TransactionReceipt,
 	}
 
 	return &TransactionReceipt{
-		FinalityStatus:  status,
+		FinalityStatus:  finalityStatus,
 		ExecutionStatus: es,
 		Type:            txn.Type,
 		Hash:            txn.Hash,
 		ActualFee:       receipt.Fee,
 		BlockHash:       blockHash,
-		BlockNumber:     receiptBlockNumber,
+		BlockNumber:     blockNumber,
 		MessagesSent:    messages,
 		Events:          events,
 		ContractAddress: contractAddress,
 		RevertReason:    receipt.RevertReason,
-	}, nil
+	}
 }
 
 // StateUpdate returns the state update identified by the given BlockID.
@@ -477,26 +582,7 @@ func (h *Handler) TransactionReceiptByHash(hash felt.Felt) (*TransactionReceipt,
 // It follows the specification defined here:
 // https://github.com/starkware-libs/starknet-specs/blob/master/api/starknet_api_openrpc.json#L77
 func (h *Handler) StateUpdate(id BlockID) (*StateUpdate, *jsonrpc.Error) {
-	var update *core.StateUpdate
-	var err error
-	if id.Latest {
-		if height, heightErr := h.bcReader.Height(); heightErr != nil {
-			err = heightErr
-		} else {
-			update, err = h.bcReader.StateUpdateByNumber(height)
-		}
-	} else if id.Pending {
-		var pending blockchain.Pending
-		pending, err = h.bcReader.Pending()
-		if err == nil {
-			update = pending.StateUpdate
-		}
-	} else if id.Hash != nil {
-		update, err = h.bcReader.StateUpdateByHash(id.Hash)
-	} else {
-		update, err = h.bcReader.StateUpdateByNumber(id.Number)
-	}
-
+	update, err := h.stateUpdateByID(&id)
 	if err != nil {
 		return nil, ErrBlockNotFound
 	}
@@ -594,16 +680,7 @@ func (h *Handler) Syncing() (*Sync, *jsonrpc.Error) {
 }
 
 func (h *Handler) stateByBlockID(id *BlockID) (core.StateReader, blockchain.StateCloser, error) {
-	switch {
-	case id.Latest:
-		return h.bcReader.HeadState()
-	case id.Hash != nil:
-		return h.bcReader.StateAtBlockHash(id.Hash)
-	case id.Pending:
-		return h.bcReader.PendingState()
-	default:
-		return h.bcReader.StateAtBlockNumber(id.Number)
-	}
+	return blockchain.StateByID(h.bcReader, blockchain.BlockID(*id))
 }
 
 // Nonce returns the nonce associated with the given address in the given block number
@@ -668,6 +745,12 @@ func (h *Handler) ClassHashAt(id BlockID, address felt.Felt) (*felt.Felt, *jsonr
 // It follows the specification defined here:
 // https://github.com/starkware-libs/starknet-specs/blob/master/api/starknet_api_openrpc.json#L248
 func (h *Handler) Class(id BlockID, classHash felt.Felt) (*Class, *jsonrpc.Error) {
+	return h.class(id, classHash, "starknet_getClass")
+}
+
+// class returns the class classHash in the block id, it fails if the class exceeds the maximum response size of
+// method
+func (h *Handler) class(id BlockID, classHash felt.Felt, method string) (*Class, *jsonrpc.Error) {
 	state, stateCloser, err := h.stateByBlockID(&id)
 	if err != nil {
 		return nil, ErrBlockNotFound
@@ -753,6 +836,9 @@ func (h *Handler) Class(id BlockID, classHash felt.Felt) (*Class, *jsonrpc.Error
 		return nil, ErrClassHashNotFound
 	}
 
+	if rpcErr := h.checkResponseSize(method, classSize(rpcClass)); rpcErr != nil {
+		return nil, rpcErr
+	}
 	return rpcClass, nil
 }
 
@@ -765,7 +851,7 @@ func (h *Handler) ClassAt(id BlockID, address felt.Felt) (*Class, *jsonrpc.Error
 	if err != nil {
 		return nil, err
 	}
-	return h.Class(id, *classHash)
+	return h.class(id, *classHash, "starknet_getClassAt")
 }
 
 // Events gets the events matching a filter
@@ -812,6 +898,7 @@ func (h *Handler) Events(args EventsArg) (*EventsChunk, *jsonrpc.Error) {
 	if err != nil {
 		return nil, ErrInternal
 	}
+	filteredEvents, cToken = h.truncateEvents("starknet_getEvents", filteredEvents, cToken)
 
 	emittedEvents := make([]*EmittedEvent, 0, len(filteredEvents))
 	for _, fEvent := range filteredEvents {
@@ -861,14 +948,22 @@ func setEventFilterRange(filter *blockchain.EventFilter, fromID, toID *BlockID,
 	return set(blockchain.EventFilterTo, toID)
 }
 
-// AddTransaction relays a transaction to the gateway.
-func (h *Handler) AddTransaction(txnJSON json.RawMessage) (*AddTxResponse, *jsonrpc.Error) {
+// AddTransaction validates a transaction against the local state and relays it to the gateway.
+func (h *Handler) AddTransaction(ctx context.Context, txnJSON json.RawMessage) (*AddTxResponse, *jsonrpc.Error) {
 	var request map[string]any
 	err := json.Unmarshal(txnJSON, &request)
 	if err != nil {
 		return nil, jsonrpc.Err(jsonrpc.InvalidJSON, err.Error())
 	}
 
+	txn, rpcErr := h.validateTransaction(ctx, txnJSON)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if h.devnet != nil {
+		return h.submitToDevnet(txnJSON)
+	}
+
 	if txnType, typeFound := request["type"]; typeFound && txnType == TxnInvoke.String() {
 		request["type"] = feeder.TxnInvoke.String()
 
@@ -906,20 +1001,144 @@ func (h *Handler) AddTransaction(txnJSON json.RawMessage) (*AddTxResponse, *json
 		txnJSON = updatedReq
 	}
 
-	resp, err := h.gatewayClient.AddTransaction(txnJSON)
+	var resp json.RawMessage
+	if h.pool != nil {
+		resp, err = h.pool.Submit(txn, txnJSON)
+	} else {
+		resp, err = h.gatewayClient.AddTransaction(txnJSON)
+	}
 	if err != nil {
 		return nil, makeJSONErrorFromGatewayError(err)
 	}
 
 	var response AddTxResponse
-	err = json.Unmarshal(resp, &response)
-	if err != nil {
+	if resp == nil {
+		// the transaction is pending in the pool
+		response = pooledTxResponse(txn)
+	} else if err = json.Unmarshal(resp, &response); err != nil {
 		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
 	}
 
+	if h.submittedTxns != nil {
+		// the transaction has already been accepted by the gateway or the pool, so failing to track it is not fatal
+		if err = h.submittedTxns.StoreSubmittedTransaction(&blockchain.SubmittedTransaction{
+			Transaction: txn,
+			SubmittedAt: uint64(time.Now().Unix()),
+		}); err != nil {
+			h.log.Warnw("Failed to store submitted transaction", "hash", txn.Hash(), "err", err)
+		}
+	}
+
 	return &response, nil
 }
 
+// pooledTxResponse returns the response to the submission of a transaction that the gateway has not accepted yet
+func pooledTxResponse(txn core.Transaction) AddTxResponse {
+	response := AddTxResponse{TransactionHash: txn.Hash()}
+	switch t := txn.(type) {
+	case *core.DeployAccountTransaction:
+		response.ContractAddress = t.ContractAddress
+	case *core.DeclareTransaction:
+		response.ClassHash = t.ClassHash
+	}
+	return response
+}
+
+// addTransactionMethod returns the method that submits transactions of type txnType
+func addTransactionMethod(txnType TransactionType) string {
+	switch txnType {
+	case TxnDeclare:
+		return "starknet_addDeclareTransaction"
+	case TxnDeployAccount:
+		return "starknet_addDeployAccountTransaction"
+	default:
+		return "starknet_addInvokeTransaction"
+	}
+}
+
+// validateTransaction checks the nonce, the max fee and the account's validation logic of a transaction
+// against the pending state, or the latest state if there is no pending block. Transactions are rejected
+// with ErrNoBlock until the node has synced a block to validate them against.
+func (h *Handler) validateTransaction(ctx context.Context, txnJSON json.RawMessage) (core.Transaction, *jsonrpc.Error) {
+	id := BlockID{Pending: true}
+	state, closer, err := h.bcReader.PendingState()
+	if err != nil {
+		id = BlockID{Latest: true}
+		state, closer, err = h.bcReader.HeadState()
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil, ErrNoBlock
+		}
+		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+	}
+	defer h.callAndLogErr(closer, "Failed to close state in transaction validation")
+
+	var broadcastedTxn BroadcastedTransaction
+	if err = json.Unmarshal(txnJSON, &broadcastedTxn); err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InvalidParams, err.Error())
+	}
+	txn, _, _, err := adaptBroadcastedTransaction(&broadcastedTxn, h.network)
+	if err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InvalidParams, err.Error())
+	}
+
+	sender, nonce := senderAndNonce(txn)
+	if sender != nil {
+		accountNonce, nonceErr := state.ContractNonce(sender)
+		if nonceErr != nil {
+			if !errors.Is(nonceErr, db.ErrKeyNotFound) {
+				return nil, jsonrpc.Err(jsonrpc.InternalError, nonceErr.Error())
+			}
+			// the account of a deploy account transaction does not exist yet
+			accountNonce = &felt.Zero
+		}
+		if nonce.Cmp(accountNonce) < 0 {
+			return nil, ErrInvalidTransactionNonce
+		}
+	}
+
+	// version 0 transactions predate fees, so a zero max fee is only accepted from them
+	maxFee := broadcastedTxn.MaxFee
+	if maxFee == nil || (maxFee.IsZero() && broadcastedTxn.Version != nil && !broadcastedTxn.Version.IsZero()) {
+		return nil, ErrInsufficientMaxFee
+	}
+
+	// signatures are checked by the validation entry point of the account, which is run as part of the simulation
+	simulated, rpcErr := h.simulateTransactions(ctx, id, []BroadcastedTransaction{broadcastedTxn},
+		addTransactionMethod(broadcastedTxn.Type))
+	if rpcErr != nil {
+		if rpcErr.Code != ErrContractError.Code {
+			return nil, rpcErr
+		}
+		validationErr := *ErrValidationFailure
+		validationErr.Data = rpcErr.Data
+		return nil, &validationErr
+	}
+	if !maxFee.IsZero() && simulated[0].FeeEstimate.OverallFee.Cmp(maxFee) > 0 {
+		return nil, ErrInsufficientMaxFee
+	}
+	return txn, nil
+}
+
+// senderAndNonce returns the account that sends txn and the nonce it uses,
+// or nils for transactions that do not have a nonce
+func senderAndNonce(txn core.Transaction) (*felt.Felt, *felt.Felt) {
+	switch t := txn.(type) {
+	case *core.InvokeTransaction:
+		if t.Nonce != nil {
+			return t.SenderAddress, t.Nonce
+		}
+	case *core.DeclareTransaction:
+		if t.Nonce != nil {
+			return t.SenderAddress, t.Nonce
+		}
+	case *core.DeployAccountTransaction:
+		return t.ContractAddress, t.Nonce
+	}
+	return nil, nil
+}
+
 func makeJSONErrorFromGatewayError(err error) *jsonrpc.Error {
 	gatewayErr, ok := err.(*gateway.Error)
 	if !ok {
@@ -982,8 +1201,10 @@ func (h *Handler) Version() (string, *jsonrpc.Error) {
 }
 
 // https://github.com/starkware-libs/starknet-specs/blob/e0b76ed0d8d8eba405e182371f9edac8b2bcbc5a/api/starknet_api_openrpc.json#L401-L445
-func (h *Handler) Call(call FunctionCall, id BlockID) ([]*felt.Felt, *jsonrpc.Error) { //nolint:gocritic
+func (h *Handler) Call(ctx context.Context, call FunctionCall, id BlockID) ([]*felt.Felt, *jsonrpc.Error) { //nolint:gocritic
+	_, stateSpan := tracing.StartSpan(ctx, "state.open")
 	state, closer, err := h.stateByBlockID(&id)
+	tracing.EndSpan(stateSpan, err)
 	if err != nil {
 		return nil, ErrBlockNotFound
 	}
@@ -1008,11 +1229,12 @@ func (h *Handler) Call(call FunctionCall, id BlockID) ([]*felt.Felt, *jsonrpc.Er
 		blockNumber = height + 1
 	}
 
-	res, err := h.vm.Call(&call.ContractAddress, &call.EntryPointSelector, call.Calldata, blockNumber, header.Timestamp, state, h.network)
+	_, vmSpan := tracing.StartSpan(ctx, "vm.Call", tracing.BlockNumber(blockNumber))
+	res, err := h.vm.Call(&call.ContractAddress, &call.EntryPointSelector, call.Calldata, blockNumber, header.Timestamp, state, h.network,
+		h.limits("starknet_call"))
+	tracing.EndSpan(vmSpan, err)
 	if err != nil {
-		contractErr := *ErrContractError
-		contractErr.Data = err.Error()
-		return nil, &contractErr
+		return nil, vmError(err)
 	}
 	return res, nil
 }
@@ -1029,6 +1251,9 @@ func (h *Handler) TransactionStatus(hash felt.Felt) (*TransactionStatus, *jsonrp
 		}
 	case ErrTxnHashNotFound:
 		txStatus, err := h.feederClient.Transaction(context.Background(), &hash)
+		if h.isSubmittedAndNotAccepted(&hash, txStatus, err) {
+			return &TransactionStatus{Finality: TxnReceived}, nil
+		}
 		if err != nil {
 			return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
 		}
@@ -1065,8 +1290,29 @@ func (h *Handler) TransactionStatus(hash felt.Felt) (*TransactionStatus, *jsonrp
 	return status, nil
 }
 
-func (h *Handler) EstimateFee(broadcastedTxns []BroadcastedTransaction, id BlockID) ([]FeeEstimate, *jsonrpc.Error) {
-	result, err := h.SimulateTransactions(id, broadcastedTxns, nil)
+// isSubmittedAndNotAccepted reports whether a transaction that is not in the local chain was submitted
+// through this node and has not been accepted by the sequencer yet, as far as the feeder knows
+func (h *Handler) isSubmittedAndNotAccepted(hash *felt.Felt, txStatus *feeder.TransactionStatus, feederErr error) bool {
+	if h.submittedTxns == nil {
+		return false
+	}
+	if feederErr == nil && txStatus.Status != "RECEIVED" && txStatus.Status != "NOT_RECEIVED" {
+		return false
+	}
+	_, err := h.submittedTxns.SubmittedTransaction(hash)
+	return err == nil
+}
+
+func (h *Handler) EstimateFee(ctx context.Context, broadcastedTxns []BroadcastedTransaction,
+	id BlockID,
+) ([]FeeEstimate, *jsonrpc.Error) {
+	return h.estimateFee(ctx, broadcastedTxns, id, "starknet_estimateFee")
+}
+
+func (h *Handler) estimateFee(ctx context.Context, broadcastedTxns []BroadcastedTransaction, id BlockID,
+	method string,
+) ([]FeeEstimate, *jsonrpc.Error) {
+	result, err := h.simulateTransactions(ctx, id, broadcastedTxns, method)
 	if err != nil {
 		return nil, err
 	}
@@ -1076,7 +1322,7 @@ func (h *Handler) EstimateFee(broadcastedTxns []BroadcastedTransaction, id Block
 	}), nil
 }
 
-func (h *Handler) EstimateMessageFee(msg MsgFromL1, id BlockID) (*FeeEstimate, *jsonrpc.Error) { //nolint:gocritic
+func (h *Handler) EstimateMessageFee(ctx context.Context, msg MsgFromL1, id BlockID) (*FeeEstimate, *jsonrpc.Error) { //nolint:gocritic
 	calldata := make([]*felt.Felt, 0, len(msg.Payload)+1)
 	// The order of the calldata parameters matters. msg.From must be prepended.
 	calldata = append(calldata, new(felt.Felt).SetBytes(msg.From.Bytes()))
@@ -1096,7 +1342,7 @@ func (h *Handler) EstimateMessageFee(msg MsgFromL1, id BlockID) (*FeeEstimate, *
 		// Must be greater than zero to successfully execute transaction.
 		PaidFeeOnL1: new(felt.Felt).SetUint64(1),
 	}
-	estimates, rpcErr := h.EstimateFee([]BroadcastedTransaction{tx}, id)
+	estimates, rpcErr := h.estimateFee(ctx, []BroadcastedTransaction{tx}, id, "starknet_estimateMessageFee")
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
@@ -1107,7 +1353,7 @@ func (h *Handler) EstimateMessageFee(msg MsgFromL1, id BlockID) (*FeeEstimate, *
 //
 // It follows the specification defined here:
 // https://github.com/starkware-libs/starknet-specs/blob/1ae810e0137cc5d175ace4554892a4f43052be56/api/starknet_trace_api_openrpc.json#L11
-func (h *Handler) TraceTransaction(hash felt.Felt) (json.RawMessage, *jsonrpc.Error) {
+func (h *Handler) TraceTransaction(ctx context.Context, hash felt.Felt) (json.RawMessage, *jsonrpc.Error) {
 	_, blockHash, blockNumber, err := h.bcReader.Receipt(&hash)
 	if err != nil {
 		return nil, ErrTxnHashNotFound
@@ -1119,7 +1365,9 @@ func (h *Handler) TraceTransaction(hash felt.Felt) (json.RawMessage, *jsonrpc.Er
 	}
 	isPending := blockHash == nil
 
+	_, stateSpan := tracing.StartSpan(ctx, "state.open")
 	state, closer, err := h.bcReader.StateAtBlockHash(block.ParentHash)
+	tracing.EndSpan(stateSpan, err)
 	if err != nil {
 		return nil, ErrBlockNotFound
 	}
@@ -1177,26 +1425,49 @@ func (h *Handler) TraceTransaction(hash felt.Felt) (json.RawMessage, *jsonrpc.Er
 		sequencerAddress = core.NetworkBlockHashMetaInfo(h.network).FallBackSequencerAddress
 	}
 
+	_, vmSpan := tracing.StartSpan(ctx, "vm.Execute", tracing.BlockNumber(blockNumber))
 	_, traces, err := h.vm.Execute(block.Transactions[:txIndex+1], classes, blockNumber, header.Timestamp,
-		sequencerAddress, state, h.network, paidFeesOnL1)
+		sequencerAddress, state, h.network, paidFeesOnL1, h.limits("starknet_traceTransaction"))
+	tracing.EndSpan(vmSpan, err)
 	if err != nil {
-		rpcErr := *ErrContractError
-		rpcErr.Data = err.Error()
-		return nil, &rpcErr
+		return nil, vmError(err)
 	}
 	trace := traces[txIndex]
+	if rpcErr := h.checkResponseSize("starknet_traceTransaction", uint64(len(trace))); rpcErr != nil {
+		return nil, rpcErr
+	}
 
 	return trace, nil
 }
 
-func (h *Handler) SimulateTransactions(id BlockID, transactions []BroadcastedTransaction,
+func (h *Handler) SimulateTransactions(ctx context.Context, id BlockID, transactions []BroadcastedTransaction,
 	simulationFlags []SimulationFlag,
 ) ([]SimulatedTransaction, *jsonrpc.Error) {
 	if len(simulationFlags) > 0 {
 		return nil, jsonrpc.Err(jsonrpc.InvalidParams, "Simulation flags are not supported")
 	}
+	simulated, rpcErr := h.simulateTransactions(ctx, id, transactions, "starknet_simulateTransactions")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	var size uint64
+	for i := range simulated {
+		// the fee estimate takes three felts
+		size += uint64(len(simulated[i].TransactionTrace)) + 3*feltSize
+	}
+	if rpcErr = h.checkResponseSize("starknet_simulateTransactions", size); rpcErr != nil {
+		return nil, rpcErr
+	}
+	return simulated, nil
+}
 
+// simulateTransactions executes transactions with the execution limits of method
+func (h *Handler) simulateTransactions(ctx context.Context, id BlockID, transactions []BroadcastedTransaction,
+	method string,
+) ([]SimulatedTransaction, *jsonrpc.Error) {
+	_, stateSpan := tracing.StartSpan(ctx, "state.open")
 	state, closer, err := h.stateByBlockID(&id)
+	tracing.EndSpan(stateSpan, err)
 	if err != nil {
 		return nil, ErrBlockNotFound
 	}
@@ -1240,11 +1511,13 @@ func (h *Handler) SimulateTransactions(id BlockID, transactions []BroadcastedTra
 	if sequencerAddress == nil {
 		sequencerAddress = core.NetworkBlockHashMetaInfo(h.network).FallBackSequencerAddress
 	}
-	gasesConsumed, traces, err := h.vm.Execute(txns, classes, blockNumber, header.Timestamp, sequencerAddress, state, h.network, paidFeesOnL1)
+	_, vmSpan := tracing.StartSpan(ctx, "vm.Execute", tracing.BlockNumber(blockNumber),
+		attribute.Int("transactions", len(txns)))
+	gasesConsumed, traces, err := h.vm.Execute(txns, classes, blockNumber, header.Timestamp, sequencerAddress, state, h.network,
+		paidFeesOnL1, h.limits(method))
+	tracing.EndSpan(vmSpan, err)
 	if err != nil {
-		rpcErr := *ErrContractError
-		rpcErr.Data = err.Error()
-		return nil, &rpcErr
+		return nil, vmError(err)
 	}
 
 	var result []SimulatedTransaction
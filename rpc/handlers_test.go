@@ -15,11 +15,13 @@ import (
 	"github.com/NethermindEth/juno/db"
 	"github.com/NethermindEth/juno/db/pebble"
 	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/mempool"
 	"github.com/NethermindEth/juno/mocks"
 	"github.com/NethermindEth/juno/rpc"
 	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
 	"github.com/NethermindEth/juno/sync"
 	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/vm"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -447,6 +449,94 @@ func TestBlockWithTxs(t *testing.T) {
 	})
 }
 
+func TestBlockWithReceipts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockReader := mocks.NewMockReader(mockCtrl)
+	handler := rpc.New(mockReader, nil, utils.MAINNET, nil, nil, nil, "", nil)
+
+	client := feeder.NewTestClient(t, utils.MAINNET)
+	gw := adaptfeeder.New(client)
+
+	block0, err := gw.BlockByNumber(context.Background(), 0)
+	require.NoError(t, err)
+
+	t.Run("empty blockchain", func(t *testing.T) {
+		mockReader.EXPECT().Head().Return(nil, errors.New("empty blockchain"))
+
+		block, rpcErr := handler.BlockWithReceipts(rpc.BlockID{Latest: true})
+		assert.Nil(t, block)
+		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
+	})
+
+	t.Run("non-existent block hash", func(t *testing.T) {
+		mockReader.EXPECT().BlockByHash(gomock.Any()).Return(nil, errors.New("block not found"))
+
+		block, rpcErr := handler.BlockWithReceipts(rpc.BlockID{Hash: new(felt.Felt).SetBytes([]byte("random"))})
+		assert.Nil(t, block)
+		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
+	})
+
+	checkBlock := func(t *testing.T, block *rpc.BlockWithReceipts, finality rpc.TxnFinalityStatus) {
+		t.Helper()
+		require.Len(t, block.Transactions, len(block0.Transactions))
+		for i, txWithReceipt := range block.Transactions {
+			assert.Equal(t, block0.Transactions[i].Hash(), txWithReceipt.Transaction.Hash)
+			assert.Equal(t, block0.Transactions[i].Hash(), txWithReceipt.Receipt.Hash)
+			assert.Equal(t, txWithReceipt.Transaction.Type, txWithReceipt.Receipt.Type)
+			assert.Equal(t, finality, txWithReceipt.Receipt.FinalityStatus)
+			assert.Equal(t, rpc.TxnSuccess, txWithReceipt.Receipt.ExecutionStatus)
+			assert.Nil(t, txWithReceipt.Receipt.BlockHash)
+			assert.Nil(t, txWithReceipt.Receipt.BlockNumber)
+			assert.Len(t, txWithReceipt.Receipt.Events, len(block0.Receipts[i].Events))
+		}
+	}
+
+	t.Run("blockID - number", func(t *testing.T) {
+		mockReader.EXPECT().BlockByNumber(uint64(0)).Return(block0, nil)
+		mockReader.EXPECT().L1Head().Return(nil, db.ErrKeyNotFound)
+
+		block, rpcErr := handler.BlockWithReceipts(rpc.BlockID{Number: 0})
+		require.Nil(t, rpcErr)
+		assert.Equal(t, rpc.BlockAcceptedL2, block.Status)
+		assert.Equal(t, block0.Hash, block.Hash)
+		checkBlock(t, block, rpc.TxnAcceptedOnL2)
+	})
+
+	t.Run("blockID - hash accepted on l1", func(t *testing.T) {
+		mockReader.EXPECT().BlockByHash(block0.Hash).Return(block0, nil)
+		mockReader.EXPECT().L1Head().Return(&core.L1Head{
+			BlockNumber: 0,
+			BlockHash:   block0.Hash,
+			StateRoot:   block0.GlobalStateRoot,
+		}, nil)
+
+		block, rpcErr := handler.BlockWithReceipts(rpc.BlockID{Hash: block0.Hash})
+		require.Nil(t, rpcErr)
+		assert.Equal(t, rpc.BlockAcceptedL1, block.Status)
+		checkBlock(t, block, rpc.TxnAcceptedOnL1)
+	})
+
+	t.Run("blockID - pending", func(t *testing.T) {
+		pendingBlock := *block0
+		pendingHeader := *block0.Header
+		pendingHeader.Hash = nil
+		pendingHeader.GlobalStateRoot = nil
+		pendingBlock.Header = &pendingHeader
+		mockReader.EXPECT().Pending().Return(blockchain.Pending{
+			Block: &pendingBlock,
+		}, nil)
+		mockReader.EXPECT().L1Head().Return(nil, db.ErrKeyNotFound)
+
+		block, rpcErr := handler.BlockWithReceipts(rpc.BlockID{Pending: true})
+		require.Nil(t, rpcErr)
+		assert.Equal(t, rpc.BlockPending, block.Status)
+		assert.Nil(t, block.Hash)
+		checkBlock(t, block, rpc.TxnAcceptedOnL2)
+	})
+}
+
 func TestTransactionByHash(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	t.Cleanup(mockCtrl.Finish)
@@ -1519,6 +1609,18 @@ func TestClass(t *testing.T) {
 		cairo0Class := coreClass.(*core.Cairo0Class)
 		assertEqualCairo0Class(t, cairo0Class, class)
 	})
+
+	t.Run("response too large", func(t *testing.T) {
+		hash := utils.HexToFelt(t, "0x1cd2edfb485241c4403254d550de0a097fa76743cd30696f714a491a454bad5")
+		sizedHandler := rpc.New(mockReader, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger()).
+			WithMaxResponseSizes(1<<20, map[string]uint64{"starknet_getClass": 1024})
+
+		class, rpcErr := sizedHandler.Class(latest, *hash)
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, rpc.ErrResponseTooLarge.Code, rpcErr.Code)
+		assert.Equal(t, rpc.ErrResponseTooLarge.Message, rpcErr.Message)
+		assert.Nil(t, class)
+	})
 }
 
 func TestClassAt(t *testing.T) {
@@ -1679,6 +1781,27 @@ func TestEvents(t *testing.T) {
 			}
 			require.Equal(t, allEvents, accEvents)
 		})
+
+		t.Run("accumulate events truncated to the maximum response size", func(t *testing.T) {
+			// every response is truncated to its first event
+			sizedHandler := rpc.New(chain, nil, utils.GOERLI2, nil, nil, nil, "", utils.NewNopZapLogger()).
+				WithMaxResponseSizes(0, map[string]uint64{"starknet_getEvents": 1})
+			var accEvents []*rpc.EmittedEvent
+			args.ChunkSize = 100
+			args.ContinuationToken = ""
+
+			for i := 0; i < len(allEvents)+1; i++ {
+				events, err := sizedHandler.Events(args)
+				require.Nil(t, err)
+				require.Len(t, events.Events, 1)
+				accEvents = append(accEvents, events.Events...)
+				args.ContinuationToken = events.ContinuationToken
+				if args.ContinuationToken == "" {
+					break
+				}
+			}
+			require.Equal(t, allEvents, accEvents)
+		})
 	})
 
 	t.Run("filter with keys", func(t *testing.T) {
@@ -1745,16 +1868,41 @@ func TestAddTransaction(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	t.Cleanup(mockCtrl.Finish)
 
+	const network = utils.MAINNET
+
+	mockReader := mocks.NewMockReader(mockCtrl)
 	mockGateway := mocks.NewMockGateway(mockCtrl)
+	mockState := mocks.NewMockStateHistoryReader(mockCtrl)
+	mockVM := mocks.NewMockVM(mockCtrl)
 	log := utils.NewNopZapLogger()
-	handler := rpc.New(nil, nil, utils.MAINNET, mockGateway, nil, nil, "", log)
+	handler := rpc.New(mockReader, nil, network, mockGateway, feeder.NewTestClient(t, network), mockVM, "", log)
+
+	mockReader.EXPECT().PendingState().Return(nil, nil, errors.New("no pending")).AnyTimes()
+	mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil).AnyTimes()
+	mockReader.EXPECT().HeadsHeader().Return(&core.Header{Number: 1, GasPrice: new(felt.Felt).SetUint64(1)}, nil).AnyTimes()
+	mockState.EXPECT().ContractNonce(gomock.Any()).Return(&felt.Zero, nil).AnyTimes()
+	mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), uint64(1), uint64(0), gomock.Any(), mockState, network, gomock.Any(), vm.Limits{}).
+		Return([]*felt.Felt{new(felt.Felt).SetUint64(0x10)}, []json.RawMessage{{}}, nil).AnyTimes()
+
+	invokeTxn := `{"type":"INVOKE","version":"0x1","sender_address":"0xdead","calldata":[],"signature":[],"nonce":"0x0",` +
+		`"max_fee":"0x100"}`
 
 	t.Run("invalid json", func(t *testing.T) {
-		_, err := handler.AddTransaction(json.RawMessage(`{]`))
+		_, err := handler.AddTransaction(context.Background(), json.RawMessage(`{]`))
 		require.NotNil(t, err)
 		assert.Equal(t, jsonrpc.InvalidJSON, err.Code)
 	})
 
+	t.Run("no state to validate against", func(t *testing.T) {
+		unsyncedReader := mocks.NewMockReader(mockCtrl)
+		unsyncedReader.EXPECT().PendingState().Return(nil, nil, errors.New("no pending"))
+		unsyncedReader.EXPECT().HeadState().Return(nil, nil, db.ErrKeyNotFound)
+		unsynced := rpc.New(unsyncedReader, nil, network, mockGateway, nil, mockVM, "", log)
+
+		_, err := unsynced.AddTransaction(context.Background(), json.RawMessage(invokeTxn))
+		assert.Equal(t, rpc.ErrNoBlock, err)
+	})
+
 	t.Run("ok response", func(t *testing.T) {
 		mockGateway.EXPECT().AddTransaction(gomock.Any()).Return(json.RawMessage(`
 		{
@@ -1764,7 +1912,7 @@ func TestAddTransaction(t *testing.T) {
 		}
 		`), nil)
 
-		response, err := handler.AddTransaction(json.RawMessage(`{}`))
+		response, err := handler.AddTransaction(context.Background(), json.RawMessage(invokeTxn))
 		require.Nil(t, err)
 		assert.Equal(t, "0x1", response.TransactionHash.String())
 		assert.Equal(t, "0x2", response.ContractAddress.String())
@@ -1772,23 +1920,160 @@ func TestAddTransaction(t *testing.T) {
 	})
 
 	t.Run("compresses sierra program", func(t *testing.T) {
-		declareTxV2 := `{"contract_class":{"sierra_program":["0x0","0x0"]},"type":"DECLARE","version":"0x2"}`
-		gwDeclareTxV2 := `{"contract_class":{"sierra_program":"H4sIAAAAAAAA/4pWMqgwUNIBk7GAAAAA//9n6XuWDQAAAA=="},"type":"DECLARE","version":"0x2"}`
-
-		mockGateway.EXPECT().AddTransaction(json.RawMessage(gwDeclareTxV2)).Return(json.RawMessage(`{}`), nil)
+		declareTxV2 := `{"contract_class":{"sierra_program":["0x0","0x0"],"contract_class_version":"0.1.0",` +
+			`"entry_points_by_type":{"CONSTRUCTOR":[],"EXTERNAL":[],"L1_HANDLER":[]},"abi":""},` +
+			`"type":"DECLARE","version":"0x2","sender_address":"0xdead","compiled_class_hash":"0x1",` +
+			`"signature":[],"nonce":"0x0","max_fee":"0x100"}`
+
+		mockGateway.EXPECT().AddTransaction(gomock.Any()).DoAndReturn(func(txnJSON json.RawMessage) (json.RawMessage, error) {
+			var request struct {
+				ContractClass struct {
+					SierraProgram string `json:"sierra_program"`
+				} `json:"contract_class"`
+			}
+			require.NoError(t, json.Unmarshal(txnJSON, &request))
+			assert.Equal(t, "H4sIAAAAAAAA/4pWMqgwUNIBk7GAAAAA//9n6XuWDQAAAA==", request.ContractClass.SierraProgram)
+			return json.RawMessage(`{}`), nil
+		})
 
-		_, err := handler.AddTransaction(json.RawMessage(declareTxV2))
+		_, err := handler.AddTransaction(context.Background(), json.RawMessage(declareTxV2))
 		require.Nil(t, err)
 	})
 
 	t.Run("changes invoke type", func(t *testing.T) {
-		invokeTxn := `{"type":"INVOKE"}`
-		gwInvokeTxn := `{"type":"INVOKE_FUNCTION"}`
+		mockGateway.EXPECT().AddTransaction(gomock.Any()).DoAndReturn(func(txnJSON json.RawMessage) (json.RawMessage, error) {
+			var request map[string]any
+			require.NoError(t, json.Unmarshal(txnJSON, &request))
+			assert.Equal(t, "INVOKE_FUNCTION", request["type"])
+			return json.RawMessage(`{}`), nil
+		})
+
+		_, err := handler.AddTransaction(context.Background(), json.RawMessage(invokeTxn))
+		require.Nil(t, err)
+	})
+}
+
+func TestAddTransactionValidation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	const network = utils.MAINNET
 
-		mockGateway.EXPECT().AddTransaction(json.RawMessage(gwInvokeTxn)).Return(json.RawMessage(`{}`), nil)
+	mockReader := mocks.NewMockReader(mockCtrl)
+	mockGateway := mocks.NewMockGateway(mockCtrl)
+	mockState := mocks.NewMockStateHistoryReader(mockCtrl)
+	mockVM := mocks.NewMockVM(mockCtrl)
+	chain := blockchain.New(pebble.NewMemTest(), network, utils.NewNopZapLogger())
+	handler := rpc.New(mockReader, nil, network, mockGateway, feeder.NewTestClient(t, network), mockVM, "",
+		utils.NewNopZapLogger()).WithSubmittedTransactions(chain)
 
-		_, err := handler.AddTransaction(json.RawMessage(invokeTxn))
+	sender := utils.HexToFelt(t, "0xdead")
+	invokeTxn := func(nonce, maxFee string) json.RawMessage {
+		return json.RawMessage(`{
+			"type": "INVOKE",
+			"version": "0x1",
+			"sender_address": "` + sender.String() + `",
+			"calldata": ["0x1", "0x2"],
+			"signature": ["0x3", "0x4"],
+			"nonce": "` + nonce + `",
+			"max_fee": "` + maxFee + `"
+		}`)
+	}
+
+	mockReader.EXPECT().PendingState().Return(nil, nil, errors.New("no pending")).AnyTimes()
+	mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil).AnyTimes()
+	mockReader.EXPECT().HeadsHeader().Return(&core.Header{Number: 1, GasPrice: new(felt.Felt).SetUint64(1)}, nil).AnyTimes()
+	mockState.EXPECT().ContractNonce(sender).Return(new(felt.Felt).SetUint64(2), nil).AnyTimes()
+
+	t.Run("nonce too low", func(t *testing.T) {
+		_, err := handler.AddTransaction(context.Background(), invokeTxn("0x1", "0x100"))
+		assert.Equal(t, rpc.ErrInvalidTransactionNonce, err)
+	})
+
+	t.Run("zero max fee", func(t *testing.T) {
+		_, err := handler.AddTransaction(context.Background(), invokeTxn("0x2", "0x0"))
+		assert.Equal(t, rpc.ErrInsufficientMaxFee, err)
+	})
+
+	t.Run("validation failure", func(t *testing.T) {
+		mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), uint64(1), uint64(0), gomock.Any(), mockState, network, gomock.Any(), vm.Limits{}).
+			Return(nil, nil, errors.New("invalid signature"))
+
+		_, err := handler.AddTransaction(context.Background(), invokeTxn("0x2", "0x100"))
+		require.NotNil(t, err)
+		assert.Equal(t, rpc.ErrValidationFailure.Code, err.Code)
+		assert.Equal(t, "invalid signature", err.Data)
+	})
+
+	t.Run("max fee lower than the estimate", func(t *testing.T) {
+		mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), uint64(1), uint64(0), gomock.Any(), mockState, network, gomock.Any(), vm.Limits{}).
+			Return([]*felt.Felt{new(felt.Felt).SetUint64(0x200)}, []json.RawMessage{{}}, nil)
+
+		_, err := handler.AddTransaction(context.Background(), invokeTxn("0x2", "0x100"))
+		assert.Equal(t, rpc.ErrInsufficientMaxFee, err)
+	})
+
+	t.Run("valid transaction is relayed and tracked", func(t *testing.T) {
+		mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), uint64(1), uint64(0), gomock.Any(), mockState, network, gomock.Any(), vm.Limits{}).
+			Return([]*felt.Felt{new(felt.Felt).SetUint64(0x10)}, []json.RawMessage{{}}, nil)
+
+		mockGateway.EXPECT().AddTransaction(gomock.Any()).Return(json.RawMessage(`{"transaction_hash": "0x1"}`), nil)
+
+		_, err := handler.AddTransaction(context.Background(), invokeTxn("0x2", "0x100"))
+		require.Nil(t, err)
+
+		txnHash, hErr := core.TransactionHash(&core.InvokeTransaction{
+			SenderAddress:        sender,
+			CallData:             []*felt.Felt{new(felt.Felt).SetUint64(1), new(felt.Felt).SetUint64(2)},
+			TransactionSignature: []*felt.Felt{new(felt.Felt).SetUint64(3), new(felt.Felt).SetUint64(4)},
+			Nonce:                new(felt.Felt).SetUint64(2),
+			MaxFee:               new(felt.Felt).SetUint64(0x100),
+			Version:              new(felt.Felt).SetUint64(1),
+		}, network)
+		require.NoError(t, hErr)
+
+		submitted, sErr := chain.SubmittedTransaction(txnHash)
+		require.NoError(t, sErr)
+		assert.Equal(t, txnHash, submitted.Transaction.Hash())
+		assert.NotZero(t, submitted.SubmittedAt)
+
+		mockReader.EXPECT().TransactionByHash(txnHash).Return(nil, db.ErrKeyNotFound)
+		status, err := handler.TransactionStatus(*txnHash)
+		require.Nil(t, err)
+		assert.Equal(t, &rpc.TransactionStatus{Finality: rpc.TxnReceived}, status)
+	})
+
+	t.Run("transaction is pooled while the gateway cannot be reached", func(t *testing.T) {
+		handler := rpc.New(mockReader, nil, network, mockGateway, feeder.NewTestClient(t, network), mockVM, "",
+			utils.NewNopZapLogger()).WithPool(mempool.New(mockGateway, utils.NewNopZapLogger()))
+		mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), uint64(1), uint64(0), gomock.Any(), mockState, network, gomock.Any(), vm.Limits{}).
+			Return([]*felt.Felt{new(felt.Felt).SetUint64(0x10)}, []json.RawMessage{{}}, nil).Times(2)
+		mockGateway.EXPECT().AddTransaction(gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		txnHash, hErr := core.TransactionHash(&core.InvokeTransaction{
+			SenderAddress:        sender,
+			CallData:             []*felt.Felt{new(felt.Felt).SetUint64(1), new(felt.Felt).SetUint64(2)},
+			TransactionSignature: []*felt.Felt{new(felt.Felt).SetUint64(3), new(felt.Felt).SetUint64(4)},
+			Nonce:                new(felt.Felt).SetUint64(2),
+			MaxFee:               new(felt.Felt).SetUint64(0x101),
+			Version:              new(felt.Felt).SetUint64(1),
+		}, network)
+		require.NoError(t, hErr)
+
+		// the resubmission is not relayed to the gateway again
+		for i := 0; i < 2; i++ {
+			response, err := handler.AddTransaction(context.Background(), invokeTxn("0x2", "0x101"))
+			require.Nil(t, err)
+			assert.Equal(t, txnHash, response.TransactionHash)
+		}
+
+		pooled, err := handler.PoolTransactions()
 		require.Nil(t, err)
+		require.Len(t, pooled, 1)
+		assert.Equal(t, txnHash, pooled[0].Transaction.Hash)
+		assert.Equal(t, "PENDING", pooled[0].Status)
+		assert.Equal(t, uint(1), pooled[0].Attempts)
+		assert.Equal(t, "connection refused", pooled[0].LastError)
 	})
 }
 
@@ -1944,7 +2229,7 @@ func TestCall(t *testing.T) {
 	t.Run("empty blockchain", func(t *testing.T) {
 		mockReader.EXPECT().HeadState().Return(nil, nil, errors.New("empty blockchain"))
 
-		res, rpcErr := handler.Call(rpc.FunctionCall{}, rpc.BlockID{Latest: true})
+		res, rpcErr := handler.Call(context.Background(), rpc.FunctionCall{}, rpc.BlockID{Latest: true})
 		require.Nil(t, res)
 		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
 	})
@@ -1952,7 +2237,7 @@ func TestCall(t *testing.T) {
 	t.Run("non-existent block hash", func(t *testing.T) {
 		mockReader.EXPECT().StateAtBlockHash(&felt.Zero).Return(nil, nil, errors.New("non-existent block hash"))
 
-		res, rpcErr := handler.Call(rpc.FunctionCall{}, rpc.BlockID{Hash: &felt.Zero})
+		res, rpcErr := handler.Call(context.Background(), rpc.FunctionCall{}, rpc.BlockID{Hash: &felt.Zero})
 		require.Nil(t, res)
 		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
 	})
@@ -1960,7 +2245,7 @@ func TestCall(t *testing.T) {
 	t.Run("non-existent block number", func(t *testing.T) {
 		mockReader.EXPECT().StateAtBlockNumber(uint64(0)).Return(nil, nil, errors.New("non-existent block number"))
 
-		res, rpcErr := handler.Call(rpc.FunctionCall{}, rpc.BlockID{Number: 0})
+		res, rpcErr := handler.Call(context.Background(), rpc.FunctionCall{}, rpc.BlockID{Number: 0})
 		require.Nil(t, res)
 		assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
 	})
@@ -1972,7 +2257,7 @@ func TestCall(t *testing.T) {
 		mockReader.EXPECT().HeadsHeader().Return(new(core.Header), nil)
 		mockState.EXPECT().ContractClassHash(&felt.Zero).Return(nil, errors.New("unknown contract"))
 
-		res, rpcErr := handler.Call(rpc.FunctionCall{}, rpc.BlockID{Latest: true})
+		res, rpcErr := handler.Call(context.Background(), rpc.FunctionCall{}, rpc.BlockID{Latest: true})
 		require.Nil(t, res)
 		assert.Equal(t, rpc.ErrContractNotFound, rpcErr)
 	})
@@ -1996,7 +2281,7 @@ func TestEstimateMessageFee(t *testing.T) {
 
 	t.Run("block not found", func(t *testing.T) {
 		mockReader.EXPECT().HeadState().Return(nil, nil, errors.New("not found"))
-		_, err := handler.EstimateMessageFee(msg, rpc.BlockID{Latest: true})
+		_, err := handler.EstimateMessageFee(context.Background(), msg, rpc.BlockID{Latest: true})
 		require.Equal(t, rpc.ErrBlockNotFound, err)
 	})
 
@@ -2011,9 +2296,11 @@ func TestEstimateMessageFee(t *testing.T) {
 	mockReader.EXPECT().HeadsHeader().Return(latestHeader, nil)
 
 	expectedGasConsumed := new(felt.Felt).SetUint64(37)
-	mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+	mockVM.EXPECT().Execute(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).DoAndReturn(
 		func(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
 			sequencerAddress *felt.Felt, state core.StateReader, network utils.Network, paidFeesOnL1 []*felt.Felt,
+			_ vm.Limits,
 		) ([]*felt.Felt, []json.RawMessage, error) {
 			require.Len(t, txns, 1)
 			assert.NotNil(t, txns[0].(*core.L1HandlerTransaction))
@@ -2029,7 +2316,7 @@ func TestEstimateMessageFee(t *testing.T) {
 		},
 	)
 
-	gasConsumed, err := handler.EstimateMessageFee(msg, rpc.BlockID{Latest: true})
+	gasConsumed, err := handler.EstimateMessageFee(context.Background(), msg, rpc.BlockID{Latest: true})
 	require.Nil(t, err)
 	require.Equal(t, rpc.FeeEstimate{
 		GasConsumed: expectedGasConsumed,
@@ -2082,9 +2369,9 @@ func TestTraceTransaction(t *testing.T) {
 		"execute_invocation":{"entry_point_selector":"0x28ffe4ff0f226a9107253e17a904099aa4f63a02a5621de0576e5aa71bc5194","calldata":["0x33434ad846cdd5f23eb73ff09fe6fddd568284a0fb7d1be20ee482f044dabe2","0x79dc0da7c54b95f10aa182ad0a46400db63156920adb65eca2654c0945a463","0x2","0x322258135d04971e96b747a5551061aa046ad5d8be11a35c67029d96b23f98","0x0"],"caller_address":"0x0","class_hash":"0x25ec026985a3bf9d0cc1fe17326b245dfdc3ff89b8fde106542a3ea56c5a918","entry_point_type":"CONSTRUCTOR","call_type":"CALL","result":[],"calls":[{"entry_point_selector":"0x79dc0da7c54b95f10aa182ad0a46400db63156920adb65eca2654c0945a463","calldata":["0x322258135d04971e96b747a5551061aa046ad5d8be11a35c67029d96b23f98","0x0"],"caller_address":"0x0","class_hash":"0x33434ad846cdd5f23eb73ff09fe6fddd568284a0fb7d1be20ee482f044dabe2","entry_point_type":"EXTERNAL","call_type":"LIBRARY_CALL","result":[],"calls":[],"events":[{"keys":["0x10c19bef19acd19b2c9f4caa40fd47c9fbe1d9f91324d44dcd36be2dae96784"],"data":["0xdac9bcffb3d967f19a7fe21002c98c984d5a9458a88e6fc5d1c478a97ed412","0x322258135d04971e96b747a5551061aa046ad5d8be11a35c67029d96b23f98","0x0"]}],"messages":[]}],"events":[],"messages":[]},
 		"fee_transfer_invocation":{"entry_point_selector":"0x83afd3f4caedc6eebf44246fe54e38c95e3179a5ec9ea81740eca5b482d12e","calldata":["0x5dcd266a80b8a5f29f04d779c6b166b80150c24f2180a75e82427242dab20a9","0x15be","0x0"],"caller_address":"0xdac9bcffb3d967f19a7fe21002c98c984d5a9458a88e6fc5d1c478a97ed412","class_hash":"0xd0e183745e9dae3e4e78a8ffedcce0903fc4900beace4e0abf192d4c202da3","entry_point_type":"EXTERNAL","call_type":"CALL","result":["0x1"],"calls":[{"entry_point_selector":"0x83afd3f4caedc6eebf44246fe54e38c95e3179a5ec9ea81740eca5b482d12e","calldata":["0x5dcd266a80b8a5f29f04d779c6b166b80150c24f2180a75e82427242dab20a9","0x15be","0x0"],"caller_address":"0xdac9bcffb3d967f19a7fe21002c98c984d5a9458a88e6fc5d1c478a97ed412","class_hash":"0x2760f25d5a4fb2bdde5f561fd0b44a3dee78c28903577d37d669939d97036a0","entry_point_type":"EXTERNAL","call_type":"LIBRARY_CALL","result":["0x1"],"calls":[],"events":[{"keys":["0x99cd8bde557814842a3121e8ddfd433a539b8c9f14bf31ebf108d12e6196e9"],"data":["0xdac9bcffb3d967f19a7fe21002c98c984d5a9458a88e6fc5d1c478a97ed412","0x5dcd266a80b8a5f29f04d779c6b166b80150c24f2180a75e82427242dab20a9","0x15be","0x0"]}],"messages":[]}],"events":[],"messages":[]}}
 	}`)
-	mockVM.EXPECT().Execute([]core.Transaction{tx}, []core.Class{declaredClass.Class}, header.Number, header.Timestamp, header.SequencerAddress, nil, utils.MAINNET, []*felt.Felt{}).Return(nil, []json.RawMessage{vmTrace}, nil)
+	mockVM.EXPECT().Execute([]core.Transaction{tx}, []core.Class{declaredClass.Class}, header.Number, header.Timestamp, header.SequencerAddress, nil, utils.MAINNET, []*felt.Felt{}, vm.Limits{}).Return(nil, []json.RawMessage{vmTrace}, nil)
 
-	trace, err := handler.TraceTransaction(*hash)
+	trace, err := handler.TraceTransaction(context.Background(), *hash)
 	require.Nil(t, err)
 	assert.Equal(t, vmTrace, trace)
 }
@@ -2101,7 +2388,7 @@ func TestSimulateTransactions(t *testing.T) {
 	handler := rpc.New(mockReader, nil, network, nil, nil, mockVM, "", log)
 
 	t.Run("failure if simulation flags provided", func(t *testing.T) {
-		_, err := handler.SimulateTransactions(rpc.BlockID{Latest: true}, []rpc.BroadcastedTransaction{}, []rpc.SimulationFlag{rpc.SkipValidateFlag})
+		_, err := handler.SimulateTransactions(context.Background(), rpc.BlockID{Latest: true}, []rpc.BroadcastedTransaction{}, []rpc.SimulationFlag{rpc.SkipValidateFlag})
 		require.NotNil(t, err)
 	})
 	t.Run("ok with zero values", func(t *testing.T) {
@@ -2111,10 +2398,35 @@ func TestSimulateTransactions(t *testing.T) {
 		mockReader.EXPECT().HeadsHeader().Return(&core.Header{}, nil)
 
 		sequencerAddress := core.NetworkBlockHashMetaInfo(network).FallBackSequencerAddress
-		mockVM.EXPECT().Execute(nil, nil, uint64(0), uint64(0), sequencerAddress, mockState, network, []*felt.Felt{}).
+		mockVM.EXPECT().Execute(nil, nil, uint64(0), uint64(0), sequencerAddress, mockState, network, []*felt.Felt{}, vm.Limits{}).
 			Return([]*felt.Felt{}, []json.RawMessage{}, nil)
 
-		_, err := handler.SimulateTransactions(rpc.BlockID{Latest: true}, []rpc.BroadcastedTransaction{}, nil)
+		_, err := handler.SimulateTransactions(context.Background(), rpc.BlockID{Latest: true}, []rpc.BroadcastedTransaction{}, nil)
+		require.Nil(t, err)
+	})
+	t.Run("execution limits", func(t *testing.T) {
+		limitedHandler := rpc.New(mockReader, nil, network, nil, nil, mockVM, "", log).WithExecutionLimits(
+			vm.Limits{MaxSteps: 1000, MaxMemory: 64},
+			map[string]vm.Limits{"starknet_simulateTransactions": {MaxSteps: 10, Timeout: time.Second}},
+		)
+		mockState := mocks.NewMockStateHistoryReader(mockCtrl)
+		mockReader.EXPECT().HeadState().Return(mockState, nopCloser, nil).Times(2)
+		mockReader.EXPECT().HeadsHeader().Return(&core.Header{}, nil).Times(2)
+		sequencerAddress := core.NetworkBlockHashMetaInfo(network).FallBackSequencerAddress
+
+		mockVM.EXPECT().Execute(nil, nil, uint64(0), uint64(0), sequencerAddress, mockState, network, []*felt.Felt{},
+			vm.Limits{MaxSteps: 10, MaxMemory: 64, Timeout: time.Second}).
+			Return(nil, nil, &vm.ResourcesExceededError{Resource: "steps"})
+		_, err := limitedHandler.SimulateTransactions(context.Background(), rpc.BlockID{Latest: true}, []rpc.BroadcastedTransaction{}, nil)
+		require.NotNil(t, err)
+		assert.Equal(t, rpc.ErrExecutionResourcesExceeded.Code, err.Code)
+		assert.Equal(t, "execution resources exceeded: steps limit reached", err.Data)
+
+		// methods without overrides use the limits of all methods
+		mockVM.EXPECT().Execute(nil, nil, uint64(0), uint64(0), sequencerAddress, mockState, network, []*felt.Felt{},
+			vm.Limits{MaxSteps: 1000, MaxMemory: 64}).
+			Return([]*felt.Felt{}, []json.RawMessage{}, nil)
+		_, err = limitedHandler.EstimateFee(context.Background(), []rpc.BroadcastedTransaction{}, rpc.BlockID{Latest: true})
 		require.Nil(t, err)
 	})
 }
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+// Job is the state of a background job of the node, Steps and Failures count the steps of the job over all the
+// runs of the node
+type Job struct {
+	Name      string     `json:"name"`
+	Priority  string     `json:"priority"`
+	Running   bool       `json:"running"`
+	NextStep  *time.Time `json:"next_step,omitempty"`
+	Steps     uint64     `json:"steps"`
+	Failures  uint64     `json:"failures"`
+	LastStep  *time.Time `json:"last_step,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// WithJobs serves the states of the jobs of manager
+func (h *Handler) WithJobs(manager *jobs.Manager) *Handler {
+	h.jobs = manager
+	return h
+}
+
+// Jobs returns the states of the background jobs of the node, ordered by priority, it is served as juno_getJobs
+func (h *Handler) Jobs() ([]*Job, *jsonrpc.Error) {
+	if h.jobs == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "the jobs are not served")
+	}
+
+	states := h.jobs.States()
+	jobStates := make([]*Job, 0, len(states))
+	for _, state := range states {
+		jobStates = append(jobStates, &Job{
+			Name:      state.Name,
+			Priority:  state.Priority.String(),
+			Running:   state.Running,
+			NextStep:  timeOrNil(state.NextStep),
+			Steps:     state.Steps,
+			Failures:  state.Failures,
+			LastStep:  timeOrNil(state.LastStep),
+			LastError: state.LastError,
+		})
+	}
+	return jobStates, nil
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
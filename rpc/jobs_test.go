@@ -0,0 +1,68 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/rpc"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingJob struct{}
+
+func (failingJob) Step(context.Context) (bool, error) {
+	return false, errors.New("disk full")
+}
+
+func TestJobs(t *testing.T) {
+	t.Run("not served", func(t *testing.T) {
+		handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger())
+		_, rpcErr := handler.Jobs()
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+	})
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	manager := jobs.New(testDB, 1, utils.NewNopZapLogger()).
+		Add("audit", failingJob{}, jobs.Options{Priority: jobs.Low, Interval: time.Hour}).
+		Add("indexer", failingJob{}, jobs.Options{Priority: jobs.High, Interval: time.Hour})
+	handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger()).WithJobs(manager)
+
+	jobStates, rpcErr := handler.Jobs()
+	require.Nil(t, rpcErr)
+	assert.Equal(t, []*rpc.Job{
+		{Name: "indexer", Priority: "high"},
+		{Name: "audit", Priority: "low"},
+	}, jobStates)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		errs <- manager.Run(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		jobStates, rpcErr = handler.Jobs()
+		require.Nil(t, rpcErr)
+		return jobStates[0].Failures == 1 && jobStates[1].Failures == 1
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-errs)
+
+	for _, job := range jobStates {
+		assert.Equal(t, uint64(1), job.Steps)
+		assert.Equal(t, "disk full", job.LastError)
+		require.NotNil(t, job.LastStep)
+		require.NotNil(t, job.NextStep)
+		assert.True(t, job.NextStep.After(*job.LastStep))
+	}
+}
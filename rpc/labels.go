@@ -0,0 +1,28 @@
+package rpc
+
+import (
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+// Labels are the human-readable labels the operator of the node set for contract addresses and selectors
+type Labels struct {
+	Addresses map[string]string `json:"addresses"`
+	Selectors map[string]string `json:"selectors"`
+}
+
+// Labels returns the labels of the node, it is served as juno_getLabels
+func (h *Handler) Labels() (*Labels, *jsonrpc.Error) {
+	return &Labels{
+		Addresses: labelsByHex(h.labels.Addresses()),
+		Selectors: labelsByHex(h.labels.Selectors()),
+	}, nil
+}
+
+func labelsByHex(labels map[felt.Felt]string) map[string]string {
+	byHex := make(map[string]string, len(labels))
+	for key, label := range labels {
+		byHex[key.String()] = label
+	}
+	return byHex
+}
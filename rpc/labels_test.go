@@ -0,0 +1,37 @@
+package rpc_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/labels"
+	"github.com/NethermindEth/juno/rpc"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabels(t *testing.T) {
+	t.Run("no labels", func(t *testing.T) {
+		handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger())
+		got, rpcErr := handler.Labels()
+		require.Nil(t, rpcErr)
+		assert.Equal(t, &rpc.Labels{Addresses: map[string]string{}, Selectors: map[string]string{}}, got)
+	})
+
+	t.Run("labels of the registry", func(t *testing.T) {
+		registry := labels.New()
+		require.NoError(t, registry.Add(&labels.File{
+			Addresses: map[string]string{"0x1": "ETH token"},
+			Selectors: map[string]string{"0x2": "custom"},
+		}))
+		handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger()).WithLabels(registry)
+
+		got, rpcErr := handler.Labels()
+		require.Nil(t, rpcErr)
+		assert.Equal(t, &rpc.Labels{
+			Addresses: map[string]string{new(felt.Felt).SetUint64(1).String(): "ETH token"},
+			Selectors: map[string]string{new(felt.Felt).SetUint64(2).String(): "custom"},
+		}, got)
+	})
+}
@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+// PoolTransaction is a transaction submitted through the node that is held by its pool
+type PoolTransaction struct {
+	Transaction *Transaction `json:"transaction"`
+	// Status is PENDING while the gateway cannot be reached, and ACCEPTED once it accepted the transaction
+	Status      string `json:"status"`
+	SubmittedAt uint64 `json:"submitted_at"`
+	Attempts    uint   `json:"attempts"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+// PoolTransactions returns the transactions of the pool in the order they were submitted, it is served as
+// juno_getPoolTransactions
+func (h *Handler) PoolTransactions() ([]*PoolTransaction, *jsonrpc.Error) {
+	poolTxns := []*PoolTransaction{}
+	if h.pool == nil {
+		return poolTxns, nil
+	}
+	for _, pooled := range h.pool.Transactions() {
+		poolTxns = append(poolTxns, &PoolTransaction{
+			Transaction: adaptTransaction(pooled.Transaction),
+			Status:      string(pooled.Status),
+			SubmittedAt: uint64(pooled.SubmittedAt.Unix()),
+			Attempts:    pooled.Attempts,
+			LastError:   pooled.LastError,
+		})
+	}
+	return poolTxns, nil
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/recentevents"
+)
+
+// ErrRateLimited is returned by the rate-limited methods when they are called more often than they may be
+var ErrRateLimited = &jsonrpc.Error{Code: -32005, Message: "Request rate limit exceeded"}
+
+// WithRecentEvents serves the events of the recent blocks of ring, at most rate requests per second if it is
+// not zero
+func (h *Handler) WithRecentEvents(ring *recentevents.Ring, rate uint) *Handler {
+	h.recentEvents = ring
+	if rate > 0 {
+		h.recentEventsLimiter = newRateLimiter(rate)
+	}
+	return h
+}
+
+// RecentEvents returns the events of the recent blocks the node keeps in memory, from the block of the
+// continuation token, or from the oldest recent block without one. The continuation token of the response
+// continues with the blocks that follow. It is served as juno_getRecentEvents.
+func (h *Handler) RecentEvents(continuationToken string) (*EventsChunk, *jsonrpc.Error) {
+	if h.recentEvents == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "recent events are not enabled")
+	}
+	if !h.recentEventsLimiter.allow() {
+		return nil, ErrRateLimited
+	}
+
+	var from uint64
+	if continuationToken == "" {
+		from, _ = h.recentEvents.Oldest()
+	} else {
+		var err error
+		if from, err = strconv.ParseUint(continuationToken, 10, 64); err != nil {
+			return nil, ErrInvalidContinuationToken
+		}
+	}
+
+	events, next, err := h.recentEvents.Since(from)
+	if errors.Is(err, recentevents.ErrNotRecent) {
+		// the client fell behind, it has to get the events it missed with starknet_getEvents
+		return nil, ErrInvalidContinuationToken
+	} else if err != nil {
+		return nil, ErrInternal
+	}
+
+	emittedEvents := make([]*EmittedEvent, 0, len(events))
+	for i := range events {
+		event := &events[i]
+		emittedEvents = append(emittedEvents, &EmittedEvent{
+			BlockNumber:     &event.BlockNumber,
+			BlockHash:       event.BlockHash,
+			TransactionHash: event.TransactionHash,
+			Event: &Event{
+				From: event.From,
+				Keys: event.Keys,
+				Data: event.Data,
+			},
+		})
+	}
+	return &EventsChunk{
+		Events:            emittedEvents,
+		ContinuationToken: strconv.FormatUint(next, 10),
+	}, nil
+}
+
+// rateLimiter is a token bucket that allows a number of calls per second, in bursts of up to as many calls.
+// A nil rateLimiter allows all calls.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate uint) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// allow returns whether a call is allowed now, and takes its token if it is
+func (l *rateLimiter) allow() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
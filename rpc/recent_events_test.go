@@ -0,0 +1,97 @@
+package rpc_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/recentevents"
+	"github.com/NethermindEth/juno/rpc"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentEvents(t *testing.T) {
+	t.Run("not enabled", func(t *testing.T) {
+		handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger())
+		_, rpcErr := handler.RecentEvents("")
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+	})
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+	var events int
+	for i := uint64(0); i < 5; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+		if i >= 2 {
+			for _, receipt := range block.Receipts {
+				events += len(receipt.Events)
+			}
+		}
+	}
+
+	ring := recentevents.New(chain, 3, utils.NewNopZapLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ring.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	require.Eventually(t, func() bool {
+		_, next, err := ring.Since(2)
+		return err == nil && next == 5
+	}, 10*time.Second, 10*time.Millisecond)
+
+	const rate = 4
+	handler := rpc.New(chain, nil, utils.GOERLI2, nil, nil, nil, "", utils.NewNopZapLogger()).
+		WithRecentEvents(ring, rate)
+
+	chunk, rpcErr := handler.RecentEvents("")
+	require.Nil(t, rpcErr)
+	assert.Len(t, chunk.Events, events)
+	assert.Equal(t, "5", chunk.ContinuationToken)
+	require.NotEmpty(t, chunk.Events)
+	assert.GreaterOrEqual(t, *chunk.Events[0].BlockNumber, uint64(2))
+
+	chunk, rpcErr = handler.RecentEvents(chunk.ContinuationToken)
+	require.Nil(t, rpcErr)
+	assert.Empty(t, chunk.Events)
+	assert.Equal(t, "5", chunk.ContinuationToken)
+
+	_, rpcErr = handler.RecentEvents(strconv.Itoa(1))
+	assert.Equal(t, rpc.ErrInvalidContinuationToken, rpcErr, "the block is no longer recent")
+
+	t.Run("rate limit", func(t *testing.T) {
+		// the calls above spent most of the burst of the limiter
+		for calls := 0; ; calls++ {
+			require.Less(t, calls, rate)
+			if _, rpcErr = handler.RecentEvents("invalid"); rpcErr == rpc.ErrRateLimited {
+				break
+			}
+		}
+		require.Eventually(t, func() bool {
+			_, rpcErr = handler.RecentEvents("invalid")
+			return rpcErr == rpc.ErrInvalidContinuationToken
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+// ErrResponseTooLarge is returned by the methods whose response exceeds their maximum response size and cannot be
+// continued, it uses the code JSON-RPC reserves for servers to report exceeded limits
+var ErrResponseTooLarge = &jsonrpc.Error{Code: -32005, Message: "Response too large"}
+
+const (
+	// feltSize is the size of a felt encoded as a quoted hex string in JSON, with its separator
+	feltSize = 69
+	// emittedEventSize is the size of the block hash, the block number, the transaction hash, the address and the
+	// field names of an emitted event encoded in JSON
+	emittedEventSize = 3*feltSize + 128
+	// entryPointSize is the size of an entry point of a class encoded in JSON
+	entryPointSize = feltSize + 48
+)
+
+// WithMaxResponseSizes caps the size in bytes of the responses of the methods that can return large responses:
+// starknet_getEvents, starknet_getClass, starknet_getClassAt, starknet_traceTransaction and
+// starknet_simulateTransactions. The non-zero sizes of methodSizes, which is keyed by method name, override size for
+// their method. Zero disables the cap.
+//
+// starknet_getEvents returns the events that fit, at least one, with the continuation token of the first event it
+// leaves out. The other methods fail with ErrResponseTooLarge.
+func (h *Handler) WithMaxResponseSizes(size uint64, methodSizes map[string]uint64) *Handler {
+	h.maxResponseSize = size
+	h.methodResponseSizes = make(map[string]uint64, len(methodSizes))
+	for method, methodSize := range methodSizes {
+		h.methodResponseSizes[strings.ToLower(method)] = methodSize
+	}
+	return h
+}
+
+// maxResponseSizeOf returns the maximum response size of method, zero if it is not capped
+func (h *Handler) maxResponseSizeOf(method string) uint64 {
+	if size := h.methodResponseSizes[strings.ToLower(method)]; size > 0 {
+		return size
+	}
+	return h.maxResponseSize
+}
+
+// checkResponseSize fails with ErrResponseTooLarge if size exceeds the maximum response size of method
+func (h *Handler) checkResponseSize(method string, size uint64) *jsonrpc.Error {
+	if maxSize := h.maxResponseSizeOf(method); maxSize > 0 && size > maxSize {
+		rpcErr := *ErrResponseTooLarge
+		rpcErr.Data = fmt.Sprintf("the response of %d bytes exceeds the maximum of %d bytes of %s", size, maxSize, method)
+		return &rpcErr
+	}
+	return nil
+}
+
+// truncateEvents returns the first events whose response fits in the maximum response size of method, and the
+// continuation token of the first event it leaves out, or token if all of them fit. The first event is returned
+// even if it does not fit, so that the events can always be paged through.
+func (h *Handler) truncateEvents(method string, events []*blockchain.FilteredEvent,
+	token *blockchain.ContinuationToken,
+) ([]*blockchain.FilteredEvent, *blockchain.ContinuationToken) {
+	maxSize := h.maxResponseSizeOf(method)
+	if maxSize == 0 {
+		return events, token
+	}
+	var size uint64
+	for i, event := range events {
+		size += emittedEventSize + uint64(len(event.Keys)+len(event.Data))*feltSize
+		if size > maxSize && i > 0 {
+			return events[:i], blockchain.NewContinuationToken(event.BlockNumber, event.EventIndex)
+		}
+	}
+	return events, token
+}
+
+// classSize estimates the size of class encoded in JSON
+func classSize(class *Class) uint64 {
+	size := uint64(len(class.Program)) + uint64(len(class.SierraProgram))*feltSize
+	switch abi := class.Abi.(type) {
+	case string:
+		size += uint64(len(abi))
+	case json.RawMessage:
+		size += uint64(len(abi))
+	}
+	entryPoints := len(class.EntryPoints.Constructor) + len(class.EntryPoints.External) +
+		len(class.EntryPoints.L1Handler)
+	return size + uint64(entryPoints)*entryPointSize
+}
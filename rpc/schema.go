@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/migration"
+)
+
+// SchemaStatus is the schema version of the database of the node and its migrations
+type SchemaStatus struct {
+	SchemaVersion       uint64              `json:"schema_version"`
+	LatestSchemaVersion uint64              `json:"latest_schema_version"`
+	AppliedMigrations   []*AppliedMigration `json:"applied_migrations"`
+	// PendingMigrations are the schema versions the migrations that were not applied yet migrate to
+	PendingMigrations []uint64 `json:"pending_migrations"`
+}
+
+// AppliedMigration is a migration applied to the database, Backup is the path of the backup taken before it and
+// RemainingBlocks the number of blocks it still migrates if it is applied in the background
+type AppliedMigration struct {
+	Version         uint64 `json:"version"`
+	Name            string `json:"name,omitempty"`
+	Backup          string `json:"backup,omitempty"`
+	RemainingBlocks uint64 `json:"remaining_blocks,omitempty"`
+}
+
+// WithSchema serves the schema status of database
+func (h *Handler) WithSchema(database db.DB) *Handler {
+	h.schemaDB = database
+	return h
+}
+
+// SchemaStatus returns the schema version of the database and its migrations, it is served as
+// juno_getSchemaVersion so that operators can check that the nodes migrated their databases after an upgrade
+func (h *Handler) SchemaStatus() (*SchemaStatus, *jsonrpc.Error) {
+	if h.schemaDB == nil {
+		return nil, jsonrpc.Err(jsonrpc.MethodNotFound, "the schema status is not served")
+	}
+
+	status, err := migration.GetStatus(h.schemaDB)
+	if err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+	}
+	applied := make([]*AppliedMigration, 0, len(status.Applied))
+	for _, m := range status.Applied {
+		applied = append(applied, &AppliedMigration{
+			Version:         m.Version,
+			Name:            m.Name,
+			Backup:          m.Backup,
+			RemainingBlocks: m.RemainingBlocks,
+		})
+	}
+	return &SchemaStatus{
+		SchemaVersion:       status.SchemaVersion,
+		LatestSchemaVersion: status.LatestSchemaVersion,
+		AppliedMigrations:   applied,
+		PendingMigrations:   status.Pending,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/jsonrpc"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/rpc"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaStatus(t *testing.T) {
+	t.Run("not served", func(t *testing.T) {
+		handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger())
+		_, rpcErr := handler.SchemaStatus()
+		require.NotNil(t, rpcErr)
+		assert.Equal(t, jsonrpc.MethodNotFound, rpcErr.Code)
+	})
+
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	handler := rpc.New(nil, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger()).WithSchema(testDB)
+	latest := migration.LatestSchemaVersion()
+
+	t.Run("pending migrations", func(t *testing.T) {
+		require.NoError(t, migration.MigrateTo(context.Background(), testDB, utils.MAINNET, 2, utils.NewNopZapLogger(),
+			migration.MigrationOptions{}))
+		status, rpcErr := handler.SchemaStatus()
+		require.Nil(t, rpcErr)
+		assert.Equal(t, uint64(2), status.SchemaVersion)
+		assert.Equal(t, latest, status.LatestSchemaVersion)
+		assert.Equal(t, []*rpc.AppliedMigration{
+			{Version: 1, Name: "migration0000"},
+			{Version: 2, Name: "relocateContractStorageRootKeys"},
+		}, status.AppliedMigrations)
+		assert.Len(t, status.PendingMigrations, int(latest-2))
+		assert.Equal(t, uint64(3), status.PendingMigrations[0])
+	})
+
+	t.Run("migrated", func(t *testing.T) {
+		require.NoError(t, migration.MigrateIfNeeded(context.Background(), testDB, utils.MAINNET,
+			utils.NewNopZapLogger(), migration.MigrationOptions{}))
+		status, rpcErr := handler.SchemaStatus()
+		require.Nil(t, rpcErr)
+		assert.Equal(t, latest, status.SchemaVersion)
+		assert.Len(t, status.AppliedMigrations, int(latest))
+		assert.Empty(t, status.PendingMigrations)
+	})
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/jsonrpc"
+)
+
+const maxStorageChunkSize = 10240
+
+type StorageEntry struct {
+	Key   *felt.Felt `json:"key"`
+	Value *felt.Felt `json:"value"`
+}
+
+type StorageChunk struct {
+	Storage           []StorageEntry `json:"storage"`
+	ContinuationToken string         `json:"continuation_token,omitempty"`
+}
+
+// ContractStorage pages through the non-zero storage of a contract at a block, in ascending order of the
+// storage keys. The continuation token of a page is the key the next page starts from.
+//
+// It is a Juno-specific method (juno_getContractStorage), no standard method enumerates the storage of a contract.
+func (h *Handler) ContractStorage(address felt.Felt, id BlockID, page ResultPageRequest) (*StorageChunk, *jsonrpc.Error) {
+	if page.ChunkSize > maxStorageChunkSize {
+		return nil, ErrPageSizeTooBig
+	}
+
+	start := &felt.Zero
+	if len(page.ContinuationToken) > 0 {
+		var err error
+		if start, err = new(felt.Felt).SetString(page.ContinuationToken); err != nil {
+			return nil, ErrInvalidContinuationToken
+		}
+	}
+
+	stateReader, stateCloser, err := h.stateByBlockID(&id)
+	if err != nil {
+		return nil, ErrBlockNotFound
+	}
+	defer h.callAndLogErr(stateCloser, "Error closing state reader in getContractStorage")
+
+	it, err := stateReader.ContractStorageIterator(&address, start)
+	if err != nil {
+		if errors.Is(err, core.ErrContractNotDeployed) {
+			return nil, ErrContractNotFound
+		}
+		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+	}
+
+	chunk := &StorageChunk{Storage: []StorageEntry{}}
+	for it.Next() {
+		if uint64(len(chunk.Storage)) == page.ChunkSize {
+			chunk.ContinuationToken = it.Key().String()
+			break
+		}
+		chunk.Storage = append(chunk.Storage, StorageEntry{Key: it.Key(), Value: it.Value()})
+	}
+	if err = errors.Join(it.Err(), it.Close()); err != nil {
+		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+	}
+	return chunk, nil
+}
+
+// StorageProof returns the proof of the value of a key of the storage of a contract in the state of the latest block,
+// which is verified against the state commitment of the block, see core.StorageProof. The proofs are served from
+// memory when they are requested again before the next block.
+//
+// It is a Juno-specific method (juno_getStorageProof).
+func (h *Handler) StorageProof(address, key felt.Felt) (*core.StorageProof, *jsonrpc.Error) {
+	proof, err := h.bcReader.StorageProof(&address, &key)
+	if err != nil {
+		if errors.Is(err, core.ErrContractNotDeployed) {
+			return nil, ErrContractNotFound
+		} else if errors.Is(err, db.ErrKeyNotFound) {
+			return nil, ErrBlockNotFound
+		}
+		return nil, jsonrpc.Err(jsonrpc.InternalError, err.Error())
+	}
+	return proof, nil
+}
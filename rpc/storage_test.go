@@ -0,0 +1,147 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/rpc"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractStorage(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	// expected holds the non-zero storage of every contract after each block, the last block is pending
+	const blocks = 7
+	expected := make([]map[felt.Felt]map[felt.Felt]felt.Felt, blocks)
+	storage := make(map[felt.Felt]map[felt.Felt]felt.Felt)
+	for i := uint64(0); i < blocks; i++ {
+		b, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		s, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+
+		if i < blocks-1 {
+			require.NoError(t, chain.Store(b, &core.BlockCommitments{}, s, nil))
+		} else {
+			b.Hash = nil
+			b.GlobalStateRoot = nil
+			require.NoError(t, chain.StorePending(&blockchain.Pending{Block: b, StateUpdate: s}))
+		}
+
+		for addr, diffs := range s.StateDiff.StorageDiffs {
+			if storage[addr] == nil {
+				storage[addr] = make(map[felt.Felt]felt.Felt)
+			}
+			for _, diff := range diffs {
+				if diff.Value.IsZero() {
+					delete(storage[addr], *diff.Key)
+				} else {
+					storage[addr][*diff.Key] = *diff.Value
+				}
+			}
+		}
+		expected[i] = make(map[felt.Felt]map[felt.Felt]felt.Felt, len(storage))
+		for addr, contractStorage := range storage {
+			expected[i][addr] = make(map[felt.Felt]felt.Felt, len(contractStorage))
+			for key, value := range contractStorage {
+				expected[i][addr][key] = value
+			}
+		}
+	}
+
+	handler := rpc.New(chain, nil, utils.GOERLI2, nil, nil, nil, "", utils.NewNopZapLogger())
+
+	// pages reads the storage of addr at id in pages of two entries
+	pages := func(t *testing.T, addr felt.Felt, id rpc.BlockID) map[felt.Felt]felt.Felt {
+		t.Helper()
+		result := make(map[felt.Felt]felt.Felt)
+		page := rpc.ResultPageRequest{ChunkSize: 2}
+		var last *felt.Felt
+		for {
+			chunk, rpcErr := handler.ContractStorage(addr, id, page)
+			require.Nil(t, rpcErr)
+			require.LessOrEqual(t, len(chunk.Storage), 2)
+			for _, entry := range chunk.Storage {
+				if last != nil {
+					assert.Equal(t, 1, entry.Key.Cmp(last), "keys are not in ascending order")
+				}
+				last = entry.Key
+				result[*entry.Key] = *entry.Value
+			}
+			if chunk.ContinuationToken == "" {
+				return result
+			}
+			page.ContinuationToken = chunk.ContinuationToken
+		}
+	}
+
+	for i := uint64(0); i < blocks; i++ {
+		id := rpc.BlockID{Number: i}
+		if i == blocks-1 {
+			id = rpc.BlockID{Pending: true}
+		}
+		for addr, contractStorage := range expected[i] {
+			assert.Equal(t, contractStorage, pages(t, addr, id), "storage of %s at block %d", addr.String(), i)
+		}
+	}
+
+	t.Run("contract that is not deployed", func(t *testing.T) {
+		_, rpcErr := handler.ContractStorage(*new(felt.Felt).SetUint64(0xdead), rpc.BlockID{Latest: true},
+			rpc.ResultPageRequest{ChunkSize: 10})
+		assert.Equal(t, rpc.ErrContractNotFound, rpcErr)
+	})
+
+	t.Run("invalid continuation token", func(t *testing.T) {
+		_, rpcErr := handler.ContractStorage(felt.Zero, rpc.BlockID{Latest: true},
+			rpc.ResultPageRequest{ChunkSize: 10, ContinuationToken: "not a key"})
+		assert.Equal(t, rpc.ErrInvalidContinuationToken, rpcErr)
+	})
+
+	t.Run("chunk size too big", func(t *testing.T) {
+		_, rpcErr := handler.ContractStorage(felt.Zero, rpc.BlockID{Latest: true},
+			rpc.ResultPageRequest{ChunkSize: 10241})
+		assert.Equal(t, rpc.ErrPageSizeTooBig, rpcErr)
+	})
+}
+
+func TestStorageProof(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.MAINNET, utils.NewNopZapLogger())
+	handler := rpc.New(chain, nil, utils.MAINNET, nil, nil, nil, "", utils.NewNopZapLogger())
+	addr := utils.HexToFelt(t, "0x20cfa74ee3564b4cd5435cdace0f9c4d43b939620e4a0bb5076105df0a626c6")
+	key := utils.HexToFelt(t, "0x5")
+
+	_, rpcErr := handler.StorageProof(*addr, *key)
+	assert.Equal(t, rpc.ErrBlockNotFound, rpcErr)
+
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	b, err := gw.BlockByNumber(context.Background(), 0)
+	require.NoError(t, err)
+	su, err := gw.StateUpdate(context.Background(), 0)
+	require.NoError(t, err)
+	require.NoError(t, chain.Store(b, &core.BlockCommitments{}, su, nil))
+
+	proof, rpcErr := handler.StorageProof(*addr, *key)
+	require.Nil(t, rpcErr)
+	assert.True(t, proof.Verify(b.GlobalStateRoot, addr, key, utils.HexToFelt(t, "0x22b")))
+
+	_, rpcErr = handler.StorageProof(*new(felt.Felt).SetUint64(0xdead), *key)
+	assert.Equal(t, rpc.ErrContractNotFound, rpcErr)
+}
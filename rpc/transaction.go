@@ -88,6 +88,7 @@ type TxnFinalityStatus uint8
 const (
 	TxnAcceptedOnL1 TxnFinalityStatus = iota + 1
 	TxnAcceptedOnL2
+	TxnReceived
 )
 
 func (fs TxnFinalityStatus) MarshalJSON() ([]byte, error) {
@@ -96,6 +97,8 @@ func (fs TxnFinalityStatus) MarshalJSON() ([]byte, error) {
 		return []byte(`"ACCEPTED_ON_L1"`), nil
 	case TxnAcceptedOnL2:
 		return []byte(`"ACCEPTED_ON_L2"`), nil
+	case TxnReceived:
+		return []byte(`"RECEIVED"`), nil
 	default:
 		return nil, errors.New("unknown FinalityStatus")
 	}
@@ -123,7 +126,7 @@ type Transaction struct {
 
 type TransactionStatus struct {
 	Finality  TxnFinalityStatus  `json:"finality_status"`
-	Execution TxnExecutionStatus `json:"execution_status"`
+	Execution TxnExecutionStatus `json:"execution_status,omitempty"`
 }
 
 type MsgFromL1 struct {
@@ -0,0 +1,103 @@
+// Package selfcheck runs fast integrity checks of a database on startup. The problems it finds come with
+// the actions that recover from them, rather than the low-level errors the node would otherwise fail with.
+package selfcheck
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/utils"
+)
+
+const (
+	adviceUpgrade = "The database was migrated by a newer version of Juno. Run that version, revert its " +
+		"migrations with `juno db revert --to %d` of that version, or delete the database directory (--db-path) " +
+		"to sync again with this version."
+	adviceRerunMigrations = "The schema version could not be read, so the migrations cannot be applied. Restore a " +
+		"snapshot with `juno snapshot import`, or delete the database directory (--db-path) to sync again."
+	adviceRestore = "The head block is corrupted. Restore a snapshot with `juno snapshot import`, or delete the " +
+		"database directory (--db-path) to sync again."
+	adviceStateRoot = "The state does not match the head block, which is usually left by a migration or a commit " +
+		"that was interrupted. Restore a snapshot with `juno snapshot import`, or delete the database directory " +
+		"(--db-path) to sync again."
+)
+
+// Problem is a check that failed and what the operator can do about it
+type Problem struct {
+	Check  string
+	Err    error
+	Advice string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %v\n%s", p.Check, p.Err, p.Advice)
+}
+
+// Schema checks that the schema version of database can be read and that the migrations of this version of
+// Juno can be applied to it
+func Schema(database db.DB) []Problem {
+	_, problems := SchemaVersion(database)
+	return problems
+}
+
+// SchemaVersion is Schema, which also returns the schema version it read so that it is not read again to find
+// whether database is up to date
+func SchemaVersion(database db.DB) (uint64, []Problem) {
+	version, err := migration.SchemaVersion(database)
+	if err != nil {
+		return 0, []Problem{{Check: "schema version", Err: err, Advice: adviceRerunMigrations}}
+	}
+	if latest := migration.LatestSchemaVersion(); version > latest {
+		return version, []Problem{{
+			Check:  "schema version",
+			Err:    fmt.Errorf("schema version %d is newer than the latest version %d", version, latest),
+			Advice: fmt.Sprintf(adviceUpgrade, latest),
+		}}
+	}
+	return version, nil
+}
+
+// Run checks the schema version, that the head block and its state update decode, and that the state
+// matches the head block. It is meant to run once the migrations are applied.
+func Run(database db.DB, network utils.Network) []Problem {
+	if problems := Schema(database); len(problems) > 0 {
+		return problems
+	}
+
+	chain := blockchain.New(database, network, utils.NewNopZapLogger())
+	height, err := chain.Height()
+	if errors.Is(err, db.ErrKeyNotFound) {
+		// an empty database has nothing else to check
+		return nil
+	} else if err != nil {
+		return []Problem{{Check: "chain height", Err: err, Advice: adviceRestore}}
+	}
+
+	head, err := chain.BlockByNumber(height)
+	if err != nil {
+		return []Problem{{Check: "head block", Err: fmt.Errorf("read block %d: %w", height, err), Advice: adviceRestore}}
+	}
+	if _, err = chain.StateUpdateByNumber(height); err != nil {
+		return []Problem{{
+			Check:  "head state update",
+			Err:    fmt.Errorf("read state update of block %d: %w", height, err),
+			Advice: adviceRestore,
+		}}
+	}
+
+	root, err := chain.StateCommitment()
+	if err != nil {
+		return []Problem{{Check: "state root", Err: err, Advice: adviceStateRoot}}
+	}
+	if !root.Equal(head.GlobalStateRoot) {
+		return []Problem{{
+			Check:  "state root",
+			Err:    fmt.Errorf("state root %s does not match the root %s of block %d", root, head.GlobalStateRoot, height),
+			Advice: adviceStateRoot,
+		}}
+	}
+	return nil
+}
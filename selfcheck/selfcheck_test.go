@@ -0,0 +1,166 @@
+package selfcheck_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/migration"
+	"github.com/NethermindEth/juno/selfcheck"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB returns a migrated database that holds the first blocks of mainnet
+func newTestDB(t *testing.T) db.DB {
+	t.Helper()
+
+	database := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, database.Close())
+	})
+	require.NoError(t, migration.MigrateIfNeeded(context.Background(), database, utils.MAINNET,
+		utils.NewNopZapLogger(), migration.MigrationOptions{}))
+
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	chain := blockchain.New(database, utils.MAINNET, utils.NewNopZapLogger())
+	for i := uint64(0); i < 3; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+	}
+	return database
+}
+
+func setSchemaVersion(t *testing.T, database db.DB, version uint64) {
+	t.Helper()
+
+	require.NoError(t, database.Update(func(txn db.Transaction) error {
+		var versionBytes [8]byte
+		binary.BigEndian.PutUint64(versionBytes[:], version)
+		return txn.Set(db.SchemaVersion.Key(), versionBytes[:])
+	}))
+}
+
+func TestRun(t *testing.T) {
+	t.Run("empty database", func(t *testing.T) {
+		database := pebble.NewMemTest()
+		t.Cleanup(func() {
+			require.NoError(t, database.Close())
+		})
+		assert.Empty(t, selfcheck.Schema(database))
+		assert.Empty(t, selfcheck.Run(database, utils.MAINNET))
+		version, problems := selfcheck.SchemaVersion(database)
+		assert.Empty(t, problems)
+		assert.Zero(t, version)
+	})
+
+	t.Run("consistent database", func(t *testing.T) {
+		assert.Empty(t, selfcheck.Run(newTestDB(t), utils.MAINNET))
+	})
+
+	t.Run("schema version of a newer version", func(t *testing.T) {
+		database := newTestDB(t)
+		setSchemaVersion(t, database, migration.LatestSchemaVersion()+1)
+
+		problems := selfcheck.Schema(database)
+		require.Len(t, problems, 1)
+		assert.Equal(t, "schema version", problems[0].Check)
+		assert.Contains(t, problems[0].Advice, "newer version of Juno")
+		assert.Equal(t, problems, selfcheck.Run(database, utils.MAINNET))
+	})
+
+	t.Run("head that does not decode", func(t *testing.T) {
+		database := newTestDB(t)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			var numBytes [8]byte
+			binary.BigEndian.PutUint64(numBytes[:], 2)
+			return txn.Set(db.BlockHeadersByNumber.Key(numBytes[:]), []byte("garbage"))
+		}))
+
+		problems := selfcheck.Run(database, utils.MAINNET)
+		require.Len(t, problems, 1)
+		assert.Equal(t, "head block", problems[0].Check)
+		assert.Contains(t, problems[0].Advice, "juno snapshot import")
+	})
+
+	t.Run("missing state root key", func(t *testing.T) {
+		database := newTestDB(t)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.Delete(db.StateTrie.Key())
+		}))
+
+		problems := selfcheck.Run(database, utils.MAINNET)
+		require.Len(t, problems, 1)
+		assert.Equal(t, "state root", problems[0].Check)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("consistent database", func(t *testing.T) {
+		var checks []string
+		problems, err := selfcheck.Verify(context.Background(), newTestDB(t), utils.MAINNET, func(p selfcheck.Progress) {
+			checks = append(checks, p.Check)
+		})
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+		assert.Contains(t, checks, "values of BlockHeadersByNumber")
+		assert.Contains(t, checks, "block hashes")
+		assert.Contains(t, checks, "contract storage roots")
+	})
+
+	t.Run("receipt that does not decode", func(t *testing.T) {
+		database := newTestDB(t)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			return txn.Set(db.ReceiptsByBlockNumberAndIndex.Key(make([]byte, 16)), []byte("garbage"))
+		}))
+
+		problems, err := selfcheck.Verify(context.Background(), database, utils.MAINNET, nil)
+		require.NoError(t, err)
+		checks := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			checks = append(checks, problem.Check)
+		}
+		// the block of the receipt does not decode either
+		assert.Equal(t, []string{"values of ReceiptsByBlockNumberAndIndex", "block hashes"}, checks)
+	})
+
+	t.Run("storage root that does not resolve", func(t *testing.T) {
+		database := newTestDB(t)
+		require.NoError(t, database.Update(func(txn db.Transaction) error {
+			var rootKey, rootKeyValue []byte
+			err := db.IterateBucket(txn, db.ContractStorage, func(key, value []byte) error {
+				if rootKey == nil && len(key) == 1+32 {
+					rootKey, rootKeyValue = key, value
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			require.NotNil(t, rootKey)
+			require.NoError(t, txn.DeletePrefix(rootKey))
+			return txn.Set(rootKey, rootKeyValue)
+		}))
+
+		problems, err := selfcheck.Verify(context.Background(), database, utils.MAINNET, nil)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Equal(t, "contract storage roots", problems[0].Check)
+		assert.ErrorIs(t, problems[0].Err, db.ErrKeyNotFound)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := selfcheck.Verify(ctx, newTestDB(t), utils.MAINNET, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
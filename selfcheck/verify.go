@@ -0,0 +1,300 @@
+package selfcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/core/trie"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/bits-and-blooms/bitset"
+)
+
+const adviceCorrupted = "The database is corrupted, usually by a disk error or by an unclean shutdown of a " +
+	"filesystem that does not preserve the order of writes. Restore a snapshot with `juno snapshot import`, or " +
+	"delete the database directory (--db-path) to sync again."
+
+const (
+	// progressInterval is the number of keys or contracts between two reports of the progress of Verify
+	progressInterval = 100_000
+	// blockProgressInterval is the number of blocks between two reports, a block takes longer to verify
+	blockProgressInterval = 1000
+)
+
+// Progress is how far Verify is in the check it runs
+type Progress struct {
+	Check string
+	// Done is the number of keys, blocks or contracts the check verified
+	Done uint64
+}
+
+// codec decodes the values of a bucket, the key is passed for the buckets whose values depend on it
+type codec struct {
+	name   string
+	bucket db.Bucket
+	decode func(key, value []byte) error
+}
+
+var codecs = []codec{
+	{name: "StateTrie", bucket: db.StateTrie, decode: decodeTrieNode(1)},
+	{name: "ClassesTrie", bucket: db.ClassesTrie, decode: decodeTrieNode(1)},
+	{name: "ContractStorage", bucket: db.ContractStorage, decode: decodeTrieNode(1 + felt.Bytes)},
+	{name: "ContractClassHash", bucket: db.ContractClassHash, decode: decodeFixed(felt.Bytes)},
+	{name: "ContractNonce", bucket: db.ContractNonce, decode: decodeFixed(felt.Bytes)},
+	{name: "ContractDeploymentHeight", bucket: db.ContractDeploymentHeight, decode: decodeFixed(8)},
+	{name: "Class", bucket: db.Class, decode: decodeEncoded[core.DeclaredClass]},
+	{name: "ChainHeight", bucket: db.ChainHeight, decode: decodeFixed(8)},
+	{name: "L1Height", bucket: db.L1Height, decode: decodeEncoded[core.L1Head]},
+	{name: "BlockHeaderNumbersByHash", bucket: db.BlockHeaderNumbersByHash, decode: decodeFixed(8)},
+	{name: "BlockHeadersByNumber", bucket: db.BlockHeadersByNumber, decode: decodeEncoded[core.Header]},
+	{name: "BlockCommitments", bucket: db.BlockCommitments, decode: decodeEncoded[core.BlockCommitments]},
+	{
+		name:   "TransactionBlockNumbersAndIndicesByHash",
+		bucket: db.TransactionBlockNumbersAndIndicesByHash,
+		decode: decodeFixed(16),
+	},
+	{
+		name:   "TransactionsByBlockNumberAndIndex",
+		bucket: db.TransactionsByBlockNumberAndIndex,
+		decode: decodeEncoded[core.Transaction],
+	},
+	{
+		name:   "ReceiptsByBlockNumberAndIndex",
+		bucket: db.ReceiptsByBlockNumberAndIndex,
+		decode: decodeEncoded[core.TransactionReceipt],
+	},
+	{name: "StateUpdatesByBlockNumber", bucket: db.StateUpdatesByBlockNumber, decode: decodeEncoded[core.StateUpdate]},
+}
+
+// CheckValue returns why value does not decode as the value of key, nil if it does or if the values of the bucket of
+// key are not checked, see db.Repair
+func CheckValue(key, value []byte) error {
+	if len(key) == 0 {
+		return nil
+	}
+	for _, c := range codecs {
+		if c.bucket == db.Bucket(key[0]) {
+			return c.decode(key, value)
+		}
+	}
+	return nil
+}
+
+func decodeEncoded[T any](_, value []byte) error {
+	var decoded T
+	return encoder.Unmarshal(value, &decoded)
+}
+
+func decodeFixed(size int) func(key, value []byte) error {
+	return func(_, value []byte) error {
+		if len(value) != size {
+			return fmt.Errorf("value is %d bytes instead of %d", len(value), size)
+		}
+		return nil
+	}
+}
+
+// decodeTrieNode decodes the nodes of the tries of a bucket, and their root keys, which are the keys of
+// rootKeyLen bytes
+func decodeTrieNode(rootKeyLen int) func(key, value []byte) error {
+	return func(key, value []byte) error {
+		if len(key) == rootKeyLen {
+			return new(bitset.BitSet).UnmarshalBinary(value)
+		}
+		var node trie.Node
+		return node.UnmarshalBinary(value)
+	}
+}
+
+// failures counts the failures of a check and keeps the first one
+type failures struct {
+	count uint64
+	first error
+}
+
+func (f *failures) add(err error) {
+	if f.count == 0 {
+		f.first = err
+	}
+	f.count++
+}
+
+// problem returns the problem of check if it failed, or nil
+func (f *failures) problem(check, advice string) []Problem {
+	if f.count == 0 {
+		return nil
+	}
+	return []Problem{{Check: check, Err: fmt.Errorf("%d failures, the first is: %w", f.count, f.first), Advice: advice}}
+}
+
+// Verify walks the whole database: it checks that the values of the buckets of the chain and of the state decode,
+// that the hashes of the blocks match their contents, and that the roots of the state trie, of the classes trie and
+// of the storage of every contract resolve to their nodes. Unlike Run, it reads every key, which takes hours for a
+// database of mainnet. progress, if it is set, is called as the checks go. The error is only set if ctx is done.
+func Verify(ctx context.Context, database db.DB, network utils.Network, progress func(Progress)) ([]Problem, error) {
+	if progress == nil {
+		progress = func(Progress) {}
+	}
+	var problems []Problem
+	err := database.View(func(txn db.Transaction) error {
+		var err error
+		problems, err = verify(ctx, txn, network, progress)
+		return err
+	})
+	return problems, err
+}
+
+func verify(ctx context.Context, txn db.Transaction, network utils.Network, progress func(Progress)) ([]Problem, error) {
+	var problems []Problem
+	for _, c := range codecs {
+		bucketProblems, err := verifyBucket(ctx, txn, c, progress)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, bucketProblems...)
+	}
+
+	var height uint64
+	err := txn.Get(db.ChainHeight.Key(), func(val []byte) error {
+		if len(val) != 8 {
+			return fmt.Errorf("chain height is %d bytes", len(val))
+		}
+		height = binary.BigEndian.Uint64(val)
+		return nil
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		// an empty database has no blocks and no state
+		return problems, nil
+	} else if err != nil {
+		return append(problems, Problem{Check: "chain height", Err: err, Advice: adviceRestore}), nil
+	}
+
+	blockProblems, err := verifyBlocks(ctx, txn, network, height, progress)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, blockProblems...)
+
+	stateProblems, err := verifyState(ctx, txn, height, progress)
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, stateProblems...), nil
+}
+
+// verifyBucket checks that the values of the bucket of c decode
+func verifyBucket(ctx context.Context, txn db.Transaction, c codec, progress func(Progress)) ([]Problem, error) {
+	check := "values of " + c.name
+	var failed failures
+	var done uint64
+	err := db.IterateBucket(txn, c.bucket, func(key, value []byte) error {
+		if err := c.decode(key, value); err != nil {
+			failed.add(fmt.Errorf("key %x: %w", key, err))
+		}
+		if done++; done%progressInterval == 0 {
+			progress(Progress{Check: check, Done: done})
+			return ctx.Err()
+		}
+		return nil
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	} else if err != nil {
+		failed.add(fmt.Errorf("read key %d: %w", done, err))
+	}
+	progress(Progress{Check: check, Done: done})
+	return failed.problem(check, adviceCorrupted), nil
+}
+
+// verifyBlocks checks the hashes of the blocks up to height, and that the blocks are found by their hashes
+func verifyBlocks(ctx context.Context, txn db.Transaction, network utils.Network, height uint64,
+	progress func(Progress),
+) ([]Problem, error) {
+	const check = "block hashes"
+	var failed failures
+	for number := uint64(0); number <= height; number++ {
+		if err := verifyBlock(txn, network, number); err != nil {
+			failed.add(fmt.Errorf("block %d: %w", number, err))
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if done := number + 1; done%blockProgressInterval == 0 || number == height {
+			progress(Progress{Check: check, Done: done})
+		}
+	}
+	return failed.problem(check, adviceCorrupted), nil
+}
+
+func verifyBlock(txn db.Transaction, network utils.Network, number uint64) error {
+	block, err := blockchain.BlockByNumber(txn, number)
+	if err != nil {
+		return err
+	}
+	if _, err = core.VerifyBlockHash(block, network); err != nil {
+		return err
+	}
+	return txn.Get(db.BlockHeaderNumbersByHash.Key(block.Hash.Marshal()), func(val []byte) error {
+		if len(val) != 8 || binary.BigEndian.Uint64(val) != number {
+			return fmt.Errorf("hash %s is mapped to block %x", block.Hash, val)
+		}
+		return nil
+	})
+}
+
+// verifyState checks that the state matches the block at height and that the storage root of every contract
+// resolves
+func verifyState(ctx context.Context, txn db.Transaction, height uint64, progress func(Progress)) ([]Problem, error) {
+	var problems []Problem
+	head, err := blockchain.BlockHeaderByNumber(txn, height)
+	if err != nil {
+		problems = append(problems, Problem{Check: "head block", Err: err, Advice: adviceRestore})
+	} else if root, rootErr := core.NewState(txn).Root(); rootErr != nil {
+		problems = append(problems, Problem{Check: "state root", Err: rootErr, Advice: adviceStateRoot})
+	} else if !root.Equal(head.GlobalStateRoot) {
+		problems = append(problems, Problem{
+			Check:  "state root",
+			Err:    fmt.Errorf("state root %s does not match the root %s of block %d", root, head.GlobalStateRoot, height),
+			Advice: adviceStateRoot,
+		})
+	}
+
+	const check = "contract storage roots"
+	var failed failures
+	var done uint64
+	prefix := db.ContractClassHash.Key()
+	err = db.IteratePrefix(txn, prefix, func(key, _ []byte) error {
+		addr := new(felt.Felt).SetBytes(bytes.TrimPrefix(key, prefix))
+		if err := verifyContract(txn, addr); err != nil {
+			failed.add(fmt.Errorf("contract %s: %w", addr, err))
+		}
+		if done++; done%progressInterval == 0 {
+			progress(Progress{Check: check, Done: done})
+			return ctx.Err()
+		}
+		return nil
+	}, db.KeysOnly())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	} else if err != nil {
+		failed.add(fmt.Errorf("read contract %d: %w", done, err))
+	}
+	progress(Progress{Check: check, Done: done})
+	return append(problems, failed.problem(check, adviceCorrupted)...), nil
+}
+
+func verifyContract(txn db.Transaction, addr *felt.Felt) error {
+	contract, err := core.NewContract(addr, txn)
+	if err != nil {
+		return err
+	}
+	_, err = contract.Root()
+	return err
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/utils"
+)
+
+var ErrStopTimeout = errors.New("services did not stop in time")
+
+// Stage is a group of services that are stopped together
+type Stage struct {
+	name     string
+	services []Service
+}
+
+// Add adds services to the stage
+func (s *Stage) Add(services ...Service) {
+	s.services = append(s.services, services...)
+}
+
+// Lifecycle runs services grouped in stages. All the services are started at once, but they are stopped one
+// stage at a time in the order the stages were created: a stage is only stopped after all the services of
+// the previous stages have returned. This allows, for example, to stop accepting requests before the state
+// they read from is closed.
+type Lifecycle struct {
+	stages      []*Stage
+	stopTimeout time.Duration
+	log         utils.SimpleLogger
+}
+
+// NewLifecycle returns a Lifecycle that waits up to stopTimeout for the services of each stage to return
+func NewLifecycle(stopTimeout time.Duration, log utils.SimpleLogger) *Lifecycle {
+	return &Lifecycle{stopTimeout: stopTimeout, log: log}
+}
+
+// Stage creates a stage that is stopped after all the stages created before it
+func (l *Lifecycle) Stage(name string) *Stage {
+	stage := &Stage{name: name}
+	l.stages = append(l.stages, stage)
+	return stage
+}
+
+// Run starts all the services and stops them, stage by stage, once ctx is cancelled or any service returns
+// an error. It returns the first error returned by a service, or ErrStopTimeout if a stage did not stop in
+// time, in which case its services may still be running.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	failed := make(chan struct{})
+	var failOnce sync.Once
+	var errsMu sync.Mutex
+	var errs []error
+
+	cancels := make([]context.CancelFunc, len(l.stages))
+	dones := make([]chan struct{}, len(l.stages))
+	for i, stage := range l.stages {
+		// stages are cancelled by Run, not by the cancellation of ctx
+		stageCtx, cancel := context.WithCancel(context.Background())
+		cancels[i] = cancel
+
+		var wg sync.WaitGroup
+		for _, s := range stage.services {
+			s := s
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := s.Run(stageCtx); err != nil {
+					l.log.Errorw("Service error", "name", reflect.TypeOf(s), "err", err)
+					errsMu.Lock()
+					errs = append(errs, err)
+					errsMu.Unlock()
+					failOnce.Do(func() { close(failed) })
+				}
+			}()
+		}
+		dones[i] = make(chan struct{})
+		go func(done chan struct{}) {
+			wg.Wait()
+			close(done)
+		}(dones[i])
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-failed:
+	}
+
+	var timedOut []string
+	for i, stage := range l.stages {
+		l.log.Infow("Stopping services", "stage", stage.name)
+		cancels[i]()
+		select {
+		case <-dones[i]:
+		case <-time.After(l.stopTimeout):
+			l.log.Warnw("Services did not stop in time, moving on to the next stage", "stage", stage.name,
+				"timeout", l.stopTimeout)
+			timedOut = append(timedOut, stage.name)
+		}
+	}
+
+	errsMu.Lock()
+	defer errsMu.Unlock()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	if len(timedOut) > 0 {
+		return fmt.Errorf("%w: %v", ErrStopTimeout, timedOut)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serviceFunc func(ctx context.Context) error
+
+func (f serviceFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// stopRecorder returns a service that records its name once it is stopped
+func stopRecorder(name string, mu *sync.Mutex, stopped *[]string) service.Service {
+	return serviceFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		// give the services of later stages a chance to stop out of order
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		*stopped = append(*stopped, name)
+		mu.Unlock()
+		return nil
+	})
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("stages are stopped in order", func(t *testing.T) {
+		var mu sync.Mutex
+		var stopped []string
+
+		lifecycle := service.NewLifecycle(time.Second, utils.NewNopZapLogger())
+		lifecycle.Stage("api").Add(stopRecorder("http", &mu, &stopped), stopRecorder("ws", &mu, &stopped))
+		lifecycle.Stage("sync").Add(stopRecorder("sync", &mu, &stopped))
+		lifecycle.Stage("monitoring").Add(stopRecorder("metrics", &mu, &stopped))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, lifecycle.Run(ctx))
+
+		require.Len(t, stopped, 4)
+		assert.ElementsMatch(t, []string{"http", "ws"}, stopped[:2])
+		assert.Equal(t, []string{"sync", "metrics"}, stopped[2:])
+	})
+
+	t.Run("service error stops all services", func(t *testing.T) {
+		errService := errors.New("service failed")
+		var mu sync.Mutex
+		var stopped []string
+
+		lifecycle := service.NewLifecycle(time.Second, utils.NewNopZapLogger())
+		lifecycle.Stage("api").Add(stopRecorder("http", &mu, &stopped))
+		lifecycle.Stage("sync").Add(serviceFunc(func(context.Context) error {
+			return errService
+		}))
+
+		require.ErrorIs(t, lifecycle.Run(context.Background()), errService)
+		assert.Equal(t, []string{"http"}, stopped)
+	})
+
+	t.Run("stage that does not stop in time", func(t *testing.T) {
+		stuck := make(chan struct{})
+		t.Cleanup(func() { close(stuck) })
+
+		lifecycle := service.NewLifecycle(10*time.Millisecond, utils.NewNopZapLogger())
+		lifecycle.Stage("sync").Add(serviceFunc(func(context.Context) error {
+			<-stuck
+			return nil
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, lifecycle.Run(ctx), service.ErrStopTimeout)
+	})
+}
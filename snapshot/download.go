@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ChecksumSuffix is appended to the name of a snapshot to name the file that holds its SHA-256 checksum,
+// in the format of sha256sum
+const ChecksumSuffix = ".sha256"
+
+// Download downloads url to path. If path holds the beginning of url from an interrupted download, only the
+// rest is downloaded.
+func Download(ctx context.Context, client *http.Client, url, path string) (err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// the server does not support ranges, or there was nothing to resume
+		if err = file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the file is complete
+		return nil
+	default:
+		return fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the file at path
+func Checksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ParseChecksum returns the checksum in the contents of a checksum file
+func ParseChecksum(contents string) (string, error) {
+	fields := strings.Fields(contents)
+	if len(fields) == 0 || len(fields[0]) != 2*sha256.Size {
+		return "", errors.New("malformed checksum file")
+	}
+	return strings.ToLower(fields[0]), nil
+}
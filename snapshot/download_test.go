@@ -0,0 +1,89 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	contents := bytes.Repeat([]byte("snapshot"), 1000)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if r.URL.Path == "/no-ranges" {
+			_, err := w.Write(contents)
+			require.NoError(t, err)
+			return
+		}
+		http.ServeContent(w, r, "snapshot.tar", time.Time{}, bytes.NewReader(contents))
+	}))
+	defer srv.Close()
+
+	t.Run("new download", func(t *testing.T) {
+		ranges = nil
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, snapshot.Download(context.Background(), srv.Client(), srv.URL+"/snapshot.tar", path))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, contents, got)
+		assert.Equal(t, []string{""}, ranges)
+	})
+
+	t.Run("resumed download", func(t *testing.T) {
+		ranges = nil
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path, contents[:1234], 0o600))
+		require.NoError(t, snapshot.Download(context.Background(), srv.Client(), srv.URL+"/snapshot.tar", path))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, contents, got)
+		assert.Equal(t, []string{"bytes=1234-"}, ranges)
+	})
+
+	t.Run("complete download", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path, contents, 0o600))
+		require.NoError(t, snapshot.Download(context.Background(), srv.Client(), srv.URL+"/snapshot.tar", path))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, contents, got)
+	})
+
+	t.Run("server without ranges", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path, []byte("partial"), 0o600))
+		require.NoError(t, snapshot.Download(context.Background(), srv.Client(), srv.URL+"/no-ranges", path))
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, contents, got)
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path, contents, 0o600))
+		sum := sha256.Sum256(contents)
+		want := hex.EncodeToString(sum[:])
+
+		got, err := snapshot.Checksum(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		parsed, err := snapshot.ParseChecksum(want + "  snapshot.tar\n")
+		require.NoError(t, err)
+		assert.Equal(t, want, parsed)
+		_, err = snapshot.ParseChecksum("1234  snapshot.tar\n")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,255 @@
+// Package snapshot creates and imports snapshots of the database of a node, so that a new node can start
+// from a recent block instead of syncing the whole chain.
+//
+// A snapshot is a tarball whose first file is the metadata of the snapshot, followed by a checkpoint of the
+// database. The head of the chain is verified against the state trie before a snapshot is created and
+// after it is imported.
+package snapshot
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/utils"
+)
+
+const (
+	metadataName = "snapshot.json"
+	dbDir        = "db"
+
+	// importCacheSize is the size of the block cache of the database opened to verify an imported snapshot
+	importCacheSize = 8 << 20
+)
+
+// Metadata describes the chain held by a snapshot
+type Metadata struct {
+	Network     string     `json:"network"`
+	BlockNumber uint64     `json:"block_number"`
+	BlockHash   *felt.Felt `json:"block_hash"`
+	StateRoot   *felt.Felt `json:"state_root"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// checkpointer is implemented by the databases that can be snapshotted
+type checkpointer interface {
+	Checkpoint(dir string) error
+}
+
+// Create verifies the head of the chain in database and writes a snapshot of it to w
+func Create(database db.DB, network utils.Network, w io.Writer) (*Metadata, error) {
+	checkpointDB, ok := database.(checkpointer)
+	if !ok {
+		return nil, errors.New("database does not support checkpoints")
+	}
+
+	metadata, err := verify(database, network)
+	if err != nil {
+		return nil, err
+	}
+	metadata.CreatedAt = time.Now().UTC()
+
+	tmp, err := os.MkdirTemp("", "juno-snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// the checkpoint is taken after the verification, this only holds because nothing writes to database
+	checkpoint := filepath.Join(tmp, dbDir)
+	if err = checkpointDB.Checkpoint(checkpoint); err != nil {
+		return nil, fmt.Errorf("checkpoint database: %w", err)
+	}
+
+	tarWriter := tar.NewWriter(w)
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = writeFile(tarWriter, metadataName, metadataJSON); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err = copyToTar(tarWriter, filepath.Join(checkpoint, entry.Name()), dbDir+"/"+entry.Name()); err != nil {
+			return nil, fmt.Errorf("write %s: %w", entry.Name(), err)
+		}
+	}
+	return metadata, tarWriter.Close()
+}
+
+// verify checks that the state root of the head of the chain is the root of the state trie
+func verify(database db.DB, network utils.Network) (*Metadata, error) {
+	chain := blockchain.New(database, network, utils.NewNopZapLogger())
+	head, err := chain.HeadsHeader()
+	if err != nil {
+		return nil, fmt.Errorf("read head: %w", err)
+	}
+	root, err := chain.StateCommitment()
+	if err != nil {
+		return nil, fmt.Errorf("compute state root: %w", err)
+	}
+	if !root.Equal(head.GlobalStateRoot) {
+		return nil, fmt.Errorf("state root %s does not match the root of block %d %s", root, head.Number,
+			head.GlobalStateRoot)
+	}
+	return &Metadata{
+		Network:     network.String(),
+		BlockNumber: head.Number,
+		BlockHash:   head.Hash,
+		StateRoot:   head.GlobalStateRoot,
+	}, nil
+}
+
+func writeFile(tarWriter *tar.Writer, name string, contents []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tarWriter.Write(contents)
+	return err
+}
+
+func copyToTar(tarWriter *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+// Import writes the database of the snapshot read from r to dbPath, which must not exist or be empty, and
+// verifies it. Nothing is left at dbPath if the snapshot is not of network or cannot be verified.
+func Import(r io.Reader, dbPath string, network utils.Network, log utils.Logger) (*Metadata, error) {
+	if entries, err := os.ReadDir(dbPath); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("%s is not empty", dbPath)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	tmp := strings.TrimSuffix(dbPath, string(filepath.Separator)) + ".import"
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	metadata, err := extract(r, tmp, network)
+	if err == nil {
+		err = verifyImport(tmp, network, metadata, log)
+	}
+	if err != nil {
+		return nil, errors.Join(err, os.RemoveAll(tmp))
+	}
+
+	// dbPath is empty if it exists
+	if err = os.RemoveAll(dbPath); err != nil {
+		return nil, err
+	}
+	return metadata, os.Rename(tmp, dbPath)
+}
+
+func extract(r io.Reader, dir string, network utils.Network) (*Metadata, error) {
+	tarReader := tar.NewReader(r)
+	header, err := tarReader.Next()
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	if header.Name != metadataName {
+		return nil, fmt.Errorf("not a snapshot: the first file is %s instead of %s", header.Name, metadataName)
+	}
+	metadata := new(Metadata)
+	if err = json.NewDecoder(tarReader).Decode(metadata); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	if metadata.Network != network.String() {
+		return nil, fmt.Errorf("snapshot is of network %s, not %s", metadata.Network, network)
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	for {
+		header, err = tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return metadata, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("read snapshot: %w", err)
+		}
+
+		name, found := strings.CutPrefix(header.Name, dbDir+"/")
+		if !found || !filepath.IsLocal(name) || header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected file %s in snapshot", header.Name)
+		}
+		if err = extractFile(tarReader, filepath.Join(dir, name)); err != nil {
+			return nil, fmt.Errorf("extract %s: %w", header.Name, err)
+		}
+	}
+}
+
+func extractFile(r io.Reader, path string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+	_, err = io.Copy(file, r)
+	return err
+}
+
+// verifyImport checks that the database extracted to dir holds the chain described by metadata
+func verifyImport(dir string, network utils.Network, metadata *Metadata, log utils.Logger) (err error) {
+	database, err := pebble.New(dir, importCacheSize, log)
+	if err != nil {
+		return fmt.Errorf("open imported database: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, database.Close())
+	}()
+
+	got, err := verify(database, network)
+	if err != nil {
+		return err
+	}
+	if got.BlockNumber != metadata.BlockNumber || !got.BlockHash.Equal(metadata.BlockHash) {
+		return fmt.Errorf("head of imported database is block %d %s instead of %d %s", got.BlockNumber,
+			got.BlockHash, metadata.BlockNumber, metadata.BlockHash)
+	}
+	return nil
+}
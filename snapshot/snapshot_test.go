@@ -0,0 +1,121 @@
+package snapshot_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/snapshot"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB returns a database at a temporary path that holds the first blocks of mainnet
+func newTestDB(t *testing.T) db.DB {
+	t.Helper()
+
+	database, err := pebble.New(t.TempDir(), 1<<20, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, database.Close())
+	})
+
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.MAINNET))
+	chain := blockchain.New(database, utils.MAINNET, utils.NewNopZapLogger())
+	for i := uint64(0); i < 3; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, nil))
+	}
+	return database
+}
+
+func TestCreateImport(t *testing.T) {
+	database := newTestDB(t)
+
+	var buf bytes.Buffer
+	metadata, err := snapshot.Create(database, utils.MAINNET, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, "mainnet", metadata.Network)
+	assert.Equal(t, uint64(2), metadata.BlockNumber)
+
+	head, err := blockchain.New(database, utils.MAINNET, utils.NewNopZapLogger()).HeadsHeader()
+	require.NoError(t, err)
+	assert.Equal(t, head.Hash, metadata.BlockHash)
+	assert.Equal(t, head.GlobalStateRoot, metadata.StateRoot)
+	snapshotBytes := buf.Bytes()
+
+	t.Run("import into an empty path", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "juno")
+		imported, err := snapshot.Import(bytes.NewReader(snapshotBytes), dbPath, utils.MAINNET, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		assert.Equal(t, metadata.BlockHash, imported.BlockHash)
+
+		importedDB, err := pebble.New(dbPath, 1<<20, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		defer importedDB.Close()
+		importedHead, err := blockchain.New(importedDB, utils.MAINNET, utils.NewNopZapLogger()).HeadsHeader()
+		require.NoError(t, err)
+		assert.Equal(t, head, importedHead)
+	})
+
+	t.Run("import of another network", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "juno")
+		_, err := snapshot.Import(bytes.NewReader(snapshotBytes), dbPath, utils.GOERLI, utils.NewNopZapLogger())
+		require.ErrorContains(t, err, "snapshot is of network mainnet, not goerli")
+
+		entries, err := os.ReadDir(filepath.Dir(dbPath))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("import into a non-empty path", func(t *testing.T) {
+		dbPath := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dbPath, "file"), nil, 0o600))
+		_, err := snapshot.Import(bytes.NewReader(snapshotBytes), dbPath, utils.MAINNET, utils.NewNopZapLogger())
+		require.ErrorContains(t, err, "is not empty")
+	})
+
+	t.Run("import of a tarball with files outside of the database", func(t *testing.T) {
+		var malicious bytes.Buffer
+		tarWriter := tar.NewWriter(&malicious)
+		tarReader := tar.NewReader(bytes.NewReader(snapshotBytes))
+		header, err := tarReader.Next()
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err = io.Copy(tarWriter, tarReader)
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "db/../../escaped", Mode: 0o600, Typeflag: tar.TypeReg}))
+		require.NoError(t, tarWriter.Close())
+
+		dir := t.TempDir()
+		_, err = snapshot.Import(&malicious, filepath.Join(dir, "juno"), utils.MAINNET, utils.NewNopZapLogger())
+		require.ErrorContains(t, err, "unexpected file")
+		_, err = os.Stat(filepath.Join(dir, "..", "escaped"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("not a snapshot", func(t *testing.T) {
+		_, err := snapshot.Import(bytes.NewReader([]byte("not a tarball")), filepath.Join(t.TempDir(), "juno"),
+			utils.MAINNET, utils.NewNopZapLogger())
+		require.Error(t, err)
+	})
+}
+
+func TestCreateEmptyDB(t *testing.T) {
+	_, err := snapshot.Create(pebble.NewMemTest(), utils.MAINNET, new(bytes.Buffer))
+	require.Error(t, err)
+}
@@ -0,0 +1,281 @@
+// Package statediff streams the normalized state diff of every block as it is stored, so that the clients that
+// index the state follow the chain without polling for state updates. A stream resumes from any stored block and
+// tells its client which blocks were reverted when the head is reorganised.
+package statediff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/utils"
+)
+
+const (
+	// headPollInterval is how frequently the head is checked for new blocks
+	headPollInterval = time.Second
+	// maxReorgDepth is how many of the blocks it sent a stream remembers to find where a reorg started
+	maxReorgDepth = 128
+	// FromBlockParam is the query parameter of the block a stream starts from
+	FromBlockParam = "from_block"
+)
+
+// ErrReorgTooDeep is returned when more blocks than a stream remembers were reverted
+var ErrReorgTooDeep = errors.New("too many blocks were reverted to resume the stream")
+
+type MessageType string
+
+const (
+	// StateDiffMessage carries the state diff of a block
+	StateDiffMessage MessageType = "state_diff"
+	// ReorgMessage tells that the block of the message and the blocks that follow it were reverted. The stream
+	// continues with the state diffs of the blocks that replace them.
+	ReorgMessage MessageType = "reorg"
+)
+
+// Message is a message of a stream
+type Message struct {
+	Type        MessageType `json:"type"`
+	BlockNumber uint64      `json:"block_number"`
+	*Diff
+}
+
+// Diff is the state diff of a block, in which the entries are sorted by address, key and class hash
+type Diff struct {
+	BlockHash         *felt.Felt      `json:"block_hash"`
+	ParentHash        *felt.Felt      `json:"parent_hash"`
+	OldRoot           *felt.Felt      `json:"old_root"`
+	NewRoot           *felt.Felt      `json:"new_root"`
+	StorageWrites     []StorageWrite  `json:"storage_writes"`
+	Nonces            []Nonce         `json:"nonces"`
+	DeclaredClasses   []DeclaredClass `json:"declared_classes"`
+	DeployedContracts []Contract      `json:"deployed_contracts"`
+	ReplacedClasses   []Contract      `json:"replaced_classes"`
+}
+
+type StorageWrite struct {
+	Address *felt.Felt `json:"address"`
+	Key     *felt.Felt `json:"key"`
+	Value   *felt.Felt `json:"value"`
+}
+
+type Nonce struct {
+	Address *felt.Felt `json:"address"`
+	Nonce   *felt.Felt `json:"nonce"`
+}
+
+// DeclaredClass is a declared class, the compiled class hash of which is only set for Cairo 1 classes
+type DeclaredClass struct {
+	ClassHash         *felt.Felt `json:"class_hash"`
+	CompiledClassHash *felt.Felt `json:"compiled_class_hash,omitempty"`
+}
+
+type Contract struct {
+	Address   *felt.Felt `json:"address"`
+	ClassHash *felt.Felt `json:"class_hash"`
+}
+
+// Streamer streams the state diffs of the blocks of a chain
+type Streamer struct {
+	chain blockchain.Reader
+	log   utils.SimpleLogger
+}
+
+func New(chain blockchain.Reader, log utils.SimpleLogger) *Streamer {
+	return &Streamer{
+		chain: chain,
+		log:   log,
+	}
+}
+
+// Stream sends the state diffs of the blocks from the block of the from_block query parameter, or from the block
+// after the head without one, until ctx is cancelled. It is served as a jsonrpc.Stream.
+func (s *Streamer) Stream(ctx context.Context, query url.Values, send func(msg []byte) error) error {
+	var from uint64
+	if fromBlock := query.Get(FromBlockParam); fromBlock != "" {
+		var err error
+		if from, err = strconv.ParseUint(fromBlock, 10, 64); err != nil {
+			return fmt.Errorf("invalid %s: %w", FromBlockParam, err)
+		}
+	} else {
+		height, err := s.chain.Height()
+		if err == nil {
+			from = height + 1
+		} else if !errors.Is(err, db.ErrKeyNotFound) {
+			return err
+		}
+	}
+
+	return s.stream(ctx, from, func(msg *Message) error {
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return send(encoded)
+	})
+}
+
+func (s *Streamer) stream(ctx context.Context, from uint64, send func(*Message) error) error {
+	next := from
+	// sent holds the hashes of the last blocks that were sent, by number
+	sent := make(map[uint64]*felt.Felt)
+	for {
+		reverted, err := s.reverted(from, next, sent)
+		if err != nil {
+			return err
+		}
+		if reverted < next {
+			s.log.Debugw("Streaming reorg", "from", reverted, "to", next-1)
+			if err = send(&Message{Type: ReorgMessage, BlockNumber: reverted}); err != nil {
+				return err
+			}
+			next = reverted
+		}
+
+		for ; ; next++ {
+			diff, err := s.diff(next)
+			if errors.Is(err, db.ErrKeyNotFound) {
+				break
+			} else if err != nil {
+				return err
+			}
+			if parent, ok := sent[next-1]; ok && !parent.Equal(diff.ParentHash) {
+				// the head was reverted since it was checked
+				break
+			}
+			if err = send(&Message{Type: StateDiffMessage, BlockNumber: next, Diff: diff}); err != nil {
+				return err
+			}
+			sent[next] = diff.BlockHash
+			delete(sent, next-maxReorgDepth)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(headPollInterval):
+		}
+	}
+}
+
+// reverted returns the first of the sent blocks before next that is no longer stored, or next if they all are.
+// The stream started from the block from.
+func (s *Streamer) reverted(from, next uint64, sent map[uint64]*felt.Felt) (uint64, error) {
+	first := next
+	for ; first > from; first-- {
+		hash, ok := sent[first-1]
+		if !ok {
+			// the block was sent so long ago that it is no longer remembered
+			return 0, ErrReorgTooDeep
+		}
+		header, err := s.chain.BlockHeaderByNumber(first - 1)
+		if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+			return 0, err
+		} else if err == nil && header.Hash.Equal(hash) {
+			break
+		}
+		delete(sent, first-1)
+	}
+	return first, nil
+}
+
+func (s *Streamer) diff(number uint64) (*Diff, error) {
+	header, err := s.chain.BlockHeaderByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	update, err := s.chain.StateUpdateByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if !update.BlockHash.Equal(header.Hash) {
+		// the block was replaced between the reads
+		return nil, db.ErrKeyNotFound
+	}
+	return normalize(header, update), nil
+}
+
+func normalize(header *core.Header, update *core.StateUpdate) *Diff {
+	stateDiff := update.StateDiff
+	diff := &Diff{
+		BlockHash:         header.Hash,
+		ParentHash:        header.ParentHash,
+		OldRoot:           update.OldRoot,
+		NewRoot:           update.NewRoot,
+		StorageWrites:     []StorageWrite{},
+		Nonces:            make([]Nonce, 0, len(stateDiff.Nonces)),
+		DeclaredClasses:   make([]DeclaredClass, 0, len(stateDiff.DeclaredV0Classes)+len(stateDiff.DeclaredV1Classes)),
+		DeployedContracts: make([]Contract, 0, len(stateDiff.DeployedContracts)),
+		ReplacedClasses:   make([]Contract, 0, len(stateDiff.ReplacedClasses)),
+	}
+
+	for address, storageDiffs := range stateDiff.StorageDiffs {
+		address := address
+		for _, storageDiff := range storageDiffs {
+			diff.StorageWrites = append(diff.StorageWrites, StorageWrite{
+				Address: &address,
+				Key:     storageDiff.Key,
+				Value:   storageDiff.Value,
+			})
+		}
+	}
+	sort.SliceStable(diff.StorageWrites, func(i, j int) bool {
+		a, b := diff.StorageWrites[i], diff.StorageWrites[j]
+		if cmp := a.Address.Cmp(b.Address); cmp != 0 {
+			return cmp < 0
+		}
+		return a.Key.Cmp(b.Key) < 0
+	})
+
+	for address, nonce := range stateDiff.Nonces {
+		address := address
+		diff.Nonces = append(diff.Nonces, Nonce{Address: &address, Nonce: nonce})
+	}
+	sort.Slice(diff.Nonces, func(i, j int) bool {
+		return diff.Nonces[i].Address.Cmp(diff.Nonces[j].Address) < 0
+	})
+
+	for _, classHash := range stateDiff.DeclaredV0Classes {
+		diff.DeclaredClasses = append(diff.DeclaredClasses, DeclaredClass{ClassHash: classHash})
+	}
+	for _, declared := range stateDiff.DeclaredV1Classes {
+		diff.DeclaredClasses = append(diff.DeclaredClasses, DeclaredClass{
+			ClassHash:         declared.ClassHash,
+			CompiledClassHash: declared.CompiledClassHash,
+		})
+	}
+	sort.Slice(diff.DeclaredClasses, func(i, j int) bool {
+		return diff.DeclaredClasses[i].ClassHash.Cmp(diff.DeclaredClasses[j].ClassHash) < 0
+	})
+
+	for _, deployed := range stateDiff.DeployedContracts {
+		diff.DeployedContracts = append(diff.DeployedContracts, Contract{
+			Address:   deployed.Address,
+			ClassHash: deployed.ClassHash,
+		})
+	}
+	sortContracts(diff.DeployedContracts)
+	for _, replaced := range stateDiff.ReplacedClasses {
+		diff.ReplacedClasses = append(diff.ReplacedClasses, Contract{
+			Address:   replaced.Address,
+			ClassHash: replaced.ClassHash,
+		})
+	}
+	sortContracts(diff.ReplacedClasses)
+	return diff
+}
+
+func sortContracts(contracts []Contract) {
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Address.Cmp(contracts[j].Address) < 0
+	})
+}
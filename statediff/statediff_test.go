@@ -0,0 +1,128 @@
+package statediff_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/statediff"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	blocks := make([]*core.Block, 4)
+	stateUpdates := make([]*core.StateUpdate, len(blocks))
+	for i := range blocks {
+		var err error
+		blocks[i], err = gw.BlockByNumber(context.Background(), uint64(i))
+		require.NoError(t, err)
+		stateUpdates[i], err = gw.StateUpdate(context.Background(), uint64(i))
+		require.NoError(t, err)
+	}
+	store := func(i int) {
+		require.NoError(t, chain.Store(blocks[i], &core.BlockCommitments{}, stateUpdates[i], nil))
+	}
+	for i := 0; i < 3; i++ {
+		store(i)
+	}
+
+	streamer := statediff.New(chain, utils.NewNopZapLogger())
+
+	t.Run("invalid from block", func(t *testing.T) {
+		err := streamer.Stream(context.Background(), url.Values{statediff.FromBlockParam: {"head"}},
+			func([]byte) error { return nil })
+		require.Error(t, err)
+	})
+
+	messages := make(chan statediff.Message, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- streamer.Stream(ctx, url.Values{statediff.FromBlockParam: {"1"}}, func(msg []byte) error {
+			var message statediff.Message
+			if err := json.Unmarshal(msg, &message); err != nil {
+				return err
+			}
+			messages <- message
+			return nil
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	next := func() statediff.Message {
+		select {
+		case message := <-messages:
+			return message
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "no message was streamed")
+			return statediff.Message{}
+		}
+	}
+	expectDiff := func(number uint64) {
+		message := next()
+		require.Equal(t, statediff.StateDiffMessage, message.Type)
+		require.Equal(t, number, message.BlockNumber)
+		assert.Equal(t, blocks[number].Hash, message.BlockHash)
+		assert.Equal(t, blocks[number].ParentHash, message.ParentHash)
+		assert.Equal(t, stateUpdates[number].NewRoot, message.NewRoot)
+
+		stateDiff := stateUpdates[number].StateDiff
+		var storageWrites int
+		for _, storageDiffs := range stateDiff.StorageDiffs {
+			storageWrites += len(storageDiffs)
+		}
+		assert.Len(t, message.StorageWrites, storageWrites)
+		for i := 1; i < len(message.StorageWrites); i++ {
+			assert.LessOrEqual(t, message.StorageWrites[i-1].Address.Cmp(message.StorageWrites[i].Address), 0)
+		}
+		assert.Len(t, message.Nonces, len(stateDiff.Nonces))
+		assert.Len(t, message.DeclaredClasses, len(stateDiff.DeclaredV0Classes)+len(stateDiff.DeclaredV1Classes))
+		assert.Len(t, message.DeployedContracts, len(stateDiff.DeployedContracts))
+		assert.Len(t, message.ReplacedClasses, len(stateDiff.ReplacedClasses))
+	}
+
+	t.Run("stored blocks are streamed from the from block", func(t *testing.T) {
+		expectDiff(1)
+		expectDiff(2)
+	})
+
+	t.Run("new blocks are streamed as they are stored", func(t *testing.T) {
+		store(3)
+		expectDiff(3)
+	})
+
+	t.Run("reverted blocks are streamed again", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		require.NoError(t, chain.RevertHead())
+		// the reverts may be streamed separately
+		message := next()
+		for message.Type == statediff.ReorgMessage && message.BlockNumber > 2 {
+			message = next()
+		}
+		assert.Equal(t, statediff.ReorgMessage, message.Type)
+		assert.Equal(t, uint64(2), message.BlockNumber)
+		assert.Nil(t, message.Diff)
+
+		store(2)
+		expectDiff(2)
+	})
+}
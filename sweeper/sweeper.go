@@ -0,0 +1,54 @@
+// Package sweeper deletes the expired entries of the buckets of transient data, such as the pending block, the
+// submitted transactions and the event filters, so that they do not accumulate in the database, see
+// db.RegisterExpiry.
+package sweeper
+
+import (
+	"context"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ jobs.Job = (*Sweeper)(nil)
+
+// SweepInterval is how frequently the transient buckets should be swept
+const SweepInterval = 10 * time.Minute
+
+// Sweeper sweeps the transient buckets of a database
+type Sweeper struct {
+	database db.DB
+	log      utils.SimpleLogger
+
+	// metrics
+	swept prometheus.Counter
+}
+
+// New returns a Sweeper of the transient buckets of database
+func New(database db.DB, log utils.SimpleLogger) *Sweeper {
+	s := &Sweeper{
+		database: database,
+		log:      log,
+		swept: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "sweeper",
+			Name:      "expired",
+			Help:      "The number of the expired entries of the transient buckets that were deleted",
+		}),
+	}
+	metrics.MustRegister(s.swept)
+	return s
+}
+
+// Step sweeps the transient buckets, the next sweep is due after SweepInterval
+func (s *Sweeper) Step(ctx context.Context) (bool, error) {
+	swept, err := db.SweepExpired(ctx, s.database, time.Now())
+	if swept > 0 {
+		s.swept.Add(float64(swept))
+		s.log.Debugw("Deleted the expired transient data", "entries", swept)
+	}
+	return false, err
+}
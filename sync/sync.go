@@ -3,16 +3,21 @@ package sync
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/NethermindEth/juno/blockchain"
 	"github.com/NethermindEth/juno/core"
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/forensics"
 	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/plugin"
 	"github.com/NethermindEth/juno/service"
 	"github.com/NethermindEth/juno/starknetdata"
+	"github.com/NethermindEth/juno/tracing"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/conc/stream"
@@ -33,9 +38,16 @@ type Synchronizer struct {
 	StartingBlockNumber *uint64
 	HighestBlockHeader  *core.Header
 
-	log utils.SimpleLogger
+	log   utils.SimpleLogger
+	hooks *plugin.Hooks
 
-	pendingPollInterval time.Duration
+	// the state diffs that do not result in the root of their block are reported to forensicsDir, once per block
+	forensicsDB  db.DB
+	forensicsDir string
+	dumpedBlock  *uint64
+
+	pendingPollInterval atomic.Int64 // time.Duration
+	pendingPollChanged  chan struct{}
 
 	catchUpMode bool
 
@@ -48,10 +60,10 @@ func New(bc *blockchain.Blockchain, starkNetData starknetdata.StarknetData,
 	log utils.SimpleLogger, pendingPollInterval time.Duration,
 ) *Synchronizer {
 	s := &Synchronizer{
-		Blockchain:          bc,
-		StarknetData:        starkNetData,
-		log:                 log,
-		pendingPollInterval: pendingPollInterval,
+		Blockchain:         bc,
+		StarknetData:       starkNetData,
+		log:                log,
+		pendingPollChanged: make(chan struct{}, 1),
 
 		opTimers: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "sync",
@@ -62,10 +74,35 @@ func New(bc *blockchain.Blockchain, starkNetData starknetdata.StarknetData,
 			Name:      "blocks",
 		}),
 	}
+	s.pendingPollInterval.Store(int64(pendingPollInterval))
 	metrics.MustRegister(s.opTimers, s.totalBlocks)
 	return s
 }
 
+// WithHooks notifies the plugins of hooks of the blocks that are stored and reverted
+func (s *Synchronizer) WithHooks(hooks *plugin.Hooks) *Synchronizer {
+	s.hooks = hooks
+	return s
+}
+
+// WithForensics writes a forensic report of the blocks whose state root does not match the state of database to a
+// new file in dir, see forensics.Dump
+func (s *Synchronizer) WithForensics(database db.DB, dir string) *Synchronizer {
+	s.forensicsDB = database
+	s.forensicsDir = dir
+	return s
+}
+
+// SetPendingPollInterval changes how frequently the pending block is polled, zero disables polling.
+// It takes effect while the Synchronizer is running.
+func (s *Synchronizer) SetPendingPollInterval(interval time.Duration) {
+	s.pendingPollInterval.Store(int64(interval))
+	select {
+	case s.pendingPollChanged <- struct{}{}:
+	default:
+	}
+}
+
 // Run starts the Synchronizer, returns an error if the loop is already running
 func (s *Synchronizer) Run(ctx context.Context) error {
 	s.syncBlocks(ctx)
@@ -159,7 +196,9 @@ func (s *Synchronizer) verifierTask(ctx context.Context, block *core.Block, stat
 	newClasses map[felt.Felt]core.Class, resetStreams context.CancelFunc,
 ) stream.Callback {
 	timer := prometheus.NewTimer(s.opTimers.WithLabelValues(opVerifyLabel))
+	_, verifySpan := tracing.StartSpan(ctx, "sync.verify", tracing.BlockNumber(block.Number))
 	commitments, err := s.Blockchain.SanityCheckNewHeight(block, stateUpdate, newClasses)
+	tracing.EndSpan(verifySpan, err)
 	timer.ObserveDuration()
 	return func() {
 		select {
@@ -172,7 +211,9 @@ func (s *Synchronizer) verifierTask(ctx context.Context, block *core.Block, stat
 				return
 			}
 			timer := prometheus.NewTimer(s.opTimers.WithLabelValues(opStoreLabel))
-			err = s.Blockchain.Store(block, commitments, stateUpdate, newClasses)
+			storeCtx, storeSpan := tracing.StartSpan(ctx, "sync.store", tracing.BlockNumber(block.Number))
+			err = s.Blockchain.StoreContext(storeCtx, block, commitments, stateUpdate, newClasses)
+			tracing.EndSpan(storeSpan, err)
 			timer.ObserveDuration()
 
 			if err != nil {
@@ -182,6 +223,9 @@ func (s *Synchronizer) verifierTask(ctx context.Context, block *core.Block, stat
 					// blocks
 					s.revertHead(block)
 				} else {
+					if errors.Is(err, core.ErrMismatchedRoot) {
+						err = s.dumpForensics(block, stateUpdate, newClasses, err)
+					}
 					s.log.Warnw("Failed storing Block", "number", block.Number,
 						"hash", block.Hash.ShortString(), "err", err)
 				}
@@ -189,6 +233,7 @@ func (s *Synchronizer) verifierTask(ctx context.Context, block *core.Block, stat
 				return
 			}
 			s.totalBlocks.Inc()
+			s.hooks.NewBlock(block, stateUpdate)
 
 			if s.HighestBlockHeader == nil || s.HighestBlockHeader.Number <= block.Number {
 				highestBlock, err := s.StarknetData.BlockLatest(ctx)
@@ -211,6 +256,26 @@ func (s *Synchronizer) verifierTask(ctx context.Context, block *core.Block, stat
 	}
 }
 
+// dumpForensics writes the forensic report of block, whose state update failed with err, unless it was written
+// already. It returns err with the path of the report.
+func (s *Synchronizer) dumpForensics(block *core.Block, stateUpdate *core.StateUpdate,
+	newClasses map[felt.Felt]core.Class, err error,
+) error {
+	if s.forensicsDB == nil || (s.dumpedBlock != nil && *s.dumpedBlock == block.Number) {
+		return err
+	}
+	number := block.Number
+	s.dumpedBlock = &number
+
+	path, dumpErr := forensics.Dump(s.forensicsDB, block, stateUpdate, newClasses, err, s.forensicsDir)
+	if dumpErr != nil {
+		s.log.Warnw("Failed to write the forensic report of the state root mismatch", "number", block.Number,
+			"err", dumpErr)
+		return err
+	}
+	return fmt.Errorf("%w (forensic report: %s)", err, path)
+}
+
 func (s *Synchronizer) nextHeight() uint64 {
 	nextHeight := uint64(0)
 	if h, err := s.Blockchain.Height(); err == nil {
@@ -256,7 +321,9 @@ func (s *Synchronizer) syncBlocks(syncCtx context.Context) {
 			curHeight, curStreamCtx, curCancel := nextHeight, streamCtx, streamCancel
 			fetchers.Go(func() stream.Callback {
 				timer := prometheus.NewTimer(s.opTimers.WithLabelValues(opFetchLabel))
-				cb := s.fetcherTask(curStreamCtx, curHeight, verifiers, curCancel)
+				fetchCtx, fetchSpan := tracing.StartSpan(curStreamCtx, "sync.fetch", tracing.BlockNumber(curHeight))
+				cb := s.fetcherTask(fetchCtx, curHeight, verifiers, curCancel)
+				fetchSpan.End()
 				timer.ObserveDuration()
 				return cb
 			})
@@ -287,21 +354,42 @@ func (s *Synchronizer) revertHead(forkBlock *core.Block) {
 		s.log.Warnw("Failed reverting HEAD", "reverted", localHead, "err", err)
 	} else {
 		s.log.Infow("Reverted HEAD", "reverted", localHead)
+		if head != nil {
+			s.hooks.RevertBlock(head)
+		}
 	}
 }
 
 func (s *Synchronizer) pollPending(ctx context.Context, sem chan struct{}) {
-	if s.pendingPollInterval == time.Duration(0) {
-		return
+	for {
+		// a nil tick channel blocks forever, so polling is paused while the interval is zero
+		var pendingPollTicker *time.Ticker
+		var tick <-chan time.Time
+		if interval := time.Duration(s.pendingPollInterval.Load()); interval > 0 {
+			pendingPollTicker = time.NewTicker(interval)
+			tick = pendingPollTicker.C
+		}
+
+		keepPolling := s.waitAndPollPending(ctx, sem, tick)
+		if pendingPollTicker != nil {
+			pendingPollTicker.Stop()
+		}
+		if !keepPolling {
+			return
+		}
 	}
+}
 
-	pendingPollTicker := time.NewTicker(s.pendingPollInterval)
+// waitAndPollPending polls the pending block on every tick until the poll interval changes,
+// it returns false once ctx is cancelled
+func (s *Synchronizer) waitAndPollPending(ctx context.Context, sem chan struct{}, tick <-chan time.Time) bool {
 	for {
 		select {
 		case <-ctx.Done():
-			pendingPollTicker.Stop()
-			return
-		case <-pendingPollTicker.C:
+			return false
+		case <-s.pendingPollChanged:
+			return true
+		case <-tick:
 			select {
 			case sem <- struct{}{}:
 				go func() {
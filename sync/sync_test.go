@@ -13,6 +13,7 @@ import (
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/db/pebble"
 	"github.com/NethermindEth/juno/mocks"
+	"github.com/NethermindEth/juno/plugin"
 	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
 	"github.com/NethermindEth/juno/sync"
 	"github.com/NethermindEth/juno/utils"
@@ -176,7 +177,9 @@ func TestReorg(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, utils.HexToFelt(t, "0x34e815552e42c5eb5233b99de2d3d7fd396e575df2719bf98e7ed2794494f86"), head.Hash)
 
-		synchronizer = sync.New(bc, mainGw, utils.NewNopZapLogger(), time.Duration(0))
+		recorder := new(blockRecorder)
+		hooks := plugin.NewHooks([]plugin.Plugin{recorder}, utils.NewNopZapLogger())
+		synchronizer = sync.New(bc, mainGw, utils.NewNopZapLogger(), time.Duration(0)).WithHooks(hooks)
 		ctx, cancel = context.WithTimeout(context.Background(), timeout)
 		require.NoError(t, synchronizer.Run(ctx))
 		cancel()
@@ -185,9 +188,36 @@ func TestReorg(t *testing.T) {
 		head, err = bc.HeadsHeader()
 		require.NoError(t, err)
 		require.Equal(t, utils.HexToFelt(t, "0x4e1f77f39545afe866ac151ac908bd1a347a2a8a7d58bef1276db4f06fdf2f6"), head.Hash)
+
+		// the plugins are told about the reverted integration blocks and the mainnet blocks that replaced them
+		require.NotEmpty(t, recorder.reverted)
+		assert.Equal(t, utils.HexToFelt(t, "0x34e815552e42c5eb5233b99de2d3d7fd396e575df2719bf98e7ed2794494f86"),
+			recorder.reverted[0].Hash)
+		assert.Equal(t, []uint64{0, 1, 2}, recorder.stored)
 	})
 }
 
+// blockRecorder is a plugin that records the numbers of the blocks that are stored and the headers of
+// those that are reverted
+type blockRecorder struct {
+	stored   []uint64
+	reverted []*core.Header
+}
+
+func (r *blockRecorder) Name() string {
+	return "recorder"
+}
+
+func (r *blockRecorder) NewBlock(block *core.Block, _ *core.StateUpdate) error {
+	r.stored = append(r.stored, block.Number)
+	return nil
+}
+
+func (r *blockRecorder) RevertBlock(reverted *core.Header) error {
+	r.reverted = append(r.reverted, reverted)
+	return nil
+}
+
 func TestPending(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,169 @@
+// Package telemetry periodically reports anonymous statistics about a node, such as its version, network
+// and sync height, to an endpoint chosen by its operator. It is only enabled when an endpoint is configured.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+)
+
+var _ service.Service = (*Reporter)(nil)
+
+const (
+	requestTimeout = 30 * time.Second
+	mebibyte       = 1 << 20
+)
+
+// Report is what is sent to the endpoint. It holds no address, path or configuration of the node. Instance
+// only tells apart the reports of different runs, it is random and changes every time the node starts.
+type Report struct {
+	Instance      string  `json:"instance"`
+	Version       string  `json:"version"`
+	Network       string  `json:"network"`
+	Height        *uint64 `json:"height"`
+	BlocksPerHour uint64  `json:"blocks_per_hour"`
+	UptimeHours   uint64  `json:"uptime_hours"`
+	OS            string  `json:"os"`
+	Arch          string  `json:"arch"`
+	CPUs          int     `json:"cpus"`
+	MemoryMiB     uint64  `json:"memory_mib"`
+}
+
+// HeightReader returns the height of the chain
+type HeightReader interface {
+	Height() (uint64, error)
+}
+
+// Reporter sends a Report to an endpoint every interval
+type Reporter struct {
+	endpoint string
+	interval time.Duration
+	chain    HeightReader
+	network  string
+	version  string
+	instance string
+	client   *http.Client
+	log      utils.SimpleLogger
+
+	started    time.Time
+	lastHeight *uint64
+	lastReport time.Time
+}
+
+// New returns a Reporter that sends its reports to endpoint, a http(s) URL
+func New(endpoint string, interval time.Duration, chain HeightReader, network utils.Network, version string,
+	log utils.SimpleLogger,
+) (*Reporter, error) {
+	if endpointURL, err := url.Parse(endpoint); err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") {
+		return nil, fmt.Errorf("telemetry endpoint %q is not a http(s) URL", endpoint)
+	}
+	instance := make([]byte, 16)
+	if _, err := rand.Read(instance); err != nil {
+		return nil, err
+	}
+
+	networkName := network.String()
+	if _, custom := network.Definition(); custom {
+		// the names of custom networks may identify their operators
+		networkName = "custom"
+	}
+	return &Reporter{
+		endpoint: endpoint,
+		interval: interval,
+		chain:    chain,
+		network:  networkName,
+		version:  version,
+		instance: hex.EncodeToString(instance),
+		client:   &http.Client{Timeout: requestTimeout},
+		log:      log,
+		started:  time.Now(),
+	}, nil
+}
+
+// Run sends a report when it starts and then every interval, until ctx is cancelled. Reports that cannot be
+// sent are dropped.
+func (r *Reporter) Run(ctx context.Context) error {
+	r.log.Infow("Sending anonymous telemetry", "endpoint", r.endpoint, "interval", r.interval)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.send(ctx, r.Report()); err != nil && ctx.Err() == nil {
+			r.log.Debugw("Failed to send telemetry", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Report returns the report of the node at this time, the sync rate is measured since the previous report
+func (r *Reporter) Report() *Report {
+	now := time.Now()
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	report := &Report{
+		Instance:    r.instance,
+		Version:     r.version,
+		Network:     r.network,
+		UptimeHours: uint64(now.Sub(r.started).Hours()),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		CPUs:        runtime.NumCPU(),
+		MemoryMiB:   memStats.Sys / mebibyte,
+	}
+
+	height, err := r.chain.Height()
+	if err != nil {
+		if !errors.Is(err, db.ErrKeyNotFound) {
+			r.log.Debugw("Failed to read height for telemetry", "err", err)
+		}
+		return report
+	}
+	report.Height = &height
+	if r.lastHeight != nil && height > *r.lastHeight {
+		if elapsed := now.Sub(r.lastReport); elapsed > 0 {
+			report.BlocksPerHour = uint64(float64(height-*r.lastHeight) / elapsed.Hours())
+		}
+	}
+	r.lastHeight, r.lastReport = &height, now
+	return report
+}
+
+func (r *Reporter) send(ctx context.Context, report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
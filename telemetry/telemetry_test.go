@@ -0,0 +1,88 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/telemetry"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type height struct {
+	height uint64
+	err    error
+}
+
+func (h *height) Height() (uint64, error) {
+	return h.height, h.err
+}
+
+func TestReporter(t *testing.T) {
+	reports := make(chan telemetry.Report, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		var report telemetry.Report
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		reports <- report
+	}))
+	t.Cleanup(srv.Close)
+
+	chain := &height{height: 10}
+	reporter, err := telemetry.New(srv.URL, time.Hour, chain, utils.GOERLI, "v0.5.0", utils.NewNopZapLogger())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- reporter.Run(ctx)
+	}()
+
+	report := <-reports
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Len(t, report.Instance, 32)
+	assert.Equal(t, "v0.5.0", report.Version)
+	assert.Equal(t, "goerli", report.Network)
+	require.NotNil(t, report.Height)
+	assert.Equal(t, uint64(10), *report.Height)
+	assert.NotZero(t, report.CPUs)
+
+	t.Run("sync rate is measured between reports", func(t *testing.T) {
+		chain.height = 20
+		next := reporter.Report()
+		assert.NotZero(t, next.BlocksPerHour)
+	})
+
+	t.Run("empty database", func(t *testing.T) {
+		chain.err = db.ErrKeyNotFound
+		assert.Nil(t, reporter.Report().Height)
+	})
+}
+
+func TestCustomNetworkName(t *testing.T) {
+	network, err := utils.RegisterNetwork(utils.NetworkDefinition{
+		Name:       "telemetry-appchain",
+		ChainID:    "SN_TELEMETRY",
+		FeederURL:  "https://feeder.example/feeder_gateway/",
+		GatewayURL: "https://feeder.example/gateway/",
+	})
+	require.NoError(t, err)
+
+	reporter, err := telemetry.New("https://telemetry.example/report", time.Hour, &height{}, network, "",
+		utils.NewNopZapLogger())
+	require.NoError(t, err)
+	assert.Equal(t, "custom", reporter.Report().Network)
+}
+
+func TestInvalidEndpoint(t *testing.T) {
+	_, err := telemetry.New("localhost:8080", time.Hour, &height{}, utils.MAINNET, "", utils.NewNopZapLogger())
+	require.Error(t, err)
+}
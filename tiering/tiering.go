@@ -0,0 +1,279 @@
+// Package tiering moves the data of the old blocks, which is rarely read, to the cold database of a tiered.DB. The
+// data is classified into categories, each of which is moved once it is older than the age its policy sets, and the
+// cold data that is read frequently is moved back to the hot database.
+package tiering
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/tiered"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/jobs"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ jobs.Job = (*Mover)(nil)
+
+const (
+	// MoveInterval is how frequently the data should be classified
+	MoveInterval = time.Minute
+	// demoteBatch is the number of keys moved to the cold database per transaction, the hot database is locked for
+	// writes while they are moved
+	demoteBatch = 10_000
+	// promoteReads is the number of reads from the cold database within a MoveInterval after which a key is moved
+	// back to the hot database
+	promoteReads = 16
+	// promotionGrace is for how long a key moved back to the hot database is not moved to the cold one again
+	promotionGrace = time.Hour
+)
+
+// category is a kind of data that is moved to the cold database once it is old
+type category struct {
+	prefixes [][]byte
+	// block returns the number of the block the entry that it is positioned at was stored with
+	block func(it db.Iterator) (uint64, error)
+	// sorted is whether the entries under the prefixes are sorted by block
+	sorted bool
+}
+
+// blockNumberKey returns the block number that the keys of the buckets keyed by block number start with
+func blockNumberKey(it db.Iterator) (uint64, error) {
+	key := it.Key()
+	if len(key) < 9 {
+		return 0, fmt.Errorf("key %x is not keyed by block number", key)
+	}
+	return binary.BigEndian.Uint64(key[1:9]), nil
+}
+
+// declaredAt returns the block number a class was declared at
+func declaredAt(it db.Iterator) (uint64, error) {
+	value, err := it.Value()
+	if err != nil {
+		return 0, err
+	}
+	// the class itself is not decoded
+	var declared struct {
+		At uint64
+	}
+	if err = encoder.Unmarshal(value, &declared); err != nil {
+		return 0, err
+	}
+	return declared.At, nil
+}
+
+// categories are the categories of data by name
+var categories = map[string]*category{
+	// the transactions and the receipts of the blocks
+	"bodies": {
+		prefixes: [][]byte{db.TransactionsByBlockNumberAndIndex.Key(), db.ReceiptsByBlockNumberAndIndex.Key()},
+		block:    blockNumberKey,
+		sorted:   true,
+	},
+	"state-updates": {
+		prefixes: [][]byte{db.StateUpdatesByBlockNumber.Key()},
+		block:    blockNumberKey,
+		sorted:   true,
+	},
+	"classes": {
+		prefixes: [][]byte{db.Class.Key()},
+		block:    declaredAt,
+	},
+}
+
+// Policy is the age, in blocks behind the head, after which the data of a category is moved to the cold database.
+// The data of the categories that are not in the policy is kept in the hot database.
+type Policy map[string]uint64
+
+// ParsePolicy parses a policy from category=age pairs
+func ParsePolicy(pairs []string) (Policy, error) {
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policy := make(Policy, len(pairs))
+	for _, pair := range pairs {
+		name, ageStr, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("tiering policy %q is not a category=age pair", pair)
+		}
+		if _, ok := categories[name]; !ok {
+			return nil, fmt.Errorf("unknown data category %q, the categories are %s", name, strings.Join(names, ", "))
+		}
+		if _, ok := policy[name]; ok {
+			return nil, fmt.Errorf("data category %q is set twice", name)
+		}
+		age, err := strconv.ParseUint(ageStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("age of data category %q: %w", name, err)
+		}
+		policy[name] = age
+	}
+	return policy, nil
+}
+
+// Prefixes returns the prefixes of the keys that the policy may move to the cold database
+func (p Policy) Prefixes() [][]byte {
+	var prefixes [][]byte
+	for name := range p {
+		prefixes = append(prefixes, categories[name].prefixes...)
+	}
+	return prefixes
+}
+
+// Mover moves the data of a tiered.DB between its databases according to a Policy
+type Mover struct {
+	database *tiered.DB
+	chain    *blockchain.Blockchain
+	policy   Policy
+	log      utils.SimpleLogger
+
+	// promoted are the keys moved back to the hot database, by when they may be moved to the cold one again
+	promoted map[string]time.Time
+
+	// metrics
+	moved *prometheus.CounterVec
+}
+
+// New returns a Mover that moves the data of chain, which is stored in database, according to policy
+func New(database *tiered.DB, chain *blockchain.Blockchain, policy Policy, log utils.SimpleLogger) *Mover {
+	m := &Mover{
+		database: database,
+		chain:    chain,
+		policy:   policy,
+		log:      log,
+		promoted: make(map[string]time.Time),
+		moved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "tiering",
+			Name:      "moved",
+			Help:      "The number of keys moved between the databases, by the database they were moved to",
+		}, []string{"to"}),
+	}
+	metrics.MustRegister(m.moved)
+	return m
+}
+
+// Step moves the data, the next move is due after MoveInterval
+func (m *Mover) Step(ctx context.Context) (bool, error) {
+	return false, m.Move(ctx)
+}
+
+// Move moves the cold data that was read frequently since the last move back to the hot database, and then the data
+// that is older than its policy to the cold database
+func (m *Mover) Move(ctx context.Context) error {
+	if err := m.promote(); err != nil {
+		return fmt.Errorf("move to the hot database: %w", err)
+	}
+
+	height, err := m.chain.Height()
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for name, age := range m.policy {
+		if height < age {
+			continue
+		}
+		if err = m.demote(ctx, categories[name], height-age); err != nil {
+			return fmt.Errorf("move %s to the cold database: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Mover) promote() error {
+	now := time.Now()
+	for key, until := range m.promoted {
+		if now.After(until) {
+			delete(m.promoted, key)
+		}
+	}
+
+	var keys [][]byte
+	for key, reads := range m.database.ColdReads() {
+		if reads >= promoteReads {
+			keys = append(keys, []byte(key))
+			m.promoted[key] = now.Add(promotionGrace)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := m.database.Promote(keys); err != nil {
+		return err
+	}
+	m.moved.WithLabelValues("hot").Add(float64(len(keys)))
+	return nil
+}
+
+// demote moves the entries of c stored before the block before to the cold database, in batches
+func (m *Mover) demote(ctx context.Context, c *category, before uint64) error {
+	for ctx.Err() == nil {
+		keys, err := m.coldKeys(c, before)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if err = m.database.Demote(keys); err != nil {
+			return err
+		}
+		m.moved.WithLabelValues("cold").Add(float64(len(keys)))
+		m.log.Debugw("Moved data to the cold database", "keys", len(keys))
+		if len(keys) < demoteBatch {
+			return nil
+		}
+	}
+	return nil
+}
+
+// coldKeys returns up to demoteBatch keys of the entries of c in the hot database that were stored before the block
+// before
+func (m *Mover) coldKeys(c *category, before uint64) ([][]byte, error) {
+	var keys [][]byte
+	err := m.database.Hot().View(func(txn db.Transaction) error {
+		it, err := txn.NewIterator()
+		if err != nil {
+			return err
+		}
+		for _, prefix := range c.prefixes {
+			for it.Seek(prefix); it.Valid() && len(keys) < demoteBatch; it.Next() {
+				key := it.Key()
+				if !bytes.HasPrefix(key, prefix) {
+					break
+				}
+				block, err := c.block(it)
+				if err != nil {
+					return db.CloseAndWrapOnError(it.Close, err)
+				}
+				if block >= before {
+					if c.sorted {
+						break
+					}
+					continue
+				}
+				if _, ok := m.promoted[string(key)]; ok {
+					continue
+				}
+				keys = append(keys, key)
+			}
+		}
+		return it.Close()
+	})
+	return keys, err
+}
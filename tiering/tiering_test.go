@@ -0,0 +1,133 @@
+package tiering_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	"github.com/NethermindEth/juno/db/tiered"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/tiering"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := tiering.ParsePolicy([]string{"bodies=100", "classes=0"})
+	require.NoError(t, err)
+	assert.Equal(t, tiering.Policy{"bodies": 100, "classes": 0}, policy)
+	assert.Len(t, policy.Prefixes(), 3)
+
+	for _, invalid := range [][]string{{"bodies"}, {"traces=1"}, {"bodies=-1"}, {"bodies=1", "bodies=2"}} {
+		_, err = tiering.ParsePolicy(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMover(t *testing.T) {
+	policy := tiering.Policy{"bodies": 2, "state-updates": 2, "classes": 2}
+	hot, cold := pebble.NewMemTest(), pebble.NewMemTest()
+	tieredDB := tiered.New(hot, cold, policy.Prefixes())
+	t.Cleanup(func() {
+		require.NoError(t, tieredDB.Close())
+	})
+	chain := blockchain.New(tieredDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	const blocks = 5
+	// declaredAt are the blocks the classes were first declared at
+	declaredAt := make(map[felt.Felt]uint64)
+	for i := uint64(0); i < blocks; i++ {
+		block, err := gw.BlockByNumber(context.Background(), i)
+		require.NoError(t, err)
+		stateUpdate, err := gw.StateUpdate(context.Background(), i)
+		require.NoError(t, err)
+		// the classes of the test blocks are not fetched, their contents do not matter
+		classes := make(map[felt.Felt]core.Class)
+		for _, deployed := range stateUpdate.StateDiff.DeployedContracts {
+			classes[*deployed.ClassHash] = &core.Cairo0Class{}
+			if _, ok := declaredAt[*deployed.ClassHash]; !ok {
+				declaredAt[*deployed.ClassHash] = i
+			}
+		}
+		require.NoError(t, chain.Store(block, &core.BlockCommitments{}, stateUpdate, classes))
+	}
+	require.NotEmpty(t, declaredAt)
+
+	has := func(database db.DB, key []byte) bool {
+		err := database.View(func(txn db.Transaction) error {
+			return txn.Get(key, func([]byte) error { return nil })
+		})
+		if err != nil {
+			require.ErrorIs(t, err, db.ErrKeyNotFound)
+		}
+		return err == nil
+	}
+	blockKey := func(bucket db.Bucket, number uint64) []byte {
+		return bucket.Key(binary.BigEndian.AppendUint64(nil, number))
+	}
+	txKey := func(number uint64) []byte {
+		return db.TransactionsByBlockNumberAndIndex.Key(binary.BigEndian.AppendUint64(nil, number), make([]byte, 8))
+	}
+
+	mover := tiering.New(tieredDB, chain, policy, utils.NewNopZapLogger())
+	require.NoError(t, mover.Move(context.Background()))
+
+	// the head is the last block, the data of the blocks before head-2 is old
+	old := func(number uint64) bool {
+		return number < blocks-1-2
+	}
+
+	t.Run("data older than the policy is moved to the cold database", func(t *testing.T) {
+		for number := uint64(0); number < blocks; number++ {
+			assert.Equal(t, old(number), has(cold, txKey(number)), number)
+			assert.Equal(t, !old(number), has(hot, txKey(number)), number)
+			assert.Equal(t, old(number), has(cold, blockKey(db.StateUpdatesByBlockNumber, number)), number)
+			assert.Equal(t, !old(number), has(hot, blockKey(db.StateUpdatesByBlockNumber, number)), number)
+		}
+		for classHash, number := range declaredAt {
+			classKey := db.Class.Key(classHash.Marshal())
+			assert.Equal(t, old(number), has(cold, classKey), number)
+			assert.Equal(t, !old(number), has(hot, classKey), number)
+		}
+	})
+
+	t.Run("cold data is read transparently", func(t *testing.T) {
+		for number := uint64(0); number < blocks; number++ {
+			block, err := chain.BlockByNumber(number)
+			require.NoError(t, err)
+			assert.Equal(t, number, block.Number)
+			_, err = chain.StateUpdateByNumber(number)
+			require.NoError(t, err)
+		}
+		_, closer, err := chain.HeadState()
+		require.NoError(t, err)
+		require.NoError(t, closer())
+	})
+
+	t.Run("frequently read cold data is moved back to the hot database", func(t *testing.T) {
+		var classKey []byte
+		for classHash, number := range declaredAt {
+			if old(number) {
+				classKey = db.Class.Key(classHash.Marshal())
+			}
+		}
+		require.NotNil(t, classKey)
+		require.False(t, has(hot, classKey))
+		for i := 0; i < 100; i++ {
+			require.True(t, has(tieredDB, classKey))
+		}
+		require.NoError(t, mover.Move(context.Background()))
+		assert.True(t, has(hot, classKey))
+
+		require.NoError(t, mover.Move(context.Background()))
+		assert.True(t, has(hot, classKey), "promoted data is not moved to the cold database right away")
+	})
+}
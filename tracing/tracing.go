@@ -0,0 +1,118 @@
+// Package tracing wires Juno's OpenTelemetry instrumentation to an OTLP exporter.
+//
+// Instrumented code always creates spans through Tracer. Until an Exporter is created the global
+// tracer provider is a no-op, so spans cost next to nothing when tracing is disabled.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/NethermindEth/juno"
+	shutdownTimeout     = 5 * time.Second
+)
+
+// Tracer returns the tracer used to instrument Juno
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartSpan starts a span named name as a child of any span in ctx
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err, if any, on span and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// BlockNumber is the attribute that identifies the block a span operates on
+func BlockNumber(number uint64) attribute.KeyValue {
+	return attribute.Int64("block.number", int64(number))
+}
+
+var _ service.Service = (*Exporter)(nil)
+
+// Exporter sends the spans created through Tracer to an OTLP collector over gRPC
+type Exporter struct {
+	provider *sdktrace.TracerProvider
+	log      utils.SimpleLogger
+}
+
+// New creates an Exporter and installs it as the global tracer provider.
+//
+// - endpoint is the host:port of the OTLP gRPC collector
+// - insecure disables TLS towards the collector
+// - sampleRatio is the fraction of root spans that are sampled, in the [0, 1] range
+func New(endpoint string, insecure bool, sampleRatio float64, version string, log utils.SimpleLogger) (*Exporter, error) {
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio %v is not in the [0, 1] range", sampleRatio)
+	}
+
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	// The client connects lazily, so an unreachable collector does not prevent the node from starting.
+	exporter, err := otlptracegrpc.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String("juno"),
+		semconv.ServiceVersionKey.String(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warnw("OpenTelemetry error", "err", err)
+	}))
+
+	return &Exporter{
+		provider: provider,
+		log:      log,
+	}, nil
+}
+
+// Run waits for ctx to be cancelled and flushes the spans that have not been exported yet
+func (e *Exporter) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.provider.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
@@ -2,7 +2,12 @@ package utils
 
 import (
 	"encoding"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/pebble"
@@ -11,7 +16,10 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var ErrUnknownLogLevel = errors.New("unknown log level (known: debug, info, warn, error)")
+var (
+	ErrUnknownLogLevel = errors.New("unknown log level (known: debug, info, warn, error)")
+	ErrUnknownModule   = errors.New("unknown log module")
+)
 
 type LogLevel int
 
@@ -81,14 +89,21 @@ type SimpleLogger interface {
 	Errorw(msg string, keysAndValues ...any)
 }
 
+// recentLogsSize is the number of entries kept in memory for diagnostics
+const recentLogsSize = 1000
+
 type ZapLogger struct {
 	*zap.SugaredLogger
+	// base logs at every level, the levels are enforced by the core of the SugaredLogger
+	base   *zap.Logger
+	levels *levels
+	recent *recentLogs
 }
 
 var _ Logger = (*ZapLogger)(nil)
 
 func NewNopZapLogger() *ZapLogger {
-	return &ZapLogger{zap.NewNop().Sugar()}
+	return newZapLogger(zap.NewNop(), zap.NewAtomicLevel(), nil)
 }
 
 func NewZapLogger(logLevel LogLevel, colour bool) (*ZapLogger, error) {
@@ -101,17 +116,286 @@ func NewZapLogger(logLevel LogLevel, colour bool) (*ZapLogger, error) {
 	config.EncoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 		enc.AppendString(t.Local().Format("15:04:05.000 02/01/2006 -07:00"))
 	}
-	level, err := zapcore.ParseLevel(logLevel.String())
+	level, err := zapLevel(logLevel)
 	if err != nil {
 		return nil, err
 	}
-	config.Level.SetLevel(level)
-	log, err := config.Build()
+	config.Level.SetLevel(zapcore.DebugLevel)
+
+	recent := newRecentLogs(recentLogsSize)
+	recentEncoderConfig := config.EncoderConfig
+	recentEncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	log, err := config.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, zapcore.NewCore(zapcore.NewConsoleEncoder(recentEncoderConfig),
+			zapcore.AddSync(recent), zapcore.DebugLevel))
+	}))
 	if err != nil {
 		return nil, err
 	}
 
-	return &ZapLogger{log.Sugar()}, nil
+	return newZapLogger(log, zap.NewAtomicLevelAt(level), recent), nil
+}
+
+func newZapLogger(base *zap.Logger, global zap.AtomicLevel, recent *recentLogs) *ZapLogger {
+	levels := &levels{global: global, modules: make(map[string]*zapcore.Level)}
+	return &ZapLogger{
+		SugaredLogger: base.WithOptions(zap.WrapCore(levels.filter(""))).Sugar(),
+		base:          base,
+		levels:        levels,
+		recent:        recent,
+	}
+}
+
+// Module returns a logger that tags its entries with the given module name. By default, it logs at the
+// level of l, which can be overridden with SetModuleLevel to debug a single subsystem.
+func (l *ZapLogger) Module(name string) *ZapLogger {
+	l.levels.register(name)
+	base := l.base.With(zap.String("module", name))
+	return &ZapLogger{
+		SugaredLogger: base.WithOptions(zap.WrapCore(l.levels.filter(name))).Sugar(),
+		base:          base,
+		levels:        l.levels,
+		recent:        l.recent,
+	}
+}
+
+// RecentLogs returns the last entries logged by the logger or any logger derived from it, oldest first
+func (l *ZapLogger) RecentLogs() []string {
+	if l.recent == nil {
+		return nil
+	}
+	return l.recent.lines()
+}
+
+// SetLevel changes the level of the logger and of every logger derived from it, except for the modules
+// whose level was overridden
+func (l *ZapLogger) SetLevel(logLevel LogLevel) error {
+	level, err := zapLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	l.levels.global.SetLevel(level)
+	return nil
+}
+
+// SetModuleLevel overrides the level of the loggers of a module
+func (l *ZapLogger) SetModuleLevel(module string, logLevel LogLevel) error {
+	level, err := zapLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	return l.levels.set(module, &level)
+}
+
+// ResetModuleLevel makes the loggers of a module follow the level of the logger they were derived from
+func (l *ZapLogger) ResetModuleLevel(module string) error {
+	return l.levels.set(module, nil)
+}
+
+// Level returns the level of the logger the modules were derived from
+func (l *ZapLogger) Level() LogLevel {
+	return logLevel(l.levels.global.Level())
+}
+
+// ModuleLevels returns the level each module currently logs at
+func (l *ZapLogger) ModuleLevels() map[string]LogLevel {
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+
+	moduleLevels := make(map[string]LogLevel, len(l.levels.modules))
+	for module, level := range l.levels.modules {
+		if level == nil {
+			moduleLevels[module] = logLevel(l.levels.global.Level())
+		} else {
+			moduleLevels[module] = logLevel(*level)
+		}
+	}
+	return moduleLevels
+}
+
+func zapLevel(logLevel LogLevel) (zapcore.Level, error) {
+	if logLevel < DEBUG || logLevel > ERROR {
+		return 0, ErrUnknownLogLevel
+	}
+	return zapcore.ParseLevel(logLevel.String())
+}
+
+func logLevel(level zapcore.Level) LogLevel {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return DEBUG
+	case level == zapcore.InfoLevel:
+		return INFO
+	case level == zapcore.WarnLevel:
+		return WARN
+	default:
+		return ERROR
+	}
+}
+
+// levels are shared by a logger and the module loggers derived from it
+type levels struct {
+	global zap.AtomicLevel
+
+	mu      sync.RWMutex
+	modules map[string]*zapcore.Level // nil if the module follows the global level
+}
+
+func (ls *levels) register(module string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if _, ok := ls.modules[module]; !ok {
+		ls.modules[module] = nil
+	}
+}
+
+func (ls *levels) set(module string, level *zapcore.Level) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if _, ok := ls.modules[module]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownModule, module)
+	}
+	ls.modules[module] = level
+	return nil
+}
+
+func (ls *levels) enabled(module string, level zapcore.Level) bool {
+	if module != "" {
+		ls.mu.RLock()
+		moduleLevel := ls.modules[module]
+		ls.mu.RUnlock()
+		if moduleLevel != nil {
+			return moduleLevel.Enabled(level)
+		}
+	}
+	return ls.global.Enabled(level)
+}
+
+func (ls *levels) filter(module string) func(zapcore.Core) zapcore.Core {
+	return func(core zapcore.Core) zapcore.Core {
+		return &filterCore{Core: core, enabled: func(level zapcore.Level) bool {
+			return ls.enabled(module, level)
+		}}
+	}
+}
+
+// filterCore drops the entries below the level of its module before they reach the wrapped core
+type filterCore struct {
+	zapcore.Core
+	enabled zap.LevelEnablerFunc
+}
+
+func (c *filterCore) Enabled(level zapcore.Level) bool {
+	return c.enabled(level)
+}
+
+func (c *filterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &filterCore{Core: c.Core.With(fields), enabled: c.enabled}
+}
+
+func (c *filterCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+type levelsResponse struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+type levelRequest struct {
+	// Module is empty to change the level of every module that is not overridden
+	Module string `json:"module"`
+	// Level is empty to reset an overridden module
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an HTTP handler that serves the current levels on GET and changes the level of
+// the logger or of a single module on PUT, with a body such as {"module": "sync", "level": "debug"}
+func (l *ZapLogger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var body levelRequest
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := l.applyLevelRequest(body); err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp := levelsResponse{Level: l.Level().String(), Modules: make(map[string]string)}
+		for module, level := range l.ModuleLevels() {
+			resp.Modules[module] = level.String()
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(resp); err != nil {
+			l.Debugw("Failed to write log levels", "err", err)
+		}
+	})
+}
+
+func (l *ZapLogger) applyLevelRequest(body levelRequest) error {
+	if body.Level == "" {
+		if body.Module == "" {
+			return ErrUnknownLogLevel
+		}
+		return l.ResetModuleLevel(body.Module)
+	}
+
+	var level LogLevel
+	if err := level.Set(body.Level); err != nil {
+		return err
+	}
+	if body.Module == "" {
+		return l.SetLevel(level)
+	}
+	return l.SetModuleLevel(body.Module, level)
+}
+
+// recentLogs is a ring buffer of the entries written to it
+type recentLogs struct {
+	mu      sync.Mutex
+	entries []string
+	next    int
+	full    bool
+}
+
+func newRecentLogs(size int) *recentLogs {
+	return &recentLogs{entries: make([]string, size)}
+}
+
+// Write stores p as a single entry, zap writes each encoded entry with one call
+func (r *recentLogs) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = strings.TrimSuffix(string(p), "\n")
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return len(p), nil
+}
+
+func (r *recentLogs) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]string(nil), r.entries[:r.next]...)
+	}
+	return append(append(make([]string, 0, len(r.entries)), r.entries[r.next:]...), r.entries[:r.next]...)
 }
 
 func (l *ZapLogger) Warningf(msg string, args ...any) {
@@ -1,12 +1,16 @@
 package utils_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/NethermindEth/juno/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
 )
 
 var levelStrings = map[utils.LogLevel]string{
@@ -93,3 +97,105 @@ func TestZapWithoutColour(t *testing.T) {
 		})
 	}
 }
+
+func TestZapSetLevel(t *testing.T) {
+	log, err := utils.NewZapLogger(utils.INFO, false)
+	require.NoError(t, err)
+	assert.False(t, log.Desugar().Core().Enabled(zapcore.DebugLevel))
+
+	require.NoError(t, log.SetLevel(utils.DEBUG))
+	assert.True(t, log.Desugar().Core().Enabled(zapcore.DebugLevel))
+
+	require.NoError(t, log.SetLevel(utils.ERROR))
+	assert.False(t, log.Desugar().Core().Enabled(zapcore.WarnLevel))
+}
+
+func TestZapModuleLevels(t *testing.T) {
+	log, err := utils.NewZapLogger(utils.INFO, false)
+	require.NoError(t, err)
+	syncLog := log.Module("sync")
+	rpcLog := log.Module("rpc")
+
+	require.ErrorIs(t, log.SetModuleLevel("trie", utils.DEBUG), utils.ErrUnknownModule)
+
+	require.NoError(t, log.SetModuleLevel("sync", utils.DEBUG))
+	assert.True(t, syncLog.Desugar().Core().Enabled(zapcore.DebugLevel))
+	assert.False(t, rpcLog.Desugar().Core().Enabled(zapcore.DebugLevel))
+	assert.False(t, log.Desugar().Core().Enabled(zapcore.DebugLevel))
+	assert.Equal(t, map[string]utils.LogLevel{"sync": utils.DEBUG, "rpc": utils.INFO}, log.ModuleLevels())
+
+	t.Run("global level does not change overridden modules", func(t *testing.T) {
+		require.NoError(t, log.SetLevel(utils.ERROR))
+		assert.True(t, syncLog.Desugar().Core().Enabled(zapcore.DebugLevel))
+		assert.False(t, rpcLog.Desugar().Core().Enabled(zapcore.WarnLevel))
+	})
+
+	t.Run("reset module", func(t *testing.T) {
+		require.NoError(t, log.ResetModuleLevel("sync"))
+		assert.False(t, syncLog.Desugar().Core().Enabled(zapcore.WarnLevel))
+		assert.Equal(t, map[string]utils.LogLevel{"sync": utils.ERROR, "rpc": utils.ERROR}, log.ModuleLevels())
+	})
+}
+
+func TestZapLevelHandler(t *testing.T) {
+	log, err := utils.NewZapLogger(utils.INFO, false)
+	require.NoError(t, err)
+	log.Module("sync")
+	handler := log.LevelHandler()
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, "/", strings.NewReader(body)))
+		return recorder
+	}
+
+	resp := serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"level": "info", "modules": {"sync": "info"}}`, resp.Body.String())
+
+	resp = serve(http.MethodPut, `{"module": "sync", "level": "debug"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"level": "info", "modules": {"sync": "debug"}}`, resp.Body.String())
+
+	resp = serve(http.MethodPut, `{"level": "warn"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"level": "warn", "modules": {"sync": "debug"}}`, resp.Body.String())
+
+	resp = serve(http.MethodPut, `{"module": "sync"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"level": "warn", "modules": {"sync": "warn"}}`, resp.Body.String())
+
+	for _, body := range []string{`{"module": "db", "level": "debug"}`, `{"module": "sync", "level": "verbose"}`, `{}`, `{`} {
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, body).Code, body)
+	}
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "").Code)
+}
+
+func TestZapRecentLogs(t *testing.T) {
+	log, err := utils.NewZapLogger(utils.INFO, true)
+	require.NoError(t, err)
+	syncLog := log.Module("sync")
+
+	log.Debugw("not logged")
+	log.Infow("first")
+	syncLog.Warnw("second", "number", 1)
+
+	recent := log.RecentLogs()
+	require.Len(t, recent, 2)
+	assert.Contains(t, recent[0], "INFO\t")
+	assert.Contains(t, recent[0], "first")
+	assert.NotContains(t, recent[0], "\x1b[", "recent logs are not coloured")
+	assert.Contains(t, recent[1], "second")
+	assert.Contains(t, recent[1], `"module": "sync", "number": 1`)
+
+	// distinct messages, so that they are not sampled
+	for i := 0; i < 2000; i++ {
+		log.Infow(fmt.Sprintf("entry %d", i))
+	}
+	recent = syncLog.RecentLogs()
+	require.Len(t, recent, 1000)
+	assert.Contains(t, recent[0], "entry 1000")
+	assert.Contains(t, recent[999], "entry 1999")
+
+	assert.Empty(t, utils.NewNopZapLogger().RecentLogs())
+}
@@ -3,7 +3,11 @@ package utils
 import (
 	"encoding"
 	"errors"
+	"fmt"
 	"math/big"
+	"net/url"
+	"strings"
+	"sync"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/ethereum/go-ethereum/common"
@@ -28,7 +32,91 @@ const (
 	INTEGRATION
 )
 
+// NetworkDefinition describes a network that is not built into Juno, such as an appchain or a new testnet,
+// so that it can be synced by registering it from the configuration.
+type NetworkDefinition struct {
+	Name       string `mapstructure:"name"`
+	ChainID    string `mapstructure:"chain-id"`
+	FeederURL  string `mapstructure:"feeder-url"`
+	GatewayURL string `mapstructure:"gateway-url"`
+	L1ChainID  uint64 `mapstructure:"l1-chain-id"`
+	// CoreContractAddress is the address of the Starknet core contract on L1, L1 verification is not
+	// available if it is empty
+	CoreContractAddress string `mapstructure:"core-contract-address"`
+	// UnverifiableRange is the first and last block whose hash cannot be verified, if any
+	UnverifiableRange []uint64 `mapstructure:"unverifiable-range"`
+	// TrustTransactionHashes and TrustBlockHashes skip the verification of the transaction and block hashes, see
+	// Verification
+	TrustTransactionHashes bool `mapstructure:"trust-transaction-hashes"`
+	TrustBlockHashes       bool `mapstructure:"trust-block-hashes"`
+}
+
+// customNetworks holds the registered network definitions, Network(INTEGRATION+1+i) is customNetworks[i]
+var (
+	customNetworksMu sync.RWMutex
+	customNetworks   []NetworkDefinition
+)
+
+// RegisterNetwork makes the network described by def available to Set. Registering a definition with
+// the name of a registered network replaces it and returns the same Network.
+func RegisterNetwork(def NetworkDefinition) (Network, error) {
+	if err := def.validate(); err != nil {
+		return 0, fmt.Errorf("network %q: %w", def.Name, err)
+	}
+	def.UnverifiableRange = append([]uint64(nil), def.UnverifiableRange...)
+
+	customNetworksMu.Lock()
+	defer customNetworksMu.Unlock()
+	for i, registered := range customNetworks {
+		if strings.EqualFold(registered.Name, def.Name) {
+			customNetworks[i] = def
+			return INTEGRATION + 1 + Network(i), nil
+		}
+	}
+	customNetworks = append(customNetworks, def)
+	return INTEGRATION + Network(len(customNetworks)), nil
+}
+
+func (d *NetworkDefinition) validate() error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	var builtin Network
+	if builtin.setBuiltin(d.Name) == nil {
+		return errors.New("name is taken by a built-in network")
+	}
+	if d.ChainID == "" {
+		return errors.New("chain-id is required")
+	}
+	for field, rawURL := range map[string]string{"feeder-url": d.FeederURL, "gateway-url": d.GatewayURL} {
+		if rawURL == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		if _, err := url.ParseRequestURI(rawURL); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	if d.CoreContractAddress != "" && !common.IsHexAddress(d.CoreContractAddress) {
+		return fmt.Errorf("core-contract-address %q is not an address", d.CoreContractAddress)
+	}
+	return validateRange(d.UnverifiableRange)
+}
+
+// Definition returns the definition n was registered with, if it is not a built-in network
+func (n Network) Definition() (NetworkDefinition, bool) {
+	customNetworksMu.RLock()
+	defer customNetworksMu.RUnlock()
+	i := int(n - INTEGRATION - 1)
+	if n <= INTEGRATION || i >= len(customNetworks) {
+		return NetworkDefinition{}, false
+	}
+	return customNetworks[i], true
+}
+
 func (n Network) String() string {
+	if def, ok := n.Definition(); ok {
+		return def.Name
+	}
 	switch n {
 	case MAINNET:
 		return "mainnet"
@@ -45,6 +133,22 @@ func (n Network) String() string {
 }
 
 func (n *Network) Set(s string) error {
+	if n.setBuiltin(s) == nil {
+		return nil
+	}
+
+	customNetworksMu.RLock()
+	defer customNetworksMu.RUnlock()
+	for i, def := range customNetworks {
+		if strings.EqualFold(def.Name, s) {
+			*n = INTEGRATION + 1 + Network(i)
+			return nil
+		}
+	}
+	return ErrUnknownNetwork
+}
+
+func (n *Network) setBuiltin(s string) error {
 	switch s {
 	case "MAINNET", "mainnet":
 		*n = MAINNET
@@ -87,15 +191,31 @@ func (n Network) baseURL() string {
 
 // FeederURL returns URL for read commands
 func (n Network) FeederURL() string {
+	if def, ok := n.Definition(); ok {
+		return withTrailingSlash(def.FeederURL)
+	}
 	return n.baseURL() + "feeder_gateway/"
 }
 
 // GatewayURL returns URL for write commands
 func (n Network) GatewayURL() string {
+	if def, ok := n.Definition(); ok {
+		return withTrailingSlash(def.GatewayURL)
+	}
 	return n.baseURL() + "gateway/"
 }
 
+func withTrailingSlash(s string) string {
+	if strings.HasSuffix(s, "/") {
+		return s
+	}
+	return s + "/"
+}
+
 func (n Network) ChainIDString() string {
+	if def, ok := n.Definition(); ok {
+		return def.ChainID
+	}
 	switch n {
 	case GOERLI, INTEGRATION:
 		return "SN_GOERLI"
@@ -110,6 +230,9 @@ func (n Network) ChainIDString() string {
 }
 
 func (n Network) DefaultL1ChainID() *big.Int {
+	if def, ok := n.Definition(); ok {
+		return new(big.Int).SetUint64(def.L1ChainID)
+	}
 	var chainID int64
 	switch n {
 	case MAINNET:
@@ -124,6 +247,13 @@ func (n Network) DefaultL1ChainID() *big.Int {
 }
 
 func (n Network) CoreContractAddress() (common.Address, error) {
+	if def, ok := n.Definition(); ok {
+		if def.CoreContractAddress == "" {
+			return common.Address{}, fmt.Errorf("l1 contract is not available on the %s network", def.Name)
+		}
+		return common.HexToAddress(def.CoreContractAddress), nil
+	}
+
 	var address common.Address
 	// The docs states the addresses for each network: https://docs.starknet.io/documentation/useful_info/
 	switch n {
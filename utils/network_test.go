@@ -139,3 +139,95 @@ func TestCoreContractAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomNetwork(t *testing.T) {
+	def := utils.NetworkDefinition{
+		Name:                "Custom",
+		ChainID:             "SN_CUSTOM",
+		FeederURL:           "http://localhost:5050/feeder_gateway",
+		GatewayURL:          "http://localhost:5050/gateway",
+		L1ChainID:           31337,
+		CoreContractAddress: "0x1234567890123456789012345678901234567890",
+		UnverifiableRange:   []uint64{5, 10},
+	}
+	network, err := utils.RegisterNetwork(def)
+	require.NoError(t, err)
+
+	t.Run("definition", func(t *testing.T) {
+		got, ok := network.Definition()
+		require.True(t, ok)
+		assert.Equal(t, def, got)
+
+		_, ok = utils.MAINNET.Definition()
+		assert.False(t, ok)
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		assert.Equal(t, "Custom", network.String())
+		assert.Equal(t, "http://localhost:5050/feeder_gateway/", network.FeederURL())
+		assert.Equal(t, "http://localhost:5050/gateway/", network.GatewayURL())
+		assert.Equal(t, new(felt.Felt).SetBytes([]byte("SN_CUSTOM")), network.ChainID())
+		assert.Equal(t, big.NewInt(31337), network.DefaultL1ChainID())
+
+		address, err := network.CoreContractAddress()
+		require.NoError(t, err)
+		assert.Equal(t, common.HexToAddress(def.CoreContractAddress), address)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		for _, name := range []string{"Custom", "custom", "CUSTOM"} {
+			n := new(utils.Network)
+			require.NoError(t, n.Set(name))
+			assert.Equal(t, network, *n)
+		}
+	})
+
+	t.Run("registering again replaces the definition", func(t *testing.T) {
+		noL1 := def
+		noL1.CoreContractAddress = ""
+		again, err := utils.RegisterNetwork(noL1)
+		require.NoError(t, err)
+		assert.Equal(t, network, again)
+
+		_, err = network.CoreContractAddress()
+		require.Error(t, err)
+	})
+
+	t.Run("verification", func(t *testing.T) {
+		verification, ok := network.Verification()
+		require.True(t, ok)
+		assert.Equal(t, utils.Verification{UnverifiableRange: []uint64{5, 10}}, verification)
+
+		_, ok = utils.MAINNET.Verification()
+		assert.False(t, ok)
+
+		override := utils.Verification{UnverifiableRange: []uint64{0, 20}, TrustTransactionHashes: true}
+		require.NoError(t, utils.SetVerification(network, override))
+		verification, ok = network.Verification()
+		require.True(t, ok)
+		assert.Equal(t, override, verification)
+
+		require.Error(t, utils.SetVerification(network, utils.Verification{UnverifiableRange: []uint64{1}}))
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		invalid := map[string]func(d *utils.NetworkDefinition){
+			"no name":             func(d *utils.NetworkDefinition) { d.Name = "" },
+			"built-in name":       func(d *utils.NetworkDefinition) { d.Name = "goerli" },
+			"no chain id":         func(d *utils.NetworkDefinition) { d.ChainID = "" },
+			"no feeder url":       func(d *utils.NetworkDefinition) { d.FeederURL = "" },
+			"invalid gateway url": func(d *utils.NetworkDefinition) { d.GatewayURL = "gateway" },
+			"invalid address":     func(d *utils.NetworkDefinition) { d.CoreContractAddress = "0x12" },
+			"invalid range":       func(d *utils.NetworkDefinition) { d.UnverifiableRange = []uint64{10, 5} },
+		}
+		for name, modify := range invalid {
+			t.Run(name, func(t *testing.T) {
+				d := def
+				d.Name = "other"
+				modify(&d)
+				_, err := utils.RegisterNetwork(d)
+				require.Error(t, err)
+			})
+		}
+	})
+}
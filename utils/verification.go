@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+)
+
+// Verification sets how the blocks of a network are verified, so that the networks and forks whose history has
+// blocks that cannot be verified, such as integration networks, are synced without changing the code.
+//
+// Trusting the hashes means that the blocks of the feeder gateway are stored without checking that they are the
+// blocks the network produced, so it should only be set for feeder gateways that are trusted.
+type Verification struct {
+	// UnverifiableRange is the first and last block whose hash cannot be verified, if any. It replaces the range
+	// built into Juno for the network.
+	UnverifiableRange []uint64 `mapstructure:"unverifiable-range"`
+	// TrustTransactionHashes stores the transactions without checking that their hashes match their contents
+	TrustTransactionHashes bool `mapstructure:"trust-transaction-hashes"`
+	// TrustBlockHashes stores the blocks without checking that their hashes match their headers and the
+	// commitments of their transactions and events
+	TrustBlockHashes bool `mapstructure:"trust-block-hashes"`
+}
+
+// verifications holds the Verification of the networks it was set for with SetVerification
+var (
+	verificationsMu sync.RWMutex
+	verifications   = make(map[Network]Verification)
+)
+
+// SetVerification sets how the blocks of n are verified, replacing its defaults and the settings of its
+// definition
+func SetVerification(n Network, v Verification) error {
+	if err := validateRange(v.UnverifiableRange); err != nil {
+		return err
+	}
+	v.UnverifiableRange = append([]uint64(nil), v.UnverifiableRange...)
+
+	verificationsMu.Lock()
+	defer verificationsMu.Unlock()
+	verifications[n] = v
+	return nil
+}
+
+// Verification returns how the blocks of n are verified, and false if it is not set with SetVerification or a
+// definition, in which case the defaults built into Juno apply
+func (n Network) Verification() (Verification, bool) {
+	verificationsMu.RLock()
+	v, ok := verifications[n]
+	verificationsMu.RUnlock()
+	if ok {
+		return v, true
+	}
+
+	if def, ok := n.Definition(); ok {
+		return Verification{
+			UnverifiableRange:      def.UnverifiableRange,
+			TrustTransactionHashes: def.TrustTransactionHashes,
+			TrustBlockHashes:       def.TrustBlockHashes,
+		}, true
+	}
+	return Verification{}, false
+}
+
+func validateRange(blocks []uint64) error {
+	if blocks != nil && (len(blocks) != 2 || blocks[0] > blocks[1]) {
+		return errors.New("unverifiable-range must be the first and last block of the range")
+	}
+	return nil
+}
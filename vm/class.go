@@ -134,6 +134,18 @@ func makeSierraClass(class *core.Cairo1Class) *feeder.SierraDefinition {
 	}
 }
 
+// ClassHash computes the hash of class from its definition
+func ClassHash(class core.Class) (*felt.Felt, error) {
+	switch c := class.(type) {
+	case *core.Cairo0Class:
+		return Cairo0ClassHash(c)
+	case *core.Cairo1Class:
+		return c.Hash(), nil
+	default:
+		return nil, errors.New("not a valid class")
+	}
+}
+
 func Cairo0ClassHash(class *core.Cairo0Class) (*felt.Felt, error) {
 	classJSON, err := marshalDeclaredClass(class)
 	if err != nil {
@@ -0,0 +1,128 @@
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/memory"
+)
+
+// ClassCacheName is the name of the cache of compiled classes in a memory budget
+const ClassCacheName = "vm-class-cache"
+
+// ClassCache holds the compiled classes the VM reads by class hash, as they are passed to the VM
+type ClassCache = memory.Cache[felt.Felt, json.RawMessage]
+
+// NewClassCache returns an empty cache of compiled classes, it must be resized to hold anything
+func NewClassCache() *ClassCache {
+	return memory.NewCache[felt.Felt, json.RawMessage](0, func(class json.RawMessage) uint64 {
+		return uint64(len(class))
+	})
+}
+
+type storageSlot struct {
+	address  felt.Felt
+	location felt.Felt
+}
+
+// executionState serves the state reads of an execution. The VM caches the reads of a transaction, but it
+// drops them with the changes of the transactions that fail, and a transaction can read the same values as
+// the transactions before it, so the reads are also cached for the whole execution. Compiled classes do not
+// change once declared and are expensive to marshal, so they are also cached across executions in classes.
+//
+// The reads of an execution come from a single goroutine, so executionState is not safe for concurrent use.
+type executionState struct {
+	state   core.StateReader
+	classes *ClassCache
+
+	storage         map[storageSlot]*felt.Felt
+	nonces          map[felt.Felt]*felt.Felt
+	classHashes     map[felt.Felt]*felt.Felt
+	compiledClasses map[felt.Felt]json.RawMessage
+}
+
+func newExecutionState(state core.StateReader, classes *ClassCache) *executionState {
+	return &executionState{
+		state:           state,
+		classes:         classes,
+		storage:         make(map[storageSlot]*felt.Felt),
+		nonces:          make(map[felt.Felt]*felt.Felt),
+		classHashes:     make(map[felt.Felt]*felt.Felt),
+		compiledClasses: make(map[felt.Felt]json.RawMessage),
+	}
+}
+
+// contractStorage returns the value of a storage location, which is zero for contracts that are not deployed
+func (s *executionState) contractStorage(address, location *felt.Felt) (*felt.Felt, error) {
+	slot := storageSlot{address: *address, location: *location}
+	if value, ok := s.storage[slot]; ok {
+		return value, nil
+	}
+
+	value, err := s.state.ContractStorage(address, location)
+	if value, err = zeroIfNotDeployed(value, err); err != nil {
+		return nil, err
+	}
+	s.storage[slot] = value
+	return value, nil
+}
+
+// contractNonce returns the nonce of a contract, which is zero for contracts that are not deployed
+func (s *executionState) contractNonce(address *felt.Felt) (*felt.Felt, error) {
+	if nonce, ok := s.nonces[*address]; ok {
+		return nonce, nil
+	}
+
+	nonce, err := s.state.ContractNonce(address)
+	if nonce, err = zeroIfNotDeployed(nonce, err); err != nil {
+		return nil, err
+	}
+	s.nonces[*address] = nonce
+	return nonce, nil
+}
+
+// contractClassHash returns the class hash of a contract, which is zero for contracts that are not deployed
+func (s *executionState) contractClassHash(address *felt.Felt) (*felt.Felt, error) {
+	if classHash, ok := s.classHashes[*address]; ok {
+		return classHash, nil
+	}
+
+	classHash, err := s.state.ContractClassHash(address)
+	if classHash, err = zeroIfNotDeployed(classHash, err); err != nil {
+		return nil, err
+	}
+	s.classHashes[*address] = classHash
+	return classHash, nil
+}
+
+// compiledClass returns the compiled class of a declared class, marshalled for the VM
+func (s *executionState) compiledClass(classHash *felt.Felt) (json.RawMessage, error) {
+	if compiledClass, ok := s.compiledClasses[*classHash]; ok {
+		return compiledClass, nil
+	}
+	if compiledClass, ok := s.classes.Get(*classHash); ok {
+		s.compiledClasses[*classHash] = compiledClass
+		return compiledClass, nil
+	}
+
+	declared, err := s.state.Class(classHash)
+	if err != nil {
+		return nil, err
+	}
+	compiledClass, err := marshalCompiledClass(declared.Class)
+	if err != nil {
+		return nil, err
+	}
+	s.compiledClasses[*classHash] = compiledClass
+	s.classes.Add(*classHash, compiledClass)
+	return compiledClass, nil
+}
+
+func zeroIfNotDeployed(value *felt.Felt, err error) (*felt.Felt, error) {
+	if errors.Is(err, core.ErrContractNotDeployed) {
+		return &felt.Zero, nil
+	}
+	return value, err
+}
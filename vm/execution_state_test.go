@@ -0,0 +1,119 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingState serves fixed values and counts the reads of each kind
+type countingState struct {
+	core.StateReader
+	deployed *felt.Felt
+	failing  bool
+	reads    map[string]int
+}
+
+func (s *countingState) read(kind string, address *felt.Felt) (*felt.Felt, error) {
+	s.reads[kind]++
+	if s.failing {
+		return nil, errors.New("read failed")
+	}
+	if !address.Equal(s.deployed) {
+		return nil, core.ErrContractNotDeployed
+	}
+	return new(felt.Felt).SetUint64(7), nil
+}
+
+func (s *countingState) ContractStorage(address, _ *felt.Felt) (*felt.Felt, error) {
+	return s.read("storage", address)
+}
+
+func (s *countingState) ContractNonce(address *felt.Felt) (*felt.Felt, error) {
+	return s.read("nonce", address)
+}
+
+func (s *countingState) ContractClassHash(address *felt.Felt) (*felt.Felt, error) {
+	return s.read("class hash", address)
+}
+
+func (s *countingState) Class(classHash *felt.Felt) (*core.DeclaredClass, error) {
+	s.reads["class"]++
+	if s.failing {
+		return nil, errors.New("read failed")
+	}
+	return &core.DeclaredClass{Class: &core.Cairo1Class{Compiled: []byte(`{"bytecode":[]}`)}}, nil
+}
+
+func TestExecutionState(t *testing.T) {
+	deployed := new(felt.Felt).SetUint64(1)
+	notDeployed := new(felt.Felt).SetUint64(2)
+	location := new(felt.Felt).SetUint64(3)
+	seven := new(felt.Felt).SetUint64(7)
+
+	t.Run("reads are cached for the execution", func(t *testing.T) {
+		state := &countingState{deployed: deployed, reads: make(map[string]int)}
+		execution := newExecutionState(state, nil)
+
+		for i := 0; i < 2; i++ {
+			value, err := execution.contractStorage(deployed, location)
+			require.NoError(t, err)
+			assert.Equal(t, seven, value)
+			value, err = execution.contractNonce(deployed)
+			require.NoError(t, err)
+			assert.Equal(t, seven, value)
+			value, err = execution.contractClassHash(deployed)
+			require.NoError(t, err)
+			assert.Equal(t, seven, value)
+
+			value, err = execution.contractStorage(notDeployed, location)
+			require.NoError(t, err)
+			assert.Equal(t, &felt.Zero, value)
+		}
+		assert.Equal(t, map[string]int{"storage": 2, "nonce": 1, "class hash": 1}, state.reads)
+
+		// another execution reads the state again
+		_, err := newExecutionState(state, nil).contractStorage(deployed, location)
+		require.NoError(t, err)
+		assert.Equal(t, 3, state.reads["storage"])
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		state := &countingState{deployed: deployed, failing: true, reads: make(map[string]int)}
+		execution := newExecutionState(state, nil)
+
+		for i := 0; i < 2; i++ {
+			_, err := execution.contractStorage(deployed, location)
+			require.Error(t, err)
+			_, err = execution.compiledClass(deployed)
+			require.Error(t, err)
+		}
+		assert.Equal(t, map[string]int{"storage": 2, "class": 2}, state.reads)
+	})
+
+	t.Run("compiled classes are cached across executions", func(t *testing.T) {
+		state := &countingState{deployed: deployed, reads: make(map[string]int)}
+		classes := NewClassCache()
+		classes.Resize(1 << 20)
+		classHash := new(felt.Felt).SetUint64(4)
+
+		for i := 0; i < 2; i++ {
+			compiledClass, err := newExecutionState(state, classes).compiledClass(classHash)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"bytecode":[]}`, string(compiledClass))
+		}
+		assert.Equal(t, 1, state.reads["class"])
+
+		// without a shared cache, the class is only cached for the execution
+		execution := newExecutionState(state, nil)
+		for i := 0; i < 2; i++ {
+			_, err := execution.compiledClass(classHash)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, state.reads["class"])
+	})
+}
@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// stepsExhausted is how the VM reports an execution that ran out of steps
+	stepsExhausted = "RunResources has no remaining steps"
+
+	resourceSteps    = "steps"
+	resourceMemory   = "memory"
+	resourceWallTime = "wall time"
+)
+
+// Limits caps the resources of an execution. A zero MaxSteps keeps the step limits of the VM, a zero MaxMemory
+// or Timeout leaves the resource uncapped.
+type Limits struct {
+	// MaxSteps is the number of Cairo steps a call, or the validation and the execution of each transaction,
+	// may take
+	MaxSteps uint64 `mapstructure:"max-steps"`
+	// MaxMemory is the memory in MiB an execution may allocate. It is checked whenever the execution reads the
+	// state, and MaxSteps bounds the memory of executions that do not.
+	MaxMemory uint `mapstructure:"max-memory"`
+	// Timeout is the wall time an execution may take. It is checked whenever the execution reads the state.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Override returns l with the non-zero fields of o
+func (l Limits) Override(o Limits) Limits {
+	if o.MaxSteps != 0 {
+		l.MaxSteps = o.MaxSteps
+	}
+	if o.MaxMemory != 0 {
+		l.MaxMemory = o.MaxMemory
+	}
+	if o.Timeout != 0 {
+		l.Timeout = o.Timeout
+	}
+	return l
+}
+
+func (l Limits) maxMemoryBytes() uint64 {
+	return uint64(l.MaxMemory) << 20
+}
+
+// ResourcesExceededError is returned by the executions that exceed their Limits
+type ResourcesExceededError struct {
+	Resource string
+}
+
+func (e *ResourcesExceededError) Error() string {
+	return fmt.Sprintf("execution resources exceeded: %s limit reached", e.Resource)
+}
@@ -0,0 +1,36 @@
+use std::alloc::{GlobalAlloc, Layout, System};
+use std::cell::Cell;
+
+thread_local! {
+    // bytes allocated minus bytes freed by the current thread
+    static ALLOCATED: Cell<isize> = Cell::new(0);
+}
+
+/// CountingAllocator counts the memory allocated by each thread, which lets an execution, that runs on the
+/// thread of its caller, be limited in the memory it allocates.
+pub struct CountingAllocator;
+
+fn add(bytes: isize) {
+    // the counter of a thread that is exiting may already be gone
+    let _ = ALLOCATED.try_with(|allocated| allocated.set(allocated.get() + bytes));
+}
+
+/// Returns the bytes the current thread allocated and did not free
+pub fn allocated() -> isize {
+    ALLOCATED.try_with(|allocated| allocated.get()).unwrap_or(0)
+}
+
+unsafe impl GlobalAlloc for CountingAllocator {
+    unsafe fn alloc(&self, layout: Layout) -> *mut u8 {
+        let ptr = System.alloc(layout);
+        if !ptr.is_null() {
+            add(layout.size() as isize);
+        }
+        ptr
+    }
+
+    unsafe fn dealloc(&self, ptr: *mut u8, layout: Layout) {
+        System.dealloc(ptr, layout);
+        add(-(layout.size() as isize));
+    }
+}
@@ -1,6 +1,8 @@
 use serde::Serialize;
 use blockifier;
-use starknet_api::core::{ContractAddress, EntryPointSelector, ClassHash};
+use starknet_api::core::{ContractAddress, EntryPointSelector, ClassHash, CompiledClassHash, Nonce};
+use starknet_api::state::StorageKey;
+use blockifier::state::cached_state::CommitmentStateDiff;
 use starknet_api::hash::StarkFelt;
 use starknet_api::transaction::{Calldata, EventContent, EthAddress, L2ToL1Payload};
 use starknet_api::deprecated_contract_class::EntryPointType;
@@ -60,6 +62,7 @@ impl From<BlockifierCallInfo> for FunctionInvocation {
             class_hash: val.call.class_hash,
             result: Some(val.execution.retdata.0),
             function_call: FunctionCall {
+                contract_address: val.call.storage_address,
                 entry_point_selector: val.call.entry_point_selector,
                 calldata: val.call.calldata,
             },
@@ -72,6 +75,7 @@ impl From<BlockifierCallInfo> for FunctionInvocation {
 
 #[derive(Serialize)]
 pub struct FunctionCall {
+    pub contract_address: ContractAddress,
     pub entry_point_selector: EntryPointSelector,
     pub calldata: Calldata,
 }
@@ -93,4 +97,65 @@ impl From<BlockifierMessageToL1> for MessageToL1 {
 }
 
 #[derive(Debug,Serialize)]
-pub struct Retdata(pub Vec<StarkFelt>);
\ No newline at end of file
+pub struct Retdata(pub Vec<StarkFelt>);
+#[derive(Serialize)]
+pub struct StateDiff {
+    pub storage_diffs: Vec<StorageDiff>,
+    pub nonces: Vec<NonceUpdate>,
+    pub class_hashes: Vec<ClassHashUpdate>,
+    pub compiled_class_hashes: Vec<CompiledClassHashUpdate>,
+}
+
+#[derive(Serialize)]
+pub struct StorageDiff {
+    pub address: ContractAddress,
+    pub storage_entries: Vec<StorageEntry>,
+}
+
+#[derive(Serialize)]
+pub struct StorageEntry {
+    pub key: StorageKey,
+    pub value: StarkFelt,
+}
+
+#[derive(Serialize)]
+pub struct NonceUpdate {
+    pub contract_address: ContractAddress,
+    pub nonce: Nonce,
+}
+
+#[derive(Serialize)]
+pub struct ClassHashUpdate {
+    pub contract_address: ContractAddress,
+    pub class_hash: ClassHash,
+}
+
+#[derive(Serialize)]
+pub struct CompiledClassHashUpdate {
+    pub class_hash: ClassHash,
+    pub compiled_class_hash: CompiledClassHash,
+}
+
+impl From<CommitmentStateDiff> for StateDiff {
+    fn from(diff: CommitmentStateDiff) -> Self {
+        StateDiff {
+            storage_diffs: diff.storage_updates.into_iter().map(|(address, entries)| StorageDiff {
+                address,
+                storage_entries: entries.into_iter().map(|(key, value)| StorageEntry { key, value }).collect(),
+            }).collect(),
+            nonces: diff.address_to_nonce.into_iter().map(|(contract_address, nonce)| NonceUpdate {
+                contract_address,
+                nonce,
+            }).collect(),
+            class_hashes: diff.address_to_class_hash.into_iter().map(|(contract_address, class_hash)| ClassHashUpdate {
+                contract_address,
+                class_hash,
+            }).collect(),
+            compiled_class_hashes: diff.class_hash_to_compiled_class_hash.into_iter()
+                .map(|(class_hash, compiled_class_hash)| CompiledClassHashUpdate {
+                    class_hash,
+                    compiled_class_hash,
+                }).collect(),
+        }
+    }
+}
@@ -1,8 +1,10 @@
 use std::{
-    ffi::{c_char, c_uchar, c_void, CStr},
+    ffi::{c_char, c_uchar, c_void, CStr, CString},
     slice,
 };
 
+use crate::allocator::allocated;
+
 use blockifier::{
     execution::contract_class::{ContractClassV0, ContractClassV1},
     state::state_api::{StateReader, StateResult},
@@ -32,15 +34,36 @@ extern "C" {
     ) -> *const c_uchar;
     fn JunoStateGetCompiledClass(reader_handle: usize, class_hash: *const c_uchar)
         -> *const c_char;
+    fn JunoReportResourceExceeded(reader_handle: usize, resource: *const c_char);
 }
 
 pub struct JunoStateReader {
     pub handle: usize, // uintptr_t equivalent
+    // bytes the execution may allocate, 0 for no limit
+    max_memory: usize,
+    // bytes the thread had allocated when the execution started
+    allocated_at_start: isize,
 }
 
 impl JunoStateReader {
-    pub fn new(handle: usize) -> Self {
-        Self { handle: handle }
+    pub fn new(handle: usize, max_memory: usize) -> Self {
+        Self {
+            handle: handle,
+            max_memory: max_memory,
+            allocated_at_start: allocated(),
+        }
+    }
+
+    /// Fails the read, and so the execution, once the execution allocated more than its memory limit
+    fn check_memory(&self) -> StateResult<()> {
+        if self.max_memory == 0 || allocated() - self.allocated_at_start <= self.max_memory as isize {
+            return Ok(());
+        }
+        let resource = CString::new("memory").unwrap();
+        unsafe { JunoReportResourceExceeded(self.handle, resource.as_ptr()) };
+        Err(StateError::StateReadError(
+            "execution exceeded its memory limit".to_string(),
+        ))
     }
 }
 
@@ -50,6 +73,7 @@ impl StateReader for JunoStateReader {
         contract_address: ContractAddress,
         key: StorageKey,
     ) -> StateResult<StarkFelt> {
+        self.check_memory()?;
         let addr = felt_to_byte_array(contract_address.0.key());
         let storage_key = felt_to_byte_array(key.0.key());
         let ptr =
@@ -71,6 +95,7 @@ impl StateReader for JunoStateReader {
     /// Returns the nonce of the given contract instance.
     /// Default: 0 for an uninitialized contract address.
     fn get_nonce_at(&mut self, contract_address: ContractAddress) -> StateResult<Nonce> {
+        self.check_memory()?;
         let addr = felt_to_byte_array(contract_address.0.key());
         let ptr = unsafe { JunoStateGetNonceAt(self.handle, addr.as_ptr()) };
         if ptr.is_null() {
@@ -88,6 +113,7 @@ impl StateReader for JunoStateReader {
     /// Returns the class hash of the contract class at the given contract instance.
     /// Default: 0 (uninitialized class hash) for an uninitialized contract address.
     fn get_class_hash_at(&mut self, contract_address: ContractAddress) -> StateResult<ClassHash> {
+        self.check_memory()?;
         let addr = felt_to_byte_array(contract_address.0.key());
         let ptr = unsafe { JunoStateGetClassHashAt(self.handle, addr.as_ptr()) };
         if ptr.is_null() {
@@ -108,6 +134,7 @@ impl StateReader for JunoStateReader {
         &mut self,
         class_hash: &ClassHash,
     ) -> StateResult<ContractClass> {
+        self.check_memory()?;
         let class_hash_bytes = felt_to_byte_array(&class_hash.0);
         let ptr = unsafe { JunoStateGetCompiledClass(self.handle, class_hash_bytes.as_ptr()) };
         if ptr.is_null() {
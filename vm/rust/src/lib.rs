@@ -1,7 +1,10 @@
+mod allocator;
 pub mod class;
 mod juno_state_reader;
 pub mod jsonrpc;
 
+use crate::allocator::CountingAllocator;
+
 use crate::juno_state_reader::{ptr_to_felt, JunoStateReader};
 use std::{
     collections::HashMap,
@@ -48,11 +51,18 @@ use starknet_api::{
 extern "C" {
     fn JunoReportError(reader_handle: usize, err: *const c_char);
     fn JunoAppendTrace(reader_handle: usize, json_trace: *const c_void, len: usize);
+    fn JunoAppendStateDiff(reader_handle: usize, json_state_diff: *const c_void, len: usize);
     fn JunoAppendResponse(reader_handle: usize, ptr: *const c_uchar);
     fn JunoAppendGasConsumed(reader_handle: usize, ptr: *const c_uchar);
 }
 
 const N_STEPS_FEE_WEIGHT: f64 = 0.01;
+// the step limits of executions whose callers do not set one
+const CALL_MAX_STEPS: usize = 4_000_000;
+const TX_MAX_STEPS: u32 = 1_000_000;
+
+#[global_allocator]
+static ALLOCATOR: CountingAllocator = CountingAllocator;
 
 #[no_mangle]
 pub extern "C" fn cairoVMCall(
@@ -64,8 +74,10 @@ pub extern "C" fn cairoVMCall(
     block_number: c_ulonglong,
     block_timestamp: c_ulonglong,
     chain_id: *const c_char,
+    max_steps: c_ulonglong,
+    max_memory: c_ulonglong,
 ) {
-    let reader = JunoStateReader::new(reader_handle);
+    let reader = JunoStateReader::new(reader_handle, max_memory as usize);
     let contract_addr_felt = ptr_to_felt(contract_address);
     let entry_point_selector_felt = ptr_to_felt(entry_point_selector);
     let chain_id_str = unsafe { CStr::from_ptr(chain_id) }.to_str().unwrap();
@@ -99,9 +111,14 @@ pub extern "C" fn cairoVMCall(
             block_number,
             block_timestamp,
             StarkFelt::default(),
+            TX_MAX_STEPS,
         ),
         AccountTransactionContext::default(),
-        4_000_000,
+        if max_steps == 0 {
+            CALL_MAX_STEPS
+        } else {
+            max_steps as usize
+        },
     );
     let call_info = entry_point.execute(&mut state, &mut resources, &mut context);
 
@@ -127,8 +144,10 @@ pub extern "C" fn cairoVMExecute(
     chain_id: *const c_char,
     sequencer_address: *const c_uchar,
     paid_fees_on_l1_json: *const c_char,
+    max_steps: c_ulonglong,
+    max_memory: c_ulonglong,
 ) {
-    let reader = JunoStateReader::new(reader_handle);
+    let reader = JunoStateReader::new(reader_handle, max_memory as usize);
     let chain_id_str = unsafe { CStr::from_ptr(chain_id) }.to_str().unwrap();
     let txn_json_str = unsafe { CStr::from_ptr(txns_json) }.to_str().unwrap();
     let sn_api_txns: Result<Vec<StarknetApiTransaction>, serde_json::Error> =
@@ -166,6 +185,11 @@ pub extern "C" fn cairoVMExecute(
         block_number,
         block_timestamp,
         sequencer_address_felt,
+        if max_steps == 0 {
+            TX_MAX_STEPS
+        } else {
+            max_steps.min(u32::MAX.into()) as u32
+        },
     );
     let mut state = CachedState::new(reader);
 
@@ -250,6 +274,8 @@ pub extern "C" fn cairoVMExecute(
             },
         }
     }
+
+    append_state_diff(reader_handle, state.to_state_diff().into());
 }
 
 fn transaction_from_api(
@@ -282,6 +308,17 @@ fn append_trace(reader_handle: usize, trace: jsonrpc::TransactionTrace) {
     };
 }
 
+fn append_state_diff(reader_handle: usize, state_diff: jsonrpc::StateDiff) {
+    let json = serde_json::to_string(&state_diff).unwrap();
+    let json_bytes = json.into_bytes();
+    let ptr = json_bytes.as_ptr();
+    let len = json_bytes.len();
+
+    unsafe {
+        JunoAppendStateDiff(reader_handle, ptr as *const c_void, len);
+    };
+}
+
 fn report_error(reader_handle: usize, msg: &str) {
     let err_msg = CString::new(msg).unwrap();
     unsafe {
@@ -294,6 +331,7 @@ fn build_block_context(
     block_number: c_ulonglong,
     block_timestamp: c_ulonglong,
     sequencer_address: StarkFelt,
+    max_n_steps: u32,
 ) -> BlockContext {
     BlockContext {
         chain_id: ChainId(chain_id_str.into()),
@@ -333,8 +371,8 @@ fn build_block_context(
             (KECCAK_BUILTIN_NAME.to_string(), N_STEPS_FEE_WEIGHT * 2048.0),
         ])
         .into(),
-        invoke_tx_max_n_steps: 1_000_000,
-        validate_max_n_steps: 1_000_000,
+        invoke_tx_max_n_steps: max_n_steps,
+        validate_max_n_steps: max_n_steps,
         max_recursion_depth: 50,
     }
 }
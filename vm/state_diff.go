@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// stateDiff is the state diff of an execution as the VM reports it
+type stateDiff struct {
+	StorageDiffs []struct {
+		Address        felt.Felt `json:"address"`
+		StorageEntries []struct {
+			Key   felt.Felt `json:"key"`
+			Value felt.Felt `json:"value"`
+		} `json:"storage_entries"`
+	} `json:"storage_diffs"`
+	Nonces []struct {
+		ContractAddress felt.Felt `json:"contract_address"`
+		Nonce           felt.Felt `json:"nonce"`
+	} `json:"nonces"`
+	ClassHashes []struct {
+		ContractAddress felt.Felt `json:"contract_address"`
+		ClassHash       felt.Felt `json:"class_hash"`
+	} `json:"class_hashes"`
+}
+
+// adaptStateDiff adapts the state diff of the execution of txns on state. The VM does not tell the deployed contracts
+// from the contracts whose class was replaced, and it does not report the classes declared by txns.
+func adaptStateDiff(diffJSON json.RawMessage, txns []core.Transaction, state core.StateReader) (*core.StateDiff, error) {
+	if diffJSON == nil {
+		return nil, errors.New("the VM did not report a state diff")
+	}
+	var diff stateDiff
+	if err := json.Unmarshal(diffJSON, &diff); err != nil {
+		return nil, err
+	}
+
+	adapted := &core.StateDiff{
+		StorageDiffs: make(map[felt.Felt][]core.StorageDiff, len(diff.StorageDiffs)),
+		Nonces:       make(map[felt.Felt]*felt.Felt, len(diff.Nonces)),
+	}
+	for i := range diff.StorageDiffs {
+		storageDiff := &diff.StorageDiffs[i]
+		entries := make([]core.StorageDiff, 0, len(storageDiff.StorageEntries))
+		for j := range storageDiff.StorageEntries {
+			entries = append(entries, core.StorageDiff{
+				Key:   &storageDiff.StorageEntries[j].Key,
+				Value: &storageDiff.StorageEntries[j].Value,
+			})
+		}
+		adapted.StorageDiffs[storageDiff.Address] = entries
+	}
+	for i := range diff.Nonces {
+		adapted.Nonces[diff.Nonces[i].ContractAddress] = &diff.Nonces[i].Nonce
+	}
+	for i := range diff.ClassHashes {
+		address, classHash := &diff.ClassHashes[i].ContractAddress, &diff.ClassHashes[i].ClassHash
+		_, err := state.ContractClassHash(address)
+		if errors.Is(err, core.ErrContractNotDeployed) {
+			adapted.DeployedContracts = append(adapted.DeployedContracts, core.DeployedContract{
+				Address:   address,
+				ClassHash: classHash,
+			})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		adapted.ReplacedClasses = append(adapted.ReplacedClasses, core.ReplacedClass{
+			Address:   address,
+			ClassHash: classHash,
+		})
+	}
+
+	for _, txn := range txns {
+		declare, ok := txn.(*core.DeclareTransaction)
+		if !ok {
+			continue
+		}
+		if declare.CompiledClassHash != nil {
+			adapted.DeclaredV1Classes = append(adapted.DeclaredV1Classes, core.DeclaredV1Class{
+				ClassHash:         declare.ClassHash,
+				CompiledClassHash: declare.CompiledClassHash,
+			})
+		} else {
+			adapted.DeclaredV0Classes = append(adapted.DeclaredV0Classes, declare.ClassHash)
+		}
+	}
+	return adapted, nil
+}
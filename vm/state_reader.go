@@ -5,11 +5,7 @@ package vm
 import "C"
 
 import (
-	"errors"
 	"unsafe"
-
-	"github.com/NethermindEth/juno/core"
-	"github.com/NethermindEth/juno/core/felt"
 )
 
 //export JunoFree
@@ -20,15 +16,15 @@ func JunoFree(ptr unsafe.Pointer) {
 //export JunoStateGetStorageAt
 func JunoStateGetStorageAt(readerHandle C.uintptr_t, contractAddress, storageLocation unsafe.Pointer) unsafe.Pointer {
 	context := unwrapContext(readerHandle)
+	if context.timedOut() {
+		return nil
+	}
 
 	contractAddressFelt := makeFeltFromPtr(contractAddress)
 	storageLocationFelt := makeFeltFromPtr(storageLocation)
-	val, err := context.state.ContractStorage(contractAddressFelt, storageLocationFelt)
+	val, err := context.state.contractStorage(contractAddressFelt, storageLocationFelt)
 	if err != nil {
-		if !errors.Is(err, core.ErrContractNotDeployed) {
-			return nil
-		}
-		val = &felt.Zero
+		return nil
 	}
 
 	return makePtrFromFelt(val)
@@ -37,14 +33,14 @@ func JunoStateGetStorageAt(readerHandle C.uintptr_t, contractAddress, storageLoc
 //export JunoStateGetNonceAt
 func JunoStateGetNonceAt(readerHandle C.uintptr_t, contractAddress unsafe.Pointer) unsafe.Pointer {
 	context := unwrapContext(readerHandle)
+	if context.timedOut() {
+		return nil
+	}
 
 	contractAddressFelt := makeFeltFromPtr(contractAddress)
-	val, err := context.state.ContractNonce(contractAddressFelt)
+	val, err := context.state.contractNonce(contractAddressFelt)
 	if err != nil {
-		if !errors.Is(err, core.ErrContractNotDeployed) {
-			return nil
-		}
-		val = &felt.Zero
+		return nil
 	}
 
 	return makePtrFromFelt(val)
@@ -53,14 +49,14 @@ func JunoStateGetNonceAt(readerHandle C.uintptr_t, contractAddress unsafe.Pointe
 //export JunoStateGetClassHashAt
 func JunoStateGetClassHashAt(readerHandle C.uintptr_t, contractAddress unsafe.Pointer) unsafe.Pointer {
 	context := unwrapContext(readerHandle)
+	if context.timedOut() {
+		return nil
+	}
 
 	contractAddressFelt := makeFeltFromPtr(contractAddress)
-	val, err := context.state.ContractClassHash(contractAddressFelt)
+	val, err := context.state.contractClassHash(contractAddressFelt)
 	if err != nil {
-		if !errors.Is(err, core.ErrContractNotDeployed) {
-			return nil
-		}
-		val = &felt.Zero
+		return nil
 	}
 
 	return makePtrFromFelt(val)
@@ -69,14 +65,12 @@ func JunoStateGetClassHashAt(readerHandle C.uintptr_t, contractAddress unsafe.Po
 //export JunoStateGetCompiledClass
 func JunoStateGetCompiledClass(readerHandle C.uintptr_t, classHash unsafe.Pointer) unsafe.Pointer {
 	context := unwrapContext(readerHandle)
-
-	classHashFelt := makeFeltFromPtr(classHash)
-	val, err := context.state.Class(classHashFelt)
-	if err != nil {
+	if context.timedOut() {
 		return nil
 	}
 
-	compiledClass, err := marshalCompiledClass(val.Class)
+	classHashFelt := makeFeltFromPtr(classHash)
+	compiledClass, err := context.state.compiledClass(classHashFelt)
 	if err != nil {
 		return nil
 	}
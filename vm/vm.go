@@ -5,10 +5,11 @@ package vm
 //#include <stddef.h>
 // extern void cairoVMCall(char* contract_address, char* entry_point_selector, char** calldata, size_t len_calldata,
 //					uintptr_t readerHandle, unsigned long long block_number, unsigned long long block_timestamp,
-//					char* chain_id);
+//					char* chain_id, unsigned long long max_steps, unsigned long long max_memory);
 //
 // extern void cairoVMExecute(char* txns_json, char* classes_json, uintptr_t readerHandle, unsigned long long block_number,
-//					unsigned long long block_timestamp, char* chain_id, char* sequencer_address, char* paid_fees_on_l1_json);
+//					unsigned long long block_timestamp, char* chain_id, char* sequencer_address, char* paid_fees_on_l1_json,
+//					unsigned long long max_steps, unsigned long long max_memory);
 //
 // #cgo LDFLAGS: -L./rust/target/release -ljuno_starknet_rs -lm -ldl
 import "C"
@@ -17,6 +18,8 @@ import (
 	"encoding/json"
 	"errors"
 	"runtime/cgo"
+	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/NethermindEth/juno/core"
@@ -27,23 +30,32 @@ import (
 //go:generate mockgen -destination=../mocks/mock_vm.go -package=mocks github.com/NethermindEth/juno/vm VM
 type VM interface {
 	Call(contractAddr, selector *felt.Felt, calldata []felt.Felt, blockNumber,
-		blockTimestamp uint64, state core.StateReader, network utils.Network,
+		blockTimestamp uint64, state core.StateReader, network utils.Network, limits Limits,
 	) ([]*felt.Felt, error)
 	Execute(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
 		sequencerAddress *felt.Felt, state core.StateReader, network utils.Network, paidFeesOnL1 []*felt.Felt,
+		limits Limits,
 	) ([]*felt.Felt, []json.RawMessage, error)
+	// ExecuteBlock executes txns like Execute, as the transactions of a new block, and also returns the state diff
+	// of their execution
+	ExecuteBlock(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
+		sequencerAddress *felt.Felt, state core.StateReader, network utils.Network, limits Limits,
+	) (*core.StateDiff, []*felt.Felt, []json.RawMessage, error)
 }
 
-type vm struct{}
+type vm struct {
+	classes *ClassCache
+}
 
-func New() VM {
-	return &vm{}
+// New returns a VM that keeps the compiled classes its executions read in classes, which may be nil
+func New(classes *ClassCache) VM {
+	return &vm{classes: classes}
 }
 
 // callContext manages the context that a Call instance executes on
 type callContext struct {
 	// state that the call is running on
-	state core.StateReader
+	state *executionState
 	// err field to be possibly populated in case of an error in execution
 	err string
 	// response from the executed Cairo function
@@ -51,6 +63,44 @@ type callContext struct {
 	// amount of gas consumed per transaction during VM execution
 	gasConsumed []*felt.Felt
 	traces      []json.RawMessage
+	// stateDiff is the state diff of the executed transactions
+	stateDiff json.RawMessage
+	// deadline is when the execution times out, it is zero if it does not
+	deadline time.Time
+	// exceeded is the resource whose limit the execution exceeded, if any
+	exceeded string
+}
+
+func newCallContext(state core.StateReader, classes *ClassCache, limits Limits) *callContext {
+	context := &callContext{state: newExecutionState(state, classes)}
+	if limits.Timeout > 0 {
+		context.deadline = time.Now().Add(limits.Timeout)
+	}
+	return context
+}
+
+// timedOut reports whether the execution is past its deadline, the state reads of an execution that timed
+// out fail so that the VM aborts it
+func (c *callContext) timedOut() bool {
+	if c.deadline.IsZero() || time.Now().Before(c.deadline) {
+		return false
+	}
+	c.exceeded = resourceWallTime
+	return true
+}
+
+// error returns the error the execution failed with, if any
+func (c *callContext) error() error {
+	if c.exceeded != "" {
+		return &ResourcesExceededError{Resource: c.exceeded}
+	}
+	if len(c.err) == 0 {
+		return nil
+	}
+	if strings.Contains(c.err, stepsExhausted) {
+		return &ResourcesExceededError{Resource: resourceSteps}
+	}
+	return errors.New(c.err)
 }
 
 func unwrapContext(readerHandle C.uintptr_t) *callContext {
@@ -68,6 +118,12 @@ func JunoReportError(readerHandle C.uintptr_t, str *C.char) {
 	context.err = C.GoString(str)
 }
 
+//export JunoReportResourceExceeded
+func JunoReportResourceExceeded(readerHandle C.uintptr_t, resource *C.char) {
+	context := unwrapContext(readerHandle)
+	context.exceeded = C.GoString(resource)
+}
+
 //export JunoAppendTrace
 func JunoAppendTrace(readerHandle C.uintptr_t, jsonBytes *C.void, bytesLen C.size_t) {
 	context := unwrapContext(readerHandle)
@@ -75,6 +131,12 @@ func JunoAppendTrace(readerHandle C.uintptr_t, jsonBytes *C.void, bytesLen C.siz
 	context.traces = append(context.traces, json.RawMessage(byteSlice))
 }
 
+//export JunoAppendStateDiff
+func JunoAppendStateDiff(readerHandle C.uintptr_t, jsonBytes *C.void, bytesLen C.size_t) {
+	context := unwrapContext(readerHandle)
+	context.stateDiff = C.GoBytes(unsafe.Pointer(jsonBytes), C.int(bytesLen))
+}
+
 //export JunoAppendResponse
 func JunoAppendResponse(readerHandle C.uintptr_t, ptr unsafe.Pointer) {
 	context := unwrapContext(readerHandle)
@@ -96,13 +158,11 @@ func makePtrFromFelt(val *felt.Felt) unsafe.Pointer {
 	return C.CBytes(feltBytes[:])
 }
 
-func (*vm) Call(contractAddr, selector *felt.Felt, calldata []felt.Felt, blockNumber,
-	blockTimestamp uint64, state core.StateReader, network utils.Network,
+func (v *vm) Call(contractAddr, selector *felt.Felt, calldata []felt.Felt, blockNumber,
+	blockTimestamp uint64, state core.StateReader, network utils.Network, limits Limits,
 ) ([]*felt.Felt, error) {
-	context := &callContext{
-		state:    state,
-		response: []*felt.Felt{},
-	}
+	context := newCallContext(state, v.classes, limits)
+	context.response = []*felt.Felt{}
 	handle := cgo.NewHandle(context)
 	defer handle.Delete()
 
@@ -126,37 +186,66 @@ func (*vm) Call(contractAddr, selector *felt.Felt, calldata []felt.Felt, blockNu
 		C.uintptr_t(handle),
 		C.ulonglong(blockNumber),
 		C.ulonglong(blockTimestamp),
-		chainID)
+		chainID,
+		C.ulonglong(limits.MaxSteps),
+		C.ulonglong(limits.maxMemoryBytes()))
 
 	for _, ptr := range calldataPtrs {
 		C.free(unsafe.Pointer(ptr))
 	}
 	C.free(unsafe.Pointer(chainID))
 
-	if len(context.err) > 0 {
-		return nil, errors.New(context.err)
+	if err := context.error(); err != nil {
+		return nil, err
 	}
 	return context.response, nil
 }
 
 // Execute executes a given transaction set and returns the gas spent per transaction
-func (*vm) Execute(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
+func (v *vm) Execute(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
 	sequencerAddress *felt.Felt, state core.StateReader, network utils.Network, paidFeesOnL1 []*felt.Felt,
+	limits Limits,
 ) ([]*felt.Felt, []json.RawMessage, error) {
-	context := &callContext{
-		state: state,
+	context, err := v.execute(txns, declaredClasses, blockNumber, blockTimestamp, sequencerAddress, state, network,
+		paidFeesOnL1, limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.gasConsumed, context.traces, nil
+}
+
+// ExecuteBlock : see VM.ExecuteBlock
+func (v *vm) ExecuteBlock(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
+	sequencerAddress *felt.Felt, state core.StateReader, network utils.Network, limits Limits,
+) (*core.StateDiff, []*felt.Felt, []json.RawMessage, error) {
+	context, err := v.execute(txns, declaredClasses, blockNumber, blockTimestamp, sequencerAddress, state, network,
+		[]*felt.Felt{}, limits)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stateDiff, err := adaptStateDiff(context.stateDiff, txns, state)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	return stateDiff, context.gasConsumed, context.traces, nil
+}
+
+func (v *vm) execute(txns []core.Transaction, declaredClasses []core.Class, blockNumber, blockTimestamp uint64,
+	sequencerAddress *felt.Felt, state core.StateReader, network utils.Network, paidFeesOnL1 []*felt.Felt,
+	limits Limits,
+) (*callContext, error) {
+	context := newCallContext(state, v.classes, limits)
 	handle := cgo.NewHandle(context)
 	defer handle.Delete()
 
 	txnsJSON, classesJSON, err := marshalTxnsAndDeclaredClasses(txns, declaredClasses)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	paidFeesOnL1Bytes, err := json.Marshal(paidFeesOnL1)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	paidFeesOnL1CStr := C.CString(string(paidFeesOnL1Bytes))
@@ -173,18 +262,20 @@ func (*vm) Execute(txns []core.Transaction, declaredClasses []core.Class, blockN
 		C.ulonglong(blockTimestamp),
 		chainID,
 		(*C.char)(unsafe.Pointer(&sequencerAddressBytes[0])),
-		paidFeesOnL1CStr)
+		paidFeesOnL1CStr,
+		C.ulonglong(limits.MaxSteps),
+		C.ulonglong(limits.maxMemoryBytes()))
 
 	C.free(unsafe.Pointer(classesJSONCStr))
 	C.free(unsafe.Pointer(paidFeesOnL1CStr))
 	C.free(unsafe.Pointer(txnsJSONCstr))
 	C.free(unsafe.Pointer(chainID))
 
-	if len(context.err) > 0 {
-		return nil, nil, errors.New(context.err)
+	if err := context.error(); err != nil {
+		return nil, err
 	}
 
-	return context.gasConsumed, context.traces, nil
+	return context, nil
 }
 
 func marshalTxnsAndDeclaredClasses(txns []core.Transaction, declaredClasses []core.Class) (json.RawMessage, json.RawMessage, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/juno/clients/feeder"
 	"github.com/NethermindEth/juno/core"
@@ -51,7 +52,7 @@ func TestV0Call(t *testing.T) {
 	}))
 
 	entryPoint := utils.HexToFelt(t, "0x39e11d48192e4333233c7eb19d10ad67c362bb28580c604d67884c85da39695")
-	ret, err := New().Call(contractAddr, entryPoint, nil, 0, 0, testState, utils.MAINNET)
+	ret, err := New(nil).Call(contractAddr, entryPoint, nil, 0, 0, testState, utils.MAINNET, Limits{})
 	require.NoError(t, err)
 	assert.Equal(t, []*felt.Felt{&felt.Zero}, ret)
 
@@ -70,7 +71,7 @@ func TestV0Call(t *testing.T) {
 		},
 	}, nil))
 
-	ret, err = New().Call(contractAddr, entryPoint, nil, 1, 0, testState, utils.MAINNET)
+	ret, err = New(nil).Call(contractAddr, entryPoint, nil, 1, 0, testState, utils.MAINNET, Limits{})
 	require.NoError(t, err)
 	assert.Equal(t, []*felt.Felt{new(felt.Felt).SetUint64(1337)}, ret)
 }
@@ -112,9 +113,9 @@ func TestV1Call(t *testing.T) {
 	// test_storage_read
 	entryPoint := utils.HexToFelt(t, "0x5df99ae77df976b4f0e5cf28c7dcfe09bd6e81aab787b19ac0c08e03d928cf")
 	storageLocation := utils.HexToFelt(t, "0x44")
-	ret, err := New().Call(contractAddr, entryPoint, []felt.Felt{
+	ret, err := New(nil).Call(contractAddr, entryPoint, []felt.Felt{
 		*storageLocation,
-	}, 0, 0, testState, utils.GOERLI)
+	}, 0, 0, testState, utils.GOERLI, Limits{})
 	require.NoError(t, err)
 	assert.Equal(t, []*felt.Felt{&felt.Zero}, ret)
 
@@ -133,11 +134,25 @@ func TestV1Call(t *testing.T) {
 		},
 	}, nil))
 
-	ret, err = New().Call(contractAddr, entryPoint, []felt.Felt{
+	ret, err = New(nil).Call(contractAddr, entryPoint, []felt.Felt{
 		*storageLocation,
-	}, 1, 0, testState, utils.GOERLI)
+	}, 1, 0, testState, utils.GOERLI, Limits{})
 	require.NoError(t, err)
 	assert.Equal(t, []*felt.Felt{new(felt.Felt).SetUint64(37)}, ret)
+
+	t.Run("exceeds limits", func(t *testing.T) {
+		for limits, resource := range map[Limits]string{
+			{MaxSteps: 1}:              resourceSteps,
+			{Timeout: time.Nanosecond}: resourceWallTime,
+		} {
+			_, err = New(nil).Call(contractAddr, entryPoint, []felt.Felt{
+				*storageLocation,
+			}, 1, 0, testState, utils.GOERLI, limits)
+			var exceeded *ResourcesExceededError
+			require.ErrorAs(t, err, &exceeded)
+			assert.Equal(t, resource, exceeded.Resource)
+		}
+	})
 }
 
 func TestExecute(t *testing.T) {
@@ -158,11 +173,11 @@ func TestExecute(t *testing.T) {
 			address   = utils.HexToFelt(t, "0x46a89ae102987331d369645031b49c27738ed096f2789c24449966da4c6de6b")
 			timestamp = uint64(1666877926)
 		)
-		_, _, err := New().Execute([]core.Transaction{}, []core.Class{}, 0, timestamp, address, state, network, []*felt.Felt{})
+		_, _, err := New(nil).Execute([]core.Transaction{}, []core.Class{}, 0, timestamp, address, state, network, []*felt.Felt{}, Limits{})
 		require.NoError(t, err)
 	})
 	t.Run("zero data", func(t *testing.T) {
-		_, _, err := New().Execute(nil, nil, 0, 0, &felt.Zero, state, network, []*felt.Felt{})
+		_, _, err := New(nil).Execute(nil, nil, 0, 0, &felt.Zero, state, network, []*felt.Felt{}, Limits{})
 		require.NoError(t, err)
 	})
 }
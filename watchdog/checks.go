@@ -0,0 +1,56 @@
+package watchdog
+
+import (
+	"fmt"
+	"time"
+)
+
+// HeadStall returns a Check that fails when the local head has not changed for timeout while the head of
+// the network is ahead of it. networkHead returns false if the head of the network is not known.
+func HeadStall(timeout time.Duration, head func() (uint64, error), networkHead func() (uint64, bool)) Check {
+	var lastHead uint64
+	var hasHead bool
+	var lastChange time.Time
+	return func() error {
+		now := time.Now()
+		if lastChange.IsZero() {
+			// the head is given timeout from the first check to change
+			lastChange = now
+		}
+		current, err := head()
+		if err == nil && (!hasHead || current != lastHead) {
+			lastHead, hasHead, lastChange = current, true, now
+		}
+
+		target, ok := networkHead()
+		if !ok || (hasHead && target <= lastHead) || now.Sub(lastChange) < timeout {
+			return nil
+		}
+		if !hasHead {
+			return fmt.Errorf("no block stored in %s while the network is at block %d", timeout, target)
+		}
+		return fmt.Errorf("head has been block %d for %s while the network is at block %d", lastHead,
+			now.Sub(lastChange).Round(time.Second), target)
+	}
+}
+
+// HeldFor returns a Check that fails when held, which returns for how long a resource has been held, is
+// longer than timeout
+func HeldFor(timeout time.Duration, resource string, held func() time.Duration) Check {
+	return func() error {
+		if duration := held(); duration > timeout {
+			return fmt.Errorf("%s has been held for %s", resource, duration.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// When returns a Check that only runs check while enabled returns true
+func When(enabled func() bool, check Check) Check {
+	return func() error {
+		if !enabled() {
+			return nil
+		}
+		return check()
+	}
+}
@@ -0,0 +1,53 @@
+package watchdog
+
+import (
+	"context"
+
+	"github.com/NethermindEth/juno/service"
+)
+
+var _ service.Service = (*Restartable)(nil)
+
+// Restartable is a service that can be restarted while it runs, by cancelling the context it was run with
+// and running it again
+type Restartable struct {
+	service service.Service
+	restart chan struct{}
+}
+
+// NewRestartable wraps s so that it can be restarted
+func NewRestartable(s service.Service) *Restartable {
+	return &Restartable{service: s, restart: make(chan struct{}, 1)}
+}
+
+// Restart stops the service and runs it again, it does not wait for the service to stop
+func (r *Restartable) Restart() {
+	select {
+	case r.restart <- struct{}{}:
+	default:
+		// a restart is already pending
+	}
+}
+
+// Run runs the service until ctx is cancelled or the service returns without being restarted
+func (r *Restartable) Run(ctx context.Context) error {
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		restarted := make(chan bool, 1)
+		go func() {
+			select {
+			case <-r.restart:
+				cancel()
+				restarted <- true
+			case <-runCtx.Done():
+				restarted <- false
+			}
+		}()
+
+		err := r.service.Run(runCtx)
+		cancel()
+		if !<-restarted || ctx.Err() != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package watchdog detects the subsystems of the node that stopped making progress, such as a sync that
+// no longer stores blocks while the network advances, and alerts about them or restarts them.
+package watchdog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ service.Service = (*Watchdog)(nil)
+
+// Check returns an error describing why a subsystem is stalled, or nil if it is making progress
+type Check func() error
+
+type watched struct {
+	name    string
+	check   Check
+	restart func()
+	stalled bool
+}
+
+// Watchdog runs checks periodically. When a check starts failing it logs an error, reports the subsystem
+// as stalled in the watchdog_stalled metric and restarts it if it can be restarted. A stalled subsystem is
+// restarted again every time it is checked until it recovers.
+type Watchdog struct {
+	interval time.Duration
+	log      utils.SimpleLogger
+
+	mu      sync.Mutex
+	watched []*watched
+
+	// metrics
+	stalled  *prometheus.GaugeVec
+	restarts *prometheus.CounterVec
+}
+
+// New returns a Watchdog that runs its checks every interval
+func New(interval time.Duration, log utils.SimpleLogger) *Watchdog {
+	w := &Watchdog{
+		interval: interval,
+		log:      log,
+		stalled: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "watchdog",
+			Name:      "stalled",
+			Help:      "Whether a subsystem of the node is stalled",
+		}, []string{"subsystem"}),
+		restarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "watchdog",
+			Name:      "restarts",
+			Help:      "The number of times a stalled subsystem was restarted",
+		}, []string{"subsystem"}),
+	}
+	metrics.MustRegister(w.stalled, w.restarts)
+	return w
+}
+
+// Watch checks a subsystem, restart is called while the subsystem is stalled unless it is nil
+func (w *Watchdog) Watch(name string, check Check, restart func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watched = append(w.watched, &watched{name: name, check: check, restart: restart})
+	w.stalled.WithLabelValues(name).Set(0)
+}
+
+// Run checks the subsystems every interval until ctx is cancelled
+func (w *Watchdog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.CheckAll()
+		}
+	}
+}
+
+// CheckAll runs the checks of all the subsystems once
+func (w *Watchdog) CheckAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, subsystem := range w.watched {
+		err := subsystem.check()
+		if err == nil {
+			if subsystem.stalled {
+				w.log.Infow("Subsystem recovered", "subsystem", subsystem.name)
+				subsystem.stalled = false
+				w.stalled.WithLabelValues(subsystem.name).Set(0)
+			}
+			continue
+		}
+
+		if !subsystem.stalled {
+			subsystem.stalled = true
+			w.stalled.WithLabelValues(subsystem.name).Set(1)
+		}
+		w.log.Errorw("Subsystem is stalled", "subsystem", subsystem.name, "err", err)
+		if subsystem.restart != nil {
+			w.log.Warnw("Restarting stalled subsystem", "subsystem", subsystem.name)
+			w.restarts.WithLabelValues(subsystem.name).Inc()
+			subsystem.restart()
+		}
+	}
+}
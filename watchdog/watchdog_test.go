@@ -0,0 +1,122 @@
+package watchdog_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/watchdog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdog(t *testing.T) {
+	w := watchdog.New(time.Hour, utils.NewNopZapLogger())
+
+	var checkErr error
+	restarts := 0
+	w.Watch("test", func() error {
+		return checkErr
+	}, func() {
+		restarts++
+	})
+
+	w.CheckAll()
+	assert.Zero(t, restarts)
+
+	checkErr = errors.New("stalled")
+	w.CheckAll()
+	w.CheckAll()
+	assert.Equal(t, 2, restarts)
+
+	checkErr = nil
+	w.CheckAll()
+	assert.Equal(t, 2, restarts)
+
+	t.Run("stops once ctx is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, w.Run(ctx))
+	})
+}
+
+func TestHeadStall(t *testing.T) {
+	var head, networkHead uint64
+	var headErr error
+	networkKnown := true
+	check := watchdog.HeadStall(10*time.Millisecond, func() (uint64, error) {
+		return head, headErr
+	}, func() (uint64, bool) {
+		return networkHead, networkKnown
+	})
+
+	require.NoError(t, check())
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, check(), "the head is not behind the network")
+
+	networkHead = 2
+	require.Error(t, check())
+
+	networkKnown = false
+	require.NoError(t, check(), "the head of the network is unknown")
+	networkKnown = true
+
+	head = 1
+	require.NoError(t, check(), "the head changed")
+	time.Sleep(20 * time.Millisecond)
+	require.ErrorContains(t, check(), "head has been block 1")
+
+	t.Run("empty database", func(t *testing.T) {
+		headErr = errors.New("empty")
+		emptyCheck := watchdog.HeadStall(10*time.Millisecond, func() (uint64, error) {
+			return head, headErr
+		}, func() (uint64, bool) {
+			return networkHead, networkKnown
+		})
+		require.NoError(t, emptyCheck())
+		time.Sleep(20 * time.Millisecond)
+		require.ErrorContains(t, emptyCheck(), "no block stored")
+	})
+}
+
+func TestHeldFor(t *testing.T) {
+	var held time.Duration
+	check := watchdog.HeldFor(time.Minute, "lock", func() time.Duration {
+		return held
+	})
+	require.NoError(t, check())
+
+	held = time.Hour
+	require.ErrorContains(t, check(), "lock has been held for 1h0m0s")
+	require.NoError(t, watchdog.When(func() bool { return false }, check)())
+}
+
+type blockingService struct {
+	runs chan struct{}
+}
+
+func (s *blockingService) Run(ctx context.Context) error {
+	s.runs <- struct{}{}
+	<-ctx.Done()
+	return nil
+}
+
+func TestRestartable(t *testing.T) {
+	s := &blockingService{runs: make(chan struct{})}
+	r := watchdog.NewRestartable(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+
+	<-s.runs
+	r.Restart()
+	<-s.runs
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,597 @@
+// Package webhook notifies external systems of the chain by POSTing JSON notifications to the URLs set by the
+// operator: new blocks, blocks that became final on L1, the events that match a filter and the blocks reverted by
+// a reorg. Notifications are journaled in the database before they are sent and are only removed once an
+// endpoint accepted them, so each of them is delivered at least once, in order, even across restarts.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/NethermindEth/juno/abi"
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/encoder"
+	"github.com/NethermindEth/juno/metrics"
+	"github.com/NethermindEth/juno/service"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/conc/pool"
+)
+
+var _ service.Service = (*Dispatcher)(nil)
+
+const (
+	// pollInterval is how frequently the chain is checked for new notifications once a webhook caught up with it
+	pollInterval   = time.Second
+	requestTimeout = 30 * time.Second
+	// maxPending is the number of undelivered notifications of a webhook beyond which no more are journaled, the
+	// webhook resumes from where it stopped once its endpoint accepts them
+	maxPending = 1000
+	// batchSize is the maximum number of notifications sent in a request
+	batchSize = 100
+	// hashDepth is the number of recently notified blocks whose hashes are kept to find out if they are reverted
+	hashDepth = 128
+
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+)
+
+// The types of notifications
+const (
+	TypeBlock     = "block"
+	TypeFinalized = "finalized"
+	TypeEvent     = "event"
+	TypeReorg     = "reorg"
+)
+
+// Config is a webhook as set in the configuration file. Blocks, Finalized and Reorgs select the notifications
+// of new blocks, of blocks accepted on L1 and of reverted blocks, and the events that match any of Events are
+// notified. Headers are set on every request, to authenticate the node to the endpoint for example. Decode adds
+// the events decoded with the ABI of the class of their contract to their notifications, see Dispatcher.WithDecoder.
+type Config struct {
+	URL       string            `mapstructure:"url"`
+	Blocks    bool              `mapstructure:"blocks"`
+	Finalized bool              `mapstructure:"finalized"`
+	Reorgs    bool              `mapstructure:"reorgs"`
+	Events    []EventFilter     `mapstructure:"events"`
+	Headers   map[string]string `mapstructure:"headers"`
+	Decode    bool              `mapstructure:"decode"`
+}
+
+// EventFilter matches the events emitted by Address, or by any contract if it is empty, whose keys match Keys.
+// The i-th key of an event must be one of Keys[i], unless Keys[i] is empty.
+type EventFilter struct {
+	Address string     `mapstructure:"address"`
+	Keys    [][]string `mapstructure:"keys"`
+}
+
+// Notification is what is sent to the endpoints, in a JSON array of the notifications that are sent together.
+// ID increases with every notification of a webhook, so that endpoints can drop the notifications that are
+// delivered again.
+type Notification struct {
+	ID          uint64     `json:"id"`
+	Type        string     `json:"type"`
+	BlockNumber uint64     `json:"block_number"`
+	BlockHash   *felt.Felt `json:"block_hash"`
+	ParentHash  *felt.Felt `json:"parent_hash,omitempty"`
+	Timestamp   uint64     `json:"timestamp,omitempty"`
+	Event       *Event     `json:"event,omitempty"`
+}
+
+// Event is an event that matched the filters of a webhook, Decoded is set if the webhook decodes the events and
+// the ABI of the class of the contract that emitted the event has it
+type Event struct {
+	TransactionHash *felt.Felt   `json:"transaction_hash"`
+	FromAddress     *felt.Felt   `json:"from_address"`
+	Keys            []*felt.Felt `json:"keys"`
+	Data            []*felt.Felt `json:"data"`
+	Decoded         *abi.Decoded `json:"decoded,omitempty"`
+}
+
+type eventFilter struct {
+	address *felt.Felt
+	keys    []map[felt.Felt]struct{}
+}
+
+// hook is a webhook and the state of the delivery of its notifications
+type hook struct {
+	Config
+	events []eventFilter
+	// decoder decodes the events of the webhook, nil unless it sets Decode and the Dispatcher has a decoder
+	decoder *abi.Decoder
+	// prefix is the prefix of the keys of the webhook in the Webhooks bucket
+	prefix []byte
+
+	retryDelay time.Duration
+	retryAt    time.Time
+}
+
+// progress is how far the notifications of a webhook were journaled: Next is the next block to notify, Finalized
+// the next block to notify as final, ID the ID of the next notification and Pending the number of notifications
+// that are not delivered yet
+type progress struct {
+	Next      uint64
+	Finalized uint64
+	ID        uint64
+	Pending   uint64
+}
+
+// Dispatcher journals and delivers the notifications of a set of webhooks
+type Dispatcher struct {
+	database db.DB
+	chain    *blockchain.Blockchain
+	hooks    []*hook
+	client   *http.Client
+	log      utils.SimpleLogger
+
+	// metrics
+	deliveries *prometheus.CounterVec
+}
+
+// New returns a Dispatcher of the webhooks of configs, it fails if any of them is invalid
+func New(database db.DB, chain *blockchain.Blockchain, configs []Config, log utils.SimpleLogger) (*Dispatcher, error) {
+	d := &Dispatcher{
+		database: database,
+		chain:    chain,
+		client:   &http.Client{Timeout: requestTimeout},
+		log:      log,
+		deliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webhook",
+			Name:      "requests",
+			Help:      "The number of requests sent to webhooks, by whether they were accepted",
+		}, []string{"result"}),
+	}
+
+	urls := make(map[string]struct{}, len(configs))
+	for _, config := range configs {
+		if configURL, err := url.Parse(config.URL); err != nil || (configURL.Scheme != "http" && configURL.Scheme != "https") {
+			return nil, fmt.Errorf("webhook URL %q is not a http(s) URL", config.URL)
+		}
+		// the URL identifies the journal of a webhook
+		if _, ok := urls[config.URL]; ok {
+			return nil, fmt.Errorf("webhook URL %q is set twice", config.URL)
+		}
+		urls[config.URL] = struct{}{}
+
+		h := &hook{Config: config}
+		for _, filter := range config.Events {
+			parsed, err := parseEventFilter(filter)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %s: %w", config.URL, err)
+			}
+			h.events = append(h.events, parsed)
+		}
+		id := sha256.Sum256([]byte(config.URL))
+		h.prefix = db.Webhooks.Key(id[:8])
+		d.hooks = append(d.hooks, h)
+	}
+
+	metrics.MustRegister(d.deliveries)
+	return d, nil
+}
+
+// WithDecoder decodes the events of the webhooks that set Decode with decoder
+func (d *Dispatcher) WithDecoder(decoder *abi.Decoder) *Dispatcher {
+	for _, h := range d.hooks {
+		if h.Decode {
+			h.decoder = decoder
+		}
+	}
+	return d
+}
+
+func parseEventFilter(filter EventFilter) (eventFilter, error) {
+	var parsed eventFilter
+	if filter.Address != "" {
+		address, err := new(felt.Felt).SetString(filter.Address)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid event address %q: %w", filter.Address, err)
+		}
+		parsed.address = address
+	}
+	for _, keys := range filter.Keys {
+		set := make(map[felt.Felt]struct{}, len(keys))
+		for _, key := range keys {
+			keyFelt, err := new(felt.Felt).SetString(key)
+			if err != nil {
+				return parsed, fmt.Errorf("invalid event key %q: %w", key, err)
+			}
+			set[*keyFelt] = struct{}{}
+		}
+		parsed.keys = append(parsed.keys, set)
+	}
+	return parsed, nil
+}
+
+func (f *eventFilter) matches(event *core.Event) bool {
+	if f.address != nil && !f.address.Equal(event.From) {
+		return false
+	}
+	for i, set := range f.keys {
+		if len(set) == 0 {
+			continue
+		}
+		if i >= len(event.Keys) {
+			return false
+		}
+		if _, ok := set[*event.Keys[i]]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Run journals and delivers the notifications of every webhook until ctx is cancelled. A webhook that is
+// enabled for the first time is notified from the block after the head.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	workers := pool.New().WithContext(ctx).WithCancelOnError()
+	for _, h := range d.hooks {
+		h := h
+		d.log.Infow("Sending notifications to webhook", "url", h.URL)
+		workers.Go(func(ctx context.Context) error {
+			return d.run(ctx, h)
+		})
+	}
+	return workers.Wait()
+}
+
+func (d *Dispatcher) run(ctx context.Context, h *hook) error {
+	for {
+		journaled, err := d.journal(h)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", h.URL, err)
+		}
+		delivered, err := d.deliver(ctx, h)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", h.URL, err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if journaled || delivered {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// journal journals the notifications of the next block of a webhook, or of the last block it was notified of
+// if it was reverted. It returns whether it journaled anything.
+func (d *Dispatcher) journal(h *hook) (bool, error) {
+	height, err := d.chain.Height()
+	if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+		return false, err
+	}
+	headExists := err == nil
+	var l1Head *core.L1Head
+	if h.Finalized {
+		if l1Head, err = d.chain.L1Head(); err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+			return false, err
+		}
+	}
+
+	var journaled bool
+	return journaled, d.database.Update(func(txn db.Transaction) error {
+		p, err := h.progress(txn)
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return h.start(txn, height, headExists)
+		} else if err != nil {
+			return err
+		}
+		if p.Pending >= maxPending {
+			return nil
+		}
+
+		if journaled, err = h.revert(txn, p); err != nil || journaled {
+			return err
+		}
+		if journaled, err = h.next(txn, p); err != nil || journaled {
+			return err
+		}
+		journaled, err = h.finalize(txn, p, l1Head)
+		return err
+	})
+}
+
+// start records that a webhook is notified from the block after the head
+func (h *hook) start(txn db.Transaction, height uint64, headExists bool) error {
+	if !headExists {
+		return h.setProgress(txn, &progress{})
+	}
+	header, err := blockchain.BlockHeaderByNumber(txn, height)
+	if err != nil {
+		return err
+	}
+	if err = txn.Set(h.hashKey(height), header.Hash.Marshal()); err != nil {
+		return err
+	}
+	return h.setProgress(txn, &progress{Next: height + 1, Finalized: height + 1})
+}
+
+// revert notifies the last block a webhook was notified of if it was reverted
+func (h *hook) revert(txn db.Transaction, p *progress) (bool, error) {
+	if p.Next == 0 {
+		return false, nil
+	}
+	number := p.Next - 1
+	var hash *felt.Felt
+	err := txn.Get(h.hashKey(number), func(val []byte) error {
+		hash = new(felt.Felt).SetBytes(val)
+		return nil
+	})
+	if errors.Is(err, db.ErrKeyNotFound) {
+		// the reorg is deeper than the hashes that are kept
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	header, err := blockchain.BlockHeaderByNumber(txn, number)
+	if err == nil && header.Hash.Equal(hash) {
+		return false, nil
+	} else if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
+		return false, err
+	}
+
+	if h.Reorgs {
+		if err = h.add(txn, p, &Notification{Type: TypeReorg, BlockNumber: number, BlockHash: hash}); err != nil {
+			return false, err
+		}
+	}
+	if err = txn.Delete(h.hashKey(number)); err != nil {
+		return false, err
+	}
+	p.Next = number
+	if p.Finalized > p.Next {
+		p.Finalized = p.Next
+	}
+	return true, h.setProgress(txn, p)
+}
+
+// next notifies the next block and its events
+func (h *hook) next(txn db.Transaction, p *progress) (bool, error) {
+	block, err := blockchain.BlockByNumber(txn, p.Next)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if h.Blocks {
+		if err = h.add(txn, p, &Notification{
+			Type:        TypeBlock,
+			BlockNumber: block.Number,
+			BlockHash:   block.Hash,
+			ParentHash:  block.ParentHash,
+			Timestamp:   block.Timestamp,
+		}); err != nil {
+			return false, err
+		}
+	}
+	for _, receipt := range block.Receipts {
+		for _, event := range receipt.Events {
+			if !h.matches(event) {
+				continue
+			}
+			notified := &Event{
+				TransactionHash: receipt.TransactionHash,
+				FromAddress:     event.From,
+				Keys:            event.Keys,
+				Data:            event.Data,
+			}
+			if h.decoder != nil {
+				if notified.Decoded, err = h.decoder.DecodeEvent(event, block.Number); err != nil {
+					return false, err
+				}
+			}
+			if err = h.add(txn, p, &Notification{
+				Type:        TypeEvent,
+				BlockNumber: block.Number,
+				BlockHash:   block.Hash,
+				Event:       notified,
+			}); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err = txn.Set(h.hashKey(block.Number), block.Hash.Marshal()); err != nil {
+		return false, err
+	}
+	if block.Number >= hashDepth {
+		if err = txn.Delete(h.hashKey(block.Number - hashDepth)); err != nil {
+			return false, err
+		}
+	}
+	p.Next++
+	return true, h.setProgress(txn, p)
+}
+
+// finalize notifies the next block that was accepted on L1
+func (h *hook) finalize(txn db.Transaction, p *progress, l1Head *core.L1Head) (bool, error) {
+	if !h.Finalized || l1Head == nil || p.Finalized >= p.Next || p.Finalized > l1Head.BlockNumber {
+		return false, nil
+	}
+	header, err := blockchain.BlockHeaderByNumber(txn, p.Finalized)
+	if err != nil {
+		return false, err
+	}
+	if err = h.add(txn, p, &Notification{
+		Type:        TypeFinalized,
+		BlockNumber: header.Number,
+		BlockHash:   header.Hash,
+		ParentHash:  header.ParentHash,
+		Timestamp:   header.Timestamp,
+	}); err != nil {
+		return false, err
+	}
+	p.Finalized++
+	return true, h.setProgress(txn, p)
+}
+
+func (h *hook) matches(event *core.Event) bool {
+	for i := range h.events {
+		if h.events[i].matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// add journals a notification
+func (h *hook) add(txn db.Transaction, p *progress, notification *Notification) error {
+	notification.ID = p.ID
+	notificationBytes, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	if err = txn.Set(h.journalKey(p.ID), notificationBytes); err != nil {
+		return err
+	}
+	p.ID++
+	p.Pending++
+	return nil
+}
+
+// deliver sends the oldest undelivered notifications of a webhook, unless a previous attempt failed too
+// recently. It returns whether the endpoint accepted them.
+func (d *Dispatcher) deliver(ctx context.Context, h *hook) (bool, error) {
+	if time.Now().Before(h.retryAt) {
+		return false, nil
+	}
+
+	var (
+		keys          [][]byte
+		notifications []json.RawMessage
+	)
+	if err := d.database.View(func(txn db.Transaction) error {
+		it, err := txn.NewIterator()
+		if err != nil {
+			return err
+		}
+		prefix := h.journalKey()
+		for it.Seek(prefix); it.Valid() && len(keys) < batchSize && bytes.HasPrefix(it.Key(), prefix); it.Next() {
+			value, valueErr := it.Value()
+			if valueErr != nil {
+				return errors.Join(valueErr, it.Close())
+			}
+			keys = append(keys, append([]byte(nil), it.Key()...))
+			notifications = append(notifications, append(json.RawMessage(nil), value...))
+		}
+		return it.Close()
+	}); err != nil {
+		return false, err
+	}
+	if len(notifications) == 0 {
+		return false, nil
+	}
+
+	if err := d.send(ctx, h, notifications); err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		d.deliveries.WithLabelValues("failed").Inc()
+		h.retryDelay *= 2
+		if h.retryDelay < minRetryDelay {
+			h.retryDelay = minRetryDelay
+		} else if h.retryDelay > maxRetryDelay {
+			h.retryDelay = maxRetryDelay
+		}
+		h.retryAt = time.Now().Add(h.retryDelay)
+		d.log.Warnw("Failed to send notifications to webhook", "url", h.URL, "err", err, "retryIn", h.retryDelay)
+		return false, nil
+	}
+	d.deliveries.WithLabelValues("accepted").Inc()
+	h.retryDelay = 0
+
+	return true, d.database.Update(func(txn db.Transaction) error {
+		for _, key := range keys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		p, err := h.progress(txn)
+		if err != nil {
+			return err
+		}
+		p.Pending -= uint64(len(keys))
+		return h.setProgress(txn, p)
+	})
+}
+
+func (d *Dispatcher) send(ctx context.Context, h *hook, notifications []json.RawMessage) error {
+	body, err := json.Marshal(notifications)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// The keys of a webhook are its prefix followed by the kind of the key
+const (
+	progressKind byte = iota
+	hashKind
+	journalKind
+)
+
+func (h *hook) progress(txn db.Transaction) (*progress, error) {
+	p := new(progress)
+	return p, txn.Get(append(bytes.Clone(h.prefix), progressKind), func(val []byte) error {
+		return encoder.Unmarshal(val, p)
+	})
+}
+
+func (h *hook) setProgress(txn db.Transaction, p *progress) error {
+	progressBytes, err := encoder.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return txn.Set(append(bytes.Clone(h.prefix), progressKind), progressBytes)
+}
+
+// hashKey is the key of the hash of a block a webhook was notified of
+func (h *hook) hashKey(number uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(bytes.Clone(h.prefix), hashKind), number)
+}
+
+// journalKey is the key of the notification with id, or the prefix of the journal if id is not given
+func (h *hook) journalKey(id ...uint64) []byte {
+	key := append(bytes.Clone(h.prefix), journalKind)
+	for _, i := range id {
+		key = binary.BigEndian.AppendUint64(key, i)
+	}
+	return key
+}
@@ -0,0 +1,240 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/blockchain"
+	"github.com/NethermindEth/juno/clients/feeder"
+	"github.com/NethermindEth/juno/core"
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/db"
+	"github.com/NethermindEth/juno/db/pebble"
+	adaptfeeder "github.com/NethermindEth/juno/starknetdata/feeder"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/juno/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpoint records the notifications it accepts, and rejects the requests while failing is set
+type endpoint struct {
+	mu            sync.Mutex
+	failing       bool
+	rejected      int
+	notifications []webhook.Notification
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.failing || r.Header.Get("Authorization") != "Bearer secret" {
+		e.rejected++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var notifications []webhook.Notification
+	if err := json.NewDecoder(r.Body).Decode(&notifications); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.notifications = append(e.notifications, notifications...)
+}
+
+func (e *endpoint) received() []webhook.Notification {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]webhook.Notification(nil), e.notifications...)
+}
+
+func TestDispatcher(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+	gw := adaptfeeder.New(feeder.NewTestClient(t, utils.GOERLI2))
+
+	blocks := make([]*core.Block, 6)
+	stateUpdates := make([]*core.StateUpdate, len(blocks))
+	for i := range blocks {
+		var err error
+		blocks[i], err = gw.BlockByNumber(context.Background(), uint64(i))
+		require.NoError(t, err)
+		stateUpdates[i], err = gw.StateUpdate(context.Background(), uint64(i))
+		require.NoError(t, err)
+	}
+	store := func(i int) {
+		require.NoError(t, chain.Store(blocks[i], &core.BlockCommitments{}, stateUpdates[i], nil))
+	}
+	for i := 0; i < 3; i++ {
+		store(i)
+	}
+
+	// the events of the blocks the webhook is notified of that are emitted by address
+	var (
+		address       *felt.Felt
+		eventsByBlock = make(map[uint64]int)
+	)
+	for _, block := range blocks[3:] {
+		for _, receipt := range block.Receipts {
+			for _, event := range receipt.Events {
+				if address == nil {
+					address = event.From
+				}
+				if event.From.Equal(address) {
+					eventsByBlock[block.Number]++
+				}
+			}
+		}
+	}
+	require.NotNil(t, address, "the blocks have no events")
+
+	e := &endpoint{failing: true}
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	config := webhook.Config{
+		URL:       srv.URL,
+		Blocks:    true,
+		Finalized: true,
+		Reorgs:    true,
+		Events:    []webhook.EventFilter{{Address: address.String()}},
+		Headers:   map[string]string{"Authorization": "Bearer secret"},
+	}
+
+	start := func() (stop func()) {
+		dispatcher, err := webhook.New(testDB, chain, []webhook.Config{config}, utils.NewNopZapLogger())
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- dispatcher.Run(ctx)
+		}()
+		return func() {
+			cancel()
+			require.NoError(t, <-done)
+		}
+	}
+	// await waits for the endpoint to have accepted n notifications
+	await := func(n int) []webhook.Notification {
+		require.Eventually(t, func() bool {
+			return len(e.received()) >= n
+		}, 10*time.Second, 10*time.Millisecond)
+		received := e.received()
+		require.Len(t, received, n)
+		return received
+	}
+
+	stop := start()
+	// blocks that were stored before the webhook was enabled are not notified, it is enabled once its progress
+	// is recorded
+	require.Eventually(t, func() bool {
+		var started bool
+		require.NoError(t, testDB.View(func(txn db.Transaction) error {
+			it, err := txn.NewIterator()
+			if err != nil {
+				return err
+			}
+			started = it.Seek(db.Webhooks.Key()) && it.Key()[0] == byte(db.Webhooks)
+			return it.Close()
+		}))
+		return started
+	}, 10*time.Second, 10*time.Millisecond)
+	store(3)
+	store(4)
+
+	// notifications are retried until the endpoint accepts them
+	require.Eventually(t, func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.rejected > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	e.mu.Lock()
+	e.failing = false
+	e.mu.Unlock()
+
+	expected := 2 + eventsByBlock[3] + eventsByBlock[4]
+	received := await(expected)
+	for i, notification := range received {
+		assert.Equal(t, uint64(i), notification.ID)
+	}
+	assert.Equal(t, webhook.TypeBlock, received[0].Type)
+	assert.Equal(t, uint64(3), received[0].BlockNumber)
+	assert.Equal(t, blocks[3].Hash, received[0].BlockHash)
+	for _, notification := range received[1 : 1+eventsByBlock[3]] {
+		assert.Equal(t, webhook.TypeEvent, notification.Type)
+		assert.Equal(t, uint64(3), notification.BlockNumber)
+		assert.Equal(t, address, notification.Event.FromAddress)
+	}
+	assert.Equal(t, webhook.TypeBlock, received[1+eventsByBlock[3]].Type)
+	assert.Equal(t, uint64(4), received[1+eventsByBlock[3]].BlockNumber)
+
+	t.Run("reorg", func(t *testing.T) {
+		require.NoError(t, chain.RevertHead())
+		received := await(expected + 1)
+		reorg := received[expected]
+		assert.Equal(t, webhook.TypeReorg, reorg.Type)
+		assert.Equal(t, uint64(4), reorg.BlockNumber)
+		assert.Equal(t, blocks[4].Hash, reorg.BlockHash)
+		expected++
+
+		store(4)
+		expected += 1 + eventsByBlock[4]
+		received = await(expected)
+		assert.Equal(t, webhook.TypeBlock, received[expected-1-eventsByBlock[4]].Type)
+		assert.Equal(t, uint64(4), received[expected-1-eventsByBlock[4]].BlockNumber)
+	})
+
+	t.Run("finalized", func(t *testing.T) {
+		require.NoError(t, chain.SetL1Head(&core.L1Head{BlockNumber: 3, BlockHash: blocks[3].Hash}))
+		received := await(expected + 1)
+		finalized := received[expected]
+		assert.Equal(t, webhook.TypeFinalized, finalized.Type)
+		assert.Equal(t, uint64(3), finalized.BlockNumber)
+		assert.Equal(t, blocks[3].Hash, finalized.BlockHash)
+		expected++
+	})
+
+	t.Run("journal survives restarts", func(t *testing.T) {
+		stop()
+		// the block is journaled by the next run, and nothing that was delivered is sent again
+		store(5)
+		stop = start()
+		expected += 1 + eventsByBlock[5]
+		received := await(expected)
+		for i, notification := range received {
+			assert.Equal(t, uint64(i), notification.ID)
+		}
+		assert.Equal(t, uint64(5), received[expected-1-eventsByBlock[5]].BlockNumber)
+	})
+	stop()
+}
+
+func TestNew(t *testing.T) {
+	testDB := pebble.NewMemTest()
+	t.Cleanup(func() {
+		require.NoError(t, testDB.Close())
+	})
+	chain := blockchain.New(testDB, utils.GOERLI2, utils.NewNopZapLogger())
+
+	for name, configs := range map[string][]webhook.Config{
+		"not a http url": {{URL: "ftp://example.com"}},
+		"set twice":      {{URL: "https://example.com"}, {URL: "https://example.com"}},
+		"invalid address": {{URL: "https://example.com", Events: []webhook.EventFilter{
+			{Address: "not a felt"},
+		}}},
+		"invalid key": {{URL: "https://example.com", Events: []webhook.EventFilter{
+			{Keys: [][]string{{"not a felt"}}},
+		}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := webhook.New(testDB, chain, configs, utils.NewNopZapLogger())
+			require.Error(t, err)
+		})
+	}
+}